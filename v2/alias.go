@@ -0,0 +1,94 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Alias is an organization-specific identifier and display name for a
+// license of the corpus, such as "ACME-PERMISSIVE-1" and "ACME approved MIT"
+// for MIT. Aliases only change how licenses are presented: matching, policies
+// and the Name of matches keep using the canonical identifiers.
+type Alias struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// ParseAliases parses a JSON object mapping canonical license names to their
+// aliases:
+//
+//	{
+//	  "MIT": {"id": "ACME-PERMISSIVE-1", "name": "ACME approved MIT"},
+//	  "Apache-2.0": {"id": "ACME-PERMISSIVE-2"}
+//	}
+func ParseAliases(b []byte) (map[string]Alias, error) {
+	var aliases map[string]Alias
+	if err := json.Unmarshal(b, &aliases); err != nil {
+		return nil, fmt.Errorf("classifier couldn't parse aliases: %w", err)
+	}
+	for _, name := range sortedKeys(aliases) {
+		if a := aliases[name]; a.ID == "" && a.Name == "" {
+			return nil, fmt.Errorf("classifier couldn't parse aliases: alias of %s has neither an id nor a name", name)
+		}
+	}
+	return aliases, nil
+}
+
+// SetAliases installs organization-specific aliases of licenses, keyed by
+// their canonical names such as "MIT". Matches of aliased licenses carry the
+// alias, and the licenses of the LicenseDB of the classifier can be looked up
+// by their alias IDs. Aliases of licenses missing from the corpus are kept,
+// so they apply if the license is added later. A nil map removes all aliases.
+func (c *Classifier) SetAliases(aliases map[string]Alias) {
+	defer c.update()()
+	c.aliases = make(map[string]Alias, len(aliases))
+	for name, a := range aliases {
+		c.aliases[name] = a
+	}
+}
+
+// applyAliases sets the aliases of the matches, which are owned by the
+// caller. The caller must hold the read lock.
+func (c *Classifier) applyAliases(ms Matches) {
+	for _, m := range ms {
+		m.Alias = c.aliases[m.Name]
+	}
+}
+
+// DisplayName returns the name the match should be presented with: the name
+// of its alias, or else its alias ID, or else the canonical name.
+func (m *Match) DisplayName() string {
+	switch {
+	case m.Alias.Name != "":
+		return m.Alias.Name
+	case m.Alias.ID != "":
+		return m.Alias.ID
+	}
+	return m.Name
+}
+
+// sortedKeys returns the keys of the aliases in order, for deterministic
+// error reporting.
+func sortedKeys(aliases map[string]Alias) []string {
+	var out []string
+	for k := range aliases {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseAliases(t *testing.T) {
+	got, err := ParseAliases([]byte(`{"MIT": {"id": "ACME-1", "name": "ACME MIT"}, "ISC": {"id": "ACME-2"}}`))
+	if err != nil {
+		t.Fatalf("ParseAliases() failed: %v", err)
+	}
+	want := map[string]Alias{"MIT": {ID: "ACME-1", Name: "ACME MIT"}, "ISC": {ID: "ACME-2"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ParseAliases() mismatch (-want +got):\n%s", diff)
+	}
+	for _, in := range []string{`{"MIT": {}}`, `["MIT"]`, `{`} {
+		if _, err := ParseAliases([]byte(in)); err == nil {
+			t.Errorf("ParseAliases(%s) succeeded", in)
+		}
+	}
+}
+
+func TestAliases(t *testing.T) {
+	mit := []byte("Permission is hereby granted, free of charge, to any person obtaining a copy")
+	c, err := New(
+		WithCorpusContent("MIT", mit),
+		WithCorpusContent("ISC", []byte("Permission to use, copy, modify, and/or distribute this software for any purpose")),
+		WithAliases(map[string]Alias{"MIT": {ID: "ACME-1", Name: "ACME MIT"}, "GPL-2.0": {ID: "ACME-9"}}),
+		WithCache(NewLRUCache(4)),
+	)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	m := c.Match(mit)
+	if len(m) != 1 || m[0].Name != "MIT" || m[0].Alias.ID != "ACME-1" || m[0].DisplayName() != "ACME MIT" {
+		t.Fatalf("Match() = %+v, want MIT aliased as ACME-1", m)
+	}
+
+	// Aliases apply to cached results, which don't keep them.
+	c.SetAliases(map[string]Alias{"MIT": {ID: "ACME-2"}})
+	if m := c.Match(mit); m[0].Alias != (Alias{ID: "ACME-2"}) || m[0].DisplayName() != "ACME-2" {
+		t.Errorf("Match() after changing the aliases = %+v, want the new alias", m[0])
+	}
+	c.SetAliases(nil)
+	if m := c.Match(mit); m[0].Alias != (Alias{}) || m[0].DisplayName() != "MIT" {
+		t.Errorf("Match() after removing the aliases = %+v, want no alias", m[0])
+	}
+
+	c.SetAliases(map[string]Alias{"MIT": {ID: "ACME-1"}, "ISC": {ID: "MIT"}})
+	db := c.LicenseDB()
+	if l, ok := db.Lookup("ACME-1"); !ok || l.ID != "MIT" || l.Alias.ID != "ACME-1" {
+		t.Errorf("Lookup(ACME-1) = %+v, %v; want MIT", l, ok)
+	}
+	// Canonical IDs take precedence over alias IDs.
+	if l, _ := db.Lookup("MIT"); l.ID != "MIT" {
+		t.Errorf("Lookup(MIT) = %+v, want MIT", l)
+	}
+	if got, want := db.IDs(), []string{"ISC", "MIT"}; !cmp.Equal(got, want) {
+		t.Errorf("IDs() = %v, want %v", got, want)
+	}
+}
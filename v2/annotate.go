@@ -0,0 +1,462 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// SpanKind describes how a span of annotated input relates to the license
+// text it was matched against.
+type SpanKind int
+
+const (
+	// SpanEqual is input text that agrees with the license text.
+	SpanEqual SpanKind = iota
+	// SpanDeleted is input text that isn't part of the license text.
+	SpanDeleted
+	// SpanInserted is license text that is missing from the input.
+	SpanInserted
+)
+
+var spanKindNames = []string{
+	SpanEqual:    "equal",
+	SpanDeleted:  "deleted",
+	SpanInserted: "inserted",
+}
+
+func (k SpanKind) String() string {
+	if k >= 0 && int(k) < len(spanKindNames) {
+		return spanKindNames[k]
+	}
+	return "unknown"
+}
+
+// MarshalText encodes the kind as its name.
+func (k SpanKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText decodes a kind from its name.
+func (k *SpanKind) UnmarshalText(text []byte) error {
+	for i, n := range spanKindNames {
+		if n == string(text) {
+			*k = SpanKind(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown span kind %q", text)
+}
+
+// Span is a region of the input annotated with the diff of a match.
+type Span struct {
+	Kind SpanKind
+	// Start and End are the byte offsets of the span in the input. Inserted
+	// spans have no extent and occur at Start.
+	Start, End int
+	// Text is the input text of the span or, for inserted spans, the
+	// normalized license text missing from the input.
+	Text string
+}
+
+// Annotate maps the diff of the explanation onto the original bytes of the
+// input, returning the spans that cover the matched text in order. The
+// normalized words of the diff are located in the input by aligning them
+// with the words of each line, so the spans hold the text as it was written,
+// including punctuation and formatting the classifier ignores.
+func (e *Explanation) Annotate() []Span {
+	if e.tokens == nil {
+		// References aren't diffed, so the whole text is reported as
+		// matching.
+		return []Span{{Kind: SpanEqual, End: len(e.MatchedText), Text: e.MatchedText}}
+	}
+
+	offsets := tokenOffsets(e.source, e.tokens)
+	kinds, inserts, last := e.diffTokens()
+	if e.first >= last {
+		return nil
+	}
+
+	var spans []Span
+	add := func(k SpanKind, start, end int) {
+		if end <= start {
+			return
+		}
+		if n := len(spans); n > 0 && spans[n-1].Kind == k && k != SpanInserted && spans[n-1].End == start {
+			spans[n-1].End = end
+			spans[n-1].Text = string(e.source[spans[n-1].Start:end])
+			return
+		}
+		spans = append(spans, Span{Kind: k, Start: start, End: end, Text: string(e.source[start:end])})
+	}
+	insert := func(at, token int) {
+		for _, text := range inserts[token] {
+			spans = append(spans, Span{Kind: SpanInserted, Start: at, End: at, Text: text})
+		}
+	}
+
+	cur := offsets[e.first][0]
+	for i := e.first; i < last; i++ {
+		start, end := offsets[i][0], offsets[i][1]
+		if start < cur {
+			start = cur
+		}
+		if end < start {
+			end = start
+		}
+		if i > e.first {
+			// Text between two deleted words is deleted with them.
+			gap := SpanEqual
+			if kinds[i-1] == SpanDeleted && kinds[i] == SpanDeleted {
+				gap = SpanDeleted
+			}
+			insert(cur, i)
+			add(gap, cur, start)
+		} else {
+			insert(start, i)
+		}
+		add(kinds[i], start, end)
+		if end > cur {
+			cur = end
+		}
+	}
+	insert(cur, last)
+	return spans
+}
+
+// diffTokens walks the diff of the explanation over the tokens of the input,
+// returning the kind of the tokens that differ from the license, the license
+// text missing before each token index, and the index following the last
+// token of the diff.
+func (e *Explanation) diffTokens() (kinds map[int]SpanKind, inserts map[int][]string, last int) {
+	kinds = make(map[int]SpanKind)
+	inserts = make(map[int][]string)
+	t := e.first
+	for _, d := range e.Diffs {
+		n := wordLen(d.Text)
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			inserts[t] = append(inserts[t], d.Text)
+		case diffmatchpatch.DiffDelete:
+			for i := 0; i < n; i++ {
+				kinds[t+i] = SpanDeleted
+			}
+			t += n
+		default:
+			t += n
+		}
+	}
+	if t > len(e.tokens) {
+		t = len(e.tokens)
+	}
+	return kinds, inserts, t
+}
+
+// TokenAnnotation is a word of the input annotated with the diff of a match,
+// or a word of the license text missing from the input.
+type TokenAnnotation struct {
+	Kind SpanKind
+	// Start and End are the byte offsets of the word in the input. Inserted
+	// words have no extent and occur at Start.
+	Start, End int
+	// Text is the word as written in the input or, for inserted words, the
+	// normalized license word missing from the input.
+	Text string
+	// Agreement is the fraction of the words around this one, within
+	// agreementWindow words on either side, that agree with the license
+	// text, so that a UI can shade the matched text as a heatmap of where
+	// it departs from the license.
+	Agreement float64
+}
+
+// agreementWindow is the number of words on either side of a word that its
+// agreement is computed over.
+const agreementWindow = 5
+
+// AnnotateTokens maps the diff of the explanation onto the words of the
+// input, returning each word of the matched text, and each license word
+// missing from it, in order. Unlike the spans of Annotate, the words are
+// reported one by one, without the text between them. Explanations of
+// matches that aren't diffed, such as references, have no token
+// annotations.
+func (e *Explanation) AnnotateTokens() []TokenAnnotation {
+	if e.tokens == nil {
+		return nil
+	}
+	offsets := tokenOffsets(e.source, e.tokens)
+	kinds, inserts, last := e.diffTokens()
+	if e.first >= last {
+		return nil
+	}
+
+	var out []TokenAnnotation
+	insert := func(at, token int) {
+		for _, text := range inserts[token] {
+			for _, w := range strings.Fields(text) {
+				out = append(out, TokenAnnotation{Kind: SpanInserted, Start: at, End: at, Text: w})
+			}
+		}
+	}
+	cur := offsets[e.first][0]
+	for i := e.first; i < last; i++ {
+		start, end := offsets[i][0], offsets[i][1]
+		if start < cur {
+			start = cur
+		}
+		if end < start {
+			end = start
+		}
+		if i > e.first {
+			insert(cur, i)
+		} else {
+			insert(start, i)
+		}
+		out = append(out, TokenAnnotation{Kind: kinds[i], Start: start, End: end, Text: string(e.source[start:end])})
+		if end > cur {
+			cur = end
+		}
+	}
+	insert(cur, last)
+
+	equal := make([]int, len(out)+1)
+	for i, t := range out {
+		equal[i+1] = equal[i]
+		if t.Kind == SpanEqual {
+			equal[i+1]++
+		}
+	}
+	for i := range out {
+		lo, hi := i-agreementWindow, i+agreementWindow+1
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > len(out) {
+			hi = len(out)
+		}
+		out[i].Agreement = float64(equal[hi]-equal[lo]) / float64(hi-lo)
+	}
+	return out
+}
+
+// HTML renders the annotated input as HTML, marking text missing from the
+// license with <del> and license text missing from the input with <ins>.
+func (e *Explanation) HTML() string {
+	var out strings.Builder
+	for _, s := range e.Annotate() {
+		text := html.EscapeString(s.Text)
+		switch s.Kind {
+		case SpanDeleted:
+			out.WriteString("<del>" + text + "</del>")
+		case SpanInserted:
+			out.WriteString("<ins>" + text + "</ins>")
+		default:
+			out.WriteString(text)
+		}
+	}
+	return out.String()
+}
+
+// ANSI escape sequences used by Explanation.ANSI.
+const (
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiReset = "\x1b[0m"
+)
+
+// ANSI renders the annotated input for a terminal, showing text missing from
+// the license in red and license text missing from the input in green.
+// Inserted text is bracketed with {+ and +} so it can be told apart from the
+// input without color.
+func (e *Explanation) ANSI() string {
+	var out strings.Builder
+	for _, s := range e.Annotate() {
+		switch s.Kind {
+		case SpanDeleted:
+			out.WriteString(ansiRed + s.Text + ansiReset)
+		case SpanInserted:
+			out.WriteString(ansiGreen + "{+" + s.Text + "+}" + ansiReset)
+		default:
+			out.WriteString(s.Text)
+		}
+	}
+	return out.String()
+}
+
+// Text renders the annotated input as plain text, bracketing text missing
+// from the license with [- and -] and license text missing from the input
+// with {+ and +}, as wdiff does.
+func (e *Explanation) Text() string {
+	var out strings.Builder
+	for _, s := range e.Annotate() {
+		switch s.Kind {
+		case SpanDeleted:
+			out.WriteString("[-" + s.Text + "-]")
+		case SpanInserted:
+			out.WriteString("{+" + s.Text + "+}")
+		default:
+			out.WriteString(s.Text)
+		}
+	}
+	return out.String()
+}
+
+// sourceWord is a whitespace-delimited word of the input.
+type sourceWord struct {
+	start, end int
+	norm       string
+}
+
+// normalizeWord approximates the token the tokenizer produces for a single
+// word of the input.
+func normalizeWord(w string) string {
+	w = strings.ToLower(normalizeUnicode(html.UnescapeString(w)))
+	w = normalizePunctuation(w)
+	w = strings.TrimLeftFunc(w, func(r rune) bool { return !isSignificant(r) })
+	if w == "" {
+		return ""
+	}
+	return cleanupToken(w)
+}
+
+// sourceWords splits the input into words, grouped by line.
+func sourceWords(in []byte) [][]sourceWord {
+	lines := [][]sourceWord{nil}
+	start := -1
+	flush := func(end int) {
+		if start != -1 {
+			w := string(in[start:end])
+			lines[len(lines)-1] = append(lines[len(lines)-1], sourceWord{start: start, end: end, norm: normalizeWord(w)})
+			start = -1
+		}
+	}
+	for i := 0; i < len(in); {
+		r, size := utf8.DecodeRune(in[i:])
+		switch {
+		case r == '\n':
+			flush(i)
+			lines = append(lines, nil)
+		case unicode.IsSpace(r):
+			flush(i)
+		case isIdeographic(r):
+			// Ideographic characters are tokens of their own.
+			flush(i)
+			start = i
+			flush(i + size)
+		default:
+			if start == -1 {
+				start = i
+			}
+		}
+		i += size
+	}
+	flush(len(in))
+	return lines
+}
+
+// tokenOffsets returns the byte offsets in the input of each of its tokens.
+// The tokens of each line are aligned with the words of that line by diffing
+// them. Tokens that have no identical word, because normalization rewrote
+// them, are attributed to the unaligned words between their neighbors.
+func tokenOffsets(in []byte, tokens []*token) [][2]int {
+	offsets := make([][2]int, len(tokens))
+	lines := sourceWords(in)
+	dmp := diffmatchpatch.New()
+	for i := 0; i < len(tokens); {
+		line := tokens[i].Line
+		j := i
+		for j < len(tokens) && tokens[j].Line == line {
+			j++
+		}
+		var words []sourceWord
+		if line-1 < len(lines) {
+			words = lines[line-1]
+		}
+		alignLine(dmp, tokens[i:j], words, offsets[i:j])
+		i = j
+	}
+	return offsets
+}
+
+// alignLine computes the offsets of the tokens of a line from its words.
+func alignLine(dmp *diffmatchpatch.DiffMatchPatch, tokens []*token, words []sourceWord, offsets [][2]int) {
+	if len(words) == 0 {
+		return
+	}
+	ids := make(map[string]rune)
+	id := func(s string) rune {
+		r, ok := ids[s]
+		if !ok {
+			r = rune(len(ids) + 1)
+			ids[s] = r
+		}
+		return r
+	}
+	tr := make([]rune, len(tokens))
+	for i, t := range tokens {
+		tr[i] = id(t.Text)
+	}
+	wr := make([]rune, len(words))
+	for i, w := range words {
+		wr[i] = id(w.norm)
+	}
+
+	ti, wi := 0, 0
+	var pendingTokens []int
+	pendingStart, pendingEnd := -1, -1
+	resolve := func() {
+		for _, t := range pendingTokens {
+			switch {
+			case pendingStart != -1:
+				offsets[t] = [2]int{pendingStart, pendingEnd}
+			case wi > 0:
+				offsets[t] = [2]int{words[wi-1].start, words[wi-1].end}
+			case wi < len(words):
+				offsets[t] = [2]int{words[wi].start, words[wi].end}
+			}
+		}
+		pendingTokens = nil
+		pendingStart, pendingEnd = -1, -1
+	}
+	for _, d := range dmp.DiffMainRunes(tr, wr, false) {
+		n := utf8.RuneCountInString(d.Text)
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			for k := 0; k < n; k++ {
+				pendingTokens = append(pendingTokens, ti)
+				ti++
+			}
+		case diffmatchpatch.DiffInsert:
+			if pendingStart == -1 {
+				pendingStart = words[wi].start
+			}
+			pendingEnd = words[wi+n-1].end
+			wi += n
+		default:
+			resolve()
+			for k := 0; k < n; k++ {
+				offsets[ti] = [2]int{words[wi].start, words[wi].end}
+				ti++
+				wi++
+			}
+		}
+	}
+	resolve()
+}
@@ -0,0 +1,205 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAnnotate(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(baseLicenses, "MIT.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read license: %v", err)
+	}
+
+	// Reformat the license as a source comment, drop a word and add a few.
+	text := strings.Replace(string(b), "free of charge", "entirely free of charge", 1)
+	text = strings.Replace(text, "merge, publish", "publish", 1)
+	text = strings.Replace(text, `"AS IS"`, "&quot;AS IS&quot;", 1)
+	in := []byte("// Copyright 2020 Someone\n//\n// " + strings.ReplaceAll(strings.TrimSpace(text), "\n", "\n// ") + "\npackage main\n")
+
+	m := c.Match(in)
+	if len(m) != 1 || m[0].Name != "MIT" {
+		t.Fatalf("Match() = %v, want a single MIT match", m)
+	}
+	e, err := c.Explain(in, m[0])
+	if err != nil {
+		t.Fatalf("Explain() failed: %v", err)
+	}
+
+	spans := e.Annotate()
+	if len(spans) == 0 {
+		t.Fatal("Annotate() returned no spans")
+	}
+	var deleted, inserted []string
+	var covered strings.Builder
+	for i, s := range spans {
+		if s.Kind != SpanInserted && s.Text != string(in[s.Start:s.End]) {
+			t.Errorf("span %d text = %q, want input bytes %q", i, s.Text, in[s.Start:s.End])
+		}
+		if i > 0 && s.Start != spans[i-1].End {
+			t.Errorf("span %d starts at %d, want %d", i, s.Start, spans[i-1].End)
+		}
+		switch s.Kind {
+		case SpanDeleted:
+			deleted = append(deleted, s.Text)
+		case SpanInserted:
+			inserted = append(inserted, s.Text)
+		default:
+			covered.WriteString(s.Text)
+		}
+	}
+	if got, want := strings.Join(deleted, "|"), "entirely"; got != want {
+		t.Errorf("deleted spans = %q, want %q", got, want)
+	}
+	if got, want := strings.Join(inserted, "|"), "merge"; got != want {
+		t.Errorf("inserted spans = %q, want %q", got, want)
+	}
+	if !strings.HasPrefix(covered.String(), "Permission is hereby granted") || !strings.HasSuffix(covered.String(), "SOFTWARE.") {
+		t.Errorf("matched text = %q, want the license text", covered.String())
+	}
+	if !strings.Contains(covered.String(), "\n// ") {
+		t.Errorf("matched text = %q, want the original comment formatting", covered.String())
+	}
+	if !strings.Contains(covered.String(), "&quot;AS IS&quot;") {
+		t.Errorf("matched text = %q, want the original entities", covered.String())
+	}
+
+	h := e.HTML()
+	for _, want := range []string{"<del>entirely</del>", "<ins>merge</ins>", "&amp;quot;AS IS&amp;quot;"} {
+		if !strings.Contains(h, want) {
+			t.Errorf("HTML() = %q, want it to contain %q", h, want)
+		}
+	}
+	a := e.ANSI()
+	for _, want := range []string{ansiRed + "entirely" + ansiReset, ansiGreen + "{+merge+}" + ansiReset} {
+		if !strings.Contains(a, want) {
+			t.Errorf("ANSI() = %q, want it to contain %q", a, want)
+		}
+	}
+	p := e.Text()
+	for _, want := range []string{"[-entirely-]", "{+merge+}"} {
+		if !strings.Contains(p, want) {
+			t.Errorf("Text() = %q, want it to contain %q", p, want)
+		}
+	}
+}
+
+func TestTokenOffsets(t *testing.T) {
+	in := []byte("Licence: (the) \"Soft-\nware\" and www.example.com")
+	doc := tokenize(in)
+	offsets := tokenOffsets(in, doc.Tokens)
+	var got []string
+	for _, o := range offsets {
+		got = append(got, string(in[o[0]:o[1]]))
+	}
+	want := []string{"Licence:", "(the)", "\"Soft-", "and", "www.example.com"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("tokenOffsets() = %q, want %q", got, want)
+	}
+}
+
+func TestAnnotateTokens(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(baseLicenses, "MIT.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read license: %v", err)
+	}
+	text := strings.Replace(string(b), "free of charge", "entirely free of charge", 1)
+	text = strings.Replace(text, "merge, publish", "publish", 1)
+	in := []byte("# Copyright 2020 Someone\n\n" + text)
+
+	m := c.Match(in)
+	if len(m) != 1 || m[0].Name != "MIT" {
+		t.Fatalf("Match() = %v, want a single MIT match", m)
+	}
+	e, err := c.Explain(in, m[0])
+	if err != nil {
+		t.Fatalf("Explain() failed: %v", err)
+	}
+
+	tokens := e.AnnotateTokens()
+	var deleted, inserted []string
+	var words []string
+	for i, tok := range tokens {
+		if i > 0 && tok.Start < tokens[i-1].End {
+			t.Errorf("token %d starts at %d, before the end of the previous one at %d", i, tok.Start, tokens[i-1].End)
+		}
+		if tok.Agreement <= 0 || tok.Agreement > 1 {
+			t.Errorf("token %d %q has agreement %v, want it in (0, 1]", i, tok.Text, tok.Agreement)
+		}
+		switch tok.Kind {
+		case SpanDeleted:
+			deleted = append(deleted, tok.Text)
+		case SpanInserted:
+			inserted = append(inserted, tok.Text)
+			if tok.Start != tok.End {
+				t.Errorf("inserted token %q spans %d-%d, want no extent", tok.Text, tok.Start, tok.End)
+			}
+		default:
+			if tok.Text != string(in[tok.Start:tok.End]) {
+				t.Errorf("token %d text = %q, want input bytes %q", i, tok.Text, in[tok.Start:tok.End])
+			}
+			words = append(words, tok.Text)
+		}
+	}
+	if got, want := strings.Join(deleted, "|"), "entirely"; got != want {
+		t.Errorf("deleted tokens = %q, want %q", got, want)
+	}
+	if got, want := strings.Join(inserted, "|"), "merge"; got != want {
+		t.Errorf("inserted tokens = %q, want %q", got, want)
+	}
+	if got := strings.Join(words[:3], " "); got != "Permission is hereby" {
+		t.Errorf("first tokens = %q, want the start of the license", got)
+	}
+	if n := len(tokens); n == 0 || tokens[n-1].Agreement != 1 {
+		t.Errorf("last token agreement = %v, want 1 far from the changes", tokens[n-1].Agreement)
+	}
+
+	ref := []byte("SPDX-License-Identifier: MIT")
+	m = c.Match(ref)
+	if len(m) != 1 {
+		t.Fatalf("Match(reference) = %v, want a single match", m)
+	}
+	if e, err = c.Explain(ref, m[0]); err != nil {
+		t.Fatalf("Explain(reference) failed: %v", err)
+	}
+	if got := e.AnnotateTokens(); got != nil {
+		t.Errorf("AnnotateTokens() of a reference = %v, want none", got)
+	}
+}
+
+func TestSpanKindText(t *testing.T) {
+	for _, k := range []SpanKind{SpanEqual, SpanDeleted, SpanInserted} {
+		text, err := k.MarshalText()
+		if err != nil {
+			t.Fatalf("MarshalText(%v) failed: %v", k, err)
+		}
+		var got SpanKind
+		if err := got.UnmarshalText(text); err != nil || got != k {
+			t.Errorf("UnmarshalText(%s) = %v, %v, want %v", text, got, err, k)
+		}
+	}
+}
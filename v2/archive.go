@@ -0,0 +1,191 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+// maxArchiveEntrySize bounds the content read from a single archive entry,
+// guarding against entries that decompress to huge sizes.
+const maxArchiveEntrySize = 16 << 20
+
+// maxArchiveSize bounds the content decompressed from an archive as a whole,
+// and maxArchiveEntries the number of its entries, guarding against archives
+// of many entries that each stay within maxArchiveEntrySize.
+var (
+	maxArchiveSize    int64 = 256 << 20
+	maxArchiveEntries       = 100000
+)
+
+// archiveLimit counts the entries of an archive and the content decompressed
+// from it against maxArchiveEntries and maxArchiveSize.
+type archiveLimit struct {
+	entries int
+	size    int64
+}
+
+// entry counts an entry, failing if there are too many.
+func (l *archiveLimit) entry() error {
+	if l.entries++; l.entries > maxArchiveEntries {
+		return &Error{Kind: ErrDocumentTooLarge, Context: fmt.Sprintf("archive holds more than %d entries", maxArchiveEntries)}
+	}
+	return nil
+}
+
+// reader returns a reader of decompressed content, which fails once more
+// than maxArchiveSize bytes have been read through the readers of l.
+func (l *archiveLimit) reader(r io.Reader) io.Reader {
+	return &archiveReader{r: r, l: l}
+}
+
+type archiveReader struct {
+	r io.Reader
+	l *archiveLimit
+}
+
+func (a *archiveReader) Read(p []byte) (int, error) {
+	n, err := a.r.Read(p)
+	if a.l.size += int64(n); a.l.size > maxArchiveSize {
+		return n, &Error{Kind: ErrDocumentTooLarge, Context: fmt.Sprintf("archive holds more than %d bytes", maxArchiveSize)}
+	}
+	return n, err
+}
+
+// ArchiveResult holds the matches found in an entry of an archive.
+type ArchiveResult struct {
+	// Name is the path of the entry within the archive.
+	Name    string
+	Matches Matches
+}
+
+// licenseFilePrefixes are the lower-cased prefixes of the names of files that
+// conventionally hold license texts.
+var licenseFilePrefixes = []string{
+	"copying",
+	"copyright",
+	"licence",
+	"license",
+	"notice",
+	"patents",
+	"unlicense",
+}
+
+// LikelyLicenseFile returns true if the base name of the supplied path is
+// conventionally used for files holding license texts, such as LICENSE,
+// COPYING.txt, LICENSE-MIT or NOTICE.md.
+func LikelyLicenseFile(name string) bool {
+	base := strings.ToLower(path.Base(strings.ReplaceAll(name, "\\", "/")))
+	if strings.HasSuffix(base, ".license") {
+		return true
+	}
+	for _, p := range licenseFilePrefixes {
+		if strings.HasPrefix(base, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchArchive finds matches in the likely license files of a zip archive,
+// such as a Go module zip, without extracting it. Results are returned in the
+// order of the entries in the archive; entries without matches are omitted.
+// Archives of too many entries, or too much content, fail with
+// ErrDocumentTooLarge.
+func (c *Classifier) MatchArchive(r io.ReaderAt, size int64) ([]*ArchiveResult, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("classifier couldn't read zip archive: %w", err)
+	}
+	var l archiveLimit
+	var out []*ArchiveResult
+	for _, f := range zr.File {
+		if err := l.entry(); err != nil {
+			return nil, err
+		}
+		if f.FileInfo().IsDir() || !LikelyLicenseFile(f.Name) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("classifier couldn't open %s: %w", f.Name, err)
+		}
+		b, err := ioutil.ReadAll(io.LimitReader(l.reader(rc), maxArchiveEntrySize))
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("classifier couldn't read %s: %w", f.Name, err)
+		}
+		if m := c.Match(b); len(m) > 0 {
+			out = append(out, &ArchiveResult{Name: f.Name, Matches: m})
+		}
+	}
+	return out, nil
+}
+
+// MatchTar finds matches in the likely license files of a tar stream, which
+// may be gzip-compressed as source distributions usually are. Results are
+// returned in the order of the entries in the stream; entries without matches
+// are omitted. Streams of too many entries, or too much content, fail with
+// ErrDocumentTooLarge.
+func (c *Classifier) MatchTar(r io.Reader) ([]*ArchiveResult, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("classifier couldn't read gzip stream: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	// The content of every entry is decompressed, if only to skip it, so
+	// all of it counts against the limit.
+	var l archiveLimit
+	var out []*ArchiveResult
+	tr := tar.NewReader(l.reader(r))
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("classifier couldn't read tar archive: %w", err)
+		}
+		if err := l.entry(); err != nil {
+			return nil, err
+		}
+		if h.Typeflag != tar.TypeReg || !LikelyLicenseFile(h.Name) {
+			continue
+		}
+		b, err := ioutil.ReadAll(io.LimitReader(tr, maxArchiveEntrySize))
+		if err != nil {
+			return nil, fmt.Errorf("classifier couldn't read %s: %w", h.Name, err)
+		}
+		if m := c.Match(b); len(m) > 0 {
+			out = append(out, &ArchiveResult{Name: h.Name, Matches: m})
+		}
+	}
+}
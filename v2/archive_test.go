@@ -0,0 +1,209 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+type archiveEntry struct {
+	name    string
+	content []byte
+}
+
+func archiveEntries(t *testing.T) []archiveEntry {
+	t.Helper()
+	read := func(name string) []byte {
+		b, err := ioutil.ReadFile(filepath.Join(baseLicenses, name))
+		if err != nil {
+			t.Fatalf("couldn't read license: %v", err)
+		}
+		return b
+	}
+	return []archiveEntry{
+		{name: "example.com/mod@v1.0.0/LICENSE", content: read("MIT.txt")},
+		{name: "example.com/mod@v1.0.0/main.go", content: read("BSD-3-Clause.txt")},
+		{name: "example.com/mod@v1.0.0/third_party/lib/COPYING.txt", content: read("BSD-3-Clause.txt")},
+		{name: "example.com/mod@v1.0.0/NOTICE", content: []byte("This product includes software developed at Example.\n")},
+	}
+}
+
+var wantArchive = map[string]string{
+	"example.com/mod@v1.0.0/LICENSE":                     "MIT",
+	"example.com/mod@v1.0.0/third_party/lib/COPYING.txt": "BSD-3-Clause",
+}
+
+func checkArchiveResults(t *testing.T, got []*ArchiveResult) {
+	t.Helper()
+	if len(got) != len(wantArchive) {
+		t.Fatalf("got %d results, want %d", len(got), len(wantArchive))
+	}
+	for _, r := range got {
+		if len(r.Matches) != 1 || r.Matches[0].Name != wantArchive[r.Name] {
+			t.Errorf("%s: got %v, want %s", r.Name, r.Matches, wantArchive[r.Name])
+		}
+	}
+}
+
+func TestMatchArchive(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	var b bytes.Buffer
+	w := zip.NewWriter(&b)
+	for _, e := range archiveEntries(t) {
+		f, err := w.Create(e.name)
+		if err != nil {
+			t.Fatalf("couldn't create zip entry: %v", err)
+		}
+		f.Write(e.content)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("couldn't write zip: %v", err)
+	}
+
+	got, err := c.MatchArchive(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatalf("MatchArchive() failed: %v", err)
+	}
+	checkArchiveResults(t, got)
+
+	if _, err := c.MatchArchive(bytes.NewReader([]byte("not a zip")), 9); err == nil {
+		t.Error("MatchArchive(not a zip) succeeded, want error")
+	}
+}
+
+func TestMatchTar(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	var b bytes.Buffer
+	w := tar.NewWriter(&b)
+	w.WriteHeader(&tar.Header{Name: "example.com/mod@v1.0.0/", Typeflag: tar.TypeDir, Mode: 0755})
+	for _, e := range archiveEntries(t) {
+		w.WriteHeader(&tar.Header{Name: e.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(e.content))})
+		w.Write(e.content)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("couldn't write tar: %v", err)
+	}
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(b.Bytes())
+	zw.Close()
+
+	for name, data := range map[string][]byte{"tar": b.Bytes(), "tar.gz": gz.Bytes()} {
+		t.Run(name, func(t *testing.T) {
+			got, err := c.MatchTar(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("MatchTar() failed: %v", err)
+			}
+			checkArchiveResults(t, got)
+		})
+	}
+}
+
+func TestArchiveLimits(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	defer func(size int64, entries int) {
+		maxArchiveSize, maxArchiveEntries = size, entries
+	}(maxArchiveSize, maxArchiveEntries)
+
+	// Many entries, each well within maxArchiveEntrySize.
+	content := bytes.Repeat([]byte("0123456789abcdef"), 1024)
+	var zb, tb bytes.Buffer
+	zw := zip.NewWriter(&zb)
+	tw := tar.NewWriter(&tb)
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("mod/LICENSE.%d", i)
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write(content)
+		tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))})
+		tw.Write(content)
+	}
+	zw.Close()
+	tw.Close()
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write(tb.Bytes())
+	w.Close()
+
+	match := map[string]func() error{
+		"zip": func() error {
+			_, err := c.MatchArchive(bytes.NewReader(zb.Bytes()), int64(zb.Len()))
+			return err
+		},
+		"tar.gz": func() error {
+			_, err := c.MatchTar(bytes.NewReader(gz.Bytes()))
+			return err
+		},
+	}
+	for _, tt := range []struct {
+		desc    string
+		size    int64
+		entries int
+		wantErr bool
+	}{
+		{"within the limits", 1 << 20, 20, false},
+		{"too much content", 10 * int64(len(content)), 20, true},
+		{"too many entries", 1 << 20, 10, true},
+	} {
+		maxArchiveSize, maxArchiveEntries = tt.size, tt.entries
+		for name, f := range match {
+			err := f()
+			if got := errors.Is(err, ErrDocumentTooLarge); got != tt.wantErr {
+				t.Errorf("%s, %s: got error %v, want ErrDocumentTooLarge: %t", tt.desc, name, err, tt.wantErr)
+			}
+		}
+	}
+}
+
+func TestLikelyLicenseFile(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "LICENSE", want: true},
+		{name: "pkg/LICENSE-MIT", want: true},
+		{name: "COPYING.LESSER", want: true},
+		{name: `vendor\lib\Licence.md`, want: true},
+		{name: "README.md", want: false},
+		{name: "reuse/LICENSES/MIT.txt", want: false},
+		{name: "src/main.go.license", want: true},
+		{name: "license/main.go", want: false},
+	}
+	for _, test := range tests {
+		if got := LikelyLicenseFile(test.name); got != test.want {
+			t.Errorf("LikelyLicenseFile(%q) = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
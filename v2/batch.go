@@ -0,0 +1,120 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"crypto/sha256"
+	"sync"
+)
+
+// Document is a named input to MatchBatch.
+type Document struct {
+	Name    string
+	Content []byte
+}
+
+// BatchResult holds the matches found in a Document by MatchBatch.
+type BatchResult struct {
+	Name    string
+	Matches Matches
+	// DuplicateOf is the name of the first document of the batch with the
+	// same content, whose classification was reused, or empty if the
+	// document was classified itself.
+	DuplicateOf string
+}
+
+// MatchBatch finds the matches of each of the supplied documents, returning
+// their results in the same order. Documents with identical content are only
+// classified once, which saves most of the work of scanning trees holding
+// many copies of the same license files, and the distinct contents are
+// classified concurrently, using the parallelism of the classifier. Each
+// result has its own copy of the matches.
+func (c *Classifier) MatchBatch(docs []Document) []*BatchResult {
+	out := make([]*BatchResult, len(docs))
+	// first maps the hash of each distinct content to the index of the first
+	// document holding it, and source each document to that index.
+	first := make(map[[sha256.Size]byte]int)
+	source := make([]int, len(docs))
+	var unique []int
+	for i, d := range docs {
+		out[i] = &BatchResult{Name: d.Name}
+		h := sha256.Sum256(d.Content)
+		j, ok := first[h]
+		if !ok {
+			j = i
+			first[h] = i
+			unique = append(unique, i)
+		}
+		source[i] = j
+		if j != i {
+			out[i].DuplicateOf = docs[j].Name
+		}
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < c.workers() && w < len(unique); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				out[i].Matches = c.Match(docs[i].Content)
+			}
+		}()
+	}
+	for _, i := range unique {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	for i, j := range source {
+		if i != j {
+			out[i].Matches = copyMatches(out[j].Matches)
+		}
+	}
+	return out
+}
+
+// copyMatches returns a deep copy of ms.
+func copyMatches(ms Matches) Matches {
+	if ms == nil {
+		return nil
+	}
+	out := make(Matches, len(ms))
+	for i, m := range ms {
+		cp := *m
+		cp.Variants = append([]VariantScore(nil), m.Variants...)
+		cp.Alternatives = append([]Alternative(nil), m.Alternatives...)
+		cp.Choice = append([]string(nil), m.Choice...)
+		cp.Phrases = append([]string(nil), m.Phrases...)
+		if m.Portion != nil {
+			p := *m.Portion
+			cp.Portion = &p
+		}
+		if m.Metadata != nil {
+			md := *m.Metadata
+			cp.Metadata = &md
+		}
+		if v := m.VersionConflict; v != nil {
+			cp.VersionConflict = &VersionConflict{
+				Versions: append([]Alternative(nil), v.Versions...),
+				Phrases:  append([]string(nil), v.Phrases...),
+			}
+		}
+		out[i] = &cp
+	}
+	return out
+}
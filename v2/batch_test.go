@@ -0,0 +1,68 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMatchBatch(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	mit, err := ioutil.ReadFile(filepath.Join(baseLicenses, "MIT.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	isc, err := ioutil.ReadFile(filepath.Join(baseLicenses, "ISC.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := []Document{
+		{Name: "a/LICENSE", Content: mit},
+		{Name: "b/LICENSE", Content: isc},
+		{Name: "c/LICENSE", Content: append([]byte(nil), mit...)},
+		{Name: "empty", Content: nil},
+		{Name: "d/LICENSE", Content: mit},
+	}
+	got := c.MatchBatch(docs)
+	if len(got) != len(docs) {
+		t.Fatalf("MatchBatch() returned %d results, want %d", len(got), len(docs))
+	}
+
+	wantDup := []string{"", "", "a/LICENSE", "", "a/LICENSE"}
+	for i, r := range got {
+		if r.Name != docs[i].Name || r.DuplicateOf != wantDup[i] {
+			t.Errorf("result %d = %s, duplicate of %q; want %s, duplicate of %q", i, r.Name, r.DuplicateOf, docs[i].Name, wantDup[i])
+		}
+		if diff := cmp.Diff(c.Match(docs[i].Content), r.Matches); diff != "" {
+			t.Errorf("result %d matches differ from Match() (-want +got):\n%s", i, diff)
+		}
+	}
+
+	// Duplicates have their own copies of the matches.
+	got[2].Matches[0].Name = "changed"
+	if got[0].Matches[0].Name != "MIT" || got[4].Matches[0].Name != "MIT" {
+		t.Error("modifying the matches of a duplicate modified those of another document")
+	}
+	if len(c.MatchBatch(nil)) != 0 {
+		t.Error("MatchBatch(nil) returned results")
+	}
+}
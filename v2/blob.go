@@ -0,0 +1,146 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"strings"
+)
+
+// This file contains routines to classify license text embedded in binary
+// content such as firmware images, in the manner of strings(1).
+
+// DefaultMinStringLength is the default minimum length of a run of printable
+// characters extracted from binary content.
+const DefaultMinStringLength = 4
+
+// BlobMatch is a match found in binary content, along with the byte offsets of
+// the matched text within that content.
+type BlobMatch struct {
+	*Match
+	// StartOffset and EndOffset delimit the bytes of the content holding the
+	// lines covered by the match.
+	StartOffset, EndOffset int
+}
+
+// stringRun is a run of printable characters in binary content.
+type stringRun struct {
+	offset int
+	text   string
+}
+
+// isPrintable returns true for the ASCII bytes kept by string extraction.
+// Line breaks and tabs are retained so that license text embedded in a binary
+// is extracted as a single run with its original layout.
+func isPrintable(b byte) bool {
+	return (b >= 0x20 && b < 0x7f) || b == '\t' || b == '\n' || b == '\r'
+}
+
+// extractStrings returns the runs of at least minLen printable characters in
+// the supplied content.
+func extractStrings(in []byte, minLen int) []stringRun {
+	if minLen < 1 {
+		minLen = DefaultMinStringLength
+	}
+	var out []stringRun
+	start := -1
+	for i := 0; i <= len(in); i++ {
+		if i < len(in) && isPrintable(in[i]) {
+			if start == -1 {
+				start = i
+			}
+			continue
+		}
+		if start != -1 && i-start >= minLen {
+			out = append(out, stringRun{offset: start, text: string(in[start:i])})
+		}
+		start = -1
+	}
+	return out
+}
+
+// binarySniffLen is the length of the start of content inspected by
+// LooksBinary.
+const binarySniffLen = 8000
+
+// LooksBinary returns true if the start of the content contains a NUL byte,
+// which doesn't occur in text files.
+func LooksBinary(in []byte) bool {
+	if len(in) > binarySniffLen {
+		in = in[:binarySniffLen]
+	}
+	return bytes.IndexByte(in, 0) != -1
+}
+
+// SetBinaryStrings configures the classifier to classify the runs of at least
+// minLen printable characters of content that LooksBinary, rather than its
+// raw bytes, so that Match finds license text embedded in compiled binaries
+// and firmware images. Unlike MatchBlob, the runs are kept on the lines they
+// occupy in the content, so the line numbers of matches refer to it. A minLen
+// of zero or less disables extraction, which is the default.
+func (c *Classifier) SetBinaryStrings(minLen int) {
+	defer c.update()()
+	if minLen < 0 {
+		minLen = 0
+	}
+	c.minStrings = minLen
+}
+
+// binaryText returns the runs of at least minLen printable characters of the
+// supplied content separated by spaces, keeping the line breaks between them.
+func binaryText(in []byte, minLen int) []byte {
+	var out bytes.Buffer
+	end := 0
+	for _, r := range extractStrings(in, minLen) {
+		out.Write(bytes.Repeat([]byte("\n"), bytes.Count(in[end:r.offset], []byte("\n"))))
+		out.WriteByte(' ')
+		out.WriteString(r.text)
+		end = r.offset + len(r.text)
+	}
+	out.Write(bytes.Repeat([]byte("\n"), bytes.Count(in[end:], []byte("\n"))))
+	return out.Bytes()
+}
+
+// MatchBlob finds matches within binary content by classifying the runs of at
+// least minLen printable characters it contains. A minLen of zero or less uses
+// DefaultMinStringLength. The offsets of each match refer to the supplied
+// content.
+func (c *Classifier) MatchBlob(in []byte, minLen int) []*BlobMatch {
+	// The runs are classified as a single document with each line of a run
+	// on its own line, recording where each line starts in the blob so the
+	// line numbers of matches can be mapped back to byte offsets.
+	var text strings.Builder
+	var lineStarts, lineEnds []int
+	for _, r := range extractStrings(in, minLen) {
+		offset := r.offset
+		for _, l := range strings.Split(r.text, "\n") {
+			lineStarts = append(lineStarts, offset)
+			lineEnds = append(lineEnds, offset+len(l))
+			offset += len(l) + 1
+			text.WriteString(l)
+			text.WriteString("\n")
+		}
+	}
+
+	var out []*BlobMatch
+	for _, m := range c.Match([]byte(text.String())) {
+		out = append(out, &BlobMatch{
+			Match:       m,
+			StartOffset: lineStarts[m.StartLine-1],
+			EndOffset:   lineEnds[m.EndLine-1],
+		})
+	}
+	return out
+}
@@ -0,0 +1,133 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExtractStrings(t *testing.T) {
+	in := []byte("\x00\x01abc\x00defg\xff\xfehello\nworld\x00")
+	tests := []struct {
+		name     string
+		minLen   int
+		expected []stringRun
+	}{
+		{
+			name:   "default length",
+			minLen: 0,
+			expected: []stringRun{
+				{offset: 6, text: "defg"},
+				{offset: 12, text: "hello\nworld"},
+			},
+		},
+		{
+			name:   "short runs",
+			minLen: 3,
+			expected: []stringRun{
+				{offset: 2, text: "abc"},
+				{offset: 6, text: "defg"},
+				{offset: 12, text: "hello\nworld"},
+			},
+		},
+		{
+			name:   "long runs",
+			minLen: 8,
+			expected: []stringRun{
+				{offset: 12, text: "hello\nworld"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := extractStrings(in, test.minLen)
+			if !cmp.Equal(got, test.expected, cmp.AllowUnexported(stringRun{})) {
+				t.Errorf("extractStrings() = %v, want %v", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestMatchBlob(t *testing.T) {
+	c := NewClassifier(.8)
+	lic := "Permission is granted to use, copy and modify this firmware\nfor any purpose, provided this notice is retained in all copies."
+	c.AddContent("Firmware-1.0", []byte(lic))
+
+	var blob bytes.Buffer
+	blob.Write([]byte{0x7f, 'E', 'L', 'F', 0x02, 0x01, 0x00, 0x00})
+	blob.Write(bytes.Repeat([]byte{0x00, 0x90}, 64))
+	blob.WriteString("init")
+	blob.WriteByte(0)
+	start := blob.Len()
+	blob.WriteString(lic)
+	end := blob.Len()
+	blob.Write([]byte{0x00, 0xde, 0xad, 0xbe, 0xef})
+
+	m := c.MatchBlob(blob.Bytes(), 0)
+	if len(m) != 1 {
+		t.Fatalf("MatchBlob() = %d matches, want 1", len(m))
+	}
+	if m[0].Name != "Firmware-1.0" {
+		t.Errorf("Name = %q, want Firmware-1.0", m[0].Name)
+	}
+	if m[0].StartOffset != start || m[0].EndOffset != end {
+		t.Errorf("offsets = [%d, %d), want [%d, %d)", m[0].StartOffset, m[0].EndOffset, start, end)
+	}
+}
+
+func TestBinaryText(t *testing.T) {
+	in := []byte("\x00ab\x00hello\n\x01\nworld\x00\xff\n")
+	want := " hello\n \nworld\n"
+	if got := string(binaryText(in, 4)); got != want {
+		t.Errorf("binaryText() = %q, want %q", got, want)
+	}
+}
+
+func TestBinaryStrings(t *testing.T) {
+	lic := "Permission is granted to use, copy and modify this firmware\nfor any purpose, provided this notice is retained in all copies."
+	c, err := New(WithCorpusContent("Firmware-1.0", []byte(lic)))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	var blob bytes.Buffer
+	blob.Write([]byte{0x7f, 'E', 'L', 'F', 0x02, 0x01, 0x00, 0x00})
+	blob.Write(bytes.Repeat([]byte{0x00, 0x90, '\n', 'x'}, 64))
+	blob.WriteString("init")
+	blob.WriteByte(0)
+	blob.WriteString(lic)
+	blob.Write([]byte{0x00, 0xde, 0xad, 0xbe, 0xef})
+	if !LooksBinary(blob.Bytes()) {
+		t.Fatal("LooksBinary() = false, want true")
+	}
+
+	c.SetBinaryStrings(DefaultMinStringLength)
+	m := c.Match(blob.Bytes())
+	if len(m) != 1 || m[0].Name != "Firmware-1.0" {
+		t.Fatalf("Match() = %v, want a Firmware-1.0 match", m)
+	}
+	// The lines of the match are the lines of the license in the blob.
+	if m[0].StartLine != 65 || m[0].EndLine != 66 {
+		t.Errorf("Match() lines = %d-%d, want 65-66", m[0].StartLine, m[0].EndLine)
+	}
+
+	// Text isn't affected.
+	if m := c.Match([]byte(lic)); len(m) != 1 || m[0].EndLine != 2 {
+		t.Errorf("Match(text) = %v, want a match of lines 1-2", m)
+	}
+}
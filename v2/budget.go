@@ -0,0 +1,145 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import "time"
+
+// Budget limits the work spent scoring the potential matches of a corpus
+// entry. Scoring diffs the text of each potential match against the entry,
+// which dominates the cost of matching. License headers are short and common
+// in source trees, so they produce many potential matches that rarely change
+// the outcome; limiting them saves time on large scans.
+type Budget struct {
+	// MaxCandidates is the number of potential matches of an entry that are
+	// scored, preferring those sharing the most text with the entry. Zero
+	// means no limit.
+	MaxCandidates int
+	// MaxDiffSize is the length, in tokens, of the longest span of the input
+	// that is diffed against an entry. Longer potential matches aren't
+	// scored. Zero means no limit.
+	MaxDiffSize int
+}
+
+// SetScoringBudget sets the budget for scoring the corpus entries with the
+// supplied match type: "License", "Header" or "Exception". The zero Budget,
+// which is the default, places no limits.
+func (c *Classifier) SetScoringBudget(matchType string, b Budget) {
+	defer c.update()()
+	if b == (Budget{}) {
+		delete(c.budgets, matchType)
+		return
+	}
+	c.budgets[matchType] = b
+}
+
+// budgeted returns the potential matches of the named corpus entry that fit
+// the budget of its match type.
+func (c *Classifier) budgeted(name string, matches matchRanges) matchRanges {
+	b, ok := c.budgets[detectionType(name)]
+	if !ok {
+		return matches
+	}
+	var out matchRanges
+	for _, m := range matches {
+		if b.MaxCandidates > 0 && len(out) == b.MaxCandidates {
+			break
+		}
+		if b.MaxDiffSize > 0 && m.TargetEnd-m.TargetStart > b.MaxDiffSize {
+			continue
+		}
+		out = append(out, m)
+	}
+	if len(out) < len(matches) {
+		c.log(PhaseScore, LevelInfo, "scoring budget skipped candidates", "license", name, "skipped", len(matches)-len(out), "candidates", len(matches))
+	}
+	return out
+}
+
+// SetTimeBudget limits the time spent matching a single document to about
+// d. Once a document runs out of time, its remaining potential matches are
+// no longer diffed: their confidence is estimated from the share of the
+// distinct tokens of the corpus entry found in them, and the matches they
+// produce are marked Approximate. Diffs under way when the time runs out are
+// cut short, so a pathological document can't hold up a scan. Zero, the
+// default, places no limit. Matches that include approximate ones aren't
+// cached.
+func (c *Classifier) SetTimeBudget(d time.Duration) {
+	defer c.update()()
+	c.timeBudget = d
+}
+
+// deadline returns the time by which a document started now must be
+// matched, or the zero time if there is no time budget.
+func (c *Classifier) deadline() time.Time {
+	if c.timeBudget <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(c.timeBudget)
+}
+
+// expired reports whether the time budget of a target has run out.
+func (d *indexedDocument) expired() bool {
+	return !d.deadline.IsZero() && time.Now().After(d.deadline)
+}
+
+// approximate returns the approximate match of the known corpus entry in the
+// tokens of the target from start to end, if the entry's distinct tokens
+// contained in them reach the threshold, or nil.
+func (c *Classifier) approximate(name string, id, known *indexedDocument, start, end int) *Match {
+	if len(known.distinct) == 0 || end <= start {
+		return nil
+	}
+	present := make(map[tokenID]bool, end-start)
+	for _, t := range id.Tokens[start:end] {
+		present[t.ID] = true
+	}
+	hits := 0
+	for _, t := range known.distinct {
+		if present[t] {
+			hits++
+		}
+	}
+	conf := float64(hits) / float64(len(known.distinct))
+	if conf < c.threshold || !c.acceptShort(name, id, known, start, end, conf) {
+		return nil
+	}
+	if id.metrics != nil {
+		id.metrics.Approximate++
+	}
+	c.log(PhaseScore, LevelInfo, "time budget exhausted, approximated candidate", "license", name, "confidence", conf)
+	return &Match{
+		Name:            LicenseName(name),
+		MatchType:       detectionType(name),
+		Category:        LicenseCategory(LicenseName(name)),
+		Variant:         name,
+		Language:        VariantLanguage(name),
+		Confidence:      c.calibrate(name, known, conf),
+		StartLine:       int(id.Tokens[start].Line),
+		EndLine:         int(id.Tokens[end-1].Line),
+		StartTokenIndex: int(id.Tokens[start].Index),
+		EndTokenIndex:   int(id.Tokens[end-1].Index),
+		Approximate:     true,
+	}
+}
+
+// approximated reports whether any of the matches is approximate.
+func approximated(ms Matches) bool {
+	for _, m := range ms {
+		if m.Approximate {
+			return true
+		}
+	}
+	return false
+}
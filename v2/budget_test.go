@@ -0,0 +1,127 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestScoringBudget(t *testing.T) {
+	header := "Copyright the authors. Licensed under the Example License, which permits use and modification of this file as long as this notice is kept."
+	license := "The Example License. Permission is granted to use, copy, modify and distribute this software and its documentation for any purpose, provided that this notice is kept in all copies of the software."
+	in := strings.Join([]string{header, "func a() {}", header, "func b() {}", header, license}, "\n\n")
+
+	count := func(c *Classifier, matchType string) int {
+		n := 0
+		for _, m := range c.Match([]byte(in)) {
+			if m.MatchType == matchType {
+				n++
+			}
+		}
+		return n
+	}
+	newClassifier := func() *Classifier {
+		c := NewClassifier(defaultThreshold)
+		c.AddContent("Example.header", []byte(header))
+		c.AddContent("Example", []byte(license))
+		return c
+	}
+
+	c := newClassifier()
+	if got := count(c, "Header"); got != 3 {
+		t.Fatalf("Match() found %d headers without a budget, want 3", got)
+	}
+
+	tests := []struct {
+		name    string
+		budget  Budget
+		headers int
+	}{
+		{name: "candidates", budget: Budget{MaxCandidates: 2}, headers: 2},
+		{name: "diff size", budget: Budget{MaxDiffSize: 10}, headers: 0},
+		{name: "generous", budget: Budget{MaxCandidates: 10, MaxDiffSize: 1000}, headers: 3},
+	}
+	for _, tt := range tests {
+		c := newClassifier()
+		c.SetScoringBudget("Header", tt.budget)
+		if got := count(c, "Header"); got != tt.headers {
+			t.Errorf("%s: Match() found %d headers, want %d", tt.name, got, tt.headers)
+		}
+		// The budget of headers doesn't apply to licenses.
+		if got := count(c, "License"); got != 1 {
+			t.Errorf("%s: Match() found %d licenses, want 1", tt.name, got)
+		}
+	}
+
+	// The zero budget removes the limits.
+	c.SetScoringBudget("Header", Budget{MaxCandidates: 1})
+	c.SetScoringBudget("Header", Budget{})
+	if got := count(c, "Header"); got != 3 {
+		t.Errorf("Match() found %d headers after removing the budget, want 3", got)
+	}
+}
+
+func TestTimeBudget(t *testing.T) {
+	mit, err := ioutil.ReadFile(filepath.Join(baseLicenses, "MIT.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := append([]byte("Copyright 2020 Example Inc.\n\n"), mit...)
+	c := NewClassifier(defaultThreshold)
+	c.AddContent("MIT", mit)
+	c.AddContent("Widget-1.0", []byte(versionedLicense))
+	want := c.Match(in)
+	if len(want) != 1 || want[0].Approximate {
+		t.Fatalf("Match() without a time budget = %v, want a single exact match", want)
+	}
+
+	// A generous budget doesn't change the matches.
+	c.SetTimeBudget(time.Hour)
+	if diff := cmp.Diff(want, c.Match(in)); diff != "" {
+		t.Errorf("Match() with a generous time budget mismatch (-want +got):\n%s", diff)
+	}
+
+	// A budget that has run out before the candidates are scored
+	// approximates them, and the approximate matches aren't cached.
+	cache := NewLRUCache(10)
+	var approximated int
+	c.SetCache(cache)
+	c.SetMetrics(MetricsFunc(func(m *DocumentMetrics) { approximated += m.Approximate }))
+	c.SetTimeBudget(time.Nanosecond)
+	got := c.Match(in)
+	if len(got) != 1 || got[0].Name != "MIT" || !got[0].Approximate {
+		t.Fatalf("Match() with an exhausted time budget = %v, want an approximate MIT match", got)
+	}
+	if got[0].Confidence < defaultThreshold || got[0].StartLine != want[0].StartLine {
+		t.Errorf("approximate match = %+v, want a confidence of at least %v starting on line %d", got[0], defaultThreshold, want[0].StartLine)
+	}
+	if approximated != 1 {
+		t.Errorf("DocumentMetrics.Approximate = %d, want 1", approximated)
+	}
+	if n := cache.Len(); n != 0 {
+		t.Errorf("cache holds %d results, want approximate matches left out", n)
+	}
+
+	// Text sharing too few tokens with the license isn't approximated to it.
+	if got := c.Match([]byte(versionedLicense)); len(got) != 1 || got[0].Name != "Widget-1.0" {
+		t.Errorf("Match(Widget-1.0) with an exhausted time budget = %v, want only Widget-1.0", got)
+	}
+}
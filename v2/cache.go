@@ -0,0 +1,175 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// CacheKey identifies the result of classifying some content. It is the
+// SHA-256 hash of the normalized content along with the corpus and the
+// configuration of the classifier, so results cached by a classifier are
+// never returned after its corpus or configuration changes.
+type CacheKey [sha256.Size]byte
+
+// Cache stores the matches of contents that have been classified, so that
+// classifying them again is nearly free. Implementations must be safe for
+// concurrent use. The matches passed to Put and returned by Get are owned by
+// the cache and aren't modified by the classifier.
+type Cache interface {
+	Get(key CacheKey) (Matches, bool)
+	Put(key CacheKey, m Matches)
+}
+
+// SetCache installs a cache that the classifier consults before matching
+// content, and fills with the results of the content it matches. A nil cache
+// disables caching.
+func (c *Classifier) SetCache(cache Cache) {
+	defer c.update()()
+	c.cache = cache
+}
+
+// update takes the lock for a change to the corpus or configuration of the
+// classifier, returning the function releasing it. The snapshot matches run
+// on is discarded.
+func (c *Classifier) update() func() {
+	c.mu.Lock()
+	c.snap.Store((*Classifier)(nil))
+	return c.mu.Unlock
+}
+
+// cacheKey returns the key of the supplied content, which has had its markup
+// stripped. The content is normalized by ignoring line ending styles and
+// trailing whitespace, which affect neither tokens nor line numbers.
+func (c *Classifier) cacheKey(in []byte) CacheKey {
+	h := sha256.New()
+	h.Write(c.cacheScope())
+	for i, l := range bytes.Split(in, []byte("\n")) {
+		if i > 0 {
+			h.Write([]byte("\n"))
+		}
+		h.Write(bytes.TrimRight(l, " \t\r"))
+	}
+	var key CacheKey
+	h.Sum(key[:0])
+	return key
+}
+
+// cacheScope returns a digest of everything other than the content that
+// determines the matches: the corpus and the configuration. That of a
+// snapshot is computed when it is taken.
+func (c *Classifier) cacheScope() []byte {
+	if c.scope != nil {
+		return c.scope
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "threshold=%v q=%v format=%v strings=%v weighted=%v edits=%+v maxTokens=%v topK=%v partial=%v overlap=%v\n", c.threshold, c.q, c.format, c.minStrings, c.weighted, c.edits, c.maxTokens, c.topK, c.partial, c.overlap)
+	var types []string
+	for t := range c.budgets {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		fmt.Fprintf(h, "budget %s=%+v\n", t, c.budgets[t])
+	}
+	for _, r := range c.rules {
+		fmt.Fprintf(h, "rule %s\n", r.name)
+	}
+	if c.calibration != nil {
+		fmt.Fprintf(h, "calibration %#v\n", c.calibration)
+	}
+	if e := c.equivalences; e != nil {
+		fmt.Fprintf(h, "equivalences %q %q\n", e.source.Classes, e.source.Stopwords)
+	}
+	for _, name := range sortedNames(c.docs) {
+		d := c.docs[name]
+		fmt.Fprintf(h, "%s\x00%s\x00", name, d.norm)
+		for _, e := range d.exemptions {
+			fmt.Fprintf(h, "%s\x00", e.phrase)
+		}
+		fmt.Fprintf(h, "anchors %v\x00", d.anchors)
+		if t := d.template; t != nil {
+			fmt.Fprintf(h, "template %v %v %v\x00", t.variable, t.optional, t.slots)
+		}
+	}
+	return h.Sum(nil)
+}
+
+// LRUCache is an in-memory Cache holding the results of a bounded number of
+// contents, discarding the least recently used when full.
+type LRUCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // of *lruEntry, most recently used first
+	entries map[CacheKey]*list.Element
+}
+
+type lruEntry struct {
+	key     CacheKey
+	matches Matches
+}
+
+// NewLRUCache returns a cache holding the results of up to size contents.
+func NewLRUCache(size int) *LRUCache {
+	if size < 1 {
+		size = 1
+	}
+	return &LRUCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[CacheKey]*list.Element),
+	}
+}
+
+// Get implements Cache.
+func (l *LRUCache) Get(key CacheKey) (Matches, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+	l.order.MoveToFront(e)
+	return e.Value.(*lruEntry).matches, true
+}
+
+// Put implements Cache.
+func (l *LRUCache) Put(key CacheKey, m Matches) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.entries[key]; ok {
+		e.Value.(*lruEntry).matches = m
+		l.order.MoveToFront(e)
+		return
+	}
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, matches: m})
+	if l.order.Len() > l.size {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// Len returns the number of contents whose results are cached.
+func (l *LRUCache) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
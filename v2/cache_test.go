@@ -0,0 +1,128 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLRUCache(t *testing.T) {
+	l := NewLRUCache(2)
+	k := func(b byte) CacheKey { return CacheKey{b} }
+	m := func(name string) Matches { return Matches{{Name: name}} }
+
+	l.Put(k(1), m("one"))
+	l.Put(k(2), m("two"))
+	if got, ok := l.Get(k(1)); !ok || got[0].Name != "one" {
+		t.Errorf("Get(1) = %v, %v; want one", got, ok)
+	}
+	// 2 is now the least recently used.
+	l.Put(k(3), m("three"))
+	if _, ok := l.Get(k(2)); ok {
+		t.Error("Get(2) found an evicted entry")
+	}
+	for _, b := range []byte{1, 3} {
+		if _, ok := l.Get(k(b)); !ok {
+			t.Errorf("Get(%d) didn't find a cached entry", b)
+		}
+	}
+	l.Put(k(3), m("THREE"))
+	if got, _ := l.Get(k(3)); got[0].Name != "THREE" {
+		t.Errorf("Get(3) = %v after replacing it, want THREE", got[0].Name)
+	}
+	if got := l.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+}
+
+// countingCache counts the hits of a cache.
+type countingCache struct {
+	*LRUCache
+	hits int
+}
+
+func (c *countingCache) Get(key CacheKey) (Matches, bool) {
+	m, ok := c.LRUCache.Get(key)
+	if ok {
+		c.hits++
+	}
+	return m, ok
+}
+
+func TestClassifierCache(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	in, err := ioutil.ReadFile(filepath.Join(baseLicenses, "MIT.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := c.Match(in)
+
+	cache := &countingCache{LRUCache: NewLRUCache(10)}
+	c.SetCache(cache)
+	if diff := cmp.Diff(want, c.Match(in)); diff != "" {
+		t.Errorf("Match() with an empty cache mismatch (-want +got):\n%s", diff)
+	}
+	got := c.Match(in)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Match() from the cache mismatch (-want +got):\n%s", diff)
+	}
+	if cache.hits != 1 {
+		t.Errorf("cache hits = %d, want 1", cache.hits)
+	}
+
+	// The cached matches aren't affected by callers modifying results.
+	got[0].Name = "changed"
+	if m := c.Match(in); m[0].Name != "MIT" {
+		t.Errorf("Match() = %s after modifying a cached result, want MIT", m[0].Name)
+	}
+
+	// Line endings and trailing whitespace don't affect the key.
+	crlf := []byte{}
+	for _, b := range in {
+		if b == '\n' {
+			crlf = append(crlf, ' ', '\r')
+		}
+		crlf = append(crlf, b)
+	}
+	hits := cache.hits
+	c.Match(crlf)
+	if cache.hits != hits+1 {
+		t.Error("Match() of content with CRLF line endings missed the cache")
+	}
+
+	// Changing the corpus or configuration invalidates the cached results.
+	hits = cache.hits
+	c.AddContent("Extra", []byte("some extra license text that matches nothing"))
+	c.Match(in)
+	c.SetTokenWeighting(true)
+	c.Match(in)
+	if cache.hits != hits {
+		t.Errorf("cache hits = %d after changing the classifier, want %d", cache.hits, hits)
+	}
+
+	c.SetCache(nil)
+	hits = cache.hits
+	c.Match(in)
+	if cache.hits != hits {
+		t.Error("Match() consulted a removed cache")
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import "math"
+
+// Calibration maps the confidence of a match of a corpus entry to one that
+// means roughly the same for entries of all lengths. The raw confidence is
+// one minus the edits relative to the length of the entry, so ten edited
+// words cost a 120 word MIT license 8% of its confidence and a 10,000 word
+// license 0.1%, although both are ten words of difference.
+type Calibration interface {
+	// Calibrate is given the name of the corpus entry, its length in words
+	// and the raw confidence of a match, and returns the calibrated
+	// confidence, between 0 and 1. It is called concurrently by concurrent
+	// matches.
+	Calibrate(entry string, length int, confidence float64) float64
+}
+
+// DefaultCalibrationLength is the reference length of LengthCalibration if
+// it isn't set.
+const DefaultCalibrationLength = 1000
+
+// LengthCalibration scales the share of a license that was edited by the
+// square root of its length relative to a reference length, which is the
+// rate at which the number of edits of a legitimate modification, such as a
+// reworded warranty disclaimer, grows with the length of a license. Licenses
+// shorter than the reference have their confidence raised, and longer ones
+// lowered.
+type LengthCalibration struct {
+	// Reference is the length, in words, of the licenses whose confidence
+	// is left unchanged, or DefaultCalibrationLength if it is zero.
+	Reference int
+	// References overrides Reference for the corpus entries of the named
+	// licenses, such as "MPL-2.0".
+	References map[string]int
+}
+
+// Calibrate implements Calibration.
+func (l LengthCalibration) Calibrate(entry string, length int, confidence float64) float64 {
+	ref := l.Reference
+	if r, ok := l.References[LicenseName(entry)]; ok {
+		ref = r
+	}
+	if ref <= 0 {
+		ref = DefaultCalibrationLength
+	}
+	if length <= 0 || confidence >= 1 {
+		return confidence
+	}
+	scaled := 1 - (1-confidence)*math.Sqrt(float64(length)/float64(ref))
+	return math.Max(0, math.Min(1, scaled))
+}
+
+// SetCalibration installs a calibration of the confidence of the matches of
+// corpus entries, or removes it if cal is nil. The threshold still applies
+// to the raw confidence, which bounds the edits matches are searched with, so
+// calibrated matches may be reported with a confidence below it. Matches of
+// references and grants aren't calibrated.
+func (c *Classifier) SetCalibration(cal Calibration) {
+	defer c.update()()
+	c.calibration = cal
+}
+
+// calibrate returns the calibrated confidence of a match of the named corpus
+// entry, or conf if there is no calibration.
+func (c *Classifier) calibrate(name string, d *indexedDocument, conf float64) float64 {
+	if c.calibration == nil {
+		return conf
+	}
+	return c.calibration.Calibrate(name, d.size(), conf)
+}
@@ -0,0 +1,118 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLengthCalibration(t *testing.T) {
+	tests := []struct {
+		description string
+		cal         LengthCalibration
+		entry       string
+		length      int
+		confidence  float64
+		want        float64
+	}{
+		{
+			description: "reference length",
+			entry:       "MIT",
+			length:      1000,
+			confidence:  0.9,
+			want:        0.9,
+		},
+		{
+			description: "short license",
+			entry:       "MIT",
+			length:      250,
+			confidence:  0.9,
+			want:        0.95,
+		},
+		{
+			description: "long license",
+			entry:       "MPL-2.0",
+			length:      4000,
+			confidence:  0.95,
+			want:        0.9,
+		},
+		{
+			description: "exact match",
+			entry:       "MPL-2.0",
+			length:      4000,
+			confidence:  1,
+			want:        1,
+		},
+		{
+			description: "clamped",
+			entry:       "MPL-2.0",
+			length:      10000,
+			confidence:  0.5,
+			want:        0,
+		},
+		{
+			description: "license reference",
+			cal:         LengthCalibration{Reference: 100, References: map[string]int{"MIT": 250}},
+			entry:       "MIT.txt",
+			length:      250,
+			confidence:  0.9,
+			want:        0.9,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			if got := tt.cal.Calibrate(tt.entry, tt.length, tt.confidence); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Calibrate(%q, %d, %v) = %v, want %v", tt.entry, tt.length, tt.confidence, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalibratedMatches(t *testing.T) {
+	b, err := ioutil.ReadFile(filepath.Join(baseLicenses, "MIT.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	modified := []byte(strings.Replace(string(b), "including without limitation the rights", "including the rights", 1))
+	confidence := func(c *Classifier) float64 {
+		ms := licenseMatches(c.Match(modified))
+		if len(ms) != 1 || ms[0].Name != "MIT" {
+			t.Fatalf("Match() = %v, want MIT", ms)
+		}
+		return ms[0].Confidence
+	}
+
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	raw := confidence(c)
+	c.SetCalibration(LengthCalibration{})
+	calibrated := confidence(c)
+	if raw >= 1 || calibrated <= raw {
+		t.Errorf("calibrated confidence of a modified MIT license = %v, want it above the raw %v", calibrated, raw)
+	}
+	if ms := licenseMatches(c.Match(b)); len(ms) != 1 || ms[0].Confidence != 1 {
+		t.Errorf("Match() of the MIT license = %v, want a match of confidence 1", ms)
+	}
+	c.SetCalibration(nil)
+	if got := confidence(c); got != raw {
+		t.Errorf("confidence without calibration = %v, want %v", got, raw)
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+// License categories. These follow the categories used by the v1 classifier,
+// with public domain dedications split out from unencumbered licenses so
+// that policies can handle them separately.
+const (
+	// CategoryRestricted licenses require mandatory source distribution of
+	// products that include code under such a license.
+	CategoryRestricted = "restricted"
+	// CategoryReciprocal licenses require modifications to the licensed code
+	// to be made available.
+	CategoryReciprocal = "reciprocal"
+	// CategoryNotice licenses require the copyright notice or an advertising
+	// clause to be distributed with the code.
+	CategoryNotice = "notice"
+	// CategoryPermissive licenses are more lenient than notice licenses and
+	// do not require a copyright notice.
+	CategoryPermissive = "permissive"
+	// CategoryUnencumbered licenses declare the code free for any use.
+	CategoryUnencumbered = "unencumbered"
+	// CategoryPublicDomain is used for dedications of the work to the public
+	// domain, including short statements that aren't full license texts.
+	CategoryPublicDomain = "public_domain"
+	// CategoryByExceptionOnly licenses are incompatible with most uses.
+	CategoryByExceptionOnly = "by_exception_only"
+	// CategoryForbidden licenses must not be used.
+	CategoryForbidden = "forbidden"
+	// CategoryProprietary is used for commercial terms, such as end-user
+	// license agreements, that restrict the use of the code.
+	CategoryProprietary = "proprietary"
+	// CategoryContributorAgreement is used for contributor license
+	// agreements and the Developer Certificate of Origin, which govern
+	// contributions to a project rather than the use of its code.
+	CategoryContributorAgreement = "contributor_agreement"
+)
+
+// licenseCategories maps each license name to its category.
+var licenseCategories = func() map[string]string {
+	categories := map[string][]string{
+		CategoryRestricted: {
+			"BCL",
+			"CC-BY-ND-1.0",
+			"CC-BY-ND-2.0",
+			"CC-BY-ND-2.5",
+			"CC-BY-ND-3.0",
+			"CC-BY-ND-4.0",
+			"CC-BY-SA-1.0",
+			"CC-BY-SA-2.0",
+			"CC-BY-SA-2.5",
+			"CC-BY-SA-3.0",
+			"CC-BY-SA-4.0",
+			"GPL-1.0",
+			"GPL-2.0",
+			"GPL-2.0-with-autoconf-exception",
+			"GPL-2.0-with-bison-exception",
+			"GPL-2.0-with-classpath-exception",
+			"GPL-2.0-with-font-exception",
+			"GPL-2.0-with-GCC-exception",
+			"GPL-3.0",
+			"GPL-3.0-with-autoconf-exception",
+			"GPL-3.0-with-GCC-exception",
+			"LGPL-2.0",
+			"LGPL-2.1",
+			"LGPL-3.0",
+			"NPL-1.0",
+			"NPL-1.1",
+			"OSL-1.0",
+			"OSL-1.1",
+			"OSL-2.0",
+			"OSL-2.1",
+			"OSL-3.0",
+			"QPL-1.0",
+			"Sleepycat",
+		},
+		CategoryReciprocal: {
+			"APSL-1.0",
+			"APSL-1.1",
+			"APSL-1.2",
+			"APSL-2.0",
+			"CDDL-1.0",
+			"CDDL-1.1",
+			"CPL-1.0",
+			"EPL-1.0",
+			"EPL-2.0",
+			"FreeImage",
+			"IPL-1.0",
+			"MPL-1.0",
+			"MPL-1.1",
+			"MPL-2.0",
+			"Ruby",
+		},
+		CategoryNotice: {
+			"AFL-1.1",
+			"AFL-1.2",
+			"AFL-2.0",
+			"AFL-2.1",
+			"AFL-3.0",
+			"Apache-1.0",
+			"Apache-1.1",
+			"Apache-2.0",
+			"Artistic-1.0-cl8",
+			"Artistic-1.0-Perl",
+			"Artistic-1.0",
+			"Artistic-2.0",
+			"BSL-1.0",
+			"BSD-2-Clause-FreeBSD",
+			"BSD-2-Clause-NetBSD",
+			"BSD-2-Clause",
+			"BSD-3-Clause-Attribution",
+			"BSD-3-Clause-Clear",
+			"BSD-3-Clause-LBNL",
+			"BSD-3-Clause",
+			"BSD-4-Clause",
+			"BSD-4-Clause-UC",
+			"BSD-Protection",
+			"CC-BY-1.0",
+			"CC-BY-2.0",
+			"CC-BY-2.5",
+			"CC-BY-3.0",
+			"CC-BY-4.0",
+			"FTL",
+			"ISC",
+			"ImageMagick",
+			"Libpng",
+			"Lil-1.0",
+			"Linux-OpenIB",
+			"LPL-1.02",
+			"LPL-1.0",
+			"MS-PL",
+			"MIT",
+			"NCSA",
+			"OpenSSL",
+			"PHP-3.01",
+			"PHP-3.0",
+			"PIL",
+			"Python-2.0",
+			"Python-2.0-complete",
+			"PostgreSQL",
+			"SGI-B-1.0",
+			"SGI-B-1.1",
+			"SGI-B-2.0",
+			"Unicode-DFS-2015",
+			"Unicode-DFS-2016",
+			"Unicode-TOU",
+			"UPL-1.0",
+			"W3C-19980720",
+			"W3C-20150513",
+			"W3C",
+			"X11",
+			"Xnet",
+			"Zend-2.0",
+			"zlib-acknowledgement",
+			"Zlib",
+			"ZPL-1.1",
+			"ZPL-2.0",
+			"ZPL-2.1",
+		},
+		CategoryUnencumbered: {
+			"0BSD",
+		},
+		CategoryByExceptionOnly: {
+			"Beerware",
+			"OFL-1.1",
+			"OpenVision",
+		},
+		CategoryForbidden: {
+			"AGPL-1.0",
+			"AGPL-3.0",
+			"CC-BY-NC-1.0",
+			"CC-BY-NC-2.0",
+			"CC-BY-NC-2.5",
+			"CC-BY-NC-3.0",
+			"CC-BY-NC-4.0",
+			"CC-BY-NC-ND-1.0",
+			"CC-BY-NC-ND-2.0",
+			"CC-BY-NC-ND-2.5",
+			"CC-BY-NC-ND-3.0",
+			"CC-BY-NC-ND-4.0",
+			"CC-BY-NC-SA-1.0",
+			"CC-BY-NC-SA-2.0",
+			"CC-BY-NC-SA-2.5",
+			"CC-BY-NC-SA-3.0",
+			"CC-BY-NC-SA-4.0",
+			"Commons-Clause",
+			"Facebook-2-Clause",
+			"Facebook-3-Clause",
+			"Facebook-Examples",
+			"WTFPL",
+		},
+		CategoryProprietary: {
+			Proprietary,
+		},
+		CategoryContributorAgreement: {
+			"Apache-CCLA-2.0",
+			"Apache-ICLA-2.0",
+			"DCO-1.1",
+		},
+		CategoryPublicDomain: {
+			"CC0-1.0",
+			"Public-Domain",
+			"Unlicense",
+			"blessing",
+		},
+	}
+	m := make(map[string]string)
+	for c, names := range categories {
+		for _, n := range names {
+			m[n] = c
+		}
+	}
+	return m
+}()
+
+// LicenseCategory returns the category of the named license, or the empty
+// string if the license has not been categorized.
+func LicenseCategory(name string) string {
+	return licenseCategories[name]
+}
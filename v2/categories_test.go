@@ -0,0 +1,159 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestLicenseCategory(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "GPL-2.0", expected: CategoryRestricted},
+		{name: "MPL-2.0", expected: CategoryReciprocal},
+		{name: "MIT", expected: CategoryNotice},
+		{name: "0BSD", expected: CategoryUnencumbered},
+		{name: "CC0-1.0", expected: CategoryPublicDomain},
+		{name: "Public-Domain", expected: CategoryPublicDomain},
+		{name: "AGPL-3.0", expected: CategoryForbidden},
+		{name: "DCO-1.1", expected: CategoryContributorAgreement},
+		{name: "not-a-license", expected: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := LicenseCategory(test.name); got != test.expected {
+				t.Errorf("LicenseCategory(%q) = %q, want %q", test.name, got, test.expected)
+			}
+		})
+	}
+}
+
+func TestPublicDomainMatches(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+
+	unlicense, err := ioutil.ReadFile(filepath.Join(baseLicenses, "Unlicense.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read license: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "one-line dedication in a comment",
+			input:    "// The author hereby releases this work into the public domain.\npackage main\n",
+			expected: "Public-Domain",
+		},
+		{
+			name:     "first-person dedication",
+			input:    "# I hereby dedicate this work to the public domain.\n",
+			expected: "Public-Domain",
+		},
+		{
+			name:     "placed in the public domain",
+			input:    "/*\n * Written by A. Developer.\n * The author hereby places this work in the public domain.\n */\n",
+			expected: "Public-Domain",
+		},
+		{
+			name:     "waiver statement",
+			input:    "The author hereby dedicates this work to the public domain and waives all\ncopyright and related rights to it.\n",
+			expected: "Public-Domain",
+		},
+		{
+			name:     "released into the public domain",
+			input:    "// This code is released into the public domain.\n",
+			expected: "Public-Domain",
+		},
+		{
+			name:     "placed into the public domain",
+			input:    "This software is hereby placed into the public domain.\n",
+			expected: "Public-Domain",
+		},
+		{
+			name:     "dedicated to the public domain",
+			input:    "# All of this code is dedicated to the public domain.\n",
+			expected: "Public-Domain",
+		},
+		{
+			name:     "dedication without a subject",
+			input:    "// Released into the public domain.\n",
+			expected: "Public-Domain",
+		},
+		{
+			name:     "full dedication text",
+			input:    string(unlicense),
+			expected: "Unlicense",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := c.Match([]byte(test.input))
+			checkMatches(t, m, test.name, []string{test.expected})
+			for _, r := range m {
+				if r.Category != CategoryPublicDomain {
+					t.Errorf("Category = %q, want %q", r.Category, CategoryPublicDomain)
+				}
+			}
+		})
+	}
+}
+
+func TestPublicDomainMentions(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	for _, input := range []string{
+		"The SHA-1 algorithm is in the public domain, but this implementation is covered by the GPL.",
+		"# This file is in the public domain.\n",
+		"This code is not released into the public domain.",
+		"This work has not been placed in the public domain by its authors.",
+	} {
+		for _, m := range c.Match([]byte(input)) {
+			if m.Name == "Public-Domain" {
+				t.Errorf("Match(%q) = Public-Domain (confidence %v), want no dedication", input, m.Confidence)
+			}
+		}
+	}
+}
+
+func TestContributorAgreementMatches(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	for _, name := range []string{"Apache-ICLA-2.0", "Apache-CCLA-2.0", "DCO-1.1"} {
+		b, err := ioutil.ReadFile(filepath.Join(baseLicenses, name+".txt"))
+		if err != nil {
+			t.Fatalf("couldn't read license: %v", err)
+		}
+		in := "# Contributing\n\nContributions must be accepted under the following terms.\n\n" + string(b)
+		ms := c.Match([]byte(in))
+		if len(ms) == 0 || ms[0].Name != name || ms[0].Category != CategoryContributorAgreement {
+			t.Errorf("Match(%s) = %v, want a %s match", name, licenseMatches(ms), CategoryContributorAgreement)
+		}
+	}
+}
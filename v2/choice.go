@@ -0,0 +1,138 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// This file contains routines to detect content offering a choice of
+// licenses, such as the "Licensed under either of Apache License, Version 2.0
+// or MIT license at your option" of many Rust crates, so that the licenses
+// are reported as alternatives rather than as licenses that all apply.
+
+var (
+	// choiceOpener starts the wording of a choice of licenses.
+	choiceOpener = regexp.MustCompile(`(?i)\b(?:dual[ -]licen[cs]ed|either of)\b`)
+	// choiceCloser ends the wording of a choice of licenses, or is the whole
+	// of it when the licenses are named on the same line.
+	choiceCloser = regexp.MustCompile(`(?i)\bat (?:your|the user's|the licensee's) (?:option|choice|discretion)\b`)
+)
+
+// maxChoiceLines bounds the lines between the opening and closing words of a
+// choice, which may list the licenses on separate lines.
+const maxChoiceLines = 15
+
+// linkChoices records the licenses offered as alternatives to each other in
+// the supplied content, which the matches were found in. Wording that is
+// part of a matched license text, such as the "at your option" of the GPL
+// headers, doesn't offer a choice.
+func linkChoices(in []byte, matches Matches) {
+	lines := strings.Split(string(in), "\n")
+	covered := func(line int) bool {
+		for _, m := range matches {
+			if m.MatchType != referenceType && m.MatchType != grantType && between(line, m.StartLine, m.EndLine) {
+				return true
+			}
+		}
+		return false
+	}
+	marked := func(re *regexp.Regexp, line int) bool {
+		return re.MatchString(lines[line-1]) && !covered(line)
+	}
+	for l := 1; l <= len(lines); l++ {
+		var start, end int
+		switch {
+		case marked(choiceOpener, l):
+			start, end = l, paragraphEnd(lines, l)
+			for c := l; c <= len(lines) && c <= l+maxChoiceLines; c++ {
+				if marked(choiceCloser, c) {
+					end = c
+					break
+				}
+			}
+		case marked(choiceCloser, l):
+			start, end = paragraphStart(lines, l), l
+		default:
+			continue
+		}
+		linkChoice(matches, start, end)
+		l = end
+	}
+}
+
+// linkChoice makes the licenses matched between the supplied lines
+// alternatives to each other, if there are several.
+func linkChoice(matches Matches, start, end int) {
+	var names []string
+	seen := make(map[string]bool)
+	var offered Matches
+	for _, m := range matches {
+		if m.MatchType == exceptionType || !between(m.StartLine, start, end) {
+			continue
+		}
+		offered = append(offered, m)
+	}
+	offered.SortByPosition()
+	for _, m := range offered {
+		if !seen[m.Name] {
+			seen[m.Name] = true
+			names = append(names, m.Name)
+		}
+	}
+	if len(names) < 2 {
+		return
+	}
+	for _, m := range offered {
+		m.Choice = nil
+		for _, n := range names {
+			if n != m.Name {
+				m.Choice = append(m.Choice, n)
+			}
+		}
+	}
+}
+
+// paragraphStart returns the first line of the paragraph holding the supplied
+// line, paragraphs being separated by blank lines.
+func paragraphStart(lines []string, line int) int {
+	for line > 1 && strings.TrimSpace(lines[line-2]) != "" {
+		line--
+	}
+	return line
+}
+
+// paragraphEnd returns the last line of the paragraph holding the supplied
+// line.
+func paragraphEnd(lines []string, line int) int {
+	for line < len(lines) && strings.TrimSpace(lines[line]) != "" {
+		line++
+	}
+	return line
+}
+
+// ChoiceExpression returns the SPDX license expression of the choice of
+// licenses the match is part of, such as "Apache-2.0 OR MIT", or the
+// expression of the match if the content offers no choice.
+func (m *Match) ChoiceExpression() string {
+	if len(m.Choice) == 0 {
+		return m.Expression()
+	}
+	names := append([]string{m.Name}, m.Choice...)
+	sort.Strings(names)
+	return strings.Join(names, " OR ")
+}
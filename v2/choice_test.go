@@ -0,0 +1,145 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestChoices(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	gplHeader, err := ioutil.ReadFile(filepath.Join(baseLicenses, "GPL-2.0.header.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	type choice struct {
+		Name   string
+		Choice []string
+	}
+	tests := []struct {
+		description string
+		in          string
+		want        []choice
+		expression  string
+	}{
+		{
+			description: "Rust crate",
+			in: `## License
+
+Licensed under either of
+
+ * Apache License, Version 2.0
+   (LICENSE-APACHE or https://www.apache.org/licenses/LICENSE-2.0)
+ * MIT license
+   (LICENSE-MIT or https://opensource.org/licenses/MIT)
+
+at your option.
+`,
+			want: []choice{
+				{"Apache-2.0", []string{"MIT"}},
+				{"Apache-2.0", []string{"MIT"}},
+				{"MIT", []string{"Apache-2.0"}},
+				{"MIT", []string{"Apache-2.0"}},
+			},
+			expression: "Apache-2.0 OR MIT",
+		},
+		{
+			description: "single line",
+			in:          "Licensed under the Apache License, Version 2.0 or the MIT license, at your option.\n",
+			want: []choice{
+				{"Apache-2.0", []string{"MIT"}},
+				{"MIT", []string{"Apache-2.0"}},
+			},
+			expression: "Apache-2.0 OR MIT",
+		},
+		{
+			description: "single line either of",
+			in:          "Licensed under either of Apache License, Version 2.0 or MIT license at your option.\n",
+			want: []choice{
+				{"Apache-2.0", []string{"MIT"}},
+				{"MIT", []string{"Apache-2.0"}},
+			},
+			expression: "Apache-2.0 OR MIT",
+		},
+		{
+			description: "dual licensed identifiers",
+			in:          "Dual licensed under MIT or Apache-2.0.\n",
+			want: []choice{
+				{"Apache-2.0", []string{"MIT"}},
+				{"MIT", []string{"Apache-2.0"}},
+			},
+			expression: "Apache-2.0 OR MIT",
+		},
+		{
+			description: "SPDX expression",
+			in:          "// SPDX-License-Identifier: Apache-2.0 OR MIT\n",
+			want: []choice{
+				{"Apache-2.0", []string{"MIT"}},
+				{"MIT", []string{"Apache-2.0"}},
+			},
+			expression: "Apache-2.0 OR MIT",
+		},
+		{
+			description: "SPDX expression with a conjunction",
+			in:          "// SPDX-License-Identifier: (MIT OR Apache-2.0) AND BSD-3-Clause\n",
+			want: []choice{
+				{"Apache-2.0", []string{"MIT"}},
+				{"BSD-3-Clause", nil},
+				{"MIT", []string{"Apache-2.0"}},
+			},
+			expression: "Apache-2.0 OR MIT",
+		},
+		{
+			description: "both apply",
+			in:          "Parts are under the Apache License, Version 2.0.\n\nOthers are under the MIT license.\n",
+			want: []choice{
+				{"Apache-2.0", nil},
+				{"MIT", nil},
+			},
+			expression: "Apache-2.0",
+		},
+		{
+			description: "option of a license text",
+			in:          string(gplHeader) + "\nSee also the MIT license.\n",
+			want: []choice{
+				{"GPL-2.0", nil},
+				{"MIT", nil},
+			},
+			expression: "GPL-2.0",
+		},
+	}
+	for _, tt := range tests {
+		ms := c.Match([]byte(tt.in))
+		ms.SortByPosition()
+		var got []choice
+		for _, m := range ms {
+			got = append(got, choice{m.Name, m.Choice})
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: choices (-want +got):\n%s", tt.description, diff)
+			continue
+		}
+		if got := ms[0].ChoiceExpression(); got != tt.expression {
+			t.Errorf("%s: ChoiceExpression() = %q, want %q", tt.description, got, tt.expression)
+		}
+	}
+}
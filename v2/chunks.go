@@ -0,0 +1,99 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Inputs of at least parallelTokenizeSize bytes, such as the aggregated
+// notice files of large products, are tokenized in chunks of about
+// tokenizeChunkSize bytes in parallel.
+const (
+	parallelTokenizeSize = 4 << 20
+	tokenizeChunkSize    = 1 << 20
+)
+
+// tokenizeChunks tokenizes the input in chunks of about size bytes in
+// parallel, producing the same document as tokenizing it at once. Chunks end
+// at line breaks, since the normalization of the text and the scanning of
+// tokens work a line at a time. The hyphenated words that continue across
+// lines, possibly across chunks, are reassembled by cleaning up the stitched
+// tokens.
+func tokenizeChunks(in []byte, size int) *document {
+	chunks := splitLines(in, size)
+	tokens := make([][]*token, len(chunks))
+	lines := make([]int, len(chunks))
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.GOMAXPROCS(0) && w < len(chunks); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				norm := normalizeText(chunks[i])
+				lines[i] = strings.Count(norm, "\n")
+				tokens[i] = scanTokens(removeIgnorableTexts(norm))
+			}
+		}()
+	}
+	for i := range chunks {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	var all []*token
+	offset := 0
+	for i, toks := range tokens {
+		// Scanning ends each chunk with a line break past its last line,
+		// which is moved onto it so that a hyphenated word continued in the
+		// next chunk is reassembled on the line it is on when the input is
+		// tokenized at once.
+		if i < len(tokens)-1 {
+			toks[len(toks)-1].Line = lines[i]
+		}
+		for _, t := range toks {
+			t.Line += offset
+			// Only the first token of the input records the text
+			// preceding it.
+			if i > 0 {
+				t.Previous = ""
+			}
+		}
+		all = append(all, toks...)
+		offset += lines[i]
+	}
+	return &document{Tokens: cleanupTokens(all)}
+}
+
+// splitLines splits the input into chunks of at least size bytes ending with
+// a line break, except for the last.
+func splitLines(in []byte, size int) [][]byte {
+	var out [][]byte
+	for len(in) > size {
+		i := bytes.IndexByte(in[size:], '\n')
+		if i == -1 {
+			break
+		}
+		out = append(out, in[:size+i+1])
+		in = in[size+i+1:]
+	}
+	return append(out, in)
+}
@@ -0,0 +1,85 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTokenizeChunks(t *testing.T) {
+	inputs := map[string]string{
+		"hyphenated across lines": "this is a hyphen-\nated word and another multi-\n\nline word\n",
+		"list markers":            "preamble text here\n(ii) not a header\nii. a header\n1.2.3 also a header\n",
+		"line separators":         "first second&#10;third\nfourth line\n",
+		"soft eol":                "one sentence. Another sentence.\nnext line.\n",
+		"ignorable texts":         "MIT License\n\nCopyright (c) 2020 Someone\n\n\n\nAll rights reserved.\ntext\n",
+		"no final newline":        "a line\nwithout a final newline",
+	}
+	files, err := filepath.Glob(filepath.Join(baseLicenses, "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputs[filepath.Base(f)] = string(b)
+	}
+
+	for name, in := range inputs {
+		want := tokenize([]byte(in))
+		for _, size := range []int{1, 16, 200} {
+			got := tokenizeChunks([]byte(in), size)
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("%s: tokenizeChunks(%d) mismatch (-want +got):\n%s", name, size, diff)
+			}
+		}
+	}
+}
+
+func TestTokenizeLargeInput(t *testing.T) {
+	b, err := ioutil.ReadFile(filepath.Join(baseLicenses, "GPL-2.0.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := bytes.Repeat(b, parallelTokenizeSize/len(b)+1)
+	doc := tokenize(in)
+	lines := strings.Count(string(in), "\n")
+	if last := doc.Tokens[len(doc.Tokens)-1]; last.Line > lines || last.Index != len(doc.Tokens)-1 {
+		t.Errorf("last token at line %d index %d, want a line up to %d and index %d", last.Line, last.Index, lines, len(doc.Tokens)-1)
+	}
+	copies := strings.Count(string(in), string(b))
+	if got, want := len(doc.Tokens), copies*len(tokenize(b).Tokens); got != want {
+		t.Errorf("tokenize() of %d copies produced %d tokens, want %d", copies, got, want)
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	got := splitLines([]byte("ab\ncd\nef\ngh"), 4)
+	want := [][]byte{[]byte("ab\ncd\n"), []byte("ef\ngh")}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("splitLines() mismatch (-want +got):\n%s", diff)
+	}
+	if got := splitLines([]byte("no line breaks at all"), 4); len(got) != 1 {
+		t.Errorf("splitLines() = %q, want a single chunk", got)
+	}
+}
@@ -17,12 +17,17 @@ package classifier
 import (
 	"fmt"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Match is the information about a single instance of a detected match.
@@ -30,10 +35,90 @@ type Match struct {
 	Name            string
 	Confidence      float64
 	MatchType       string
+	Category        string
 	StartLine       int
 	EndLine         int
 	StartTokenIndex int
 	EndTokenIndex   int
+	// BaseLicense is the name of the license an exception applies to. It is
+	// only set for matches with a MatchType of Exception.
+	BaseLicense string
+	// Variant is the name of the corpus entry of the license that matched
+	// best, such as "GPL-2.0.header_a". It is empty for references.
+	Variant string
+	// Variants are the scores of the corpus entries of the license that
+	// matched the same region, including Variant, best first. When several
+	// entries of a license, such as its text and its header, match the same
+	// region, they are reported as this single match.
+	Variants []VariantScore
+	// Language is the ISO 639-1 code of the language of the matched text,
+	// such as "de", if it matched a translation of the license, and empty
+	// otherwise.
+	Language string
+	// Metadata is the metadata of the corpus entry that matched, if it has
+	// any. The language and category it declares are those of the match,
+	// and its SPDX identifier is the one returned by SPDXID.
+	Metadata *EntryMetadata
+	// Alias is the organization-specific alias of the license, if one was
+	// installed with SetAliases.
+	Alias Alias
+	// Alternatives are the other licenses that matched the region, best
+	// first, if the classifier reports them. See SetTopK.
+	Alternatives []Alternative
+	// Choice are the other licenses the content offers as alternatives to
+	// this one, in the order they appear, as in "dual licensed under the MIT
+	// or Apache-2.0 licenses, at your option". It is empty unless the
+	// content offers a choice.
+	Choice []string
+	// PatentGrant and PatentRetaliation tell whether the license has a
+	// patent grant and a clause terminating the rights of licensees who
+	// bring patent litigation, and whether they are part of the matched
+	// text.
+	PatentGrant, PatentRetaliation ClauseStatus
+	// TrademarkRestriction is true if the matched text withholds the use of
+	// the names or trademarks of the licensors, as the endorsement clause
+	// of BSD-3-Clause does. BinaryAttribution is true if it requires notices
+	// to accompany distributions in binary form, as the second clause of
+	// BSD-2-Clause and the NOTICE file clause of Apache-2.0 do.
+	TrademarkRestriction, BinaryAttribution bool
+	// Phrases are the phrases that recognized proprietary terms, for matches
+	// with a MatchType of Proprietary.
+	Phrases []string
+	// Portion is the part of the license text found, for matches with a
+	// MatchType of Partial. See SetPartialMatching.
+	Portion *Portion
+	// VersionConflict is set if the matched text has language of another
+	// version of the license, so that the version matched is in doubt.
+	VersionConflict *VersionConflict
+	// Approximate is true if the time budget of the document ran out before
+	// the matched text could be diffed against the license, so that its
+	// confidence is only an estimate. See SetTimeBudget.
+	Approximate bool
+	// Overlaps are the other licenses whose reported matches overlap this
+	// one, in order. It is only set with the OverlapReportAll strategy; the
+	// other strategies don't report overlapping matches.
+	Overlaps []string
+	// ID identifies the match among the matches of the content. It depends
+	// only on the license and the text matched, so the same match has the
+	// same ID in every scan of the content, even after unrelated edits.
+	ID string
+}
+
+// VariantScore is the confidence with which a corpus entry matched.
+type VariantScore struct {
+	Variant    string
+	MatchType  string
+	Confidence float64
+}
+
+// Expression returns the SPDX license expression for the match. Exceptions
+// that have been linked to a license are reported in the form
+// "GPL-2.0 WITH Classpath-exception-2.0".
+func (m *Match) Expression() string {
+	if m.MatchType == exceptionType && m.BaseLicense != "" {
+		return m.BaseLicense + " WITH " + m.Name
+	}
+	return m.Name
 }
 
 // Matches is a sortable slice of Match.
@@ -52,132 +137,469 @@ func (d Matches) Less(i, j int) bool {
 	if di.StartTokenIndex != dj.StartTokenIndex {
 		return di.StartTokenIndex < dj.StartTokenIndex
 	}
-	// Should never get here, but tiebreak based on the larger license.
-	return di.EndTokenIndex > dj.EndTokenIndex
+	// Tiebreak based on the larger license.
+	if di.EndTokenIndex != dj.EndTokenIndex {
+		return di.EndTokenIndex > dj.EndTokenIndex
+	}
+	// Different licenses can match the same text with the same confidence,
+	// so the order is made total by their names.
+	if di.Name != dj.Name {
+		return di.Name < dj.Name
+	}
+	return di.MatchType < dj.MatchType
 }
 
 // Match reports instances of the supplied content in the corpus.
 func (c *Classifier) match(in []byte) Matches {
-	id := c.createTargetIndexedDocument(in)
+	s := c.snapshot()
+	return s.cachedMatch(s.stripMarkup(in), nil)
+}
+
+// cachedMatch reports instances of the supplied content, which has had its
+// markup stripped, in the corpus, consulting the cache if there is one. doc
+// is the tokenized content, or nil if it must be tokenized.
+func (c *Classifier) cachedMatch(in []byte, doc *document) Matches {
+	var dm *DocumentMetrics
+	if c.metrics != nil {
+		dm = &DocumentMetrics{}
+		defer func(start time.Time) {
+			dm.Total = time.Since(start)
+			c.metrics.ObserveDocument(dm)
+		}(time.Now())
+	}
+	var m Matches
+	if c.cache == nil {
+		m = c.matchContent(in, doc, dm)
+	} else {
+		key := c.cacheKey(in)
+		var ok bool
+		if m, ok = c.cache.Get(key); ok {
+			m = copyMatches(m)
+			if dm != nil {
+				dm.Cache = CacheHit
+			}
+		} else {
+			m = c.matchContent(in, doc, dm)
+			if !approximated(m) {
+				c.cache.Put(key, copyMatches(m))
+			}
+			if dm != nil {
+				dm.Cache = CacheMiss
+			}
+		}
+	}
+	c.applyAliases(m)
+	c.applyMetadata(m)
+	if dm != nil {
+		dm.Matches = len(m)
+	}
+	return m
+}
+
+// matchContent reports instances of the supplied content, which has had its
+// markup stripped, in the corpus. doc is the tokenized content, or nil if it
+// must be tokenized. The work done is recorded in dm, unless it is nil.
+func (c *Classifier) matchContent(in []byte, doc *document, dm *DocumentMetrics) Matches {
+	deadline := c.deadline()
+	if doc == nil {
+		start := dm.now()
+		doc = c.tokenize(in)
+		if dm != nil {
+			dm.Tokenize = time.Since(start)
+		}
+	}
+	if dm != nil {
+		dm.Tokens = len(doc.Tokens)
+	}
+	var ms Matches
+	if c.maxTokens > 0 && len(doc.Tokens) > c.maxTokens {
+		ms = c.matchWindows(in, doc, dm, deadline)
+	} else {
+		ms = c.matchDocument(in, doc, dm, deadline)
+	}
+	if len(ms) == 0 {
+		ms = findProprietary(in, doc)
+	}
+	c.flagPatents(ms, doc)
+	flagConditions(ms, doc)
+	c.flagVersions(ms, doc)
+	linkChoices(in, ms)
+	assignIDs(ms, doc)
+	return ms
+}
+
+// matchDocument reports instances of the tokenized content in the corpus,
+// approximating the matches found after the deadline, unless it is zero.
+func (c *Classifier) matchDocument(in []byte, doc *document, dm *DocumentMetrics, deadline time.Time) Matches {
+	id := c.generateIndexedDocument(doc, false)
+	defer id.release()
+	id.content = in
+	id.metrics = dm
+	id.deadline = deadline
+	refs := withGrants(findReferences(in, id, c.licenses), doc)
+
+	var ms Matches
+	if firstPass := c.firstPass(id); len(firstPass) == 0 {
+		ms = refs
+	} else {
+		ms = resolve(c.candidates(id, firstPass), refs, c.topK, c.overlap)
+	}
+	if c.partial && !id.expired() {
+		if partials := c.partialMatches(id, ms); len(partials) > 0 {
+			ms = append(ms, partials...)
+			sort.Sort(ms)
+		}
+	}
+	return ms
+}
 
+// firstPass returns the corpus entries whose token frequencies are similar
+// enough to those of the target to be worth searching for in it.
+func (c *Classifier) firstPass(id *indexedDocument) map[string]*indexedDocument {
+	defer id.metrics.record(PhaseSearchset, id.metrics.now())
 	firstPass := make(map[string]*indexedDocument)
+	present := newTokenSet(id)
+	floor := c.searchThreshold()
+	var langs map[string]bool
+	detected := false
 	for l, d := range c.docs {
+		if lang := VariantLanguage(l); lang != "" {
+			if !detected {
+				langs, detected = c.languages(id), true
+			}
+			if langs != nil && !langs[lang] {
+				continue
+			}
+		}
+		if !c.plausible(present, d) {
+			continue
+		}
 		sim := id.tokenSimilarity(d)
-		if sim >= c.threshold {
+		if sim >= floor {
 			firstPass[l] = d
 		}
 	}
+	return firstPass
+}
 
-	if len(firstPass) == 0 {
-		return nil
-	}
-
+// candidates returns the potential matches of the supplied corpus entries in
+// the target, which may overlap.
+func (c *Classifier) candidates(id *indexedDocument, firstPass map[string]*indexedDocument) Matches {
+	dm := id.metrics
+	start := dm.now()
 	// Perform the expensive work of generating a searchset to look for token runs.
-	id.generateSearchSet(c.q)
+	id.generateTargetSearchSet(c.q)
 
 	var candidates Matches
-	for l, d := range firstPass {
-		matches := c.findPotentialMatches(d.s, id.s, c.threshold)
+	for _, l := range sortedNames(firstPass) {
+		d := firstPass[l]
+		matches := c.budgeted(l, c.findPotentialMatches(d.s, id.s, c.searchThreshold()))
+		dm.record(PhaseSearchset, start)
+		if dm != nil {
+			dm.Entries++
+			dm.Candidates += len(matches)
+		}
 		for _, m := range matches {
 			startIndex := m.TargetStart
 			endIndex := m.TargetEnd
-			conf, startOffset, endOffset := c.score(l, id, d, startIndex, endIndex)
-			if conf >= c.threshold && (endIndex-startIndex-startOffset-endOffset) > 0 {
+			if id.expired() {
+				if a := c.approximate(l, id, d, startIndex, endIndex); a != nil {
+					candidates = append(candidates, a)
+				}
+				continue
+			}
+			conf, startOffset, endOffset := c.score(l, id, d, startIndex, endIndex, d.maxDistance)
+			if conf < c.threshold && id.expired() {
+				// The diff may have been cut short by the deadline.
+				if a := c.approximate(l, id, d, startIndex, endIndex); a != nil {
+					candidates = append(candidates, a)
+				}
+				continue
+			}
+			if conf >= c.threshold && (endIndex-startIndex-startOffset-endOffset) > 0 && c.acceptShort(l, id, d, startIndex+startOffset, endIndex-endOffset, conf) {
 				candidates = append(candidates, &Match{
 					Name:            LicenseName(l),
 					MatchType:       detectionType(l),
-					Confidence:      conf,
-					StartLine:       id.Tokens[startIndex+startOffset].Line,
-					EndLine:         id.Tokens[endIndex-endOffset-1].Line,
-					StartTokenIndex: id.Tokens[startIndex+startOffset].Index,
-					EndTokenIndex:   id.Tokens[endIndex-endOffset-1].Index,
+					Category:        LicenseCategory(LicenseName(l)),
+					Variant:         l,
+					Language:        VariantLanguage(l),
+					Confidence:      c.calibrate(l, d, conf),
+					StartLine:       int(id.Tokens[startIndex+startOffset].Line),
+					EndLine:         int(id.Tokens[endIndex-endOffset-1].Line),
+					StartTokenIndex: int(id.Tokens[startIndex+startOffset].Index),
+					EndTokenIndex:   int(id.Tokens[endIndex-endOffset-1].Index),
 				})
 			}
 
 		}
+		start = dm.now()
 	}
+	return candidates
+}
+
+// resolve selects the candidates to report, discarding those overlapping
+// better matches as the strategy decides, and adds the references not
+// covered by them. The retained
+// matches record the best of the other candidates of their region, up to topK
+// licenses in all.
+func resolve(candidates, refs Matches, topK int, strategy OverlapStrategy) Matches {
 	sort.Sort(candidates)
-	retain := make([]bool, len(candidates))
-	for i, c := range candidates {
-		// Filter out overlapping licenses based primarily on confidence. Since
-		// the candidates slice is ordered by confidence, we look for overlaps and
-		// decide if we retain the record c.
-
-		// For each candidate, only add it to the report unless we have a
-		// higher-quality hit that contains these lines. In the case of two
-		// licenses having overlap, we consider 'token density' to break ties. If a
-		// less confident match of a larger license has more matching tokens than a
-		// perfect match of a smaller license, we want to keep that. This handles
-		// licenses that include another license as a subtext. NPL contains MPL
-		// as a concrete example.
-
-		keep := true
-		proposals := make(map[int]bool)
-		for j, o := range candidates {
-			if j == i {
-				break
-			}
-			// Make sure to only check containment on licenses that are still in consideration at this point.
-			if contains(c, o) && retain[j] {
-				// The license here can override a previous detection, but that isn't sufficient to be kept
-				// on its own. Consider the licenses Xnet, MPL-1.1 and NPL-1.1 in a file that just has MPL-1.1.
-				// The confidence rating on NPL-1.1 will cause Xnet to not be retained, which is correct, but it
-				// shouldn't be retained if the token confidence for MPL is higher than NPL since the NPL-specific
-				// bits are missing.
-
-				ctoks := float64(c.EndTokenIndex - c.StartTokenIndex)
-				otoks := float64(o.EndTokenIndex - o.StartTokenIndex)
-				cconf := ctoks * c.Confidence
-				oconf := otoks * o.Confidence
-
-				// If the two licenses are exactly the same confidence, that means we
-				// have an ambiguous detect and should retain both, so the caller can
-				// see and resolve the situation.
-				if cconf > oconf {
-					proposals[j] = false
-				} else if oconf > cconf {
-					keep = false
-				}
-			} else if overlaps(c, o) && retain[j] {
-				keep = false
-			}
+	retain := retainCandidates(candidates, strategy)
 
-		}
+	var out Matches
+	for i, keep := range retain {
 		if keep {
-			retain[i] = true
-			for p, v := range proposals {
-				retain[p] = v
-			}
+			out = append(out, candidates[i])
 		}
 	}
+	out = consolidateVariants(out, candidates)
+	if strategy == OverlapReportAll {
+		flagOverlaps(out)
+	}
+	addAlternatives(out, candidates, topK)
+	linkExceptions(out)
+	out = addReferences(out, refs)
+	sort.Sort(out)
+	return out
+}
 
+// consolidateVariants merges the retained matches of a license that cover the
+// same region into the first of them, which is the best, and records the
+// scores of all the candidates of the license in the region, including those
+// discarded in favor of the retained match.
+func consolidateVariants(retained, candidates Matches) Matches {
 	var out Matches
-	for i, keep := range retain {
-		if keep {
-			out = append(out, candidates[i])
+	merged := make([]bool, len(retained))
+	for i, m := range retained {
+		if merged[i] {
+			continue
+		}
+		for j := i + 1; j < len(retained); j++ {
+			if o := retained[j]; o.Name == m.Name && sameRegion(m, o) {
+				merged[j] = true
+			}
+		}
+		best := make(map[string]VariantScore)
+		for _, o := range candidates {
+			if o.Name != m.Name || !sameRegion(m, o) {
+				continue
+			}
+			if v, ok := best[o.Variant]; !ok || o.Confidence > v.Confidence {
+				best[o.Variant] = VariantScore{Variant: o.Variant, MatchType: o.MatchType, Confidence: o.Confidence}
+			}
 		}
+		m.Variants = make([]VariantScore, 0, len(best))
+		for _, v := range best {
+			m.Variants = append(m.Variants, v)
+		}
+		sort.Slice(m.Variants, func(i, j int) bool {
+			vi, vj := m.Variants[i], m.Variants[j]
+			if vi.Confidence != vj.Confidence {
+				return vi.Confidence > vj.Confidence
+			}
+			// The retained match comes first among variants of equal
+			// confidence.
+			if (vi.Variant == m.Variant) != (vj.Variant == m.Variant) {
+				return vi.Variant == m.Variant
+			}
+			return vi.Variant < vj.Variant
+		})
+		out = append(out, m)
 	}
 	return out
 }
 
+// sameRegion returns true if the lines covered by a and b overlap.
+func sameRegion(a, b *Match) bool {
+	return overlaps(a, b) || overlaps(b, a)
+}
+
+// sortedNames returns the names of the supplied corpus entries in order, so
+// that they are visited in the same order on every run.
+func sortedNames(docs map[string]*indexedDocument) []string {
+	names := make([]string, 0, len(docs))
+	for n := range docs {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// linkExceptions associates each exception match with the license it most
+// likely modifies. Exceptions normally follow the license or header they apply
+// to, so the closest match ending before the exception is preferred, falling
+// back to the closest match that follows it.
+func linkExceptions(matches Matches) {
+	for _, e := range matches {
+		if e.MatchType != exceptionType {
+			continue
+		}
+		var before, after *Match
+		for _, m := range matches {
+			if m.MatchType == exceptionType {
+				continue
+			}
+			if m.EndLine <= e.StartLine {
+				if before == nil || m.EndLine > before.EndLine {
+					before = m
+				}
+			} else if m.StartLine >= e.EndLine {
+				if after == nil || m.StartLine < after.StartLine {
+					after = m
+				}
+			}
+		}
+		switch {
+		case before != nil:
+			e.BaseLicense = before.Name
+		case after != nil:
+			e.BaseLicense = after.Name
+		}
+	}
+}
+
 // Classifier provides methods for identifying open source licenses in text
 // content.
+//
+// A Classifier is safe for concurrent use. Matching keeps all of its
+// intermediate state (the indexed target, searchset, diffs and scores) local
+// to the call, and reads the corpus and configuration from a snapshot taken
+// after they last changed, without locking, so once the corpus is loaded a
+// Classifier can be shared by goroutines calling Match concurrently without
+// contention. Adding content or changing the configuration doesn't wait for
+// the matches in progress, which finish with the snapshot they started with,
+// and applies to the matches started afterward.
+//
+// Classification is deterministic: the same corpus and content always produce
+// the same matches in the same order, regardless of the order the corpus was
+// loaded in, the goroutine doing the matching or the randomized iteration
+// order of Go maps. Candidates are considered in the order of their corpus
+// entry names, and matches that tie on confidence and position are ordered
+// by license name, so a compliance audit can be reproduced exactly.
 type Classifier struct {
-	tc        *TraceConfiguration
+	core
+	// mu guards the core of the classifier, which is written by adding
+	// content and the setters.
+	mu sync.RWMutex
+	// snap holds the snapshot of the core that matches run on, or a nil
+	// *Classifier after a change until the next match takes a new one.
+	snap atomic.Value
+	// reloadMu is held by Reload.
+	reloadMu sync.Mutex
+}
+
+// core is the corpus and configuration of a classifier, everything matching
+// reads.
+type core struct {
 	dict      *dictionary
 	docs      map[string]*indexedDocument
 	threshold float64
 	q         int // The value of q for q-grams in this corpus
+
+	// docFreq counts the number of corpus documents each token appears in.
+	docFreq map[tokenID]int
+	// weighted enables weighting of edits by token distinctiveness.
+	weighted bool
+	// format is the markup stripped from content before matching.
+	format Format
+	// minStrings is the minimum length of the printable runs extracted from
+	// binary content before matching, or zero if it is matched as is.
+	minStrings int
+	// maxDocSize is the size of the largest content MatchFrom reads, or zero
+	// if there is no limit.
+	maxDocSize int64
+	// exemptions are the phrases exempt from equivalent-word normalization,
+	// keyed by license or corpus entry name.
+	exemptions map[string][]*exemption
+	// equivalences are the equivalence classes and stopwords applied to
+	// tokenized text, or nil if there are none.
+	equivalences *equivalences
+	// anchors are the phrases matches of short licenses must contain, keyed
+	// by license or corpus entry name.
+	anchors map[string][]string
+	// metadata is the metadata declared with SetEntryMetadata, keyed by
+	// license or corpus entry name.
+	metadata map[string]*EntryMetadata
+	// issues are the problems found with corpus entries, keyed by name.
+	issues map[string]*CorpusIssue
+	// origins are the files the corpus entries were read from, keyed by
+	// name, or empty for entries added with AddContent.
+	origins map[string]string
+	// budgets limit the scoring of the corpus entries of each match type.
+	budgets map[string]Budget
+	// parallelism is the number of goroutines loading the corpus and
+	// scanning files, or GOMAXPROCS if it is zero.
+	parallelism int
+	// aliases are the organization-specific aliases of licenses, keyed by
+	// license name.
+	aliases map[string]Alias
+	// maxTokens is the size of the windows documents with more tokens are
+	// matched in, or zero to match documents whole.
+	maxTokens int
+	// noPrefilter disables the prefilter, so that tests can check it doesn't
+	// change the matches.
+	noPrefilter bool
+	// topK is the number of licenses reported for the region of each match.
+	topK int
+	// overlap decides which of overlapping candidates are reported.
+	overlap OverlapStrategy
+	// rules are the diff rules consulted after the built-in ones, in order.
+	rules []namedRule
+	// edits are the costs of the kinds of word edits.
+	edits EditWeights
+	// calibration rescales the confidence of matches, if set.
+	calibration Calibration
+	// partial enables the matching of portions of license texts.
+	partial bool
+	// timeBudget is the time allowed for matching a document, or zero for
+	// no limit.
+	timeBudget time.Duration
+	// logger receives the diagnostics of the classifier, if set.
+	logger Logger
+	// metrics receives the metrics of the documents matched, if set.
+	metrics Metrics
+	// filter restricts the entries added to the corpus, if set.
+	filter *corpusFilter
+	// sources are the sources of the corpus, loaded again by Reload. tuneQ
+	// has Reload tune the q-gram length of the new corpus.
+	sources []corpusSource
+	tuneQ   bool
+
+	// cache holds the results of contents previously matched, if set.
+	cache Cache
+	// scope is the digest of the corpus and configuration included in the
+	// keys of the cache, versions the other versions of each versioned
+	// license and licenses the names of the licenses of the corpus, keyed by
+	// their lower case form. They are only set in snapshots.
+	scope    []byte
+	versions map[string]map[string]*otherVersion
+	licenses map[string]string
 }
 
-// NewClassifier creates a classifier with an empty corpus.
+// NewClassifier creates a classifier with an empty corpus. It is equivalent
+// to New(WithThreshold(threshold)), except that the threshold isn't
+// validated.
 func NewClassifier(threshold float64) *Classifier {
-	classifier := &Classifier{
-		tc:        new(TraceConfiguration),
+	classifier := &Classifier{core: core{
 		dict:      newDictionary(),
 		docs:      make(map[string]*indexedDocument),
+		docFreq:   make(map[tokenID]int),
 		threshold: threshold,
 		q:         computeQ(threshold),
+		edits:     DefaultEditWeights,
+
+		exemptions: make(map[string][]*exemption),
+		anchors:    make(map[string][]string),
+		metadata:   make(map[string]*EntryMetadata),
+		issues:     make(map[string]*CorpusIssue),
+		origins:    make(map[string]string),
+		budgets:    make(map[string]Budget),
+	}}
+	for name, phrases := range defaultExemptions {
+		classifier.SetNormalizationExemptions(name, phrases)
+	}
+	for name, phrases := range defaultAnchors {
+		classifier.SetAnchors(name, phrases)
 	}
 	return classifier
 }
@@ -186,38 +608,164 @@ func NewClassifier(threshold float64) *Classifier {
 // classifier.
 func (c *Classifier) LoadLicenses(dir string) error {
 	var files []string
+	found := false
 	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
+			if path == dir {
+				return err
+			}
 			return nil
 		}
 		if !strings.HasSuffix(path, "txt") {
 			return nil
 		}
+		found = true
+		if !c.filter.retains(corpusName(path)) {
+			return nil
+		}
 		files = append(files, path)
 		return nil
 	})
 	if err != nil {
+		return corpusLoadError(err)
+	}
+	if !found {
+		return &Error{Kind: ErrNoLicenseData, Context: "no license files in " + dir}
+	}
+	if err := c.loadFiles(files, ioutil.ReadFile); err != nil {
 		return err
 	}
+	c.addSource(func(c *Classifier) error { return c.LoadLicenses(dir) })
+	return nil
+}
 
-	for _, f := range files {
-		_, name := path.Split(f)
-		name = strings.Replace(name, ".txt", "", 1)
-		b, err := ioutil.ReadFile(f)
-		if err != nil {
+// LoadLicensesFS adds the licenses of the supplied file system to the corpus
+// of the classifier, as LoadLicenses does for a directory. It serves corpora
+// embedded in binaries, such as that of the licenses package.
+func (c *Classifier) LoadLicensesFS(fsys fs.FS) error {
+	if err := c.loadLicensesFS(fsys); err != nil {
+		return err
+	}
+	c.addSource(func(c *Classifier) error { return c.LoadLicensesFS(fsys) })
+	return nil
+}
+
+// loadLicensesFS adds the licenses of the file system to the corpus without
+// recording it as a source.
+func (c *Classifier) loadLicensesFS(fsys fs.FS) error {
+	var files []string
+	found := false
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil && path == "." {
 			return err
 		}
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if !strings.HasSuffix(path, "txt") {
+			return nil
+		}
+		found = true
+		if !c.filter.retains(corpusName(path)) {
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		return corpusLoadError(err)
+	}
+	if !found {
+		return &Error{Kind: ErrNoLicenseData, Context: "no license files in the file system"}
+	}
+	read := func(name string) ([]byte, error) { return fs.ReadFile(fsys, name) }
+	return c.loadFiles(files, read)
+}
+
+// loadFiles adds the license files with the supplied paths, read with read,
+// to the corpus.
+func (c *Classifier) loadFiles(files []string, read func(name string) ([]byte, error)) error {
+	// Reading and tokenizing the files is independent, but their content
+	// must be added to the corpus in order for the dictionary to be
+	// deterministic.
+	docs := make([]*document, len(files))
+	contents := make([][]byte, len(files))
+	metas := make([]*EntryMetadata, len(files))
+	errs := make([]error, len(files))
+	next := make(chan int)
+	c.mu.RLock()
+	eq := c.equivalences
+	c.mu.RUnlock()
+	var wg sync.WaitGroup
+	for w := 0; w < c.workers(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				b, err := read(files[i])
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+				if metas[i], err = readMetadata(files[i], read); err != nil {
+					errs[i] = err
+					continue
+				}
+				contents[i] = []byte(trimExtraneousTrailingText(string(b)))
+				docs[i] = eq.apply(tokenize(contents[i]))
+			}
+		}()
+	}
+	for i := range files {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
 
-		content := trimExtraneousTrailingText(string(b))
-		c.AddContent(name, []byte(content))
+	for i, f := range files {
+		if errs[i] != nil {
+			return corpusLoadError(errs[i])
+		}
+		c.addContent(corpusName(f), f, contents[i], docs[i], metas[i])
 	}
 	return nil
 }
 
+// corpusName returns the name of the corpus entry of a license file.
+func corpusName(file string) string {
+	_, name := path.Split(file)
+	return strings.Replace(name, ".txt", "", 1)
+}
+
+// workers returns the number of goroutines to use for parallel work.
+func (c *Classifier) workers() int {
+	if c.parallelism > 0 {
+		return c.parallelism
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
 // SetTraceConfiguration installs a tracing configuration for the classifier.
+//
+// Deprecated: use SetLogger.
 func (c *Classifier) SetTraceConfiguration(in *TraceConfiguration) {
-	c.tc = in
-	c.tc.init()
+	defer c.update()()
+	c.logger = nil
+	if in != nil {
+		in.init()
+		c.logger = in
+	}
+}
+
+// SetTokenWeighting enables or disables weighting of edits by the
+// distinctiveness of the words involved. When enabled, a mismatch on a word
+// that appears in few corpus documents ("apache", "affero") reduces the
+// confidence of a match more than a mismatch on a word common to most
+// licenses, which improves the separation between sibling licenses.
+func (c *Classifier) SetTokenWeighting(enabled bool) {
+	defer c.update()()
+	c.weighted = enabled
+	c.updateMaxDistances()
 }
 
 // Match finds matches within an unknown text. This will not modify the contents
@@ -226,22 +774,70 @@ func (c *Classifier) Match(in []byte) Matches {
 	return c.match(in)
 }
 
-// MatchFrom finds matches within the read content.
+// MatchTokenized finds matches within a document tokenized with Tokenize,
+// which spares tokenizing content again that was tokenized for other
+// purposes. The matches are those Match finds in the content of the
+// document, except that markup isn't stripped, whatever the input format of
+// the classifier, since the document was tokenized with it. The
+// equivalences of the classifier are applied to documents tokenized without
+// them.
+func (c *Classifier) MatchTokenized(d *TokenizedDocument) Matches {
+	s := c.snapshot()
+	doc := d.doc
+	if d.eq != s.equivalences {
+		doc = s.equivalences.apply(doc)
+	}
+	return s.cachedMatch(d.content, doc)
+}
+
+// MatchFrom finds matches within the read content. It fails with
+// ErrDocumentTooLarge if the content is larger than the limit set with
+// SetMaxDocumentSize, and with ErrUnsupportedEncoding if it is UTF-16 or
+// UTF-32 text.
 func (c *Classifier) MatchFrom(in io.Reader) (Matches, error) {
+	limit := c.snapshot().maxDocSize
+	if limit > 0 {
+		in = io.LimitReader(in, limit+1)
+	}
 	b, err := ioutil.ReadAll(in)
 	if err != nil {
 		return nil, fmt.Errorf("classifier couldn't read: %w", err)
 	}
+	if limit > 0 && int64(len(b)) > limit {
+		return nil, &Error{Kind: ErrDocumentTooLarge, Context: fmt.Sprintf("more than %d bytes", limit)}
+	}
+	if err := checkEncoding(b); err != nil {
+		return nil, err
+	}
 	return c.Match(b), nil
 }
 
+// SetMaxDocumentSize limits the size of the content MatchFrom reads to n
+// bytes. Zero, the default, reads content of any size.
+func (c *Classifier) SetMaxDocumentSize(n int64) {
+	defer c.update()()
+	c.maxDocSize = n
+}
+
+const exceptionType = "Exception"
+
 func detectionType(in string) string {
 	if strings.Index(in, ".header") != -1 {
 		return "Header"
 	}
+	if isException(in) {
+		return exceptionType
+	}
 	return "License"
 }
 
+// isException returns true if the corpus entry is a standalone license
+// exception, such as Classpath-exception-2.0, rather than a license that
+// incorporates one, such as GPL-2.0-with-classpath-exception.
+func isException(in string) bool {
+	return strings.Contains(in, "-exception") && !strings.Contains(in, "-with-")
+}
+
 // LicenseName produces the output name for a license, removing the internal structure
 // of the filename in use.
 func LicenseName(in string) string {
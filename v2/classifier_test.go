@@ -21,12 +21,15 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"testing/iotest"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/google/go-cmp/cmp"
 )
 
 type scenario struct {
@@ -250,12 +253,388 @@ func TestMatchFrom(t *testing.T) {
 	}
 }
 
+func TestMatchTokenized(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard Google classifier: %v", err)
+	}
+	files, err := getScenarioFilenames()
+	if err != nil {
+		t.Fatalf("encountered error walking scenarios directory: %v", err)
+	}
+	for _, f := range files {
+		s := readScenario(f)
+		checkMatches(t, c.MatchTokenized(Tokenize(s.data)), f, s.expected)
+	}
+
+	// A document can be classified repeatedly, including from the cache of
+	// the matches of its content.
+	b, err := ioutil.ReadFile(filepath.Join("licenses", "Apache-2.0.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := Tokenize(b)
+	want := c.Match(b)
+	c.SetCache(NewLRUCache(10))
+	for i := 0; i < 3; i++ {
+		if got := c.MatchTokenized(d); !cmp.Equal(got, want) {
+			t.Errorf("MatchTokenized() #%d: diff (-want +got):\n%s", i, cmp.Diff(want, got))
+		}
+	}
+}
+
+func TestCreativeCommonsVariants(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(baseLicenses, "CC-BY-*.txt"))
+	if err != nil {
+		t.Fatalf("couldn't find Creative Commons licenses: %v", err)
+	}
+
+	for _, f := range files {
+		name := strings.TrimSuffix(filepath.Base(f), ".txt")
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			t.Fatalf("couldn't read %s: %v", f, err)
+		}
+		checkMatches(t, c.Match(b), f, []string{name})
+	}
+
+	// Stripping the NonCommercial element changes the license, so the
+	// modified text must not be reported as the original variant.
+	b, err := ioutil.ReadFile(filepath.Join(baseLicenses, "CC-BY-NC-SA-4.0.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read license: %v", err)
+	}
+	stripped := bytes.ReplaceAll(b, []byte("NonCommercial"), nil)
+	for _, m := range c.Match(stripped) {
+		if m.Name == "CC-BY-NC-SA-4.0" {
+			t.Errorf("Match(stripped CC-BY-NC-SA-4.0) = %v, want no CC-BY-NC-SA-4.0 match", spew.Sdump(m))
+		}
+	}
+}
+
+func TestPDFExtractedText(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+
+	// Text copied out of PDFs and word processors picks up ligatures,
+	// typographic quotes and dashes, and non-breaking spaces. None of these
+	// should cost any confidence.
+	pdf := strings.NewReplacer(
+		"fi", "\ufb01",
+		"fl", "\ufb02",
+		"ff", "\ufb00",
+		`"`, "\u201c",
+		"'", "\u2019",
+		"-", "\u2011",
+		"; ", ";\u00a0",
+		", ", ",\u2009",
+		"...", "\u2026",
+	)
+	for _, name := range []string{"Apache-2.0", "BSD-3-Clause", "MIT", "MPL-2.0"} {
+		b, err := ioutil.ReadFile(filepath.Join(baseLicenses, name+".txt"))
+		if err != nil {
+			t.Fatalf("couldn't read license: %v", err)
+		}
+		m := c.Match([]byte(pdf.Replace(string(b))))
+		checkMatches(t, m, name, []string{name})
+		for _, l := range m {
+			if l.Name == name && l.Confidence != 1.0 {
+				t.Errorf("Match(%s) confidence = %v, want 1.0", name, l.Confidence)
+			}
+		}
+	}
+}
+
+// gizmoLicense is a license written in Chinese, as commonly found in the
+// EULAs of vendored SDKs.
+const gizmoLicense = `小工具软件许可协议
+
+本协议是您与小工具公司之间关于使用本软件的法律协议。在遵守本协议条款的前提下，
+公司授予您非独占的、不可转让的许可，允许您在您的设备上安装和使用本软件。
+未经公司书面同意，您不得出租、出售、分发或以其他方式转让本软件，也不得对本软件
+进行反向工程、反编译或反汇编。本软件按“原样”提供，不附带任何明示或暗示的担保。
+在任何情况下，公司均不对因使用本软件而产生的任何损害承担责任。
+`
+
+func TestIdeographicMatch(t *testing.T) {
+	c, err := New(WithCorpusContent("Gizmo-EULA", []byte(gizmoLicense)))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	for _, tt := range []struct {
+		desc string
+		in   string
+		want bool
+	}{
+		{desc: "verbatim", in: gizmoLicense, want: true},
+		{desc: "reflowed", in: strings.ReplaceAll(gizmoLicense, "，\n", "，"), want: true},
+		{desc: "with a changed clause", in: strings.Replace(gizmoLicense, "出租、出售、分发", "出售", 1), want: true},
+		{desc: "unrelated", in: "本软件由社区志愿者维护，欢迎提交问题和补丁，一起让它变得更好。", want: false},
+	} {
+		m := licenseMatches(c.Match([]byte(tt.in)))
+		if got := len(m) == 1 && m[0].Name == "Gizmo-EULA"; got != tt.want {
+			t.Errorf("Match() of the %s text = %v, want a match: %v", tt.desc, m, tt.want)
+		}
+	}
+}
+
+func TestExceptionMatches(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+
+	var in bytes.Buffer
+	for _, f := range []string{"GPL-2.0.header.txt", "Classpath-exception-2.0.txt"} {
+		b, err := ioutil.ReadFile(filepath.Join(baseLicenses, f))
+		if err != nil {
+			t.Fatalf("couldn't read license: %v", err)
+		}
+		in.Write(b)
+		in.WriteString("\n")
+	}
+
+	m := c.Match(in.Bytes())
+	checkMatches(t, m, "GPL-2.0 header with Classpath exception", []string{"Classpath-exception-2.0", "GPL-2.0"})
+	for _, e := range m {
+		if e.MatchType != "Exception" {
+			continue
+		}
+		if got, want := e.Expression(), "GPL-2.0 WITH Classpath-exception-2.0"; got != want {
+			t.Errorf("Expression() = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestLinkExceptions(t *testing.T) {
+	lic := &Match{Name: "Apache-2.0", MatchType: "License", StartLine: 1, EndLine: 200}
+	hdr := &Match{Name: "GPL-2.0", MatchType: "Header", StartLine: 300, EndLine: 310}
+	before := &Match{Name: "LLVM-exception", MatchType: "Exception", StartLine: 201, EndLine: 215}
+	after := &Match{Name: "Font-exception-2.0", MatchType: "Exception", StartLine: 290, EndLine: 298}
+	alone := &Match{Name: "Bison-exception-2.2", MatchType: "Exception", StartLine: 1, EndLine: 10}
+
+	linkExceptions(Matches{hdr, after, lic, before})
+	if got, want := before.Expression(), "Apache-2.0 WITH LLVM-exception"; got != want {
+		t.Errorf("Expression() = %q, want %q", got, want)
+	}
+	if got, want := after.Expression(), "Apache-2.0 WITH Font-exception-2.0"; got != want {
+		t.Errorf("Expression() = %q, want %q", got, want)
+	}
+
+	linkExceptions(Matches{alone})
+	if got, want := alone.Expression(), "Bison-exception-2.2"; got != want {
+		t.Errorf("Expression() = %q, want %q", got, want)
+	}
+	if got, want := hdr.Expression(), "GPL-2.0"; got != want {
+		t.Errorf("Expression() = %q, want %q", got, want)
+	}
+}
+
+func TestMatchTiesAreDeterministic(t *testing.T) {
+	text := []byte("Redistribution of this software is permitted provided that this notice is retained in all copies and that the name of the author is not used to endorse derived products.")
+	for i := 0; i < 20; i++ {
+		c := NewClassifier(defaultThreshold)
+		// Alternate the order the identical entries are added in.
+		names := []string{"Zeta", "Alpha", "Dup_b", "Dup_a"}
+		if i%2 == 1 {
+			names = []string{"Dup_a", "Dup_b", "Alpha", "Zeta"}
+		}
+		for _, n := range names {
+			c.AddContent(n, text)
+		}
+
+		m := c.Match(text)
+		var got []string
+		for _, l := range m {
+			got = append(got, l.Name)
+		}
+		// The variants of Dup are consolidated into a single match.
+		if want := []string{"Alpha", "Dup", "Zeta"}; !cmp.Equal(got, want) {
+			t.Fatalf("run %d: Match() = %v, want %v", i, got, want)
+		}
+		wantVariants := []VariantScore{
+			{Variant: "Dup_a", MatchType: "License", Confidence: 1},
+			{Variant: "Dup_b", MatchType: "License", Confidence: 1},
+		}
+		if m[1].Variant != "Dup_a" || !cmp.Equal(m[1].Variants, wantVariants) {
+			t.Fatalf("run %d: Match() variants = %s %v, want Dup_a %v", i, m[1].Variant, m[1].Variants, wantVariants)
+		}
+		e, err := c.Explain(text, m[1])
+		if err != nil {
+			t.Fatalf("run %d: Explain() failed: %v", i, err)
+		}
+		if e.Variant != "Dup_a" {
+			t.Fatalf("run %d: Explain() used variant %s, want Dup_a", i, e.Variant)
+		}
+	}
+}
+
+func TestMatchConsolidatesVariants(t *testing.T) {
+	text := "Redistribution of this software is permitted provided that this notice is retained in all copies and that the name of the author is not used to endorse derived products."
+	header := "Redistribution of this software is permitted provided that this notice is retained"
+	c := NewClassifier(defaultThreshold)
+	c.AddContent("Notice", []byte(text))
+	c.AddContent("Notice_alt", []byte(strings.Replace(text, "derived products", "derived works", 1)))
+	c.AddContent("Notice.header", []byte(header))
+
+	m := c.Match([]byte(text))
+	if len(m) != 1 {
+		t.Fatalf("Match() = %v, want a single match", spew.Sdump(m))
+	}
+	if m[0].Name != "Notice" || m[0].MatchType != "License" || m[0].Variant != "Notice" || m[0].Confidence != 1 {
+		t.Errorf("Match() = %s %s variant %s confidence %v, want the Notice license text", m[0].Name, m[0].MatchType, m[0].Variant, m[0].Confidence)
+	}
+	var got []string
+	for i, v := range m[0].Variants {
+		got = append(got, v.Variant)
+		if i > 0 && v.Confidence > m[0].Variants[i-1].Confidence {
+			t.Errorf("variants aren't ordered by confidence: %v", m[0].Variants)
+		}
+	}
+	if want := []string{"Notice", "Notice.header", "Notice_alt"}; !cmp.Equal(got, want) {
+		t.Errorf("Match() variants = %v, want %v", got, want)
+	}
+}
+
+// TestMatchDeterminism checks that the scenarios produce exactly the same
+// matches when matched repeatedly and concurrently, and with a corpus loaded
+// in a different order.
+func TestMatchDeterminism(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	files, err := getScenarioFilenames()
+	if err != nil {
+		t.Fatalf("encountered error walking scenarios directory: %v", err)
+	}
+	var inputs [][]byte
+	for _, f := range files {
+		inputs = append(inputs, readScenario(f).data)
+	}
+
+	reversed := NewClassifier(defaultThreshold)
+	var names []string
+	for n := range c.docs {
+		names = append(names, n)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	for _, n := range names {
+		b, err := ioutil.ReadFile(filepath.Join(baseLicenses, n+".txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		reversed.AddContent(n, []byte(trimExtraneousTrailingText(string(b))))
+	}
+
+	want := make([]Matches, len(inputs))
+	for i, in := range inputs {
+		want[i] = c.Match(in)
+	}
+	for i, in := range inputs {
+		if diff := cmp.Diff(want[i], reversed.Match(in)); diff != "" {
+			t.Errorf("Match() with the corpus loaded in reverse order differs (-want +got):\n%s", diff)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for j := range inputs {
+				// Each goroutine starts at a different input so that the
+				// inputs are matched concurrently with different ones.
+				i := (j + g*len(inputs)/4) % len(inputs)
+				if diff := cmp.Diff(want[i], c.Match(inputs[i])); diff != "" {
+					t.Errorf("concurrent Match() differs (-want +got):\n%s", diff)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func benchmarkScenarios(b *testing.B) (*Classifier, [][]byte) {
+	c, err := classifier()
+	if err != nil {
+		b.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	files, err := getScenarioFilenames()
+	if err != nil {
+		b.Fatalf("encountered error walking scenarios directory: %v", err)
+	}
+	var data [][]byte
+	for _, f := range files {
+		data = append(data, readScenario(f).data)
+	}
+	return c, data
+}
+
+func BenchmarkMatch(b *testing.B) {
+	c, data := benchmarkScenarios(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Match(data[i%len(data)])
+	}
+}
+
+func BenchmarkMatchParallel(b *testing.B) {
+	c, data := benchmarkScenarios(b)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			c.Match(data[i%len(data)])
+		}
+	})
+}
+
+func BenchmarkLoadCorpus(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := classifier(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkCorpusMemory reports the heap retained by a classifier holding
+// the default corpus, which is most of the memory a service needs.
+func BenchmarkCorpusMemory(b *testing.B) {
+	var retained uint64
+	for i := 0; i < b.N; i++ {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		c, err := classifier()
+		if err != nil {
+			b.Fatal(err)
+		}
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		retained += after.HeapAlloc - before.HeapAlloc
+		runtime.KeepAlive(c)
+	}
+	b.ReportMetric(float64(retained)/float64(b.N), "heap-bytes")
+}
+
 // checkMatches diffs the resulting matches against the expected content and
 // sets test results.
 func checkMatches(t *testing.T, m Matches, f string, e []string) {
 	found := make(map[string]bool)
-	// Uniquify the licenses found
+	// Uniquify the licenses found. Scenarios describe the license texts
+	// expected in the content, so references to licenses by name are ignored.
 	for _, l := range m {
+		if l.MatchType == "Reference" {
+			continue
+		}
 		found[l.Name] = true
 	}
 
@@ -0,0 +1,249 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// A reviewer of a modified license usually wants to know which of its terms
+// changed rather than read a word diff of the whole text. The corpus entries
+// are divided into clauses when they're added, at their paragraphs and
+// numbered sections, so that the diff of a match can be attributed to them.
+
+// clause is a paragraph or numbered section of a corpus entry.
+type clause struct {
+	// label identifies the clause, such as "Section 4(b)" or "paragraph 2".
+	label string
+	// start is the index of the first token of the clause in the entry.
+	start int
+}
+
+var (
+	// keywordSection starts sections introduced by the word "section", as in
+	// the Creative Commons licenses.
+	keywordSection = regexp.MustCompile(`^(?i:section)\s+(\d+(?:\.\d+)*)(?:[\s.:]|$)`)
+	// numberedSection starts sections numbered as "4." or "3.1.".
+	numberedSection = regexp.MustCompile(`^(\d+(?:\.\d+)*)\.\s`)
+	// listItem starts the items of a section, such as "(b)", "b)" or "b.".
+	listItem = regexp.MustCompile(`^\(?([a-z]|[ivx]+)[.)]\s`)
+)
+
+// marker is the number of a section or list item that starts a clause.
+type marker struct {
+	text string
+	// indent is the column of a section number, or of the text following
+	// an item number, since lists of items are often aligned on their text,
+	// as with roman numerals.
+	indent int
+}
+
+// segmentClauses divides the content of a corpus entry, tokenized as doc,
+// into clauses. A clause starts after each blank line and at each line
+// starting with a section or item number. Clauses starting with a number are
+// labeled after it and the numbers of the sections and items it's nested
+// in, as told by indentation, and the others after their paragraph in the
+// content.
+func segmentClauses(content []byte, doc *document) []clause {
+	var clauses []clause
+	var nesting []marker
+	paragraph := 0
+	blank := true
+	t := 0
+	for i, line := range strings.Split(string(content), "\n") {
+		text := strings.TrimSpace(line)
+		if text == "" {
+			blank = true
+			continue
+		}
+		m := marker{indent: strings.Index(line, text)}
+		if s := keywordSection.FindStringSubmatch(text); s != nil && blank {
+			m.text, m.indent = s[1], -1
+		} else if s := numberedSection.FindStringSubmatch(text); s != nil {
+			m.text = s[1]
+		} else if s := listItem.FindStringSubmatch(text); s != nil && len(nesting) > 0 {
+			m.text, m.indent = s[1], m.indent+len(s[0])
+		} else if !blank {
+			continue
+		}
+		blank = false
+		paragraph++
+		label := fmt.Sprintf("paragraph %d", paragraph)
+		if m.text != "" {
+			// Sections and items nest in those indented less than them.
+			for len(nesting) > 0 && nesting[len(nesting)-1].indent >= m.indent {
+				nesting = nesting[:len(nesting)-1]
+			}
+			nesting = append(nesting, m)
+			label = "Section " + nesting[0].text
+			for _, n := range nesting[1:] {
+				label += "(" + n.text + ")"
+			}
+		}
+
+		for t < len(doc.Tokens) && doc.Tokens[t].Line < i+1 {
+			t++
+		}
+		if t == len(doc.Tokens) {
+			break
+		}
+		if n := len(clauses); n > 0 && clauses[n-1].start == t {
+			// The previous clause has no tokens of its own.
+			clauses = clauses[:n-1]
+		}
+		clauses = append(clauses, clause{label: label, start: t})
+	}
+	if len(clauses) > 0 {
+		clauses[0].start = 0
+	}
+	return clauses
+}
+
+// ClauseChangeKind describes how a clause of a license differs in the text
+// matched against it.
+type ClauseChangeKind int
+
+const (
+	// ClauseAltered is a clause with words added, removed or replaced.
+	ClauseAltered ClauseChangeKind = iota
+	// ClauseRemoved is a clause missing entirely from the matched text.
+	ClauseRemoved
+	// ClauseAdded is text of the matched text that isn't part of the license,
+	// following a clause.
+	ClauseAdded
+)
+
+func (k ClauseChangeKind) String() string {
+	switch k {
+	case ClauseAltered:
+		return "altered"
+	case ClauseRemoved:
+		return "removed"
+	case ClauseAdded:
+		return "added"
+	}
+	return "unknown"
+}
+
+// ClauseChange is a clause of a license that differs in the matched text.
+type ClauseChange struct {
+	// Clause identifies the clause, such as "Section 4(b)" or "paragraph 2".
+	// For added text, it is the clause the text follows.
+	Clause string
+	Kind   ClauseChangeKind
+	// Insertions and Deletions are the number of words the matched text
+	// lacks or adds in the clause.
+	Insertions, Deletions int
+}
+
+func (c ClauseChange) String() string {
+	if c.Kind == ClauseAdded {
+		return "text added after " + c.Clause
+	}
+	return c.Clause + " " + c.Kind.String()
+}
+
+// minAddedWords is the least number of words added between two clauses that
+// is reported as added text rather than as an alteration of the clause that
+// follows.
+const minAddedWords = 5
+
+// ClauseChanges attributes the diff of the explanation to the clauses of the
+// corpus entry it was scored against, and returns the clauses that differ
+// in order. Explanations of matches that aren't backed by a corpus text have
+// no clause changes.
+func (e *Explanation) ClauseChanges() []ClauseChange {
+	if len(e.clauses) == 0 {
+		return nil
+	}
+	changes := make([]ClauseChange, len(e.clauses))
+	equal := make([]int, len(e.clauses))
+	at := func(k int) int {
+		return sort.Search(len(e.clauses), func(i int) bool { return e.clauses[i].start > k }) - 1
+	}
+	// added holds the text added after each clause.
+	added := make([][]ClauseChange, len(e.clauses))
+	k := 0
+	for i, d := range e.Diffs {
+		n := wordLen(d.Text)
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			for j := 0; j < n; j++ {
+				equal[at(k)]++
+				k++
+			}
+		case diffmatchpatch.DiffInsert:
+			for j := 0; j < n; j++ {
+				changes[at(k)].Insertions++
+				k++
+			}
+		case diffmatchpatch.DiffDelete:
+			if k > 0 && (k >= e.KnownLength || e.clauses[at(k)].start == k) && n >= minAddedWords && !replaced(e.Diffs, i) {
+				prev := at(k - 1)
+				added[prev] = append(added[prev], ClauseChange{Clause: e.clauses[prev].label, Kind: ClauseAdded, Deletions: n})
+				continue
+			}
+			if k < e.KnownLength {
+				changes[at(k)].Deletions += n
+			} else {
+				changes[len(changes)-1].Deletions += n
+			}
+		}
+	}
+
+	var out []ClauseChange
+	for i, c := range changes {
+		c.Clause = e.clauses[i].label
+		if equal[i] == 0 && c.Insertions > 0 {
+			c.Kind = ClauseRemoved
+		}
+		if c.Insertions > 0 || c.Deletions > 0 {
+			out = append(out, c)
+		}
+		out = append(out, added[i]...)
+	}
+	return out
+}
+
+// replaced reports whether the deletion at index i of diffs is paired with
+// an insertion, replacing license words rather than adding to them.
+func replaced(diffs []diffmatchpatch.Diff, i int) bool {
+	return (i > 0 && diffs[i-1].Type == diffmatchpatch.DiffInsert) ||
+		(i+1 < len(diffs) && diffs[i+1].Type == diffmatchpatch.DiffInsert)
+}
+
+// Summary describes the match of the explanation and the clauses that differ
+// in it, such as "Apache-2.0 matched at 0.94; Section 4(b) altered".
+func (e *Explanation) Summary() string {
+	s := fmt.Sprintf("%s matched at %.2f", e.Match.Name, e.Match.Confidence)
+	if c := e.clauseSummary(); c != "" {
+		s += "; " + c
+	}
+	return s
+}
+
+// clauseSummary lists the clause changes of the explanation.
+func (e *Explanation) clauseSummary() string {
+	var changes []string
+	for _, c := range e.ClauseChanges() {
+		changes = append(changes, c.String())
+	}
+	return strings.Join(changes, ", ")
+}
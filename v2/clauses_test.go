@@ -0,0 +1,149 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSegmentClauses(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "paragraphs",
+			content: "Permission is granted.\nTo anyone.\n\n\nThe software is provided as is.\n",
+			want:    []string{"paragraph 1", "paragraph 2"},
+		},
+		{
+			name: "numbered sections",
+			content: `Terms of use.
+
+1. Definitions. Words mean things.
+
+2. Redistribution. You may redistribute, provided that:
+
+(a) You keep this notice; and
+
+(b) You mark your changes.
+A change is any edit.
+
+Unnumbered text ends the list.
+
+3. Warranty. There is none.
+`,
+			want: []string{"paragraph 1", "Section 1", "Section 2", "Section 2(a)", "Section 2(b)", "paragraph 6", "Section 3"},
+		},
+		{
+			name: "nested items",
+			content: `Section 1 -- Conditions.
+
+  a. Attribution.
+
+       1. If You Share, You must:
+
+                 i. name the creator;
+
+                ii. keep the notice.
+
+  b. Other rights.
+
+Section 2 -- Term.
+`,
+			want: []string{"Section 1", "Section 1(a)", "Section 1(a)(1)", "Section 1(a)(1)(i)", "Section 1(a)(1)(ii)", "Section 1(b)", "Section 2"},
+		},
+		{
+			name:    "reference to a section",
+			content: "Section 1 -- Term.\n\nRights end as in\nSection 1(a), unless cured.\n",
+			want:    []string{"Section 1", "paragraph 2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := tokenize([]byte(tt.content))
+			clauses := segmentClauses([]byte(tt.content), doc)
+			var got []string
+			for i, c := range clauses {
+				got = append(got, c.label)
+				if i == 0 && c.start != 0 {
+					t.Errorf("first clause starts at token %d, want 0", c.start)
+				}
+				if i > 0 && c.start <= clauses[i-1].start {
+					t.Errorf("clause %s starts at token %d, not after %s", c.label, c.start, clauses[i-1].label)
+				}
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("segmentClauses() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestClauseChanges(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(baseLicenses, "Apache-2.0.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read license: %v", err)
+	}
+	s := strings.Replace(string(b), "prominent notices stating that\nYou changed the files", "notices", 1)
+	s = s[:strings.Index(s, "6. Trademarks.")] + s[strings.Index(s, "7. Disclaimer"):]
+	s = strings.Replace(s, "8. Limitation", "You agree to send the Licensor a postcard of the Work every year.\n\n8. Limitation", 1)
+	in := []byte(s)
+
+	var e *Explanation
+	for _, m := range c.Match(in) {
+		if m.Name == "Apache-2.0" && m.MatchType == "License" {
+			if e, err = c.Explain(in, m); err != nil {
+				t.Fatalf("Explain() failed: %v", err)
+			}
+		}
+	}
+	if e == nil {
+		t.Fatalf("Match() found no Apache-2.0 license")
+	}
+	want := []ClauseChange{
+		{Clause: "Section 4(b)", Kind: ClauseAltered, Insertions: 7},
+		{Clause: "Section 6", Kind: ClauseRemoved, Insertions: 44},
+		{Clause: "Section 7", Kind: ClauseAdded, Deletions: 13},
+	}
+	if diff := cmp.Diff(want, e.ClauseChanges()); diff != "" {
+		t.Errorf("ClauseChanges() mismatch (-want +got):\n%s", diff)
+	}
+	if got, want := e.Summary(), "; Section 4(b) altered, Section 6 removed, text added after Section 7"; !strings.HasSuffix(got, want) {
+		t.Errorf("Summary() = %q, want it to end with %q", got, want)
+	}
+
+	ref := []byte("This project is licensed under the Apache License 2.0.\n")
+	ms := c.Match(ref)
+	if len(ms) != 1 {
+		t.Fatalf("Match() = %v, want a single reference", ms)
+	}
+	if e, err = c.Explain(ref, ms[0]); err != nil {
+		t.Fatalf("Explain() failed: %v", err)
+	}
+	if got := e.ClauseChanges(); got != nil {
+		t.Errorf("ClauseChanges() of a reference = %v, want none", got)
+	}
+}
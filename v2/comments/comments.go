@@ -0,0 +1,225 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package comments isolates the comments of source files so that license
+// headers can be classified without interference from the surrounding code.
+//
+// The extracted text has the same length and layout as the source file: code,
+// strings and comment markers are replaced with spaces and line breaks are
+// kept, so the line numbers and byte offsets of matches found in the
+// extracted text refer to the original file.
+//
+//	lang := comments.LanguageOf(filename)
+//	matches := c.Match(comments.Header(contents, lang))
+package comments
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Extract returns the text of all comments in the source. Source in an
+// unknown language is returned unchanged.
+func Extract(src []byte, lang Language) []byte {
+	return extract(src, lang, false)
+}
+
+// Header returns the text of the comments preceding the first line of code
+// in the source, which is where license headers are placed. A leading "#!"
+// line and XML declarations don't count as code. Source in an unknown
+// language is returned unchanged.
+func Header(src []byte, lang Language) []byte {
+	return extract(src, lang, true)
+}
+
+// extract blanks out everything but comment text in the source, stopping at
+// the first code if header is set.
+func extract(src []byte, lang Language, header bool) []byte {
+	st, ok := styles[lang]
+	if !ok {
+		return src
+	}
+
+	out := make([]byte, len(src))
+	for i, c := range src {
+		if c == '\n' {
+			out[i] = '\n'
+		} else {
+			out[i] = ' '
+		}
+	}
+	keep := func(start, end int) {
+		copy(out[start:end], src[start:end])
+	}
+
+	i := 0
+	if bytes.HasPrefix(src, []byte("#!")) {
+		i = lineEnd(src, 0)
+	}
+	for i < len(src) {
+		c := src[i]
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' {
+			i++
+			continue
+		}
+		if m := lineMarker(src, i, st); m != "" {
+			end := lineEnd(src, i)
+			keep(i+len(m), end)
+			i = end
+			continue
+		}
+		if start, end, n := blockMarker(src, i, st); n > 0 {
+			close := findBlockEnd(src, i+n, start, end, st.nested)
+			if close == -1 {
+				keep(i+n, len(src))
+				break
+			}
+			keep(i+n, close)
+			i = close + len(end)
+			continue
+		}
+		if d := docstring(src, i, st); d != "" {
+			close := bytes.Index(src[i+len(d):], []byte(d))
+			if close == -1 {
+				keep(i+len(d), len(src))
+				break
+			}
+			keep(i+len(d), i+len(d)+close)
+			i += len(d) + close + len(d)
+			continue
+		}
+		if lang == XML && bytes.HasPrefix(src[i:], []byte("<?")) {
+			if end := bytes.Index(src[i:], []byte("?>")); end != -1 {
+				i += end + 2
+				continue
+			}
+		}
+
+		// Anything else is code.
+		if header {
+			break
+		}
+		switch {
+		case strings.IndexByte(st.quotes, c) != -1:
+			i = stringEnd(src, i, c, true)
+		case strings.IndexByte(st.raw, c) != -1:
+			i = stringEnd(src, i, c, false)
+		default:
+			i++
+		}
+	}
+	return out
+}
+
+// lineEnd returns the index of the newline ending the line containing i, or
+// the length of src if the line isn't terminated.
+func lineEnd(src []byte, i int) int {
+	if n := bytes.IndexByte(src[i:], '\n'); n != -1 {
+		return i + n
+	}
+	return len(src)
+}
+
+// hasMarker returns the length of the marker m found at src[i:], or zero.
+// Markers starting with a newline must begin a line.
+func hasMarker(src []byte, i int, m string) int {
+	if strings.HasPrefix(m, "\n") {
+		if (i == 0 || src[i-1] == '\n') && bytes.HasPrefix(src[i:], []byte(m[1:])) {
+			return len(m) - 1
+		}
+		return 0
+	}
+	if bytes.HasPrefix(src[i:], []byte(m)) {
+		return len(m)
+	}
+	return 0
+}
+
+// lineMarker returns the line comment marker at src[i:], if any. Block
+// markers take precedence, since some start with a line marker, as in Lua.
+func lineMarker(src []byte, i int, st *style) string {
+	if _, _, n := blockMarker(src, i, st); n > 0 {
+		return ""
+	}
+	for _, m := range st.line {
+		if hasMarker(src, i, m) > 0 {
+			return m
+		}
+	}
+	return ""
+}
+
+// blockMarker returns the delimiters of the block comment starting at
+// src[i:], along with the length of its start marker.
+func blockMarker(src []byte, i int, st *style) (start, end string, n int) {
+	for _, b := range st.block {
+		if n := hasMarker(src, i, b[0]); n > 0 {
+			return b[0], b[1], n
+		}
+	}
+	return "", "", 0
+}
+
+// findBlockEnd returns the index of the end marker of a block comment whose
+// text starts at i, or -1 if the comment isn't terminated. End markers
+// starting with a newline include it, so the comment text excludes it.
+func findBlockEnd(src []byte, i int, start, end string, nested bool) int {
+	depth := 1
+	for ; i < len(src); i++ {
+		if nested && hasMarker(src, i, start) > 0 {
+			depth++
+			i += len(start) - 1
+			continue
+		}
+		if bytes.HasPrefix(src[i:], []byte(end)) {
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// docstring returns the docstring delimiter at src[i:], if any.
+func docstring(src []byte, i int, st *style) string {
+	for _, d := range st.docstrings {
+		if bytes.HasPrefix(src[i:], []byte(d)) {
+			return d
+		}
+	}
+	return ""
+}
+
+// stringEnd returns the index following the string literal starting at
+// src[i]. Strings with escapes end at the end of the line if they aren't
+// closed, so that a stray quote, such as a Rust lifetime, can't hide the
+// comments that follow.
+func stringEnd(src []byte, i int, quote byte, escapes bool) int {
+	for j := i + 1; j < len(src); j++ {
+		switch src[j] {
+		case '\\':
+			if escapes {
+				j++
+			}
+		case '\n':
+			if escapes {
+				return j
+			}
+		case quote:
+			return j + 1
+		}
+	}
+	return len(src)
+}
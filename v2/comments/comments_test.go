@@ -0,0 +1,157 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package comments
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+// fields returns the words of the text, to compare extracted text without
+// regard to the blanks that replace code.
+func fields(b []byte) string {
+	return strings.Join(strings.Fields(string(b)), " ")
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name   string
+		lang   Language
+		src    string
+		all    string
+		header string
+	}{
+		{
+			name:   "go",
+			lang:   Go,
+			src:    "// Copyright 2020\n/* Licensed under\n * the MIT License. */\npackage main\n\nvar s = \"// not a comment\" // trailing\nvar r = `/* raw */`\n",
+			all:    "Copyright 2020 Licensed under * the MIT License. trailing",
+			header: "Copyright 2020 Licensed under * the MIT License.",
+		},
+		{
+			name:   "python",
+			lang:   Python,
+			src:    "#!/usr/bin/env python\n# -*- coding: utf-8 -*-\n\"\"\"Licensed under the\nApache License.\"\"\"\nimport os\nx = '# no' # yes\n",
+			all:    "-*- coding: utf-8 -*- Licensed under the Apache License. yes",
+			header: "-*- coding: utf-8 -*- Licensed under the Apache License.",
+		},
+		{
+			name:   "shell",
+			lang:   Shell,
+			src:    "#!/bin/sh\n# SPDX-License-Identifier: MIT\necho \"#1\" # done\n",
+			all:    "SPDX-License-Identifier: MIT done",
+			header: "SPDX-License-Identifier: MIT",
+		},
+		{
+			name:   "ruby",
+			lang:   Ruby,
+			src:    "=begin\nLicensed under the MIT License.\n=end\nputs 'x' # ok\n",
+			all:    "Licensed under the MIT License. ok",
+			header: "Licensed under the MIT License.",
+		},
+		{
+			name:   "xml",
+			lang:   XML,
+			src:    "<?xml version=\"1.0\"?>\n<!-- Licensed under the\n     Apache License. -->\n<project><!-- inner --></project>\n",
+			all:    "Licensed under the Apache License. inner",
+			header: "Licensed under the Apache License.",
+		},
+		{
+			name:   "nested rust comments",
+			lang:   Rust,
+			src:    "/* outer /* inner */ still outer */\nfn f<'a>(x: &'a str) {} // after lifetimes\n",
+			all:    "outer /* inner */ still outer after lifetimes",
+			header: "outer /* inner */ still outer",
+		},
+		{
+			name:   "lua block comment",
+			lang:   Lua,
+			src:    "--[[ Licensed\nunder MIT ]]\n-- line\nlocal x = 1\n",
+			all:    "Licensed under MIT line",
+			header: "Licensed under MIT line",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			all := Extract([]byte(test.src), test.lang)
+			if len(all) != len(test.src) || strings.Count(string(all), "\n") != strings.Count(test.src, "\n") {
+				t.Errorf("Extract() changed the layout of the source: %q", all)
+			}
+			if got := fields(all); got != test.all {
+				t.Errorf("Extract() = %q, want %q", got, test.all)
+			}
+			if got := fields(Header([]byte(test.src), test.lang)); got != test.header {
+				t.Errorf("Header() = %q, want %q", got, test.header)
+			}
+		})
+	}
+}
+
+func TestExtractOffsets(t *testing.T) {
+	src := "package main // MIT\n"
+	out := Extract([]byte(src), Go)
+	if i := strings.Index(string(out), "MIT"); i != strings.Index(src, "MIT") {
+		t.Errorf("Extract() moved the comment text to offset %d, want %d", i, strings.Index(src, "MIT"))
+	}
+}
+
+func TestUnknownLanguage(t *testing.T) {
+	src := []byte("some text // with markers")
+	if got := Extract(src, Unknown); string(got) != string(src) {
+		t.Errorf("Extract(Unknown) = %q, want the source unchanged", got)
+	}
+}
+
+func TestLanguageOf(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     Language
+	}{
+		{filename: "main.go", want: Go},
+		{filename: "src/lib.RS", want: Rust},
+		{filename: "include/foo.hpp", want: C},
+		{filename: "Makefile", want: Shell},
+		{filename: "pkg/BUILD", want: Python},
+		{filename: "pom.xml", want: XML},
+		{filename: "LICENSE", want: Unknown},
+	}
+	for _, test := range tests {
+		if got := LanguageOf(test.filename); got != test.want {
+			t.Errorf("LanguageOf(%q) = %q, want %q", test.filename, got, test.want)
+		}
+	}
+}
+
+func TestClassifyHeader(t *testing.T) {
+	c := classifier.NewClassifier(.8)
+	if err := c.LoadLicenses("../licenses"); err != nil {
+		t.Fatalf("couldn't load licenses: %v", err)
+	}
+	// This file starts with the Apache 2.0 header.
+	src, err := ioutil.ReadFile("comments.go")
+	if err != nil {
+		t.Fatalf("couldn't read source: %v", err)
+	}
+	m := c.Match(Header(src, LanguageOf("comments.go")))
+	if len(m) != 1 || m[0].Name != "Apache-2.0" || m[0].MatchType != "Header" {
+		t.Fatalf("Match(Header()) = %v, want the Apache-2.0 header", m)
+	}
+	if m[0].StartLine != 3 || m[0].EndLine != 13 {
+		t.Errorf("Match(Header()) lines = %d-%d, want 3-13", m[0].StartLine, m[0].EndLine)
+	}
+}
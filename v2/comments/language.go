@@ -0,0 +1,170 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package comments
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Language is a programming language whose comments can be extracted.
+type Language string
+
+// Supported languages.
+const (
+	Unknown    Language = ""
+	C          Language = "c" // Also C++, Objective-C and other C-like languages.
+	CSharp     Language = "csharp"
+	Go         Language = "go"
+	Haskell    Language = "haskell"
+	Java       Language = "java"
+	JavaScript Language = "javascript" // Also TypeScript.
+	Kotlin     Language = "kotlin"
+	Lisp       Language = "lisp"
+	Lua        Language = "lua"
+	Perl       Language = "perl"
+	PHP        Language = "php"
+	Python     Language = "python"
+	R          Language = "r"
+	Ruby       Language = "ruby"
+	Rust       Language = "rust"
+	Scala      Language = "scala"
+	Shell      Language = "shell" // Also Makefiles, Dockerfiles and other hash-commented files.
+	SQL        Language = "sql"
+	Swift      Language = "swift"
+	XML        Language = "xml" // Also HTML.
+	YAML       Language = "yaml"
+)
+
+// style describes the syntax of comments and strings in a language.
+type style struct {
+	line   []string    // Markers starting comments that end with the line.
+	block  [][2]string // Start and end markers of comments spanning lines.
+	nested bool        // Block comments nest.
+	quotes string      // Characters delimiting strings with backslash escapes.
+	raw    string      // Characters delimiting strings without escapes.
+	// docstrings delimit strings that are treated as comments, as they hold
+	// the license text of Python modules.
+	docstrings []string
+}
+
+var (
+	bcpl = &style{line: []string{"//"}, block: [][2]string{{"/*", "*/"}}, quotes: `"'`}
+	hash = &style{line: []string{"#"}, quotes: `"'`}
+)
+
+var styles = map[Language]*style{
+	C:          bcpl,
+	CSharp:     bcpl,
+	Go:         {line: []string{"//"}, block: [][2]string{{"/*", "*/"}}, quotes: `"'`, raw: "`"},
+	Haskell:    {line: []string{"--"}, block: [][2]string{{"{-", "-}"}}, nested: true, quotes: `"`},
+	Java:       bcpl,
+	JavaScript: {line: []string{"//"}, block: [][2]string{{"/*", "*/"}}, quotes: "\"'`"},
+	Kotlin:     {line: []string{"//"}, block: [][2]string{{"/*", "*/"}}, nested: true, quotes: `"'`},
+	Lisp:       {line: []string{";"}, block: [][2]string{{"#|", "|#"}}, quotes: `"`},
+	Lua:        {line: []string{"--"}, block: [][2]string{{"--[[", "]]"}}, quotes: `"'`},
+	Perl:       {line: []string{"#"}, block: [][2]string{{"\n=pod", "\n=cut"}, {"\n=head1", "\n=cut"}}, quotes: `"'`},
+	PHP:        {line: []string{"//", "#"}, block: [][2]string{{"/*", "*/"}}, quotes: `"'`},
+	Python:     {line: []string{"#"}, quotes: `"'`, docstrings: []string{`"""`, `'''`}},
+	R:          hash,
+	Ruby:       {line: []string{"#"}, block: [][2]string{{"\n=begin", "\n=end"}}, quotes: `"'`},
+	Rust:       {line: []string{"//"}, block: [][2]string{{"/*", "*/"}}, nested: true, quotes: `"`},
+	Scala:      {line: []string{"//"}, block: [][2]string{{"/*", "*/"}}, nested: true, quotes: `"'`},
+	Shell:      hash,
+	SQL:        {line: []string{"--"}, block: [][2]string{{"/*", "*/"}}, quotes: `"'`},
+	Swift:      {line: []string{"//"}, block: [][2]string{{"/*", "*/"}}, nested: true, quotes: `"`},
+	XML:        {block: [][2]string{{"<!--", "-->"}}},
+	YAML:       hash,
+}
+
+var extensions = map[string]Language{
+	".c":     C,
+	".cc":    C,
+	".cpp":   C,
+	".cxx":   C,
+	".c++":   C,
+	".h":     C,
+	".hh":    C,
+	".hpp":   C,
+	".hxx":   C,
+	".m":     C,
+	".mm":    C,
+	".cs":    CSharp,
+	".go":    Go,
+	".hs":    Haskell,
+	".java":  Java,
+	".js":    JavaScript,
+	".jsx":   JavaScript,
+	".mjs":   JavaScript,
+	".ts":    JavaScript,
+	".tsx":   JavaScript,
+	".kt":    Kotlin,
+	".kts":   Kotlin,
+	".lisp":  Lisp,
+	".el":    Lisp,
+	".clj":   Lisp,
+	".scm":   Lisp,
+	".lua":   Lua,
+	".pl":    Perl,
+	".pm":    Perl,
+	".php":   PHP,
+	".py":    Python,
+	".pyi":   Python,
+	".bzl":   Python,
+	".r":     R,
+	".rb":    Ruby,
+	".rs":    Rust,
+	".scala": Scala,
+	".sh":    Shell,
+	".bash":  Shell,
+	".zsh":   Shell,
+	".mk":    Shell,
+	".cmake": Shell,
+	".tcl":   Shell,
+	".toml":  Shell,
+	".sql":   SQL,
+	".swift": Swift,
+	".xml":   XML,
+	".html":  XML,
+	".htm":   XML,
+	".xhtml": XML,
+	".svg":   XML,
+	".pom":   XML,
+	".xsd":   XML,
+	".xsl":   XML,
+	".yaml":  YAML,
+	".yml":   YAML,
+}
+
+var basenames = map[string]Language{
+	"makefile":       Shell,
+	"gnumakefile":    Shell,
+	"dockerfile":     Shell,
+	"cmakelists.txt": Shell,
+	"build":          Python,
+	"workspace":      Python,
+	"gemfile":        Ruby,
+	"rakefile":       Ruby,
+}
+
+// LanguageOf determines the language of a source file from its name, and
+// returns Unknown if it isn't supported.
+func LanguageOf(filename string) Language {
+	base := strings.ToLower(filepath.Base(filename))
+	if l, ok := basenames[base]; ok {
+		return l
+	}
+	return extensions[filepath.Ext(base)]
+}
@@ -0,0 +1,195 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package compat implements the API of the v1 license classifier,
+// github.com/google/licenseclassifier, on top of the v2 classifier, so that
+// programs written against v1 can switch engines without being rewritten.
+//
+//	l, err := compat.New(compat.DefaultConfidenceThreshold)
+//	...
+//	for _, m := range l.MultipleMatch(contents, true) {
+//		fmt.Println(m.Name, m.Confidence)
+//	}
+//
+// Match has the fields of the v1 stringclassifier.Match, so the matches of
+// either version convert to the other with a type conversion. A DualRun
+// classifies content with both versions and reports where they disagree,
+// so that a codebase can be migrated a piece at a time.
+package compat
+
+import (
+	"math"
+	"regexp"
+	"sort"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+// DefaultConfidenceThreshold is the default threshold of the v1 classifier.
+const DefaultConfidenceThreshold = 0.80
+
+// Match is a license found in content, as reported by the v1 classifier.
+type Match struct {
+	Name       string  // Name of the license matched
+	Confidence float64 // Confidence percentage
+	Offset     int     // The offset into the content the match was made
+	Extent     int     // The length from the offset into the content
+}
+
+// Matches is a list of matches, sortable in the order v1 reports them: by
+// decreasing confidence, then by name, offset and decreasing extent.
+type Matches []*Match
+
+func (m Matches) Len() int      { return len(m) }
+func (m Matches) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
+func (m Matches) Less(i, j int) bool {
+	if math.Abs(m[j].Confidence-m[i].Confidence) < math.SmallestNonzeroFloat64 {
+		if m[i].Name == m[j].Name {
+			if m[i].Offset > m[j].Offset {
+				return false
+			}
+			if m[i].Offset == m[j].Offset {
+				return m[i].Extent > m[j].Extent
+			}
+			return true
+		}
+		return m[i].Name < m[j].Name
+	}
+	return m[i].Confidence > m[j].Confidence
+}
+
+// Names returns an unsorted slice of the names of the matched licenses.
+func (m Matches) Names() []string {
+	var names []string
+	for _, n := range m {
+		names = append(names, n.Name)
+	}
+	return names
+}
+
+// License is a classifier with the methods of the v1 License, backed by a
+// v2 classifier loaded with the embedded license corpus.
+type License struct {
+	c *classifier.Classifier
+
+	// Threshold is the lowest confidence percentage acceptable for the
+	// classifier.
+	Threshold float64
+
+	// forbidden restricts the matches to forbidden licenses.
+	forbidden bool
+}
+
+// New creates a classifier with the supplied confidence threshold, loaded
+// with the license corpus embedded in the v2 licenses package and
+// configured by the supplied v2 options.
+func New(threshold float64, opts ...classifier.Option) (*License, error) {
+	c, err := classifier.NewDefaultClassifier(append(opts, classifier.WithThreshold(threshold))...)
+	if err != nil {
+		return nil, err
+	}
+	return &License{c: c, Threshold: threshold}, nil
+}
+
+// NewWithForbiddenLicenses creates a classifier that only reports the
+// licenses of the forbidden category, as the v1 classifier loaded with the
+// archive of forbidden licenses does.
+func NewWithForbiddenLicenses(threshold float64, opts ...classifier.Option) (*License, error) {
+	l, err := New(threshold, opts...)
+	if err != nil {
+		return nil, err
+	}
+	l.forbidden = true
+	return l, nil
+}
+
+// Classifier returns the v2 classifier the matches are found with, for
+// callers migrating to its API.
+func (c *License) Classifier() *classifier.Classifier {
+	return c.c
+}
+
+// WithinConfidenceThreshold returns true if the confidence value is above or
+// equal to the confidence threshold.
+func (c *License) WithinConfidenceThreshold(conf float64) bool {
+	return conf > c.Threshold || math.Abs(conf-c.Threshold) < math.SmallestNonzeroFloat64
+}
+
+// NearestMatch returns the match of the content with the highest
+// confidence, including the matches of license headers. Unlike v1, which
+// reports the nearest license however distant, it returns nil if no license
+// matches within the threshold.
+func (c *License) NearestMatch(contents string) *Match {
+	ms := c.MultipleMatch(contents, true)
+	if len(ms) == 0 {
+		return nil
+	}
+	return ms[0]
+}
+
+// MultipleMatch matches all licenses within the content. Matches of license
+// headers are only reported if includeHeaders is set. Identical matches are
+// reported once, and the matches are sorted as Matches are.
+//
+// The offset and extent of a match span the lines it was found on, in the
+// content as supplied; v1 reported them in its normalized form of the
+// content.
+func (c *License) MultipleMatch(contents string, includeHeaders bool) Matches {
+	lineStarts := []int{0}
+	for i, r := range contents {
+		if r == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	lineEnd := func(line int) int {
+		if line < len(lineStarts) {
+			return lineStarts[line] - 1
+		}
+		return len(contents)
+	}
+
+	seen := make(map[Match]bool)
+	var matches Matches
+	for _, m := range c.c.Match([]byte(contents)) {
+		if !c.WithinConfidenceThreshold(m.Confidence) {
+			continue
+		}
+		if !includeHeaders && m.MatchType == "Header" {
+			continue
+		}
+		if c.forbidden && m.Category != classifier.CategoryForbidden {
+			continue
+		}
+		v := Match{Name: m.Name, Confidence: m.Confidence}
+		if m.StartLine > 0 && m.StartLine <= len(lineStarts) {
+			v.Offset = lineStarts[m.StartLine-1]
+			v.Extent = lineEnd(m.EndLine) - v.Offset
+		}
+		if !seen[v] {
+			seen[v] = true
+			matches = append(matches, &v)
+		}
+	}
+	sort.Sort(matches)
+	return matches
+}
+
+// publicDomainRE is the expression v1 recognizes public domain notices with.
+var publicDomainRE = regexp.MustCompile("(?i)(this file )?is( in the)? public domain")
+
+// HasPublicDomainNotice reports whether the content has a public domain
+// notice, as v1 does.
+func (c *License) HasPublicDomainNotice(contents string) bool {
+	return publicDomainRE.FindString(contents) != ""
+}
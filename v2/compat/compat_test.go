@@ -0,0 +1,128 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compat
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newLicense(t *testing.T) *License {
+	t.Helper()
+	l, err := New(DefaultConfidenceThreshold)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	return l
+}
+
+func readLicense(t *testing.T, name string) string {
+	t.Helper()
+	b, err := ioutil.ReadFile(filepath.Join("..", "licenses", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestMultipleMatch(t *testing.T) {
+	l := newLicense(t)
+	mit := readLicense(t, "MIT.txt")
+	header := readLicense(t, "Apache-2.0.header.txt")
+	prefix := "// Some code.\n\n"
+	in := prefix + mit
+
+	want := Matches{{Name: "MIT", Confidence: 1, Offset: len(prefix), Extent: len(strings.TrimRight(mit, "\n"))}}
+	if diff := cmp.Diff(want, l.MultipleMatch(in, false)); diff != "" {
+		t.Errorf("MultipleMatch() mismatch (-want +got):\n%s", diff)
+	}
+
+	if got := l.MultipleMatch(header, false); len(got) != 0 {
+		t.Errorf("MultipleMatch(header, false) = %v, want no matches", got.Names())
+	}
+	if got := l.MultipleMatch(header, true); len(got) != 1 || got[0].Name != "Apache-2.0" {
+		t.Errorf("MultipleMatch(header, true) = %v, want Apache-2.0", got.Names())
+	}
+
+	if got := l.NearestMatch(in); got == nil || got.Name != "MIT" {
+		t.Errorf("NearestMatch() = %+v, want MIT", got)
+	}
+	if got := l.NearestMatch("Hello, world."); got != nil {
+		t.Errorf("NearestMatch() = %+v, want nil", got)
+	}
+}
+
+func TestNewWithForbiddenLicenses(t *testing.T) {
+	l, err := NewWithForbiddenLicenses(DefaultConfidenceThreshold)
+	if err != nil {
+		t.Fatalf("NewWithForbiddenLicenses() failed: %v", err)
+	}
+	in := readLicense(t, "MIT.txt") + "\n" + readLicense(t, "AGPL-3.0.txt")
+	if got, want := l.MultipleMatch(in, false).Names(), []string{"AGPL-3.0"}; !cmp.Equal(got, want) {
+		t.Errorf("MultipleMatch() = %v, want %v", got, want)
+	}
+}
+
+func TestWithinConfidenceThreshold(t *testing.T) {
+	l := &License{Threshold: 0.8}
+	for _, tt := range []struct {
+		conf float64
+		want bool
+	}{
+		{0.79, false},
+		{0.8, true},
+		{1, true},
+	} {
+		if got := l.WithinConfidenceThreshold(tt.conf); got != tt.want {
+			t.Errorf("WithinConfidenceThreshold(%v) = %v, want %v", tt.conf, got, tt.want)
+		}
+	}
+}
+
+func TestMatchesOrder(t *testing.T) {
+	ms := Matches{
+		{Name: "MIT", Confidence: 0.9, Offset: 10, Extent: 5},
+		{Name: "BSD-3-Clause", Confidence: 0.9, Offset: 50, Extent: 5},
+		{Name: "MIT", Confidence: 0.9, Offset: 10, Extent: 20},
+		{Name: "MIT", Confidence: 0.9, Offset: 0, Extent: 5},
+		{Name: "Apache-2.0", Confidence: 1, Offset: 100, Extent: 5},
+	}
+	sort.Sort(ms)
+	want := Matches{
+		{Name: "Apache-2.0", Confidence: 1, Offset: 100, Extent: 5},
+		{Name: "BSD-3-Clause", Confidence: 0.9, Offset: 50, Extent: 5},
+		{Name: "MIT", Confidence: 0.9, Offset: 0, Extent: 5},
+		{Name: "MIT", Confidence: 0.9, Offset: 10, Extent: 20},
+		{Name: "MIT", Confidence: 0.9, Offset: 10, Extent: 5},
+	}
+	if diff := cmp.Diff(want, ms); diff != "" {
+		t.Errorf("sort.Sort() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestHasPublicDomainNotice(t *testing.T) {
+	l := &License{}
+	if !l.HasPublicDomainNotice("This file is in the public domain.") {
+		t.Error("HasPublicDomainNotice() = false, want true")
+	}
+	if l.HasPublicDomainNotice("Copyright 2020 Google Inc.") {
+		t.Error("HasPublicDomainNotice() = true, want false")
+	}
+}
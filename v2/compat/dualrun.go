@@ -0,0 +1,142 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compat
+
+import (
+	"sort"
+	"sync"
+)
+
+// MatchFunc finds the licenses in content, as the MultipleMatch method of a
+// v1 License does. A v1 classifier is adapted by converting its matches:
+//
+//	v1, err := licenseclassifier.New(licenseclassifier.DefaultConfidenceThreshold)
+//	...
+//	f := func(contents string, includeHeaders bool) compat.Matches {
+//		var ms compat.Matches
+//		for _, m := range v1.MultipleMatch(contents, includeHeaders) {
+//			ms = append(ms, (*compat.Match)(m))
+//		}
+//		return ms
+//	}
+type MatchFunc func(contents string, includeHeaders bool) Matches
+
+// Disagreement is a difference between the licenses v1 and v2 found in the
+// same content.
+type Disagreement struct {
+	// V1 and V2 are the matches of each version.
+	V1, V2 Matches
+	// OnlyV1 and OnlyV2 are the names of the licenses only one version
+	// found, sorted.
+	OnlyV1, OnlyV2 []string
+}
+
+// Compare returns the disagreement between the matches of v1 and v2 in the
+// same content, or nil if they found the same licenses. The confidence and
+// location of the matches aren't compared, since the versions score and
+// locate matches differently.
+func Compare(v1, v2 Matches) *Disagreement {
+	in := func(ms Matches) map[string]bool {
+		names := make(map[string]bool)
+		for _, m := range ms {
+			names[m.Name] = true
+		}
+		return names
+	}
+	only := func(a, b map[string]bool) []string {
+		var names []string
+		for n := range a {
+			if !b[n] {
+				names = append(names, n)
+			}
+		}
+		sort.Strings(names)
+		return names
+	}
+	n1, n2 := in(v1), in(v2)
+	d := &Disagreement{V1: v1, V2: v2, OnlyV1: only(n1, n2), OnlyV2: only(n2, n1)}
+	if len(d.OnlyV1) == 0 && len(d.OnlyV2) == 0 {
+		return nil
+	}
+	return d
+}
+
+// DualRunStats counts the content a DualRun classified.
+type DualRunStats struct {
+	// Compared is the number of contents classified with both versions,
+	// and Disagreed the number of them they disagreed on.
+	Compared, Disagreed int
+}
+
+// DualRun classifies content with both the v1 classifier and the v2 engine,
+// reporting where they disagree. It returns the matches of v1 until it is
+// switched to v2 with UseV2, so that a codebase can be migrated once the
+// disagreements have been reviewed. It is safe for concurrent use.
+type DualRun struct {
+	v1     MatchFunc
+	v2     *License
+	report func(contents string, d *Disagreement)
+
+	mu    sync.Mutex
+	useV2 bool
+	stats DualRunStats
+}
+
+// NewDualRun returns a DualRun of the v1 classifier, adapted as a MatchFunc,
+// and the v2 one. The report function is called with each content the
+// versions disagree on; it may be nil.
+func NewDualRun(v1 MatchFunc, v2 *License, report func(contents string, d *Disagreement)) *DualRun {
+	return &DualRun{v1: v1, v2: v2, report: report}
+}
+
+// UseV2 selects the version whose matches are returned. Both versions keep
+// being run and compared.
+func (r *DualRun) UseV2(v2 bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.useV2 = v2
+}
+
+// Stats returns the number of contents classified and disagreed on so far.
+func (r *DualRun) Stats() DualRunStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stats
+}
+
+// MultipleMatch matches all licenses within the content with both versions,
+// reports their disagreement, if any, and returns the matches of the version
+// in use.
+func (r *DualRun) MultipleMatch(contents string, includeHeaders bool) Matches {
+	v1 := r.v1(contents, includeHeaders)
+	v2 := r.v2.MultipleMatch(contents, includeHeaders)
+	d := Compare(v1, v2)
+
+	r.mu.Lock()
+	r.stats.Compared++
+	if d != nil {
+		r.stats.Disagreed++
+	}
+	useV2 := r.useV2
+	r.mu.Unlock()
+
+	if d != nil && r.report != nil {
+		r.report(contents, d)
+	}
+	if useV2 {
+		return v2
+	}
+	return v1
+}
@@ -0,0 +1,76 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compat
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCompare(t *testing.T) {
+	mit := &Match{Name: "MIT", Confidence: 1}
+	weakMIT := &Match{Name: "MIT", Confidence: 0.85, Offset: 10}
+	bsd := &Match{Name: "BSD-3-Clause", Confidence: 1}
+	isc := &Match{Name: "ISC", Confidence: 1}
+
+	if d := Compare(Matches{mit}, Matches{weakMIT}); d != nil {
+		t.Errorf("Compare() = %+v, want agreement", d)
+	}
+	if d := Compare(nil, nil); d != nil {
+		t.Errorf("Compare(nil, nil) = %+v, want agreement", d)
+	}
+	got := Compare(Matches{mit, bsd}, Matches{isc, weakMIT})
+	want := &Disagreement{V1: Matches{mit, bsd}, V2: Matches{isc, weakMIT}, OnlyV1: []string{"BSD-3-Clause"}, OnlyV2: []string{"ISC"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Compare() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDualRun(t *testing.T) {
+	l := newLicense(t)
+	mit := readLicense(t, "MIT.txt")
+	// The v1 stand-in finds MIT in everything.
+	v1 := func(contents string, includeHeaders bool) Matches {
+		return Matches{{Name: "MIT", Confidence: 1}}
+	}
+	var reported []*Disagreement
+	r := NewDualRun(v1, l, func(contents string, d *Disagreement) {
+		reported = append(reported, d)
+	})
+
+	if got := r.MultipleMatch(mit, false).Names(); !cmp.Equal(got, []string{"MIT"}) {
+		t.Errorf("MultipleMatch(MIT) = %v, want MIT", got)
+	}
+	if len(reported) != 0 {
+		t.Errorf("MultipleMatch(MIT) reported %+v, want no disagreement", reported[0])
+	}
+
+	apache := readLicense(t, "Apache-2.0.txt")
+	if got := r.MultipleMatch(apache, false).Names(); !cmp.Equal(got, []string{"MIT"}) {
+		t.Errorf("MultipleMatch(Apache-2.0) = %v, want the v1 matches", got)
+	}
+	if len(reported) != 1 || !cmp.Equal(reported[0].OnlyV1, []string{"MIT"}) || !cmp.Equal(reported[0].OnlyV2, []string{"Apache-2.0"}) {
+		t.Errorf("MultipleMatch(Apache-2.0) reported %+v, want MIT only in v1 and Apache-2.0 only in v2", reported)
+	}
+
+	r.UseV2(true)
+	if got := r.MultipleMatch(apache, false).Names(); !cmp.Equal(got, []string{"Apache-2.0"}) {
+		t.Errorf("MultipleMatch(Apache-2.0) with v2 = %v, want the v2 matches", got)
+	}
+	if got, want := r.Stats(), (DualRunStats{Compared: 3, Disagreed: 2}); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
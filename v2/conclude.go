@@ -0,0 +1,364 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// EvidenceSource is the kind of file evidence of the license of a component
+// comes from. The sources are listed in order of precedence.
+type EvidenceSource int
+
+const (
+	// SourceLicenseFile is a license file of the component, such as LICENSE
+	// or COPYING. See FindLicenseFiles.
+	SourceLicenseFile EvidenceSource = iota
+	// SourceManifest is a package manifest declaring the license of the
+	// component, such as package.json or Cargo.toml.
+	SourceManifest
+	// SourceFile is any other file of the component, such as a source file
+	// with a license header or a README naming the license.
+	SourceFile
+)
+
+func (s EvidenceSource) String() string {
+	switch s {
+	case SourceLicenseFile:
+		return "license file"
+	case SourceManifest:
+		return "manifest"
+	case SourceFile:
+		return "file"
+	}
+	return "unknown"
+}
+
+// Evidence is what was found about the license of a component in one of its
+// files.
+type Evidence struct {
+	// File is the path of the file.
+	File   string
+	Source EvidenceSource
+	// Matches are the matches found in the file, for license files and other
+	// files.
+	Matches Matches
+	// Declared are the licenses a manifest declares, as named by the
+	// classifier. Several licenses are all required unless Choice is set.
+	Declared []string
+	// Choice is true if the manifest lets licensees choose among the
+	// Declared licenses, as "MIT OR Apache-2.0" does.
+	Choice bool
+}
+
+// Conclusion is the license concluded for a component by Conclude.
+type Conclusion struct {
+	// License is the concluded license, as an expression of license names
+	// such as "MIT", "Apache-2.0 AND BSD-3-Clause" or "GPL-2.0 OR MIT", or
+	// empty if no license could be concluded.
+	License string
+	// Confidence is the confidence in the conclusion, from 0 to 1.
+	Confidence float64
+	// Rationale records the evidence the conclusion rests on and the
+	// decisions taken, in order, for a reviewer to follow.
+	Rationale []string
+}
+
+// Confidence adjustments applied by Conclude, as multipliers of the
+// confidence of the evidence.
+const (
+	// declaredConfidence is the confidence of a conclusion resting on a
+	// manifest declaration alone, which nothing corroborates.
+	declaredConfidence = 0.8
+	// fileFactor scales the confidence of a conclusion resting on license
+	// texts and headers outside the license files.
+	fileFactor = 0.8
+	// referenceFactor scales the confidence of a conclusion resting on mere
+	// references to licenses by name.
+	referenceFactor = 0.5
+	// conflictFactor scales the confidence of a conclusion contradicted by
+	// evidence of lower precedence.
+	conflictFactor = 0.8
+)
+
+// Conclude concludes the license of a component from the evidence found in
+// its files, as a reviewer would, applying these rules in order:
+//
+//  1. The license texts found in the license files of the component, with
+//     the exceptions linked to them, are its license. If the component offers
+//     a choice between licenses, they are alternatives; otherwise all of them
+//     apply.
+//  2. Without license texts in license files, the licenses declared by the
+//     manifests are the license of the component, provided they agree.
+//  3. Otherwise, the license texts and headers found in the other files are
+//     its license.
+//  4. Otherwise, the licenses its files refer to by name are its license,
+//     with a much lower confidence.
+//
+// The confidence of a conclusion resting on license texts and headers is the
+// lowest of their confidences, scaled down when the evidence is weaker than
+// a license file. Evidence of lower precedence that agrees with the
+// conclusion is recorded in the rationale; evidence that contradicts it
+// lowers the confidence. Partial matches and permission grants aren't
+// relied on.
+func Conclude(evidence []*Evidence) *Conclusion {
+	c := &Conclusion{}
+	files := evidenceOf(evidence, SourceLicenseFile)
+	manifests := evidenceOf(evidence, SourceManifest)
+	others := evidenceOf(evidence, SourceFile)
+
+	declared, agree := declaredLicense(manifests)
+	if len(manifests) > 0 && !agree {
+		c.note("the manifests disagree: %s", describeDeclarations(manifests))
+	}
+
+	if terms, conf, from := licenseTerms(files, textMatch); len(terms) > 0 {
+		c.conclude(terms, conf, "license texts found in %s", from)
+		if declared != "" && agree {
+			c.corroborate("the declaration of "+strings.Join(evidenceFiles(manifests), ", "), declared)
+		}
+		if t, _, from := licenseTerms(others, textMatch); len(t) > 0 {
+			c.corroborate("the licenses of "+from, expression(t))
+		}
+		return c
+	}
+	if len(files) > 0 {
+		c.note("the license files %s contain no license text", strings.Join(evidenceFiles(files), ", "))
+	}
+	if declared != "" && agree {
+		c.License, c.Confidence = declared, declaredConfidence
+		c.note("concluded %s, declared by %s", declared, strings.Join(evidenceFiles(manifests), ", "))
+		if t, conf, from := licenseTerms(others, textMatch); len(t) > 0 {
+			if expression(t) == declared {
+				c.Confidence = declaredConfidence + (1-declaredConfidence)*conf
+				c.note("corroborated by the licenses of %s", from)
+			} else {
+				c.Confidence *= conflictFactor
+				c.note("contradicted by %s found in %s", expression(t), from)
+			}
+		}
+		return c
+	}
+	if terms, conf, from := licenseTerms(others, textMatch); len(terms) > 0 {
+		c.conclude(terms, conf*fileFactor, "license texts and headers found in %s", from)
+		return c
+	}
+	if terms, conf, from := licenseTerms(append(files, others...), referenceMatch); len(terms) > 0 {
+		c.conclude(terms, conf*referenceFactor, "references to licenses found in %s", from)
+		return c
+	}
+	c.note("no license evidence was found")
+	return c
+}
+
+// note adds a step to the rationale.
+func (c *Conclusion) note(format string, args ...interface{}) {
+	c.Rationale = append(c.Rationale, fmt.Sprintf(format, args...))
+}
+
+// conclude sets the license to the expression of terms.
+func (c *Conclusion) conclude(terms []string, confidence float64, format string, from string) {
+	c.License, c.Confidence = expression(terms), confidence
+	c.note("concluded %s from the "+format, c.License, from)
+}
+
+// corroborate records whether the license found by lower-precedence
+// evidence agrees with the conclusion, lowering the confidence if it
+// doesn't.
+func (c *Conclusion) corroborate(what, license string) {
+	if license == c.License {
+		c.note("corroborated by %s", what)
+		return
+	}
+	c.Confidence *= conflictFactor
+	c.note("contradicted by %s, %s; the license files take precedence", what, license)
+}
+
+// evidenceOf returns the evidence from the source.
+func evidenceOf(evidence []*Evidence, s EvidenceSource) []*Evidence {
+	var out []*Evidence
+	for _, e := range evidence {
+		if e.Source == s {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func evidenceFiles(evidence []*Evidence) []string {
+	var names []string
+	for _, e := range evidence {
+		names = append(names, e.File)
+	}
+	return names
+}
+
+// declaredLicense returns the expression of the licenses declared by the
+// manifests, and false if they don't all declare the same.
+func declaredLicense(manifests []*Evidence) (string, bool) {
+	var declared string
+	for i, m := range manifests {
+		d := declaredBy(m)
+		if i > 0 && d != declared {
+			return "", false
+		}
+		declared = d
+	}
+	return declared, true
+}
+
+// declaredBy returns the expression of the licenses declared by a manifest.
+func declaredBy(m *Evidence) string {
+	if len(m.Declared) == 0 {
+		return ""
+	}
+	names := append([]string(nil), m.Declared...)
+	sort.Strings(names)
+	if m.Choice && len(names) > 1 {
+		return expression([]string{"(" + strings.Join(names, " OR ") + ")"})
+	}
+	return expression(names)
+}
+
+func describeDeclarations(manifests []*Evidence) string {
+	var out []string
+	for _, m := range manifests {
+		d := declaredBy(m)
+		if d == "" {
+			d = "nothing"
+		}
+		out = append(out, fmt.Sprintf("%s declares %s", m.File, d))
+	}
+	return strings.Join(out, ", ")
+}
+
+// expression joins the terms of a license expression, which all apply.
+func expression(terms []string) string {
+	sorted := append([]string(nil), terms...)
+	sort.Strings(sorted)
+	if len(sorted) == 1 {
+		return strings.TrimSuffix(strings.TrimPrefix(sorted[0], "("), ")")
+	}
+	return strings.Join(sorted, " AND ")
+}
+
+// textMatch selects license texts and headers, and the exceptions and
+// proprietary terms that accompany them.
+func textMatch(m *Match) bool {
+	switch m.MatchType {
+	case "License", "Header", exceptionType, proprietaryType:
+		return true
+	}
+	return false
+}
+
+// referenceMatch selects references to licenses by name.
+func referenceMatch(m *Match) bool {
+	return m.MatchType == referenceType
+}
+
+// licenseTerms returns the terms of the license expression of the selected
+// matches of the evidence, all of which apply, with the lowest confidence of
+// the matches and the files they were found in. Exceptions linked to a
+// license are attached to it, and licenses offered as a choice form a single
+// term.
+func licenseTerms(evidence []*Evidence, selected func(*Match) bool) ([]string, float64, string) {
+	best := make(map[string]float64)
+	choices := make(map[string][]string)
+	var exceptions []*Match
+	var from []string
+	for _, e := range evidence {
+		found := false
+		for _, m := range e.Matches {
+			if !selected(m) {
+				continue
+			}
+			found = true
+			if m.MatchType == exceptionType && m.BaseLicense != "" {
+				exceptions = append(exceptions, m)
+				continue
+			}
+			if c, ok := best[m.Name]; !ok || m.Confidence < c {
+				best[m.Name] = m.Confidence
+			}
+			if len(m.Choice) > 0 {
+				choices[m.Name] = m.Choice
+			}
+		}
+		if found {
+			from = append(from, e.File)
+		}
+	}
+	for _, x := range exceptions {
+		c, ok := best[x.BaseLicense]
+		if !ok {
+			continue
+		}
+		delete(best, x.BaseLicense)
+		if x.Confidence < c {
+			c = x.Confidence
+		}
+		best[x.Expression()] = c
+		if ch, ok := choices[x.BaseLicense]; ok {
+			choices[x.Expression()] = ch
+		}
+	}
+	if len(best) == 0 {
+		return nil, 0, ""
+	}
+
+	// Licenses offered as alternatives to each other are grouped into a
+	// single term.
+	var terms []string
+	confidence := 1.0
+	grouped := make(map[string]bool)
+	for _, name := range sortedLicenses(best) {
+		if c := best[name]; c < confidence {
+			confidence = c
+		}
+		if grouped[name] {
+			continue
+		}
+		group := []string{name}
+		grouped[name] = true
+		for _, alt := range choices[name] {
+			for _, n := range sortedLicenses(best) {
+				if !grouped[n] && (n == alt || strings.HasPrefix(n, alt+" WITH ")) {
+					group = append(group, n)
+					grouped[n] = true
+				}
+			}
+		}
+		if len(group) == 1 {
+			terms = append(terms, name)
+			continue
+		}
+		sort.Strings(group)
+		terms = append(terms, "("+strings.Join(group, " OR ")+")")
+	}
+	return terms, confidence, strings.Join(from, ", ")
+}
+
+// sortedLicenses returns the names of the licenses, sorted.
+func sortedLicenses(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,146 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestConclude(t *testing.T) {
+	text := func(name string, conf float64) *Match {
+		return &Match{Name: name, MatchType: "License", Confidence: conf}
+	}
+	header := func(name string, conf float64) *Match {
+		return &Match{Name: name, MatchType: "Header", Confidence: conf}
+	}
+	ref := func(name string) *Match {
+		return &Match{Name: name, MatchType: referenceType, Confidence: 1}
+	}
+	license := func(file string, ms ...*Match) *Evidence {
+		return &Evidence{File: file, Source: SourceLicenseFile, Matches: ms}
+	}
+	source := func(file string, ms ...*Match) *Evidence {
+		return &Evidence{File: file, Source: SourceFile, Matches: ms}
+	}
+	manifest := func(file string, choice bool, licenses ...string) *Evidence {
+		return &Evidence{File: file, Source: SourceManifest, Declared: licenses, Choice: choice}
+	}
+	apache := text("Apache-2.0", 0.99)
+	apache.Choice = []string{"MIT"}
+	mit := text("MIT", 0.97)
+	mit.Choice = []string{"Apache-2.0"}
+
+	tests := []struct {
+		name       string
+		evidence   []*Evidence
+		license    string
+		confidence float64
+		rationale  string // a step of the rationale
+	}{
+		{
+			name:       "license file corroborated by manifest",
+			evidence:   []*Evidence{license("LICENSE", text("MIT", 0.98)), manifest("package.json", false, "MIT"), source("main.go", header("MIT", 0.9))},
+			license:    "MIT",
+			confidence: 0.98,
+			rationale:  "corroborated by the declaration of package.json",
+		},
+		{
+			name:       "license file contradicted by manifest",
+			evidence:   []*Evidence{license("LICENSE", text("MIT", 1)), manifest("package.json", false, "ISC")},
+			license:    "MIT",
+			confidence: conflictFactor,
+			rationale:  "contradicted by the declaration of package.json, ISC; the license files take precedence",
+		},
+		{
+			name:       "several license files",
+			evidence:   []*Evidence{license("LICENSE", text("MIT", 1)), license("LICENSE.bsd", text("BSD-3-Clause", 0.95))},
+			license:    "BSD-3-Clause AND MIT",
+			confidence: 0.95,
+			rationale:  "concluded BSD-3-Clause AND MIT from the license texts found in LICENSE, LICENSE.bsd",
+		},
+		{
+			name:       "choice",
+			evidence:   []*Evidence{license("LICENSE", apache, mit), manifest("Cargo.toml", true, "MIT", "Apache-2.0")},
+			license:    "Apache-2.0 OR MIT",
+			confidence: 0.97,
+			rationale:  "corroborated by the declaration of Cargo.toml",
+		},
+		{
+			name: "exception",
+			evidence: []*Evidence{license("COPYING", text("GPL-2.0", 0.99),
+				&Match{Name: "Classpath-exception-2.0", MatchType: exceptionType, BaseLicense: "GPL-2.0", Confidence: 0.96})},
+			license:    "GPL-2.0 WITH Classpath-exception-2.0",
+			confidence: 0.96,
+		},
+		{
+			name:       "manifest without license texts",
+			evidence:   []*Evidence{license("LICENSE", ref("MIT")), manifest("package.json", false, "MIT")},
+			license:    "MIT",
+			confidence: declaredConfidence,
+			rationale:  "the license files LICENSE contain no license text",
+		},
+		{
+			name:       "manifest corroborated by headers",
+			evidence:   []*Evidence{manifest("package.json", false, "MIT"), source("index.js", header("MIT", 0.9))},
+			license:    "MIT",
+			confidence: declaredConfidence + (1-declaredConfidence)*0.9,
+			rationale:  "corroborated by the licenses of index.js",
+		},
+		{
+			name:       "disagreeing manifests",
+			evidence:   []*Evidence{manifest("package.json", false, "MIT"), manifest("setup.cfg", false, "ISC"), source("a.js", header("MIT", 0.9)), source("b.js", header("MIT", 1))},
+			license:    "MIT",
+			confidence: 0.9 * fileFactor,
+			rationale:  "the manifests disagree: package.json declares MIT, setup.cfg declares ISC",
+		},
+		{
+			name:       "references",
+			evidence:   []*Evidence{source("README.md", ref("Apache-2.0"))},
+			license:    "Apache-2.0",
+			confidence: referenceFactor,
+			rationale:  "concluded Apache-2.0 from the references to licenses found in README.md",
+		},
+		{
+			name:      "nothing",
+			evidence:  []*Evidence{source("main.go", &Match{Name: "MIT", MatchType: partialType, Confidence: 0.9})},
+			rationale: "no license evidence was found",
+		},
+	}
+	for _, tt := range tests {
+		got := Conclude(tt.evidence)
+		if got.License != tt.license || math.Abs(got.Confidence-tt.confidence) > 1e-9 {
+			t.Errorf("%s: Conclude() = %q with confidence %v, want %q with confidence %v", tt.name, got.License, got.Confidence, tt.license, tt.confidence)
+		}
+		if tt.rationale != "" && !strings.Contains(strings.Join(got.Rationale, "\n"), tt.rationale) {
+			t.Errorf("%s: Conclude() rationale = %q, want a step %q", tt.name, got.Rationale, tt.rationale)
+		}
+	}
+}
+
+func TestConcludeMatches(t *testing.T) {
+	c := NewClassifier(defaultThreshold)
+	c.AddContent("Widget-1.0", []byte(versionedLicense))
+	c.AddContent("Gadget-1.0", []byte(gadgetLicense))
+	got := Conclude([]*Evidence{
+		{File: "LICENSE", Source: SourceLicenseFile, Matches: c.Match([]byte(versionedLicense))},
+		{File: "NOTICE", Source: SourceLicenseFile, Matches: c.Match([]byte(gadgetLicense))},
+		{File: "widget.gemspec", Source: SourceManifest, Declared: []string{"Widget-1.0", "Gadget-1.0"}},
+	})
+	if got.License != "Gadget-1.0 AND Widget-1.0" || got.Confidence != 1 {
+		t.Errorf("Conclude() = %q with confidence %v, want Gadget-1.0 AND Widget-1.0 with confidence 1 (rationale %q)", got.License, got.Confidence, got.Rationale)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// These tests exercise the concurrency guarantees of the Classifier. Run them
+// with -race to detect unsynchronized access.
+
+const concurrency = 8
+
+// concurrentInputs returns a few inputs with matches of different types.
+func concurrentInputs(t *testing.T) [][]byte {
+	t.Helper()
+	var out [][]byte
+	for _, f := range []string{"Apache-2.0.txt", "MIT.txt", "GPL-2.0.header.txt"} {
+		b, err := ioutil.ReadFile(filepath.Join(baseLicenses, f))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, b)
+	}
+	return append(out, []byte("Licensed under the MIT license, see LICENSE."))
+}
+
+func TestConcurrentMatch(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	inputs := concurrentInputs(t)
+	var want []Matches
+	for _, in := range inputs {
+		want = append(want, c.Match(in))
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan string, concurrency*len(inputs))
+	for g := 0; g < concurrency; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := range inputs {
+				// Each goroutine visits the inputs in a different order.
+				i = (i + g) % len(inputs)
+				if diff := cmp.Diff(want[i], c.Match(inputs[i])); diff != "" {
+					errs <- fmt.Sprintf("Match(input %d) mismatch (-want +got):\n%s", i, diff)
+				}
+				if _, err := c.Explain(inputs[i], want[i][0]); err != nil {
+					errs <- fmt.Sprintf("Explain(input %d) failed: %v", i, err)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for e := range errs {
+		t.Error(e)
+	}
+}
+
+func TestConcurrentMatchWhileModified(t *testing.T) {
+	c, err := New(WithCorpusDir(baseLicenses), WithCache(NewLRUCache(16)))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	inputs := concurrentInputs(t)
+
+	var wg sync.WaitGroup
+	for g := 0; g < concurrency; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for _, in := range inputs {
+				if m := c.Match(in); len(m) == 0 {
+					t.Errorf("goroutine %d: Match() found nothing", g)
+				}
+			}
+			c.LicenseDB()
+			c.CorpusVersion()
+			c.ValidateCorpus()
+		}(g)
+	}
+	// Changes to the corpus and configuration don't disturb the matches in
+	// progress, which run on a snapshot, including those replacing the
+	// derived state of the corpus entries.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < concurrency; i++ {
+			c.AddContent(fmt.Sprintf("Extra-%d", i), []byte("this is extra corpus content that matches nothing in the inputs"))
+			c.SetTokenWeighting(i%2 == 0)
+			c.SetInputFormat(FormatPlain)
+			c.SetScoringBudget("Header", Budget{MaxCandidates: 100 + i})
+			c.SetNormalizationExemptions(fmt.Sprintf("Extra-%d", i), []string{"extra"})
+			c.SetTraceConfiguration(&TraceConfiguration{})
+			c.SetQGramSize(i%2 + 1)
+			c.SetEditWeights(DefaultEditWeights)
+		}
+	}()
+	wg.Wait()
+
+	if got := len(c.LicenseDB().Search("Extra")); got != concurrency {
+		t.Errorf("corpus has %d extra entries, want %d", got, concurrency)
+	}
+}
+
+func TestConcurrentScanSessions(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	files := []string{
+		filepath.Join(baseLicenses, "MIT.txt"),
+		filepath.Join(baseLicenses, "ISC.txt"),
+		filepath.Join(baseLicenses, "Zlib.txt"),
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 3; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s := c.NewScanSession(files, 2)
+			if err := s.Start(); err != nil {
+				t.Errorf("Start() failed: %v", err)
+				return
+			}
+			for _, r := range s.Wait() {
+				if r.Err != nil || len(r.Matches) == 0 {
+					t.Errorf("%s: matches %v, error %v; want a match", r.Filename, r.Matches, r.Err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,74 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Reports of the licenses in a codebase are read for what they require of
+// its distributors. Two conditions that are easily overlooked, because they
+// aren't met by keeping the license with the source, are recognized in the
+// matched text and flagged on each match: restrictions on the use of the
+// names and trademarks of the licensors, and notices that must accompany
+// distributions in binary form.
+
+var (
+	// trademarkRestriction recognizes the clauses in normalized text.
+	trademarkRestriction = []*regexp.Regexp{
+		// BSD-3-Clause, Apache-1.1: "may be used to endorse or promote
+		// products derived from this software".
+		regexp.MustCompile(`\bused to endorse or promote products\b`),
+		// Apache-1.1, OpenSSL: "Products derived from this software may
+		// not be called "Apache"".
+		regexp.MustCompile(`\bproducts derived from this software may not be called\b`),
+		// Apache-2.0: "does not grant permission to use the trade names,
+		// trademarks"; MPL-2.0: "does not grant any rights in the
+		// trademarks".
+		regexp.MustCompile(`\bdoes not grant\b(?: \S+){0,6}? (?:trade ?names?|trademarks?)\b`),
+		regexp.MustCompile(`\bno (?:rights?|license|permission)\b(?: \S+){0,6}? (?:trade ?names?|trademarks?)\b`),
+	}
+	// binaryAttribution recognizes the clauses in normalized text.
+	binaryAttribution = []*regexp.Regexp{
+		// BSD-2-Clause: "Redistributions in binary form must reproduce
+		// the above copyright notice".
+		regexp.MustCompile(`\bredistributions? in binary form must reproduce\b`),
+		// OpenSSL: "Redistributions of any form whatsoever must retain
+		// the following acknowledgment".
+		regexp.MustCompile(`\bredistributions? of any form whatsoever must retain\b`),
+		// Apache-1.1: "The end-user documentation included with the
+		// redistribution, if any, must include the following
+		// acknowledgment".
+		regexp.MustCompile(`\bend ?user documentation included with the redistribution\b`),
+		// Apache-2.0: "must include a readable copy of the attribution
+		// notices contained within such NOTICE file".
+		regexp.MustCompile(`\bmust include a readable copy of the attribution notices\b`),
+	}
+)
+
+// flagConditions flags the matches whose text restricts the use of
+// trademarks or requires attribution in binary distributions.
+func flagConditions(ms Matches, doc *document) {
+	for _, m := range ms {
+		var words []string
+		for i := m.StartTokenIndex; i <= m.EndTokenIndex && i < len(doc.Tokens); i++ {
+			words = append(words, doc.Tokens[i].Text)
+		}
+		norm := strings.Join(words, " ")
+		m.TrademarkRestriction = matchesAny(trademarkRestriction, norm)
+		m.BinaryAttribution = matchesAny(binaryAttribution, norm)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestConditionFlags(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	tests := []struct {
+		description          string
+		file                 string
+		in                   string
+		matchType            string
+		trademark, attribute bool
+	}{
+		{"BSD-3-Clause", "BSD-3-Clause.txt", "", "License", true, true},
+		{"BSD-2-Clause", "BSD-2-Clause.txt", "", "License", false, true},
+		{"Apache-2.0", "Apache-2.0.txt", "", "License", true, true},
+		{"MPL-2.0", "MPL-2.0.txt", "", "License", true, false},
+		{"MIT", "MIT.txt", "", "License", false, false},
+		{"reference", "", "SPDX-License-Identifier: BSD-3-Clause", "Reference", false, false},
+	}
+	for _, tt := range tests {
+		in := []byte(tt.in)
+		if tt.file != "" {
+			if in, err = ioutil.ReadFile(filepath.Join(baseLicenses, tt.file)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var m *Match
+		for _, o := range c.Match(in) {
+			if o.MatchType == tt.matchType {
+				m = o
+			}
+		}
+		if m == nil {
+			t.Errorf("%s: Match() found no %s match", tt.description, tt.matchType)
+			continue
+		}
+		if m.TrademarkRestriction != tt.trademark || m.BinaryAttribution != tt.attribute {
+			t.Errorf("%s: trademark restriction %v, binary attribution %v; want %v, %v", tt.description, m.TrademarkRestriction, m.BinaryAttribution, tt.trademark, tt.attribute)
+		}
+	}
+}
+
+func TestConditionClauses(t *testing.T) {
+	tests := []struct {
+		in                   string
+		trademark, attribute bool
+	}{
+		{"neither the name of the university nor the names of its contributors may be used to endorse or promote products derived from this software", true, false},
+		{"this license does not grant permission to use the trade names trademarks service marks or product names of the licensor", true, false},
+		{"redistributions in binary form must reproduce the above copyright notice", false, true},
+		{"the enduser documentation included with the redistribution if any must include the following acknowledgment", false, true},
+		{"redistributions of source code must retain the above copyright notice", false, false},
+		{"declining to grant rights under trademark law for use of some trade names", false, false},
+	}
+	for _, tt := range tests {
+		if got := matchesAny(trademarkRestriction, tt.in); got != tt.trademark {
+			t.Errorf("trademark restriction of %q = %v, want %v", tt.in, got, tt.trademark)
+		}
+		if got := matchesAny(binaryAttribution, tt.in); got != tt.attribute {
+			t.Errorf("binary attribution of %q = %v, want %v", tt.in, got, tt.attribute)
+		}
+	}
+}
@@ -0,0 +1,416 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Hermetic build environments can't rely on a corpus directory being
+// installed next to the program. A CorpusSource supplies the corpus from
+// wherever it is kept, such as an archive embedded in the program or
+// downloaded from a server or a storage bucket, verified against a checksum.
+
+// CorpusSource supplies a license corpus.
+type CorpusSource interface {
+	// Corpus returns the license files of the corpus, laid out as
+	// LoadLicensesFS expects them.
+	Corpus(ctx context.Context) (fs.FS, error)
+}
+
+// LoadCorpus adds the licenses supplied by the source to the corpus of the
+// classifier. Reload fetches the corpus from the source again.
+func (c *Classifier) LoadCorpus(ctx context.Context, src CorpusSource) error {
+	fsys, err := src.Corpus(ctx)
+	if err != nil {
+		return corpusLoadError(err)
+	}
+	if err := c.loadLicensesFS(fsys); err != nil {
+		return err
+	}
+	c.addSource(func(c *Classifier) error { return c.LoadCorpus(context.Background(), src) })
+	return nil
+}
+
+// ParseCorpusSource returns the source of the corpus at the supplied
+// location: the http, https, gs or s3 URL of an archive, which must have the
+// checksum sum and is cached in cacheDir as RemoteCorpus does, a local zip,
+// tar or gzipped tar archive, which is verified against sum if it is set, or
+// a directory.
+func ParseCorpusSource(location, sum, cacheDir string) CorpusSource {
+	if u, err := url.Parse(location); err == nil {
+		switch u.Scheme {
+		case "http", "https", "gs", "s3":
+			return &RemoteCorpus{URL: location, SHA256: sum, CacheDir: cacheDir}
+		}
+	}
+	if fi, err := os.Stat(location); err == nil && fi.IsDir() {
+		return DirCorpus(location)
+	}
+	return &fileCorpus{name: location, sum: sum}
+}
+
+// fileCorpus is the source of a corpus archive in a local file, which is
+// read again on each load.
+type fileCorpus struct {
+	name, sum string
+}
+
+func (f *fileCorpus) Corpus(ctx context.Context) (fs.FS, error) {
+	b, err := ioutil.ReadFile(f.name)
+	if err != nil {
+		return nil, err
+	}
+	return ArchiveCorpus(b, f.sum).Corpus(ctx)
+}
+
+// DirCorpus returns the source of the corpus in the supplied directory.
+func DirCorpus(dir string) CorpusSource {
+	return dirCorpus(dir)
+}
+
+type dirCorpus string
+
+func (d dirCorpus) Corpus(ctx context.Context) (fs.FS, error) {
+	return os.DirFS(string(d)), nil
+}
+
+// ArchiveCorpus returns the source of the corpus in the supplied zip, tar or
+// gzipped tar archive, such as one embedded in the program. If sum is set,
+// the archive must have it as its hex-encoded SHA-256 checksum.
+//
+//	//go:embed corpus.zip
+//	var corpus []byte
+//	...
+//	c, err := classifier.New(classifier.WithCorpusSource(classifier.ArchiveCorpus(corpus, sum)))
+func ArchiveCorpus(archive []byte, sum string) CorpusSource {
+	return &archiveCorpus{archive: archive, sum: sum}
+}
+
+type archiveCorpus struct {
+	archive []byte
+	sum     string
+}
+
+func (a *archiveCorpus) Corpus(ctx context.Context) (fs.FS, error) {
+	if err := verifyChecksum(a.archive, a.sum); err != nil {
+		return nil, err
+	}
+	return openCorpusArchive(a.archive)
+}
+
+// RemoteCorpus is the source of a corpus archive downloaded from a server or
+// a storage bucket.
+type RemoteCorpus struct {
+	// URL locates the zip, tar or gzipped tar archive of the corpus. Besides
+	// http and https URLs, gs://bucket/object and s3://bucket/key name
+	// objects of Google Cloud Storage and Amazon S3 buckets, which are
+	// downloaded from the HTTPS endpoints of the services.
+	URL string
+	// SHA256 is the hex-encoded SHA-256 checksum the archive must have. It
+	// is required, since the corpus decides which licenses are found.
+	SHA256 string
+	// CacheDir is a directory to keep downloaded archives in, named after
+	// their checksum, so that they are only downloaded once.
+	CacheDir string
+	// Client downloads the archive. Private buckets need a client that
+	// authenticates its requests. If it is nil, http.DefaultClient is used.
+	Client *http.Client
+}
+
+// maxCorpusArchiveSize bounds the size of a downloaded corpus archive, and
+// maxCorpusEntrySize that of a file of a tar archive.
+var (
+	maxCorpusArchiveSize int64 = 256 << 20
+	maxCorpusEntrySize   int64 = maxArchiveEntrySize
+)
+
+// Corpus returns the files of the archive, downloading it unless it is
+// cached.
+func (r *RemoteCorpus) Corpus(ctx context.Context) (fs.FS, error) {
+	if r.SHA256 == "" {
+		return nil, &Error{Kind: ErrCorpusLoad, Context: fmt.Sprintf("corpus %s has no checksum", r.URL)}
+	}
+	cached := ""
+	if r.CacheDir != "" {
+		cached = filepath.Join(r.CacheDir, strings.ToLower(r.SHA256))
+		if b, err := ioutil.ReadFile(cached); err == nil && verifyChecksum(b, r.SHA256) == nil {
+			return openCorpusArchive(b)
+		}
+	}
+	b, err := r.download(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyChecksum(b, r.SHA256); err != nil {
+		return nil, err
+	}
+	fsys, err := openCorpusArchive(b)
+	if err != nil {
+		return nil, err
+	}
+	if cached != "" {
+		if err := writeCached(cached, b); err != nil {
+			return nil, err
+		}
+	}
+	return fsys, nil
+}
+
+// download fetches the archive.
+func (r *RemoteCorpus) download(ctx context.Context) ([]byte, error) {
+	u, err := corpusURL(r.URL)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxCorpusArchiveSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > maxCorpusArchiveSize {
+		return nil, fmt.Errorf("GET %s: archive is larger than %d bytes", u, maxCorpusArchiveSize)
+	}
+	return b, nil
+}
+
+// corpusURL returns the HTTP URL of the archive at the supplied URL.
+func corpusURL(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	object := strings.TrimPrefix(u.Path, "/")
+	switch u.Scheme {
+	case "http", "https":
+		return raw, nil
+	case "gs":
+		return "https://storage.googleapis.com/" + u.Host + "/" + object, nil
+	case "s3":
+		return "https://" + u.Host + ".s3.amazonaws.com/" + object, nil
+	}
+	return "", fmt.Errorf("unsupported corpus URL %q", raw)
+}
+
+// writeCached writes the archive to the cache atomically, so that
+// concurrent builds never read a partial archive.
+func writeCached(name string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), name)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// verifyChecksum returns an error unless the content has the supplied
+// hex-encoded SHA-256 checksum, or the checksum is empty.
+func verifyChecksum(b []byte, sum string) error {
+	if sum == "" {
+		return nil
+	}
+	h := sha256.Sum256(b)
+	if got := hex.EncodeToString(h[:]); !strings.EqualFold(got, sum) {
+		return &Error{Kind: ErrCorpusLoad, Context: fmt.Sprintf("archive has checksum %s, want %s", got, sum)}
+	}
+	return nil
+}
+
+// openCorpusArchive returns the files of a zip, tar or gzipped tar archive.
+func openCorpusArchive(b []byte) (fs.FS, error) {
+	if bytes.HasPrefix(b, []byte("PK\x03\x04")) {
+		return zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	}
+	var r io.Reader = bytes.NewReader(b)
+	if bytes.HasPrefix(b, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		r = gz
+	}
+	fsys := make(corpusFS)
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read corpus archive: %w", err)
+		}
+		name := path.Clean(strings.TrimPrefix(h.Name, "/"))
+		if h.Typeflag != tar.TypeReg || !fs.ValidPath(name) {
+			continue
+		}
+		data, err := ioutil.ReadAll(io.LimitReader(tr, maxCorpusEntrySize+1))
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read corpus archive: %w", err)
+		}
+		if int64(len(data)) > maxCorpusEntrySize {
+			return nil, fmt.Errorf("corpus archive entry %s is larger than %d bytes", name, maxCorpusEntrySize)
+		}
+		if err := fsys.add(name, data, h.ModTime); err != nil {
+			return nil, err
+		}
+	}
+	return fsys, nil
+}
+
+// corpusFS holds the files of a tar archive in memory, keyed by their
+// names. Directories are entries without content, listing the names of
+// their children.
+type corpusFS map[string]*corpusEntry
+
+type corpusEntry struct {
+	name     string
+	data     []byte
+	modTime  time.Time
+	children []string // nil for a file
+}
+
+// add adds a file, and the directories leading to it. It fails if the
+// archive already has an entry of that name, or a file where one of the
+// directories would be.
+func (a corpusFS) add(name string, data []byte, modTime time.Time) error {
+	if _, ok := a[name]; ok {
+		return fmt.Errorf("corpus archive has duplicate entry %s", name)
+	}
+	a[name] = &corpusEntry{name: path.Base(name), data: data, modTime: modTime}
+	for child := name; child != "."; {
+		dir := path.Dir(child)
+		d, ok := a[dir]
+		if ok && !d.IsDir() {
+			return fmt.Errorf("corpus archive entry %s is inside file %s", name, dir)
+		}
+		if !ok {
+			d = &corpusEntry{name: path.Base(dir), children: []string{}}
+			a[dir] = d
+		}
+		d.children = append(d.children, child)
+		if ok {
+			return nil
+		}
+		child = dir
+	}
+	return nil
+}
+
+func (a corpusFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	e, ok := a[name]
+	if !ok {
+		if name != "." {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		e = &corpusEntry{name: ".", children: []string{}}
+	}
+	return &corpusFile{fs: a, entry: e, Reader: bytes.NewReader(e.data)}, nil
+}
+
+// corpusFile is an open entry of an corpusFS.
+type corpusFile struct {
+	fs    corpusFS
+	entry *corpusEntry
+	*bytes.Reader
+	listed int
+}
+
+func (f *corpusFile) Stat() (fs.FileInfo, error) { return f.entry, nil }
+func (f *corpusFile) Close() error               { return nil }
+
+// ReadDir lists the children of a directory, in the order they were added,
+// which fs.ReadDir sorts.
+func (f *corpusFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !f.entry.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: f.entry.name, Err: fs.ErrInvalid}
+	}
+	rest := f.entry.children[f.listed:]
+	if n > 0 && len(rest) > n {
+		rest = rest[:n]
+	}
+	if n > 0 && len(rest) == 0 {
+		return nil, io.EOF
+	}
+	out := make([]fs.DirEntry, len(rest))
+	for i, name := range rest {
+		out[i] = f.fs[name]
+	}
+	f.listed += len(rest)
+	return out, nil
+}
+
+func (e *corpusEntry) Name() string       { return e.name }
+func (e *corpusEntry) Size() int64        { return int64(len(e.data)) }
+func (e *corpusEntry) ModTime() time.Time { return e.modTime }
+func (e *corpusEntry) IsDir() bool        { return e.children != nil }
+func (e *corpusEntry) Sys() interface{}   { return nil }
+
+// Type and Info make an entry its own fs.DirEntry.
+func (e *corpusEntry) Type() fs.FileMode          { return e.Mode().Type() }
+func (e *corpusEntry) Info() (fs.FileInfo, error) { return e, nil }
+
+func (e *corpusEntry) Mode() fs.FileMode {
+	if e.IsDir() {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
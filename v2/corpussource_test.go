@@ -0,0 +1,294 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// corpusArchives returns a zip and a gzipped tar archive of a small corpus.
+func corpusArchives(t *testing.T) (zipped, tarred []byte) {
+	t.Helper()
+	var zb, tb bytes.Buffer
+	zw := zip.NewWriter(&zb)
+	gw := gzip.NewWriter(&tb)
+	tw := tar.NewWriter(gw)
+	for _, name := range []string{"MIT.txt", "ISC.txt"} {
+		b, err := ioutil.ReadFile(filepath.Join(baseLicenses, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := zw.Create("licenses/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(b)
+		if err := tw.WriteHeader(&tar.Header{Name: "licenses/" + name, Mode: 0o644, Size: int64(len(b)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write(b)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return zb.Bytes(), tb.Bytes()
+}
+
+func checksum(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func checkCorpus(t *testing.T, c *Classifier) {
+	t.Helper()
+	mit, err := ioutil.ReadFile(filepath.Join(baseLicenses, "MIT.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := c.Match(mit); len(m) != 1 || m[0].Name != "MIT" {
+		t.Errorf("Match() = %v, want MIT", m)
+	}
+}
+
+func TestArchiveCorpus(t *testing.T) {
+	zipped, tarred := corpusArchives(t)
+	for _, tt := range []struct {
+		description string
+		archive     []byte
+		sum         string
+	}{
+		{"zip", zipped, checksum(zipped)},
+		{"gzipped tar", tarred, strings.ToUpper(checksum(tarred))},
+		{"unverified", zipped, ""},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			c, err := New(WithCorpusSource(ArchiveCorpus(tt.archive, tt.sum)))
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+			checkCorpus(t, c)
+		})
+	}
+
+	_, err := New(WithCorpusSource(ArchiveCorpus(zipped, checksum(tarred))))
+	if !errors.Is(err, ErrCorpusLoad) {
+		t.Errorf("New() with the wrong checksum = %v, want ErrCorpusLoad", err)
+	}
+	if _, err := New(WithCorpusSource(ArchiveCorpus([]byte("not an archive"), ""))); err == nil {
+		t.Error("New() with a malformed archive succeeded")
+	}
+}
+
+func TestRemoteCorpus(t *testing.T) {
+	zipped, _ := corpusArchives(t)
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/corpus.zip" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(zipped)
+	}))
+	defer ts.Close()
+
+	cache := t.TempDir()
+	src := &RemoteCorpus{URL: ts.URL + "/corpus.zip", SHA256: checksum(zipped), CacheDir: cache}
+	for i := 0; i < 2; i++ {
+		c, err := New(WithCorpusSource(src))
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		checkCorpus(t, c)
+	}
+	if requests != 1 {
+		t.Errorf("downloaded the cached archive %d times, want once", requests)
+	}
+
+	// Without a cache, Reload downloads the archive again.
+	c, err := New(WithCorpusSource(&RemoteCorpus{URL: ts.URL + "/corpus.zip", SHA256: checksum(zipped)}))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if err := c.Reload(); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+	checkCorpus(t, c)
+	if requests != 3 {
+		t.Errorf("made %d requests, want 3", requests)
+	}
+
+	for _, src := range []*RemoteCorpus{
+		{URL: ts.URL + "/missing.zip", SHA256: checksum(zipped)},
+		{URL: ts.URL + "/corpus.zip", SHA256: strings.Repeat("0", 64), CacheDir: cache},
+		{URL: ts.URL + "/corpus.zip"},
+	} {
+		if _, err := New(WithCorpusSource(src)); !errors.Is(err, ErrCorpusLoad) {
+			t.Errorf("New(%s) = %v, want ErrCorpusLoad", src.URL, err)
+		}
+	}
+	if files, _ := filepath.Glob(filepath.Join(cache, "*")); len(files) != 1 {
+		t.Errorf("cache holds %v, want only the verified archive", files)
+	}
+	if requests != 5 {
+		t.Errorf("made %d requests, want 5: an archive without a checksum isn't downloaded", requests)
+	}
+
+	defer func(max int64) { maxCorpusArchiveSize = max }(maxCorpusArchiveSize)
+	maxCorpusArchiveSize = int64(len(zipped)) - 1
+	if _, err := New(WithCorpusSource(&RemoteCorpus{URL: ts.URL + "/corpus.zip", SHA256: checksum(zipped)})); !errors.Is(err, ErrCorpusLoad) {
+		t.Errorf("New() with an oversized archive = %v, want ErrCorpusLoad", err)
+	}
+}
+
+func TestCorpusFS(t *testing.T) {
+	_, tarred := corpusArchives(t)
+	fsys, err := openCorpusArchive(tarred)
+	if err != nil {
+		t.Fatalf("openCorpusArchive() failed: %v", err)
+	}
+	var files []string
+	fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if len(files) == 0 {
+		t.Fatal("openCorpusArchive() holds no files")
+	}
+	if err := fstest.TestFS(fsys, files...); err != nil {
+		t.Error(err)
+	}
+}
+
+// tarArchive returns a tar archive of files with the supplied names and
+// contents.
+func tarArchive(t *testing.T, files ...string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	tw := tar.NewWriter(&b)
+	for i := 0; i+1 < len(files); i += 2 {
+		if err := tw.WriteHeader(&tar.Header{Name: files[i], Mode: 0o644, Size: int64(len(files[i+1])), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(files[i+1]))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestCorpusArchiveErrors(t *testing.T) {
+	defer func(n int64) { maxCorpusEntrySize = n }(maxCorpusEntrySize)
+	maxCorpusEntrySize = 16
+	for _, tt := range []struct {
+		description string
+		archive     []byte
+		wantErr     bool
+	}{
+		{"within limits", tarArchive(t, "licenses/MIT.txt", "MIT", "licenses/ISC.txt", "ISC"), false},
+		{"entry at the limit", tarArchive(t, "MIT.txt", strings.Repeat("x", 16)), false},
+		{"oversized entry", tarArchive(t, "MIT.txt", strings.Repeat("x", 17)), true},
+		{"duplicate entry", tarArchive(t, "licenses/MIT.txt", "MIT", "licenses/MIT.txt", "MIT"), true},
+		{"entry inside a file", tarArchive(t, "licenses", "MIT", "licenses/MIT.txt", "MIT"), true},
+		{"file over a directory", tarArchive(t, "licenses/MIT.txt", "MIT", "licenses", "MIT"), true},
+	} {
+		fsys, err := openCorpusArchive(tt.archive)
+		if gotErr := err != nil; gotErr != tt.wantErr {
+			t.Errorf("%s: openCorpusArchive() = %v, want error %v", tt.description, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		entries, err := fs.ReadDir(fsys, ".")
+		if err != nil {
+			t.Errorf("%s: ReadDir() failed: %v", tt.description, err)
+			continue
+		}
+		if len(entries) != 1 {
+			t.Errorf("%s: ReadDir() = %d entries, want 1", tt.description, len(entries))
+		}
+	}
+}
+
+func TestCorpusURL(t *testing.T) {
+	for _, tt := range []struct {
+		in, want string
+	}{
+		{"https://example.com/corpus.zip", "https://example.com/corpus.zip"},
+		{"gs://corpora/licenses/v2.zip", "https://storage.googleapis.com/corpora/licenses/v2.zip"},
+		{"s3://corpora/licenses/v2.tar.gz", "https://corpora.s3.amazonaws.com/licenses/v2.tar.gz"},
+	} {
+		if got, err := corpusURL(tt.in); err != nil || got != tt.want {
+			t.Errorf("corpusURL(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := corpusURL("ftp://example.com/corpus.zip"); err == nil {
+		t.Error("corpusURL() of an ftp URL succeeded")
+	}
+}
+
+func TestDirCorpus(t *testing.T) {
+	c, err := New(WithCorpusSource(DirCorpus(baseLicenses)))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	checkCorpus(t, c)
+}
+
+func TestParseCorpusSource(t *testing.T) {
+	zipped, _ := corpusArchives(t)
+	archive := filepath.Join(t.TempDir(), "corpus.zip")
+	if err := ioutil.WriteFile(archive, zipped, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, location := range []string{archive, baseLicenses} {
+		c, err := New(WithCorpusSource(ParseCorpusSource(location, "", "")))
+		if err != nil {
+			t.Fatalf("New(%s) failed: %v", location, err)
+		}
+		checkCorpus(t, c)
+	}
+	if _, err := New(WithCorpusSource(ParseCorpusSource(archive, strings.Repeat("0", 64), ""))); !errors.Is(err, ErrCorpusLoad) {
+		t.Errorf("New() with the wrong checksum = %v, want ErrCorpusLoad", err)
+	}
+	src := ParseCorpusSource("gs://corpora/v2.zip", "abc", "/cache")
+	if r, ok := src.(*RemoteCorpus); !ok || r.URL != "gs://corpora/v2.zip" || r.SHA256 != "abc" || r.CacheDir != "/cache" {
+		t.Errorf("ParseCorpusSource() = %+v, want a RemoteCorpus", src)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+// deprecatedLicenses maps the corpus names that the SPDX license list has
+// deprecated to the expression that replaces them.
+var deprecatedLicenses = map[string]string{
+	"AGPL-1.0":                         "AGPL-1.0-only",
+	"AGPL-3.0":                         "AGPL-3.0-only",
+	"GPL-1.0":                          "GPL-1.0-only",
+	"GPL-2.0":                          "GPL-2.0-only",
+	"GPL-2.0-with-GCC-exception":       "GPL-2.0-only WITH GCC-exception-2.0",
+	"GPL-2.0-with-autoconf-exception":  "GPL-2.0-only WITH Autoconf-exception-2.0",
+	"GPL-2.0-with-bison-exception":     "GPL-2.0-only WITH Bison-exception-2.2",
+	"GPL-2.0-with-classpath-exception": "GPL-2.0-only WITH Classpath-exception-2.0",
+	"GPL-2.0-with-font-exception":      "GPL-2.0-only WITH Font-exception-2.0",
+	"GPL-3.0":                          "GPL-3.0-only",
+	"GPL-3.0-with-GCC-exception":       "GPL-3.0-only WITH GCC-exception-3.1",
+	"GPL-3.0-with-autoconf-exception":  "GPL-3.0-only WITH Autoconf-exception-3.0",
+	"LGPL-2.0":                         "LGPL-2.0-only",
+	"LGPL-2.1":                         "LGPL-2.1-only",
+	"LGPL-3.0":                         "LGPL-3.0-only",
+	"bzip2-1.0.5":                      "bzip2-1.0.6",
+}
+
+// DeprecatedLicense reports whether the named license is a deprecated SPDX
+// identifier and, if so, the expression that replaces it.
+func DeprecatedLicense(name string) (replacement string, deprecated bool) {
+	replacement, deprecated = deprecatedLicenses[name]
+	return replacement, deprecated
+}
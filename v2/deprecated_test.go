@@ -0,0 +1,51 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDeprecatedLicense(t *testing.T) {
+	tests := []struct {
+		name        string
+		replacement string
+		deprecated  bool
+	}{
+		{name: "GPL-2.0", replacement: "GPL-2.0-only", deprecated: true},
+		{name: "GPL-2.0-with-classpath-exception", replacement: "GPL-2.0-only WITH Classpath-exception-2.0", deprecated: true},
+		{name: "MIT", replacement: "", deprecated: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, d := DeprecatedLicense(test.name)
+			if r != test.replacement || d != test.deprecated {
+				t.Errorf("DeprecatedLicense(%q) = %q, %v, want %q, %v", test.name, r, d, test.replacement, test.deprecated)
+			}
+		})
+	}
+}
+
+func TestDeprecatedLicensesInCorpus(t *testing.T) {
+	// Every deprecated name should refer to a license in the corpus, so that
+	// the table is kept up to date as the corpus changes.
+	for name := range deprecatedLicenses {
+		if _, err := os.Stat(filepath.Join(baseLicenses, name+".txt")); err != nil {
+			t.Errorf("deprecated license %s is not in the corpus: %v", name, err)
+		}
+	}
+}
@@ -16,6 +16,7 @@ package classifier
 
 import (
 	"strings"
+	"time"
 
 	"github.com/sergi/go-diff/diffmatchpatch"
 )
@@ -28,9 +29,10 @@ import (
 // that reconstruct (as best possible) the source value.
 func diffRange(known string, diffs []diffmatchpatch.Diff) (start, end int) {
 	var foundStart bool
-	var seen string
+	p := textPool.Get().(*[]byte)
+	seen := (*p)[:0]
 	for end = 0; end < len(diffs); end++ {
-		if len(seen) > 1 && seen[:len(seen)-1] == known {
+		if len(seen) > 1 && string(seen[:len(seen)-1]) == known {
 			break
 		}
 		switch diffs[end].Type {
@@ -39,34 +41,51 @@ func diffRange(known string, diffs []diffmatchpatch.Diff) (start, end int) {
 				start = end
 				foundStart = true
 			}
-			seen += diffs[end].Text + " "
+			seen = append(append(seen, diffs[end].Text...), ' ')
 		}
 	}
+	if cap(seen) <= maxPooled {
+		*p = seen
+		textPool.Put(p)
+	}
 	return start, end
 }
 
 func docDiff(id string, doc1 *indexedDocument, doc1Start, doc1End int, doc2 *indexedDocument, doc2Start, doc2End int) []diffmatchpatch.Diff {
-	chars1 := doc1.runes[doc1Start:doc1End]
-	chars2 := doc2.runes[doc2Start:doc2End]
+	// The diff appends to slices of its inputs, overwriting the runes that
+	// follow them, so it is given runes of its own rather than the token IDs
+	// of the documents, which are shared by concurrent matches.
+	chars1 := diffWordsToRunes(doc1, doc1Start, doc1End)
+	chars2 := diffWordsToRunes(doc2, doc2Start, doc2End)
+	defer putRunes(chars1)
+	defer putRunes(chars2)
 
 	dmp := diffmatchpatch.New()
-	diffs := dmp.DiffMainRunes(chars1, chars2, false)
+	if !doc1.deadline.IsZero() {
+		// Diffs past the deadline are cut short rather than refined.
+		if left := time.Until(doc1.deadline); left < dmp.DiffTimeout {
+			dmp.DiffTimeout = time.Nanosecond
+			if left > 0 {
+				dmp.DiffTimeout = left
+			}
+		}
+	}
+	diffs := dmp.DiffMainRunes(*chars1, *chars2, false)
 
 	// Recover the words from the previous rune encoding and return the textual diffs.
 	diffs = diffRunesToWords(diffs, doc1.dict)
 	return diffs
 }
 
-func diffWordsToRunes(doc *indexedDocument, start, end int) []rune {
+func diffWordsToRunes(doc *indexedDocument, start, end int) *[]rune {
 	// Creates a slice of runes using the indexed values as a basis for runes.
 	// The go-diff code basically does exactly this using ephemeral dictionaries
 	// for each input string. We leverage the fact we have a persistent dictionary
-	// to make this operation cheaper.
-	// TODO: perhaps we should cache these in the corpus?
-	runes := make([]rune, 0, end-start)
-
+	// to make this operation cheaper. The slice comes from runePool, and is
+	// returned to it once the diff is done.
+	runes := getRunes(end - start)
 	for _, t := range doc.Tokens[start:end] {
-		runes = append(runes, rune(t.ID))
+		*runes = append(*runes, rune(t.ID))
 	}
 	return runes
 }
@@ -75,14 +94,12 @@ func diffWordsToRunes(doc *indexedDocument, start, end int) []rune {
 func diffRunesToWords(diffs []diffmatchpatch.Diff, dict *dictionary) []diffmatchpatch.Diff {
 	hydrated := make([]diffmatchpatch.Diff, 0, len(diffs))
 	for _, aDiff := range diffs {
-		chars := []rune(aDiff.Text)
 		var sb strings.Builder
-
-		for i, r := range chars {
-			sb.WriteString(dict.getWord(tokenID(r)))
-			if (i + 1) < len(chars) {
+		for i, r := range aDiff.Text {
+			if i > 0 {
 				sb.WriteByte(' ')
 			}
+			sb.WriteString(dict.getWord(tokenID(r)))
 		}
 
 		aDiff.Text = sb.String()
@@ -113,3 +130,15 @@ func textLength(diffs []diffmatchpatch.Diff) int {
 	}
 	return l
 }
+
+// targetLength returns the number of tokens of the first document of a diff
+// covered by diffs.
+func targetLength(diffs []diffmatchpatch.Diff) int {
+	l := 0
+	for _, d := range diffs {
+		if d.Type != diffmatchpatch.DiffInsert {
+			l += wordLen(d.Text)
+		}
+	}
+	return l
+}
@@ -15,9 +15,12 @@
 // Package classifier provides the implementation of the v2 license classifier.
 package classifier
 
-import "strings"
+import (
+	"strings"
+	"time"
+)
 
-type tokenID int // type to ensure safety when manipulating token identifiers.
+type tokenID uint32 // type to ensure safety when manipulating token identifiers.
 
 // token provides detailed information about a single textual token in the document.
 type token struct {
@@ -33,10 +36,12 @@ type document struct {
 	Tokens []*token // ordered tokens of the document
 }
 
+// indexedToken is the compact form of a token kept in the index of the
+// corpus, whose entries hold most of the memory of a classifier.
 type indexedToken struct {
-	Index int     // the token's location in the tokenized document
-	Line  int     // line position of this token in the source
 	ID    tokenID // identifier of the text in the dictionary
+	Index uint32  // the token's location in the tokenized document
+	Line  uint32  // line position of this token in the source
 }
 
 type indexedDocument struct {
@@ -44,14 +49,53 @@ type indexedDocument struct {
 	f      *frequencyTable // frequencies computed for this document
 	dict   *dictionary     // The corpus dictionary for this document
 	s      *searchSet      // The searchset for this document
-	runes  []rune
-	norm   string // The normalized token sequence
+	norm   string          // The normalized token sequence
+
+	// exemptions are the phrases of a corpus entry exempt from equivalent-word
+	// normalization, and exemptCounts their number of occurrences.
+	exemptions   []*exemption
+	exemptCounts map[string]int
+	// content is the text of the document, and exemptLines the lines of the
+	// text of a target prepared for comparing exempt phrases, computed on
+	// demand.
+	content     []byte
+	exemptLines []string
+	// distinct are the distinct tokens of a corpus entry, for the prefilter.
+	distinct []tokenID
+	// maxDistance is the largest distance of a match of a corpus entry at the
+	// threshold of the classifier.
+	maxDistance int
+	// clauses are the clauses of a corpus entry, in order.
+	clauses []clause
+	// patentGrant and patentRetaliation are true if a corpus entry has a
+	// patent grant and a patent retaliation clause.
+	patentGrant, patentRetaliation bool
+	// metrics records the work of matching a target document, if metrics
+	// are collected.
+	metrics *DocumentMetrics
+	// deadline is the time by which a target must be matched, or zero if
+	// there is no time budget.
+	deadline time.Time
+	// template marks the variable and optional text of a corpus entry
+	// written as an SPDX license template, if it is one.
+	template *template
+	// anchors are the phrases a match of a short corpus entry must contain.
+	anchors [][]tokenID
+	// meta is the metadata of the metadata file of a corpus entry, if it has
+	// one.
+	meta *EntryMetadata
 }
 
 func (d *indexedDocument) generateSearchSet(q int) {
 	d.s = newSearchSet(d, q)
 }
 
+// generateTargetSearchSet generates the searchset of a target, with buffers
+// from the pools.
+func (d *indexedDocument) generateTargetSearchSet(q int) {
+	d.s = newTargetSearchSet(d, q)
+}
+
 func (d *indexedDocument) size() int {
 	return len(d.Tokens)
 }
@@ -96,10 +140,47 @@ func max(a, b int) int {
 }
 
 // AddContent incorporates the provided textual content into the classifier for
-// matching. This will not modify the supplied content.
+// matching. This will not modify the supplied content. Content of licenses
+// excluded from the corpus by WithLicenses or WithoutCategories is ignored.
 func (c *Classifier) AddContent(name string, content []byte) {
-	doc := tokenize(content)
+	c.addContent(name, "", content, c.tokenize(content), nil)
+	content = append([]byte(nil), content...)
+	c.addSource(func(c *Classifier) error {
+		c.AddContent(name, content)
+		return nil
+	})
+}
+
+// addContent adds content, already tokenized as doc, to the corpus. origin is
+// the file the content was read from, if any, and meta the metadata read
+// from its metadata file.
+func (c *Classifier) addContent(name, origin string, content []byte, doc *document, meta *EntryMetadata) {
+	if !c.filter.retains(name) {
+		return
+	}
+	var tmpl *template
+	if isTemplate(content) {
+		content, doc, tmpl = parseTemplate(content, c.equivalences)
+	}
+	defer c.update()()
 	c.addDocument(name, doc)
+	c.origins[name] = origin
+	if tmpl != nil {
+		id := c.docs[name]
+		id.template = tmpl
+		id.generateFrequencies()
+		id.distinct = id.distinctTokens()
+	}
+	c.docs[name].content = append([]byte(nil), content...)
+	c.docs[name].meta = meta
+	c.docs[name].clauses = segmentClauses(content, doc)
+	c.docs[name].patentGrant, c.docs[name].patentRetaliation = patentClauses(c.docs[name].norm)
+	if ex := c.exemptionsFor(name); len(ex) > 0 {
+		id := c.docs[name]
+		id.exemptions = ex
+		id.exemptCounts = exemptionCounts(exemptionText(content), ex)
+	}
+	c.setAnchors(name, c.docs[name])
 }
 
 // addDocument takes a textual document and incorporates it into the classifier for matching.
@@ -111,15 +192,33 @@ func (c *Classifier) addDocument(name string, doc *document) {
 	id.generateFrequencies()
 	id.generateSearchSet(c.q)
 	id.s.origin = name
+	id.distinct = id.distinctTokens()
+	id.maxDistance = maxDistance(id.size(), c.threshold, c.minEditCost())
+	// Content added under the name of an existing entry replaces it, so the
+	// tokens of the old entry no longer count towards the document
+	// frequencies.
+	if old, ok := c.docs[name]; ok {
+		for t := range old.f.counts {
+			if c.docFreq[t]--; c.docFreq[t] <= 0 {
+				delete(c.docFreq, t)
+			}
+		}
+	}
+	for t := range id.f.counts {
+		c.docFreq[t]++
+	}
 	c.docs[name] = id
+	c.checkEntry(name, id)
 }
 
 // generateIndexedDocument creates an indexedDocument from the supplied document. if addWords
 // is true, the classifier dictionary is updated with new tokens encountered in the document.
 func (c *Classifier) generateIndexedDocument(d *document, addWords bool) *indexedDocument {
-	id := &indexedDocument{
-		Tokens: make([]indexedToken, 0, len(d.Tokens)),
-		dict:   c.dict,
+	id := &indexedDocument{dict: c.dict}
+	if addWords {
+		id.Tokens = make([]indexedToken, 0, len(d.Tokens))
+	} else {
+		id.Tokens = getTokens(len(d.Tokens))
 	}
 
 	for _, t := range d.Tokens {
@@ -131,37 +230,44 @@ func (c *Classifier) generateIndexedDocument(d *document, addWords bool) *indexe
 		}
 
 		id.Tokens = append(id.Tokens, indexedToken{
-			Index: t.Index,
-			Line:  t.Line,
 			ID:    tokID,
+			Index: uint32(t.Index),
+			Line:  uint32(t.Line),
 		})
 
 	}
+	id.generateDerived()
+	return id
+}
+
+// generateDerived computes the frequencies and normalized forms of the
+// tokens of the document.
+func (id *indexedDocument) generateDerived() {
 	id.generateFrequencies()
-	id.runes = diffWordsToRunes(id, 0, id.size())
 	id.norm = id.normalized()
-	return id
 }
 
 // createTargetIndexedDocument creates an indexed document without adding the
 // words to the classifier dictionary. This should be used for matching targets, not
 // populating the corpus.
 func (c *Classifier) createTargetIndexedDocument(in []byte) *indexedDocument {
-	doc := tokenize(in)
-	return c.generateIndexedDocument(doc, false)
+	doc := c.tokenize(in)
+	id := c.generateIndexedDocument(doc, false)
+	id.content = in
+	return id
 }
 
 // dictionary is used to intern all the token words encountered in the text corpus.
-// words and indices form an inverse mapping relationship. It is just a convenience type
-// over a pair of correlated maps.
+// words and indices form an inverse mapping relationship: the IDs are dense, so
+// the words are a slice, holding the word of ID i at index i-1, rather than a
+// second map.
 type dictionary struct {
-	words   map[tokenID]string
+	words   []string
 	indices map[string]tokenID
 }
 
 func newDictionary() *dictionary {
 	return &dictionary{
-		words:   make(map[tokenID]string),
 		indices: make(map[string]tokenID),
 	}
 }
@@ -172,8 +278,8 @@ func (d *dictionary) add(word string) tokenID {
 		return idx
 	}
 	// token IDs start from 1, 0 is reserved for the invalid ID
-	idx := tokenID(len(d.words) + 1)
-	d.words[idx] = word
+	d.words = append(d.words, word)
+	idx := tokenID(len(d.words))
 	d.indices[word] = idx
 	return idx
 }
@@ -191,8 +297,21 @@ func (d *dictionary) getIndex(word string) tokenID {
 
 // getWord returns the word associated with the index.
 func (d *dictionary) getWord(index tokenID) string {
-	if word, found := d.words[index]; found {
-		return word
+	if index != unknownIndex && int(index) <= len(d.words) {
+		return d.words[index-1]
 	}
 	return unknownWord
 }
+
+// clone returns a copy of the dictionary, to which the words added to the
+// dictionary afterward aren't added.
+func (d *dictionary) clone() *dictionary {
+	out := &dictionary{
+		words:   d.words[:len(d.words):len(d.words)],
+		indices: make(map[string]tokenID, len(d.indices)),
+	}
+	for w, i := range d.indices {
+		out.indices[w] = i
+	}
+	return out
+}
@@ -0,0 +1,114 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"fmt"
+	"math"
+)
+
+// EditWeights are the costs of the kinds of word edits between matched text
+// and a license, which reduce the confidence of a match by their cost
+// relative to the length of the license. By default each edit costs 1, as in
+// the word Levenshtein distance, but an organization may, for example, want
+// text added to a license to cost less than license terms removed from it.
+type EditWeights struct {
+	// Added is the cost of a word of the matched text that isn't in the
+	// license, counted in Explanation.Deletions.
+	Added float64
+	// Removed is the cost of a word of the license missing from the matched
+	// text, counted in Explanation.Insertions.
+	Removed float64
+	// Substituted is the cost of a word of the license replaced by another.
+	Substituted float64
+}
+
+// DefaultEditWeights are the edit weights of classifiers that haven't set
+// them.
+var DefaultEditWeights = EditWeights{Added: 1, Removed: 1, Substituted: 1}
+
+// valid returns an error if a weight isn't positive.
+func (w EditWeights) valid() error {
+	for _, x := range []float64{w.Added, w.Removed, w.Substituted} {
+		if !(x > 0) {
+			return fmt.Errorf("edit weight %v is not positive", x)
+		}
+	}
+	return nil
+}
+
+// cost returns the cost of a run of edits adding and removing words, which
+// pairs the words of either kind as substitutions.
+func (w EditWeights) cost(added, removed float64) float64 {
+	s := math.Min(added, removed)
+	return s*w.Substituted + (added-s)*w.Added + (removed-s)*w.Removed
+}
+
+// min returns the cost of the cheapest edit.
+func (w EditWeights) min() float64 {
+	return math.Min(w.Added, math.Min(w.Removed, w.Substituted))
+}
+
+// SetEditWeights sets the costs of the kinds of word edits. Weights that
+// aren't positive are replaced by those of DefaultEditWeights. Edits costing
+// less than 1 allow more of them in a match, so the q-grams are shortened if
+// needed for the matches to still be found, which makes matching slower.
+func (c *Classifier) SetEditWeights(w EditWeights) {
+	defer c.update()()
+	if w.Added <= 0 {
+		w.Added = DefaultEditWeights.Added
+	}
+	if w.Removed <= 0 {
+		w.Removed = DefaultEditWeights.Removed
+	}
+	if w.Substituted <= 0 {
+		w.Substituted = DefaultEditWeights.Substituted
+	}
+	c.edits = w
+	c.updateMaxDistances()
+	if limit := computeQ(c.searchThreshold()); c.q > limit {
+		c.setQ(limit)
+	}
+}
+
+// minEditCost returns the least cost of an edited word, which bounds the
+// number of edits of a match at the threshold.
+func (c *Classifier) minEditCost() float64 {
+	m := c.edits.min()
+	if c.weighted {
+		m *= minTokenWeight
+	}
+	return m
+}
+
+// searchThreshold returns the confidence a match at the threshold would have
+// if all edits cost 1, which is lower than the threshold when some edits cost
+// less. Candidates are searched for at this confidence, so that the cheaper
+// edits don't exclude them before they're scored.
+func (c *Classifier) searchThreshold() float64 {
+	m := c.edits.min()
+	if m >= 1 {
+		return c.threshold
+	}
+	return math.Max(0, 1-(1-c.threshold)/m)
+}
+
+// updateMaxDistances recomputes the largest distances of matches of the
+// corpus entries after the cost of edits changed.
+func (c *Classifier) updateMaxDistances() {
+	for _, d := range c.docs {
+		d.maxDistance = maxDistance(d.size(), c.threshold, c.minEditCost())
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+func TestEditCost(t *testing.T) {
+	c := NewClassifier(.8)
+	diffs := []diffmatchpatch.Diff{
+		{Type: diffmatchpatch.DiffDelete, Text: "three added words"},
+		{Type: diffmatchpatch.DiffInsert, Text: "removed"},
+		{Type: diffmatchpatch.DiffEqual, Text: "identical words"},
+		{Type: diffmatchpatch.DiffInsert, Text: "two removed"},
+	}
+	if got, want := c.editCost(diffs, unitWeight), float64(diffLevenshteinWord(diffs)); got != want {
+		t.Errorf("editCost() with the default weights = %v, want the Levenshtein distance %v", got, want)
+	}
+	c.SetEditWeights(EditWeights{Added: .25, Removed: 2, Substituted: 1.5})
+	// One substitution and two added words, then two removed words.
+	if got, want := c.editCost(diffs, unitWeight), 1.5+2*.25+2*2.0; got != want {
+		t.Errorf("editCost() = %v, want %v", got, want)
+	}
+}
+
+func TestSetEditWeights(t *testing.T) {
+	// Boilerplate added to the license costs less than license terms removed
+	// from it.
+	added := []byte(strings.Replace(versionedLicense, "clearly marked as such.", "clearly marked as such, and the name of the company shall appear in the documentation.", 1))
+	removed := []byte(strings.Replace(versionedLicense, "without warranty of any kind, express or implied", "without warranty", 1))
+	weights := EditWeights{Added: .25, Removed: 2, Substituted: 1}
+
+	tests := []struct {
+		name    string
+		weights *EditWeights
+		in      []byte
+		matched bool
+	}{
+		{name: "added words", in: added},
+		{name: "weighted added words", weights: &weights, in: added, matched: true},
+		{name: "removed words", in: removed, matched: true},
+		{name: "weighted removed words", weights: &weights, in: removed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClassifier(.9)
+			if tt.weights != nil {
+				c.SetEditWeights(*tt.weights)
+			}
+			c.AddContent("Widget-1.0", []byte(versionedLicense))
+			ms := licenseMatches(c.Match(tt.in))
+			if got := len(ms) == 1; got != tt.matched {
+				t.Errorf("Match() = %v, want a match: %v", ms, tt.matched)
+			}
+		})
+	}
+
+	c := NewClassifier(.9)
+	c.AddContent("Widget-1.0", []byte(versionedLicense))
+	scope, max := string(c.cacheScope()), c.docs["Widget-1.0"].maxDistance
+	c.SetEditWeights(weights)
+	if string(c.cacheScope()) == scope {
+		t.Errorf("SetEditWeights() didn't change the cache scope")
+	}
+	if got := c.docs["Widget-1.0"].maxDistance; got <= max {
+		t.Errorf("maxDistance with an edit cost of %v = %d, want more than %d", weights.Added, got, max)
+	}
+	if got, want := c.q, computeQ(c.searchThreshold()); got != want {
+		t.Errorf("q = %d after SetEditWeights(), want %d", got, want)
+	}
+	c.SetEditWeights(EditWeights{Added: -1})
+	if c.edits != DefaultEditWeights {
+		t.Errorf("SetEditWeights() with invalid weights set %+v, want the defaults", c.edits)
+	}
+}
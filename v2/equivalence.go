@@ -0,0 +1,222 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Equivalences are words and phrases the classifier treats as the same, in
+// addition to the interchangeable words of the SPDX matching guidelines,
+// such as "licence" and "license", that the tokenizer always normalizes.
+// They extend normalization to spellings the guidelines don't cover, such as
+// regional or dated ones, without changes to the classifier.
+type Equivalences struct {
+	// Classes are the sets of interchangeable phrases. The first phrase of a
+	// class is its canonical form, which the others are replaced with.
+	Classes [][]string `json:"classes,omitempty"`
+	// Stopwords are the words and phrases ignored when matching.
+	Stopwords []string `json:"stopwords,omitempty"`
+}
+
+// ParseEquivalences parses a JSON object declaring equivalence classes and
+// stopwords:
+//
+//	{
+//	  "classes": [["color", "colour"], ["first", "1st"]],
+//	  "stopwords": ["hereinafter"]
+//	}
+func ParseEquivalences(b []byte) (*Equivalences, error) {
+	var e Equivalences
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, fmt.Errorf("classifier couldn't parse equivalences: %w", err)
+	}
+	if err := e.valid(); err != nil {
+		return nil, fmt.Errorf("classifier couldn't parse equivalences: %w", err)
+	}
+	return &e, nil
+}
+
+// valid returns an error if a class has fewer than two phrases, a phrase
+// has no words, or a phrase is declared twice.
+func (e *Equivalences) valid() error {
+	seen := make(map[string]bool)
+	check := func(p string) error {
+		words := phraseWords(p)
+		if len(words) == 0 {
+			return fmt.Errorf("phrase %q has no words", p)
+		}
+		k := strings.Join(words, " ")
+		if seen[k] {
+			return fmt.Errorf("phrase %q is declared twice", p)
+		}
+		seen[k] = true
+		return nil
+	}
+	for _, class := range e.Classes {
+		if len(class) < 2 {
+			return fmt.Errorf("class %q has fewer than two phrases", class)
+		}
+		for _, p := range class {
+			if err := check(p); err != nil {
+				return err
+			}
+		}
+	}
+	for _, p := range e.Stopwords {
+		if err := check(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetEquivalences sets the equivalence classes and stopwords of the
+// classifier, replacing those previously set; nil removes them. Like
+// normalization exemptions, they must be set before loading the corpus,
+// whose entries are tokenized as they are added. An index keeps the
+// corpus tokenized with the equivalences it was written with. Classes with
+// fewer than two phrases and phrases without words are ignored, and
+// ParseEquivalences rejects them.
+func (c *Classifier) SetEquivalences(e *Equivalences) {
+	eq := compileEquivalences(e)
+	defer c.update()()
+	c.equivalences = eq
+}
+
+// equivalenceRule replaces the words of a phrase by those of its canonical
+// form, or by none for a stopword.
+type equivalenceRule struct {
+	from, to []string
+}
+
+// equivalences are the compiled equivalence classes and stopwords of a
+// classifier.
+type equivalences struct {
+	// rules are the rules keyed by the first word of their phrase, longest
+	// phrases first.
+	rules map[string][]equivalenceRule
+	// source is the declaration the rules were compiled from.
+	source Equivalences
+}
+
+// compileEquivalences returns the rules of the declared equivalences, or nil
+// if there are none.
+func compileEquivalences(e *Equivalences) *equivalences {
+	if e == nil {
+		return nil
+	}
+	eq := &equivalences{rules: make(map[string][]equivalenceRule)}
+	add := func(from, to []string) {
+		if len(from) == 0 {
+			return
+		}
+		rs := append(eq.rules[from[0]], equivalenceRule{from: from, to: to})
+		// Rules are few per word, so an insertion sort keeps them ordered.
+		for i := len(rs) - 1; i > 0 && len(rs[i].from) > len(rs[i-1].from); i-- {
+			rs[i], rs[i-1] = rs[i-1], rs[i]
+		}
+		eq.rules[from[0]] = rs
+	}
+	for _, class := range e.Classes {
+		if len(class) < 2 {
+			continue
+		}
+		to := phraseWords(class[0])
+		if len(to) == 0 {
+			continue
+		}
+		for _, p := range class[1:] {
+			add(phraseWords(p), to)
+		}
+		eq.source.Classes = append(eq.source.Classes, append([]string(nil), class...))
+	}
+	for _, p := range e.Stopwords {
+		add(phraseWords(p), nil)
+		eq.source.Stopwords = append(eq.source.Stopwords, p)
+	}
+	if len(eq.rules) == 0 {
+		return nil
+	}
+	return eq
+}
+
+// phraseWords returns the words of a phrase, normalized as the tokenizer
+// normalizes text.
+func phraseWords(p string) []string {
+	var words []string
+	for _, t := range tokenize([]byte(p)).Tokens {
+		words = append(words, t.Text)
+	}
+	return words
+}
+
+// apply returns a copy of the document with the phrases of the equivalence
+// classes replaced by their canonical forms and the stopwords removed. The
+// words of a replaced phrase keep the line of its first word. The document
+// is returned as is if there are no equivalences.
+func (e *equivalences) apply(doc *document) *document {
+	if e == nil {
+		return doc
+	}
+	out := &document{Tokens: make([]*token, 0, len(doc.Tokens))}
+	emit := func(tok *token, text string) {
+		t := *tok
+		t.Text = text
+		t.Index = len(out.Tokens)
+		out.Tokens = append(out.Tokens, &t)
+	}
+	for i := 0; i < len(doc.Tokens); {
+		r, ok := e.match(doc.Tokens[i:])
+		if !ok {
+			emit(doc.Tokens[i], doc.Tokens[i].Text)
+			i++
+			continue
+		}
+		for _, w := range r.to {
+			emit(doc.Tokens[i], w)
+		}
+		i += len(r.from)
+	}
+	return out
+}
+
+// match returns the longest rule whose phrase starts the tokens.
+func (e *equivalences) match(tokens []*token) (equivalenceRule, bool) {
+	for _, r := range e.rules[tokens[0].Text] {
+		if len(r.from) > len(tokens) {
+			continue
+		}
+		matched := true
+		for j, w := range r.from[1:] {
+			if tokens[j+1].Text != w {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return r, true
+		}
+	}
+	return equivalenceRule{}, false
+}
+
+// tokenize tokenizes content as the package tokenizer does and applies the
+// equivalences of the classifier.
+func (c *Classifier) tokenize(in []byte) *document {
+	return c.equivalences.apply(tokenize(in))
+}
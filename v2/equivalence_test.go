@@ -0,0 +1,140 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseEquivalences(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want *Equivalences
+		err  bool
+	}{
+		{
+			name: "classes and stopwords",
+			in:   `{"classes": [["color", "colour"], ["first", "1st"]], "stopwords": ["hereinafter"]}`,
+			want: &Equivalences{Classes: [][]string{{"color", "colour"}, {"first", "1st"}}, Stopwords: []string{"hereinafter"}},
+		},
+		{
+			name: "malformed",
+			in:   `{"classes": "color"}`,
+			err:  true,
+		},
+		{
+			name: "single phrase class",
+			in:   `{"classes": [["color"]]}`,
+			err:  true,
+		},
+		{
+			name: "phrase without words",
+			in:   `{"stopwords": ["--"]}`,
+			err:  true,
+		},
+		{
+			name: "phrase declared twice",
+			in:   `{"classes": [["color", "colour"]], "stopwords": ["Colour"]}`,
+			err:  true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseEquivalences([]byte(test.in))
+			if (err != nil) != test.err {
+				t.Fatalf("ParseEquivalences() error = %v, want error %v", err, test.err)
+			}
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("ParseEquivalences() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestEquivalencesApply(t *testing.T) {
+	eq := compileEquivalences(&Equivalences{
+		Classes:   [][]string{{"color", "colour"}, {"end user", "enduser", "end-user of it"}},
+		Stopwords: []string{"hereinafter", "as such"},
+	})
+	doc := tokenize([]byte("The colour, hereinafter the\ncolor, chosen by the end-user of it as such."))
+	got := eq.apply(doc)
+
+	var texts []string
+	for i, tok := range got.Tokens {
+		if tok.Index != i {
+			t.Errorf("token %d %q has index %d", i, tok.Text, tok.Index)
+		}
+		texts = append(texts, tok.Text)
+	}
+	want := "the color the color chosen by the end user"
+	if s := strings.Join(texts, " "); s != want {
+		t.Errorf("apply() = %q, want %q", s, want)
+	}
+	if got.Tokens[3].Line != 2 {
+		t.Errorf("apply() put %q on line %d, want 2", got.Tokens[3].Text, got.Tokens[3].Line)
+	}
+	if doc.Tokens[1].Text != "colour" {
+		t.Errorf("apply() modified the document")
+	}
+	if (*equivalences)(nil).apply(doc) != doc {
+		t.Errorf("apply() without equivalences copied the document")
+	}
+}
+
+func TestSetEquivalences(t *testing.T) {
+	license := "The colour of this software may be changed by any licensee, who is hereinafter called the painter."
+	text := "The color of this software may be changed by any licensee, who is called the painter."
+	eq := &Equivalences{Classes: [][]string{{"color", "colour"}}, Stopwords: []string{"hereinafter"}}
+
+	confidence := func(ms Matches) float64 {
+		if len(ms) == 0 {
+			return 0
+		}
+		return ms[0].Confidence
+	}
+
+	c, err := New(WithCorpusContent("Painter", []byte(license)))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if got := confidence(c.Match([]byte(text))); got == 1 {
+		t.Errorf("Match() without equivalences confidence = %v, want less than 1", got)
+	}
+
+	c, err = New(WithEquivalences(eq), WithCorpusContent("Painter", []byte(license)))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if got := confidence(c.Match([]byte(text))); got != 1 {
+		t.Errorf("Match() confidence = %v, want 1", got)
+	}
+	if got := confidence(c.MatchTokenized(Tokenize([]byte(text)))); got != 1 {
+		t.Errorf("MatchTokenized() of a document tokenized without equivalences confidence = %v, want 1", got)
+	}
+	if got := confidence(c.MatchTokenized(c.Tokenize([]byte(text)))); got != 1 {
+		t.Errorf("MatchTokenized() confidence = %v, want 1", got)
+	}
+	if got, want := c.Normalize([]byte("The colour, hereinafter")), "the color\n"; got != want {
+		t.Errorf("Normalize() = %q, want %q", got, want)
+	}
+
+	if _, err := New(WithEquivalences(&Equivalences{Classes: [][]string{{"color"}}})); err == nil {
+		t.Errorf("New() with a single phrase class succeeded, want an error")
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"errors"
+)
+
+// The failures callers may need to tell apart are reported with these
+// errors, wrapped in an *Error giving their context, so they can be tested for
+// with errors.Is.
+var (
+	// ErrCorpusLoad is returned when the license corpus can't be read.
+	ErrCorpusLoad = errors.New("classifier: couldn't load the license corpus")
+	// ErrNoLicenseData is returned when a corpus holds no license files, or
+	// there is no corpus entry for the license of a match.
+	ErrNoLicenseData = errors.New("classifier: no license data")
+	// ErrDocumentTooLarge is returned when content is larger than the limit
+	// set with SetMaxDocumentSize.
+	ErrDocumentTooLarge = errors.New("classifier: document too large")
+	// ErrUnsupportedEncoding is returned for content the tokenizer can't
+	// read, such as UTF-16 text.
+	ErrUnsupportedEncoding = errors.New("classifier: unsupported text encoding")
+)
+
+// Error is a failure of the classifier. It matches its Kind, one of the
+// errors above, with errors.Is, and unwraps to its cause, if any.
+type Error struct {
+	Kind error
+	// Context describes what failed, such as the file being read, if the
+	// cause doesn't.
+	Context string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	msg := e.Kind.Error()
+	if e.Context != "" {
+		msg += ": " + e.Context
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Is reports whether target is the kind of the error.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Unwrap returns the cause of the error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// corpusLoadError wraps an error loading the corpus, unless it already is an
+// error of the classifier.
+func corpusLoadError(err error) error {
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	return &Error{Kind: ErrCorpusLoad, Err: err}
+}
+
+// byteOrderMarks are the byte order marks of the Unicode encodings the
+// tokenizer can't read. Those of UTF-32 come first, since the mark of UTF-32LE
+// starts with that of UTF-16LE.
+var byteOrderMarks = []struct {
+	mark     []byte
+	encoding string
+}{
+	{[]byte{0x00, 0x00, 0xfe, 0xff}, "UTF-32BE"},
+	{[]byte{0xff, 0xfe, 0x00, 0x00}, "UTF-32LE"},
+	{[]byte{0xfe, 0xff}, "UTF-16BE"},
+	{[]byte{0xff, 0xfe}, "UTF-16LE"},
+}
+
+// checkEncoding returns an error if the supplied content starts with the byte
+// order mark of an encoding the tokenizer can't read.
+func checkEncoding(in []byte) error {
+	for _, b := range byteOrderMarks {
+		if bytes.HasPrefix(in, b.mark) {
+			return &Error{Kind: ErrUnsupportedEncoding, Context: b.encoding + " byte order mark"}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCorpusErrors(t *testing.T) {
+	c := NewClassifier(defaultThreshold)
+	if err := c.LoadLicenses(filepath.Join(t.TempDir(), "missing")); !errors.Is(err, ErrCorpusLoad) || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("LoadLicenses(missing directory) = %v, want ErrCorpusLoad caused by fs.ErrNotExist", err)
+	}
+	if err := c.LoadLicenses(t.TempDir()); !errors.Is(err, ErrNoLicenseData) {
+		t.Errorf("LoadLicenses(empty directory) = %v, want ErrNoLicenseData", err)
+	}
+	if err := c.LoadLicensesFS(fstest.MapFS{"README.md": {}}); !errors.Is(err, ErrNoLicenseData) {
+		t.Errorf("LoadLicensesFS(no licenses) = %v, want ErrNoLicenseData", err)
+	}
+	if _, err := NewLicenseDB(filepath.Join(t.TempDir(), "missing")); !errors.Is(err, ErrCorpusLoad) {
+		t.Errorf("NewLicenseDB(missing directory) = %v, want ErrCorpusLoad", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "MIT.txt"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A directory named like a license file can't be read as one.
+	if err := c.LoadLicenses(dir); !errors.Is(err, ErrCorpusLoad) {
+		t.Errorf("LoadLicenses(unreadable license) = %v, want ErrCorpusLoad", err)
+	}
+}
+
+func TestMatchFromErrors(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	c.SetMaxDocumentSize(100)
+	_, err = c.MatchFrom(strings.NewReader(strings.Repeat("x", 101)))
+	if !errors.Is(err, ErrDocumentTooLarge) {
+		t.Errorf("MatchFrom(101 bytes) = %v, want ErrDocumentTooLarge", err)
+	}
+	var e *Error
+	if !errors.As(err, &e) || e.Kind != ErrDocumentTooLarge {
+		t.Errorf("MatchFrom(101 bytes) = %v, want an *Error", err)
+	}
+	if _, err := c.MatchFrom(strings.NewReader(strings.Repeat("x", 100))); err != nil {
+		t.Errorf("MatchFrom(100 bytes) failed: %v", err)
+	}
+
+	utf16 := []byte{0xff, 0xfe, 'M', 0, 'I', 0, 'T', 0}
+	if _, err := c.MatchFrom(bytes.NewReader(utf16)); !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Errorf("MatchFrom(UTF-16) = %v, want ErrUnsupportedEncoding", err)
+	}
+}
+
+func TestExplainNoLicenseData(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	m := &Match{Name: "No-Such-License", MatchType: "License", StartLine: 1, EndLine: 1, EndTokenIndex: 1}
+	if _, err := c.Explain([]byte("some license text"), m); !errors.Is(err, ErrNoLicenseData) {
+		t.Errorf("Explain(unknown license) = %v, want ErrNoLicenseData", err)
+	}
+}
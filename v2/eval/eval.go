@@ -0,0 +1,210 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package eval measures the accuracy of the license classifier against
+// labeled datasets. Datasets are loaded into cases holding the content of a
+// file and the licenses it is known to contain, from the scenario format of
+// this repository or the layouts of third-party test corpora, so that the
+// classifier can be compared with other scanners on the same data. Cases can
+// also be synthesized from the corpus with a Generator, for fixtures that
+// exercise integrations of the classifier deterministically.
+//
+//	cases, err := eval.LoadScanCode("scancode-toolkit/tests/licensedcode/data/datadriven")
+//	...
+//	r := eval.Evaluate(c, cases)
+//	fmt.Printf("precision %.3f recall %.3f\n", r.Precision(), r.Recall())
+package eval
+
+import (
+	"sort"
+	"strings"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+// Case is a labeled input of a dataset.
+type Case struct {
+	// Name identifies the case, usually by the path of its file.
+	Name    string
+	Content []byte
+	// Expected are the identifiers of the licenses the content is known to
+	// contain, which may be empty.
+	Expected []string
+}
+
+// Result is the outcome of classifying a case. Licenses are compared by
+// their normalized identifiers; see Normalize.
+type Result struct {
+	Case *Case
+	// Found are the licenses both expected and matched, Missing those
+	// expected but not matched, and Unexpected those matched but not
+	// expected.
+	Found, Missing, Unexpected []string
+}
+
+// Correct returns true if the licenses matched are those expected.
+func (r *Result) Correct() bool {
+	return len(r.Missing) == 0 && len(r.Unexpected) == 0
+}
+
+// Counts are the outcomes of an evaluation: the licenses found, unexpected
+// and missing.
+type Counts struct {
+	TruePositives, FalsePositives, FalseNegatives int
+}
+
+// Precision returns the fraction of the licenses matched that were expected,
+// or 1 if nothing was matched.
+func (c Counts) Precision() float64 {
+	return ratio(c.TruePositives, c.TruePositives+c.FalsePositives)
+}
+
+// Recall returns the fraction of the licenses expected that were matched, or
+// 1 if nothing was expected.
+func (c Counts) Recall() float64 {
+	return ratio(c.TruePositives, c.TruePositives+c.FalseNegatives)
+}
+
+// F1 returns the harmonic mean of the precision and recall.
+func (c Counts) F1() float64 {
+	p, rec := c.Precision(), c.Recall()
+	if p+rec == 0 {
+		return 0
+	}
+	return 2 * p * rec / (p + rec)
+}
+
+// Report holds the results of an evaluation, and their counts over all
+// cases.
+type Report struct {
+	Results []*Result
+	Counts
+}
+
+// LicenseStats holds the counts of an evaluation for a single license.
+type LicenseStats struct {
+	// License is the normalized identifier of the license; see Normalize.
+	License string
+	Counts
+}
+
+// ByLicense returns the counts of each license expected or matched in the
+// evaluation, in the order of their identifiers, so that the accuracy of the
+// classifier can be compared license by license.
+func (r *Report) ByLicense() []*LicenseStats {
+	stats := make(map[string]*LicenseStats)
+	get := func(l string) *LicenseStats {
+		s, ok := stats[l]
+		if !ok {
+			s = &LicenseStats{License: l}
+			stats[l] = s
+		}
+		return s
+	}
+	for _, res := range r.Results {
+		for _, l := range res.Found {
+			get(l).TruePositives++
+		}
+		for _, l := range res.Unexpected {
+			get(l).FalsePositives++
+		}
+		for _, l := range res.Missing {
+			get(l).FalseNegatives++
+		}
+	}
+	out := make([]*LicenseStats, 0, len(stats))
+	for _, s := range stats {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].License < out[j].License })
+	return out
+}
+
+// Failures returns the results of the cases that weren't classified
+// correctly.
+func (r *Report) Failures() []*Result {
+	var out []*Result
+	for _, res := range r.Results {
+		if !res.Correct() {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 1
+	}
+	return float64(n) / float64(d)
+}
+
+// Evaluate classifies the content of each case and compares the licenses
+// matched with those expected. References to licenses by name and informal
+// permission grants are ignored, since datasets label the license texts a
+// file contains.
+func Evaluate(c *classifier.Classifier, cases []*Case) *Report {
+	r := &Report{}
+	for _, tc := range cases {
+		res := compare(tc, c.Match(tc.Content))
+		r.TruePositives += len(res.Found)
+		r.FalsePositives += len(res.Unexpected)
+		r.FalseNegatives += len(res.Missing)
+		r.Results = append(r.Results, res)
+	}
+	return r
+}
+
+// compare compares the matches of a case with the licenses it expects.
+func compare(tc *Case, matches classifier.Matches) *Result {
+	expected := make(map[string]bool)
+	for _, e := range tc.Expected {
+		expected[Normalize(e)] = true
+	}
+	matched := make(map[string]bool)
+	for _, m := range matches {
+		if m.MatchType != "Reference" && m.MatchType != "Grant" {
+			matched[Normalize(m.Name)] = true
+		}
+	}
+	res := &Result{Case: tc}
+	for l := range expected {
+		if matched[l] {
+			res.Found = append(res.Found, l)
+		} else {
+			res.Missing = append(res.Missing, l)
+		}
+	}
+	for l := range matched {
+		if !expected[l] {
+			res.Unexpected = append(res.Unexpected, l)
+		}
+	}
+	sort.Strings(res.Found)
+	sort.Strings(res.Missing)
+	sort.Strings(res.Unexpected)
+	return res
+}
+
+// Normalize returns the form of a license identifier used for comparisons:
+// lower case, without the suffixes distinguishing "only" and "or later"
+// versions, which the classifier doesn't report, in their SPDX ("-only",
+// "-or-later", "+") or ScanCode ("-plus") forms.
+func Normalize(id string) string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	for _, suffix := range []string{"-only", "-or-later", "+", "-plus"} {
+		id = strings.TrimSuffix(id, suffix)
+	}
+	return id
+}
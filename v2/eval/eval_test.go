@@ -0,0 +1,131 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+func newClassifier(t *testing.T) *classifier.Classifier {
+	t.Helper()
+	c, err := classifier.New(classifier.WithCorpusDir(filepath.Join("..", "licenses")))
+	if err != nil {
+		t.Fatalf("classifier.New() failed: %v", err)
+	}
+	return c
+}
+
+func readLicense(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := ioutil.ReadFile(filepath.Join("..", "licenses", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestEvaluate(t *testing.T) {
+	c := newClassifier(t)
+	cases := []*Case{
+		{Name: "mit", Content: readLicense(t, "MIT.txt"), Expected: []string{"mit"}},
+		{Name: "gpl", Content: readLicense(t, "GPL-2.0.txt"), Expected: []string{"GPL-2.0-or-later"}},
+		{Name: "mislabeled", Content: readLicense(t, "ISC.txt"), Expected: []string{"Apache-2.0"}},
+		{Name: "reference", Content: []byte("Licensed under the MIT license."), Expected: []string{}},
+	}
+	r := Evaluate(c, cases)
+
+	if got, want := len(r.Results), len(cases); got != want {
+		t.Fatalf("Evaluate() returned %d results, want %d", got, want)
+	}
+	want := &Result{Case: cases[2], Missing: []string{"apache-2.0"}, Unexpected: []string{"isc"}}
+	if diff := cmp.Diff(want, r.Results[2]); diff != "" {
+		t.Errorf("Evaluate() mislabeled result mismatch (-want +got):\n%s", diff)
+	}
+	if got := r.Failures(); len(got) != 1 || got[0] != r.Results[2] {
+		t.Errorf("Failures() = %v, want the mislabeled case", got)
+	}
+	if r.TruePositives != 2 || r.FalsePositives != 1 || r.FalseNegatives != 1 {
+		t.Errorf("Evaluate() counts = %d/%d/%d, want 2/1/1", r.TruePositives, r.FalsePositives, r.FalseNegatives)
+	}
+	if got := r.Precision(); got != 2.0/3 {
+		t.Errorf("Precision() = %v, want %v", got, 2.0/3)
+	}
+	if got := r.Recall(); got != 2.0/3 {
+		t.Errorf("Recall() = %v, want %v", got, 2.0/3)
+	}
+	if got := r.F1(); got < 0.666 || got > 0.667 {
+		t.Errorf("F1() = %v, want 2/3", got)
+	}
+}
+
+func TestByLicense(t *testing.T) {
+	r := &Report{Results: []*Result{
+		{Found: []string{"mit"}},
+		{Found: []string{"mit"}, Unexpected: []string{"isc"}},
+		{Missing: []string{"apache-2.0", "mit"}},
+	}}
+	want := []*LicenseStats{
+		{License: "apache-2.0", Counts: Counts{FalseNegatives: 1}},
+		{License: "isc", Counts: Counts{FalsePositives: 1}},
+		{License: "mit", Counts: Counts{TruePositives: 2, FalseNegatives: 1}},
+	}
+	got := r.ByLicense()
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("ByLicense() mismatch (-want +got):\n%s", diff)
+	}
+	if p, rec := got[2].Precision(), got[2].Recall(); p != 1 || rec != 2.0/3 {
+		t.Errorf("mit precision and recall = %v, %v, want 1, 2/3", p, rec)
+	}
+	if f := got[0].F1(); f != 0 {
+		t.Errorf("apache-2.0 F1 = %v, want 0", f)
+	}
+}
+
+func TestEvaluateEmpty(t *testing.T) {
+	r := &Report{}
+	if r.Precision() != 1 || r.Recall() != 1 || r.F1() != 1 {
+		t.Errorf("empty report = %v/%v/%v, want 1/1/1", r.Precision(), r.Recall(), r.F1())
+	}
+}
+
+func TestEvaluateScenarios(t *testing.T) {
+	cases, err := LoadScenarios(filepath.Join("..", "scenarios"))
+	if err != nil {
+		t.Fatalf("LoadScenarios() failed: %v", err)
+	}
+	r := Evaluate(newClassifier(t), cases)
+	for _, f := range r.Failures() {
+		t.Errorf("%s: missing %v, unexpected %v", f.Case.Name, f.Missing, f.Unexpected)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	for in, want := range map[string]string{
+		"GPL-2.0-only":     "gpl-2.0",
+		"GPL-2.0-or-later": "gpl-2.0",
+		"LGPL-2.1+":        "lgpl-2.1",
+		"gpl-2.0-plus":     "gpl-2.0",
+		" MIT ":            "mit",
+	} {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
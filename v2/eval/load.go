@@ -0,0 +1,261 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// LoadScenarios loads the cases of a directory of scenarios in the format of
+// the scenarios of this repository: any number of description lines, then a
+// line of the form "EXPECTED:A,B" listing the licenses of the content that
+// follows, or "EXPECTED:" for content without licenses. Markdown files, which
+// document the scenarios, are ignored.
+func LoadScenarios(dir string) ([]*Case, error) {
+	var cases []*Case
+	err := walkFiles(dir, func(path string) error {
+		if strings.HasSuffix(path, ".md") {
+			return nil
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		parts := bytes.SplitN(b, []byte("EXPECTED:"), 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("%s: no EXPECTED line", path)
+		}
+		lines := bytes.SplitN(parts[1], []byte("\n"), 2)
+		tc := &Case{Name: path, Expected: splitList(string(lines[0]), ",")}
+		if len(lines) == 2 {
+			tc.Content = lines[1]
+		}
+		cases = append(cases, tc)
+		return nil
+	})
+	return cases, err
+}
+
+// spdxSample matches the names of the files of the SPDX license test corpus:
+// the license identifier, optionally followed by the number of the sample
+// for licenses with several.
+var spdxSample = regexp.MustCompile(`^(.+?)(?:_\d+)?\.txt$`)
+
+// LoadSPDX loads the cases of a directory laid out like the SPDX license test
+// files, where each file holds a sample of a single license and is named
+// after its identifier, such as "Apache-2.0.txt", with an optional "_<n>"
+// suffix to distinguish several samples of a license ("MIT_2.txt"). Files
+// without the .txt extension are ignored.
+func LoadSPDX(dir string) ([]*Case, error) {
+	var cases []*Case
+	err := walkFiles(dir, func(path string) error {
+		m := spdxSample.FindStringSubmatch(filepath.Base(path))
+		if m == nil {
+			return nil
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		cases = append(cases, &Case{Name: path, Content: b, Expected: []string{m[1]}})
+		return nil
+	})
+	return cases, err
+}
+
+// LoadScanCode loads the cases of a directory laid out like the data-driven
+// license detection tests of ScanCode, where the expected licenses of each
+// file are listed in a YAML file of the same name with a .yml extension
+// appended ("mit.txt" and "mit.txt.yml"), under license_expressions or, in
+// older versions, licenses. Expressions are split into the licenses and
+// exceptions they combine. ScanCode license keys are compared with the
+// identifiers reported by the classifier regardless of case, so keys that
+// differ from the SPDX identifier of a license will be reported as missing.
+func LoadScanCode(dir string) ([]*Case, error) {
+	var cases []*Case
+	err := walkFiles(dir, func(path string) error {
+		if !strings.HasSuffix(path, ".yml") {
+			return nil
+		}
+		content := strings.TrimSuffix(path, ".yml")
+		if _, err := os.Stat(content); err != nil {
+			// Some YAML files describe the test data rather than a test.
+			return nil
+		}
+		y, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadFile(content)
+		if err != nil {
+			return err
+		}
+		var expected []string
+		for _, e := range yamlList(y, "license_expressions", "licenses") {
+			expected = append(expected, expressionLicenses(e)...)
+		}
+		cases = append(cases, &Case{Name: content, Content: b, Expected: dedup(expected)})
+		return nil
+	})
+	return cases, err
+}
+
+// LoadMapping loads the cases listed by a mapping file, which maps files to
+// the licenses they are known to contain. Each line of the file holds the
+// path of a file, relative to the directory of the mapping file, followed by
+// the identifiers of its licenses, separated by whitespace or commas; a path
+// alone declares a file without licenses. Blank lines and lines starting with
+// # are ignored. Paths containing spaces aren't supported.
+//
+//	# path              licenses
+//	vendor/a/LICENSE    MIT
+//	vendor/b/COPYING    GPL-2.0-or-later, Classpath-exception-2.0
+//	src/main.go
+func LoadMapping(file string) ([]*Case, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(file)
+	var cases []*Case
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(strings.ReplaceAll(line, ",", " "))
+		path := filepath.Join(dir, filepath.FromSlash(fields[0]))
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", file, n, err)
+		}
+		cases = append(cases, &Case{Name: path, Content: content, Expected: dedup(fields[1:])})
+	}
+	return cases, nil
+}
+
+// walkFiles calls fn with the paths of the files under dir in lexical order,
+// skipping hidden files and directories.
+func walkFiles(dir string, fn func(path string) error) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		return fn(path)
+	})
+}
+
+// yamlList returns the items of the first of the named top-level list keys
+// present in a YAML document, written in block style ("- item" lines) or
+// flow style ("[a, b]").
+func yamlList(y []byte, keys ...string) []string {
+	lines := make(map[string][]string)
+	var current string
+	s := bufio.NewScanner(bytes.NewReader(y))
+	for s.Scan() {
+		line := s.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+		case line[0] != ' ' && line[0] != '-':
+			current = ""
+			i := strings.Index(line, ":")
+			if i < 0 {
+				continue
+			}
+			current = line[:i]
+			rest := strings.TrimSpace(line[i+1:])
+			if strings.HasPrefix(rest, "[") && strings.HasSuffix(rest, "]") {
+				lines[current] = append(lines[current], splitList(rest[1:len(rest)-1], ",")...)
+				current = ""
+			}
+		case current != "" && strings.HasPrefix(trimmed, "- "):
+			lines[current] = append(lines[current], unquote(strings.TrimSpace(trimmed[2:])))
+		}
+	}
+	for _, k := range keys {
+		if l, ok := lines[k]; ok {
+			return l
+		}
+	}
+	return nil
+}
+
+// expressionLicenses returns the licenses and exceptions of a license
+// expression, such as "(mit OR apache-2.0) AND gpl-2.0 WITH
+// classpath-exception-2.0".
+func expressionLicenses(expr string) []string {
+	expr = strings.NewReplacer("(", " ", ")", " ").Replace(expr)
+	var out []string
+	for _, w := range strings.Fields(expr) {
+		switch strings.ToUpper(w) {
+		case "AND", "OR", "WITH":
+			continue
+		}
+		out = append(out, w)
+	}
+	return out
+}
+
+// splitList splits a separated list, trimming spaces and quotes from its
+// items and omitting empty ones.
+func splitList(s, sep string) []string {
+	out := []string{}
+	for _, item := range strings.Split(s, sep) {
+		if item = unquote(strings.TrimSpace(item)); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// dedup returns the distinct strings of l, sorted.
+func dedup(l []string) []string {
+	seen := make(map[string]bool)
+	out := []string{}
+	for _, s := range l {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
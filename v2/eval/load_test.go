@@ -0,0 +1,170 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// expectations returns the expected licenses of cases by their names
+// relative to dir.
+func expectations(t *testing.T, dir string, cases []*Case) map[string][]string {
+	t.Helper()
+	out := make(map[string][]string)
+	for _, c := range cases {
+		rel, err := filepath.Rel(dir, c.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out[filepath.ToSlash(rel)] = c.Expected
+	}
+	return out
+}
+
+func TestLoadScenarios(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"one":     "A description.\nEXPECTED:MIT,Apache-2.0\nThe content.\n",
+		"none":    "EXPECTED:\nNo license here.\n",
+		".hidden": "EXPECTED:MIT\n",
+	})
+	cases, err := LoadScenarios(dir)
+	if err != nil {
+		t.Fatalf("LoadScenarios() failed: %v", err)
+	}
+	want := map[string][]string{"one": {"MIT", "Apache-2.0"}, "none": {}}
+	if diff := cmp.Diff(want, expectations(t, dir, cases)); diff != "" {
+		t.Errorf("LoadScenarios() mismatch (-want +got):\n%s", diff)
+	}
+	for _, c := range cases {
+		if filepath.Base(c.Name) == "one" && string(c.Content) != "The content.\n" {
+			t.Errorf("LoadScenarios() content = %q, want the text after EXPECTED", c.Content)
+		}
+	}
+
+	bad := writeFiles(t, map[string]string{"bad": "no expectation"})
+	if _, err := LoadScenarios(bad); err == nil {
+		t.Error("LoadScenarios() succeeded without an EXPECTED line, want error")
+	}
+}
+
+func TestLoadSPDX(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"Apache-2.0.txt":          "apache",
+		"MIT_2.txt":               "mit",
+		"sub/BSD-3-Clause.txt":    "bsd",
+		"README.md":               "readme",
+		"GPL-2.0-or-later.txt":    "gpl",
+		"CC-BY-SA-4.0_12.txt":     "cc",
+		"LGPL-2.1_x.txt":          "lgpl",
+		"BSD-2-Clause-Patent.txt": "bsd2",
+	})
+	cases, err := LoadSPDX(dir)
+	if err != nil {
+		t.Fatalf("LoadSPDX() failed: %v", err)
+	}
+	want := map[string][]string{
+		"Apache-2.0.txt":          {"Apache-2.0"},
+		"BSD-2-Clause-Patent.txt": {"BSD-2-Clause-Patent"},
+		"CC-BY-SA-4.0_12.txt":     {"CC-BY-SA-4.0"},
+		"GPL-2.0-or-later.txt":    {"GPL-2.0-or-later"},
+		"LGPL-2.1_x.txt":          {"LGPL-2.1_x"},
+		"MIT_2.txt":               {"MIT"},
+		"sub/BSD-3-Clause.txt":    {"BSD-3-Clause"},
+	}
+	if diff := cmp.Diff(want, expectations(t, dir, cases)); diff != "" {
+		t.Errorf("LoadSPDX() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadScanCode(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"lic1/mit.txt":     "mit",
+		"lic1/mit.txt.yml": "license_expressions:\n  - mit\nnotes: a note\n",
+		"lic1/dual.c":      "dual",
+		"lic1/dual.c.yml": `notes: |
+    licensed under either
+license_expressions:
+- (mit OR apache-2.0) AND gpl-2.0 WITH classpath-exception-2.0
+- mit
+`,
+		"lic2/old.txt":      "old",
+		"lic2/old.txt.yml":  "licenses: [bsd-new, 'zlib']\n",
+		"lic2/none.txt":     "none",
+		"lic2/none.txt.yml": "license_expressions:\n",
+		"lic2/data.yml":     "description: not a test\n",
+	})
+	cases, err := LoadScanCode(dir)
+	if err != nil {
+		t.Fatalf("LoadScanCode() failed: %v", err)
+	}
+	want := map[string][]string{
+		"lic1/mit.txt":  {"mit"},
+		"lic1/dual.c":   {"apache-2.0", "classpath-exception-2.0", "gpl-2.0", "mit"},
+		"lic2/old.txt":  {"bsd-new", "zlib"},
+		"lic2/none.txt": {},
+	}
+	if diff := cmp.Diff(want, expectations(t, dir, cases)); diff != "" {
+		t.Errorf("LoadScanCode() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadMapping(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"expected.txt":     "# path licenses\n\nvendor/a/LICENSE  MIT\nvendor/b/COPYING GPL-2.0-or-later, Classpath-exception-2.0\nsrc/main.go\n",
+		"vendor/a/LICENSE": "MIT text",
+		"vendor/b/COPYING": "GPL text",
+		"src/main.go":      "package main",
+	})
+	cases, err := LoadMapping(filepath.Join(dir, "expected.txt"))
+	if err != nil {
+		t.Fatalf("LoadMapping() failed: %v", err)
+	}
+	want := map[string][]string{
+		"vendor/a/LICENSE": {"MIT"},
+		"vendor/b/COPYING": {"Classpath-exception-2.0", "GPL-2.0-or-later"},
+		"src/main.go":      {},
+	}
+	if diff := cmp.Diff(want, expectations(t, dir, cases)); diff != "" {
+		t.Errorf("LoadMapping() mismatch (-want +got):\n%s", diff)
+	}
+	if len(cases) != 3 || string(cases[0].Content) != "MIT text" {
+		t.Errorf("LoadMapping() = %v, want the cases in the order of the mapping", cases)
+	}
+
+	missing := writeFiles(t, map[string]string{"expected.txt": "LICENSE MIT\n"})
+	if _, err := LoadMapping(filepath.Join(missing, "expected.txt")); err == nil {
+		t.Error("LoadMapping() succeeded with a missing file, want error")
+	}
+}
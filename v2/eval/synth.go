@@ -0,0 +1,217 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+// noiseWords are the words of the content generated around license texts,
+// resembling source code and prose unrelated to licensing.
+var noiseWords = []string{
+	"func", "return", "if", "else", "for", "range", "var", "const", "nil",
+	"err", "buf", "len", "index", "value", "config", "handler", "request",
+	"response", "client", "server", "cache", "token", "parse", "format",
+	"update", "delete", "insert", "select", "table", "column", "widget",
+	"render", "layout", "button", "event", "queue", "worker", "thread",
+	"timeout", "retry", "metrics", "logger", "debug", "the", "a", "of",
+	"and", "to", "in", "is", "this", "that", "with", "on", "be",
+}
+
+// Generator synthesizes cases from the texts of a license corpus: license
+// texts surrounded by unrelated content, texts mutated at a given rate of
+// word edits, and concatenations of texts. The content of the cases depends
+// only on the corpus, the seed and the sequence of calls, so generators
+// created alike produce the same cases.
+type Generator struct {
+	rng   *rand.Rand
+	names []string
+	texts map[string][]byte
+}
+
+// NewGenerator returns a generator of cases from the corpus in dir, laid out
+// like the licenses directory of this repository, with pseudo-random choices
+// made from the seed.
+func NewGenerator(dir string, seed int64) (*Generator, error) {
+	g := &Generator{rng: rand.New(rand.NewSource(seed)), texts: make(map[string][]byte)}
+	err := walkFiles(dir, func(path string) error {
+		if !strings.HasSuffix(path, ".txt") {
+			return nil
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(filepath.Base(path), ".txt")
+		g.names = append(g.names, name)
+		g.texts[name] = b
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(g.names) == 0 {
+		return nil, fmt.Errorf("no corpus entries in %s", dir)
+	}
+	sort.Strings(g.names)
+	return g, nil
+}
+
+// Entries returns the names of the corpus entries of the generator, such as
+// "MIT" or "Apache-2.0.header".
+func (g *Generator) Entries() []string {
+	return append([]string(nil), g.names...)
+}
+
+// text returns the text of the named corpus entry, or of a random one if the
+// name is empty.
+func (g *Generator) text(name string) (string, []byte, error) {
+	if name == "" {
+		name = g.names[g.rng.Intn(len(g.names))]
+	}
+	b, ok := g.texts[name]
+	if !ok {
+		return "", nil, fmt.Errorf("no corpus entry %q", name)
+	}
+	return name, b, nil
+}
+
+// WithNoise returns a case of the text of the named corpus entry, or of a
+// random one if the name is empty, with the given number of lines of
+// unrelated content before and after it.
+func (g *Generator) WithNoise(name string, lines int) (*Case, error) {
+	name, b, err := g.text(name)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(g.noise(lines))
+	buf.Write(b)
+	if len(b) > 0 && b[len(b)-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+	buf.WriteString(g.noise(lines))
+	return &Case{
+		Name:     fmt.Sprintf("noise(%s,%d)", name, lines),
+		Content:  buf.Bytes(),
+		Expected: []string{classifier.LicenseName(name)},
+	}, nil
+}
+
+// Mutated returns a case of the text of the named corpus entry, or of a
+// random one if the name is empty, in which each word is edited with the
+// given probability: replaced by an unrelated word, deleted, or followed by
+// an inserted word. The case expects the license of the entry, which the
+// classifier is only expected to find at rates well below one minus its
+// threshold.
+func (g *Generator) Mutated(name string, rate float64) (*Case, error) {
+	if rate < 0 || rate > 1 {
+		return nil, fmt.Errorf("edit rate %v out of range [0, 1]", rate)
+	}
+	name, b, err := g.text(name)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for _, line := range strings.SplitAfter(string(b), "\n") {
+		nl := strings.HasSuffix(line, "\n")
+		var out []string
+		for _, w := range strings.Fields(line) {
+			if g.rng.Float64() >= rate {
+				out = append(out, w)
+				continue
+			}
+			switch g.rng.Intn(3) {
+			case 0:
+				out = append(out, g.word())
+			case 1:
+			case 2:
+				out = append(out, w, g.word())
+			}
+		}
+		buf.WriteString(strings.Join(out, " "))
+		if nl {
+			buf.WriteByte('\n')
+		}
+	}
+	return &Case{
+		Name:     fmt.Sprintf("mutated(%s,%v)", name, rate),
+		Content:  buf.Bytes(),
+		Expected: []string{classifier.LicenseName(name)},
+	}, nil
+}
+
+// Concatenated returns a case of the texts of the named corpus entries, one
+// after the other and separated by a line of unrelated content. If no names
+// are given, the texts of two random entries are concatenated.
+func (g *Generator) Concatenated(names ...string) (*Case, error) {
+	if len(names) == 0 {
+		names = []string{"", ""}
+	}
+	var buf bytes.Buffer
+	var used, expected []string
+	seen := make(map[string]bool)
+	for i, n := range names {
+		name, b, err := g.text(n)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteString(g.noise(1))
+		}
+		buf.Write(b)
+		if len(b) > 0 && b[len(b)-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+		used = append(used, name)
+		if l := classifier.LicenseName(name); !seen[l] {
+			seen[l] = true
+			expected = append(expected, l)
+		}
+	}
+	return &Case{
+		Name:     fmt.Sprintf("concatenated(%s)", strings.Join(used, ",")),
+		Content:  buf.Bytes(),
+		Expected: expected,
+	}, nil
+}
+
+// noise returns lines of unrelated content.
+func (g *Generator) noise(lines int) string {
+	var sb strings.Builder
+	for i := 0; i < lines; i++ {
+		n := 3 + g.rng.Intn(8)
+		for j := 0; j < n; j++ {
+			if j > 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(g.word())
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+func (g *Generator) word() string {
+	return noiseWords[g.rng.Intn(len(noiseWords))]
+}
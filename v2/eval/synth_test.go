@@ -0,0 +1,113 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newGenerator(t *testing.T, seed int64) *Generator {
+	t.Helper()
+	g, err := NewGenerator(filepath.Join("..", "licenses"), seed)
+	if err != nil {
+		t.Fatalf("NewGenerator() failed: %v", err)
+	}
+	return g
+}
+
+// generate returns cases of each kind, including ones of random entries.
+func generate(t *testing.T, g *Generator) []*Case {
+	t.Helper()
+	var cases []*Case
+	for _, fn := range []func() (*Case, error){
+		func() (*Case, error) { return g.WithNoise("Apache-2.0", 20) },
+		func() (*Case, error) { return g.WithNoise("", 5) },
+		func() (*Case, error) { return g.Mutated("GPL-2.0", 0.02) },
+		func() (*Case, error) { return g.Mutated("", 0.1) },
+		func() (*Case, error) { return g.Concatenated("MIT", "BSD-3-Clause", "MIT") },
+		func() (*Case, error) { return g.Concatenated() },
+	} {
+		tc, err := fn()
+		if err != nil {
+			t.Fatal(err)
+		}
+		cases = append(cases, tc)
+	}
+	return cases
+}
+
+func TestGeneratorDeterministic(t *testing.T) {
+	a := generate(t, newGenerator(t, 1))
+	b := generate(t, newGenerator(t, 1))
+	if diff := cmp.Diff(a, b); diff != "" {
+		t.Errorf("generators with the same seed differ (-first +second):\n%s", diff)
+	}
+	if c := generate(t, newGenerator(t, 2)); cmp.Equal(a, c) {
+		t.Error("generators with different seeds generated the same cases")
+	}
+}
+
+func TestGeneratorCases(t *testing.T) {
+	c := newClassifier(t)
+	cases := generate(t, newGenerator(t, 1))
+	for _, i := range []int{0, 2, 4} {
+		r := Evaluate(c, cases[i:i+1])
+		if res := r.Results[0]; len(res.Missing) > 0 || len(res.Unexpected) > 0 {
+			t.Errorf("%s: missing %v, unexpected %v", cases[i].Name, res.Missing, res.Unexpected)
+		}
+	}
+	if got, want := cases[4].Expected, []string{"MIT", "BSD-3-Clause"}; !cmp.Equal(got, want) {
+		t.Errorf("Concatenated() expected %v, want %v", got, want)
+	}
+}
+
+func TestGeneratorMutated(t *testing.T) {
+	g := newGenerator(t, 1)
+	tc, err := g.Mutated("MIT", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Fields(string(tc.Content)), strings.Fields(string(readLicense(t, "MIT.txt"))); !cmp.Equal(got, want) {
+		t.Errorf("Mutated(MIT, 0) changed the words of the text:\n%s", tc.Content)
+	}
+	tc, err = g.Mutated("MIT", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(tc.Content, readLicense(t, "MIT.txt")) {
+		t.Error("Mutated(MIT, 1) didn't change the text")
+	}
+}
+
+func TestGeneratorErrors(t *testing.T) {
+	g := newGenerator(t, 1)
+	if _, err := g.WithNoise("No-Such-License", 1); err == nil {
+		t.Error("WithNoise() of an unknown entry succeeded")
+	}
+	if _, err := g.Mutated("MIT", 1.5); err == nil {
+		t.Error("Mutated() with a rate above 1 succeeded")
+	}
+	if _, err := g.Concatenated("MIT", "No-Such-License"); err == nil {
+		t.Error("Concatenated() of an unknown entry succeeded")
+	}
+	if _, err := NewGenerator(t.TempDir(), 1); err == nil {
+		t.Error("NewGenerator() of an empty directory succeeded")
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// The tokenizer treats interchangeable words such as "licence" and "license"
+// as the same, which is right for almost all licenses. For some, though, the
+// spelling is part of a proper name and changing it changes the license. The
+// phrases declared here are compared as written, before equivalences are
+// applied, and each occurrence that differs between a corpus entry and the
+// text it's matched against counts as an edit.
+
+// defaultExemptions are the normalization exemptions of the standard corpus,
+// keyed by license name.
+var defaultExemptions = map[string][]string{
+	"EUPL-1.0": {"European Union Public Licence"},
+	"EUPL-1.1": {"European Union Public Licence"},
+}
+
+// exemption is a phrase exempt from equivalent-word normalization.
+type exemption struct {
+	phrase string
+	re     *regexp.Regexp
+}
+
+func newExemption(phrase string) *exemption {
+	words := strings.Fields(exemptionText([]byte(phrase)))
+	for i, w := range words {
+		words[i] = regexp.QuoteMeta(w)
+	}
+	return &exemption{
+		phrase: phrase,
+		re:     regexp.MustCompile(`\b` + strings.Join(words, `\s+`) + `\b`),
+	}
+}
+
+// SetNormalizationExemptions declares phrases of a license that must match as
+// written rather than after equivalent words have been normalized. The name
+// is either a license name, applying to all of its corpus entries, or the
+// name of a single corpus entry. Exemptions apply to content added to the
+// corpus after they are set, so they must be declared before loading the
+// corpus. Setting exemptions replaces those previously declared for the name,
+// including the defaults of the standard corpus.
+func (c *Classifier) SetNormalizationExemptions(name string, phrases []string) {
+	var ex []*exemption
+	for _, p := range phrases {
+		ex = append(ex, newExemption(p))
+	}
+	defer c.update()()
+	c.exemptions[name] = ex
+}
+
+// exemptionsFor returns the exemptions for the named corpus entry.
+func (c *Classifier) exemptionsFor(name string) []*exemption {
+	if ex, ok := c.exemptions[name]; ok {
+		return ex
+	}
+	return c.exemptions[LicenseName(name)]
+}
+
+// exemptionText returns the content lower-cased and normalized as the
+// tokenizer does, except for the substitution of equivalent words.
+func exemptionText(in []byte) string {
+	s := strings.ToLower(normalizeUnicode(string(in)))
+	s = html.UnescapeString(s)
+	s = normalizeURLs(s)
+	return normalizePunctuation(s)
+}
+
+// exemptionCounts counts the occurrences of the phrases of each exemption in
+// the text.
+func exemptionCounts(text string, ex []*exemption) map[string]int {
+	counts := make(map[string]int)
+	for _, e := range ex {
+		counts[e.phrase] = len(e.re.FindAllStringIndex(text, -1))
+	}
+	return counts
+}
+
+// exemptionDistance returns the number of edits due to occurrences of the
+// exempt phrases of the known document that differ in the tokens start
+// through end of the unknown document.
+func exemptionDistance(unknown, known *indexedDocument, start, end int) int {
+	if known.exemptions == nil || start >= end {
+		return 0
+	}
+	if unknown.exemptLines == nil {
+		unknown.exemptLines = strings.Split(exemptionText(unknown.content), "\n")
+	}
+	first, last := int(unknown.Tokens[start].Line), int(unknown.Tokens[end-1].Line)
+	if last > len(unknown.exemptLines) {
+		last = len(unknown.exemptLines)
+	}
+	text := strings.Join(unknown.exemptLines[first-1:last], "\n")
+
+	distance := 0
+	for _, e := range known.exemptions {
+		diff := known.exemptCounts[e.phrase] - len(e.re.FindAllStringIndex(text, -1))
+		if diff < 0 {
+			diff = -diff
+		}
+		distance += diff * len(strings.Fields(e.phrase))
+	}
+	return distance
+}
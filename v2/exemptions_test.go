@@ -0,0 +1,96 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExemptionCounts(t *testing.T) {
+	ex := []*exemption{newExemption("Public Licence"), newExemption("Licence")}
+	text := exemptionText([]byte("This Public\nLicence, the LICENCE and licences under\nthe Public License."))
+	got := exemptionCounts(text, ex)
+	if got["Public Licence"] != 1 || got["Licence"] != 2 {
+		t.Errorf("exemptionCounts() = %v, want 1 Public Licence and 2 Licence", got)
+	}
+}
+
+func TestNormalizationExemptions(t *testing.T) {
+	const known = `This Example Public Licence applies to any work which is provided under the
+terms of this Example Public Licence. The work may be used, modified and
+redistributed by any person, provided that this notice is retained in all
+copies and that the name of the Example Public Licence is not changed.`
+
+	tests := []struct {
+		name    string
+		exempt  bool
+		input   string
+		perfect bool
+	}{
+		{
+			name:    "without exemptions the spelling is normalized",
+			input:   strings.ReplaceAll(known, "Licence", "License"),
+			perfect: true,
+		},
+		{
+			name:    "exact text",
+			exempt:  true,
+			input:   known,
+			perfect: true,
+		},
+		{
+			name:    "other exempt spelling",
+			exempt:  true,
+			input:   strings.ReplaceAll(known, "Licence", "License"),
+			perfect: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewClassifier(.8)
+			if test.exempt {
+				c.SetNormalizationExemptions("Example", []string{"Example Public Licence"})
+			}
+			c.AddContent("Example", []byte(known))
+
+			m := c.Match([]byte(test.input))
+			if len(m) != 1 {
+				t.Fatalf("Match() = %d matches, want 1", len(m))
+			}
+			if got := m[0].Confidence == 1.0; got != test.perfect {
+				t.Errorf("Match() confidence = %v, want perfect match %v", m[0].Confidence, test.perfect)
+			}
+
+			e, err := c.Explain([]byte(test.input), m[0])
+			if err != nil {
+				t.Fatalf("Explain() failed: %v", err)
+			}
+			if want := confidencePercentage(e.KnownLength, e.Distance); want != m[0].Confidence {
+				t.Errorf("Explain() distance %d gives confidence %v, want %v", e.Distance, want, m[0].Confidence)
+			}
+		})
+	}
+}
+
+func TestDefaultExemptions(t *testing.T) {
+	c := NewClassifier(.8)
+	if ex := c.exemptionsFor("EUPL-1.1"); len(ex) == 0 {
+		t.Error("exemptionsFor(EUPL-1.1) is empty, want the proper name of the license")
+	}
+	if ex := c.exemptionsFor("MIT"); len(ex) != 0 {
+		t.Errorf("exemptionsFor(MIT) = %v, want none", ex)
+	}
+}
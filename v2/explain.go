@@ -0,0 +1,205 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// Explanation holds the evidence behind a single match: the text that was
+// matched, the corpus text it was compared against, the diff between them and
+// how that diff was scored.
+type Explanation struct {
+	// Match is the match being explained.
+	Match *Match
+	// Variant is the name of the corpus entry the match was scored against.
+	Variant string
+	// MatchedText is the content of the lines of the input covered by the match.
+	MatchedText string
+	// KnownText is the normalized text of the corpus entry.
+	KnownText string
+	// Diffs is the word diff from the normalized matched text to KnownText.
+	Diffs []diffmatchpatch.Diff
+	// KnownLength is the number of tokens in the corpus entry.
+	KnownLength int
+	// Insertions and Deletions are the number of words the matched text lacks
+	// or adds relative to the corpus entry.
+	Insertions, Deletions int
+	// Distance is the word Levenshtein distance used to compute the confidence.
+	Distance int
+	// MaxDistance is the largest distance of a match of the corpus entry at
+	// the threshold of the classifier.
+	MaxDistance int
+	// Rules describes the decisions of the rules that inspect the diff for
+	// unacceptable changes.
+	Rules []string
+
+	// source is the content the match was found in.
+	source []byte
+	// tokens are the tokens of source, and first is the index of the token
+	// corresponding to the start of Diffs.
+	tokens []*token
+	first  int
+	// clauses are the clauses of the corpus entry.
+	clauses []clause
+	// verdict describes the decision of the diff rule that decided about
+	// the diff, if any.
+	verdict string
+	// templateEdits are the word edits the template of the corpus entry
+	// allows, which aren't counted in Distance.
+	templateEdits int
+}
+
+// rejectionReasons describes the negative results of scoreDiffs.
+var rejectionReasons = map[int]string{
+	versionChange:          "rejected: the license version was changed",
+	introducedPhraseChange: "rejected: a phrase identifying a different license was introduced",
+	lesserGPLChange:        "rejected: Lesser was added or removed in a GNU license",
+	creativeCommonsChange:  "rejected: a Creative Commons license element was added or removed",
+	distanceExceeded:       "rejected: too many word edits for the threshold",
+}
+
+// Explain produces the evidence for a match previously returned by Match for
+// the same input. Matches that aren't backed by a corpus text, such as
+// references, are explained by the matched text alone.
+func (c *Classifier) Explain(in []byte, m *Match) (*Explanation, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e := &Explanation{
+		Match:       m,
+		MatchedText: sourceLines(in, m.StartLine, m.EndLine),
+		source:      in,
+	}
+	switch m.MatchType {
+	case referenceType:
+		e.Rules = []string{"accepted: license referenced by name"}
+		return e, nil
+	case grantType:
+		e.Rules = []string{"accepted: informal permission statement, which needs review"}
+		return e, nil
+	case proprietaryType:
+		e.Rules = []string{"accepted: proprietary terms recognized by " + strings.Join(m.Phrases, ", ")}
+		return e, nil
+	case partialType:
+		if p := m.Portion; p != nil {
+			e.Rules = []string{fmt.Sprintf("accepted: %.0f%% of the license text, from %s to %s", 100*p.Share(), p.FirstClause, p.LastClause)}
+			return e, nil
+		}
+	}
+
+	doc := c.tokenize(c.stripMarkup(in))
+	id := c.generateIndexedDocument(doc, false)
+	id.content = in
+	start, end := -1, -1
+	for i, t := range id.Tokens {
+		if int(t.Index) == m.StartTokenIndex {
+			start = i
+		}
+		if int(t.Index) == m.EndTokenIndex {
+			end = i + 1
+		}
+	}
+	if start == -1 || end == -1 || start >= end {
+		return nil, fmt.Errorf("match %s is not located in the supplied content", m.Name)
+	}
+
+	// Several corpus entries can produce the same license name and type, so
+	// the entry with the smallest distance explains the match, the first by
+	// name in case of a tie.
+	found := false
+	for _, name := range sortedNames(c.docs) {
+		known := c.docs[name]
+		if LicenseName(name) != m.Name || detectionType(name) != m.MatchType {
+			continue
+		}
+		all := docDiff(name, id, start, end, known, 0, known.size())
+		s, en := diffRange(known.norm, all)
+		diffs := all[s:en]
+		scored, allowed := diffs, 0
+		if known.template != nil {
+			scored, allowed = known.template.filter(diffs)
+		}
+		distance, verdict := c.applyDiffRules(name, scored, scoreDiffs(name, scored, noBound), noBound)
+		if found && (distance < 0 || (e.Distance >= 0 && distance >= e.Distance)) {
+			continue
+		}
+		found = true
+		e.verdict = verdict
+		e.templateEdits = allowed
+		e.Variant = name
+		e.KnownText = known.norm
+		e.KnownLength = known.size()
+		e.MaxDistance = known.maxDistance
+		e.clauses = known.clauses
+		e.Diffs = diffs
+		e.Distance = distance
+		e.first = start + targetLength(all[:s])
+	}
+	if !found {
+		return nil, &Error{Kind: ErrNoLicenseData, Context: fmt.Sprintf("no corpus entry for %s %s", m.MatchType, m.Name)}
+	}
+	e.tokens = doc.Tokens
+
+	for _, d := range e.Diffs {
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			e.Insertions += wordLen(d.Text)
+		case diffmatchpatch.DiffDelete:
+			e.Deletions += wordLen(d.Text)
+		}
+	}
+	if e.Distance >= 0 {
+		if x := exemptionDistance(id, c.docs[e.Variant], e.first, e.first+targetLength(e.Diffs)); x > 0 {
+			e.Distance += x
+			e.Rules = append(e.Rules, fmt.Sprintf("penalized: %d word edits in phrases exempt from normalization", x))
+		}
+	}
+	if e.templateEdits > 0 {
+		e.Rules = append(e.Rules, fmt.Sprintf("ignored: %d word edits in the variable and optional text of the template", e.templateEdits))
+	}
+	if e.verdict != "" {
+		e.Rules = append(e.Rules, e.verdict)
+	}
+	switch r, ok := rejectionReasons[e.Distance]; {
+	case ok:
+		e.Rules = append(e.Rules, r)
+	case e.Distance == ruleRejected:
+		// The verdict of the rule explains the rejection.
+	case e.Distance > e.MaxDistance:
+		e.Rules = append(e.Rules, fmt.Sprintf("rejected: %d word edits against %d known words, at most %d allowed", e.Distance, e.KnownLength, e.MaxDistance))
+	default:
+		e.Rules = append(e.Rules, fmt.Sprintf("accepted: %d word edits against %d known words, at most %d allowed", e.Distance, e.KnownLength, e.MaxDistance))
+	}
+	return e, nil
+}
+
+// sourceLines returns the lines start through end (1-based, inclusive) of in.
+func sourceLines(in []byte, start, end int) string {
+	lines := splitSourceLines(in)
+	if start < 1 {
+		start = 1
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+	if start > end {
+		return ""
+	}
+	return string(bytes.Join(lines[start-1:end], []byte("\n")))
+}
@@ -0,0 +1,76 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	c := NewClassifier(.8)
+	c.AddContent("Sample-1.0", []byte("permission is granted to use this sample software for any purpose without fee"))
+
+	in := []byte("// header\n// permission is granted to use this sample code for any purpose without fee\n")
+	m := c.Match(in)
+	if len(m) != 1 {
+		t.Fatalf("Match() = %d matches, want 1", len(m))
+	}
+
+	e, err := c.Explain(in, m[0])
+	if err != nil {
+		t.Fatalf("Explain() unexpected error: %v", err)
+	}
+	if e.Variant != "Sample-1.0" {
+		t.Errorf("Variant = %q, want Sample-1.0", e.Variant)
+	}
+	if want := "// permission is granted to use this sample code for any purpose without fee"; e.MatchedText != want {
+		t.Errorf("MatchedText = %q, want %q", e.MatchedText, want)
+	}
+	if e.Distance != 1 || e.Insertions != 1 || e.Deletions != 1 {
+		t.Errorf("Distance, Insertions, Deletions = %d, %d, %d, want 1, 1, 1", e.Distance, e.Insertions, e.Deletions)
+	}
+	if e.KnownLength != 13 {
+		t.Errorf("KnownLength = %d, want 13", e.KnownLength)
+	}
+	if e.MaxDistance != 2 {
+		t.Errorf("MaxDistance = %d, want 2", e.MaxDistance)
+	}
+	if len(e.Rules) != 1 || !strings.HasPrefix(e.Rules[0], "accepted") {
+		t.Errorf("Rules = %v, want a single acceptance", e.Rules)
+	}
+
+	if _, err := c.Explain(in, &Match{Name: "Unknown", MatchType: "License", StartTokenIndex: 0, EndTokenIndex: 3}); err == nil {
+		t.Errorf("Explain(unknown license) succeeded, want error")
+	}
+}
+
+func TestSourceLines(t *testing.T) {
+	in := []byte("one\ntwo\nthree\n")
+	tests := []struct {
+		start, end int
+		expected   string
+	}{
+		{1, 1, "one"},
+		{2, 3, "two\nthree"},
+		{3, 10, "three\n"},
+		{5, 4, ""},
+	}
+	for _, test := range tests {
+		if got := sourceLines(in, test.start, test.end); got != test.expected {
+			t.Errorf("sourceLines(%d, %d) = %q, want %q", test.start, test.end, got, test.expected)
+		}
+	}
+}
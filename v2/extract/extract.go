@@ -0,0 +1,97 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package extract recovers the text of documents stored in formats the
+// classifier can't read directly, such as the PDF and RTF files vendor license
+// agreements are often distributed as. It is kept separate from the classifier
+// so that programs only classifying plain text don't depend on it.
+//
+// The extracted text is suitable for classification, not display: layout is
+// reduced to line breaks and spaces.
+//
+//	f, err := os.Open("EULA.pdf")
+//	...
+//	r, err := extract.NewReader(f)
+//	...
+//	matches, err := c.MatchFrom(r)
+package extract
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+)
+
+// Format is the format of a document as determined by Detect.
+type Format string
+
+// Formats recognized by Detect.
+const (
+	Text Format = "text"
+	PDF  Format = "pdf"
+	RTF  Format = "rtf"
+)
+
+// ErrNoText is returned when a document contains no text that can be
+// extracted, such as a PDF consisting of scanned images.
+var ErrNoText = errors.New("extract: no text found in document")
+
+// Detect determines the format of a document from its leading bytes.
+// Documents that aren't PDF or RTF are reported as Text.
+func Detect(in []byte) Format {
+	switch {
+	case hasPDFHeader(in):
+		return PDF
+	case bytes.HasPrefix(bytes.TrimLeft(in, leadingSpace), []byte(`{\rtf`)):
+		return RTF
+	}
+	return Text
+}
+
+// leadingSpace is the whitespace and byte order mark allowed before the
+// header of a document.
+const leadingSpace = " \t\r\n\ufeff"
+
+// hasPDFHeader returns true if the document starts with the header of a PDF
+// file. Readers tolerate junk before the header, but text files that merely
+// mention "%PDF-" near their start mustn't be read as PDF.
+func hasPDFHeader(in []byte) bool {
+	return bytes.HasPrefix(bytes.TrimLeft(in, leadingSpace), []byte("%PDF-"))
+}
+
+// Bytes returns the text of the supplied document. Documents in a format
+// that isn't recognized are returned unchanged.
+func Bytes(in []byte) ([]byte, error) {
+	switch Detect(in) {
+	case PDF:
+		return ExtractPDF(in)
+	case RTF:
+		return ExtractRTF(in)
+	}
+	return in, nil
+}
+
+// NewReader reads the document from r and returns a reader of its text.
+func NewReader(r io.Reader) (io.Reader, error) {
+	in, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	out, err := Bytes(in)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(out), nil
+}
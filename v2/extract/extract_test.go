@@ -0,0 +1,85 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extract
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Format
+	}{
+		{input: "%PDF-1.7\n", want: PDF},
+		{input: "\ufeff\n%PDF-1.4\n", want: PDF},
+		{input: "junk\n%PDF-1.4\n", want: Text},
+		{input: "Files starting with %PDF- are PDF documents.", want: Text},
+		{input: "\ufeff{\\rtf1\\ansi text}", want: RTF},
+		{input: "Permission is hereby granted", want: Text},
+		{input: "", want: Text},
+	}
+	for _, test := range tests {
+		if got := Detect([]byte(test.input)); got != test.want {
+			t.Errorf("Detect(%q) = %v, want %v", test.input, got, test.want)
+		}
+	}
+}
+
+func TestMatchFrom(t *testing.T) {
+	c := classifier.NewClassifier(.8)
+	if err := c.LoadLicenses("../licenses"); err != nil {
+		t.Fatalf("couldn't load licenses: %v", err)
+	}
+	mit, err := ioutil.ReadFile("../licenses/MIT.txt")
+	if err != nil {
+		t.Fatalf("couldn't read license: %v", err)
+	}
+
+	var content, rtf strings.Builder
+	content.WriteString("BT /F1 10 Tf 12 TL 72 720 Td\n")
+	rtf.WriteString(`{\rtf1\ansi{\fonttbl{\f0 Arial;}}\f0 `)
+	for _, l := range strings.Split(string(mit), "\n") {
+		l = strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(l)
+		content.WriteString("(" + l + ") Tj T*\n")
+		rtf.WriteString(strings.NewReplacer("{", `\{`, "}", `\}`).Replace(l) + "\\par\n")
+	}
+	content.WriteString("ET")
+	rtf.WriteString("}")
+
+	for name, doc := range map[string][]byte{
+		"pdf": makePDF(content.String(), true),
+		"rtf": []byte(rtf.String()),
+		"txt": mit,
+	} {
+		t.Run(name, func(t *testing.T) {
+			r, err := NewReader(strings.NewReader(string(doc)))
+			if err != nil {
+				t.Fatalf("NewReader() failed: %v", err)
+			}
+			m, err := c.MatchFrom(r)
+			if err != nil {
+				t.Fatalf("MatchFrom() failed: %v", err)
+			}
+			if len(m) != 1 || m[0].Name != "MIT" || m[0].Confidence != 1.0 {
+				t.Errorf("MatchFrom() = %v, want a single exact MIT match", m)
+			}
+		})
+	}
+}
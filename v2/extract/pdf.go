@@ -0,0 +1,290 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// ExtractPDF returns the text shown by the content streams of a PDF document.
+//
+// Only the text operators of uncompressed and Flate-compressed streams are
+// interpreted, and strings are decoded as single-byte Latin text. That covers
+// the documents produced by common word processors; text in fonts with
+// custom encodings or in scanned images isn't recovered, and ErrNoText is
+// returned when nothing is.
+func ExtractPDF(in []byte) ([]byte, error) {
+	if !hasPDFHeader(in) {
+		return nil, errors.New("extract: not a PDF document")
+	}
+
+	var out strings.Builder
+	for _, s := range pdfStreams(in) {
+		if !bytes.Contains(s, []byte("BT")) {
+			continue
+		}
+		pdfText(s, &out)
+	}
+	if strings.TrimSpace(out.String()) == "" {
+		return nil, ErrNoText
+	}
+	return []byte(out.String()), nil
+}
+
+var (
+	streamKeyword    = []byte("stream")
+	endstreamKeyword = []byte("endstream")
+)
+
+// maxStreamSize and maxDecodedSize bound the size of a decompressed stream
+// and of all the decompressed streams of a document, since a small PDF can
+// hold streams that inflate without limit. Streams are cut at the first
+// bound and decoding stops at the second.
+var (
+	maxStreamSize  int64 = 16 << 20
+	maxDecodedSize int64 = 64 << 20
+)
+
+// pdfStreams returns the decoded content of the streams in a PDF document
+// that may contain text. Streams that can't be decoded are skipped.
+func pdfStreams(in []byte) [][]byte {
+	var out [][]byte
+	var decoded int64
+	pos := 0
+	for decoded < maxDecodedSize {
+		i := bytes.Index(in[pos:], streamKeyword)
+		if i == -1 {
+			return out
+		}
+		start := pos + i
+		pos = start + len(streamKeyword)
+		// Skip the "stream" at the end of "endstream".
+		if start >= 3 && bytes.HasSuffix(in[:start], []byte("end")) {
+			continue
+		}
+		// The stream data starts after the end of line following the keyword.
+		data := pos
+		if data < len(in) && in[data] == '\r' {
+			data++
+		}
+		if data < len(in) && in[data] == '\n' {
+			data++
+		}
+		end := bytes.Index(in[data:], endstreamKeyword)
+		if end == -1 {
+			return out
+		}
+		raw := bytes.TrimRight(in[data:data+end], "\r\n")
+		pos = data + end + len(endstreamKeyword)
+
+		// The stream dictionary follows the object header.
+		dict := in[:start]
+		if o := bytes.LastIndex(dict, []byte("obj")); o != -1 {
+			dict = dict[o:]
+		}
+		if bytes.Contains(dict, []byte("/Length1")) || bytes.Contains(dict, []byte("/Image")) {
+			// Embedded fonts and images.
+			continue
+		}
+		switch {
+		case bytes.Contains(dict, []byte("/FlateDecode")):
+			r, err := zlib.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				continue
+			}
+			limit := maxStreamSize
+			if rest := maxDecodedSize - decoded; rest < limit {
+				limit = rest
+			}
+			b, err := ioutil.ReadAll(io.LimitReader(r, limit))
+			if err != nil && len(b) == 0 {
+				continue
+			}
+			decoded += int64(len(b))
+			out = append(out, b)
+		case bytes.Contains(dict, []byte("/Filter")):
+			// Other filters are used for images and aren't supported.
+		default:
+			out = append(out, raw)
+		}
+	}
+	return out
+}
+
+// isPDFDelimiter returns true for the characters that end a token in a PDF
+// content stream.
+func isPDFDelimiter(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n', '\f', 0, '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+// pdfText interprets the text operators of a content stream, writing the text
+// they show to out.
+func pdfText(s []byte, out *strings.Builder) {
+	var strs []string  // Strings operands of the next operator.
+	var nums []float64 // Numeric operands of the next operator.
+	inArray := false
+	newline := func() {
+		if out.Len() > 0 && !strings.HasSuffix(out.String(), "\n") {
+			out.WriteString("\n")
+		}
+	}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '(':
+			str, n := pdfLiteral(s[i:])
+			strs = append(strs, str)
+			i += n
+		case c == '<' && i+1 < len(s) && s[i+1] == '<':
+			i += 2
+		case c == '>' && i+1 < len(s) && s[i+1] == '>':
+			i += 2
+		case c == '<':
+			end := bytes.IndexByte(s[i:], '>')
+			if end == -1 {
+				return
+			}
+			strs = append(strs, pdfHex(s[i+1:i+end]))
+			i += end + 1
+		case c == '[':
+			inArray = true
+			i++
+		case c == ']':
+			inArray = false
+			i++
+		case c == '%':
+			for i < len(s) && s[i] != '\n' && s[i] != '\r' {
+				i++
+			}
+		case isPDFDelimiter(c):
+			i++
+		default:
+			start := i
+			for i < len(s) && !isPDFDelimiter(s[i]) {
+				i++
+			}
+			tok := string(s[start:i])
+			if f, err := strconv.ParseFloat(tok, 64); err == nil {
+				if inArray && f < -200 && len(strs) > 0 {
+					// A large negative adjustment in a TJ array
+					// separates words.
+					strs[len(strs)-1] += " "
+				}
+				nums = append(nums, f)
+				continue
+			}
+			switch tok {
+			case "Tj", "TJ":
+				out.WriteString(strings.Join(strs, ""))
+			case "'", "\"":
+				newline()
+				out.WriteString(strings.Join(strs, ""))
+			case "T*", "ET":
+				newline()
+			case "Td", "TD":
+				if len(nums) >= 2 && nums[len(nums)-1] != 0 {
+					newline()
+				} else {
+					out.WriteString(" ")
+				}
+			}
+			strs, nums = nil, nil
+		}
+	}
+}
+
+// pdfLiteral decodes the literal string at the start of s, returning it along
+// with the number of bytes it occupies.
+func pdfLiteral(s []byte) (string, int) {
+	var out strings.Builder
+	depth := 0
+	i := 0
+	for ; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '(':
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case ')':
+			depth--
+			if depth == 0 {
+				return out.String(), i + 1
+			}
+		case '\\':
+			i++
+			if i == len(s) {
+				break
+			}
+			switch e := s[i]; e {
+			case 'n':
+				out.WriteByte('\n')
+			case 'r':
+				out.WriteByte('\r')
+			case 't':
+				out.WriteByte('\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// Line continuation.
+				if e == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+					i++
+				}
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				n := 0
+				j := i
+				for ; j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7'; j++ {
+					n = n*8 + int(s[j]-'0')
+				}
+				out.WriteRune(decodeCP1252(byte(n)))
+				i = j - 1
+			default:
+				out.WriteByte(e)
+			}
+			continue
+		}
+		out.WriteRune(decodeCP1252(c))
+	}
+	return out.String(), i
+}
+
+// pdfHex decodes the contents of a hexadecimal string.
+func pdfHex(s []byte) string {
+	var digits []byte
+	for _, c := range s {
+		if (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	var out strings.Builder
+	for i := 0; i < len(digits); i += 2 {
+		v, _ := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+		out.WriteRune(decodeCP1252(byte(v)))
+	}
+	return out.String()
+}
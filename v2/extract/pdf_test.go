@@ -0,0 +1,125 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// makePDF builds a PDF document with a single page showing the supplied
+// content stream, compressing it if requested.
+func makePDF(content string, compress bool) []byte {
+	stream := []byte(content)
+	filter := ""
+	if compress {
+		var b bytes.Buffer
+		w := zlib.NewWriter(&b)
+		w.Write(stream)
+		w.Close()
+		stream = b.Bytes()
+		filter = " /Filter /FlateDecode"
+	}
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	out.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	out.WriteString("2 0 obj\n<< /Type /Pages /Kids [3 0 R] /Count 1 >>\nendobj\n")
+	out.WriteString("3 0 obj\n<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>\nendobj\n")
+	fmt.Fprintf(&out, "4 0 obj\n<< /Length %d%s >>\nstream\n", len(stream), filter)
+	out.Write(stream)
+	out.WriteString("\nendstream\nendobj\n")
+	fmt.Fprintf(&out, "5 0 obj\n<< /Length 4 /Subtype /Image /Filter /DCTDecode >>\nstream\n\xff\xd8BT\nendstream\nendobj\n")
+	out.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return out.Bytes()
+}
+
+func TestExtractPDF(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		output  string
+	}{
+		{
+			name:    "show text",
+			content: "BT /F1 12 Tf 72 720 Td (Permission is hereby granted) Tj ET",
+			output:  "Permission is hereby granted\n",
+		},
+		{
+			name:    "line operators",
+			content: "BT /F1 12 Tf 14 TL (first) Tj T* (second) Tj 0 -14 Td (third) Tj (fourth) ' ET",
+			output:  "first\nsecond\nthird\nfourth\n",
+		},
+		{
+			name:    "kerned array",
+			content: "BT [(Redis) 20 (tribution) -250 (and) -300 (use)] TJ ET",
+			output:  "Redistribution and use\n",
+		},
+		{
+			name:    "escapes and hex strings",
+			content: `BT (\(c\) caf\351 \223AS IS\224) Tj <20414243> Tj ET`,
+			output:  "(c) café “AS IS” ABC\n",
+		},
+	}
+	for _, test := range tests {
+		for _, compress := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/compress=%v", test.name, compress), func(t *testing.T) {
+				got, err := ExtractPDF(makePDF(test.content, compress))
+				if err != nil {
+					t.Fatalf("ExtractPDF() failed: %v", err)
+				}
+				if string(got) != test.output {
+					t.Errorf("ExtractPDF() = %q, want %q", got, test.output)
+				}
+			})
+		}
+	}
+}
+
+func TestPDFStreamLimits(t *testing.T) {
+	defer func(stream, decoded int64) {
+		maxStreamSize, maxDecodedSize = stream, decoded
+	}(maxStreamSize, maxDecodedSize)
+	maxStreamSize, maxDecodedSize = 1000, 2500
+
+	bomb := makePDF(strings.Repeat("BT (a) Tj ET\n", 10000), true)
+	streams := pdfStreams(bomb)
+	if len(streams) != 1 || len(streams[0]) != 1000 {
+		t.Errorf("pdfStreams(bomb) = %d streams, want one cut at 1000 bytes", len(streams))
+	}
+
+	var doc []byte
+	for i := 0; i < 5; i++ {
+		doc = append(doc, bomb...)
+	}
+	var total int
+	for _, s := range pdfStreams(doc) {
+		total += len(s)
+	}
+	if total != 2500 {
+		t.Errorf("pdfStreams() decoded %d bytes, want 2500", total)
+	}
+}
+
+func TestExtractPDFErrors(t *testing.T) {
+	if _, err := ExtractPDF([]byte("plain text")); err == nil {
+		t.Error("ExtractPDF(plain text) succeeded, want error")
+	}
+	if _, err := ExtractPDF(makePDF("0 0 m 100 100 l S", true)); err != ErrNoText {
+		t.Errorf("ExtractPDF(no text) = %v, want %v", err, ErrNoText)
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extract
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// rtfDestinations are the groups of an RTF document that don't contain body
+// text.
+var rtfDestinations = map[string]bool{
+	"author":             true,
+	"colortbl":           true,
+	"colorschememapping": true,
+	"datastore":          true,
+	"filetbl":            true,
+	"fldinst":            true,
+	"fonttbl":            true,
+	"footer":             true,
+	"footerf":            true,
+	"footerl":            true,
+	"footerr":            true,
+	"generator":          true,
+	"header":             true,
+	"headerf":            true,
+	"headerl":            true,
+	"headerr":            true,
+	"info":               true,
+	"latentstyles":       true,
+	"listoverridetable":  true,
+	"listtable":          true,
+	"object":             true,
+	"pict":               true,
+	"revtbl":             true,
+	"rsidtbl":            true,
+	"stylesheet":         true,
+	"themedata":          true,
+	"xmlnstbl":           true,
+}
+
+// rtfSymbols are control words that stand for text.
+var rtfSymbols = map[string]string{
+	"par":       "\n",
+	"line":      "\n",
+	"sect":      "\n",
+	"page":      "\n",
+	"row":       "\n",
+	"cell":      " ",
+	"tab":       "\t",
+	"emdash":    "—",
+	"endash":    "–",
+	"emspace":   " ",
+	"enspace":   " ",
+	"qmspace":   " ",
+	"bullet":    "•",
+	"lquote":    "‘",
+	"rquote":    "’",
+	"ldblquote": "“",
+	"rdblquote": "”",
+}
+
+// cp1252 maps the bytes 0x80-0x9f of the Windows-1252 code page, which RTF
+// documents use for \'hh escapes, to Unicode. The remaining bytes are the
+// same as in ISO 8859-1. Undefined bytes are mapped to spaces.
+var cp1252 = [32]rune{
+	'€', 0, '‚', 'ƒ', '„', '…', '†', '‡',
+	'ˆ', '‰', 'Š', '‹', 'Œ', 0, 'Ž', 0,
+	0, '‘', '’', '“', '”', '•', '–', '—',
+	'˜', '™', 'š', '›', 'œ', 0, 'ž', 'Ÿ',
+}
+
+func decodeCP1252(b byte) rune {
+	if b >= 0x80 && b < 0xa0 {
+		if r := cp1252[b-0x80]; r != 0 {
+			return r
+		}
+		return ' '
+	}
+	return rune(b)
+}
+
+// rtfGroup is the state of a group in an RTF document.
+type rtfGroup struct {
+	skip bool // The group is a destination without body text.
+	uc   int  // The number of fallback characters following a \u escape.
+}
+
+// ExtractRTF returns the body text of an RTF document.
+func ExtractRTF(in []byte) ([]byte, error) {
+	in = bytes.TrimLeft(in, " \t\r\n\ufeff")
+	if !bytes.HasPrefix(in, []byte(`{\rtf`)) {
+		return nil, errors.New("extract: not an RTF document")
+	}
+
+	var out strings.Builder
+	stack := []rtfGroup{{uc: 1}}
+	// skipChars counts fallback characters that remain to be skipped after
+	// a \u escape.
+	skipChars := 0
+	emit := func(s string) {
+		if !stack[len(stack)-1].skip {
+			out.WriteString(s)
+		}
+	}
+	for i := 0; i < len(in); i++ {
+		c := in[i]
+		switch c {
+		case '{':
+			stack = append(stack, stack[len(stack)-1])
+			skipChars = 0
+			continue
+		case '}':
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+			skipChars = 0
+			continue
+		case '\r', '\n':
+			// Line breaks in RTF source aren't part of the text.
+			continue
+		}
+
+		if c != '\\' {
+			if skipChars > 0 {
+				skipChars--
+				continue
+			}
+			emit(string(decodeCP1252(c)))
+			continue
+		}
+
+		// A control symbol or control word.
+		i++
+		if i == len(in) {
+			break
+		}
+		c = in[i]
+		switch {
+		case c == '\'':
+			if i+2 < len(in) {
+				if v, err := strconv.ParseUint(string(in[i+1:i+3]), 16, 8); err == nil {
+					if skipChars > 0 {
+						skipChars--
+					} else {
+						emit(string(decodeCP1252(byte(v))))
+					}
+				}
+			}
+			i += 2
+		case c == '*':
+			stack[len(stack)-1].skip = true
+		case c == '~':
+			emit(" ")
+		case c == '_':
+			emit("-")
+		case c == '-':
+			// Optional hyphen.
+		case c == '\r' || c == '\n':
+			emit("\n")
+		case c == '\\' || c == '{' || c == '}':
+			emit(string(c))
+		case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			start := i
+			for i < len(in) && ((in[i] >= 'a' && in[i] <= 'z') || (in[i] >= 'A' && in[i] <= 'Z')) {
+				i++
+			}
+			word := string(in[start:i])
+			numStart := i
+			if i < len(in) && in[i] == '-' {
+				i++
+			}
+			for i < len(in) && in[i] >= '0' && in[i] <= '9' {
+				i++
+			}
+			param, hasParam := 0, i > numStart
+			if hasParam {
+				param, _ = strconv.Atoi(string(in[numStart:i]))
+			}
+			// A space delimiting the control word is part of it.
+			if i < len(in) && in[i] == ' ' {
+				i++
+			}
+			i--
+
+			switch {
+			case rtfDestinations[word]:
+				stack[len(stack)-1].skip = true
+			case word == "uc" && hasParam:
+				stack[len(stack)-1].uc = param
+			case word == "u" && hasParam:
+				if param < 0 {
+					param += 65536
+				}
+				emit(string(rune(param)))
+				skipChars = stack[len(stack)-1].uc
+			default:
+				if s, ok := rtfSymbols[word]; ok {
+					emit(s)
+				}
+			}
+		}
+	}
+
+	if strings.TrimSpace(out.String()) == "" {
+		return nil, ErrNoText
+	}
+	return []byte(out.String()), nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extract
+
+import (
+	"testing"
+)
+
+func TestExtractRTF(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		output string
+	}{
+		{
+			name:   "paragraphs",
+			input:  `{\rtf1\ansi\deff0 {\fonttbl {\f0 Times New Roman;}}\f0\fs24 Permission is \b granted\b0 .\par Second line.}`,
+			output: "Permission is granted.\nSecond line.",
+		},
+		{
+			name:   "skipped destinations",
+			input:  "{\\rtf1{\\info{\\author Someone}}{\\*\\generator Writer;}{\\colortbl;\\red0\\green0\\blue0;}Body}",
+			output: "Body",
+		},
+		{
+			name:   "escapes",
+			input:  `{\rtf1 \ldblquote AS IS\rdblquote  \'93quoted\'94 caf\'e9 \{braces\} a\~b}`,
+			output: "“AS IS” “quoted” café {braces} a b",
+		},
+		{
+			name:   "unicode",
+			input:  `{\rtf1\uc1 \u8220?AS IS\u8221? {\uc2 \u-3913??}}`,
+			output: "“AS IS” \uf0b7",
+		},
+		{
+			name:   "source line breaks",
+			input:  "{\\rtf1 Redistribution and\r\n use\\line in source}",
+			output: "Redistribution and use\nin source",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ExtractRTF([]byte(test.input))
+			if err != nil {
+				t.Fatalf("ExtractRTF() failed: %v", err)
+			}
+			if string(got) != test.output {
+				t.Errorf("ExtractRTF() = %q, want %q", got, test.output)
+			}
+		})
+	}
+}
+
+func TestExtractRTFErrors(t *testing.T) {
+	if _, err := ExtractRTF([]byte("plain text")); err == nil {
+		t.Error("ExtractRTF(plain text) succeeded, want error")
+	}
+	if _, err := ExtractRTF([]byte(`{\rtf1{\fonttbl{\f0 Arial;}}}`)); err != ErrNoText {
+		t.Errorf("ExtractRTF(empty document) = %v, want %v", err, ErrNoText)
+	}
+}
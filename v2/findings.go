@@ -0,0 +1,202 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"sort"
+)
+
+// Finding is a license found in a file by a scan, as recorded in a scan
+// report. Its JSON form is that of the records identify_license writes with
+// -format json or ndjson, so their reports can be compared directly.
+type Finding struct {
+	File       string  `json:"file"`
+	License    string  `json:"license"`
+	MatchType  string  `json:"matchType"`
+	Confidence float64 `json:"confidence"`
+	StartLine  int     `json:"startLine,omitempty"`
+	EndLine    int     `json:"endLine,omitempty"`
+}
+
+// FindingsOf returns the findings of the matches recorded in a scan state,
+// ordered by file.
+func FindingsOf(s *ScanState) []Finding {
+	files := make([]string, 0, len(s.Files))
+	for f := range s.Files {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	var out []Finding
+	for _, f := range files {
+		for _, m := range s.Files[f].Matches {
+			out = append(out, Finding{
+				File:       f,
+				License:    m.Name,
+				MatchType:  m.MatchType,
+				Confidence: m.Confidence,
+				StartLine:  m.StartLine,
+				EndLine:    m.EndLine,
+			})
+		}
+	}
+	return out
+}
+
+// ReadFindings reads the findings of a scan report: a JSON array of
+// findings or a finding per line, as identify_license writes with -format
+// json or ndjson, or a scan state saved by ScanState.Save.
+func ReadFindings(r io.Reader) ([]Finding, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("classifier couldn't read scan report: %w", err)
+	}
+	var out []Finding
+	dec := json.NewDecoder(bytes.NewReader(b))
+	for {
+		var v json.RawMessage
+		if err := dec.Decode(&v); err == io.EOF {
+			return out, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("classifier couldn't parse scan report: %w", err)
+		}
+		switch v = bytes.TrimSpace(v); {
+		case bytes.HasPrefix(v, []byte("[")):
+			var fs []Finding
+			if err := json.Unmarshal(v, &fs); err != nil {
+				return nil, fmt.Errorf("classifier couldn't parse scan report: %w", err)
+			}
+			out = append(out, fs...)
+		default:
+			var probe struct {
+				Files json.RawMessage `json:"files"`
+			}
+			if err := json.Unmarshal(v, &probe); err != nil {
+				return nil, fmt.Errorf("classifier couldn't parse scan report: %w", err)
+			}
+			if probe.Files != nil {
+				var s ScanState
+				if err := json.Unmarshal(v, &s); err != nil {
+					return nil, fmt.Errorf("classifier couldn't parse scan state: %w", err)
+				}
+				out = append(out, FindingsOf(&s)...)
+				continue
+			}
+			var f Finding
+			if err := json.Unmarshal(v, &f); err != nil {
+				return nil, fmt.Errorf("classifier couldn't parse scan report: %w", err)
+			}
+			out = append(out, f)
+		}
+	}
+}
+
+// ChangeKind is the kind of a change of the findings between two scans.
+type ChangeKind string
+
+const (
+	// FindingAdded is a license found in a file by the later scan only.
+	FindingAdded ChangeKind = "added"
+	// FindingRemoved is a license found in a file by the earlier scan only.
+	FindingRemoved ChangeKind = "removed"
+	// FindingChanged is a license found in a file by both scans, with
+	// another match type or confidence.
+	FindingChanged ChangeKind = "changed"
+)
+
+// FindingChange is a difference between the findings of two scans.
+type FindingChange struct {
+	Kind ChangeKind `json:"kind"`
+	// Before is the finding of the earlier scan, and After that of the
+	// later one. Before is nil for added findings, and After for removed
+	// ones.
+	Before *Finding `json:"before,omitempty"`
+	After  *Finding `json:"after,omitempty"`
+}
+
+// String describes the change, as in "added GPL-3.0 in vendor/foo/COPYING
+// (License, confidence: 1)".
+func (c FindingChange) String() string {
+	switch c.Kind {
+	case FindingAdded:
+		return fmt.Sprintf("added %s in %s (%s, confidence: %v)", c.After.License, c.After.File, c.After.MatchType, c.After.Confidence)
+	case FindingRemoved:
+		return fmt.Sprintf("removed %s in %s (%s, confidence: %v)", c.Before.License, c.Before.File, c.Before.MatchType, c.Before.Confidence)
+	}
+	return fmt.Sprintf("changed %s in %s (%s, confidence: %v -> %s, confidence: %v)", c.After.License, c.After.File,
+		c.Before.MatchType, c.Before.Confidence, c.After.MatchType, c.After.Confidence)
+}
+
+// confidenceEpsilon is the difference of confidence below which findings
+// are considered unchanged, so that confidences rounded when serialized
+// compare equal.
+const confidenceEpsilon = 1e-6
+
+// DiffFindings compares the findings of two scans, an earlier one and a
+// later one, returning the licenses added to and removed from each file and
+// those whose match type or confidence changed, ordered by file and license.
+// The findings of a license in a file are paired in the order of their
+// lines, so findings that merely moved within the file aren't reported.
+func DiffFindings(before, after []Finding) []FindingChange {
+	type key struct{ file, license string }
+	group := func(fs []Finding) map[key][]Finding {
+		g := make(map[key][]Finding)
+		for _, f := range fs {
+			k := key{f.File, f.License}
+			g[k] = append(g[k], f)
+		}
+		for _, fs := range g {
+			sort.SliceStable(fs, func(i, j int) bool { return fs[i].StartLine < fs[j].StartLine })
+		}
+		return g
+	}
+	b, a := group(before), group(after)
+	keys := make([]key, 0, len(a)+len(b))
+	for k := range b {
+		keys = append(keys, k)
+	}
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].file != keys[j].file {
+			return keys[i].file < keys[j].file
+		}
+		return keys[i].license < keys[j].license
+	})
+
+	var out []FindingChange
+	for _, k := range keys {
+		bs, as := b[k], a[k]
+		for i := 0; i < len(bs) || i < len(as); i++ {
+			switch {
+			case i >= len(as):
+				out = append(out, FindingChange{Kind: FindingRemoved, Before: &bs[i]})
+			case i >= len(bs):
+				out = append(out, FindingChange{Kind: FindingAdded, After: &as[i]})
+			case bs[i].MatchType != as[i].MatchType || math.Abs(bs[i].Confidence-as[i].Confidence) > confidenceEpsilon:
+				out = append(out, FindingChange{Kind: FindingChanged, Before: &bs[i], After: &as[i]})
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,157 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadFindings(t *testing.T) {
+	want := []Finding{
+		{File: "LICENSE", License: "MIT", MatchType: "License", Confidence: 1, StartLine: 1, EndLine: 17},
+		{File: "vendor/foo/COPYING", License: "GPL-3.0", MatchType: "License", Confidence: 0.98, StartLine: 1, EndLine: 619},
+	}
+	state, err := json.Marshal(&ScanState{Scope: "s", Files: map[string]*FileState{
+		"vendor/foo/COPYING": {Matches: Matches{{Name: "GPL-3.0", MatchType: "License", Confidence: 0.98, StartLine: 1, EndLine: 619}}},
+		"LICENSE":            {Matches: Matches{{Name: "MIT", MatchType: "License", Confidence: 1, StartLine: 1, EndLine: 17}}},
+		"main.go":            {},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		description string
+		report      string
+	}{
+		{
+			description: "JSON array",
+			report: `[
+  {"file": "LICENSE", "id": "8536fa528f4a9cb3", "license": "MIT", "spdxId": "MIT", "matchType": "License", "confidence": 1, "startLine": 1, "endLine": 17},
+  {"file": "vendor/foo/COPYING", "license": "GPL-3.0", "matchType": "License", "confidence": 0.98, "startLine": 1, "endLine": 619}
+]`,
+		},
+		{
+			description: "JSON per line",
+			report: `{"file":"LICENSE","license":"MIT","matchType":"License","confidence":1,"startLine":1,"endLine":17}
+{"file":"vendor/foo/COPYING","license":"GPL-3.0","matchType":"License","confidence":0.98,"startLine":1,"endLine":619}
+`,
+		},
+		{
+			description: "scan state",
+			report:      string(state),
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			got, err := ReadFindings(strings.NewReader(tt.report))
+			if err != nil {
+				t.Fatalf("ReadFindings() failed: %v", err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("ReadFindings() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if got, err := ReadFindings(strings.NewReader("")); err != nil || got != nil {
+		t.Errorf("ReadFindings(empty) = %v, %v, want no findings", got, err)
+	}
+	for _, in := range []string{`{"file": 1}`, `[{`, `"MIT"`} {
+		if _, err := ReadFindings(strings.NewReader(in)); err == nil {
+			t.Errorf("ReadFindings(%s) succeeded", in)
+		}
+	}
+}
+
+func TestDiffFindings(t *testing.T) {
+	mit := Finding{File: "LICENSE", License: "MIT", MatchType: "License", Confidence: 1, StartLine: 1, EndLine: 17}
+	header := Finding{File: "main.go", License: "Apache-2.0", MatchType: "Header", Confidence: 0.9, StartLine: 1, EndLine: 13}
+	gpl := Finding{File: "vendor/foo/COPYING", License: "GPL-3.0", MatchType: "License", Confidence: 1, StartLine: 1, EndLine: 619}
+	bsd := Finding{File: "vendor/bar/LICENSE", License: "BSD-3-Clause", MatchType: "License", Confidence: 1, StartLine: 1, EndLine: 27}
+
+	moved := header
+	moved.StartLine, moved.EndLine = 3, 15
+	stronger := moved
+	stronger.Confidence = 0.95
+	rounded := mit
+	rounded.Confidence = 1 - 1e-9
+	second := mit
+	second.StartLine, second.EndLine = 30, 46
+
+	for _, tt := range []struct {
+		description   string
+		before, after []Finding
+		want          []FindingChange
+	}{
+		{
+			description: "unchanged",
+			before:      []Finding{mit, header},
+			after:       []Finding{header, rounded},
+		},
+		{
+			description: "moved within the file",
+			before:      []Finding{header},
+			after:       []Finding{moved},
+		},
+		{
+			description: "added and removed",
+			before:      []Finding{mit, bsd},
+			after:       []Finding{gpl, mit},
+			want: []FindingChange{
+				{Kind: FindingRemoved, Before: &bsd},
+				{Kind: FindingAdded, After: &gpl},
+			},
+		},
+		{
+			description: "changed confidence",
+			before:      []Finding{header},
+			after:       []Finding{stronger},
+			want:        []FindingChange{{Kind: FindingChanged, Before: &header, After: &stronger}},
+		},
+		{
+			description: "another copy",
+			before:      []Finding{mit},
+			after:       []Finding{second, mit},
+			want:        []FindingChange{{Kind: FindingAdded, After: &second}},
+		},
+	} {
+		t.Run(tt.description, func(t *testing.T) {
+			got := DiffFindings(tt.before, tt.after)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("DiffFindings() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFindingChangeString(t *testing.T) {
+	before := Finding{File: "vendor/foo/COPYING", License: "GPL-3.0", MatchType: "Header", Confidence: 0.9}
+	after := Finding{File: "vendor/foo/COPYING", License: "GPL-3.0", MatchType: "License", Confidence: 1}
+	for _, tt := range []struct {
+		change FindingChange
+		want   string
+	}{
+		{FindingChange{Kind: FindingAdded, After: &after}, "added GPL-3.0 in vendor/foo/COPYING (License, confidence: 1)"},
+		{FindingChange{Kind: FindingRemoved, Before: &before}, "removed GPL-3.0 in vendor/foo/COPYING (Header, confidence: 0.9)"},
+		{FindingChange{Kind: FindingChanged, Before: &before, After: &after}, "changed GPL-3.0 in vendor/foo/COPYING (Header, confidence: 0.9 -> License, confidence: 1)"},
+	} {
+		if got := tt.change.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
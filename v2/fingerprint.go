@@ -0,0 +1,212 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+)
+
+// Fingerprint is a simhash of the normalized tokens of a text. Texts that
+// differ in a few words have fingerprints that differ in a few bits, so the
+// copies of an unknown license found across a codebase, with their own
+// copyright notices and formatting, can be told apart from other texts
+// without a corpus entry for them.
+type Fingerprint uint64
+
+// shingleSize is the number of consecutive tokens hashed as a feature of a
+// fingerprint.
+const shingleSize = 2
+
+// fingerprint returns the simhash of the tokens: each bit is set if it is
+// set in most of the hashes of their shingles.
+func fingerprint(tokens []*token) Fingerprint {
+	if len(tokens) == 0 {
+		return 0
+	}
+	var weights [64]int
+	words := make([]string, 0, shingleSize)
+	for i := 0; i == 0 || i+shingleSize <= len(tokens); i++ {
+		words = words[:0]
+		for j := i; j < i+shingleSize && j < len(tokens); j++ {
+			words = append(words, tokens[j].Text)
+		}
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words, " ")))
+		sum := h.Sum64()
+		for b := range weights {
+			if sum&(1<<uint(b)) != 0 {
+				weights[b]++
+			} else {
+				weights[b]--
+			}
+		}
+	}
+	var f Fingerprint
+	for b, w := range weights {
+		if w > 0 {
+			f |= 1 << uint(b)
+		}
+	}
+	return f
+}
+
+// Fingerprint returns the fingerprint of the document.
+func (d *TokenizedDocument) Fingerprint() Fingerprint {
+	return fingerprint(d.doc.Tokens)
+}
+
+// Distance returns the number of bits in which the fingerprints differ.
+func (f Fingerprint) Distance(o Fingerprint) int {
+	return bits.OnesCount64(uint64(f ^ o))
+}
+
+// DefaultClusterDistance is the largest distance between the fingerprints of
+// copies of a license that differ in their copyright notices or a few words.
+const DefaultClusterDistance = 8
+
+// Cluster groups the fingerprints whose distance to another fingerprint of
+// the group is at most maxDistance, returning the indices of the
+// fingerprints of each group. The groups are ordered by their first index,
+// and each holds its indices in order.
+func Cluster(fps []Fingerprint, maxDistance int) [][]int {
+	// The groups are the connected components of the fingerprints that are
+	// close enough, found with a union-find forest.
+	parent := make([]int, len(fps))
+	for i := range parent {
+		parent[i] = i
+	}
+	var root func(i int) int
+	root = func(i int) int {
+		if parent[i] != i {
+			parent[i] = root(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range fps {
+		for j := i + 1; j < len(fps); j++ {
+			if fps[i].Distance(fps[j]) <= maxDistance {
+				ri, rj := root(i), root(j)
+				if ri < rj {
+					parent[rj] = ri
+				} else if rj < ri {
+					parent[ri] = rj
+				}
+			}
+		}
+	}
+
+	var groups [][]int
+	group := make(map[int]int)
+	for i := range fps {
+		r := root(i)
+		g, ok := group[r]
+		if !ok {
+			g = len(groups)
+			group[r] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
+
+// Unknown is a region of the input that reads like a license, but isn't
+// covered by a match.
+type Unknown struct {
+	Gap
+	// Fingerprint is the fingerprint of the text of the region, for
+	// grouping the copies of the same unknown license with Cluster.
+	Fingerprint Fingerprint
+	// Density is the fraction of the tokens of the region that are terms
+	// common in licenses.
+	Density float64
+}
+
+// A gap reads like a license if it has at least minUnknownTokens tokens, of
+// which at least a fraction of minLegalDensity are terms common in licenses,
+// from at least minLegalTerms distinct ones. Licenses have a density of
+// legal terms above 7%, except for a few short or unusual ones, while other
+// prose rarely reaches 3%.
+const (
+	minUnknownTokens = 30
+	minLegalDensity  = .06
+	minLegalTerms    = 5
+)
+
+// legalTerms are words whose frequency sets the text of licenses apart from
+// other prose, in the form the tokenizer normalizes them to.
+var legalTerms = map[string]bool{
+	"agreement": true, "authors": true, "binary": true, "claim": true,
+	"conditions": true, "contract": true, "contributor": true, "contributors": true,
+	"copies": true, "copy": true, "copyright": true, "damages": true,
+	"derivative": true, "disclaimed": true, "disclaimer": true, "distribute": true,
+	"distributed": true, "distribution": true, "express": true, "fitness": true,
+	"free": true, "grant": true, "granted": true, "grants": true,
+	"holder": true, "holders": true, "implied": true, "indirect": true,
+	"liability": true, "liable": true, "license": true, "licensed": true,
+	"licensee": true, "licenses": true, "licensor": true, "limitation": true,
+	"merchantability": true, "modification": true, "modifications": true, "modify": true,
+	"notice": true, "obligations": true, "patent": true, "patents": true,
+	"permission": true, "permitted": true, "purpose": true, "redistribute": true,
+	"redistribution": true, "redistributions": true, "reproduce": true, "reserved": true,
+	"rights": true, "royalty": true, "shall": true, "software": true,
+	"source": true, "sublicense": true, "terms": true, "tort": true,
+	"warranties": true, "warranty": true, "without": true,
+}
+
+// legalDensity returns the fraction of the tokens that are legal terms and
+// the number of distinct legal terms among them.
+func legalDensity(tokens []*token) (float64, int) {
+	if len(tokens) == 0 {
+		return 0, 0
+	}
+	seen := make(map[string]bool)
+	n := 0
+	for _, t := range tokens {
+		if legalTerms[t.Text] {
+			n++
+			seen[t.Text] = true
+		}
+	}
+	return float64(n) / float64(len(tokens)), len(seen)
+}
+
+// Unknowns returns the gaps between the matches Match found in the supplied
+// content that read like licenses, judged by the density of the terms common
+// in licenses among their tokens, so that licenses missing from the corpus
+// can be reviewed. Clustering their fingerprints groups the copies of the
+// same license, so each is reviewed once. The markup of the content is
+// stripped as Match does.
+func (c *Classifier) Unknowns(in []byte, matches Matches) []*Unknown {
+	c.mu.RLock()
+	in = c.stripMarkup(in)
+	c.mu.RUnlock()
+
+	var out []*Unknown
+	for _, g := range Gaps(in, matches) {
+		doc := tokenize([]byte(g.Text))
+		if len(doc.Tokens) < minUnknownTokens {
+			continue
+		}
+		density, terms := legalDensity(doc.Tokens)
+		if density < minLegalDensity || terms < minLegalTerms {
+			continue
+		}
+		out = append(out, &Unknown{Gap: g, Fingerprint: fingerprint(doc.Tokens), Density: density})
+	}
+	return out
+}
@@ -0,0 +1,120 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// widgetLicense and gadgetLicense are licenses missing from the corpus.
+const (
+	widgetLicense = `The Widget Public License
+
+Permission is granted to any person obtaining a copy of this software to
+use, copy and modify it, provided that this notice is retained in all
+copies and that modified versions are clearly marked as such. The software
+is provided without warranty of any kind, express or implied. In no event
+shall the authors or copyright holders be liable for any claim, damages or
+other liability arising from its use.
+`
+	gadgetLicense = `Gadget Community Terms
+
+You may redistribute the source code and binary forms of the gadget library
+for noncommercial purposes only. Commercial distribution requires a separate
+written agreement with the licensor. Any patent rights of contributors are
+granted to licensees royalty free under these terms, which terminate
+automatically if you breach their conditions.
+`
+)
+
+func TestFingerprint(t *testing.T) {
+	mit, err := ioutil.ReadFile(filepath.Join("licenses", "MIT.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	apache, err := ioutil.ReadFile(filepath.Join("licenses", "Apache-2.0.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := Tokenize(mit).Fingerprint()
+	copied := "Copyright 2021 Example Corp.\n\n" + strings.Replace(string(mit), "\n", "\n\n", 5)
+	if d := f.Distance(Tokenize([]byte(copied)).Fingerprint()); d > DefaultClusterDistance {
+		t.Errorf("distance between copies of MIT = %d, want at most %d", d, DefaultClusterDistance)
+	}
+	if d := f.Distance(Tokenize(apache).Fingerprint()); d <= DefaultClusterDistance {
+		t.Errorf("distance between MIT and Apache-2.0 = %d, want more than %d", d, DefaultClusterDistance)
+	}
+	if d := Tokenize([]byte(widgetLicense)).Fingerprint().Distance(Tokenize([]byte(gadgetLicense)).Fingerprint()); d <= DefaultClusterDistance {
+		t.Errorf("distance between unrelated licenses = %d, want more than %d", d, DefaultClusterDistance)
+	}
+	if f := Tokenize(nil).Fingerprint(); f != 0 {
+		t.Errorf("fingerprint of no tokens = %x, want 0", f)
+	}
+}
+
+func TestCluster(t *testing.T) {
+	fps := []Fingerprint{0x0, 0x1, 0xff00, 0x3, 0xff01, 0xf0f0}
+	want := [][]int{{0, 1, 3}, {2, 4}, {5}}
+	if diff := cmp.Diff(want, Cluster(fps, 1)); diff != "" {
+		t.Errorf("Cluster(): diff (-want +got):\n%s", diff)
+	}
+	if got := Cluster(nil, 1); got != nil {
+		t.Errorf("Cluster(nil) = %v, want nil", got)
+	}
+}
+
+func TestUnknowns(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	mit, err := ioutil.ReadFile(filepath.Join("licenses", "MIT.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Notices of three components: two under the widget license with their
+	// own copyright notices, and one under the gadget license.
+	notices := []string{
+		"widget\nCopyright 2019 Widget Authors\n\n" + widgetLicense + "\nfoo\n\n" + string(mit),
+		"The foo component is a library for drawing widgets. It was written over\nthe summer and works on most platforms, although the port to the older\nones is slow and lacks a few of the features of the others.\n\n" +
+			string(mit) + "\nwidget fork\nCopyright 2021 Forkers\n\n" + widgetLicense,
+		"gadget\n\n" + gadgetLicense,
+	}
+	var unknowns []*Unknown
+	var fps []Fingerprint
+	for i, n := range notices {
+		in := []byte(n)
+		u := c.Unknowns(in, c.Match(in))
+		if len(u) != 1 {
+			t.Fatalf("Unknowns() of notice %d = %v, want a single region", i, u)
+		}
+		unknowns = append(unknowns, u...)
+		fps = append(fps, u[0].Fingerprint)
+	}
+	for i, want := range []string{"The Widget Public License", "The Widget Public License", "Gadget Community Terms"} {
+		if u := unknowns[i]; !strings.Contains(u.Text, want) || u.Density < minLegalDensity {
+			t.Errorf("unknown %d = %+v, want a region of %q", i, u, want)
+		}
+	}
+	if got, want := Cluster(fps, DefaultClusterDistance), [][]int{{0, 1}, {2}}; !cmp.Equal(got, want) {
+		t.Errorf("Cluster() of the unknowns = %v, want %v", got, want)
+	}
+}
@@ -24,8 +24,13 @@ func newFrequencyTable() *frequencyTable {
 	}
 }
 
+// update counts the tokens of the document. The variable and optional text
+// of a template isn't counted, since copies of the license needn't have it.
 func (f *frequencyTable) update(d *indexedDocument) {
-	for _, tok := range d.Tokens {
+	for k, tok := range d.Tokens {
+		if d.template != nil && d.template.free(k) {
+			continue
+		}
 		f.counts[tok.ID]++
 	}
 }
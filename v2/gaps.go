@@ -0,0 +1,127 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+)
+
+// Gap is a region of the input not covered by a match, such as the names and
+// copyright notices of the components of a notice file between their
+// licenses, or a license the classifier doesn't recognize.
+type Gap struct {
+	StartLine int
+	EndLine   int
+	// Text is the text of the lines of the gap.
+	Text string
+}
+
+// Gaps returns the regions of the input that hold text but aren't covered by
+// the license, header or exception matches, in order, for follow-up review.
+// Reference and grant matches only name a license, so their lines can lie in
+// gaps. Blank lines and lines of punctuation, such as the separators between
+// the sections of a notice file, don't hold text: they don't start or end
+// gaps, but don't split one either.
+func Gaps(in []byte, matches Matches) []Gap {
+	lines := splitSourceLines(in)
+	covered := make([]bool, len(lines)+1)
+	for _, m := range matches {
+		if !attributes(m) {
+			continue
+		}
+		for l := m.StartLine; l <= m.EndLine && l <= len(lines); l++ {
+			covered[l] = true
+		}
+	}
+
+	var gaps []Gap
+	start, end := 0, 0
+	flush := func() {
+		if start != 0 {
+			gaps = append(gaps, Gap{
+				StartLine: start,
+				EndLine:   end,
+				Text:      string(bytes.Join(lines[start-1:end], []byte("\n"))),
+			})
+		}
+		start, end = 0, 0
+	}
+	for i, line := range lines {
+		l := i + 1
+		switch {
+		case covered[l]:
+			flush()
+		case hasText(line):
+			if start == 0 {
+				start = l
+			}
+			end = l
+		}
+	}
+	flush()
+	return gaps
+}
+
+// splitSourceLines splits content into lines as the tokenizer numbers them.
+func splitSourceLines(in []byte) [][]byte {
+	return bytes.Split(in, []byte("\n"))
+}
+
+// hasText reports whether the line holds a letter or a digit.
+func hasText(line []byte) bool {
+	return bytes.IndexFunc(line, isSignificant) != -1
+}
+
+// attributes reports whether the match attributes the text it covers to a
+// license, rather than only naming one.
+func attributes(m *Match) bool {
+	return m.MatchType != referenceType && m.MatchType != grantType && m.MatchType != proprietaryType
+}
+
+// Coverage returns the fraction of the tokens of the document that lie in
+// the license, header and exception matches found in it, so that a review can
+// tell how much of a file, such as a notice file, the licenses found explain.
+// A document without tokens is fully covered.
+func (d *TokenizedDocument) Coverage(matches Matches) float64 {
+	n := len(d.doc.Tokens)
+	if n == 0 {
+		return 1
+	}
+	covered := make([]bool, n)
+	count := 0
+	for _, m := range matches {
+		if !attributes(m) {
+			continue
+		}
+		for i := m.StartTokenIndex; i <= m.EndTokenIndex && i < n; i++ {
+			if !covered[i] {
+				covered[i] = true
+				count++
+			}
+		}
+	}
+	return float64(count) / float64(n)
+}
+
+// Coverage returns the fraction of the tokens of the supplied content that
+// lie in the license, header and exception matches Match found in it. The
+// content is tokenized again, after stripping its markup as Match does; the
+// coverage of a document classified with MatchTokenized is computed from it.
+func (c *Classifier) Coverage(in []byte, matches Matches) float64 {
+	c.mu.RLock()
+	in = c.stripMarkup(in)
+	c.mu.RUnlock()
+	return Tokenize(in).Coverage(matches)
+}
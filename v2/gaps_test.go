@@ -0,0 +1,202 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// section is a license of a notice file and the lines of its text.
+type section struct {
+	name               string
+	banner             int
+	startLine, endLine int
+}
+
+// notice concatenates the corpus licenses back to back, each preceded by a
+// banner naming its component, as in the notice files of products.
+func notice(files []string) (string, []section, error) {
+	var b strings.Builder
+	var sections []section
+	line := 1
+	for i, f := range files {
+		content, err := ioutil.ReadFile(f)
+		if err != nil {
+			return "", nil, err
+		}
+		text := strings.TrimRight(string(content), "\n")
+		fmt.Fprintf(&b, "=== component-%d ===\n\n%s\n\n", i, text)
+		lines := strings.Split(text, "\n")
+		sections = append(sections, section{
+			name:      LicenseName(strings.TrimSuffix(filepath.Base(f), ".txt")),
+			banner:    line,
+			startLine: line + 2,
+			endLine:   line + 1 + len(lines),
+		})
+		line += len(lines) + 3
+	}
+	return b.String(), sections, nil
+}
+
+func TestConcatenatedNotice(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	all, err := filepath.Glob(filepath.Join("licenses", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []string
+	for _, f := range all {
+		if !strings.Contains(f, ".header") && !isException(filepath.Base(f)) {
+			files = append(files, f)
+		}
+	}
+	sort.Strings(files)
+	const count = 40
+	var picked []string
+	for i := 0; i < count; i++ {
+		picked = append(picked, files[i*len(files)/count])
+	}
+
+	in, sections, err := notice(picked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches := c.Match([]byte(in))
+	if len(matches) != len(sections) {
+		t.Fatalf("Match() of the notice found %d matches, want %d", len(matches), len(sections))
+	}
+	for i, m := range matches {
+		s := sections[i]
+		if m.Name != s.name || m.StartLine < s.startLine || m.EndLine > s.endLine {
+			t.Errorf("match %d = %s at lines %d-%d, want %s within lines %d-%d", i, m.Name, m.StartLine, m.EndLine, s.name, s.startLine, s.endLine)
+		}
+	}
+
+	// Every banner lies in a gap before the match of its license. The gaps
+	// also hold the lines of the licenses that aren't part of their matches,
+	// such as copyright notices.
+	gaps := Gaps([]byte(in), matches)
+	for i, s := range sections {
+		var found *Gap
+		for j, g := range gaps {
+			if g.StartLine <= s.banner && s.banner <= g.EndLine {
+				found = &gaps[j]
+			}
+		}
+		want := fmt.Sprintf("=== component-%d ===", i)
+		if found == nil || !strings.Contains(found.Text, want) {
+			t.Errorf("Gaps() has no gap holding %q at line %d", want, s.banner)
+		} else if found.EndLine >= matches[i].StartLine {
+			t.Errorf("gap at lines %d-%d overlaps match %d at lines %d-%d", found.StartLine, found.EndLine, i, matches[i].StartLine, matches[i].EndLine)
+		}
+	}
+}
+
+func TestGaps(t *testing.T) {
+	in := []byte("Component A\n\nCopyright 2020 A\nlicense\nlicense\n----\n\nComponent B\nlicense\n  \nreferences MIT\n")
+	matches := Matches{
+		{Name: "MIT", MatchType: "License", StartLine: 4, EndLine: 5},
+		{Name: "Apache-2.0", MatchType: "Header", StartLine: 9, EndLine: 9},
+		{Name: "MIT", MatchType: referenceType, StartLine: 11, EndLine: 11},
+	}
+	want := []Gap{
+		{StartLine: 1, EndLine: 3, Text: "Component A\n\nCopyright 2020 A"},
+		{StartLine: 8, EndLine: 8, Text: "Component B"},
+		{StartLine: 11, EndLine: 11, Text: "references MIT"},
+	}
+	if diff := cmp.Diff(want, Gaps(in, matches)); diff != "" {
+		t.Errorf("Gaps(): diff (-want +got):\n%s", diff)
+	}
+	if got := Gaps(in, Matches{{MatchType: "License", StartLine: 1, EndLine: 11}}); got != nil {
+		t.Errorf("Gaps() of a covered input = %v, want none", got)
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	mit, err := ioutil.ReadFile(filepath.Join("licenses", "MIT.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := len(Tokenize(mit).Tokens())
+
+	tests := []struct {
+		name string
+		in   string
+		want float64
+	}{
+		{
+			name: "license",
+			in:   string(mit),
+			want: 1,
+		},
+		{
+			name: "license and component names",
+			in:   "The widget and gadget components\n\n" + string(mit) + "\nsee https://example.com\n",
+			want: float64(n) / float64(n+7),
+		},
+		{
+			name: "no license",
+			in:   "The widget and gadget components\n",
+			want: 0,
+		},
+		{
+			name: "empty",
+			in:   "",
+			want: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			in := []byte(test.in)
+			if got := c.Coverage(in, c.Match(in)); got != test.want {
+				t.Errorf("Coverage() = %v, want %v", got, test.want)
+			}
+			d := Tokenize(in)
+			if got := d.Coverage(c.MatchTokenized(d)); got != test.want {
+				t.Errorf("TokenizedDocument.Coverage() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCoverageStripsMarkup(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	mit, err := ioutil.ReadFile(filepath.Join("licenses", "MIT.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetInputFormat(FormatHTML)
+	in := []byte(`<div class="license"><p>` + string(mit) + `</p></div>`)
+	if got := c.Coverage(in, c.Match(in)); got != 1 {
+		t.Errorf("Coverage() of HTML = %v, want 1", got)
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitscan
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+// Status describes how a file changed between two revisions.
+type Status string
+
+// The statuses of changed files.
+const (
+	Added    Status = "added"
+	Modified Status = "modified"
+	Renamed  Status = "renamed"
+	Deleted  Status = "deleted"
+)
+
+// Delta describes how the licenses found in a file changed between two
+// revisions.
+type Delta struct {
+	// Path is the path of the file in the head revision, or in the base
+	// revision if it was deleted.
+	Path string
+	// OldPath is the path of a renamed file in the base revision.
+	OldPath string
+	Status  Status
+	// Matches are the matches found in the file in the head revision.
+	Matches classifier.Matches
+	// Introduced are the matches of licenses that weren't found in the file
+	// in the base revision.
+	Introduced classifier.Matches
+	// Removed are the names of the licenses found in the file in the base
+	// revision that are no longer found in it, in order.
+	Removed []string
+}
+
+// fileChange is an entry of the output of git diff-tree.
+type fileChange struct {
+	old, new *File
+}
+
+// ScanDelta classifies only the files that were added, modified or renamed
+// between the base and head revisions of the repository at dir, and the files
+// that were deleted, and reports the files whose licenses changed: files that
+// introduce licenses they didn't have in base, and files that lost licenses.
+// Files whose licenses are unchanged are omitted, so the cost of a scan
+// depends on the size of the change rather than of the repository. A nil
+// filter selects the likely license files, as classifier.LikelyLicenseFile
+// does. To check the changes of a branch, as in a pull request, pass the merge
+// base of the branch and its target as base.
+func ScanDelta(c *classifier.Classifier, dir, base, head string, filter func(path string) bool) ([]*Delta, error) {
+	if filter == nil {
+		filter = classifier.LikelyLicenseFile
+	}
+	changes, err := diffTree(dir, base, head)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	br, err := openBlobs(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer br.close()
+	match := func(f *File) (classifier.Matches, error) {
+		if f == nil || !filter(f.Path) {
+			return nil, nil
+		}
+		b, err := br.read(f.Blob)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read %s: %v", f.Path, err)
+		}
+		return c.Match(b), nil
+	}
+	var out []*Delta
+	for _, ch := range changes {
+		before, err := match(ch.old)
+		if err != nil {
+			return nil, err
+		}
+		after, err := match(ch.new)
+		if err != nil {
+			return nil, err
+		}
+		if d := delta(ch, before, after); d != nil {
+			out = append(out, d)
+		}
+	}
+	return out, br.close()
+}
+
+// delta compares the matches of a changed file, returning nil if its
+// licenses are unchanged.
+func delta(ch *fileChange, before, after classifier.Matches) *Delta {
+	d := &Delta{Matches: after}
+	switch {
+	case ch.old == nil:
+		d.Status, d.Path = Added, ch.new.Path
+	case ch.new == nil:
+		d.Status, d.Path = Deleted, ch.old.Path
+	case ch.old.Path != ch.new.Path:
+		d.Status, d.Path, d.OldPath = Renamed, ch.new.Path, ch.old.Path
+	default:
+		d.Status, d.Path = Modified, ch.new.Path
+	}
+
+	had := make(map[string]bool)
+	for _, m := range before {
+		had[m.Name] = true
+	}
+	has := make(map[string]bool)
+	for _, m := range after {
+		has[m.Name] = true
+		if !had[m.Name] {
+			d.Introduced = append(d.Introduced, m)
+		}
+	}
+	for name := range had {
+		if !has[name] {
+			d.Removed = append(d.Removed, name)
+		}
+	}
+	if len(d.Introduced) == 0 && len(d.Removed) == 0 {
+		return nil
+	}
+	sort.Strings(d.Removed)
+	return d
+}
+
+// diffTree returns the regular files that differ between the trees of the
+// base and head revisions, detecting renames.
+func diffTree(dir, base, head string) ([]*fileChange, error) {
+	for _, rev := range []string{base, head} {
+		if err := checkRevision(rev); err != nil {
+			return nil, err
+		}
+	}
+	out, err := git(dir, "diff-tree", "-r", "-z", "-M", "--no-commit-id", base, head)
+	if err != nil {
+		return nil, err
+	}
+	// Each entry is ":<old mode> <new mode> <old object> <new object>
+	// <status>", followed by the path, and a second path for renames and
+	// copies, each terminated by a NUL.
+	fields := strings.Split(string(out), "\x00")
+	var changes []*fileChange
+	for i := 0; i < len(fields) && fields[i] != ""; {
+		meta := strings.Fields(strings.TrimPrefix(fields[i], ":"))
+		if len(meta) != 5 || i+1 >= len(fields) {
+			return nil, fmt.Errorf("malformed diff-tree entry %q", fields[i])
+		}
+		paths := fields[i+1 : i+2]
+		i += 2
+		status := meta[4][0]
+		if status == 'R' || status == 'C' {
+			if i >= len(fields) {
+				return nil, fmt.Errorf("malformed diff-tree entry %q", strings.Join(meta, " "))
+			}
+			paths = append(paths, fields[i])
+			i++
+		}
+		ch := &fileChange{}
+		oldPath, newPath := paths[0], paths[len(paths)-1]
+		if regular(meta[0]) && status != 'A' && status != 'C' {
+			ch.old = &File{Path: oldPath, Blob: meta[2]}
+		}
+		if regular(meta[1]) && status != 'D' {
+			ch.new = &File{Path: newPath, Blob: meta[3]}
+		}
+		if ch.old != nil || ch.new != nil {
+			changes = append(changes, ch)
+		}
+	}
+	return changes, nil
+}
+
+// regular returns true for the modes of regular files.
+func regular(mode string) bool {
+	return mode == "100644" || mode == "100755"
+}
@@ -0,0 +1,120 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitscan
+
+import (
+	"strings"
+	"testing"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+func TestScanDelta(t *testing.T) {
+	c := testClassifier(t)
+	r := newTestRepo(t)
+	base := r.commit(map[string]string{
+		"LICENSE":              readLicense(t, "MIT"),
+		"docs/LICENSE.txt":     readLicense(t, "BSD-3-Clause"),
+		"old/COPYING":          readLicense(t, "BSD-3-Clause"),
+		"vendor/a/LICENSE":     readLicense(t, "Apache-2.0"),
+		"README.md":            "A project.\n",
+		"unchanged/LICENSE.md": readLicense(t, "MIT"),
+	})
+	head := r.commit(map[string]string{
+		// A license is replaced.
+		"LICENSE": readLicense(t, "Apache-2.0"),
+		// A license is edited without changing it.
+		"docs/LICENSE.txt": readLicense(t, "BSD-3-Clause") + "\nSee also the NOTICE file.\n",
+		// A license file is renamed.
+		"old/COPYING": "",
+		"new/COPYING": readLicense(t, "BSD-3-Clause"),
+		// A license file is deleted.
+		"vendor/a/LICENSE": "",
+		// A license file is added.
+		"vendor/b/LICENSE": readLicense(t, "MIT"),
+		// Other files aren't classified.
+		"README.md": "A project under the MIT license.\n" + readLicense(t, "MIT"),
+	})
+
+	deltas, err := ScanDelta(c, r.dir, base, head, nil)
+	if err != nil {
+		t.Fatalf("ScanDelta() failed: %v", err)
+	}
+	var got []string
+	for _, d := range deltas {
+		var introduced []string
+		for _, m := range d.Introduced {
+			introduced = append(introduced, m.Name)
+		}
+		got = append(got, strings.Join([]string{d.Path, string(d.Status), d.OldPath, strings.Join(introduced, ","), strings.Join(d.Removed, ",")}, "|"))
+	}
+	want := []string{
+		"LICENSE|modified||Apache-2.0|MIT",
+		"vendor/a/LICENSE|deleted|||Apache-2.0",
+		"vendor/b/LICENSE|added||MIT|",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ScanDelta() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Renamed files whose licenses are unchanged aren't reported.
+	renamed := r.commit(map[string]string{
+		"new/COPYING":  "",
+		"new/LICENSE":  readLicense(t, "BSD-3-Clause") + "\nCopyright 2021 The Authors\n",
+		"vendor/b/FOO": "bar\n",
+	})
+	deltas, err = ScanDelta(c, r.dir, head, renamed, func(path string) bool { return true })
+	if err != nil {
+		t.Fatalf("ScanDelta() failed: %v", err)
+	}
+	if len(deltas) != 0 {
+		t.Errorf("ScanDelta() of a rename = %d deltas, want none", len(deltas))
+	}
+	deltas, err = ScanDelta(c, r.dir, base, renamed, func(path string) bool { return strings.HasPrefix(path, "new/") || strings.HasPrefix(path, "old/") })
+	if err != nil {
+		t.Fatalf("ScanDelta() failed: %v", err)
+	}
+	if len(deltas) != 0 {
+		t.Errorf("ScanDelta() = %d deltas, want none for renamed files with the same licenses", len(deltas))
+	}
+
+	if deltas, err := ScanDelta(c, r.dir, head, head, nil); err != nil || len(deltas) != 0 {
+		t.Errorf("ScanDelta() of a revision against itself = %v, %v, want no deltas", deltas, err)
+	}
+	if _, err := ScanDelta(c, r.dir, base, "no-such-branch", nil); err == nil {
+		t.Error("ScanDelta() of a missing revision succeeded, want an error")
+	}
+}
+
+func TestDiffTreeRenames(t *testing.T) {
+	r := newTestRepo(t)
+	content := strings.Repeat("some content that is long enough to detect a rename\n", 10)
+	base := r.commit(map[string]string{"a/LICENSE": content})
+	head := r.commit(map[string]string{"a/LICENSE": "", "b/LICENSE": content + "more\n"})
+	changes, err := diffTree(r.dir, base, head)
+	if err != nil {
+		t.Fatalf("diffTree() failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].old == nil || changes[0].new == nil || changes[0].old.Path != "a/LICENSE" || changes[0].new.Path != "b/LICENSE" {
+		t.Fatalf("diffTree() = %v, want the rename of a/LICENSE to b/LICENSE", changes)
+	}
+	if d := delta(changes[0], nil, nil); d != nil {
+		t.Errorf("delta() of a file without licenses = %+v, want nil", d)
+	}
+	d := delta(changes[0], classifier.Matches{{Name: "MIT"}}, nil)
+	if d == nil || d.Status != Renamed || d.Path != "b/LICENSE" || d.OldPath != "a/LICENSE" || strings.Join(d.Removed, ",") != "MIT" {
+		t.Errorf("delta() of a renamed file = %+v, want MIT removed from the renamed file", d)
+	}
+}
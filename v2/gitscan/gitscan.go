@@ -0,0 +1,276 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gitscan classifies the files of a git repository as of a revision.
+// The files are read from the object store of the repository with the
+// plumbing commands of git, so that continuous integration systems can scan a
+// revision without checking it out, including in bare repositories.
+// ScanDelta classifies only the files changed between two revisions, for
+// checking the licenses introduced by a change in large repositories.
+//
+//	c, err := classifier.New(classifier.WithCorpusDir(licenseDir))
+//	...
+//	results, err := gitscan.Scan(c, repoDir, "origin/main", nil)
+//	...
+//	for _, r := range results {
+//		for _, m := range r.Matches {
+//			fmt.Printf("%s: %s (%v)\n", r.Path, m.Name, m.Confidence)
+//		}
+//	}
+package gitscan
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+// GitCommand is the git executable run by the package.
+var GitCommand = "git"
+
+// MaxFileSize bounds the size of the files classified by Scan and ScanDelta.
+// Larger files are skipped, since they are almost never license texts.
+const MaxFileSize = 16 << 20
+
+// File is a regular file of the tree of a revision.
+type File struct {
+	// Path is the slash-separated path of the file from the root of the
+	// repository.
+	Path string
+	// Blob is the name of the object holding the content of the file.
+	Blob string
+	// Size is the size of the content in bytes.
+	Size int64
+}
+
+// Result holds the matches found in a file.
+type Result struct {
+	Path    string
+	Blob    string
+	Matches classifier.Matches
+}
+
+// git runs a git command in the repository at dir and returns its output.
+func git(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command(GitCommand, append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %v: %s", args[0], err, msg)
+		}
+		return nil, fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return out, nil
+}
+
+// checkRevision rejects revisions that git would parse as options.
+func checkRevision(rev string) error {
+	if rev == "" || strings.HasPrefix(rev, "-") {
+		return fmt.Errorf("invalid revision %q", rev)
+	}
+	return nil
+}
+
+// ResolveRevision returns the name of the commit identified by rev, which is
+// anything git rev-parse accepts, such as a branch, a tag or an abbreviated
+// commit name, in the repository at dir.
+func ResolveRevision(dir, rev string) (string, error) {
+	if err := checkRevision(rev); err != nil {
+		return "", err
+	}
+	out, err := git(dir, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("revision %s not found in %s: %v", rev, dir, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// ListFiles returns the regular files of the tree of rev in the repository at
+// dir, in the order of their paths. Symbolic links and submodules are
+// omitted.
+func ListFiles(dir, rev string) ([]*File, error) {
+	if err := checkRevision(rev); err != nil {
+		return nil, err
+	}
+	out, err := git(dir, "ls-tree", "-r", "-z", "--long", "--full-tree", rev)
+	if err != nil {
+		return nil, err
+	}
+	var files []*File
+	for _, entry := range strings.Split(string(out), "\x00") {
+		if entry == "" {
+			continue
+		}
+		f, ok, err := parseTreeEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// parseTreeEntry parses an entry of the long format of git ls-tree,
+// "<mode> <type> <object> <size>\t<path>". It returns false for entries that
+// aren't regular files.
+func parseTreeEntry(entry string) (*File, bool, error) {
+	tab := strings.IndexByte(entry, '\t')
+	if tab == -1 {
+		return nil, false, fmt.Errorf("malformed tree entry %q", entry)
+	}
+	fields := strings.Fields(entry[:tab])
+	if len(fields) != 4 {
+		return nil, false, fmt.Errorf("malformed tree entry %q", entry)
+	}
+	if fields[1] != "blob" || (fields[0] != "100644" && fields[0] != "100755") {
+		return nil, false, nil
+	}
+	size, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil {
+		return nil, false, fmt.Errorf("malformed tree entry %q: %v", entry, err)
+	}
+	return &File{Path: entry[tab+1:], Blob: fields[2], Size: size}, true, nil
+}
+
+// Scan classifies the files of the tree of rev in the repository at dir that
+// are selected by filter. A nil filter selects the likely license files, as
+// classifier.LikelyLicenseFile does. Results are returned in the order of the
+// paths of the files; files without matches are omitted.
+func Scan(c *classifier.Classifier, dir, rev string, filter func(path string) bool) ([]*Result, error) {
+	files, err := ListFiles(dir, rev)
+	if err != nil {
+		return nil, err
+	}
+	return classify(c, dir, files, filter)
+}
+
+// classify classifies the selected files, reading their content from the
+// object store of the repository at dir.
+func classify(c *classifier.Classifier, dir string, files []*File, filter func(path string) bool) ([]*Result, error) {
+	if filter == nil {
+		filter = classifier.LikelyLicenseFile
+	}
+	var selected []*File
+	for _, f := range files {
+		if f.Size <= MaxFileSize && filter(f.Path) {
+			selected = append(selected, f)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, nil
+	}
+
+	br, err := openBlobs(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer br.close()
+	var out []*Result
+	for _, f := range selected {
+		b, err := br.read(f.Blob)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read %s: %v", f.Path, err)
+		}
+		if m := c.Match(b); len(m) > 0 {
+			out = append(out, &Result{Path: f.Path, Blob: f.Blob, Matches: m})
+		}
+	}
+	return out, br.close()
+}
+
+// blobReader reads the content of objects from a git cat-file --batch
+// process, which avoids starting a process for each file.
+type blobReader struct {
+	cmd    *exec.Cmd
+	in     io.WriteCloser
+	out    *bufio.Reader
+	stderr bytes.Buffer
+	closed bool
+}
+
+func openBlobs(dir string) (*blobReader, error) {
+	br := &blobReader{cmd: exec.Command(GitCommand, "-C", dir, "cat-file", "--batch")}
+	br.cmd.Stderr = &br.stderr
+	in, err := br.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := br.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := br.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("git cat-file: %v", err)
+	}
+	br.in, br.out = in, bufio.NewReader(out)
+	return br, nil
+}
+
+// read returns the content of the named blob, or nil if it is larger than
+// MaxFileSize.
+func (br *blobReader) read(name string) ([]byte, error) {
+	if _, err := fmt.Fprintf(br.in, "%s\n", name); err != nil {
+		return nil, fmt.Errorf("git cat-file: %v", err)
+	}
+	header, err := br.out.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file: %v", err)
+	}
+	// The header is "<object> <type> <size>", or "<object> missing".
+	fields := strings.Fields(header)
+	if len(fields) != 3 || fields[1] != "blob" {
+		return nil, fmt.Errorf("git cat-file: unexpected response %q", strings.TrimSpace(header))
+	}
+	size, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file: unexpected response %q", strings.TrimSpace(header))
+	}
+	// The content is followed by a line feed.
+	if size > MaxFileSize {
+		if _, err := io.CopyN(ioutil.Discard, br.out, int64(size)+1); err != nil {
+			return nil, fmt.Errorf("git cat-file: %v", err)
+		}
+		return nil, nil
+	}
+	b := make([]byte, size+1)
+	if _, err := io.ReadFull(br.out, b); err != nil {
+		return nil, fmt.Errorf("git cat-file: %v", err)
+	}
+	return b[:size], nil
+}
+
+// close ends the cat-file process. It can be called more than once.
+func (br *blobReader) close() error {
+	if br.closed {
+		return nil
+	}
+	br.closed = true
+	br.in.Close()
+	io.Copy(ioutil.Discard, br.out)
+	if err := br.cmd.Wait(); err != nil {
+		return fmt.Errorf("git cat-file: %v: %s", err, strings.TrimSpace(br.stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitscan
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+func readLicense(t *testing.T, name string) string {
+	t.Helper()
+	b, err := ioutil.ReadFile(filepath.Join("..", "licenses", name+".txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func testClassifier(t *testing.T) *classifier.Classifier {
+	t.Helper()
+	var opts []classifier.Option
+	for _, name := range []string{"MIT", "Apache-2.0", "BSD-3-Clause"} {
+		opts = append(opts, classifier.WithCorpusContent(name, []byte(readLicense(t, name))))
+	}
+	c, err := classifier.New(opts...)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	return c
+}
+
+// testRepo is a git repository for tests.
+type testRepo struct {
+	t   *testing.T
+	dir string
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	t.Helper()
+	if _, err := exec.LookPath(GitCommand); err != nil {
+		t.Skip("git isn't installed")
+	}
+	dir, err := ioutil.TempDir("", "gitscan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	r := &testRepo{t: t, dir: dir}
+	r.git("init", "-q")
+	r.git("config", "user.name", "Test")
+	r.git("config", "user.email", "test@example.com")
+	r.git("config", "commit.gpgsign", "false")
+	return r
+}
+
+func (r *testRepo) git(args ...string) string {
+	r.t.Helper()
+	out, err := git(r.dir, args...)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// commit writes the supplied files, removing those with empty content, and
+// commits them, returning the name of the commit.
+func (r *testRepo) commit(files map[string]string) string {
+	r.t.Helper()
+	for name, content := range files {
+		path := filepath.Join(r.dir, filepath.FromSlash(name))
+		if content == "" {
+			if err := os.Remove(path); err != nil {
+				r.t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			r.t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			r.t.Fatal(err)
+		}
+	}
+	r.git("add", "-A")
+	r.git("commit", "-q", "-m", "update")
+	return r.git("rev-parse", "HEAD")
+}
+
+func TestScan(t *testing.T) {
+	c := testClassifier(t)
+	r := newTestRepo(t)
+	first := r.commit(map[string]string{
+		"LICENSE":                  readLicense(t, "MIT"),
+		"README.md":                "A project.\n",
+		"vendor/lib/LICENSE.txt":   readLicense(t, "BSD-3-Clause"),
+		"vendor/lib/main.go":       "package lib\n",
+		"third_party/COPYING.spdx": "not a license\n",
+	})
+	if err := os.Symlink("LICENSE", filepath.Join(r.dir, "LICENSE-link")); err != nil {
+		t.Fatal(err)
+	}
+	r.commit(map[string]string{"LICENSE": readLicense(t, "Apache-2.0")})
+
+	// The working tree isn't consulted.
+	if err := os.Remove(filepath.Join(r.dir, "LICENSE")); err != nil {
+		t.Fatal(err)
+	}
+
+	summary := func(results []*Result) string {
+		var s []string
+		for _, res := range results {
+			for _, m := range res.Matches {
+				s = append(s, res.Path+":"+m.Name)
+			}
+		}
+		return strings.Join(s, " ")
+	}
+	for _, tt := range []struct {
+		rev  string
+		want string
+	}{
+		{rev: first, want: "LICENSE:MIT vendor/lib/LICENSE.txt:BSD-3-Clause"},
+		{rev: "HEAD", want: "LICENSE:Apache-2.0 vendor/lib/LICENSE.txt:BSD-3-Clause"},
+		{rev: "HEAD~1", want: "LICENSE:MIT vendor/lib/LICENSE.txt:BSD-3-Clause"},
+	} {
+		results, err := Scan(c, r.dir, tt.rev, nil)
+		if err != nil {
+			t.Fatalf("Scan(%s) failed: %v", tt.rev, err)
+		}
+		if got := summary(results); got != tt.want {
+			t.Errorf("Scan(%s) = %q, want %q", tt.rev, got, tt.want)
+		}
+	}
+
+	// Filters select the files to classify.
+	results, err := Scan(c, r.dir, "HEAD", func(path string) bool { return !strings.HasPrefix(path, "vendor/") })
+	if err != nil {
+		t.Fatalf("Scan() failed: %v", err)
+	}
+	if got, want := summary(results), "LICENSE:Apache-2.0"; got != want {
+		t.Errorf("Scan() with a filter = %q, want %q", got, want)
+	}
+
+	// Bare repositories can be scanned.
+	bare := filepath.Join(r.dir, "bare.git")
+	r.git("clone", "-q", "--bare", r.dir, bare)
+	results, err = Scan(c, bare, "HEAD", nil)
+	if err != nil {
+		t.Fatalf("Scan() of a bare repository failed: %v", err)
+	}
+	if got, want := summary(results), "LICENSE:Apache-2.0 vendor/lib/LICENSE.txt:BSD-3-Clause"; got != want {
+		t.Errorf("Scan() of a bare repository = %q, want %q", got, want)
+	}
+
+	for _, rev := range []string{"no-such-branch", "--all", ""} {
+		if _, err := Scan(c, r.dir, rev, nil); err == nil {
+			t.Errorf("Scan(%q) succeeded, want an error", rev)
+		}
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit(map[string]string{
+		"b.txt":       "b\n",
+		"a/file name": "spaced\n",
+	})
+	if err := os.Symlink("b.txt", filepath.Join(r.dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	head := r.commit(map[string]string{"c.txt": "c\n"})
+
+	files, err := ListFiles(r.dir, head)
+	if err != nil {
+		t.Fatalf("ListFiles() failed: %v", err)
+	}
+	var got []string
+	for _, f := range files {
+		got = append(got, f.Path)
+		if len(f.Blob) < 40 || f.Size != 2 && f.Size != 7 {
+			t.Errorf("ListFiles() returned %+v, want a blob name and size", f)
+		}
+	}
+	if want := "a/file name b.txt c.txt"; strings.Join(got, " ") != want {
+		t.Errorf("ListFiles() = %q, want %q", got, want)
+	}
+
+	if rev, err := ResolveRevision(r.dir, "HEAD"); err != nil || rev != head {
+		t.Errorf("ResolveRevision(HEAD) = %q, %v, want %q", rev, err, head)
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gomod
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+// maxLicenseFileSize bounds the size of the license files that are
+// classified, so that large files with license-like names, such as a
+// NOTICE file listing every dependency of a project, aren't read in full.
+const maxLicenseFileSize = 1 << 20
+
+// LicenseFile holds the matches found in a license file of a module.
+type LicenseFile struct {
+	// Name is the name of the file within the module.
+	Name    string
+	Matches classifier.Matches
+}
+
+// Report is the result of auditing the licenses of a module.
+type Report struct {
+	Module *Module
+	// Files are the license files in the root directory of the module.
+	Files []*LicenseFile
+	// License is the concluded license of the module: the names of the
+	// licenses found in its license files, sorted and joined with " AND ", or
+	// empty if none were found.
+	License string
+	// Confidence is the lowest of the confidences of the licenses making up
+	// License.
+	Confidence float64
+	// Err is the error that prevented the module from being audited, such as
+	// its source missing from the module cache.
+	Err error
+}
+
+// Audit classifies the license files of each module, which must have its Dir
+// set, and returns a report for each in the same order.
+func Audit(c *classifier.Classifier, mods []*Module) []*Report {
+	var reports []*Report
+	for _, m := range mods {
+		reports = append(reports, audit(c, m))
+	}
+	return reports
+}
+
+// AuditFile audits the modules listed by Load.
+func AuditFile(c *classifier.Classifier, name, cache string) ([]*Report, error) {
+	mods, err := Load(name, cache)
+	if err != nil {
+		return nil, err
+	}
+	return Audit(c, mods), nil
+}
+
+// Load returns the modules listed in a go.mod or go.sum file, built into a Go
+// binary, or extracted in a module cache directory, with their Dir set. The
+// modules of files are located in the supplied module cache.
+func Load(name, cache string) ([]*Module, error) {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return ScanCache(name)
+	}
+
+	var mods []*Module
+	switch base := filepath.Base(name); {
+	case base == "go.sum" || strings.HasSuffix(base, ".mod"):
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		if base == "go.sum" {
+			mods, err = ParseGoSum(data)
+		} else {
+			mods, err = ParseGoMod(data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+	default:
+		_, mods, err = ReadBinary(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := Resolve(mods, filepath.Dir(name), cache); err != nil {
+		return nil, err
+	}
+	return mods, nil
+}
+
+func audit(c *classifier.Classifier, m *Module) *Report {
+	r := &Report{Module: m}
+	entries, err := ioutil.ReadDir(m.Dir)
+	if err != nil {
+		r.Err = fmt.Errorf("module %s isn't available: %v", m, err)
+		return r
+	}
+	for _, e := range entries {
+		if !e.Mode().IsRegular() || !classifier.LikelyLicenseFile(e.Name()) || e.Size() > maxLicenseFileSize {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(m.Dir, e.Name()))
+		if err != nil {
+			r.Err = err
+			return r
+		}
+		r.Files = append(r.Files, &LicenseFile{Name: e.Name(), Matches: c.Match(b)})
+	}
+	r.License, r.Confidence = conclude(r.Files)
+	return r
+}
+
+// conclude determines the license of a module from the license texts found
+// in its license files. Headers and references are ignored, since a license
+// file that only mentions a license doesn't grant it. Exceptions linked to a
+// license are reported with it, as in "GPL-2.0 WITH Classpath-exception-2.0".
+func conclude(files []*LicenseFile) (string, float64) {
+	best := make(map[string]float64)
+	add := func(name string, confidence float64) {
+		if c, ok := best[name]; !ok || confidence > c {
+			best[name] = confidence
+		}
+	}
+	var exceptions []*classifier.Match
+	for _, f := range files {
+		for _, m := range f.Matches {
+			switch {
+			case m.MatchType == "License":
+				add(m.Name, m.Confidence)
+			case m.MatchType == "Exception" && m.BaseLicense != "":
+				exceptions = append(exceptions, m)
+			}
+		}
+	}
+	for _, e := range exceptions {
+		c, ok := best[e.BaseLicense]
+		if !ok {
+			continue
+		}
+		delete(best, e.BaseLicense)
+		if e.Confidence < c {
+			c = e.Confidence
+		}
+		add(e.Expression(), c)
+	}
+	if len(best) == 0 {
+		return "", 0
+	}
+	var names []string
+	confidence := 1.0
+	for n, c := range best {
+		names = append(names, n)
+		if c < confidence {
+			confidence = c
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, " AND "), confidence
+}
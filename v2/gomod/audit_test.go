@@ -0,0 +1,117 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gomod
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+func readLicense(t *testing.T, name string) string {
+	t.Helper()
+	b, err := ioutil.ReadFile(filepath.Join("..", "licenses", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestAuditFile(t *testing.T) {
+	c := classifier.NewClassifier(0.8)
+	if err := c.LoadLicenses(filepath.Join("..", "licenses")); err != nil {
+		t.Fatalf("LoadLicenses() failed: %v", err)
+	}
+
+	dir, err := ioutil.TempDir("", "gomod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache := filepath.Join(dir, "mod")
+	writeFiles(t, dir, map[string]string{
+		"mod/github.com/!mixed/dual@v1.0.0/LICENSE-MIT":      readLicense(t, "MIT.txt"),
+		"mod/github.com/!mixed/dual@v1.0.0/LICENSE-APACHE":   readLicense(t, "Apache-2.0.txt"),
+		"mod/github.com/!mixed/dual@v1.0.0/main.go":          "package dual\n",
+		"mod/github.com/!mixed/dual@v1.0.0/docs/LICENSE.txt": readLicense(t, "BSD-3-Clause.txt"),
+		"mod/example.com/unlicensed@v0.1.0/README.md":        "Nothing to see here.\n",
+		"app/go.sum": strings.Join([]string{
+			"example.com/missing v1.0.0 h1:AAAA=",
+			"example.com/unlicensed v0.1.0 h1:BBBB=",
+			"example.com/unlicensed v0.1.0/go.mod h1:CCCC=",
+			"github.com/Mixed/dual v1.0.0 h1:DDDD=",
+		}, "\n"),
+	})
+
+	reports, err := AuditFile(c, filepath.Join(dir, "app", "go.sum"), cache)
+	if err != nil {
+		t.Fatalf("AuditFile() failed: %v", err)
+	}
+	if len(reports) != 3 {
+		t.Fatalf("AuditFile() returned %d reports, want 3", len(reports))
+	}
+
+	if r := reports[0]; r.Module.Path != "example.com/missing" || r.Err == nil {
+		t.Errorf("AuditFile() report for %s has error %v, want the module to be missing", r.Module, r.Err)
+	}
+	if r := reports[1]; r.Err != nil || len(r.Files) != 0 || r.License != "" {
+		t.Errorf("AuditFile() report for %s = %q, %d files, %v, want no license", r.Module, r.License, len(r.Files), r.Err)
+	}
+	r := reports[2]
+	if r.Err != nil {
+		t.Fatalf("AuditFile() report for %s has error %v", r.Module, r.Err)
+	}
+	if len(r.Files) != 2 || r.Files[0].Name != "LICENSE-APACHE" || r.Files[1].Name != "LICENSE-MIT" {
+		t.Errorf("AuditFile() report for %s has files %v, want the license files in the module root", r.Module, r.Files)
+	}
+	if r.License != "Apache-2.0 AND MIT" || r.Confidence < 0.99 {
+		t.Errorf("AuditFile() concluded %q (%v) for %s, want Apache-2.0 AND MIT", r.License, r.Confidence, r.Module)
+	}
+
+	// The module cache itself can be audited.
+	reports, err = AuditFile(c, cache, "")
+	if err != nil {
+		t.Fatalf("AuditFile() of the module cache failed: %v", err)
+	}
+	if len(reports) != 2 || reports[1].License != "Apache-2.0 AND MIT" {
+		t.Errorf("AuditFile() of the module cache returned %d reports, want 2 including the dual-licensed module", len(reports))
+	}
+}
+
+func TestConclude(t *testing.T) {
+	files := []*LicenseFile{
+		{Name: "LICENSE", Matches: classifier.Matches{
+			{Name: "GPL-2.0", MatchType: "License", Confidence: 0.98},
+			{Name: "Classpath-exception-2.0", MatchType: "Exception", Confidence: 0.95, BaseLicense: "GPL-2.0"},
+			{Name: "MIT", MatchType: "Reference", Confidence: 1.0},
+		}},
+		{Name: "COPYING", Matches: classifier.Matches{
+			{Name: "BSD-3-Clause", MatchType: "License", Confidence: 0.9},
+			{Name: "BSD-3-Clause", MatchType: "License", Confidence: 1.0},
+			{Name: "Apache-2.0", MatchType: "Header", Confidence: 1.0},
+		}},
+	}
+	license, confidence := conclude(files)
+	if want := "BSD-3-Clause AND GPL-2.0 WITH Classpath-exception-2.0"; license != want || confidence != 0.95 {
+		t.Errorf("conclude() = %q, %v, want %q, 0.95", license, confidence, want)
+	}
+	if license, confidence := conclude(nil); license != "" || confidence != 0 {
+		t.Errorf("conclude(nil) = %q, %v, want no license", license, confidence)
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+package gomod
+
+import (
+	"debug/buildinfo"
+	"fmt"
+	"runtime/debug"
+)
+
+// ReadBinary returns the main module of a Go binary and the modules it was
+// built with, read from the build information the go command embeds in
+// binaries. Replaced modules have their Replace set. The dependencies are
+// ordered by path.
+func ReadBinary(name string) (*Module, []*Module, error) {
+	bi, err := buildinfo.ReadFile(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't read Go build information: %w", err)
+	}
+	var deps []*Module
+	for _, d := range bi.Deps {
+		deps = append(deps, binaryModule(d))
+	}
+	sortModules(deps)
+	return binaryModule(&bi.Main), deps, nil
+}
+
+func binaryModule(m *debug.Module) *Module {
+	out := &Module{Path: m.Path, Version: m.Version}
+	if m.Replace != nil {
+		out.Replace = &Module{Path: m.Replace.Path, Version: m.Replace.Version}
+	}
+	return out
+}
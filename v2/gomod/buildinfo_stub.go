@@ -0,0 +1,27 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !go1.18
+// +build !go1.18
+
+package gomod
+
+import "errors"
+
+// ReadBinary returns the main module of a Go binary and the modules it was
+// built with. Reading binaries requires the debug/buildinfo package of Go
+// 1.18, so it always fails when built with an earlier version.
+func ReadBinary(name string) (*Module, []*Module, error) {
+	return nil, nil, errors.New("reading Go binaries requires Go 1.18 or later")
+}
@@ -0,0 +1,66 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+package gomod
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadBinary(t *testing.T) {
+	// The test binary is a Go binary built with the dependencies of this
+	// module.
+	main, deps, err := ReadBinary(os.Args[0])
+	if err != nil {
+		t.Fatalf("ReadBinary() failed: %v", err)
+	}
+	if main.Path != "github.com/google/licenseclassifier/v2" {
+		t.Errorf("ReadBinary() main module = %s, want github.com/google/licenseclassifier/v2", main.Path)
+	}
+	var diff *Module
+	for _, d := range deps {
+		if d.Path == "github.com/sergi/go-diff" {
+			diff = d
+		}
+	}
+	if diff == nil || diff.Version != "v1.1.0" {
+		t.Fatalf("ReadBinary() deps = %v, want github.com/sergi/go-diff@v1.1.0", deps)
+	}
+
+	mods, err := Load(os.Args[0], "/cache")
+	if err != nil {
+		t.Fatalf("Load() of a binary failed: %v", err)
+	}
+	found := false
+	for _, m := range mods {
+		if m.Path == diff.Path {
+			found = true
+			if want := filepath.Join("/cache", "github.com", "sergi", "go-diff@v1.1.0"); m.Dir != want {
+				t.Errorf("Load() set the Dir of %s to %q, want %q", m, m.Dir, want)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Load() = %v, want github.com/sergi/go-diff", mods)
+	}
+
+	if _, _, err := ReadBinary("buildinfo.go"); err == nil {
+		t.Error("ReadBinary() of a source file succeeded, want error")
+	}
+}
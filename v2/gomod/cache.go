@@ -0,0 +1,170 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gomod
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// CacheDir returns the module cache directory used by the go command: the
+// value of GOMODCACHE, or pkg/mod in the first entry of GOPATH, which
+// defaults to the go directory in the home directory.
+func CacheDir() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	gopath := filepath.SplitList(os.Getenv("GOPATH"))
+	if len(gopath) > 0 && gopath[0] != "" {
+		return filepath.Join(gopath[0], "pkg", "mod")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, "go", "pkg", "mod")
+}
+
+// EscapePath escapes a module path or version for use in the module cache,
+// where each upper-case letter is replaced by an exclamation mark followed by
+// the letter's lower-case equivalent.
+func EscapePath(path string) (string, error) {
+	var b strings.Builder
+	for _, r := range path {
+		switch {
+		case r == '!' || r >= unicode.MaxASCII:
+			return "", fmt.Errorf("invalid character %q in module path %q", r, path)
+		case 'A' <= r && r <= 'Z':
+			b.WriteByte('!')
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), nil
+}
+
+// UnescapePath reverses EscapePath.
+func UnescapePath(escaped string) (string, error) {
+	var b strings.Builder
+	bang := false
+	for _, r := range escaped {
+		switch {
+		case bang && 'a' <= r && r <= 'z':
+			b.WriteRune(unicode.ToUpper(r))
+			bang = false
+		case bang || ('A' <= r && r <= 'Z'):
+			return "", fmt.Errorf("invalid escaped module path %q", escaped)
+		case r == '!':
+			bang = true
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if bang {
+		return "", fmt.Errorf("invalid escaped module path %q", escaped)
+	}
+	return b.String(), nil
+}
+
+// ModuleDir returns the directory of the source of a module version in the
+// module cache.
+func ModuleDir(cache, path, version string) (string, error) {
+	p, err := EscapePath(path)
+	if err != nil {
+		return "", err
+	}
+	v, err := EscapePath(version)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cache, filepath.FromSlash(p)+"@"+v), nil
+}
+
+// ScanCache returns the modules whose source is extracted in the supplied
+// module cache directory, ordered by path and version, with their Dir set.
+func ScanCache(cache string) ([]*Module, error) {
+	var mods []*Module
+	var walk func(dir, prefix string) error
+	walk = func(dir, prefix string) error {
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			// The download cache holds module zips rather than their source.
+			if !e.IsDir() || (prefix == "" && e.Name() == "cache") {
+				continue
+			}
+			name := prefix + e.Name()
+			i := strings.LastIndex(name, "@")
+			if i < 0 {
+				if err := walk(filepath.Join(dir, e.Name()), name+"/"); err != nil {
+					return err
+				}
+				continue
+			}
+			path, err := UnescapePath(name[:i])
+			if err != nil {
+				return err
+			}
+			version, err := UnescapePath(name[i+1:])
+			if err != nil {
+				return err
+			}
+			mods = append(mods, &Module{Path: path, Version: version, Dir: filepath.Join(dir, e.Name())})
+		}
+		return nil
+	}
+	if err := walk(cache, ""); err != nil {
+		return nil, err
+	}
+	sortModules(mods)
+	return mods, nil
+}
+
+// Resolve sets the Dir of each module without one to the directory holding
+// its source: the local directory replacing it, interpreted relative to the
+// directory of the go.mod file in modDir, or its directory in the module
+// cache.
+func Resolve(mods []*Module, modDir, cache string) error {
+	for _, m := range mods {
+		if m.Dir != "" {
+			continue
+		}
+		src := m
+		if m.Replace != nil {
+			src = m.Replace
+		}
+		if src.isLocal() {
+			dir := filepath.FromSlash(src.Path)
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(modDir, dir)
+			}
+			m.Dir = dir
+			continue
+		}
+		dir, err := ModuleDir(cache, src.Path, src.Version)
+		if err != nil {
+			return err
+		}
+		m.Dir = dir
+	}
+	return nil
+}
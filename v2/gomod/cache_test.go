@@ -0,0 +1,135 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gomod
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEscapePath(t *testing.T) {
+	tests := []struct {
+		path, escaped string
+	}{
+		{path: "golang.org/x/text", escaped: "golang.org/x/text"},
+		{path: "github.com/BurntSushi/toml", escaped: "github.com/!burnt!sushi/toml"},
+		{path: "v1.0.0-RC1", escaped: "v1.0.0-!r!c1"},
+	}
+	for _, tt := range tests {
+		got, err := EscapePath(tt.path)
+		if err != nil || got != tt.escaped {
+			t.Errorf("EscapePath(%q) = %q, %v, want %q", tt.path, got, err, tt.escaped)
+		}
+		got, err = UnescapePath(tt.escaped)
+		if err != nil || got != tt.path {
+			t.Errorf("UnescapePath(%q) = %q, %v, want %q", tt.escaped, got, err, tt.path)
+		}
+	}
+
+	for _, p := range []string{"example.com/bang!", "example.com/é"} {
+		if _, err := EscapePath(p); err == nil {
+			t.Errorf("EscapePath(%q) succeeded, want error", p)
+		}
+	}
+	for _, p := range []string{"example.com/Upper", "example.com/!", "example.com/!!a", "example.com/!1"} {
+		if _, err := UnescapePath(p); err == nil {
+			t.Errorf("UnescapePath(%q) succeeded, want error", p)
+		}
+	}
+}
+
+func TestCacheDir(t *testing.T) {
+	defer os.Setenv("GOMODCACHE", os.Getenv("GOMODCACHE"))
+	defer os.Setenv("GOPATH", os.Getenv("GOPATH"))
+
+	os.Setenv("GOMODCACHE", "/tmp/modcache")
+	if got := CacheDir(); got != "/tmp/modcache" {
+		t.Errorf("CacheDir() = %q, want the value of GOMODCACHE", got)
+	}
+	os.Setenv("GOMODCACHE", "")
+	os.Setenv("GOPATH", filepath.Join("/tmp", "gopath")+string(filepath.ListSeparator)+"/tmp/other")
+	if got, want := CacheDir(), filepath.Join("/tmp", "gopath", "pkg", "mod"); got != want {
+		t.Errorf("CacheDir() = %q, want %q", got, want)
+	}
+}
+
+// writeFiles creates the named files, with parent directories, under dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestScanCache(t *testing.T) {
+	cache, err := ioutil.TempDir("", "modcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cache)
+	writeFiles(t, cache, map[string]string{
+		"cache/download/golang.org/x/text/@v/v0.3.3.zip":    "",
+		"github.com/!burnt!sushi/toml@v0.3.1/LICENSE":       "",
+		"github.com/!burnt!sushi/toml@v0.3.1/sub@v1/go.mod": "",
+		"golang.org/x/text@v0.3.3/LICENSE":                  "",
+		"golang.org/x/text@v0.3.0/LICENSE":                  "",
+	})
+
+	got, err := ScanCache(cache)
+	if err != nil {
+		t.Fatalf("ScanCache() failed: %v", err)
+	}
+	want := []*Module{
+		{Path: "github.com/BurntSushi/toml", Version: "v0.3.1", Dir: filepath.Join(cache, "github.com", "!burnt!sushi", "toml@v0.3.1")},
+		{Path: "golang.org/x/text", Version: "v0.3.0", Dir: filepath.Join(cache, "golang.org", "x", "text@v0.3.0")},
+		{Path: "golang.org/x/text", Version: "v0.3.3", Dir: filepath.Join(cache, "golang.org", "x", "text@v0.3.3")},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ScanCache() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	mods := []*Module{
+		{Path: "github.com/BurntSushi/toml", Version: "v0.3.1"},
+		{Path: "golang.org/x/text", Version: "v0.3.3", Replace: &Module{Path: "github.com/golang/text", Version: "v0.3.4"}},
+		{Path: "example.com/local", Version: "v1.2.3", Replace: &Module{Path: "../local"}},
+		{Path: "example.com/known", Version: "v1.0.0", Dir: "/src/known"},
+	}
+	if err := Resolve(mods, filepath.Join("/src", "app"), "/cache"); err != nil {
+		t.Fatalf("Resolve() failed: %v", err)
+	}
+	want := []string{
+		filepath.Join("/cache", "github.com", "!burnt!sushi", "toml@v0.3.1"),
+		filepath.Join("/cache", "github.com", "golang", "text@v0.3.4"),
+		filepath.Join("/src", "local"),
+		"/src/known",
+	}
+	for i, m := range mods {
+		if m.Dir != want[i] {
+			t.Errorf("Resolve() set the Dir of %s to %q, want %q", m, m.Dir, want[i])
+		}
+	}
+}
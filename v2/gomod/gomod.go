@@ -0,0 +1,201 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gomod audits the licenses of the dependencies of a Go module. The
+// modules are read from a go.mod or go.sum file, the build information of a
+// Go binary, or a module cache directory, and the license files of each are
+// located in the module cache, or downloaded from a module proxy, and
+// classified.
+//
+//	c, err := classifier.New(classifier.WithCorpusDir(licenseDir))
+//	...
+//	reports, err := gomod.AuditFile(c, "go.sum", gomod.CacheDir())
+//	...
+//	for _, r := range reports {
+//		fmt.Printf("%s %s: %s (%v)\n", r.Module.Path, r.Module.Version, r.License, r.Confidence)
+//	}
+package gomod
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Module identifies a version of a module.
+type Module struct {
+	Path    string
+	Version string
+	// Indirect is true for requirements marked "// indirect" in go.mod.
+	Indirect bool
+	// Replace is the module providing the source of this one, if it was
+	// replaced in go.mod. A replacement in the local file system has a path
+	// starting with ./ or ../, or an absolute path, and no version.
+	Replace *Module
+	// Dir is the directory holding the source of the module, if it's known.
+	Dir string
+}
+
+func (m *Module) String() string {
+	if m.Version == "" {
+		return m.Path
+	}
+	return m.Path + "@" + m.Version
+}
+
+// isLocal returns true if the module is a directory in the local file system.
+func (m *Module) isLocal() bool {
+	return m.Version == "" && (strings.HasPrefix(m.Path, "./") || strings.HasPrefix(m.Path, "../") ||
+		strings.HasPrefix(m.Path, "/") || filepath.IsAbs(m.Path))
+}
+
+// ParseGoMod returns the modules required by the supplied go.mod file, with
+// any replacements applied, in the order they're listed.
+func ParseGoMod(data []byte) ([]*Module, error) {
+	var mods []*Module
+	replace := make(map[string]*Module)
+	block := ""
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; s.Scan(); n++ {
+		line := s.Text()
+		indirect := false
+		if i := strings.Index(line, "//"); i >= 0 {
+			indirect = strings.TrimSpace(line[i+2:]) == "indirect"
+			line = line[:i]
+		}
+		f := strings.Fields(line)
+		if len(f) == 0 {
+			continue
+		}
+
+		verb := block
+		switch {
+		case block != "" && f[0] == ")":
+			block = ""
+			continue
+		case block == "" && len(f) == 2 && f[1] == "(":
+			block = f[0]
+			continue
+		case block == "":
+			verb, f = f[0], f[1:]
+		}
+
+		switch verb {
+		case "require":
+			if len(f) != 2 {
+				return nil, fmt.Errorf("go.mod:%d: malformed require directive", n)
+			}
+			path, err := unquote(f[0])
+			if err != nil {
+				return nil, fmt.Errorf("go.mod:%d: %v", n, err)
+			}
+			mods = append(mods, &Module{Path: path, Version: f[1], Indirect: indirect})
+		case "replace":
+			arrow := -1
+			for i, w := range f {
+				if w == "=>" {
+					arrow = i
+				}
+			}
+			if arrow < 1 || arrow > 2 || len(f)-arrow-1 < 1 || len(f)-arrow-1 > 2 {
+				return nil, fmt.Errorf("go.mod:%d: malformed replace directive", n)
+			}
+			old, err := unquote(f[0])
+			if err != nil {
+				return nil, fmt.Errorf("go.mod:%d: %v", n, err)
+			}
+			if arrow == 2 {
+				old += "@" + f[1]
+			}
+			path, err := unquote(f[arrow+1])
+			if err != nil {
+				return nil, fmt.Errorf("go.mod:%d: %v", n, err)
+			}
+			r := &Module{Path: path}
+			if len(f) == arrow+3 {
+				r.Version = f[arrow+2]
+			}
+			replace[old] = r
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, m := range mods {
+		if r, ok := replace[m.Path+"@"+m.Version]; ok {
+			m.Replace = r
+		} else if r, ok := replace[m.Path]; ok {
+			m.Replace = r
+		}
+	}
+	return mods, nil
+}
+
+// ParseGoSum returns the modules whose source is listed in the supplied
+// go.sum file, ordered by path and version. Modules listed only for their
+// go.mod file aren't part of the build and are omitted.
+func ParseGoSum(data []byte) ([]*Module, error) {
+	var mods []*Module
+	seen := make(map[string]bool)
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; s.Scan(); n++ {
+		f := strings.Fields(s.Text())
+		if len(f) == 0 {
+			continue
+		}
+		if len(f) != 3 {
+			return nil, fmt.Errorf("go.sum:%d: malformed line", n)
+		}
+		if strings.HasSuffix(f[1], "/go.mod") {
+			continue
+		}
+		m := &Module{Path: f[0], Version: f[1]}
+		if seen[m.String()] {
+			continue
+		}
+		seen[m.String()] = true
+		mods = append(mods, m)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	sortModules(mods)
+	return mods, nil
+}
+
+// unquote returns a module path, which may be written as a Go string literal.
+func unquote(s string) (string, error) {
+	if !strings.HasPrefix(s, `"`) && !strings.HasPrefix(s, "`") {
+		return s, nil
+	}
+	u, err := strconv.Unquote(s)
+	if err != nil {
+		return "", fmt.Errorf("malformed module path %s", s)
+	}
+	return u, nil
+}
+
+func sortModules(mods []*Module) {
+	sort.Slice(mods, func(i, j int) bool {
+		if mods[i].Path != mods[j].Path {
+			return mods[i].Path < mods[j].Path
+		}
+		return mods[i].Version < mods[j].Version
+	})
+}
@@ -0,0 +1,101 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gomod
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseGoMod(t *testing.T) {
+	gomod := `module example.com/app
+
+go 1.15
+
+require github.com/single/dep v1.0.0
+
+require (
+	github.com/BurntSushi/toml v0.3.1 // indirect
+	golang.org/x/text v0.3.3
+	"example.com/quoted" v0.1.0
+	example.com/local v1.2.3 // keep this one
+)
+
+replace golang.org/x/text v0.3.3 => github.com/golang/text v0.3.4
+
+replace (
+	example.com/local => ../local
+	example.com/unused v1.0.0 => example.com/other v1.0.0
+)
+
+exclude example.com/broken v1.0.0
+`
+	want := []*Module{
+		{Path: "github.com/single/dep", Version: "v1.0.0"},
+		{Path: "github.com/BurntSushi/toml", Version: "v0.3.1", Indirect: true},
+		{Path: "golang.org/x/text", Version: "v0.3.3", Replace: &Module{Path: "github.com/golang/text", Version: "v0.3.4"}},
+		{Path: "example.com/quoted", Version: "v0.1.0"},
+		{Path: "example.com/local", Version: "v1.2.3", Replace: &Module{Path: "../local"}},
+	}
+	got, err := ParseGoMod([]byte(gomod))
+	if err != nil {
+		t.Fatalf("ParseGoMod() failed: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ParseGoMod() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseGoModErrors(t *testing.T) {
+	tests := []string{
+		"require example.com/dep\n",
+		"require (\n\texample.com/dep v1.0.0 extra\n)\n",
+		"replace example.com/dep v1.0.0\n",
+		"replace => example.com/other v1.0.0\n",
+		`require "example.com/dep v1.0.0` + "\n",
+	}
+	for _, tt := range tests {
+		if _, err := ParseGoMod([]byte(tt)); err == nil {
+			t.Errorf("ParseGoMod(%q) succeeded, want error", tt)
+		}
+	}
+}
+
+func TestParseGoSum(t *testing.T) {
+	gosum := `github.com/davecgh/go-spew v1.1.1 h1:vj9j/u1bqnvCEfJOwUhtlOARqs3+rkHYY13jYWTU97c=
+github.com/davecgh/go-spew v1.1.1/go.mod h1:J7Y8YcW2NihsgmVo/mv3lAwl/skON4iLHjSsI+c5H38=
+github.com/google/go-cmp v0.5.2 h1:X2ev0eStA3AbceY54o37/0PQ/UWqKEiiO2dKL5OPaFM=
+github.com/google/go-cmp v0.5.2/go.mod h1:v8dTdLbMG2kIc/vJvl+f65V22dbkXbowE6jgT/gNBxE=
+golang.org/x/xerrors v0.0.0-20191204190536-9bdfabe68543/go.mod h1:I/5z698sn9Ujc0iS4iM4I6Q1hGXM1Am2uSlRXHZFjvc=
+
+github.com/davecgh/go-spew v1.1.1 h1:vj9j/u1bqnvCEfJOwUhtlOARqs3+rkHYY13jYWTU97c=
+`
+	want := []*Module{
+		{Path: "github.com/davecgh/go-spew", Version: "v1.1.1"},
+		{Path: "github.com/google/go-cmp", Version: "v0.5.2"},
+	}
+	got, err := ParseGoSum([]byte(gosum))
+	if err != nil {
+		t.Fatalf("ParseGoSum() failed: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ParseGoSum() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := ParseGoSum([]byte("github.com/google/go-cmp v0.5.2\n")); err == nil {
+		t.Error("ParseGoSum() of a malformed line succeeded, want error")
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gomod
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+// maxModuleZipSize is the largest module zip the go command accepts.
+const maxModuleZipSize = 500 << 20
+
+// DefaultProxy is the module proxy used by the go command by default.
+const DefaultProxy = "https://proxy.golang.org"
+
+// AuditProxy classifies the license files of each module in its zip, as
+// downloaded from the supplied module proxy, without extracting it. This
+// audits modules that aren't in the local module cache. Modules replaced by
+// local directories can't be downloaded and are reported with an error.
+// Unlike Audit, the Files of the reports only hold the license files in which
+// matches were found.
+func AuditProxy(c *classifier.Classifier, mods []*Module, proxy string) []*Report {
+	var reports []*Report
+	for _, m := range mods {
+		r := &Report{Module: m}
+		r.Files, r.Err = auditZip(c, m, strings.TrimSuffix(proxy, "/"))
+		r.License, r.Confidence = conclude(r.Files)
+		reports = append(reports, r)
+	}
+	return reports
+}
+
+func auditZip(c *classifier.Classifier, m *Module, proxy string) ([]*LicenseFile, error) {
+	src := m
+	if m.Replace != nil {
+		src = m.Replace
+	}
+	if src.isLocal() {
+		return nil, fmt.Errorf("module %s is replaced by local directory %s", m, src.Path)
+	}
+	path, err := EscapePath(src.Path)
+	if err != nil {
+		return nil, err
+	}
+	version, err := EscapePath(src.Version)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.Get(fmt.Sprintf("%s/%s/@v/%s.zip", proxy, path, version))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't download module %s: %v", src, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("couldn't download module %s: %s", src, resp.Status)
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxModuleZipSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't download module %s: %v", src, err)
+	}
+	if len(b) > maxModuleZipSize {
+		return nil, fmt.Errorf("module %s is larger than %d bytes", src, maxModuleZipSize)
+	}
+	results, err := c.MatchArchive(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+
+	// Module zips hold the files of the module under a path@version
+	// directory, and only the license files at its root are considered, as
+	// for modules in the module cache.
+	prefix := src.Path + "@" + src.Version + "/"
+	var files []*LicenseFile
+	for _, res := range results {
+		name := strings.TrimPrefix(res.Name, prefix)
+		if name == res.Name || strings.Contains(name, "/") {
+			continue
+		}
+		files = append(files, &LicenseFile{Name: name, Matches: res.Matches})
+	}
+	return files, nil
+}
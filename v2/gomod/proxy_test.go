@@ -0,0 +1,79 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gomod
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+func TestAuditProxy(t *testing.T) {
+	c := classifier.NewClassifier(0.8)
+	if err := c.LoadLicenses(filepath.Join("..", "licenses")); err != nil {
+		t.Fatalf("LoadLicenses() failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"github.com/Upper/mod@v1.0.0/LICENSE":     readLicense(t, "MIT.txt"),
+		"github.com/Upper/mod@v1.0.0/sub/LICENSE": readLicense(t, "BSD-3-Clause.txt"),
+		"github.com/Upper/mod@v1.0.0/mod.go":      "package mod\n",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Module proxies are queried with escaped module paths.
+		if r.URL.Path != "/github.com/!upper/mod/@v/v1.0.0.zip" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(buf.Bytes())
+	}))
+	defer srv.Close()
+
+	mods := []*Module{
+		{Path: "github.com/Upper/mod", Version: "v1.0.0"},
+		{Path: "example.com/missing", Version: "v1.0.0"},
+		{Path: "example.com/replaced", Version: "v1.0.0", Replace: &Module{Path: "github.com/Upper/mod", Version: "v1.0.0"}},
+		{Path: "example.com/local", Version: "v1.0.0", Replace: &Module{Path: "../local"}},
+	}
+	reports := AuditProxy(c, mods, srv.URL+"/")
+	for _, i := range []int{0, 2} {
+		r := reports[i]
+		if r.Err != nil || r.License != "MIT" || len(r.Files) != 1 || r.Files[0].Name != "LICENSE" {
+			t.Errorf("AuditProxy() report for %s = %q, %v, %v, want MIT from LICENSE", r.Module, r.License, r.Files, r.Err)
+		}
+	}
+	for _, i := range []int{1, 3} {
+		if r := reports[i]; r.Err == nil {
+			t.Errorf("AuditProxy() report for %s succeeded, want error", r.Module)
+		}
+	}
+}
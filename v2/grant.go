@@ -0,0 +1,145 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// This file contains routines to detect informal permission statements, such
+// as "Permission to use, copy and modify this file is granted", that aren't
+// the text of any license of the corpus. They grant rights under terms no one
+// has vetted, so they are reported for review rather than ignored.
+
+const grantType = "Grant"
+
+// AdHocGrant is the name of the matches of informal permission statements.
+// Their MatchType is "Grant".
+const AdHocGrant = "LicenseRef-ad-hoc-grant"
+
+// grantVerbs are the rights informal grants usually mention.
+const grantVerbs = `(?:use|copy|modify|distribute|redistribute|reproduce|sell)`
+
+// grantRule recognizes informal permission statements in the text of the
+// tokens of a document separated by single spaces. Matching the expressions is
+// slow, so they are only matched against texts containing the literal.
+type grantRule struct {
+	literal string
+	re      *regexp.Regexp
+}
+
+// grantRules recognize informal permission statements.
+var grantRules = []grantRule{
+	// Permission is hereby granted to anyone to use, copy...
+	{"permission", regexp.MustCompile(`\bpermission (?:is )?(?:hereby )?(?:granted|given) (?:[^ ]+ ){0,12}?to ` + grantVerbs + `\b`)},
+	// Permission to use, copy, modify... is hereby granted.
+	{"permission", regexp.MustCompile(`\bpermission to ` + grantVerbs + ` (?:[^ ]+ ){0,40}?(?:is |are )?(?:hereby )?(?:granted|given)\b`)},
+	// You are free to use, copy...
+	{"free to", regexp.MustCompile(`\b(?:you|anyone|everyone) (?:is |are )free to ` + grantVerbs + `\b`)},
+	// This file may be freely used, copied...
+	{"freely", regexp.MustCompile(`\bmay be freely (?:used|copied|modified|distributed|redistributed|reproduced)\b`)},
+}
+
+// grantStatements are informal grants commonly found verbatim, in normalized
+// form, that the rules don't recognize.
+var grantStatements = []string{
+	"do whatever you want with this",
+	"do what you want with this",
+	"use it however you want",
+	"use it as you see fit",
+	"no restrictions on its use",
+	"you can use this code for anything",
+	"feel free to use this code",
+	"feel free to use copy",
+	"feel free to use modify",
+	"use at your own risk and do what you want",
+}
+
+var grantStatementRules = func() []grantRule {
+	var out []grantRule
+	for _, s := range grantStatements {
+		out = append(out, grantRule{s, regexp.MustCompile(`\b` + regexp.QuoteMeta(s) + `\b`)})
+	}
+	return out
+}()
+
+// withGrants adds a match for each informal permission statement in the
+// tokenized document to the references found in it, keeping the matches in
+// the order of their lines. Like references, grants that are part of the
+// text of a license match are discarded by addReferences.
+func withGrants(refs Matches, doc *document) Matches {
+	out := append(refs, findGrants(doc)...)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].StartLine < out[j].StartLine })
+	return out
+}
+
+// findGrants returns a match for each informal permission statement in the
+// tokenized document.
+func findGrants(doc *document) Matches {
+	words := make([]string, len(doc.Tokens))
+	for i, t := range doc.Tokens {
+		words[i] = t.Text
+	}
+	norm := strings.Join(words, " ")
+	type span struct{ start, end int }
+	var spans []span
+	for _, rules := range [][]grantRule{grantRules, grantStatementRules} {
+		for _, r := range rules {
+			if !strings.Contains(norm, r.literal) {
+				continue
+			}
+			for _, loc := range r.re.FindAllStringIndex(norm, -1) {
+				spans = append(spans, span{loc[0], loc[1]})
+			}
+		}
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+
+	// Statements are usually found by several rules, so the tokens they
+	// cover are marked and each run of marked tokens is a single grant.
+	covered := make([]bool, len(doc.Tokens))
+	for _, s := range spans {
+		first := strings.Count(norm[:s.start], " ")
+		last := first + strings.Count(norm[s.start:s.end], " ")
+		for i := first; i <= last && i < len(covered); i++ {
+			covered[i] = true
+		}
+	}
+	var out Matches
+	for i := 0; i < len(covered); i++ {
+		if !covered[i] {
+			continue
+		}
+		j := i
+		for j+1 < len(covered) && covered[j+1] {
+			j++
+		}
+		out = append(out, &Match{
+			Name:            AdHocGrant,
+			Confidence:      1.0,
+			MatchType:       grantType,
+			StartLine:       doc.Tokens[i].Line,
+			EndLine:         doc.Tokens[j].Line,
+			StartTokenIndex: doc.Tokens[i].Index,
+			EndTokenIndex:   doc.Tokens[j].Index,
+		})
+		i = j
+	}
+	return out
+}
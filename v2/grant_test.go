@@ -0,0 +1,91 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindGrants(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		lines [][2]int
+	}{
+		{
+			name:  "permission is granted",
+			in:    "Copyright 1999 Someone.\nPermission is hereby granted to anyone to use this file for any purpose.\n",
+			lines: [][2]int{{2, 2}},
+		},
+		{
+			name:  "permission to use",
+			in:    "// Permission to use, copy and\n// modify this file is granted,\n// provided this notice is kept.\n",
+			lines: [][2]int{{1, 2}},
+		},
+		{
+			name:  "free to use",
+			in:    "You are free to use this code.\n\nThis file may be freely copied.\n",
+			lines: [][2]int{{1, 1}, {3, 3}},
+		},
+		{
+			name:  "statement",
+			in:    "# Do whatever you want with this.\n",
+			lines: [][2]int{{1, 1}},
+		},
+		{
+			name: "no grant",
+			in:   "Permission was denied.\nYou are not free to use this.\n",
+		},
+	}
+	for _, tt := range tests {
+		got := findGrants(tokenize([]byte(tt.in)))
+		if len(got) != len(tt.lines) {
+			t.Errorf("%s: findGrants() = %d grants, want %d", tt.name, len(got), len(tt.lines))
+			continue
+		}
+		for i, m := range got {
+			if m.Name != AdHocGrant || m.MatchType != grantType || m.StartLine != tt.lines[i][0] || m.EndLine != tt.lines[i][1] {
+				t.Errorf("%s: grant %d = %+v, want lines %v", tt.name, i, m, tt.lines[i])
+			}
+		}
+	}
+}
+
+func TestMatchGrants(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+
+	m := c.Match([]byte("Copyright 2001 Someone.\n\nPermission to use, copy, and modify this software\nis hereby granted, provided this notice is retained.\n"))
+	if len(m) != 1 || m[0].Name != AdHocGrant || m[0].StartLine != 3 || m[0].EndLine != 4 {
+		t.Errorf("Match() = %v, want an ad-hoc grant on lines 3-4", m)
+	}
+
+	// The grants of license texts are part of their matches.
+	for _, name := range []string{"MIT.txt", "ISC.txt"} {
+		b, err := ioutil.ReadFile(filepath.Join(baseLicenses, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range c.Match(b) {
+			if m.MatchType == grantType {
+				t.Errorf("Match(%s) reported a grant: %+v", name, m)
+			}
+		}
+	}
+}
@@ -0,0 +1,20 @@
+module github.com/google/licenseclassifier/v2/grpcserver
+
+go 1.21
+
+require (
+	github.com/google/licenseclassifier/v2 v2.0.0
+	google.golang.org/grpc v1.64.1
+	google.golang.org/protobuf v1.34.2
+)
+
+require (
+	github.com/sergi/go-diff v1.1.0 // indirect
+	golang.org/x/net v0.26.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+	golang.org/x/text v0.16.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237 // indirect
+)
+
+// The server is developed alongside the classifier.
+replace github.com/google/licenseclassifier/v2 => ../
@@ -0,0 +1,155 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package grpcserver exposes the license classifier as a gRPC service, the
+// Classifier service of licenseclassifier.proto, for clients that would
+// rather not speak the JSON API of the server package. It is a module of its
+// own, so that programs using the classifier don't depend on gRPC.
+//
+//	c, err := classifier.New(classifier.WithCorpusDir(dir))
+//	...
+//	s := grpc.NewServer(grpc.MaxRecvMsgSize(32 << 20))
+//	licenseclassifierpb.RegisterClassifierServer(s, grpcserver.New(c))
+//	log.Fatal(s.Serve(lis))
+//
+// The size of requests is limited by the MaxRecvMsgSize of the gRPC server,
+// 4MB unless it is set.
+package grpcserver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	classifier "github.com/google/licenseclassifier/v2"
+	pb "github.com/google/licenseclassifier/v2/grpcserver/licenseclassifierpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements the Classifier service with a shared classifier.
+type Server struct {
+	pb.UnimplementedClassifierServer
+
+	c *classifier.Classifier
+}
+
+// New returns a server classifying content with the supplied classifier,
+// which must not be modified while the server is in use.
+func New(c *classifier.Classifier) *Server {
+	return &Server{c: c}
+}
+
+// Classify finds the licenses in the content of the request.
+func (s *Server) Classify(ctx context.Context, req *pb.ClassifyRequest) (*pb.ClassifyResponse, error) {
+	ms := s.c.Match(req.Content)
+	return &pb.ClassifyResponse{Matches: matches(ms), Coverage: s.c.Coverage(req.Content, ms)}, nil
+}
+
+// zipMagic starts zip archives.
+var zipMagic = []byte("PK\x03\x04")
+
+// ClassifyArchive finds the licenses in the likely license files of a zip or
+// tar archive. Archives too large to classify fail with
+// codes.ResourceExhausted.
+func (s *Server) ClassifyArchive(ctx context.Context, req *pb.ClassifyArchiveRequest) (*pb.ClassifyArchiveResponse, error) {
+	var results []*classifier.ArchiveResult
+	var err error
+	if bytes.HasPrefix(req.Archive, zipMagic) {
+		results, err = s.c.MatchArchive(bytes.NewReader(req.Archive), int64(len(req.Archive)))
+	} else {
+		results, err = s.c.MatchTar(bytes.NewReader(req.Archive))
+	}
+	switch {
+	case errors.Is(err, classifier.ErrDocumentTooLarge):
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	resp := &pb.ClassifyArchiveResponse{}
+	for _, r := range results {
+		resp.Entries = append(resp.Entries, &pb.ArchiveEntry{Name: r.Name, Matches: matches(r.Matches)})
+	}
+	return resp, nil
+}
+
+// Corpus describes the licenses of the corpus.
+func (s *Server) Corpus(ctx context.Context, req *pb.CorpusRequest) (*pb.CorpusResponse, error) {
+	db := s.c.LicenseDB()
+	stats := make(map[string]classifier.LicenseStats)
+	for _, st := range s.c.Licenses() {
+		stats[st.Name] = st
+	}
+	resp := &pb.CorpusResponse{Version: s.c.CorpusVersion(), IndexBytes: s.c.IndexBytes()}
+	for _, id := range db.IDs() {
+		l, _ := db.Lookup(id)
+		st := stats[id]
+		var obligations []string
+		for _, o := range l.Obligations {
+			obligations = append(obligations, o.String())
+		}
+		resp.Licenses = append(resp.Licenses, &pb.License{
+			Id:          l.ID,
+			Name:        l.Name,
+			Category:    l.Category,
+			OsiApproved: l.OSIApproved,
+			Obligations: obligations,
+			Variants:    int32(st.Variants),
+			MinTokens:   int32(st.MinTokens),
+			MaxTokens:   int32(st.MaxTokens),
+			IndexBytes:  st.IndexBytes,
+		})
+	}
+	return resp, nil
+}
+
+// matches converts classifier matches to their response form.
+func matches(ms classifier.Matches) []*pb.Match {
+	var out []*pb.Match
+	for _, m := range ms {
+		var alts []*pb.Alternative
+		for _, a := range m.Alternatives {
+			alts = append(alts, &pb.Alternative{Name: a.Name, MatchType: a.MatchType, Confidence: a.Confidence})
+		}
+		var threshold float64
+		if m.Metadata != nil {
+			threshold = m.Metadata.Threshold
+		}
+		out = append(out, &pb.Match{
+			Id:                   m.ID,
+			Name:                 m.Name,
+			SpdxId:               m.SPDXID(),
+			Expression:           m.Expression(),
+			MatchType:            m.MatchType,
+			Kind:                 string(m.Kind()),
+			Category:             m.Category,
+			Variant:              m.Variant,
+			Language:             m.Language,
+			Confidence:           m.Confidence,
+			StartLine:            int32(m.StartLine),
+			EndLine:              int32(m.EndLine),
+			PatentGrant:          m.PatentGrant.String(),
+			PatentRetaliation:    m.PatentRetaliation.String(),
+			TrademarkRestriction: m.TrademarkRestriction,
+			BinaryAttribution:    m.BinaryAttribution,
+			RecommendedThreshold: threshold,
+			Phrases:              m.Phrases,
+			Choice:               m.Choice,
+			Approximate:          m.Approximate,
+			Overlaps:             m.Overlaps,
+			Alternatives:         alts,
+		})
+	}
+	return out
+}
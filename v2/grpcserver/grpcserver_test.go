@@ -0,0 +1,145 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcserver
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"testing"
+
+	classifier "github.com/google/licenseclassifier/v2"
+	pb "github.com/google/licenseclassifier/v2/grpcserver/licenseclassifierpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newClient serves a classifier over an in-memory connection and returns a
+// client of it.
+func newClient(t *testing.T) pb.ClassifierClient {
+	t.Helper()
+	c, err := classifier.New(classifier.WithCorpusDir(filepath.Join("..", "licenses")))
+	if err != nil {
+		t.Fatalf("classifier.New() failed: %v", err)
+	}
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	pb.RegisterClassifierServer(s, New(c))
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc.NewClient() failed: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewClassifierClient(conn)
+}
+
+func readLicense(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := ioutil.ReadFile(filepath.Join("..", "licenses", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestClassify(t *testing.T) {
+	client := newClient(t)
+	ctx := context.Background()
+
+	resp, err := client.Classify(ctx, &pb.ClassifyRequest{Content: readLicense(t, "MIT.txt")})
+	if err != nil {
+		t.Fatalf("Classify() failed: %v", err)
+	}
+	if len(resp.Matches) != 1 {
+		t.Fatalf("Classify() = %v, want one match", resp.Matches)
+	}
+	m := resp.Matches[0]
+	if m.Name != "MIT" || m.SpdxId != "MIT" || m.Kind != string(classifier.FullText) || m.Confidence < 0.99 || m.StartLine != 1 {
+		t.Errorf("Classify() match = %v, want the full text of MIT", m)
+	}
+	if resp.Coverage < 0.9 {
+		t.Errorf("Classify() coverage = %v, want most of the text", resp.Coverage)
+	}
+
+	resp, err = client.Classify(ctx, &pb.ClassifyRequest{Content: []byte("no license here")})
+	if err != nil || len(resp.Matches) != 0 {
+		t.Errorf("Classify() of text without a license = %v, %v; want no matches", resp, err)
+	}
+}
+
+func TestClassifyArchive(t *testing.T) {
+	client := newClient(t)
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string][]byte{
+		"mod/LICENSE": readLicense(t, "Apache-2.0.txt"),
+		"mod/main.go": []byte("package main"),
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(content)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.ClassifyArchive(ctx, &pb.ClassifyArchiveRequest{Archive: buf.Bytes()})
+	if err != nil {
+		t.Fatalf("ClassifyArchive() failed: %v", err)
+	}
+	if len(resp.Entries) != 1 || resp.Entries[0].Name != "mod/LICENSE" || resp.Entries[0].Matches[0].Name != "Apache-2.0" {
+		t.Errorf("ClassifyArchive() entries = %v, want Apache-2.0 in mod/LICENSE", resp.Entries)
+	}
+
+	_, err = client.ClassifyArchive(ctx, &pb.ClassifyArchiveRequest{Archive: []byte("not an archive")})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("ClassifyArchive() of text = %v, want InvalidArgument", err)
+	}
+}
+
+func TestCorpus(t *testing.T) {
+	client := newClient(t)
+	resp, err := client.Corpus(context.Background(), &pb.CorpusRequest{})
+	if err != nil {
+		t.Fatalf("Corpus() failed: %v", err)
+	}
+	if resp.Version == "" || resp.IndexBytes == 0 {
+		t.Errorf("Corpus() = version %q, %d index bytes; want both set", resp.Version, resp.IndexBytes)
+	}
+	for _, l := range resp.Licenses {
+		if l.Id == "MIT" {
+			if l.Category == "" || !l.OsiApproved || l.Variants == 0 || len(l.Obligations) == 0 {
+				t.Errorf("Corpus() MIT = %v, want its category, approval, variants and obligations", l)
+			}
+			return
+		}
+	}
+	t.Error("Corpus() doesn't list MIT")
+}
@@ -0,0 +1,223 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: licenseclassifier.proto
+
+package licenseclassifierpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Classifier_Classify_FullMethodName        = "/licenseclassifier.v1.Classifier/Classify"
+	Classifier_ClassifyArchive_FullMethodName = "/licenseclassifier.v1.Classifier/ClassifyArchive"
+	Classifier_Corpus_FullMethodName          = "/licenseclassifier.v1.Classifier/Corpus"
+)
+
+// ClassifierClient is the client API for Classifier service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Classifier classifies content with a shared classifier.
+type ClassifierClient interface {
+	// Classify finds the licenses in the content of the request.
+	Classify(ctx context.Context, in *ClassifyRequest, opts ...grpc.CallOption) (*ClassifyResponse, error)
+	// ClassifyArchive finds the licenses in the likely license files of a zip
+	// or tar archive.
+	ClassifyArchive(ctx context.Context, in *ClassifyArchiveRequest, opts ...grpc.CallOption) (*ClassifyArchiveResponse, error)
+	// Corpus describes the licenses of the corpus.
+	Corpus(ctx context.Context, in *CorpusRequest, opts ...grpc.CallOption) (*CorpusResponse, error)
+}
+
+type classifierClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewClassifierClient(cc grpc.ClientConnInterface) ClassifierClient {
+	return &classifierClient{cc}
+}
+
+func (c *classifierClient) Classify(ctx context.Context, in *ClassifyRequest, opts ...grpc.CallOption) (*ClassifyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClassifyResponse)
+	err := c.cc.Invoke(ctx, Classifier_Classify_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *classifierClient) ClassifyArchive(ctx context.Context, in *ClassifyArchiveRequest, opts ...grpc.CallOption) (*ClassifyArchiveResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClassifyArchiveResponse)
+	err := c.cc.Invoke(ctx, Classifier_ClassifyArchive_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *classifierClient) Corpus(ctx context.Context, in *CorpusRequest, opts ...grpc.CallOption) (*CorpusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CorpusResponse)
+	err := c.cc.Invoke(ctx, Classifier_Corpus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ClassifierServer is the server API for Classifier service.
+// All implementations must embed UnimplementedClassifierServer
+// for forward compatibility.
+//
+// Classifier classifies content with a shared classifier.
+type ClassifierServer interface {
+	// Classify finds the licenses in the content of the request.
+	Classify(context.Context, *ClassifyRequest) (*ClassifyResponse, error)
+	// ClassifyArchive finds the licenses in the likely license files of a zip
+	// or tar archive.
+	ClassifyArchive(context.Context, *ClassifyArchiveRequest) (*ClassifyArchiveResponse, error)
+	// Corpus describes the licenses of the corpus.
+	Corpus(context.Context, *CorpusRequest) (*CorpusResponse, error)
+	mustEmbedUnimplementedClassifierServer()
+}
+
+// UnimplementedClassifierServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedClassifierServer struct{}
+
+func (UnimplementedClassifierServer) Classify(context.Context, *ClassifyRequest) (*ClassifyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Classify not implemented")
+}
+func (UnimplementedClassifierServer) ClassifyArchive(context.Context, *ClassifyArchiveRequest) (*ClassifyArchiveResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClassifyArchive not implemented")
+}
+func (UnimplementedClassifierServer) Corpus(context.Context, *CorpusRequest) (*CorpusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Corpus not implemented")
+}
+func (UnimplementedClassifierServer) mustEmbedUnimplementedClassifierServer() {}
+func (UnimplementedClassifierServer) testEmbeddedByValue()                    {}
+
+// UnsafeClassifierServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ClassifierServer will
+// result in compilation errors.
+type UnsafeClassifierServer interface {
+	mustEmbedUnimplementedClassifierServer()
+}
+
+func RegisterClassifierServer(s grpc.ServiceRegistrar, srv ClassifierServer) {
+	// If the following call pancis, it indicates UnimplementedClassifierServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Classifier_ServiceDesc, srv)
+}
+
+func _Classifier_Classify_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClassifyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClassifierServer).Classify(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Classifier_Classify_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClassifierServer).Classify(ctx, req.(*ClassifyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Classifier_ClassifyArchive_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClassifyArchiveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClassifierServer).ClassifyArchive(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Classifier_ClassifyArchive_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClassifierServer).ClassifyArchive(ctx, req.(*ClassifyArchiveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Classifier_Corpus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CorpusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClassifierServer).Corpus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Classifier_Corpus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClassifierServer).Corpus(ctx, req.(*CorpusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Classifier_ServiceDesc is the grpc.ServiceDesc for Classifier service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Classifier_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "licenseclassifier.v1.Classifier",
+	HandlerType: (*ClassifierServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Classify",
+			Handler:    _Classifier_Classify_Handler,
+		},
+		{
+			MethodName: "ClassifyArchive",
+			Handler:    _Classifier_ClassifyArchive_Handler,
+		},
+		{
+			MethodName: "Corpus",
+			Handler:    _Classifier_Corpus_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "licenseclassifier.proto",
+}
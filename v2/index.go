@@ -0,0 +1,460 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"unsafe"
+)
+
+// An index is the corpus of a classifier in the form it is matched in,
+// written by WriteIndex and loaded by LoadIndex. It holds the dictionary
+// and, for each entry, its tokens, normalized text, q-gram checksums and
+// packed q-gram hash as arrays of little-endian 32-bit words aligned to 4
+// bytes. On little-endian machines LoadIndex maps the file read-only and
+// uses these arrays in place rather than copying them to the heap, so the
+// scanners on a machine share a single copy of the corpus in the page
+// cache.
+//
+// The file starts with indexMagic and the version of the format, followed
+// by the length of the q-grams, the words of the dictionary in the order of
+// their IDs and the entries in the order of their names. Each string and
+// byte array is preceded by its length and padded to a multiple of 4 bytes.
+// Version 2 added the metadata of the entries; indexes of version 1 are
+// still loaded.
+const (
+	indexMagic   = "LCINDEX\x00"
+	indexVersion = 2
+)
+
+// Flags of the entries of an index.
+const (
+	indexPatentGrant = 1 << iota
+	indexPatentRetaliation
+	indexTemplate
+	indexMetadata
+)
+
+// ErrBadIndex is returned when a file isn't an index written by WriteIndex.
+var ErrBadIndex = errors.New("malformed license index")
+
+// WriteIndex writes the corpus of the classifier to w, in the form loaded
+// by LoadIndex.
+func (c *Classifier) WriteIndex(w io.Writer) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	bw := bufio.NewWriter(w)
+	iw := &indexWriter{w: bw}
+	iw.raw([]byte(indexMagic))
+	iw.u32(indexVersion)
+	iw.u32(uint32(c.q))
+	iw.u32(uint32(len(c.dict.words)))
+	for _, word := range c.dict.words {
+		iw.str(word)
+	}
+	names := sortedNames(c.docs)
+	iw.u32(uint32(len(names)))
+	for _, name := range names {
+		iw.entry(name, c.origins[name], c.docs[name])
+	}
+	if iw.err != nil {
+		return iw.err
+	}
+	return bw.Flush()
+}
+
+// indexWriter writes the fields of an index, recording the first error.
+type indexWriter struct {
+	w   io.Writer
+	n   int
+	err error
+}
+
+func (iw *indexWriter) raw(b []byte) {
+	if iw.err != nil {
+		return
+	}
+	var n int
+	n, iw.err = iw.w.Write(b)
+	iw.n += n
+}
+
+func (iw *indexWriter) u32(v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	iw.raw(b[:])
+}
+
+func (iw *indexWriter) u32s(vs []uint32) {
+	iw.u32(uint32(len(vs)))
+	b := make([]byte, 4*len(vs))
+	for i, v := range vs {
+		binary.LittleEndian.PutUint32(b[4*i:], v)
+	}
+	iw.raw(b)
+}
+
+// bytes writes b and pads it to keep the following fields aligned.
+func (iw *indexWriter) bytes(b []byte) {
+	iw.u32(uint32(len(b)))
+	iw.raw(b)
+	iw.raw(make([]byte, (4-iw.n%4)%4))
+}
+
+func (iw *indexWriter) str(s string) {
+	iw.bytes([]byte(s))
+}
+
+func (iw *indexWriter) bools(bs []bool) {
+	b := make([]byte, len(bs))
+	for i, v := range bs {
+		if v {
+			b[i] = 1
+		}
+	}
+	iw.bytes(b)
+}
+
+func (iw *indexWriter) entry(name, origin string, d *indexedDocument) {
+	iw.str(name)
+	iw.str(origin)
+	iw.bytes(d.content)
+	iw.str(d.norm)
+	toks := make([]uint32, 0, 3*len(d.Tokens))
+	for _, t := range d.Tokens {
+		toks = append(toks, uint32(t.ID), t.Index, t.Line)
+	}
+	iw.u32s(toks)
+
+	var flags uint32
+	if d.patentGrant {
+		flags |= indexPatentGrant
+	}
+	if d.patentRetaliation {
+		flags |= indexPatentRetaliation
+	}
+	if d.template != nil {
+		flags |= indexTemplate
+	}
+	if d.meta != nil {
+		flags |= indexMetadata
+	}
+	iw.u32(flags)
+
+	iw.u32(uint32(d.s.q))
+	iw.u32s(d.s.Checksums)
+	p := d.s.packed
+	if p == nil {
+		p = d.s.Hashes.pack()
+	}
+	iw.u32s(p.keys)
+	iw.u32s(p.ends)
+	iw.u32s(p.offsets)
+
+	iw.u32(uint32(len(d.clauses)))
+	for _, cl := range d.clauses {
+		iw.u32(uint32(cl.start))
+		iw.str(cl.label)
+	}
+
+	if t := d.template; t != nil {
+		iw.bools(t.variable)
+		iw.bools(t.optional)
+		var slots []uint32
+		for s, ok := range t.slots {
+			if ok {
+				slots = append(slots, uint32(s))
+			}
+		}
+		sort.Slice(slots, func(i, j int) bool { return slots[i] < slots[j] })
+		iw.u32s(slots)
+		iw.u32(uint32(t.omittable))
+	}
+
+	if d.meta != nil {
+		b, _ := json.Marshal(d.meta)
+		iw.bytes(b)
+	}
+}
+
+// LoadIndex adds the corpus of the index written to the file at path by
+// WriteIndex to the corpus of the classifier, which must be empty. The file
+// is mapped read-only where the platform allows it, and must not be
+// modified in place while the classifier is in use. Entries excluded by WithLicenses
+// or WithoutCategories are skipped, and the normalization exemptions of the
+// classifier apply to the entries as they do to loaded licenses.
+func (c *Classifier) LoadIndex(path string) error {
+	data, err := mapFile(path)
+	if err != nil {
+		return fmt.Errorf("couldn't read license index %s: %w", path, err)
+	}
+	if err := c.loadIndex(data); err != nil {
+		return fmt.Errorf("couldn't load license index %s: %w", path, err)
+	}
+	c.addSource(func(c *Classifier) error { return c.LoadIndex(path) })
+	return nil
+}
+
+// ReadIndex adds the corpus of the index read from r to the corpus of the
+// classifier, as LoadIndex does, holding the index in memory.
+func (c *Classifier) ReadIndex(r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("couldn't read license index: %w", err)
+	}
+	if err := c.loadIndex(data); err != nil {
+		return fmt.Errorf("couldn't load license index: %w", err)
+	}
+	return nil
+}
+
+func (c *Classifier) loadIndex(data []byte) error {
+	ir := &indexReader{b: data}
+	if !bytes.Equal(ir.raw(len(indexMagic)), []byte(indexMagic)) {
+		return ErrBadIndex
+	}
+	if v := ir.u32(); v < 1 || v > indexVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrBadIndex, v)
+	}
+	q := int(ir.u32())
+	dict := newDictionary()
+	dict.words = make([]string, ir.u32())
+	for i := range dict.words {
+		dict.words[i] = ir.str()
+		dict.indices[dict.words[i]] = tokenID(i + 1)
+	}
+	type entry struct {
+		name, origin string
+		d            *indexedDocument
+	}
+	entries := make([]entry, ir.u32())
+	for i := range entries {
+		if ir.err != nil {
+			return ir.err
+		}
+		entries[i].name, entries[i].origin, entries[i].d = ir.entry(dict)
+	}
+	if ir.err != nil {
+		return ir.err
+	}
+	if ir.off != len(data) {
+		return fmt.Errorf("%w: %d trailing bytes", ErrBadIndex, len(data)-ir.off)
+	}
+
+	defer c.update()()
+	if len(c.docs) > 0 {
+		return errors.New("an index can only be loaded into an empty corpus")
+	}
+	c.dict = dict
+	for _, e := range entries {
+		name, d := e.name, e.d
+		if !c.filter.retains(name) {
+			continue
+		}
+		if q != c.q {
+			d.generateSearchSet(c.q)
+		}
+		d.s.origin = name
+		d.generateFrequencies()
+		d.distinct = d.distinctTokens()
+		d.maxDistance = maxDistance(d.size(), c.threshold, c.minEditCost())
+		for t := range d.f.counts {
+			c.docFreq[t]++
+		}
+		if ex := c.exemptionsFor(name); len(ex) > 0 {
+			d.exemptions = ex
+			d.exemptCounts = exemptionCounts(exemptionText(d.content), ex)
+		}
+		c.setAnchors(name, d)
+		c.docs[name] = d
+		c.origins[name] = e.origin
+		c.checkEntry(name, d)
+	}
+	return nil
+}
+
+// indexReader reads the fields of an index, recording the first error. The
+// arrays and strings it returns share the memory of the index when it is
+// suitably aligned.
+type indexReader struct {
+	b   []byte
+	off int
+	err error
+}
+
+func (ir *indexReader) raw(n int) []byte {
+	if ir.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(ir.b)-ir.off {
+		ir.err = fmt.Errorf("%w: truncated at offset %d", ErrBadIndex, ir.off)
+		return nil
+	}
+	b := ir.b[ir.off : ir.off+n : ir.off+n]
+	ir.off += n
+	return b
+}
+
+func (ir *indexReader) u32() uint32 {
+	b := ir.raw(4)
+	if b == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+func (ir *indexReader) u32s() []uint32 {
+	n := int(ir.u32())
+	if n > len(ir.b) {
+		n = -1
+	}
+	b := ir.raw(4 * n)
+	if len(b) == 0 {
+		return nil
+	}
+	if littleEndian && uintptr(unsafe.Pointer(&b[0]))%4 == 0 {
+		return (*[1 << 28]uint32)(unsafe.Pointer(&b[0]))[:n:n]
+	}
+	vs := make([]uint32, n)
+	for i := range vs {
+		vs[i] = binary.LittleEndian.Uint32(b[4*i:])
+	}
+	return vs
+}
+
+func (ir *indexReader) bytes() []byte {
+	b := ir.raw(int(ir.u32()))
+	ir.raw((4 - ir.off%4) % 4)
+	return b
+}
+
+func (ir *indexReader) str() string {
+	b := ir.bytes()
+	if len(b) == 0 {
+		return ""
+	}
+	return *(*string)(unsafe.Pointer(&b))
+}
+
+func (ir *indexReader) bools() []bool {
+	b := ir.bytes()
+	bs := make([]bool, len(b))
+	for i, v := range b {
+		bs[i] = v != 0
+	}
+	return bs
+}
+
+func (ir *indexReader) entry(dict *dictionary) (name, origin string, d *indexedDocument) {
+	name = ir.str()
+	origin = ir.str()
+	d = &indexedDocument{dict: dict}
+	d.content = ir.bytes()
+	d.norm = ir.str()
+	toks := ir.u32s()
+	if len(toks)%3 != 0 {
+		ir.err = fmt.Errorf("%w: inconsistent entry %s", ErrBadIndex, name)
+	}
+	d.Tokens = tokens(toks)
+	flags := ir.u32()
+	d.patentGrant = flags&indexPatentGrant != 0
+	d.patentRetaliation = flags&indexPatentRetaliation != 0
+
+	d.s = &searchSet{Tokens: d.Tokens, q: int(ir.u32())}
+	d.s.Checksums = ir.u32s()
+	d.s.packed = &packedHash{keys: ir.u32s(), ends: ir.u32s(), offsets: ir.u32s()}
+
+	d.clauses = make([]clause, 0, ir.u32())
+	for i := 0; i < cap(d.clauses) && ir.err == nil; i++ {
+		start := int(ir.u32())
+		d.clauses = append(d.clauses, clause{start: start, label: ir.str()})
+	}
+
+	if flags&indexTemplate != 0 {
+		t := &template{variable: ir.bools(), optional: ir.bools(), slots: make(map[int]bool)}
+		for _, s := range ir.u32s() {
+			t.slots[int(s)] = true
+		}
+		t.omittable = int(ir.u32())
+		d.template = t
+	}
+	if flags&indexMetadata != 0 {
+		b := ir.bytes()
+		if ir.err == nil {
+			var err error
+			if d.meta, err = ParseEntryMetadata(b); err != nil {
+				ir.err = fmt.Errorf("%w: entry %s: %v", ErrBadIndex, name, err)
+			}
+		}
+	}
+	if ir.err == nil && !d.valid() {
+		ir.err = fmt.Errorf("%w: inconsistent entry %s", ErrBadIndex, name)
+	}
+	return name, origin, d
+}
+
+// valid reports whether the arrays of an entry read from an index are
+// consistent, so that matching can't index them out of range.
+func (d *indexedDocument) valid() bool {
+	for _, t := range d.Tokens {
+		if int(t.ID) > len(d.dict.words) {
+			return false
+		}
+	}
+	p := d.s.packed
+	if len(p.ends) != len(p.keys) || d.s.q > len(d.Tokens) || len(d.s.Checksums) > len(d.Tokens) {
+		return false
+	}
+	end := uint32(0)
+	for _, e := range p.ends {
+		if e < end || int(e) > len(p.offsets) {
+			return false
+		}
+		end = e
+	}
+	for _, o := range p.offsets {
+		if int(o)+d.s.q > len(d.Tokens) {
+			return false
+		}
+	}
+	return true
+}
+
+// tokens returns the tokens of an entry stored as triples of words, sharing
+// their memory.
+func tokens(vs []uint32) []indexedToken {
+	n := len(vs) / 3
+	if n == 0 {
+		return nil
+	}
+	// indexedToken is laid out as three uint32 fields, in the order they are
+	// written.
+	return (*[1 << 26]indexedToken)(unsafe.Pointer(&vs[0]))[:n:n]
+}
+
+// littleEndian is true if the machine stores words in the byte order of an
+// index, so that its arrays can be used in place.
+var littleEndian = func() bool {
+	v := uint16(1)
+	return *(*byte)(unsafe.Pointer(&v)) == 1
+}()
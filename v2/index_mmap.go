@@ -0,0 +1,73 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package classifier
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// mapping is the memory a file is mapped into, and the file it was mapped
+// from.
+type mapping struct {
+	fi   os.FileInfo
+	data []byte
+}
+
+// mappings holds the mapping of each index file, so that reloading an
+// unchanged index reuses its mapping rather than mapping it again.
+var mappings = struct {
+	sync.Mutex
+	m map[string]mapping
+}{m: make(map[string]mapping)}
+
+// mapFile maps the file at path into memory read-only. A mapping is never
+// released, since the corpora loaded from it refer to its memory, as do the
+// names of the matches found with them, which callers may keep indefinitely.
+// Instead, the file is mapped once for as long as it's unchanged: loading it
+// again, as Reload does, reuses the mapping. Only an index replaced by a new
+// file is mapped again, leaving the mapping of the old file in place.
+func mapFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := fi.Size()
+	if size == 0 || int64(int(size)) != size {
+		return ioutil.ReadAll(f)
+	}
+
+	mappings.Lock()
+	defer mappings.Unlock()
+	if m, ok := mappings.m[path]; ok && os.SameFile(m.fi, fi) && m.fi.Size() == size && m.fi.ModTime().Equal(fi.ModTime()) {
+		return m.data, nil
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	mappings.m[path] = mapping{fi: fi, data: data}
+	return data, nil
+}
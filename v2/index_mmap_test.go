@@ -0,0 +1,69 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package classifier
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMapFileReusesMapping(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mapfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "licenses.idx")
+	if err := ioutil.WriteFile(path, []byte("first index"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := mapFile(path)
+	if err != nil {
+		t.Fatalf("mapFile() failed: %v", err)
+	}
+	b, err := mapFile(path)
+	if err != nil {
+		t.Fatalf("mapFile() failed: %v", err)
+	}
+	if &a[0] != &b[0] {
+		t.Error("mapFile() of an unchanged file mapped it again")
+	}
+
+	// An index is replaced by renaming a new file over it.
+	tmp := filepath.Join(dir, "licenses.idx.new")
+	if err := ioutil.WriteFile(tmp, []byte("second index"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	c, err := mapFile(path)
+	if err != nil {
+		t.Fatalf("mapFile() failed: %v", err)
+	}
+	if got := string(c); got != "second index" {
+		t.Errorf("mapFile() of a replaced file = %q, want %q", got, "second index")
+	}
+	// The old mapping stays valid for the corpora that refer to it.
+	if got := string(a); got != "first index" {
+		t.Errorf("old mapping = %q, want %q", got, "first index")
+	}
+}
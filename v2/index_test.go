@@ -0,0 +1,176 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestIndex(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	c.AddContent("Widget-1.0", []byte(versionedLicense))
+	dir, err := ioutil.TempDir("", "index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "licenses.idx")
+	var buf bytes.Buffer
+	if err := c.WriteIndex(&buf); err != nil {
+		t.Fatalf("WriteIndex() failed: %v", err)
+	}
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := New(WithThreshold(defaultThreshold), WithIndexFile(path))
+	if err != nil {
+		t.Fatalf("New(WithIndexFile()) failed: %v", err)
+	}
+	read := NewClassifier(defaultThreshold)
+	if err := read.ReadIndex(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("ReadIndex() failed: %v", err)
+	}
+	files, err := getScenarioFilenames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, l := range map[string]*Classifier{"LoadIndex": loaded, "ReadIndex": read} {
+		if got, want := l.CorpusVersion(), c.CorpusVersion(); got != want {
+			t.Errorf("%s: CorpusVersion() = %s, want %s", name, got, want)
+		}
+		if diff := cmp.Diff(c.Licenses(), l.Licenses(), cmpopts.IgnoreFields(LicenseStats{}, "IndexBytes")); diff != "" {
+			t.Errorf("%s: Licenses() mismatch (-want +got):\n%s", name, diff)
+		}
+		for _, f := range append(files, filepath.Join(baseLicenses, "MIT.txt")) {
+			in, err := ioutil.ReadFile(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.Match(in), l.Match(in)); diff != "" {
+				t.Errorf("%s: Match(%s) mismatch (-want +got):\n%s", name, f, diff)
+			}
+		}
+	}
+
+	// The index of a loaded corpus is the index it was loaded from.
+	var again bytes.Buffer
+	if err := loaded.WriteIndex(&again); err != nil {
+		t.Fatalf("WriteIndex() of the loaded corpus failed: %v", err)
+	}
+	if !bytes.Equal(again.Bytes(), buf.Bytes()) {
+		t.Error("WriteIndex() of the loaded corpus differs from the index it was loaded from")
+	}
+	if err := loaded.Reload(); err != nil {
+		t.Errorf("Reload() failed: %v", err)
+	}
+	if got, want := loaded.CorpusVersion(), c.CorpusVersion(); got != want {
+		t.Errorf("CorpusVersion() after Reload() = %s, want %s", got, want)
+	}
+	if err := loaded.LoadIndex(path); err == nil {
+		t.Error("LoadIndex() into a loaded corpus succeeded, want an error")
+	}
+}
+
+func TestIndexFilter(t *testing.T) {
+	c := NewClassifier(defaultThreshold)
+	c.AddContent("Widget-1.0", []byte(versionedLicense))
+	c.AddContent("Gadget-1.0", []byte(gadgetLicense))
+	var buf bytes.Buffer
+	if err := c.WriteIndex(&buf); err != nil {
+		t.Fatalf("WriteIndex() failed: %v", err)
+	}
+	dir, err := ioutil.TempDir("", "index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "licenses.idx")
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := New(WithThreshold(defaultThreshold), WithLicenses("Gadget-1.0"), WithIndexFile(path))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if diff := cmp.Diff([]string{"Gadget-1.0"}, l.LicenseDB().IDs()); diff != "" {
+		t.Errorf("licenses of the filtered index: (-want +got)\n%s", diff)
+	}
+}
+
+func TestBadIndex(t *testing.T) {
+	c := NewClassifier(defaultThreshold)
+	c.AddContent("Widget-1.0", []byte(versionedLicense))
+	var buf bytes.Buffer
+	if err := c.WriteIndex(&buf); err != nil {
+		t.Fatalf("WriteIndex() failed: %v", err)
+	}
+	index := buf.Bytes()
+	for name, in := range map[string][]byte{
+		"empty":     nil,
+		"magic":     []byte("not an index"),
+		"truncated": index[:len(index)-8],
+		"trailing":  append(append([]byte(nil), index...), 0, 0, 0, 0),
+	} {
+		err := NewClassifier(defaultThreshold).ReadIndex(bytes.NewReader(in))
+		if !errors.Is(err, ErrBadIndex) {
+			t.Errorf("ReadIndex(%s) = %v, want ErrBadIndex", name, err)
+		}
+	}
+}
+
+func BenchmarkIndexMemory(b *testing.B) {
+	c, err := classifier()
+	if err != nil {
+		b.Fatal(err)
+	}
+	f, err := ioutil.TempFile("", "index")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if err := c.WriteIndex(f); err != nil {
+		b.Fatal(err)
+	}
+	f.Close()
+	b.ResetTimer()
+	var retained uint64
+	for i := 0; i < b.N; i++ {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		l := NewClassifier(defaultThreshold)
+		if err := l.LoadIndex(f.Name()); err != nil {
+			b.Fatal(err)
+		}
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		retained += after.HeapAlloc - before.HeapAlloc
+		runtime.KeepAlive(l)
+	}
+	b.ReportMetric(float64(retained)/float64(b.N), "heap-bytes")
+}
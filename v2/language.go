@@ -0,0 +1,128 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import "strings"
+
+// Translations of licenses are corpus entries named after the license with
+// the ISO 639-1 code of their language as variant suffix, such as
+// "EUPL-1.2_de" for the German text of the EUPL 1.2. They are reported under
+// the name of the license, with the language of the text in Match.Language.
+// The official translations of a license are legally equivalent to its
+// English text, so a match of any of them identifies the same license.
+
+// stopwords are frequent words of the languages translations are detected
+// in, chosen to be rare in the other languages. Accented words are left out,
+// since normalization may strip their accents.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "that", "for", "with", "this", "or", "be", "by", "any", "shall"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "mit", "von", "zu", "den", "dem", "des", "eine", "oder", "sie", "auf", "werden"},
+	"fr": {"le", "les", "et", "du", "est", "une", "pour", "dans", "qui", "par", "sur", "ou", "pas", "ne", "vous", "au"},
+	"es": {"el", "los", "las", "y", "que", "en", "por", "con", "para", "una", "del", "se", "lo", "su"},
+	"it": {"il", "di", "che", "e", "per", "un", "della", "non", "con", "sono", "si", "gli", "delle", "al"},
+	"nl": {"het", "een", "en", "van", "dat", "niet", "op", "te", "voor", "met", "zijn", "wordt", "deze"},
+	"pt": {"os", "e", "do", "da", "dos", "que", "em", "um", "uma", "para", "com", "ao", "seu"},
+}
+
+// stopwordLanguages maps each stopword to the languages it is frequent in.
+var stopwordLanguages = func() map[string][]string {
+	m := make(map[string][]string)
+	for lang, words := range stopwords {
+		for _, w := range words {
+			m[w] = append(m[w], lang)
+		}
+	}
+	return m
+}()
+
+// minLanguageShare is the share of the words of a text that must be
+// stopwords of a language for the text to be considered written in it.
+const minLanguageShare = 0.05
+
+// minLanguageWords is the number of words below which the language of a
+// text isn't detected.
+const minLanguageWords = 20
+
+// VariantLanguage returns the ISO 639-1 code of the language of a corpus
+// entry, such as "de" for "EUPL-1.2_de", or the empty string for entries in
+// English.
+func VariantLanguage(variant string) string {
+	i := strings.LastIndex(variant, "_")
+	if i == -1 {
+		return ""
+	}
+	if lang := variant[i+1:]; lang != "en" && stopwords[lang] != nil {
+		return lang
+	}
+	return ""
+}
+
+// languageShares returns the share of the words of the supplied text that
+// are stopwords of each language.
+func languageShares(words []string) map[string]float64 {
+	shares := make(map[string]float64)
+	if len(words) < minLanguageWords {
+		return shares
+	}
+	for _, w := range words {
+		for _, lang := range stopwordLanguages[w] {
+			shares[lang]++
+		}
+	}
+	for lang := range shares {
+		shares[lang] /= float64(len(words))
+	}
+	return shares
+}
+
+// DetectLanguage returns the ISO 639-1 code of the language the supplied
+// text is mostly written in, among English and the languages of the
+// translations the corpus can hold, or the empty string if there is too
+// little text to tell.
+func DetectLanguage(in []byte) string {
+	var words []string
+	for _, t := range tokenize(in).Tokens {
+		words = append(words, t.Text)
+	}
+	best, share := "", minLanguageShare
+	for lang, s := range languageShares(words) {
+		if s > share || (s == share && lang < best) {
+			best, share = lang, s
+		}
+	}
+	return best
+}
+
+// languages returns the set of languages a target document is partly
+// written in, so that the translations in other languages aren't searched
+// for. It returns nil if the document is too short to tell, in which case
+// every translation is searched for.
+func (c *Classifier) languages(id *indexedDocument) map[string]bool {
+	words := make([]string, len(id.Tokens))
+	for i, t := range id.Tokens {
+		words[i] = c.dict.getWord(t.ID)
+	}
+	shares := languageShares(words)
+	if len(shares) == 0 {
+		return nil
+	}
+	present := make(map[string]bool)
+	for lang, s := range shares {
+		if s >= minLanguageShare {
+			present[lang] = true
+		}
+	}
+	return present
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// widgetGerman is a German translation of versionedLicense.
+const widgetGerman = `Die Widget Public License
+
+Hiermit wird jeder Person, die eine Kopie dieser Software erhält, unter
+Version 1.0 dieser Bedingungen die Erlaubnis erteilt, sie zu nutzen, zu
+kopieren und zu verändern, sofern dieser Hinweis in allen Kopien erhalten
+bleibt und veränderte Versionen als solche deutlich gekennzeichnet werden.
+Die Software wird ohne jede ausdrückliche oder stillschweigende Gewährleistung
+bereitgestellt. In keinem Fall haften die Autoren oder Inhaber der Urheberrechte
+für Ansprüche, Schäden oder sonstige Haftung, die sich aus ihrer Nutzung ergeben.
+`
+
+// widgetFrench is a French translation of versionedLicense.
+const widgetFrench = `La Widget Public License
+
+La permission est accordée selon la version 1.0 de ces conditions à toute
+personne obtenant une copie de ce logiciel de l'utiliser, de le copier et de le
+modifier, à condition que cet avis soit conservé dans toutes les copies et que
+les versions modifiées soient clairement signalées comme telles. Le logiciel est
+fourni sans garantie d'aucune sorte, expresse ou implicite. En aucun cas les
+auteurs ou les titulaires du droit d'auteur ne sont responsables de toute
+réclamation, de tout dommage ou de toute autre responsabilité découlant de son
+utilisation.
+`
+
+func TestVariantLanguage(t *testing.T) {
+	for variant, want := range map[string]string{
+		"EUPL-1.2_de":       "de",
+		"EUPL-1.2_fr":       "fr",
+		"EUPL-1.2":          "",
+		"EUPL-1.2_en":       "",
+		"GPL-2.0.header_a":  "",
+		"BSD-3-Clause_sun":  "",
+		"Public-Domain_nl":  "nl",
+		"libtiff_singular":  "",
+		"CC-BY-SA-4.0_it":   "it",
+		"CC-BY-SA-4.0_xx":   "",
+		"Apache-2.0.header": "",
+	} {
+		if got := VariantLanguage(variant); got != want {
+			t.Errorf("VariantLanguage(%q) = %q, want %q", variant, got, want)
+		}
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	mit, err := ioutil.ReadFile(filepath.Join(baseLicenses, "MIT.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		desc string
+		in   string
+		want string
+	}{
+		{desc: "English", in: string(mit), want: "en"},
+		{desc: "German", in: widgetGerman, want: "de"},
+		{desc: "French", in: widgetFrench, want: "fr"},
+		{desc: "too short", in: "Die Widget Public License", want: ""},
+	} {
+		if got := DetectLanguage([]byte(tt.in)); got != tt.want {
+			t.Errorf("DetectLanguage() of the %s text = %q, want %q", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestTranslationMatch(t *testing.T) {
+	c, err := New(
+		WithCorpusContent("Widget-1.0", []byte(versionedLicense)),
+		WithCorpusContent("Widget-1.0_de", []byte(widgetGerman)),
+		WithCorpusContent("Widget-1.0_fr", []byte(widgetFrench)),
+	)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	for _, tt := range []struct {
+		in       string
+		language string
+	}{
+		{in: versionedLicense, language: ""},
+		{in: widgetGerman, language: "de"},
+		{in: widgetFrench, language: "fr"},
+	} {
+		m := licenseMatches(c.Match([]byte(tt.in)))
+		if len(m) != 1 || m[0].Name != "Widget-1.0" || m[0].Language != tt.language || m[0].Confidence != 1 {
+			t.Errorf("Match() = %v, want Widget-1.0 in language %q", m, tt.language)
+		}
+	}
+
+	// Translations are only searched for in content written in their
+	// language.
+	id := c.generateIndexedDocument(tokenize([]byte(widgetGerman)), false)
+	fp := c.firstPass(id)
+	if _, ok := fp["Widget-1.0_fr"]; ok {
+		t.Error("firstPass() searches for the French translation in German text")
+	}
+	if _, ok := fp["Widget-1.0_de"]; !ok {
+		t.Error("firstPass() doesn't search for the German translation in German text")
+	}
+}
@@ -0,0 +1,508 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// LicenseInfo describes a license known to the classifier.
+type LicenseInfo struct {
+	// ID is the name of the license, which is its SPDX identifier for
+	// licenses on the SPDX license list.
+	ID string
+	// Name is the full name of the license, such as "Apache License 2.0", or
+	// the ID if it isn't known.
+	Name string
+	// Family is the license without its version or variant, such as "GPL"
+	// for GPL-2.0 and GPL-3.0-with-GCC-exception.
+	Family   string
+	Category string
+	// OSIApproved is true if the license is approved by the Open Source
+	// Initiative.
+	OSIApproved bool
+	// Deprecated is true if the ID is deprecated by the SPDX license list,
+	// and Replacement is the expression that replaces it.
+	Deprecated  bool
+	Replacement string
+	// Exception is true for license exceptions, such as
+	// Classpath-exception-2.0.
+	Exception bool
+	// Variants are the names of the corpus entries for the license, which
+	// include alternative texts and license headers.
+	Variants []string
+	// Obligations are the conditions the license imposes, or nil if they
+	// aren't known.
+	Obligations []Obligation
+	// Alias is the organization-specific alias of the license, if the
+	// database was obtained from a classifier with aliases.
+	Alias Alias
+}
+
+// LicenseDB answers queries about the licenses of a corpus. It only depends
+// on the names of the corpus entries, so it can be used without loading the
+// corpus for matching, for example to offer a choice of licenses or to
+// validate license identifiers entered by users. A LicenseDB is read-only
+// and safe for concurrent use.
+type LicenseDB struct {
+	licenses map[string]*LicenseInfo
+	ids      []string
+	// aliasIDs maps alias IDs to the IDs of the licenses they alias.
+	aliasIDs map[string]string
+}
+
+// NewLicenseDB returns a LicenseDB describing the licenses of the corpus in
+// the supplied directory.
+func NewLicenseDB(dir string) (*LicenseDB, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, corpusLoadError(err)
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".txt") {
+			names = append(names, strings.TrimSuffix(e.Name(), ".txt"))
+		}
+	}
+	return newLicenseDB(names), nil
+}
+
+// LicenseDB returns a LicenseDB describing the licenses in the corpus of the
+// classifier.
+func (c *Classifier) LicenseDB() *LicenseDB {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	db := newLicenseDB(sortedNames(c.docs))
+	for name, a := range c.aliases {
+		info, ok := db.licenses[name]
+		if !ok {
+			continue
+		}
+		info.Alias = a
+		if a.ID != "" {
+			db.aliasIDs[a.ID] = name
+		}
+	}
+	return db
+}
+
+// CorpusVersion returns an identifier of the corpus of the classifier, which
+// changes whenever an entry is added, removed or has its normalized text
+// changed. Classifiers with the same corpus version produce the same matches
+// at the same threshold.
+func (c *Classifier) CorpusVersion() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	h := sha256.New()
+	for _, name := range sortedNames(c.docs) {
+		fmt.Fprintf(h, "%s\x00%s\x00", name, c.docs[name].norm)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func newLicenseDB(entries []string) *LicenseDB {
+	db := &LicenseDB{licenses: make(map[string]*LicenseInfo), aliasIDs: make(map[string]string)}
+	sort.Strings(entries)
+	for _, e := range entries {
+		id := LicenseName(e)
+		info, ok := db.licenses[id]
+		if !ok {
+			info = &LicenseInfo{
+				ID:          id,
+				Name:        id,
+				Family:      licenseFamily(id),
+				Category:    LicenseCategory(id),
+				OSIApproved: osiApproved[id],
+				Exception:   isException(id),
+			}
+			if n, ok := licenseNames[id]; ok {
+				info.Name = n
+			}
+			info.Replacement, info.Deprecated = DeprecatedLicense(id)
+			info.Obligations, _ = Obligations(id)
+			db.licenses[id] = info
+			db.ids = append(db.ids, id)
+		}
+		info.Variants = append(info.Variants, e)
+	}
+	sort.Strings(db.ids)
+	return db
+}
+
+// licenseFamily returns the license without its version or variant: the
+// words of its name up to the first one starting with a digit.
+func licenseFamily(id string) string {
+	words := strings.Split(id, "-")
+	for i, w := range words {
+		if i > 0 && w != "" && unicode.IsDigit(rune(w[0])) {
+			return strings.Join(words[:i], "-")
+		}
+	}
+	return id
+}
+
+// info returns a copy of the information of a license, so that callers can't
+// modify the database.
+func (db *LicenseDB) info(id string) LicenseInfo {
+	i := *db.licenses[id]
+	i.Variants = append([]string(nil), i.Variants...)
+	if i.Obligations != nil {
+		i.Obligations = append([]Obligation{}, i.Obligations...)
+	}
+	return i
+}
+
+// filter returns the licenses satisfying keep, ordered by ID.
+func (db *LicenseDB) filter(keep func(i *LicenseInfo) bool) []LicenseInfo {
+	var out []LicenseInfo
+	for _, id := range db.ids {
+		if keep(db.licenses[id]) {
+			out = append(out, db.info(id))
+		}
+	}
+	return out
+}
+
+// IDs returns the IDs of the licenses in the database, in order.
+func (db *LicenseDB) IDs() []string {
+	return append([]string(nil), db.ids...)
+}
+
+// Lookup returns the license with the supplied ID or alias ID. The "-only"
+// and "-or-later" suffixes of SPDX identifiers are ignored, since the
+// classifier doesn't distinguish them.
+func (db *LicenseDB) Lookup(id string) (LicenseInfo, bool) {
+	if _, ok := db.licenses[baseID(id)]; ok {
+		return db.info(baseID(id)), true
+	}
+	if name, ok := db.aliasIDs[id]; ok {
+		return db.info(name), true
+	}
+	return LicenseInfo{}, false
+}
+
+// baseID strips the suffixes of SPDX identifiers the corpus doesn't
+// distinguish.
+func baseID(id string) string {
+	for _, suffix := range []string{"-only", "-or-later", "+"} {
+		id = strings.TrimSuffix(id, suffix)
+	}
+	return id
+}
+
+// Family returns the licenses of the supplied family, such as "GPL" or
+// "CC-BY-SA", ignoring case.
+func (db *LicenseDB) Family(family string) []LicenseInfo {
+	return db.filter(func(i *LicenseInfo) bool { return strings.EqualFold(i.Family, family) })
+}
+
+// Category returns the licenses of the supplied category, such as
+// CategoryNotice.
+func (db *LicenseDB) Category(category string) []LicenseInfo {
+	return db.filter(func(i *LicenseInfo) bool { return i.Category == category })
+}
+
+// OSIApproved returns the licenses approved by the Open Source Initiative.
+func (db *LicenseDB) OSIApproved() []LicenseInfo {
+	return db.filter(func(i *LicenseInfo) bool { return i.OSIApproved })
+}
+
+// Search returns the licenses whose ID or name contains every word of the
+// query, ignoring case. Licenses whose ID or name equals the query come
+// first, followed by the others in order of ID.
+func (db *LicenseDB) Search(query string) []LicenseInfo {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return nil
+	}
+	q := strings.Join(words, " ")
+	var exact, partial []LicenseInfo
+	for _, id := range db.ids {
+		i := db.licenses[id]
+		id, name := strings.ToLower(i.ID), strings.ToLower(i.Name)
+		if id == q || name == q {
+			exact = append(exact, db.info(i.ID))
+			continue
+		}
+		all := true
+		for _, w := range words {
+			if !strings.Contains(id, w) && !strings.Contains(name, w) {
+				all = false
+				break
+			}
+		}
+		if all {
+			partial = append(partial, db.info(i.ID))
+		}
+	}
+	return append(exact, partial...)
+}
+
+// osiApproved lists the licenses of the corpus approved by the Open Source
+// Initiative.
+var osiApproved = map[string]bool{
+	"0BSD":                          true,
+	"AFL-1.1":                       true,
+	"AFL-1.2":                       true,
+	"AFL-2.0":                       true,
+	"AFL-2.1":                       true,
+	"AFL-3.0":                       true,
+	"AGPL-3.0":                      true,
+	"APSL-1.0":                      true,
+	"APSL-1.1":                      true,
+	"APSL-1.2":                      true,
+	"APSL-2.0":                      true,
+	"Apache-1.1":                    true,
+	"Apache-2.0":                    true,
+	"Artistic-1.0":                  true,
+	"Artistic-1.0-cl8":              true,
+	"Artistic-1.0-Perl":             true,
+	"Artistic-2.0":                  true,
+	"BSD-2-Clause":                  true,
+	"BSD-3-Clause":                  true,
+	"BSL-1.0":                       true,
+	"CDDL-1.0":                      true,
+	"CPAL-1.0":                      true,
+	"CPL-1.0":                       true,
+	"EPL-1.0":                       true,
+	"EPL-2.0":                       true,
+	"EUPL-1.1":                      true,
+	"GPL-2.0":                       true,
+	"GPL-3.0":                       true,
+	"IPL-1.0":                       true,
+	"ISC":                           true,
+	"LGPL-2.0":                      true,
+	"LGPL-2.1":                      true,
+	"LGPL-3.0":                      true,
+	"LPL-1.0":                       true,
+	"LPL-1.02":                      true,
+	"LPPL-1.3c":                     true,
+	"MIT":                           true,
+	"MPL-1.0":                       true,
+	"MPL-1.1":                       true,
+	"MPL-2.0":                       true,
+	"MPL-2.0-no-copyleft-exception": true,
+	"MS-PL":                         true,
+	"MS-RL":                         true,
+	"NCSA":                          true,
+	"OFL-1.1":                       true,
+	"OSL-1.0":                       true,
+	"OSL-2.0":                       true,
+	"OSL-2.1":                       true,
+	"OSL-3.0":                       true,
+	"PHP-3.0":                       true,
+	"PHP-3.01":                      true,
+	"PostgreSQL":                    true,
+	"Python-2.0":                    true,
+	"QPL-1.0":                       true,
+	"SISSL":                         true,
+	"Sleepycat":                     true,
+	"UPL-1.0":                       true,
+	"Unicode-DFS-2016":              true,
+	"Unlicense":                     true,
+	"W3C":                           true,
+	"Xnet":                          true,
+	"ZPL-2.0":                       true,
+	"ZPL-2.1":                       true,
+	"Zlib":                          true,
+}
+
+// licenseNames maps the licenses of the corpus to their full names, as given
+// by the SPDX license list where the license is on it.
+var licenseNames = map[string]string{
+	"0BSD":                             "BSD Zero Clause License",
+	"AFL-1.1":                          "Academic Free License v1.1",
+	"AFL-1.2":                          "Academic Free License v1.2",
+	"AFL-2.0":                          "Academic Free License v2.0",
+	"AFL-2.1":                          "Academic Free License v2.1",
+	"AFL-3.0":                          "Academic Free License v3.0",
+	"AGPL-1.0":                         "Affero General Public License v1.0",
+	"AGPL-3.0":                         "GNU Affero General Public License v3.0",
+	"AML":                              "Apple MIT License",
+	"AMPAS":                            "Academy of Motion Picture Arts and Sciences BSD",
+	"APSL-1.0":                         "Apple Public Source License 1.0",
+	"APSL-1.1":                         "Apple Public Source License 1.1",
+	"APSL-1.2":                         "Apple Public Source License 1.2",
+	"APSL-2.0":                         "Apple Public Source License 2.0",
+	"Apache-1.0":                       "Apache License 1.0",
+	"Apache-1.1":                       "Apache License 1.1",
+	"Apache-2.0":                       "Apache License 2.0",
+	"Apache-CCLA-2.0":                  "Apache Software Foundation Corporate Contributor License Agreement 2.0",
+	"Apache-ICLA-2.0":                  "Apache Software Foundation Individual Contributor License Agreement 2.0",
+	"Artistic-1.0":                     "Artistic License 1.0",
+	"Artistic-1.0-Perl":                "Artistic License 1.0 (Perl)",
+	"Artistic-1.0-cl8":                 "Artistic License 1.0 w/clause 8",
+	"Artistic-2.0":                     "Artistic License 2.0",
+	"Autoconf-exception-2.0":           "Autoconf exception 2.0",
+	"BCL":                              "Binary Code License for Java SE",
+	"BSD-2-Clause":                     "BSD 2-Clause \"Simplified\" License",
+	"BSD-2-Clause-FreeBSD":             "BSD 2-Clause FreeBSD License",
+	"BSD-2-Clause-NetBSD":              "BSD 2-Clause NetBSD License",
+	"BSD-3-Clause":                     "BSD 3-Clause \"New\" or \"Revised\" License",
+	"BSD-3-Clause-Attribution":         "BSD with attribution",
+	"BSD-3-Clause-Clear":               "BSD 3-Clause Clear License",
+	"BSD-3-Clause-LBNL":                "Lawrence Berkeley National Labs BSD variant license",
+	"BSD-4-Clause":                     "BSD 4-Clause \"Original\" or \"Old\" License",
+	"BSD-4-Clause-UC":                  "BSD-4-Clause (University of California-Specific)",
+	"BSD-Protection":                   "BSD Protection License",
+	"BSL-1.0":                          "Boost Software License 1.0",
+	"BabelstoneIDS":                    "Babelstone IDS License",
+	"Beerware":                         "Beerware License",
+	"Bison-exception-2.2":              "Bison exception 2.2",
+	"BitTorrent-1.1":                   "BitTorrent Open Source License v1.1",
+	"Business-Source-License-1.1":      "Business Source License 1.1",
+	"CC-BY-1.0":                        "Creative Commons Attribution 1.0 Generic",
+	"CC-BY-2.0":                        "Creative Commons Attribution 2.0 Generic",
+	"CC-BY-2.5":                        "Creative Commons Attribution 2.5 Generic",
+	"CC-BY-3.0":                        "Creative Commons Attribution 3.0 Unported",
+	"CC-BY-4.0":                        "Creative Commons Attribution 4.0 International",
+	"CC-BY-NC-1.0":                     "Creative Commons Attribution Non Commercial 1.0 Generic",
+	"CC-BY-NC-2.0":                     "Creative Commons Attribution Non Commercial 2.0 Generic",
+	"CC-BY-NC-2.5":                     "Creative Commons Attribution Non Commercial 2.5 Generic",
+	"CC-BY-NC-3.0":                     "Creative Commons Attribution Non Commercial 3.0 Unported",
+	"CC-BY-NC-4.0":                     "Creative Commons Attribution Non Commercial 4.0 International",
+	"CC-BY-NC-ND-1.0":                  "Creative Commons Attribution Non Commercial No Derivatives 1.0 Generic",
+	"CC-BY-NC-ND-2.0":                  "Creative Commons Attribution Non Commercial No Derivatives 2.0 Generic",
+	"CC-BY-NC-ND-2.5":                  "Creative Commons Attribution Non Commercial No Derivatives 2.5 Generic",
+	"CC-BY-NC-ND-3.0":                  "Creative Commons Attribution Non Commercial No Derivatives 3.0 Unported",
+	"CC-BY-NC-ND-4.0":                  "Creative Commons Attribution Non Commercial No Derivatives 4.0 International",
+	"CC-BY-NC-SA-1.0":                  "Creative Commons Attribution Non Commercial Share Alike 1.0 Generic",
+	"CC-BY-NC-SA-2.0":                  "Creative Commons Attribution Non Commercial Share Alike 2.0 Generic",
+	"CC-BY-NC-SA-2.5":                  "Creative Commons Attribution Non Commercial Share Alike 2.5 Generic",
+	"CC-BY-NC-SA-3.0":                  "Creative Commons Attribution Non Commercial Share Alike 3.0 Unported",
+	"CC-BY-NC-SA-4.0":                  "Creative Commons Attribution Non Commercial Share Alike 4.0 International",
+	"CC-BY-ND-1.0":                     "Creative Commons Attribution No Derivatives 1.0 Generic",
+	"CC-BY-ND-2.0":                     "Creative Commons Attribution No Derivatives 2.0 Generic",
+	"CC-BY-ND-2.5":                     "Creative Commons Attribution No Derivatives 2.5 Generic",
+	"CC-BY-ND-3.0":                     "Creative Commons Attribution No Derivatives 3.0 Unported",
+	"CC-BY-ND-4.0":                     "Creative Commons Attribution No Derivatives 4.0 International",
+	"CC-BY-SA-1.0":                     "Creative Commons Attribution Share Alike 1.0 Generic",
+	"CC-BY-SA-2.0":                     "Creative Commons Attribution Share Alike 2.0 Generic",
+	"CC-BY-SA-2.5":                     "Creative Commons Attribution Share Alike 2.5 Generic",
+	"CC-BY-SA-3.0":                     "Creative Commons Attribution Share Alike 3.0 Unported",
+	"CC-BY-SA-4.0":                     "Creative Commons Attribution Share Alike 4.0 International",
+	"CC0-1.0":                          "Creative Commons Zero v1.0 Universal",
+	"CDDL-1.0":                         "Common Development and Distribution License 1.0",
+	"CDDL-1.1":                         "Common Development and Distribution License 1.1",
+	"CPAL-1.0":                         "Common Public Attribution License 1.0",
+	"CPL-1.0":                          "Common Public License 1.0",
+	"Classpath-exception-2.0":          "Classpath exception 2.0",
+	"Commons-Clause":                   "Commons Clause License Condition v1.0",
+	"DBAD":                             "Don't Be A Dick Public License",
+	"DCO-1.1":                          "Developer Certificate of Origin 1.1",
+	"EPL-1.0":                          "Eclipse Public License 1.0",
+	"EPL-2.0":                          "Eclipse Public License 2.0",
+	"EUPL-1.0":                         "European Union Public License 1.0",
+	"EUPL-1.1":                         "European Union Public License 1.1",
+	"FTL":                              "Freetype Project License",
+	"Facebook-2-Clause":                "Facebook 2-Clause License",
+	"Facebook-3-Clause":                "Facebook 3-Clause License",
+	"Facebook-Examples":                "Facebook Examples License",
+	"Font-exception-2.0":               "Font exception 2.0",
+	"FreeImage":                        "FreeImage Public License v1.0",
+	"GCC-exception-2.0":                "GCC Runtime Library exception 2.0",
+	"GPL-1.0":                          "GNU General Public License v1.0",
+	"GPL-2.0":                          "GNU General Public License v2.0",
+	"GPL-2.0-with-GCC-exception":       "GNU General Public License v2.0 w/GCC Runtime Library exception",
+	"GPL-2.0-with-autoconf-exception":  "GNU General Public License v2.0 w/Autoconf exception",
+	"GPL-2.0-with-bison-exception":     "GNU General Public License v2.0 w/Bison exception",
+	"GPL-2.0-with-classpath-exception": "GNU General Public License v2.0 w/Classpath exception",
+	"GPL-2.0-with-font-exception":      "GNU General Public License v2.0 w/Font exception",
+	"GPL-3.0":                          "GNU General Public License v3.0",
+	"GPL-3.0-with-GCC-exception":       "GNU General Public License v3.0 w/GCC Runtime Library exception",
+	"GPL-3.0-with-autoconf-exception":  "GNU General Public License v3.0 w/Autoconf exception",
+	"GPL-3.0-with-bison-exception":     "GNU General Public License v3.0 w/Bison exception",
+	"GUST-Font-License":                "GUST Font License",
+	"IPL-1.0":                          "IBM Public License v1.0",
+	"ISC":                              "ISC License",
+	"ImageMagick":                      "ImageMagick License",
+	"JSON":                             "JSON License",
+	"LGPL-2.0":                         "GNU Library General Public License v2",
+	"LGPL-2.1":                         "GNU Lesser General Public License v2.1",
+	"LGPL-3.0":                         "GNU Lesser General Public License v3.0",
+	"LGPLLR":                           "Lesser General Public License For Linguistic Resources",
+	"LLVM-exception":                   "LLVM Exception",
+	"LPL-1.0":                          "Lucent Public License Version 1.0",
+	"LPL-1.02":                         "Lucent Public License v1.02",
+	"LPPL-1.3c":                        "LaTeX Project Public License v1.3c",
+	"Libpng":                           "libpng License",
+	"Lil-1.0":                          "Lil License v1",
+	"Linux-OpenIB":                     "Linux Kernel Variant of OpenIB.org license",
+	"MIT":                              "MIT License",
+	"MPL-1.0":                          "Mozilla Public License 1.0",
+	"MPL-1.1":                          "Mozilla Public License 1.1",
+	"MPL-2.0":                          "Mozilla Public License 2.0",
+	"MPL-2.0-no-copyleft-exception":    "Mozilla Public License 2.0 (no copyleft exception)",
+	"MS-PL":                            "Microsoft Public License",
+	"MS-RL":                            "Microsoft Reciprocal License",
+	"NCBI":                             "NCBI Public Domain Notice",
+	"NCSA":                             "University of Illinois/NCSA Open Source License",
+	"NPL-1.0":                          "Netscape Public License v1.0",
+	"NPL-1.1":                          "Netscape Public License v1.1",
+	"OFL-1.1":                          "SIL Open Font License 1.1",
+	"OSL-1.0":                          "Open Software License 1.0",
+	"OSL-1.1":                          "Open Software License 1.1",
+	"OSL-2.0":                          "Open Software License 2.0",
+	"OSL-2.1":                          "Open Software License 2.1",
+	"OSL-3.0":                          "Open Software License 3.0",
+	"OpenSSL":                          "OpenSSL License",
+	"OpenVision":                       "OpenVision License",
+	"PHP-3.0":                          "PHP License v3.0",
+	"PHP-3.01":                         "PHP License v3.01",
+	"PIL":                              "Python Imaging Library License",
+	"PostgreSQL":                       "PostgreSQL License",
+	"Public-Domain":                    "Public Domain Dedication",
+	"Python-2.0":                       "Python License 2.0",
+	"Python-2.0-complete":              "Python License 2.0 (complete history)",
+	"QPL-1.0":                          "Q Public License 1.0",
+	"Ruby":                             "Ruby License",
+	"SGI-B-1.0":                        "SGI Free Software License B v1.0",
+	"SGI-B-1.1":                        "SGI Free Software License B v1.1",
+	"SGI-B-2.0":                        "SGI Free Software License B v2.0",
+	"SISSL":                            "Sun Industry Standards Source License v1.1",
+	"SISSL-1.2":                        "Sun Industry Standards Source License v1.2",
+	"Sleepycat":                        "Sleepycat License",
+	"UPL-1.0":                          "Universal Permissive License v1.0",
+	"Unicode-DFS-2015":                 "Unicode License Agreement - Data Files and Software (2015)",
+	"Unicode-DFS-2016":                 "Unicode License Agreement - Data Files and Software (2016)",
+	"Unicode-TOU":                      "Unicode Terms of Use",
+	"Unlicense":                        "The Unlicense",
+	"W3C":                              "W3C Software Notice and License (2002-12-31)",
+	"W3C-19980720":                     "W3C Software Notice and License (1998-07-20)",
+	"W3C-20150513":                     "W3C Software Notice and Document License (2015-05-13)",
+	"WTFPL":                            "Do What The F*ck You Want To Public License",
+	"X11":                              "X11 License",
+	"Xnet":                             "X.Net License",
+	"ZPL-1.1":                          "Zope Public License 1.1",
+	"ZPL-2.0":                          "Zope Public License 2.0",
+	"ZPL-2.1":                          "Zope Public License 2.1",
+	"Zend-2.0":                         "Zend License v2.0",
+	"Zlib":                             "zlib License",
+	"blessing":                         "SQLite Blessing",
+	"bzip2-1.0":                        "bzip2 and libbzip2 License",
+	"bzip2-1.0.3":                      "bzip2 and libbzip2 License v1.0.3",
+	"bzip2-1.0.4":                      "bzip2 and libbzip2 License v1.0.4",
+	"bzip2-1.0.5":                      "bzip2 and libbzip2 License v1.0.5",
+	"bzip2-1.0.6":                      "bzip2 and libbzip2 License v1.0.6",
+	"eGenix":                           "eGenix.com Public License 1.1.0",
+	"libtiff":                          "libtiff License",
+	"zlib-acknowledgement":             "zlib/libpng License with Acknowledgement",
+}
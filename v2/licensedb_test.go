@@ -0,0 +1,157 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func ids(infos []LicenseInfo) []string {
+	var out []string
+	for _, i := range infos {
+		out = append(out, i.ID)
+	}
+	return out
+}
+
+func TestLicenseDB(t *testing.T) {
+	db, err := NewLicenseDB(baseLicenses)
+	if err != nil {
+		t.Fatalf("NewLicenseDB() failed: %v", err)
+	}
+
+	gpl, ok := db.Lookup("GPL-2.0-only")
+	if !ok {
+		t.Fatal("Lookup(GPL-2.0-only) failed")
+	}
+	want := LicenseInfo{
+		ID:          "GPL-2.0",
+		Name:        "GNU General Public License v2.0",
+		Family:      "GPL",
+		Category:    CategoryRestricted,
+		OSIApproved: true,
+		Deprecated:  true,
+		Replacement: "GPL-2.0-only",
+		Obligations: []Obligation{Attribution, ShareAlike, DiscloseSource},
+		Variants: []string{
+			"GPL-2.0", "GPL-2.0.header", "GPL-2.0.header_a", "GPL-2.0.header_b",
+			"GPL-2.0.header_c", "GPL-2.0.header_d", "GPL-2.0.header_e",
+		},
+	}
+	if diff := cmp.Diff(want, gpl); diff != "" {
+		t.Errorf("Lookup(GPL-2.0-only) mismatch (-want +got):\n%s", diff)
+	}
+	// The returned information is a copy.
+	gpl.Variants[0] = "changed"
+	if again, _ := db.Lookup("GPL-2.0"); again.Variants[0] != "GPL-2.0" {
+		t.Error("modifying the result of Lookup() modified the database")
+	}
+	if _, ok := db.Lookup("Not-A-License"); ok {
+		t.Error("Lookup(Not-A-License) succeeded")
+	}
+	if e, _ := db.Lookup("Classpath-exception-2.0"); !e.Exception || e.Family != "Classpath-exception" {
+		t.Errorf("Lookup(Classpath-exception-2.0) = %+v, want an exception", e)
+	}
+
+	if got, want := ids(db.Family("gpl")), []string{
+		"GPL-1.0", "GPL-2.0", "GPL-2.0-with-GCC-exception", "GPL-2.0-with-autoconf-exception",
+		"GPL-2.0-with-bison-exception", "GPL-2.0-with-classpath-exception", "GPL-2.0-with-font-exception",
+		"GPL-3.0", "GPL-3.0-with-GCC-exception", "GPL-3.0-with-autoconf-exception", "GPL-3.0-with-bison-exception",
+	}; !cmp.Equal(got, want) {
+		t.Errorf("Family(gpl) = %v, want %v", got, want)
+	}
+	if got := ids(db.Category(CategoryReciprocal)); len(got) == 0 || got[0] != "APSL-1.0" {
+		t.Errorf("Category(reciprocal) = %v, want the reciprocal licenses", got)
+	}
+	for _, l := range db.OSIApproved() {
+		if !l.OSIApproved {
+			t.Errorf("OSIApproved() returned %s, which isn't approved", l.ID)
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "mit", want: []string{"MIT", "AML"}},
+		{query: "  Apache   License 2.0 ", want: []string{"Apache-2.0", "Apache-CCLA-2.0", "Apache-ICLA-2.0"}},
+		{query: "lesser general", want: []string{"LGPL-2.1", "LGPL-3.0", "LGPLLR"}},
+		{query: "no such license", want: nil},
+		{query: "", want: nil},
+	}
+	for _, tt := range tests {
+		if got := ids(db.Search(tt.query)); !cmp.Equal(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestClassifierLicenseDB(t *testing.T) {
+	c := NewClassifier(defaultThreshold)
+	c.AddContent("MIT", []byte("Permission is hereby granted"))
+	c.AddContent("MIT_a", []byte("Permission is granted"))
+	c.AddContent("Custom.header", []byte("Custom license header"))
+
+	db := c.LicenseDB()
+	if got, want := db.IDs(), []string{"Custom", "MIT"}; !cmp.Equal(got, want) {
+		t.Errorf("IDs() = %v, want %v", got, want)
+	}
+	if m, _ := db.Lookup("MIT"); !cmp.Equal(m.Variants, []string{"MIT", "MIT_a"}) {
+		t.Errorf("Lookup(MIT) variants = %v, want MIT and MIT_a", m.Variants)
+	}
+	if c, _ := db.Lookup("Custom"); c.Name != "Custom" || c.Category != "" {
+		t.Errorf("Lookup(Custom) = %+v, want a license without metadata", c)
+	}
+}
+
+func TestLicenseFamily(t *testing.T) {
+	tests := map[string]string{
+		"GPL-2.0":                         "GPL",
+		"GPL-3.0-with-autoconf-exception": "GPL",
+		"BSD-3-Clause":                    "BSD",
+		"CC-BY-NC-SA-4.0":                 "CC-BY-NC-SA",
+		"0BSD":                            "0BSD",
+		"bzip2-1.0.6":                     "bzip2",
+		"W3C-19980720":                    "W3C",
+		"MIT":                             "MIT",
+	}
+	for id, want := range tests {
+		if got := licenseFamily(id); got != want {
+			t.Errorf("licenseFamily(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestCorpusVersion(t *testing.T) {
+	c := NewClassifier(defaultThreshold)
+	c.AddContent("MIT", []byte("Permission is hereby granted, free of charge"))
+	v := c.CorpusVersion()
+	if len(v) != 16 {
+		t.Errorf("CorpusVersion() = %q, want 16 hex digits", v)
+	}
+
+	// The version only depends on the content of the corpus.
+	same := NewClassifier(defaultThreshold)
+	same.AddContent("MIT", []byte("PERMISSION is hereby granted,\nfree of charge"))
+	if got := same.CorpusVersion(); got != v {
+		t.Errorf("CorpusVersion() = %q for the same corpus, want %q", got, v)
+	}
+	c.AddContent("ISC", []byte("Permission to use, copy, modify"))
+	if got := c.CorpusVersion(); got == v {
+		t.Errorf("CorpusVersion() = %q after adding an entry, want a new version", got)
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// LicenseFilePriority ranks the files found by FindLicenseFiles by how likely
+// they are to hold the license terms of the files around them. Lower
+// priorities are more likely.
+type LicenseFilePriority int
+
+const (
+	// PriorityLicenseName is the priority of files named as license files,
+	// such as LICENSE, COPYING.txt, LICENSE-MIT or MIT-LICENSE.md.
+	PriorityLicenseName LicenseFilePriority = iota
+	// PriorityLicenseDir is the priority of the documents in a directory of
+	// license texts, such as LICENSES/Apache-2.0.txt in a REUSE project.
+	PriorityLicenseDir
+	// PriorityNotice is the priority of notice files, such as NOTICE,
+	// COPYRIGHT and PATENTS, and of the .license files REUSE projects use to
+	// annotate other files.
+	PriorityNotice
+	// PriorityContent is the priority of other documents whose beginning reads
+	// like license terms, such as a README with a license section.
+	PriorityContent
+)
+
+func (p LicenseFilePriority) String() string {
+	switch p {
+	case PriorityLicenseName:
+		return "license name"
+	case PriorityLicenseDir:
+		return "license directory"
+	case PriorityNotice:
+		return "notice"
+	case PriorityContent:
+		return "content"
+	}
+	return "unknown"
+}
+
+// LicenseFileCandidate is a file found by FindLicenseFiles.
+type LicenseFileCandidate struct {
+	// Path is the slash-separated path of the file in the file system.
+	Path     string
+	Priority LicenseFilePriority
+}
+
+// licenseSniffSize is the length of the beginning of a document read to
+// decide if it reads like license terms.
+const licenseSniffSize = 4096
+
+// maxSniffedFileSize is the size of the largest document whose content is
+// sniffed. Larger documents are rarely about licensing.
+const maxSniffedFileSize = 1 << 20
+
+// documentExtensions are the extensions of the documents whose names are
+// recognized without them, and whose content is sniffed.
+var documentExtensions = map[string]bool{
+	"":          true,
+	".adoc":     true,
+	".htm":      true,
+	".html":     true,
+	".markdown": true,
+	".md":       true,
+	".org":      true,
+	".rst":      true,
+	".rtf":      true,
+	".txt":      true,
+}
+
+// licenseDirs are the lower-cased names of the directories holding license
+// texts.
+var licenseDirs = map[string]bool{
+	"licence":  true,
+	"licences": true,
+	"license":  true,
+	"licenses": true,
+}
+
+// licenseWords are the lower-cased names of license and notice files, which
+// may be followed by an extension naming the license, as in LICENSE.MIT.
+var licenseWords = map[string]LicenseFilePriority{
+	"copying":   PriorityLicenseName,
+	"licence":   PriorityLicenseName,
+	"license":   PriorityLicenseName,
+	"unlicense": PriorityLicenseName,
+	"copyright": PriorityNotice,
+	"notice":    PriorityNotice,
+	"patents":   PriorityNotice,
+}
+
+// licenseContentPhrases are lower-cased phrases whose presence at the
+// beginning of a document suggests that it states license terms.
+var licenseContentPhrases = []string{
+	"licensed under",
+	"permission is hereby granted",
+	"redistribution and use in source and binary forms",
+	"this program is free software",
+	"this library is free software",
+	"spdx-license-identifier",
+	"provided \"as is\"",
+	"released under the",
+	"is licensed",
+	"# license",
+}
+
+// FindLicenseFiles returns the files of fsys likely to hold license terms,
+// most likely first: files named as license files, documents in directories
+// of license texts, notice files, and other documents, such as READMEs, whose
+// beginning reads like license terms. Files of the same priority are ordered
+// by depth, so that the license of the root of a project precedes those of
+// its parts, and then by path. Hidden directories, such as .git, are
+// skipped, as are source files, whose license headers are found by matching
+// them.
+func FindLicenseFiles(fsys fs.FS) ([]LicenseFileCandidate, error) {
+	var found []LicenseFileCandidate
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != "." && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if priority, ok := licenseFilePriority(p); ok {
+			found = append(found, LicenseFileCandidate{Path: p, Priority: priority})
+			return nil
+		}
+		if !documentExtensions[strings.ToLower(path.Ext(p))] {
+			return nil
+		}
+		ok, err := readsLikeLicense(fsys, p, d)
+		if err != nil {
+			return err
+		}
+		if ok {
+			found = append(found, LicenseFileCandidate{Path: p, Priority: PriorityContent})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(found, func(i, j int) bool {
+		a, b := found[i], found[j]
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		if da, db := strings.Count(a.Path, "/"), strings.Count(b.Path, "/"); da != db {
+			return da < db
+		}
+		return a.Path < b.Path
+	})
+	return found, nil
+}
+
+// licenseFilePriority returns the priority of the file at the
+// slash-separated path p if its name or directory marks it as a license
+// file.
+func licenseFilePriority(p string) (LicenseFilePriority, bool) {
+	base := strings.ToLower(path.Base(p))
+	if strings.HasSuffix(base, ".license") {
+		return PriorityNotice, true
+	}
+	ext := path.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	if !documentExtensions[ext] {
+		// Names such as LICENSE.MIT and COPYING.LESSER have extensions of
+		// their own, unlike source files such as licenses.go.
+		priority, ok := licenseWords[stem]
+		return priority, ok
+	}
+	for _, prefix := range []string{"licence", "license", "copying", "unlicense"} {
+		if strings.HasPrefix(stem, prefix) {
+			return PriorityLicenseName, true
+		}
+	}
+	for _, suffix := range []string{"-licence", "-license", "_licence", "_license"} {
+		if strings.HasSuffix(stem, suffix) {
+			return PriorityLicenseName, true
+		}
+	}
+	if dir := path.Dir(p); dir != "." && licenseDirs[strings.ToLower(path.Base(dir))] {
+		return PriorityLicenseDir, true
+	}
+	for _, prefix := range []string{"copyright", "notice", "patents"} {
+		if strings.HasPrefix(stem, prefix) {
+			return PriorityNotice, true
+		}
+	}
+	return 0, false
+}
+
+// readsLikeLicense reports whether the beginning of the document at p
+// contains a phrase stating license terms.
+func readsLikeLicense(fsys fs.FS, p string, d fs.DirEntry) (bool, error) {
+	info, err := d.Info()
+	if err != nil {
+		return false, err
+	}
+	if info.Size() > maxSniffedFileSize {
+		return false, nil
+	}
+	f, err := fsys.Open(p)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	b := make([]byte, licenseSniffSize)
+	n, err := io.ReadFull(f, b)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, err
+	}
+	b = bytes.ToLower(b[:n])
+	if bytes.IndexByte(b, 0) >= 0 {
+		return false, nil
+	}
+	text := strings.Join(strings.Fields(string(b)), " ")
+	for _, phrase := range licenseContentPhrases {
+		if strings.Contains(text, phrase) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
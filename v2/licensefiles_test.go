@@ -0,0 +1,73 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFindLicenseFiles(t *testing.T) {
+	file := func(s string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(s)} }
+	fsys := fstest.MapFS{
+		"LICENSE":                           file(versionedLicense),
+		"NOTICE":                            file("Widget\nCopyright 2020 Example Inc."),
+		"README.md":                         file("# Widget\n\nA widget.\n\n# License\n\nWidget is released under the Widget License."),
+		"CONTRIBUTING.md":                   file("Send pull requests."),
+		"main.go":                           file("// Licensed under the Widget License.\npackage main"),
+		"main.go.license":                   file("SPDX-License-Identifier: Widget-1.0"),
+		"LICENSES/Widget-1.0.txt":           file(versionedLicense),
+		"LICENSES/Gadget-1.0.txt":           file(gadgetLicense),
+		"docs/license/main.go":              file("package license"),
+		"docs/terms.txt":                    file("Permission is hereby granted, free of charge, to any person."),
+		"docs/image.png":                    file("\x89PNG licensed under"),
+		"third_party/lib/COPYING.LESSER":    file("GNU LESSER GENERAL PUBLIC LICENSE"),
+		"third_party/lib/MIT-LICENSE.txt":   file("The MIT License"),
+		"third_party/lib/LICENCE.md":        file("The MIT License"),
+		"third_party/lib/PATENTS":           file("Additional grant of patent rights."),
+		"third_party/lib/blob.bin":          file("licensed under\x00"),
+		".git/objects/LICENSE":              file(versionedLicense),
+		"third_party/lib/licenses.go":       file("package lib"),
+		"third_party/lib/NOTICE.apache":     file("Apache Widget"),
+		"third_party/lib/README":            file("This library is free software; you can redistribute it."),
+		"third_party/lib/docs/UNLICENSE":    file("This is free and unencumbered software."),
+		"third_party/lib/docs/copyrights.h": file("/* Copyright */"),
+	}
+	got, err := FindLicenseFiles(fsys)
+	if err != nil {
+		t.Fatalf("FindLicenseFiles() failed: %v", err)
+	}
+	want := []LicenseFileCandidate{
+		{Path: "LICENSE", Priority: PriorityLicenseName},
+		{Path: "third_party/lib/COPYING.LESSER", Priority: PriorityLicenseName},
+		{Path: "third_party/lib/LICENCE.md", Priority: PriorityLicenseName},
+		{Path: "third_party/lib/MIT-LICENSE.txt", Priority: PriorityLicenseName},
+		{Path: "third_party/lib/docs/UNLICENSE", Priority: PriorityLicenseName},
+		{Path: "LICENSES/Gadget-1.0.txt", Priority: PriorityLicenseDir},
+		{Path: "LICENSES/Widget-1.0.txt", Priority: PriorityLicenseDir},
+		{Path: "NOTICE", Priority: PriorityNotice},
+		{Path: "main.go.license", Priority: PriorityNotice},
+		{Path: "third_party/lib/NOTICE.apache", Priority: PriorityNotice},
+		{Path: "third_party/lib/PATENTS", Priority: PriorityNotice},
+		{Path: "README.md", Priority: PriorityContent},
+		{Path: "docs/terms.txt", Priority: PriorityContent},
+		{Path: "third_party/lib/README", Priority: PriorityContent},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("FindLicenseFiles() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package licenses embeds the license corpus of the classifier, so that
+// programs can classify content without locating the corpus on disk.
+//
+//	c, err := classifier.New(classifier.WithCorpusFS(licenses.FS))
+package licenses
+
+import "embed"
+
+// FS holds the license files of the corpus.
+//
+//go:embed *.txt
+var FS embed.FS
@@ -0,0 +1,157 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Phase is a phase of classification diagnostics are logged for.
+type Phase string
+
+const (
+	// PhaseSearchset finds the regions of the input sharing q-grams with a
+	// corpus entry.
+	PhaseSearchset Phase = "searchset"
+	// PhaseDiff compares a candidate region with the text of a corpus entry
+	// and applies the rules that reject unacceptable changes.
+	PhaseDiff Phase = "diff"
+	// PhaseScore computes the confidence of candidate regions and limits how
+	// many are scored.
+	PhaseScore Phase = "score"
+)
+
+// LogLevel is the importance of a diagnostic. The levels have the values of
+// the corresponding levels of log/slog, so a Logger can pass them on as
+// slog.Level(level).
+type LogLevel int
+
+const (
+	// LevelDebug diagnostics detail the intermediate results of a phase.
+	LevelDebug LogLevel = -4
+	// LevelInfo diagnostics report decisions affecting the matches, such as
+	// the rejection of a candidate by a diff rule.
+	LevelInfo LogLevel = 0
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	}
+	return fmt.Sprintf("LEVEL(%d)", int(l))
+}
+
+// Logger receives the diagnostics of a classifier. Its methods are called
+// concurrently by concurrent matches. A Logger for a *slog.Logger can be
+// written as:
+//
+//	func (l slogLogger) Enabled(p classifier.Phase, level classifier.LogLevel) bool {
+//		return l.Logger.Enabled(context.Background(), slog.Level(level))
+//	}
+//
+//	func (l slogLogger) Log(p classifier.Phase, level classifier.LogLevel, msg string, args ...interface{}) {
+//		l.Logger.Log(context.Background(), slog.Level(level), msg, append([]interface{}{"phase", p}, args...)...)
+//	}
+type Logger interface {
+	// Enabled reports whether diagnostics of the phase at the level are
+	// logged, so that the classifier only prepares those that are.
+	Enabled(phase Phase, level LogLevel) bool
+	// Log records a diagnostic. The args are alternating keys and values,
+	// as slog.Logger.Log takes them; most diagnostics start with the name
+	// of the corpus entry under the "license" key.
+	Log(phase Phase, level LogLevel, msg string, args ...interface{})
+}
+
+// SetLogger installs the logger receiving the diagnostics of the classifier.
+// A nil logger, the default, discards them.
+func (c *Classifier) SetLogger(l Logger) {
+	defer c.update()()
+	c.logger = l
+}
+
+// logging reports whether diagnostics of the phase at the level are logged.
+func (c *Classifier) logging(p Phase, level LogLevel) bool {
+	return c.logger != nil && c.logger.Enabled(p, level)
+}
+
+// log records a diagnostic, if diagnostics of the phase at the level are
+// logged. Diagnostics that are costly to prepare are guarded by logging.
+func (c *Classifier) log(p Phase, level LogLevel, msg string, args ...interface{}) {
+	if c.logging(p, level) {
+		c.logger.Log(p, level, msg, args...)
+	}
+}
+
+// textLogger writes diagnostics as lines of key=value pairs.
+type textLogger struct {
+	mu     sync.Mutex
+	w      io.Writer
+	levels map[Phase]LogLevel
+}
+
+// NewLogger returns a Logger writing the diagnostics of each phase at or
+// above the level set for it in levels as lines of key=value pairs, such as
+//
+//	level=INFO phase=diff msg="diff rule decided" license=MIT verdict="rejected by rule ..."
+//
+// Phases without a level aren't logged.
+func NewLogger(w io.Writer, levels map[Phase]LogLevel) Logger {
+	l := &textLogger{w: w, levels: make(map[Phase]LogLevel)}
+	for p, level := range levels {
+		l.levels[p] = level
+	}
+	return l
+}
+
+func (l *textLogger) Enabled(p Phase, level LogLevel) bool {
+	min, ok := l.levels[p]
+	return ok && level >= min
+}
+
+func (l *textLogger) Log(p Phase, level LogLevel, msg string, args ...interface{}) {
+	if !l.Enabled(p, level) {
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "level=%s phase=%s msg=%s", level, p, logValue(msg))
+	for i := 0; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			fmt.Fprintf(&b, " !BADKEY=%s", logValue(args[i]))
+			break
+		}
+		fmt.Fprintf(&b, " %v=%s", args[i], logValue(args[i+1]))
+	}
+	b.WriteString("\n")
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	io.WriteString(l.w, b.String())
+}
+
+// logValue formats a value of a diagnostic, quoting it if it's empty or
+// contains spaces, quotes or equal signs.
+func logValue(v interface{}) string {
+	s := fmt.Sprint(v)
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
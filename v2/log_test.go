@@ -0,0 +1,103 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+func TestNewLogger(t *testing.T) {
+	var out strings.Builder
+	c, err := New(
+		WithThreshold(.9),
+		WithCorpusContent("Widget-1.0", []byte(versionedLicense)),
+		WithLogger(NewLogger(&out, map[Phase]LogLevel{PhaseScore: LevelDebug, PhaseDiff: LevelInfo})),
+		WithDiffRule("strict", DiffRuleFunc(func(string, []diffmatchpatch.Diff) (Verdict, string) {
+			return Reject, "no variants allowed"
+		})),
+	)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	c.Match([]byte(versionedLicense))
+
+	logs := out.String()
+	for _, want := range []string{
+		"level=DEBUG phase=score msg=\"scoring candidate\" license=Widget-1.0 start=0 end=",
+		"level=INFO phase=diff msg=\"diff rule decided\" license=Widget-1.0 verdict=\"rejected by rule strict: no variants allowed\"\n",
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("logs = %s\nwant them to contain %q", logs, want)
+		}
+	}
+	// The searchset isn't logged, nor diagnostics of the diff below the
+	// level set for it.
+	for _, unwanted := range []string{"phase=searchset", "level=DEBUG phase=diff"} {
+		if strings.Contains(logs, unwanted) {
+			t.Errorf("logs = %s\nwant them not to contain %q", logs, unwanted)
+		}
+	}
+
+	c.SetLogger(nil)
+	out.Reset()
+	c.Match([]byte(versionedLicense))
+	if out.Len() != 0 {
+		t.Errorf("logs without a logger = %s, want none", out.String())
+	}
+}
+
+func TestLogValue(t *testing.T) {
+	for _, tt := range []struct {
+		in   interface{}
+		want string
+	}{
+		{in: "MIT", want: "MIT"},
+		{in: 0.5, want: "0.5"},
+		{in: "", want: `""`},
+		{in: "two words", want: `"two words"`},
+		{in: `a "quote"`, want: `"a \"quote\""`},
+		{in: "k=v", want: `"k=v"`},
+	} {
+		if got := logValue(tt.in); got != tt.want {
+			t.Errorf("logValue(%v) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTraceConfiguration(t *testing.T) {
+	var out strings.Builder
+	c := NewClassifier(.9)
+	c.AddContent("Widget-1.0", []byte(versionedLicense))
+	c.AddContent("Gadget-1.0", []byte(gadgetLicense))
+	c.SetTraceConfiguration(&TraceConfiguration{
+		TracePhases:   "score",
+		TraceLicenses: "Widget*",
+		Tracer: func(f string, args ...interface{}) {
+			fmt.Fprintf(&out, f+"\n", args...)
+		},
+	})
+	c.Match([]byte(versionedLicense + "\n" + gadgetLicense))
+	logs := out.String()
+	if !strings.Contains(logs, "scored candidate license=Widget-1.0 confidence=1") {
+		t.Errorf("traces = %s\nwant the scoring of Widget-1.0", logs)
+	}
+	if strings.Contains(logs, "Gadget") || strings.Contains(logs, "matched q-grams") {
+		t.Errorf("traces = %s\nwant only the scoring of Widget-1.0", logs)
+	}
+}
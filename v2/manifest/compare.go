@@ -0,0 +1,195 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manifest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+// DiscrepancyKind identifies the kind of a Discrepancy.
+type DiscrepancyKind string
+
+// Kinds of discrepancy between a declaration and the license files of a
+// package.
+const (
+	// Undeclared is a license found in the license files that the manifest
+	// doesn't declare.
+	Undeclared DiscrepancyKind = "undeclared"
+	// NotFound is a declared license that isn't found in the license files.
+	NotFound DiscrepancyKind = "not-found"
+	// Unrecognized is a declaration that doesn't name a known license.
+	Unrecognized DiscrepancyKind = "unrecognized"
+	// NoDeclaration is reported for a manifest that declares no license.
+	NoDeclaration DiscrepancyKind = "no-declaration"
+	// NoLicenseFile is reported for a package without license files.
+	NoLicenseFile DiscrepancyKind = "no-license-file"
+	// MissingFile is a license file the manifest refers to that doesn't
+	// exist.
+	MissingFile DiscrepancyKind = "missing-file"
+)
+
+// Discrepancy is a disagreement between the licenses declared by a manifest
+// and those found in the license files of its package.
+type Discrepancy struct {
+	Kind DiscrepancyKind
+	// License is the license or declaration concerned, if any.
+	License string
+	Message string
+}
+
+func (d *Discrepancy) String() string {
+	return fmt.Sprintf("%s (%s)", d.Message, d.Kind)
+}
+
+// LicenseFile holds the matches found in a license file of a package.
+type LicenseFile struct {
+	// Name is the name of the file, relative to the directory of the
+	// manifest.
+	Name    string
+	Matches classifier.Matches
+}
+
+// Report is the result of checking a manifest against the license files of
+// its package.
+type Report struct {
+	Declaration   *Declaration
+	Files         []*LicenseFile
+	Discrepancies []*Discrepancy
+}
+
+// Compare returns the discrepancies between the licenses declared by a
+// manifest and those found in the license files of its package. License
+// texts, headers and exceptions count as found; mere references to a
+// license by name and informal permission grants don't.
+func Compare(d *Declaration, files []*LicenseFile) []*Discrepancy {
+	var out []*Discrepancy
+	for _, v := range d.Unrecognized {
+		out = append(out, &Discrepancy{
+			Kind:    Unrecognized,
+			License: v,
+			Message: fmt.Sprintf("declared license %q isn't a known license", v),
+		})
+	}
+	if len(d.Values) == 0 {
+		out = append(out, &Discrepancy{
+			Kind:    NoDeclaration,
+			Message: fmt.Sprintf("%s doesn't declare a license", d.Manifest),
+		})
+	}
+	if len(files) == 0 {
+		return append(out, &Discrepancy{
+			Kind:    NoLicenseFile,
+			Message: "no license files were found",
+		})
+	}
+
+	found := make(map[string]string)
+	for _, f := range files {
+		for _, m := range f.Matches {
+			if m.MatchType == "Reference" || m.MatchType == "Grant" {
+				continue
+			}
+			if _, ok := found[m.Name]; !ok {
+				found[m.Name] = f.Name
+			}
+		}
+	}
+	declared := make(map[string]bool)
+	for _, l := range d.Licenses {
+		declared[l] = true
+		if _, ok := found[l]; !ok {
+			out = append(out, &Discrepancy{
+				Kind:    NotFound,
+				License: l,
+				Message: fmt.Sprintf("declared license %s isn't found in the license files", l),
+			})
+		}
+	}
+	var undeclared []string
+	for l := range found {
+		if !declared[l] {
+			undeclared = append(undeclared, l)
+		}
+	}
+	sort.Strings(undeclared)
+	for _, l := range undeclared {
+		out = append(out, &Discrepancy{
+			Kind:    Undeclared,
+			License: l,
+			Message: fmt.Sprintf("license %s found in %s isn't declared", l, found[l]),
+		})
+	}
+	return out
+}
+
+// Check reads each manifest in the supplied directory, classifies the
+// license files of the package, being the likely license files in the
+// directory and those the manifest refers to, and compares the two. Reports
+// are returned in the order of the manifest names.
+func Check(c *classifier.Classifier, dir string) ([]*Report, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var manifests, licenses []string
+	for _, e := range entries {
+		if !e.Mode().IsRegular() {
+			continue
+		}
+		if IsManifest(e.Name()) {
+			manifests = append(manifests, e.Name())
+		} else if classifier.LikelyLicenseFile(e.Name()) {
+			licenses = append(licenses, e.Name())
+		}
+	}
+
+	var reports []*Report
+	for _, name := range manifests {
+		d, err := ParseFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		r := &Report{Declaration: d}
+		seen := make(map[string]bool)
+		for _, f := range append(append([]string(nil), licenses...), d.Files...) {
+			f = filepath.ToSlash(filepath.Clean(filepath.FromSlash(f)))
+			if seen[f] {
+				continue
+			}
+			seen[f] = true
+			b, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(f)))
+			if os.IsNotExist(err) {
+				r.Discrepancies = append(r.Discrepancies, &Discrepancy{
+					Kind:    MissingFile,
+					Message: fmt.Sprintf("license file %s named by %s doesn't exist", f, name),
+				})
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			r.Files = append(r.Files, &LicenseFile{Name: f, Matches: c.Match(b)})
+		}
+		r.Discrepancies = append(r.Discrepancies, Compare(d, r.Files)...)
+		reports = append(reports, r)
+	}
+	return reports, nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manifest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+func kinds(ds []*Discrepancy) []string {
+	var out []string
+	for _, d := range ds {
+		out = append(out, string(d.Kind)+" "+d.License)
+	}
+	return out
+}
+
+func TestCompare(t *testing.T) {
+	files := []*LicenseFile{
+		{Name: "LICENSE", Matches: classifier.Matches{
+			{Name: "MIT", MatchType: "License"},
+			{Name: "Apache-2.0", MatchType: "Reference"},
+		}},
+		{Name: "COPYING", Matches: classifier.Matches{
+			{Name: "Zlib", MatchType: "License"},
+			{Name: "BSD-3-Clause", MatchType: "Header"},
+		}},
+	}
+	tests := []struct {
+		name  string
+		decl  *Declaration
+		files []*LicenseFile
+		want  []string
+	}{
+		{
+			name:  "agreement",
+			decl:  &Declaration{Values: []string{"MIT AND Zlib AND BSD-3-Clause"}, Licenses: []string{"MIT", "Zlib", "BSD-3-Clause"}},
+			files: files,
+		},
+		{
+			name:  "disagreement",
+			decl:  &Declaration{Values: []string{"Apache-2.0 OR MIT", "Acme"}, Licenses: []string{"Apache-2.0", "MIT"}, Unrecognized: []string{"Acme"}},
+			files: files,
+			want:  []string{"unrecognized Acme", "not-found Apache-2.0", "undeclared BSD-3-Clause", "undeclared Zlib"},
+		},
+		{
+			name:  "no declaration",
+			decl:  &Declaration{Manifest: "package.json"},
+			files: files[:1],
+			want:  []string{"no-declaration ", "undeclared MIT"},
+		},
+		{
+			name: "no license files",
+			decl: &Declaration{Values: []string{"MIT"}, Licenses: []string{"MIT"}},
+			want: []string{"no-license-file "},
+		},
+	}
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.want, kinds(Compare(tt.decl, tt.files))); diff != "" {
+			t.Errorf("%s: Compare() mismatch (-want +got):\n%s", tt.name, diff)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	c := classifier.NewClassifier(0.8)
+	if err := c.LoadLicenses(filepath.Join("..", "licenses")); err != nil {
+		t.Fatalf("LoadLicenses() failed: %v", err)
+	}
+	mit, err := ioutil.ReadFile(filepath.Join("..", "licenses", "MIT.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"LICENSE":      string(mit),
+		"package.json": `{"name": "pkg", "license": "MIT"}`,
+		"Cargo.toml":   "[package]\nlicense = \"Apache-2.0\"\nlicense-file = \"docs/LICENSE-APACHE\"\n",
+		"main.go":      "package main\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reports, err := Check(c, dir)
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+	if len(reports) != 2 {
+		t.Fatalf("Check() returned %d reports, want 2", len(reports))
+	}
+	cargo, npm := reports[0], reports[1]
+	if filepath.Base(npm.Declaration.Manifest) != "package.json" || len(npm.Discrepancies) != 0 {
+		t.Errorf("Check() report for %s has discrepancies %v, want none", npm.Declaration.Manifest, npm.Discrepancies)
+	}
+	want := []string{"missing-file ", "not-found Apache-2.0", "undeclared MIT"}
+	if diff := cmp.Diff(want, kinds(cargo.Discrepancies)); diff != "" {
+		t.Errorf("Check() report for Cargo.toml mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package manifest reads the licenses declared in package manifests and
+// compares them with the licenses found in the license files of a package,
+// flagging packages whose declarations and license files disagree.
+//
+// The license fields of package.json, Cargo.toml, setup.cfg, pyproject.toml,
+// pom.xml and .gemspec files are understood, as are those of the METADATA
+// and PKG-INFO files of installed Python packages. Declarations are read as
+// SPDX license expressions, falling back to recognizing common license names
+// such as "Apache License, Version 2.0", and are reported with the license
+// names used by the classifier.
+package manifest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Declaration holds the licenses declared by a manifest.
+type Declaration struct {
+	// Manifest is the name of the manifest file.
+	Manifest string
+	// Values are the license declarations as written in the manifest.
+	Values []string
+	// Licenses are the licenses named by Values, without duplicates.
+	Licenses []string
+	// Unrecognized are the values that don't name a license.
+	Unrecognized []string
+	// Files are the license files the manifest refers to, relative to its
+	// directory.
+	Files []string
+}
+
+// parsers maps the base names of manifest files to their parsers. Files with
+// the extension .gemspec are parsed by parseGemspec.
+var parsers = map[string]func(d *Declaration, data []byte) error{
+	"package.json":   parsePackageJSON,
+	"Cargo.toml":     parseCargoToml,
+	"setup.cfg":      parseSetupCfg,
+	"pyproject.toml": parsePyproject,
+	"pom.xml":        parsePOM,
+	"METADATA":       parseCoreMetadata,
+	"PKG-INFO":       parseCoreMetadata,
+}
+
+func parserFor(name string) func(d *Declaration, data []byte) error {
+	base := filepath.Base(name)
+	if strings.HasSuffix(base, ".gemspec") {
+		return parseGemspec
+	}
+	return parsers[base]
+}
+
+// IsManifest returns true if the named file is a manifest this package can
+// parse.
+func IsManifest(name string) bool {
+	return parserFor(name) != nil
+}
+
+// Parse reads the license declarations of a manifest. The format of the
+// manifest is determined by its name.
+func Parse(name string, data []byte) (*Declaration, error) {
+	parse := parserFor(name)
+	if parse == nil {
+		return nil, fmt.Errorf("%s isn't a known manifest", name)
+	}
+	d := &Declaration{Manifest: name}
+	if err := parse(d, data); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	seen := make(map[string]bool)
+	for _, v := range d.Values {
+		ids, ok := Identify(v)
+		if !ok {
+			d.Unrecognized = append(d.Unrecognized, v)
+			continue
+		}
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				d.Licenses = append(d.Licenses, id)
+			}
+		}
+	}
+	return d, nil
+}
+
+// ParseFile reads the license declarations of the named manifest file.
+func ParseFile(name string) (*Declaration, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(name, data)
+}
+
+// namePattern recognizes a license by a name other than its identifier.
+type namePattern struct {
+	re   *regexp.Regexp
+	name func(m []string) string
+}
+
+func literal(name string) func([]string) string {
+	return func([]string) string { return name }
+}
+
+// versioned returns a name function that appends the version in the last
+// submatch to the supplied prefix, normalizing it to a major.minor form.
+func versioned(prefix string) func([]string) string {
+	return func(m []string) string {
+		v := m[len(m)-1]
+		if !strings.Contains(v, ".") {
+			v += ".0"
+		}
+		return prefix + v
+	}
+}
+
+// version matches the version of a license name, as in "v2", "2.0" or
+// ", Version 2.0", with an optional "only" or "or later" qualifier.
+const version = `,?(?: -)? ?(?:v ?|version )?(\d(?:\.\d)?)(?:\+| only| or later)?`
+
+// namePatterns recognize the common names of licenses. They're matched
+// against complete declarations, with any leading "the" removed.
+var namePatterns = []namePattern{
+	{regexp.MustCompile(`(?i)^(?:mit|expat)(?: license)?$`), literal("MIT")},
+	{regexp.MustCompile(`(?i)^isc(?: license)?$`), literal("ISC")},
+	{regexp.MustCompile(`(?i)^apache(?: software)?(?: license)?` + version + `$`), versioned("Apache-")},
+	{regexp.MustCompile(`(?i)^(?:gnu )?(?:affero general public license|agpl)` + version + `$`), versioned("AGPL-")},
+	{regexp.MustCompile(`(?i)^(?:gnu )?(?:(?:lesser|library) general public license|lgpl)` + version + `$`), versioned("LGPL-")},
+	{regexp.MustCompile(`(?i)^(?:gnu )?(?:general public license|gpl)` + version + `$`), versioned("GPL-")},
+	{regexp.MustCompile(`(?i)^(?:mozilla public license|mpl)` + version + `$`), versioned("MPL-")},
+	{regexp.MustCompile(`(?i)^(?:eclipse public license|epl)` + version + `$`), versioned("EPL-")},
+	{regexp.MustCompile(`(?i)^boost software license(?:` + version + `)?$`), literal("BSL-1.0")},
+	{regexp.MustCompile(`(?i)^(?:simplified bsd|freebsd|bsd[ -](?:2|two)[ -]clause)(?: license)?$`), literal("BSD-2-Clause")},
+	{regexp.MustCompile(`(?i)^(?:new bsd|revised bsd|modified bsd|bsd[ -](?:3|three)[ -]clause)(?: license)?$`), literal("BSD-3-Clause")},
+	{regexp.MustCompile(`(?i)^unlicense$`), literal("Unlicense")},
+	{regexp.MustCompile(`(?i)^cc0(?:[ -]1\.0)?(?: universal)?$`), literal("CC0-1.0")},
+	{regexp.MustCompile(`(?i)^public domain$`), literal("Public-Domain")},
+}
+
+// expressionOperators are the keywords of an SPDX license expression.
+var expressionOperators = map[string]bool{
+	"AND":  true,
+	"OR":   true,
+	"WITH": true,
+}
+
+// identifier matches the syntax of an SPDX license identifier.
+var identifier = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.+-]*$`)
+
+// Identify returns the licenses named by a license declaration, which is
+// either an SPDX license expression such as "MIT OR Apache-2.0" or a common
+// license name such as "Apache License, Version 2.0". Identifiers are
+// reported with the names the classifier uses, which don't distinguish
+// "-only" and "-or-later" variants. It returns false if the declaration is
+// neither.
+func Identify(decl string) ([]string, bool) {
+	if ids, ok := parseExpression(decl); ok {
+		return ids, true
+	}
+	name := strings.Join(strings.Fields(decl), " ")
+	if strings.HasPrefix(strings.ToLower(name), "the ") {
+		name = name[len("the "):]
+	}
+	for _, p := range namePatterns {
+		if m := p.re.FindStringSubmatch(name); m != nil {
+			return []string{p.name(m)}, true
+		}
+	}
+	return nil, false
+}
+
+// parseExpression returns the licenses of an SPDX license expression. The
+// slash of old Cargo manifests, as in "MIT/Apache-2.0", is read as OR.
+func parseExpression(expr string) ([]string, bool) {
+	expr = strings.NewReplacer("(", " ", ")", " ", "/", " OR ").Replace(expr)
+	var ids []string
+	operand := true
+	for _, f := range strings.Fields(expr) {
+		if expressionOperators[strings.ToUpper(f)] {
+			if operand {
+				return nil, false
+			}
+			operand = true
+			continue
+		}
+		if !operand || !identifier.MatchString(f) {
+			return nil, false
+		}
+		operand = false
+		ids = append(ids, canonicalID(f))
+	}
+	if operand {
+		return nil, false
+	}
+	return ids, true
+}
+
+// canonicalID returns the name the classifier uses for an SPDX identifier.
+func canonicalID(id string) string {
+	id = strings.TrimSuffix(id, "+")
+	id = strings.TrimSuffix(id, "-only")
+	return strings.TrimSuffix(id, "-or-later")
+}
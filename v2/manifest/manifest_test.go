@@ -0,0 +1,94 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manifest
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIdentify(t *testing.T) {
+	tests := []struct {
+		decl string
+		want []string
+	}{
+		{decl: "MIT", want: []string{"MIT"}},
+		{decl: "MIT OR Apache-2.0", want: []string{"MIT", "Apache-2.0"}},
+		{decl: "(MIT or Apache-2.0) AND BSD-3-Clause", want: []string{"MIT", "Apache-2.0", "BSD-3-Clause"}},
+		{decl: "MIT/Apache-2.0", want: []string{"MIT", "Apache-2.0"}},
+		{decl: "GPL-2.0-only WITH Classpath-exception-2.0", want: []string{"GPL-2.0", "Classpath-exception-2.0"}},
+		{decl: "GPL-3.0-or-later", want: []string{"GPL-3.0"}},
+		{decl: "LGPL-2.1+", want: []string{"LGPL-2.1"}},
+		{decl: "The MIT License", want: []string{"MIT"}},
+		{decl: "Apache License, Version 2.0", want: []string{"Apache-2.0"}},
+		{decl: "The Apache Software License, Version 2.0", want: []string{"Apache-2.0"}},
+		{decl: "Apache 2", want: []string{"Apache-2.0"}},
+		{decl: "GNU General Public License v3 or later", want: []string{"GPL-3.0"}},
+		{decl: "GNU Lesser General Public License, version 2.1", want: []string{"LGPL-2.1"}},
+		{decl: "Eclipse Public License - v 1.0", want: []string{"EPL-1.0"}},
+		{decl: "Mozilla Public License 2.0", want: []string{"MPL-2.0"}},
+		{decl: "New BSD License", want: []string{"BSD-3-Clause"}},
+		{decl: "Simplified BSD", want: []string{"BSD-2-Clause"}},
+		{decl: "Public Domain", want: []string{"Public-Domain"}},
+		{decl: "Some Custom License", want: nil},
+		{decl: "MIT AND", want: nil},
+		{decl: "OR MIT", want: nil},
+		{decl: "", want: nil},
+	}
+	for _, tt := range tests {
+		got, ok := Identify(tt.decl)
+		if ok != (tt.want != nil) {
+			t.Errorf("Identify(%q) = %v, %v, want %v", tt.decl, got, ok, tt.want)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("Identify(%q) mismatch (-want +got):\n%s", tt.decl, diff)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	d, err := Parse("pkg/package.json", []byte(`{"license": "(MIT OR Apache-2.0)", "licenses": [{"type": "Apache-2.0"}, {"type": "Custom"}]}`))
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	want := &Declaration{
+		Manifest:     "pkg/package.json",
+		Values:       []string{"(MIT OR Apache-2.0)", "Apache-2.0", "Custom"},
+		Licenses:     []string{"MIT", "Apache-2.0", "Custom"},
+		Unrecognized: nil,
+	}
+	if diff := cmp.Diff(want, d); diff != "" {
+		t.Errorf("Parse() mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, name := range []string{"README.md", "package.json.bak"} {
+		if IsManifest(name) {
+			t.Errorf("IsManifest(%q) = true, want false", name)
+		}
+		if _, err := Parse(name, nil); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", name)
+		}
+	}
+	for _, name := range []string{"Cargo.toml", "dir/pom.xml", "rails.gemspec", "setup.cfg", "pyproject.toml"} {
+		if !IsManifest(name) {
+			t.Errorf("IsManifest(%q) = false, want true", name)
+		}
+	}
+	if _, err := Parse("package.json", []byte("{")); err == nil {
+		t.Error("Parse() of malformed JSON succeeded, want error")
+	}
+}
@@ -0,0 +1,599 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// parsePackageJSON reads the license of an npm package.json file. Besides
+// the license field, the deprecated license object and licenses array are
+// understood, as is "SEE LICENSE IN <file>".
+func parsePackageJSON(d *Declaration, data []byte) error {
+	var pkg struct {
+		License  json.RawMessage `json:"license"`
+		Licenses []struct {
+			Type string `json:"type"`
+		} `json:"licenses"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return err
+	}
+	if len(pkg.License) > 0 {
+		var s string
+		var obj struct {
+			Type string `json:"type"`
+		}
+		switch {
+		case json.Unmarshal(pkg.License, &s) == nil:
+		case json.Unmarshal(pkg.License, &obj) == nil:
+			s = obj.Type
+		default:
+			return fmt.Errorf("malformed license field")
+		}
+		if f := strings.TrimPrefix(s, "SEE LICENSE IN "); f != s {
+			d.Files = append(d.Files, strings.TrimSpace(f))
+		} else if s != "" {
+			d.Values = append(d.Values, s)
+		}
+	}
+	for _, l := range pkg.Licenses {
+		if l.Type != "" {
+			d.Values = append(d.Values, l.Type)
+		}
+	}
+	return nil
+}
+
+// parseCargoToml reads the license and license-file fields of the package
+// table of a Cargo.toml file.
+func parseCargoToml(d *Declaration, data []byte) error {
+	t, err := parseTOML(data)
+	if err != nil {
+		return err
+	}
+	if v, ok := t["package.license"]; ok {
+		s, err := tomlString(v)
+		if err != nil {
+			return fmt.Errorf("package.license: %v", err)
+		}
+		d.Values = append(d.Values, s)
+	}
+	if v, ok := t["package.license-file"]; ok {
+		s, err := tomlString(v)
+		if err != nil {
+			return fmt.Errorf("package.license-file: %v", err)
+		}
+		d.Files = append(d.Files, s)
+	}
+	return nil
+}
+
+// parsePyproject reads the license of a pyproject.toml file from the project
+// table, where it is either an expression or a table naming a file or
+// holding a license text, or from the tool.poetry table. If neither declares
+// a license, the license classifiers of the project are used.
+func parsePyproject(d *Declaration, data []byte) error {
+	t, err := parseTOML(data)
+	if err != nil {
+		return err
+	}
+	for _, key := range []string{"project.license", "tool.poetry.license"} {
+		v, ok := t[key]
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(v, "{") {
+			table, err := tomlInlineTable(v)
+			if err != nil {
+				return fmt.Errorf("%s: %v", key, err)
+			}
+			if f, ok := table["file"]; ok {
+				d.Files = append(d.Files, f)
+			}
+			if text, ok := table["text"]; ok {
+				d.Values = append(d.Values, text)
+			}
+			continue
+		}
+		s, err := tomlString(v)
+		if err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+		d.Values = append(d.Values, s)
+	}
+	if v, ok := t["project.license-files"]; ok {
+		files, err := tomlArray(v)
+		if err != nil {
+			return fmt.Errorf("project.license-files: %v", err)
+		}
+		d.Files = append(d.Files, files...)
+	}
+	if len(d.Values) == 0 {
+		if v, ok := t["project.classifiers"]; ok {
+			classifiers, err := tomlArray(v)
+			if err != nil {
+				return fmt.Errorf("project.classifiers: %v", err)
+			}
+			d.Values = append(d.Values, troveLicenses(classifiers)...)
+		}
+	}
+	return nil
+}
+
+// parseSetupCfg reads the license of the metadata section of a setup.cfg
+// file, falling back to its license classifiers.
+func parseSetupCfg(d *Declaration, data []byte) error {
+	cfg := parseINI(data)
+	if v := cfg["metadata.license"]; v != "" {
+		d.Values = append(d.Values, v)
+	} else {
+		d.Values = append(d.Values, troveLicenses(strings.Split(cfg["metadata.classifiers"], "\n"))...)
+	}
+	for _, key := range []string{"metadata.license_file", "metadata.license_files"} {
+		for _, f := range strings.FieldsFunc(cfg[key], func(r rune) bool { return r == '\n' || r == ',' }) {
+			if f = strings.TrimSpace(f); f != "" {
+				d.Files = append(d.Files, f)
+			}
+		}
+	}
+	return nil
+}
+
+// parseCoreMetadata reads the license of an installed Python package from
+// the METADATA file of its .dist-info directory or the PKG-INFO file of its
+// .egg-info directory. License-Expression takes precedence over License,
+// which older tools fill with the whole license text or "UNKNOWN"; neither
+// of those is a declaration. Without either, the license classifiers of the
+// package are used.
+func parseCoreMetadata(d *Declaration, data []byte) error {
+	h := parseHeaders(data)
+	if v := h["License-Expression"]; len(v) > 0 {
+		d.Values = append(d.Values, v[0])
+	} else if v := h["License"]; len(v) > 0 && v[0] != "" && v[0] != "UNKNOWN" && !strings.Contains(v[0], "\n") {
+		d.Values = append(d.Values, v[0])
+	}
+	if len(d.Values) == 0 {
+		d.Values = append(d.Values, troveLicenses(h["Classifier"])...)
+	}
+	d.Files = append(d.Files, h["License-File"]...)
+	return nil
+}
+
+// parseHeaders returns the values of the header fields of a core metadata
+// file, which are in the format of email headers, keyed by field name. Values
+// continued on indented lines are joined with newlines. The body following
+// the headers, which holds the description of the package, is ignored.
+func parseHeaders(data []byte) map[string][]string {
+	out := make(map[string][]string)
+	key := ""
+	s := bufio.NewScanner(bytes.NewReader(data))
+	s.Buffer(nil, 1<<20)
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), "\r")
+		switch {
+		case line == "":
+			return out
+		case key != "" && (line[0] == ' ' || line[0] == '\t'):
+			v := out[key]
+			v[len(v)-1] += "\n" + strings.TrimSpace(line)
+		default:
+			i := strings.Index(line, ":")
+			if i < 0 {
+				key = ""
+				continue
+			}
+			key = strings.TrimSpace(line[:i])
+			out[key] = append(out[key], strings.TrimSpace(line[i+1:]))
+		}
+	}
+	return out
+}
+
+// troveLicenses maps the license classifiers of Python packages to license
+// names. Classifiers that don't identify a single license, such as "BSD
+// License", are reported as written.
+func troveLicenses(classifiers []string) []string {
+	var out []string
+	for _, c := range classifiers {
+		c = strings.TrimSpace(c)
+		if !strings.HasPrefix(c, "License ::") {
+			continue
+		}
+		parts := strings.Split(c, " :: ")
+		name := parts[len(parts)-1]
+		if id, ok := troveNames[name]; ok {
+			out = append(out, id)
+		} else if len(parts) > 2 || name == "Public Domain" {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// troveNames maps the names used by Python license classifiers to license
+// identifiers where the name doesn't already identify a license.
+var troveNames = map[string]string{
+	"Apache Software License":                                    "Apache-2.0",
+	"GNU Affero General Public License v3":                       "AGPL-3.0",
+	"GNU Affero General Public License v3 or later (AGPLv3+)":    "AGPL-3.0",
+	"GNU General Public License v2 (GPLv2)":                      "GPL-2.0",
+	"GNU General Public License v2 or later (GPLv2+)":            "GPL-2.0",
+	"GNU General Public License v3 (GPLv3)":                      "GPL-3.0",
+	"GNU General Public License v3 or later (GPLv3+)":            "GPL-3.0",
+	"GNU Lesser General Public License v2 (LGPLv2)":              "LGPL-2.0",
+	"GNU Lesser General Public License v2 or later (LGPLv2+)":    "LGPL-2.0",
+	"GNU Lesser General Public License v3 (LGPLv3)":              "LGPL-3.0",
+	"GNU Lesser General Public License v3 or later (LGPLv3+)":    "LGPL-3.0",
+	"ISC License (ISCL)":                                         "ISC",
+	"Mozilla Public License 2.0 (MPL 2.0)":                       "MPL-2.0",
+	"Python Software Foundation License":                         "PSF-2.0",
+	"The Unlicense (Unlicense)":                                  "Unlicense",
+	"Boost Software License 1.0 (BSL-1.0)":                       "BSL-1.0",
+	"Eclipse Public License 2.0 (EPL-2.0)":                       "EPL-2.0",
+	"Universal Permissive License (UPL)":                         "UPL-1.0",
+	"zlib/libpng License":                                        "Zlib",
+	"CC0 1.0 Universal (CC0 1.0) Public Domain Dedication":       "CC0-1.0",
+	"European Union Public Licence 1.2 (EUPL 1.2)":               "EUPL-1.2",
+	"MIT No Attribution License (MIT-0)":                         "MIT-0",
+	"Historical Permission Notice and Disclaimer (HPND)":         "HPND",
+	"Common Development and Distribution License 1.0 (CDDL-1.0)": "CDDL-1.0",
+}
+
+// parsePOM reads the names of the licenses of a Maven pom.xml file. Names
+// that aren't recognized are identified by their URL where possible.
+func parsePOM(d *Declaration, data []byte) error {
+	var pom struct {
+		Licenses []struct {
+			Name string `xml:"name"`
+			URL  string `xml:"url"`
+		} `xml:"licenses>license"`
+	}
+	if err := xml.Unmarshal(data, &pom); err != nil {
+		return err
+	}
+	for _, l := range pom.Licenses {
+		name := strings.Join(strings.Fields(l.Name), " ")
+		if _, ok := Identify(name); !ok {
+			if id := licenseFromURL(l.URL); id != "" {
+				name = id
+			}
+		}
+		if name != "" {
+			d.Values = append(d.Values, name)
+		}
+	}
+	return nil
+}
+
+// licenseURLs recognize the canonical URLs of licenses.
+var licenseURLs = []namePattern{
+	{regexp.MustCompile(`(?i)\b(?:spdx|opensource)\.org/licenses/([A-Za-z0-9.+-]*[A-Za-z0-9+])`), func(m []string) string {
+		return canonicalID(strings.TrimSuffix(strings.TrimSuffix(m[1], ".html"), ".php"))
+	}},
+	{regexp.MustCompile(`(?i)\bapache\.org/licenses/LICENSE-2\.0\b`), literal("Apache-2.0")},
+	{regexp.MustCompile(`(?i)\bgnu\.org/(?:licenses|copyleft)/(?:old-licenses/)?gpl-(\d(?:\.\d)?)`), versioned("GPL-")},
+	{regexp.MustCompile(`(?i)\bgnu\.org/(?:licenses|copyleft)/(?:old-licenses/)?lgpl-(\d(?:\.\d)?)`), versioned("LGPL-")},
+	{regexp.MustCompile(`(?i)\bgnu\.org/(?:licenses|copyleft)/agpl-(\d(?:\.\d)?)`), versioned("AGPL-")},
+	{regexp.MustCompile(`(?i)\beclipse\.org/legal/epl-v(\d)(\d)\b`), func(m []string) string { return "EPL-" + m[1] + "." + m[2] }},
+	{regexp.MustCompile(`(?i)\bmozilla\.org/MPL/(\d\.\d)\b`), versioned("MPL-")},
+}
+
+// licenseFromURL returns the license identified by a license URL, or the
+// empty string if it isn't recognized.
+func licenseFromURL(url string) string {
+	for _, p := range licenseURLs {
+		if m := p.re.FindStringSubmatch(url); m != nil {
+			return p.name(m)
+		}
+	}
+	return ""
+}
+
+var (
+	// gemspecLicense matches an assignment to the license or licenses
+	// attribute of a gem specification.
+	gemspecLicense = regexp.MustCompile(`\.licenses?\s*=\s*(.+)`)
+	// gemspecString matches a quoted string or a %w array of words.
+	gemspecString = regexp.MustCompile(`"([^"]*)"|'([^']*)'|%w[\[({<]([^\])}>]*)`)
+)
+
+// parseGemspec reads the licenses assigned in a Ruby .gemspec file.
+func parseGemspec(d *Declaration, data []byte) error {
+	for _, m := range gemspecLicense.FindAllSubmatch(data, -1) {
+		for _, s := range gemspecString.FindAllSubmatch(m[1], -1) {
+			switch {
+			case s[3] != nil:
+				for _, w := range strings.Fields(string(s[3])) {
+					d.Values = append(d.Values, w)
+				}
+			case s[2] != nil:
+				d.Values = append(d.Values, string(s[2]))
+			default:
+				d.Values = append(d.Values, string(s[1]))
+			}
+		}
+	}
+	return nil
+}
+
+// parseINI returns the values of an INI file such as setup.cfg, keyed by
+// "section.key". Values continued on indented lines are joined with
+// newlines.
+func parseINI(data []byte) map[string]string {
+	out := make(map[string]string)
+	section, key := "", ""
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := s.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";"):
+			continue
+		case key != "" && (line[0] == ' ' || line[0] == '\t'):
+			if out[key] != "" {
+				out[key] += "\n"
+			}
+			out[key] += trimmed
+		case strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"):
+			section, key = strings.TrimSpace(trimmed[1:len(trimmed)-1]), ""
+		default:
+			i := strings.IndexAny(trimmed, "=:")
+			if i < 0 {
+				key = ""
+				continue
+			}
+			key = section + "." + strings.TrimSpace(trimmed[:i])
+			out[key] = strings.TrimSpace(trimmed[i+1:])
+		}
+	}
+	return out
+}
+
+// parseTOML returns the raw values of a TOML document, keyed by their table
+// and key as in "package.license". This reads only as much TOML as manifests
+// need: values spanning several lines are collected, but left unparsed.
+func parseTOML(data []byte) (map[string]string, error) {
+	out := make(map[string]string)
+	table := ""
+	lines := strings.Split(string(data), "\n")
+	for n := 0; n < len(lines); n++ {
+		line := strings.TrimSpace(stripTOMLComment(lines[n]))
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "[[") && strings.HasSuffix(line, "]]"):
+			table = strings.TrimSpace(line[2 : len(line)-2])
+			continue
+		case strings.HasPrefix(line, "["):
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: malformed table header", n+1)
+			}
+			table = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		i := strings.Index(line, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: expected key = value", n+1)
+		}
+		key := strings.Trim(strings.TrimSpace(line[:i]), `"'`)
+		value := strings.TrimSpace(line[i+1:])
+		// Multi-line strings run until their closing delimiter, and may hold
+		// anything, including "#", so they're read from the raw lines.
+		if delim := multilineDelim(value); delim != "" {
+			start := n
+			value = strings.TrimSpace(strings.TrimSpace(lines[n])[i+1:])
+			for !strings.Contains(value[3:], delim) {
+				if n+1 == len(lines) {
+					return nil, fmt.Errorf("line %d: unterminated multi-line string", start+1)
+				}
+				n++
+				value += "\n" + strings.TrimSuffix(lines[n], "\r")
+			}
+			end := 3 + strings.Index(value[3:], delim) + 3
+			// Up to two quotes may sit just inside the closing delimiter.
+			for q := 0; q < 2 && end < len(value) && value[end] == delim[0]; q++ {
+				end++
+			}
+			value = value[:end]
+		}
+		// Arrays and inline tables may continue on the following lines until
+		// their brackets balance.
+		for depth(value) > 0 && n+1 < len(lines) {
+			n++
+			value += "\n" + strings.TrimSpace(stripTOMLComment(lines[n]))
+		}
+		if table != "" {
+			key = table + "." + key
+		}
+		out[key] = value
+	}
+	return out, nil
+}
+
+// stripTOMLComment removes a comment from a line of TOML.
+func stripTOMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// depth returns the nesting of the brackets and braces left open at the
+// end of a TOML value.
+func depth(value string) int {
+	d := 0
+	var quote byte
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			d++
+		case c == ']' || c == '}':
+			d--
+		}
+	}
+	return d
+}
+
+// multilineDelim returns the delimiter of the multi-line string that value
+// starts, if any.
+func multilineDelim(value string) string {
+	for _, d := range []string{`"""`, "'''"} {
+		if strings.HasPrefix(value, d) {
+			return d
+		}
+	}
+	return ""
+}
+
+// lineEndingBackslash matches the escaped newlines of a multi-line basic
+// string, which are removed along with the whitespace that follows them.
+var lineEndingBackslash = regexp.MustCompile(`\\[ \t]*\r?\n\s*`)
+
+// tomlString returns the string of a TOML basic or literal string value,
+// including multi-line ones.
+func tomlString(v string) (string, error) {
+	if d := multilineDelim(v); d != "" && len(v) >= 6 && strings.HasSuffix(v, d) {
+		// A newline right after the opening delimiter isn't part of the
+		// string.
+		inner := v[3 : len(v)-3]
+		if strings.HasPrefix(inner, "\r\n") {
+			inner = inner[2:]
+		} else {
+			inner = strings.TrimPrefix(inner, "\n")
+		}
+		if d == "'''" {
+			return inner, nil
+		}
+		inner = lineEndingBackslash.ReplaceAllString(inner, "")
+		var b strings.Builder
+		for i := 0; i < len(inner); i++ {
+			switch c := inner[i]; c {
+			case '\\':
+				b.WriteByte(c)
+				if i+1 < len(inner) {
+					i++
+					b.WriteByte(inner[i])
+				}
+			case '"':
+				b.WriteString(`\"`)
+			case '\n':
+				b.WriteString(`\n`)
+			case '\r':
+				b.WriteString(`\r`)
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return strconv.Unquote(`"` + b.String() + `"`)
+	}
+	switch {
+	case len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'':
+		return v[1 : len(v)-1], nil
+	case len(v) >= 2 && v[0] == '"':
+		return strconv.Unquote(v)
+	}
+	return "", fmt.Errorf("expected a string, found %s", v)
+}
+
+// splitTOML splits the elements of an array or inline table at the commas
+// that aren't quoted.
+func splitTOML(v string) []string {
+	var out []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(v); i++ {
+		switch c := v[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			out = append(out, strings.TrimSpace(v[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(v[start:]); last != "" {
+		out = append(out, last)
+	}
+	return out
+}
+
+// tomlArray returns the strings of a TOML array of strings.
+func tomlArray(v string) ([]string, error) {
+	if !strings.HasPrefix(v, "[") || !strings.HasSuffix(v, "]") {
+		return nil, fmt.Errorf("expected an array, found %s", v)
+	}
+	var out []string
+	for _, e := range splitTOML(v[1 : len(v)-1]) {
+		s, err := tomlString(e)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// tomlInlineTable returns the string values of a TOML inline table.
+func tomlInlineTable(v string) (map[string]string, error) {
+	if !strings.HasPrefix(v, "{") || !strings.HasSuffix(v, "}") {
+		return nil, fmt.Errorf("expected an inline table, found %s", v)
+	}
+	out := make(map[string]string)
+	for _, e := range splitTOML(v[1 : len(v)-1]) {
+		i := strings.Index(e, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("malformed inline table %s", v)
+		}
+		s, err := tomlString(strings.TrimSpace(e[i+1:]))
+		if err != nil {
+			return nil, err
+		}
+		out[strings.Trim(strings.TrimSpace(e[:i]), `"'`)] = s
+	}
+	return out, nil
+}
@@ -0,0 +1,251 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manifest
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParsers(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		values []string
+		files  []string
+	}{
+		{
+			name:   "package.json",
+			data:   `{"name": "left-pad", "license": "WTFPL"}`,
+			values: []string{"WTFPL"},
+		},
+		{
+			name:   "package.json",
+			data:   `{"license": {"type": "MIT", "url": "https://opensource.org/licenses/MIT"}}`,
+			values: []string{"MIT"},
+		},
+		{
+			name:  "package.json",
+			data:  `{"license": "SEE LICENSE IN EULA.txt"}`,
+			files: []string{"EULA.txt"},
+		},
+		{
+			name: "Cargo.toml",
+			data: `[package]
+name = "serde" # a comment
+license = "MIT OR Apache-2.0"
+license-file = 'LICENSE-COMMERCIAL'
+
+[dependencies]
+license = "not-the-package-license"
+`,
+			values: []string{"MIT OR Apache-2.0"},
+			files:  []string{"LICENSE-COMMERCIAL"},
+		},
+		{
+			name: "pyproject.toml",
+			data: `[project]
+name = "requests"
+license = {text = "Apache 2.0", file = "LICENSE"}
+classifiers = [
+    "License :: OSI Approved :: MIT License",  # ignored, since license is set
+]
+`,
+			values: []string{"Apache 2.0"},
+			files:  []string{"LICENSE"},
+		},
+		{
+			name: "pyproject.toml",
+			data: `[project]
+license = "BSD-3-Clause"
+license-files = ["LICEN[CS]E*", "AUTHORS.md"]
+`,
+			values: []string{"BSD-3-Clause"},
+			files:  []string{"LICEN[CS]E*", "AUTHORS.md"},
+		},
+		{
+			name: "pyproject.toml",
+			data: `[project]
+classifiers = [
+    "Programming Language :: Python",
+    "License :: OSI Approved :: GNU General Public License v3 (GPLv3)",
+    "License :: OSI Approved :: BSD License",
+]
+`,
+			values: []string{"GPL-3.0", "BSD License"},
+		},
+		{
+			name: "pyproject.toml",
+			data: `[project]
+name = "example"
+description = """
+An example package.
+It isn't # a comment, and spans [several lines.
+"""
+readme = '''
+Installing = easy
+'''
+license = """Apache-2.0 OR \
+    MIT"""
+keywords = [
+    "license",
+    "example",
+]
+`,
+			values: []string{"Apache-2.0 OR MIT"},
+		},
+		{
+			name: "pyproject.toml",
+			data: `[tool.poetry]
+license = "MIT"
+`,
+			values: []string{"MIT"},
+		},
+		{
+			name: "setup.cfg",
+			data: `[metadata]
+name = attrs
+license = MIT
+license_files =
+    LICENSE
+    AUTHORS.rst
+`,
+			values: []string{"MIT"},
+			files:  []string{"LICENSE", "AUTHORS.rst"},
+		},
+		{
+			name: "setup.cfg",
+			data: `[metadata]
+classifiers =
+	License :: OSI Approved :: Apache Software License
+	Operating System :: OS Independent
+`,
+			values: []string{"Apache-2.0"},
+		},
+		{
+			name: "pom.xml",
+			data: `<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <licenses>
+    <license>
+      <name>The Apache Software License,
+        Version 2.0</name>
+      <url>https://www.apache.org/licenses/LICENSE-2.0.txt</url>
+    </license>
+    <license>
+      <name>EPL</name>
+      <url>https://www.eclipse.org/legal/epl-v20.html</url>
+    </license>
+    <license>
+      <name>Corporate License</name>
+    </license>
+  </licenses>
+</project>
+`,
+			values: []string{"The Apache Software License, Version 2.0", "EPL", "Corporate License"},
+		},
+		{
+			name:   "pom.xml",
+			data:   `<project><licenses><license><name>GNU GPL</name><url>http://www.gnu.org/licenses/gpl-3.0.html</url></license></licenses></project>`,
+			values: []string{"GPL-3.0"},
+		},
+		{
+			name: "rake.gemspec",
+			data: `Gem::Specification.new do |s|
+  s.name = "rake"
+  s.license = "MIT"
+  s.licenses = ['Apache-2.0', "BSD-2-Clause"]
+  s.licenses = %w[Ruby GPL-2.0]
+end
+`,
+			values: []string{"MIT", "Apache-2.0", "BSD-2-Clause", "Ruby", "GPL-2.0"},
+		},
+		{
+			name: "METADATA",
+			data: `Metadata-Version: 2.4
+Name: attrs
+Version: 23.2.0
+License-Expression: MIT
+License-File: LICENSE
+Classifier: License :: OSI Approved :: Apache Software License
+
+License: GPL-3.0 in the description is ignored
+`,
+			values: []string{"MIT"},
+			files:  []string{"LICENSE"},
+		},
+		{
+			name:   "PKG-INFO",
+			data:   "Metadata-Version: 1.2\r\nName: six\r\nLicense: Copyright (c) 2010 Benjamin Peterson\r\n        \r\n        Permission is hereby granted\r\nClassifier: License :: OSI Approved :: MIT License\r\n",
+			values: []string{"MIT License"},
+		},
+		{
+			name: "METADATA",
+			data: `Metadata-Version: 2.1
+Name: legacy
+License: UNKNOWN
+Classifier: Programming Language :: Python
+`,
+		},
+	}
+	for _, tt := range tests {
+		d, err := Parse(tt.name, []byte(tt.data))
+		if err != nil {
+			t.Errorf("Parse(%s) failed: %v", tt.name, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.values, d.Values); diff != "" {
+			t.Errorf("Parse(%s) values mismatch (-want +got):\n%s", tt.name, diff)
+		}
+		if diff := cmp.Diff(tt.files, d.Files); diff != "" {
+			t.Errorf("Parse(%s) files mismatch (-want +got):\n%s", tt.name, diff)
+		}
+	}
+}
+
+func TestParseTOMLMultilineStrings(t *testing.T) {
+	data := "[project]\n" +
+		"description = \"\"\"\nSays \"hi\" # twice\n  and \\tabs\"\"\"\"  # comment\n" +
+		"readme = '''\nC:\\path\n'''\n"
+	values, err := parseTOML([]byte(data))
+	if err != nil {
+		t.Fatalf("parseTOML() = %v", err)
+	}
+	want := map[string]string{
+		"project.description": "Says \"hi\" # twice\n  and \tabs\"",
+		"project.readme":      "C:\\path\n",
+	}
+	for key, w := range want {
+		got, err := tomlString(values[key])
+		if err != nil || got != w {
+			t.Errorf("tomlString(%q) = %q, %v, want %q", values[key], got, err, w)
+		}
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []string{
+		"[package\nlicense = \"MIT\"\n",
+		"[package]\nlicense\n",
+		"[package]\nlicense = MIT\n",
+		"[package]\ndescription = \"\"\"\nnever closed\n",
+	}
+	for _, tt := range tests {
+		if _, err := Parse("Cargo.toml", []byte(tt)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", tt)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Format identifies the markup language of an input document.
+type Format int
+
+const (
+	// FormatPlain treats input as plain text. This is the default.
+	FormatPlain Format = iota
+	// FormatAuto detects the markup of each input with DetectFormat.
+	FormatAuto
+	// FormatMarkdown treats input as Markdown.
+	FormatMarkdown
+	// FormatHTML treats input as HTML.
+	FormatHTML
+)
+
+var formatNames = map[Format]string{
+	FormatPlain:    "plain",
+	FormatAuto:     "auto",
+	FormatMarkdown: "markdown",
+	FormatHTML:     "html",
+}
+
+func (f Format) String() string {
+	if n, ok := formatNames[f]; ok {
+		return n
+	}
+	return "unknown"
+}
+
+// ParseFormat returns the Format with the supplied name, as returned by
+// Format.String.
+func ParseFormat(name string) (Format, bool) {
+	for f, n := range formatNames {
+		if n == name {
+			return f, true
+		}
+	}
+	return FormatPlain, false
+}
+
+// htmlIndicator matches tags that only occur in HTML documents or in source
+// comments written in HTML, such as Javadoc.
+var htmlIndicator = regexp.MustCompile(`(?i)<(?:!doctype\s+html|html|head|body|p|div|br|span|h[1-6]|ul|ol|li|pre|table|a\s)[^>]*>`)
+
+// markdownIndicator matches headings, code fences, inline links and strong
+// emphasis.
+var markdownIndicator = regexp.MustCompile("(?m)^(?:#{1,6}[ \\t]+\\S|[ \\t]*(?:```|~~~))|\\[[^\\]\\n]+\\]\\([^)\\s]+\\)|\\*\\*[^*\\n]+\\*\\*")
+
+// DetectFormat guesses the markup language of the supplied content. It
+// returns FormatHTML, FormatMarkdown or FormatPlain.
+func DetectFormat(in []byte) Format {
+	switch {
+	case htmlIndicator.Match(in):
+		return FormatHTML
+	case markdownIndicator.Match(in):
+		return FormatMarkdown
+	}
+	return FormatPlain
+}
+
+// SetInputFormat configures the markup the classifier strips from content
+// before matching it. Markup characters would otherwise be tokenized as part
+// of the neighboring words, which degrades matching of LICENSE.md files and
+// license web pages. Stripping preserves line breaks, so the line numbers of
+// matches refer to the original content.
+func (c *Classifier) SetInputFormat(f Format) {
+	defer c.update()()
+	c.format = f
+}
+
+// stripMarkup removes the markup of the classifier's configured input format
+// from the supplied content. Binary content is instead reduced to its strings,
+// if the classifier is configured to extract them.
+func (c *Classifier) stripMarkup(in []byte) []byte {
+	if c.minStrings > 0 && LooksBinary(in) {
+		return binaryText(in, c.minStrings)
+	}
+	f := c.format
+	if f == FormatAuto {
+		f = DetectFormat(in)
+	}
+	switch f {
+	case FormatHTML:
+		return []byte(stripHTML(string(in)))
+	case FormatMarkdown:
+		return []byte(stripMarkdown(string(in)))
+	}
+	return in
+}
+
+var (
+	htmlInvisible = regexp.MustCompile(`(?is)<!--.*?-->|<script[^>]*>.*?</script>|<style[^>]*>.*?</style>`)
+	htmlTag       = regexp.MustCompile(`<[!/]?[a-zA-Z][a-zA-Z0-9-]*(?:\s[^>]*)?/?>`)
+)
+
+// keepNewlines returns a replacement for s consisting of a space followed by
+// the newlines of s, so that removing s doesn't change line numbering.
+func keepNewlines(s string) string {
+	return " " + strings.Repeat("\n", strings.Count(s, "\n"))
+}
+
+// stripHTML removes tags, comments, scripts and style sheets from HTML
+// content. Entities are left for the tokenizer to unescape.
+func stripHTML(s string) string {
+	s = htmlInvisible.ReplaceAllStringFunc(s, keepNewlines)
+	return htmlTag.ReplaceAllStringFunc(s, keepNewlines)
+}
+
+// markdownLine is a rewrite applied to each line of Markdown content.
+type markdownLine struct {
+	re   *regexp.Regexp
+	repl string
+}
+
+var markdownRewrites = []markdownLine{
+	// Code fences, horizontal rules and setext heading underlines.
+	{regexp.MustCompile("^[ \\t]*(?:```|~~~).*$"), ""},
+	{regexp.MustCompile(`^[ \t]*(?:[-*_=][ \t]*){3,}$`), ""},
+	// ATX headings, block quotes and bullets.
+	{regexp.MustCompile(`^[ \t]{0,3}#{1,6}[ \t]+`), ""},
+	{regexp.MustCompile(`[ \t]+#+[ \t]*$`), ""},
+	{regexp.MustCompile(`^[ \t]*(?:>[ \t]?)+`), ""},
+	{regexp.MustCompile(`^[ \t]*[-*+][ \t]+`), ""},
+	// Images, inline links, reference links and autolinks.
+	{regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`), "$1"},
+	{regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`), "$1"},
+	{regexp.MustCompile(`\[([^\]]+)\]\[[^\]]*\]`), "$1"},
+	{regexp.MustCompile(`<((?:https?|ftp)://[^>\s]+)>`), "$1"},
+	// Emphasis and code spans.
+	{regexp.MustCompile(`\*\*([^*]+)\*\*`), "$1"},
+	{regexp.MustCompile(`\b__([^_]+)__\b`), "$1"},
+	{regexp.MustCompile(`\b_([^_]+)_\b`), "$1"},
+	{regexp.MustCompile("`+([^`]+)`+"), "$1"},
+}
+
+// stripMarkdown removes Markdown syntax from content, line by line. Inline
+// HTML is removed as well.
+func stripMarkdown(s string) string {
+	lines := strings.Split(stripHTML(s), "\n")
+	for i, l := range lines {
+		for _, r := range markdownRewrites {
+			l = r.re.ReplaceAllString(l, r.repl)
+		}
+		lines[i] = l
+	}
+	return strings.Join(lines, "\n")
+}
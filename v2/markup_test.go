@@ -0,0 +1,237 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Format
+	}{
+		{
+			name:  "plain",
+			input: "Permission is hereby granted, free of charge.\n1. Definitions.",
+			want:  FormatPlain,
+		},
+		{
+			name:  "html page",
+			input: "<!DOCTYPE html>\n<html><body>MIT License</body></html>",
+			want:  FormatHTML,
+		},
+		{
+			name:  "javadoc",
+			input: "/**\n * Licensed under the MIT license.\n * <p>\n * Copyright\n */",
+			want:  FormatHTML,
+		},
+		{
+			name:  "markdown heading",
+			input: "# MIT License\n\nPermission is hereby granted",
+			want:  FormatMarkdown,
+		},
+		{
+			name:  "markdown link",
+			input: "See [the license](https://opensource.org/licenses/MIT).",
+			want:  FormatMarkdown,
+		},
+		{
+			name:  "c preprocessor",
+			input: "#include <stdio.h>\n#define LICENSE 1",
+			want:  FormatPlain,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := DetectFormat([]byte(test.input)); got != test.want {
+				t.Errorf("DetectFormat() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range []Format{FormatPlain, FormatAuto, FormatMarkdown, FormatHTML} {
+		got, ok := ParseFormat(f.String())
+		if !ok || got != f {
+			t.Errorf("ParseFormat(%q) = %v, %v, want %v, true", f.String(), got, ok, f)
+		}
+	}
+	if _, ok := ParseFormat("pdf"); ok {
+		t.Error("ParseFormat(\"pdf\") succeeded, want failure")
+	}
+}
+
+func TestStripHTML(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{
+			input:  "<p>Permission is <b>hereby</b> granted</p>",
+			output: " Permission is  hereby  granted ",
+		},
+		{
+			input:  "<a\nhref=\"x\">link</a><!-- a\ncomment -->",
+			output: " \nlink  \n",
+		},
+		{
+			input:  "<style>p {\n}</style>text &amp; more",
+			output: " \ntext &amp; more",
+		},
+		{
+			input:  "Copyright <author@example.com>",
+			output: "Copyright <author@example.com>",
+		},
+		{
+			input:  "a < b and c > d",
+			output: "a < b and c > d",
+		},
+	}
+	for _, test := range tests {
+		if got := stripHTML(test.input); got != test.output {
+			t.Errorf("stripHTML(%q) = %q, want %q", test.input, got, test.output)
+		}
+	}
+}
+
+func TestStripMarkdown(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{
+			input:  "## The MIT License ##",
+			output: "The MIT License",
+		},
+		{
+			input:  "Title\n=====\n\n---",
+			output: "Title\n\n\n",
+		},
+		{
+			input:  "> quoted **strong** and _emphasized_ `code`",
+			output: "quoted strong and emphasized code",
+		},
+		{
+			input:  "* See [LICENSE](LICENSE.txt) or <https://opensource.org/licenses/MIT>",
+			output: "See LICENSE or https://opensource.org/licenses/MIT",
+		},
+		{
+			input:  "```\nsnake_case_name\n```",
+			output: "\nsnake_case_name\n",
+		},
+	}
+	for _, test := range tests {
+		if got := stripMarkdown(test.input); got != test.output {
+			t.Errorf("stripMarkdown(%q) = %q, want %q", test.input, got, test.output)
+		}
+	}
+}
+
+func TestMatchMarkup(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	c.SetInputFormat(FormatAuto)
+
+	tests := []struct {
+		name      string
+		input     string
+		startLine int
+		endLine   int
+	}{
+		{
+			name: "markdown",
+			input: `# The **BSD** 3-Clause License
+
+_Redistribution_ and use in source and binary forms, with or without modification,
+are permitted provided that the following conditions are met:
+
+* Redistributions of source code must retain the above copyright notice, this
+  list of conditions and the following disclaimer.
+* Redistributions in binary form must reproduce the above copyright notice,
+  this list of conditions and the following disclaimer in the documentation
+  and/or other materials provided with the distribution.
+* Neither the name of the copyright holder nor the names of its contributors
+  may be used to endorse or promote products derived from this software without
+  specific prior written permission.
+
+**THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS" AND
+ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.**
+`,
+			startLine: 3,
+			endLine:   24,
+		},
+		{
+			name: "html",
+			input: `<html><head><title>BSD License</title></head>
+<body>
+<p>Redistribution and use in source and binary forms, with or without modification,
+are permitted provided that the following conditions are met:</p>
+<ol>
+<li>Redistributions of source code must retain the above copyright notice, this
+list of conditions and the following disclaimer.</li>
+<li>Redistributions in binary form must reproduce the above copyright notice,
+this list of conditions and the following disclaimer in the documentation
+and/or other materials provided with the distribution.</li>
+<li>Neither the name of the copyright holder nor the names of its contributors
+may be used to endorse or promote products derived from this software without
+specific prior written permission.</li>
+</ol>
+<p>THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS &quot;AS IS&quot; AND
+ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE FOR
+ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.</p>
+</body></html>
+`,
+			startLine: 3,
+			endLine:   24,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := c.Match([]byte(test.input))
+			checkMatches(t, m, test.name, []string{"BSD-3-Clause"})
+			for _, l := range m {
+				if l.MatchType == referenceType {
+					continue
+				}
+				if l.Confidence != 1.0 {
+					t.Errorf("confidence = %v, want 1.0", l.Confidence)
+				}
+				if l.StartLine != test.startLine || l.EndLine != test.endLine {
+					t.Errorf("lines = %d-%d, want %d-%d", l.StartLine, l.EndLine, test.startLine, test.endLine)
+				}
+			}
+		})
+	}
+}
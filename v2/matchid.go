@@ -0,0 +1,72 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+)
+
+// assignIDs sets the IDs of matches found in the supplied document. The ID
+// of a match is a digest of its license, its match type and the normalized
+// text it covers, so it doesn't change when text is added or removed
+// elsewhere in the document and the matches of two scans of a file can be
+// paired by ID. Matches of the same text are told apart by a suffix counting
+// their occurrences in the order of their positions, such as "-2".
+func assignIDs(ms Matches, doc *document) {
+	byPosition := make(Matches, len(ms))
+	copy(byPosition, ms)
+	sort.SliceStable(byPosition, func(i, j int) bool {
+		return positionLess(byPosition[i], byPosition[j])
+	})
+	seen := make(map[string]int)
+	for _, m := range byPosition {
+		h := sha256.New()
+		fmt.Fprintf(h, "%s\x00%s\x00", m.Name, m.MatchType)
+		for i := m.StartTokenIndex; i <= m.EndTokenIndex && i < len(doc.Tokens); i++ {
+			fmt.Fprintf(h, "%s ", doc.Tokens[i].Text)
+		}
+		id := hex.EncodeToString(h.Sum(nil)[:8])
+		seen[id]++
+		if n := seen[id]; n > 1 {
+			id = fmt.Sprintf("%s-%d", id, n)
+		}
+		m.ID = id
+	}
+}
+
+// positionLess orders matches by their positions, then by license name.
+func positionLess(a, b *Match) bool {
+	if a.StartTokenIndex != b.StartTokenIndex {
+		return a.StartTokenIndex < b.StartTokenIndex
+	}
+	if a.EndTokenIndex != b.EndTokenIndex {
+		return a.EndTokenIndex > b.EndTokenIndex
+	}
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return a.MatchType < b.MatchType
+}
+
+// SortByPosition orders the matches by their positions in the content, the
+// larger of matches starting at the same token first, then by license name,
+// rather than by confidence. The order is the same for every scan of the
+// content, which makes the reports of scans easy to compare.
+func (d Matches) SortByPosition() {
+	sort.SliceStable(d, func(i, j int) bool { return positionLess(d[i], d[j]) })
+}
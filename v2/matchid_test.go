@@ -0,0 +1,80 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMatchIDs(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	mit, err := ioutil.ReadFile(filepath.Join(baseLicenses, "MIT.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := func(ms Matches) []string {
+		var out []string
+		for _, m := range ms {
+			out = append(out, m.ID)
+		}
+		return out
+	}
+
+	first := c.Match(mit)
+	if len(first) != 1 || first[0].ID == "" {
+		t.Fatalf("Match() = %v, want a single match with an ID", licenseMatches(first))
+	}
+	// The ID doesn't depend on the position of the match.
+	moved := c.Match([]byte("Preamble of the project.\n\n\n" + string(mit)))
+	if diff := cmp.Diff(ids(first), ids(moved)); diff != "" {
+		t.Errorf("IDs changed when the license moved (-want +got):\n%s", diff)
+	}
+
+	// Copies of the same text are told apart by their order.
+	twice := c.Match([]byte(string(mit) + "\n\n" + string(mit)))
+	twice.SortByPosition()
+	want := []string{first[0].ID, first[0].ID + "-2"}
+	if diff := cmp.Diff(want, ids(twice)); diff != "" {
+		t.Errorf("IDs of repeated license (-want +got):\n%s", diff)
+	}
+	if twice[0].StartLine > twice[1].StartLine {
+		t.Errorf("SortByPosition() ordered line %d before line %d", twice[0].StartLine, twice[1].StartLine)
+	}
+
+}
+
+func TestSortByPosition(t *testing.T) {
+	ms := Matches{
+		{Name: "b", Confidence: 1, StartTokenIndex: 10, EndTokenIndex: 20},
+		{Name: "a", Confidence: 0.9, StartTokenIndex: 0, EndTokenIndex: 5},
+		{Name: "a", Confidence: 0.95, StartTokenIndex: 10, EndTokenIndex: 20},
+		{Name: "c", Confidence: 1, StartTokenIndex: 10, EndTokenIndex: 30},
+	}
+	ms.SortByPosition()
+	var got []string
+	for _, m := range ms {
+		got = append(got, m.Name)
+	}
+	if diff := cmp.Diff([]string{"a", "c", "a", "b"}, got); diff != "" {
+		t.Errorf("SortByPosition() (-want +got):\n%s", diff)
+	}
+}
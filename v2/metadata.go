@@ -0,0 +1,171 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// A corpus entry has so far been described by its file name alone: the
+// language of "EUPL-1.2_de" is taken from its suffix, and its category and
+// SPDX identifier are looked up by its license name. A metadata file next to
+// the license file, named after the entry with the .json extension such as
+// "EUPL-1.2_de.json", describes the entry explicitly, so entries whose names
+// don't follow these conventions can still be reported accurately.
+
+// EntryMetadata describes a corpus entry. Fields left empty fall back to
+// those derived from the name of the entry.
+type EntryMetadata struct {
+	// Language is the ISO 639-1 code of the language of the entry, such as
+	// "de".
+	Language string `json:"language,omitempty"`
+	// SPDXID is the SPDX identifier of the license of the entry.
+	SPDXID string `json:"spdxId,omitempty"`
+	// Category is the category of the license, one of the Category
+	// constants.
+	Category string `json:"category,omitempty"`
+	// Threshold is the lowest confidence at which matches of the entry are
+	// recommended to be trusted, from 0 to 1, or 0 if the threshold of the
+	// classifier is enough.
+	Threshold float64 `json:"threshold,omitempty"`
+}
+
+// ParseEntryMetadata parses the metadata of a corpus entry, in the format of
+// the metadata files of the corpus:
+//
+//	{"language": "de", "spdxId": "EUPL-1.2", "category": "reciprocal", "threshold": 0.9}
+func ParseEntryMetadata(b []byte) (*EntryMetadata, error) {
+	dec := json.NewDecoder(strings.NewReader(string(b)))
+	dec.DisallowUnknownFields()
+	var m EntryMetadata
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("classifier couldn't parse entry metadata: %w", err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("classifier couldn't parse entry metadata: %w", err)
+	}
+	return &m, nil
+}
+
+// validate reports the first invalid field of the metadata.
+func (m *EntryMetadata) validate() error {
+	if l := m.Language; l != "" && (len(l) != 2 || strings.Trim(l, "abcdefghijklmnopqrstuvwxyz") != "") {
+		return fmt.Errorf("language %q isn't an ISO 639-1 code", l)
+	}
+	if m.Category != "" && !categories[m.Category] {
+		return fmt.Errorf("unknown license category %q", m.Category)
+	}
+	if m.Threshold < 0 || m.Threshold > 1 {
+		return fmt.Errorf("threshold %v isn't between 0 and 1", m.Threshold)
+	}
+	return nil
+}
+
+// SetEntryMetadata declares the metadata of a corpus entry, taking
+// precedence over its metadata file. The name is either the name of a single
+// corpus entry or a license name, applying to the entries of the license
+// that have no metadata of their own. Unlike anchors, metadata applies to
+// matches found after it is set, whether the entry is already in the corpus
+// or not. Nil metadata removes that declared for the name.
+func (c *Classifier) SetEntryMetadata(name string, m *EntryMetadata) error {
+	if m == nil {
+		defer c.update()()
+		delete(c.metadata, name)
+		return nil
+	}
+	if err := m.validate(); err != nil {
+		return fmt.Errorf("invalid metadata for %s: %w", name, err)
+	}
+	md := *m
+	defer c.update()()
+	c.metadata[name] = &md
+	return nil
+}
+
+// EntryMetadata returns the metadata of the named corpus entry, or nil if it
+// has none.
+func (c *Classifier) EntryMetadata(name string) *EntryMetadata {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if m := c.metadataFor(name); m != nil {
+		md := *m
+		return &md
+	}
+	return nil
+}
+
+// metadataFor returns the metadata of the named corpus entry: that declared
+// for the entry, or else that of its metadata file, or else that declared
+// for its license. The caller must hold the read lock.
+func (c *Classifier) metadataFor(name string) *EntryMetadata {
+	if m, ok := c.metadata[name]; ok {
+		return m
+	}
+	if d, ok := c.docs[name]; ok && d.meta != nil {
+		return d.meta
+	}
+	return c.metadata[LicenseName(name)]
+}
+
+// applyMetadata sets the metadata of the matches, which are owned by the
+// caller, and the language and category it declares. The caller must hold
+// the read lock.
+func (c *Classifier) applyMetadata(ms Matches) {
+	for _, m := range ms {
+		entry := m.Variant
+		if entry == "" {
+			entry = m.Name
+		}
+		m.Metadata = nil
+		md := c.metadataFor(entry)
+		if md == nil {
+			continue
+		}
+		cp := *md
+		m.Metadata = &cp
+		if md.Language != "" {
+			m.Language = md.Language
+		}
+		if md.Category != "" {
+			m.Category = md.Category
+		}
+	}
+}
+
+// metadataFile returns the path of the metadata file of a license file.
+func metadataFile(file string) string {
+	return strings.TrimSuffix(file, ".txt") + ".json"
+}
+
+// readMetadata reads the metadata file of the license file, returning nil if
+// there is none.
+func readMetadata(file string, read func(name string) ([]byte, error)) (*EntryMetadata, error) {
+	b, err := read(metadataFile(file))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m, err := ParseEntryMetadata(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", metadataFile(file), err)
+	}
+	return m, nil
+}
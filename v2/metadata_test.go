@@ -0,0 +1,204 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseEntryMetadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    *EntryMetadata
+		wantErr bool
+	}{
+		{
+			name: "all fields",
+			in:   `{"language": "de", "spdxId": "EUPL-1.2", "category": "reciprocal", "threshold": 0.9}`,
+			want: &EntryMetadata{Language: "de", SPDXID: "EUPL-1.2", Category: CategoryReciprocal, Threshold: 0.9},
+		},
+		{
+			name: "empty",
+			in:   `{}`,
+			want: &EntryMetadata{},
+		},
+		{
+			name:    "unknown field",
+			in:      `{"lang": "de"}`,
+			wantErr: true,
+		},
+		{
+			name:    "language name",
+			in:      `{"language": "German"}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown category",
+			in:      `{"category": "copyleft"}`,
+			wantErr: true,
+		},
+		{
+			name:    "threshold out of range",
+			in:      `{"threshold": 90}`,
+			wantErr: true,
+		},
+		{
+			name:    "malformed",
+			in:      `{"language": `,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEntryMetadata([]byte(tt.in))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseEntryMetadata() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseEntryMetadata() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// metadataCorpus returns a classifier of MIT, with a metadata file, and ISC,
+// without one.
+func metadataCorpus(t *testing.T) *Classifier {
+	t.Helper()
+	fsys := fstest.MapFS{
+		"MIT.json": {Data: []byte(`{"language": "fr", "spdxId": "X-MIT", "category": "notice", "threshold": 0.95}`)},
+	}
+	for _, name := range []string{"MIT.txt", "ISC.txt"} {
+		b, err := ioutil.ReadFile(filepath.Join(baseLicenses, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fsys[name] = &fstest.MapFile{Data: b}
+	}
+	c := NewClassifier(defaultThreshold)
+	if err := c.LoadLicensesFS(fsys); err != nil {
+		t.Fatalf("LoadLicensesFS() failed: %v", err)
+	}
+	return c
+}
+
+// matchOf returns the match of the named license in the license file of the
+// test corpus.
+func matchOf(t *testing.T, c *Classifier, name string) *Match {
+	t.Helper()
+	b, err := ioutil.ReadFile(filepath.Join(baseLicenses, name+".txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range c.Match(b) {
+		if m.Name == name {
+			return m
+		}
+	}
+	t.Fatalf("Match() found no match of %s", name)
+	return nil
+}
+
+func TestEntryMetadata(t *testing.T) {
+	c := metadataCorpus(t)
+	want := &EntryMetadata{Language: "fr", SPDXID: "X-MIT", Category: CategoryNotice, Threshold: 0.95}
+	if diff := cmp.Diff(want, c.EntryMetadata("MIT")); diff != "" {
+		t.Errorf("EntryMetadata(MIT) mismatch (-want +got):\n%s", diff)
+	}
+	m := matchOf(t, c, "MIT")
+	if diff := cmp.Diff(want, m.Metadata); diff != "" {
+		t.Errorf("Metadata mismatch (-want +got):\n%s", diff)
+	}
+	if m.Language != "fr" || m.Category != CategoryNotice || m.SPDXID() != "X-MIT" {
+		t.Errorf("Match() = language %q, category %q, SPDX ID %q, want fr, notice, X-MIT", m.Language, m.Category, m.SPDXID())
+	}
+
+	m = matchOf(t, c, "ISC")
+	if m.Metadata != nil || m.SPDXID() != "ISC" {
+		t.Errorf("Match() of ISC = metadata %+v, SPDX ID %q, want none and ISC", m.Metadata, m.SPDXID())
+	}
+
+	if err := c.SetEntryMetadata("ISC", &EntryMetadata{Threshold: 2}); err == nil {
+		t.Error("SetEntryMetadata() with a threshold of 2 succeeded, want error")
+	}
+	if err := c.SetEntryMetadata("ISC", &EntryMetadata{Category: CategoryForbidden}); err != nil {
+		t.Fatalf("SetEntryMetadata() failed: %v", err)
+	}
+	if m := matchOf(t, c, "ISC"); m.Category != CategoryForbidden {
+		t.Errorf("Match() of ISC has category %q, want %q", m.Category, CategoryForbidden)
+	}
+	if err := c.SetEntryMetadata("MIT", &EntryMetadata{SPDXID: "Y-MIT"}); err != nil {
+		t.Fatalf("SetEntryMetadata() failed: %v", err)
+	}
+	if m := matchOf(t, c, "MIT"); m.SPDXID() != "Y-MIT" || m.Language != "" {
+		t.Errorf("Match() of MIT = SPDX ID %q, language %q, want Y-MIT and none", m.SPDXID(), m.Language)
+	}
+	c.SetEntryMetadata("MIT", nil)
+	if m := matchOf(t, c, "MIT"); m.SPDXID() != "X-MIT" {
+		t.Errorf("Match() of MIT has SPDX ID %q after removing its metadata, want X-MIT", m.SPDXID())
+	}
+}
+
+func TestEntryMetadataFile(t *testing.T) {
+	b, err := ioutil.ReadFile(filepath.Join(baseLicenses, "MIT.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"MIT.txt":  {Data: b},
+		"MIT.json": {Data: []byte(`{"category": "copyleft"}`)},
+	}
+	if err := NewClassifier(defaultThreshold).LoadLicensesFS(fsys); err == nil {
+		t.Error("LoadLicensesFS() with an unknown category succeeded, want error")
+	}
+}
+
+func TestEntryMetadataIndex(t *testing.T) {
+	c := metadataCorpus(t)
+	var buf bytes.Buffer
+	if err := c.WriteIndex(&buf); err != nil {
+		t.Fatalf("WriteIndex() failed: %v", err)
+	}
+	read := NewClassifier(defaultThreshold)
+	if err := read.ReadIndex(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("ReadIndex() failed: %v", err)
+	}
+	if diff := cmp.Diff(c.EntryMetadata("MIT"), read.EntryMetadata("MIT")); diff != "" {
+		t.Errorf("EntryMetadata(MIT) mismatch (-want +got):\n%s", diff)
+	}
+
+	// An index of version 1, which has no metadata, is still loaded.
+	plain, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	buf.Reset()
+	if err := plain.WriteIndex(&buf); err != nil {
+		t.Fatalf("WriteIndex() failed: %v", err)
+	}
+	b := buf.Bytes()
+	binary.LittleEndian.PutUint32(b[len(indexMagic):], 1)
+	if err := NewClassifier(defaultThreshold).ReadIndex(bytes.NewReader(b)); err != nil {
+		t.Errorf("ReadIndex() of a version 1 index failed: %v", err)
+	}
+}
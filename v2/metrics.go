@@ -0,0 +1,122 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import "time"
+
+// CacheResult describes how the cache served the classification of a
+// document.
+type CacheResult int
+
+const (
+	// CacheNone means no cache is installed.
+	CacheNone CacheResult = iota
+	// CacheHit means the matches were found in the cache.
+	CacheHit
+	// CacheMiss means the matches weren't in the cache, so the document was
+	// classified and its matches added to it.
+	CacheMiss
+)
+
+func (r CacheResult) String() string {
+	switch r {
+	case CacheHit:
+		return "hit"
+	case CacheMiss:
+		return "miss"
+	}
+	return "none"
+}
+
+// DocumentMetrics describes the work of classifying a document. The times of
+// the phases are zero for documents served from the cache.
+type DocumentMetrics struct {
+	// Tokens is the number of tokens of the document, or zero if it was
+	// served from the cache.
+	Tokens int
+	// Tokenize is the time spent tokenizing the document, which is zero for
+	// documents tokenized by Tokenize before being matched.
+	Tokenize time.Duration
+	// Searchset is the time spent selecting the corpus entries to search for
+	// and finding the regions of the document resembling them.
+	Searchset time.Duration
+	// Diff is the time spent diffing the regions with the corpus entries, and
+	// Score the time spent scoring the diffs.
+	Diff, Score time.Duration
+	// Total is the time spent classifying the document, from start to end.
+	Total time.Duration
+	// Entries is the number of corpus entries searched for in the document,
+	// and Candidates the number of regions of the document scored against
+	// them.
+	Entries, Candidates int
+	// Approximate is the number of candidates whose confidence was estimated
+	// because the time budget of the document ran out. See SetTimeBudget.
+	Approximate int
+	// Matches is the number of matches reported.
+	Matches int
+	// Cache is how the cache served the document.
+	Cache CacheResult
+}
+
+// Metrics receives the metrics of each document the classifier matches,
+// from Match, MatchTokenized and the functions built on them.
+// Implementations must be safe for concurrent use, and must not change the
+// configuration of the classifier, which is locked while they are called.
+type Metrics interface {
+	ObserveDocument(m *DocumentMetrics)
+}
+
+// MetricsFunc adapts a function to the Metrics interface.
+type MetricsFunc func(m *DocumentMetrics)
+
+// ObserveDocument calls f(m).
+func (f MetricsFunc) ObserveDocument(m *DocumentMetrics) {
+	f(m)
+}
+
+// SetMetrics installs the receiver of the metrics of the documents the
+// classifier matches. A nil receiver disables the collection of metrics.
+func (c *Classifier) SetMetrics(m Metrics) {
+	defer c.update()()
+	c.metrics = m
+}
+
+// now returns the current time, to time a phase from, if metrics are
+// collected.
+func (m *DocumentMetrics) now() time.Time {
+	if m == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// record adds the time elapsed since start to that of the phase, returning
+// the current time to time the next phase from. It does nothing if metrics
+// aren't collected.
+func (m *DocumentMetrics) record(p Phase, start time.Time) time.Time {
+	if m == nil {
+		return start
+	}
+	now := time.Now()
+	switch p {
+	case PhaseSearchset:
+		m.Searchset += now.Sub(start)
+	case PhaseDiff:
+		m.Diff += now.Sub(start)
+	case PhaseScore:
+		m.Score += now.Sub(start)
+	}
+	return now
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	var got []DocumentMetrics
+	c, err := New(
+		WithThreshold(.9),
+		WithCorpusContent("Widget-1.0", []byte(versionedLicense)),
+		WithCorpusContent("Gadget-1.0", []byte(gadgetLicense)),
+		WithCache(NewLRUCache(4)),
+		WithMetrics(MetricsFunc(func(m *DocumentMetrics) { got = append(got, *m) })),
+	)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	in := []byte(versionedLicense)
+	c.Match(in)
+	c.Match(in)
+	c.MatchTokenized(Tokenize([]byte("Nothing to see here.")))
+	if len(got) != 3 {
+		t.Fatalf("observed %d documents, want 3", len(got))
+	}
+
+	miss := got[0]
+	if miss.Cache != CacheMiss || miss.Matches != 1 || miss.Entries != 1 || miss.Candidates == 0 {
+		t.Errorf("metrics of a new document = %+v, want a cache miss with 1 match of 1 entry searched", miss)
+	}
+	if miss.Tokens == 0 || miss.Tokenize <= 0 || miss.Searchset <= 0 || miss.Diff <= 0 || miss.Score <= 0 {
+		t.Errorf("metrics of a new document = %+v, want tokens and the time of each phase", miss)
+	}
+	if sum := miss.Tokenize + miss.Searchset + miss.Diff + miss.Score; miss.Total < sum {
+		t.Errorf("total time %v less than that of the phases %v", miss.Total, sum)
+	}
+
+	if hit := got[1]; hit != (DocumentMetrics{Cache: CacheHit, Matches: 1, Total: hit.Total}) {
+		t.Errorf("metrics of a cached document = %+v, want a cache hit with 1 match and no work", hit)
+	}
+
+	if pre := got[2]; pre.Cache != CacheMiss || pre.Tokenize != 0 || pre.Tokens != 4 || pre.Entries != 0 || pre.Matches != 0 {
+		t.Errorf("metrics of a tokenized document = %+v, want a cache miss with 4 tokens and nothing searched", pre)
+	}
+
+	c.SetMetrics(nil)
+	c.Match(in)
+	if len(got) != 3 {
+		t.Errorf("observed %d documents after removing the metrics, want 3", len(got))
+	}
+}
+
+func TestMetricsWindows(t *testing.T) {
+	var got DocumentMetrics
+	c, err := New(
+		WithThreshold(.9),
+		WithCorpusContent("Widget-1.0", []byte(versionedLicense)),
+		WithMaxTokens(200),
+		WithMetrics(MetricsFunc(func(m *DocumentMetrics) { got = *m })),
+	)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	c.Match([]byte(strings.Repeat(versionedLicense+"\n", 3)))
+	if got.Cache != CacheNone || got.Matches != 3 || got.Entries != 2 || got.Diff <= 0 {
+		t.Errorf("metrics of a windowed document = %+v, want the work of all the windows without a cache", got)
+	}
+}
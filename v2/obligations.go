@@ -0,0 +1,247 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import "fmt"
+
+// Obligation is a condition a license imposes on those using or distributing
+// the licensed work.
+type Obligation int
+
+// Obligations imposed by licenses.
+const (
+	// Attribution requires the copyright and license notices to be kept with
+	// copies of the work.
+	Attribution Obligation = iota
+	// ShareAlike requires modified versions of the work to be distributed
+	// under the same license.
+	ShareAlike
+	// DiscloseSource requires the source of the work to be made available
+	// when it is distributed.
+	DiscloseSource
+	// PatentGrant is an express license to the patents of the contributors,
+	// which usually ends if the licensee brings patent claims over the work.
+	PatentGrant
+	// NetworkCopyleft extends the source obligations to users interacting
+	// with the work over a network.
+	NetworkCopyleft
+)
+
+var obligationNames = []string{
+	Attribution:     "attribution",
+	ShareAlike:      "share-alike",
+	DiscloseSource:  "disclose-source",
+	PatentGrant:     "patent-grant",
+	NetworkCopyleft: "network-copyleft",
+}
+
+// MarshalText encodes the obligation as its name.
+func (o Obligation) MarshalText() ([]byte, error) {
+	return []byte(o.String()), nil
+}
+
+// UnmarshalText decodes an obligation from its name.
+func (o *Obligation) UnmarshalText(text []byte) error {
+	for i, n := range obligationNames {
+		if n == string(text) {
+			*o = Obligation(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown obligation %q", text)
+}
+
+func (o Obligation) String() string {
+	if o >= 0 && int(o) < len(obligationNames) {
+		return obligationNames[o]
+	}
+	return "unknown"
+}
+
+// Obligations returns the obligations imposed by the license with the
+// supplied ID, in the order they're declared, and false if the obligations
+// of the license aren't known. The "-only" and "-or-later" suffixes of SPDX
+// identifiers are ignored.
+func Obligations(licenseID string) ([]Obligation, bool) {
+	o, ok := licenseObligations[baseID(licenseID)]
+	if !ok {
+		return nil, false
+	}
+	return append([]Obligation{}, o...), true
+}
+
+// licenseObligations maps licenses to their obligations. GPL variants that
+// include an exception share the obligations of the GPL; the exceptions
+// relax how far the obligations reach rather than which apply.
+var licenseObligations = func() map[string][]Obligation {
+	// Common combinations of obligations.
+	none := []Obligation{}
+	notice := []Obligation{Attribution}
+	noticePatent := []Obligation{Attribution, PatentGrant}
+	shareAlike := []Obligation{Attribution, ShareAlike}
+	copyleft := []Obligation{Attribution, ShareAlike, DiscloseSource}
+	copyleftPatent := []Obligation{Attribution, ShareAlike, DiscloseSource, PatentGrant}
+	networkCopyleft := []Obligation{Attribution, ShareAlike, DiscloseSource, NetworkCopyleft}
+	networkPatent := []Obligation{Attribution, ShareAlike, DiscloseSource, PatentGrant, NetworkCopyleft}
+	discloseNotice := []Obligation{Attribution, DiscloseSource}
+	return map[string][]Obligation{
+		"0BSD":          none,
+		"CC0-1.0":       none,
+		"Public-Domain": none,
+		"Unlicense":     none,
+		"blessing":      none,
+		"WTFPL":         none,
+
+		"AFL-1.1":                  noticePatent,
+		"AFL-1.2":                  noticePatent,
+		"AFL-2.0":                  noticePatent,
+		"AFL-2.1":                  noticePatent,
+		"AFL-3.0":                  noticePatent,
+		"Apache-1.0":               notice,
+		"Apache-1.1":               notice,
+		"Apache-2.0":               noticePatent,
+		"Artistic-1.0":             notice,
+		"Artistic-1.0-Perl":        notice,
+		"Artistic-1.0-cl8":         notice,
+		"Artistic-2.0":             noticePatent,
+		"AML":                      notice,
+		"BSD-2-Clause":             notice,
+		"BSD-2-Clause-FreeBSD":     notice,
+		"BSD-2-Clause-NetBSD":      notice,
+		"BSD-3-Clause":             notice,
+		"BSD-3-Clause-Attribution": notice,
+		"BSD-3-Clause-Clear":       notice,
+		"BSD-3-Clause-LBNL":        notice,
+		"BSD-4-Clause":             notice,
+		"BSD-4-Clause-UC":          notice,
+		"BSL-1.0":                  notice,
+		"CC-BY-1.0":                notice,
+		"CC-BY-2.0":                notice,
+		"CC-BY-2.5":                notice,
+		"CC-BY-3.0":                notice,
+		"CC-BY-4.0":                notice,
+		"CC-BY-ND-1.0":             notice,
+		"CC-BY-ND-2.0":             notice,
+		"CC-BY-ND-2.5":             notice,
+		"CC-BY-ND-3.0":             notice,
+		"CC-BY-ND-4.0":             notice,
+		"CC-BY-NC-1.0":             notice,
+		"CC-BY-NC-2.0":             notice,
+		"CC-BY-NC-2.5":             notice,
+		"CC-BY-NC-3.0":             notice,
+		"CC-BY-NC-4.0":             notice,
+		"CC-BY-NC-ND-1.0":          notice,
+		"CC-BY-NC-ND-2.0":          notice,
+		"CC-BY-NC-ND-2.5":          notice,
+		"CC-BY-NC-ND-3.0":          notice,
+		"CC-BY-NC-ND-4.0":          notice,
+		"Facebook-2-Clause":        notice,
+		"Facebook-3-Clause":        notice,
+		"FTL":                      notice,
+		"ISC":                      notice,
+		"JSON":                     notice,
+		"LPPL-1.3c":                notice,
+		"Libpng":                   notice,
+		"MIT":                      notice,
+		"MS-PL":                    noticePatent,
+		"NCSA":                     notice,
+		"OpenSSL":                  notice,
+		"PHP-3.0":                  notice,
+		"PHP-3.01":                 notice,
+		"PIL":                      notice,
+		"PostgreSQL":               notice,
+		"Python-2.0":               notice,
+		"UPL-1.0":                  noticePatent,
+		"Unicode-DFS-2015":         notice,
+		"Unicode-DFS-2016":         notice,
+		"W3C":                      notice,
+		"W3C-19980720":             notice,
+		"W3C-20150513":             notice,
+		"Ruby":                     notice,
+		"SGI-B-2.0":                notice,
+		"X11":                      notice,
+		"Xnet":                     notice,
+		"ZPL-2.0":                  notice,
+		"ZPL-2.1":                  notice,
+		"Zend-2.0":                 notice,
+		"ZPL-1.1":                  notice,
+		"Zlib":                     notice,
+		"bzip2-1.0.5":              notice,
+		"bzip2-1.0.6":              notice,
+		"eGenix":                   notice,
+		"libtiff":                  notice,
+		"zlib-acknowledgement":     notice,
+
+		"CC-BY-SA-1.0":    shareAlike,
+		"CC-BY-SA-2.0":    shareAlike,
+		"CC-BY-SA-2.5":    shareAlike,
+		"CC-BY-SA-3.0":    shareAlike,
+		"CC-BY-SA-4.0":    shareAlike,
+		"CC-BY-NC-SA-1.0": shareAlike,
+		"CC-BY-NC-SA-2.0": shareAlike,
+		"CC-BY-NC-SA-2.5": shareAlike,
+		"CC-BY-NC-SA-3.0": shareAlike,
+		"CC-BY-NC-SA-4.0": shareAlike,
+		"OFL-1.1":         shareAlike,
+
+		"Sleepycat": discloseNotice,
+		"QPL-1.0":   discloseNotice,
+
+		"GPL-1.0":                          copyleft,
+		"GPL-2.0":                          copyleft,
+		"GPL-2.0-with-GCC-exception":       copyleft,
+		"GPL-2.0-with-autoconf-exception":  copyleft,
+		"GPL-2.0-with-bison-exception":     copyleft,
+		"GPL-2.0-with-classpath-exception": copyleft,
+		"GPL-2.0-with-font-exception":      copyleft,
+		"LGPL-2.0":                         copyleft,
+		"LGPL-2.1":                         copyleft,
+
+		"CDDL-1.0":                        copyleftPatent,
+		"CDDL-1.1":                        copyleftPatent,
+		"CPL-1.0":                         copyleftPatent,
+		"EPL-1.0":                         copyleftPatent,
+		"EPL-2.0":                         copyleftPatent,
+		"GPL-3.0":                         copyleftPatent,
+		"GPL-3.0-with-GCC-exception":      copyleftPatent,
+		"GPL-3.0-with-autoconf-exception": copyleftPatent,
+		"GPL-3.0-with-bison-exception":    copyleftPatent,
+		"IPL-1.0":                         copyleftPatent,
+		"LGPL-3.0":                        copyleftPatent,
+		"MPL-1.0":                         copyleftPatent,
+		"MPL-1.1":                         copyleftPatent,
+		"MPL-2.0":                         copyleftPatent,
+		"MPL-2.0-no-copyleft-exception":   copyleftPatent,
+		"MS-RL":                           copyleftPatent,
+		"NPL-1.0":                         copyleftPatent,
+		"NPL-1.1":                         copyleftPatent,
+		"OSL-1.0":                         copyleftPatent,
+		"OSL-1.1":                         copyleftPatent,
+
+		"AGPL-1.0": networkCopyleft,
+
+		"AGPL-3.0": networkPatent,
+		"APSL-1.0": networkPatent,
+		"APSL-1.1": networkPatent,
+		"APSL-1.2": networkPatent,
+		"APSL-2.0": networkPatent,
+		"CPAL-1.0": networkPatent,
+		"EUPL-1.0": networkPatent,
+		"EUPL-1.1": networkPatent,
+		"OSL-2.0":  networkPatent,
+		"OSL-2.1":  networkPatent,
+		"OSL-3.0":  networkPatent,
+	}
+}()
@@ -0,0 +1,91 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestObligations(t *testing.T) {
+	tests := []struct {
+		id   string
+		want []Obligation
+		ok   bool
+	}{
+		{id: "MIT", want: []Obligation{Attribution}, ok: true},
+		{id: "Apache-2.0", want: []Obligation{Attribution, PatentGrant}, ok: true},
+		{id: "GPL-2.0-only", want: []Obligation{Attribution, ShareAlike, DiscloseSource}, ok: true},
+		{id: "GPL-3.0-or-later", want: []Obligation{Attribution, ShareAlike, DiscloseSource, PatentGrant}, ok: true},
+		{id: "AGPL-3.0", want: []Obligation{Attribution, ShareAlike, DiscloseSource, PatentGrant, NetworkCopyleft}, ok: true},
+		{id: "CC-BY-SA-4.0", want: []Obligation{Attribution, ShareAlike}, ok: true},
+		{id: "Unlicense", want: []Obligation{}, ok: true},
+		{id: "Classpath-exception-2.0"},
+		{id: "not-a-license"},
+	}
+	for _, tt := range tests {
+		got, ok := Obligations(tt.id)
+		if ok != tt.ok {
+			t.Errorf("Obligations(%q) ok = %v, want %v", tt.id, ok, tt.ok)
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("Obligations(%q) mismatch (-want +got):\n%s", tt.id, diff)
+		}
+	}
+
+	// The returned obligations are a copy.
+	o, _ := Obligations("MIT")
+	o[0] = NetworkCopyleft
+	if again, _ := Obligations("MIT"); again[0] != Attribution {
+		t.Error("modifying the result of Obligations() modified the table")
+	}
+}
+
+func TestObligationsOfCorpus(t *testing.T) {
+	db, err := NewLicenseDB(baseLicenses)
+	if err != nil {
+		t.Fatalf("NewLicenseDB() failed: %v", err)
+	}
+	for id := range licenseObligations {
+		if _, ok := db.Lookup(id); !ok {
+			t.Errorf("obligations are listed for %s, which isn't in the corpus", id)
+		}
+	}
+}
+
+func TestObligationText(t *testing.T) {
+	b, err := json.Marshal([]Obligation{Attribution, NetworkCopyleft})
+	if err != nil {
+		t.Fatalf("Marshal() failed: %v", err)
+	}
+	if got, want := string(b), `["attribution","network-copyleft"]`; got != want {
+		t.Errorf("Marshal() = %s, want %s", got, want)
+	}
+	var o []Obligation
+	if err := json.Unmarshal(b, &o); err != nil {
+		t.Fatalf("Unmarshal() failed: %v", err)
+	}
+	if diff := cmp.Diff([]Obligation{Attribution, NetworkCopyleft}, o); diff != "" {
+		t.Errorf("Unmarshal() mismatch (-want +got):\n%s", diff)
+	}
+	if err := json.Unmarshal([]byte(`["copyleft"]`), &o); err == nil {
+		t.Error("Unmarshal() of an unknown obligation succeeded, want error")
+	}
+	if got := Obligation(42).String(); got != "unknown" {
+		t.Errorf("String() = %q, want unknown", got)
+	}
+}
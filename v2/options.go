@@ -0,0 +1,401 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/google/licenseclassifier/v2/licenses"
+)
+
+// DefaultThreshold is the confidence threshold of classifiers created by New
+// without the WithThreshold option.
+const DefaultThreshold = 0.8
+
+// Option configures a classifier created by New.
+type Option func(*config)
+
+// config collects the configuration of a classifier. The corpus is loaded
+// after the rest of the configuration is applied, since the threshold and
+// the normalization exemptions determine how its entries are indexed.
+type config struct {
+	threshold   float64
+	parallelism int
+	maxTokens   int
+	maxMemory   int64
+	q           int
+	autoQ       bool
+	edits       *EditWeights
+	eq          *Equivalences
+	licenses    []string
+	excluded    []string
+	setup       []func(*Classifier)
+	corpus      []func(*Classifier) error
+}
+
+// New creates a classifier configured by the supplied options. Without
+// options, the classifier has an empty corpus and a threshold of
+// DefaultThreshold.
+//
+//	c, err := classifier.New(
+//		classifier.WithThreshold(0.9),
+//		classifier.WithCorpusDir("licenses"),
+//		classifier.WithInputFormat(classifier.FormatAuto),
+//	)
+func New(opts ...Option) (*Classifier, error) {
+	cfg := &config{threshold: DefaultThreshold}
+	for _, o := range opts {
+		o(cfg)
+	}
+	if cfg.threshold <= 0 || cfg.threshold > 1 {
+		return nil, fmt.Errorf("threshold %v is not in the range (0, 1]", cfg.threshold)
+	}
+	if cfg.parallelism < 0 {
+		return nil, fmt.Errorf("parallelism %d is negative", cfg.parallelism)
+	}
+	if cfg.maxTokens < 0 {
+		return nil, fmt.Errorf("maximum of %d tokens is negative", cfg.maxTokens)
+	}
+	if cfg.maxMemory < 0 {
+		return nil, fmt.Errorf("maximum memory of %d bytes is negative", cfg.maxMemory)
+	}
+	if cfg.maxMemory > 0 {
+		cfg.maxTokens = maxTokensForMemory(cfg.maxMemory)
+	}
+	if cfg.q < 0 {
+		return nil, fmt.Errorf("q-gram size %d is negative", cfg.q)
+	}
+	if cfg.edits != nil {
+		if err := cfg.edits.valid(); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.eq != nil {
+		if err := cfg.eq.valid(); err != nil {
+			return nil, err
+		}
+	}
+
+	filter, err := newCorpusFilter(cfg.licenses, cfg.excluded)
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewClassifier(cfg.threshold)
+	c.filter = filter
+	c.parallelism = cfg.parallelism
+	c.maxTokens = cfg.maxTokens
+	if cfg.q > 0 {
+		c.q = cfg.q
+	}
+	if cfg.edits != nil {
+		c.SetEditWeights(*cfg.edits)
+	}
+	if cfg.eq != nil {
+		c.SetEquivalences(cfg.eq)
+	}
+	for _, s := range cfg.setup {
+		s(c)
+	}
+	for _, load := range cfg.corpus {
+		if err := load(c); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.checkCorpusFilter(); err != nil {
+		return nil, err
+	}
+	if cfg.autoQ {
+		c.TuneQGramSize()
+		c.tuneQ = true
+	}
+	return c, nil
+}
+
+// NewDefaultClassifier creates a classifier with the license corpus
+// embedded in the licenses package, configured by the supplied options, so
+// that no files need be distributed with programs using it.
+//
+//	c, err := classifier.NewDefaultClassifier()
+//	...
+//	matches := c.Match(content)
+func NewDefaultClassifier(opts ...Option) (*Classifier, error) {
+	return New(append([]Option{WithCorpusFS(licenses.FS)}, opts...)...)
+}
+
+// WithThreshold sets the minimum confidence of the matches reported by the
+// classifier, between 0 (exclusive) and 1.
+func WithThreshold(threshold float64) Option {
+	return func(cfg *config) { cfg.threshold = threshold }
+}
+
+// WithCorpusDir adds the licenses in the supplied directory to the corpus,
+// as LoadLicenses does. It can be given several times to combine corpora.
+func WithCorpusDir(dir string) Option {
+	return func(cfg *config) {
+		cfg.corpus = append(cfg.corpus, func(c *Classifier) error { return c.LoadLicenses(dir) })
+	}
+}
+
+// WithCorpusFS adds the licenses in the supplied file system to the corpus,
+// as LoadLicensesFS does.
+func WithCorpusFS(fsys fs.FS) Option {
+	return func(cfg *config) {
+		cfg.corpus = append(cfg.corpus, func(c *Classifier) error { return c.LoadLicensesFS(fsys) })
+	}
+}
+
+// WithCorpusSource adds the licenses supplied by the source to the corpus,
+// as LoadCorpus does.
+func WithCorpusSource(src CorpusSource) Option {
+	return func(cfg *config) {
+		cfg.corpus = append(cfg.corpus, func(c *Classifier) error { return c.LoadCorpus(context.Background(), src) })
+	}
+}
+
+// WithIndexFile loads the corpus from an index written by WriteIndex, as
+// LoadIndex does.
+func WithIndexFile(path string) Option {
+	return func(cfg *config) {
+		cfg.corpus = append(cfg.corpus, func(c *Classifier) error { return c.LoadIndex(path) })
+	}
+}
+
+// WithCorpusContent adds a single entry to the corpus, as AddContent does.
+func WithCorpusContent(name string, content []byte) Option {
+	return func(cfg *config) {
+		cfg.corpus = append(cfg.corpus, func(c *Classifier) error {
+			c.AddContent(name, content)
+			return nil
+		})
+	}
+}
+
+// WithLicenses restricts the corpus to the entries of the named licenses,
+// including their headers and variants, which makes matching faster when
+// only a few licenses are of interest. Content of other licenses is only
+// reported through references to them, such as SPDX identifiers. New fails
+// if a named license has no entry in the corpus. It can be given several
+// times to name more licenses.
+func WithLicenses(names ...string) Option {
+	return func(cfg *config) { cfg.licenses = append(cfg.licenses, names...) }
+}
+
+// WithoutCategories drops the entries of the licenses of the supplied
+// categories, such as CategoryForbidden, from the corpus, as WithLicenses
+// drops those of unnamed licenses.
+func WithoutCategories(categories ...string) Option {
+	return func(cfg *config) { cfg.excluded = append(cfg.excluded, categories...) }
+}
+
+// WithTraceConfiguration installs a tracing configuration, as
+// SetTraceConfiguration does.
+//
+// Deprecated: use WithLogger.
+func WithTraceConfiguration(tc *TraceConfiguration) Option {
+	return func(cfg *config) {
+		cfg.setup = append(cfg.setup, func(c *Classifier) { c.SetTraceConfiguration(tc) })
+	}
+}
+
+// WithLogger installs the logger receiving the diagnostics of the
+// classifier, as SetLogger does.
+func WithLogger(l Logger) Option {
+	return func(cfg *config) {
+		cfg.setup = append(cfg.setup, func(c *Classifier) { c.SetLogger(l) })
+	}
+}
+
+// WithMetrics installs the receiver of the metrics of the documents the
+// classifier matches, as SetMetrics does.
+func WithMetrics(m Metrics) Option {
+	return func(cfg *config) {
+		cfg.setup = append(cfg.setup, func(c *Classifier) { c.SetMetrics(m) })
+	}
+}
+
+// WithMaxTokens bounds the memory used to match large documents by matching
+// those with more than n tokens in overlapping windows, as SetMaxTokens does.
+func WithMaxTokens(n int) Option {
+	return func(cfg *config) { cfg.maxTokens = n }
+}
+
+// WithMaxMemory bounds the memory used to match a single document to about
+// the supplied number of bytes, by matching documents in windows of as many
+// tokens as fit in it. See SetMaxTokens. Documents are still tokenized as a
+// whole, which takes memory proportional to their size but a fraction of
+// what matching them whole does.
+func WithMaxMemory(bytes int64) Option {
+	return func(cfg *config) { cfg.maxMemory = bytes }
+}
+
+// WithMaxDocumentSize limits the size of the content MatchFrom reads, as
+// SetMaxDocumentSize does.
+func WithMaxDocumentSize(n int64) Option {
+	return func(cfg *config) {
+		cfg.setup = append(cfg.setup, func(c *Classifier) { c.SetMaxDocumentSize(n) })
+	}
+}
+
+// WithQGramSize sets the length of the q-grams used to find candidate
+// regions, rather than deriving it from the threshold. See SetQGramSize.
+func WithQGramSize(q int) Option {
+	return func(cfg *config) { cfg.q = q }
+}
+
+// WithAutoQGramSize tunes the length of the q-grams to the corpus once it is
+// loaded, as TuneQGramSize does. It takes precedence over WithQGramSize.
+func WithAutoQGramSize() Option {
+	return func(cfg *config) { cfg.autoQ = true }
+}
+
+// WithAliases installs organization-specific aliases of licenses, as
+// SetAliases does.
+func WithAliases(aliases map[string]Alias) Option {
+	return func(cfg *config) {
+		cfg.setup = append(cfg.setup, func(c *Classifier) { c.SetAliases(aliases) })
+	}
+}
+
+// WithTopK reports the other licenses that scored best in the region of each
+// match, as SetTopK does.
+func WithTopK(k int) Option {
+	return func(cfg *config) {
+		cfg.setup = append(cfg.setup, func(c *Classifier) { c.SetTopK(k) })
+	}
+}
+
+// WithOverlapStrategy sets the strategy deciding which of overlapping
+// matches are reported, as SetOverlapStrategy does.
+func WithOverlapStrategy(s OverlapStrategy) Option {
+	return func(cfg *config) {
+		cfg.setup = append(cfg.setup, func(c *Classifier) { c.SetOverlapStrategy(s) })
+	}
+}
+
+// WithParallelism sets the number of goroutines used to load the corpus and
+// by scan sessions created without an explicit number of workers. Zero, the
+// default, uses GOMAXPROCS goroutines.
+func WithParallelism(n int) Option {
+	return func(cfg *config) { cfg.parallelism = n }
+}
+
+// WithTokenWeighting enables or disables the weighting of edits by the
+// distinctiveness of the words involved, as SetTokenWeighting does.
+func WithTokenWeighting(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.setup = append(cfg.setup, func(c *Classifier) { c.SetTokenWeighting(enabled) })
+	}
+}
+
+// WithInputFormat sets the markup stripped from content before it is
+// matched, as SetInputFormat does.
+func WithInputFormat(f Format) Option {
+	return func(cfg *config) {
+		cfg.setup = append(cfg.setup, func(c *Classifier) { c.SetInputFormat(f) })
+	}
+}
+
+// WithBinaryStrings classifies the strings of binary content rather than its
+// raw bytes, as SetBinaryStrings does.
+func WithBinaryStrings(minLen int) Option {
+	return func(cfg *config) {
+		cfg.setup = append(cfg.setup, func(c *Classifier) { c.SetBinaryStrings(minLen) })
+	}
+}
+
+// WithNormalizationExemptions declares phrases of a license or corpus entry
+// that must match as written, as SetNormalizationExemptions does. The
+// exemptions apply to the corpus loaded by the other options regardless of
+// the order the options are given in.
+func WithNormalizationExemptions(name string, phrases []string) Option {
+	return func(cfg *config) {
+		cfg.setup = append(cfg.setup, func(c *Classifier) { c.SetNormalizationExemptions(name, phrases) })
+	}
+}
+
+// WithAnchors declares the phrases matches of a short license or corpus
+// entry must contain, as SetAnchors does, for the corpus loaded by the other
+// options.
+func WithAnchors(name string, phrases []string) Option {
+	return func(cfg *config) {
+		cfg.setup = append(cfg.setup, func(c *Classifier) { c.SetAnchors(name, phrases) })
+	}
+}
+
+// WithTimeBudget limits the time spent matching a single document, as
+// SetTimeBudget does.
+func WithTimeBudget(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.setup = append(cfg.setup, func(c *Classifier) { c.SetTimeBudget(d) })
+	}
+}
+
+// WithScoringBudget limits the scoring of the corpus entries of a match type,
+// as SetScoringBudget does.
+func WithScoringBudget(matchType string, b Budget) Option {
+	return func(cfg *config) {
+		cfg.setup = append(cfg.setup, func(c *Classifier) { c.SetScoringBudget(matchType, b) })
+	}
+}
+
+// WithCache installs a cache of the results of matched contents, as SetCache
+// does.
+func WithCache(cache Cache) Option {
+	return func(cfg *config) {
+		cfg.setup = append(cfg.setup, func(c *Classifier) { c.SetCache(cache) })
+	}
+}
+
+// WithDiffRule registers a rule consulted about the diffs of candidate
+// matches, as AddDiffRule does.
+func WithDiffRule(name string, r DiffRule) Option {
+	return func(cfg *config) {
+		cfg.setup = append(cfg.setup, func(c *Classifier) { c.AddDiffRule(name, r) })
+	}
+}
+
+// WithEditWeights sets the costs of the kinds of word edits, as
+// SetEditWeights does. All of the weights must be positive.
+func WithEditWeights(w EditWeights) Option {
+	return func(cfg *config) { cfg.edits = &w }
+}
+
+// WithEquivalences sets the equivalence classes and stopwords of the
+// classifier, as SetEquivalences does, before the corpus is loaded. New fails
+// if a class has fewer than two phrases or a phrase has no words.
+func WithEquivalences(e *Equivalences) Option {
+	return func(cfg *config) { cfg.eq = e }
+}
+
+// WithCalibration installs a calibration of the confidence of matches, as
+// SetCalibration does.
+func WithCalibration(cal Calibration) Option {
+	return func(cfg *config) {
+		cfg.setup = append(cfg.setup, func(c *Classifier) { c.SetCalibration(cal) })
+	}
+}
+
+// WithPartialMatching enables or disables the matching of portions of license
+// texts, as SetPartialMatching does.
+func WithPartialMatching(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.setup = append(cfg.setup, func(c *Classifier) { c.SetPartialMatching(enabled) })
+	}
+}
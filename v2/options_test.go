@@ -0,0 +1,117 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+func TestNew(t *testing.T) {
+	c, err := New()
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if c.threshold != DefaultThreshold || c.q != computeQ(DefaultThreshold) {
+		t.Errorf("New() threshold = %v, q = %d, want %v and %d", c.threshold, c.q, DefaultThreshold, computeQ(DefaultThreshold))
+	}
+	if len(c.docs) != 0 {
+		t.Errorf("New() loaded %d corpus entries, want none", len(c.docs))
+	}
+
+	for _, opt := range []Option{WithThreshold(0), WithThreshold(1.5), WithParallelism(-1), WithMaxTokens(-1), WithMaxMemory(-1), WithQGramSize(-1), WithEditWeights(EditWeights{Added: .5})} {
+		if _, err := New(opt); err == nil {
+			t.Error("New() succeeded with an invalid option, want error")
+		}
+	}
+}
+
+func TestNewMatchesNewClassifier(t *testing.T) {
+	want, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	c, err := New(WithThreshold(defaultThreshold), WithCorpusDir(baseLicenses), WithParallelism(3))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if got, want := c.CorpusVersion(), want.CorpusVersion(); got != want {
+		t.Errorf("CorpusVersion() = %s, want %s", got, want)
+	}
+	if got, want := len(c.dict.words), len(want.dict.words); got != want {
+		t.Errorf("dictionary has %d words, want %d", got, want)
+	}
+
+	in, err := ioutil.ReadFile(filepath.Join(baseLicenses, "Apache-2.0.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := c.Match(in)
+	if len(m) == 0 || m[0].Name != "Apache-2.0" || m[0].Confidence != 1.0 {
+		t.Errorf("Match() = %v, want an exact Apache-2.0 match", m)
+	}
+}
+
+func TestNewOptionOrder(t *testing.T) {
+	// Exemptions apply to the corpus even when they're given after it.
+	c, err := New(
+		WithCorpusContent("Test", []byte("the licensor shall not be liable for damages")),
+		WithNormalizationExemptions("Test", []string{"licensor"}),
+		WithTokenWeighting(true),
+		WithInputFormat(FormatMarkdown),
+		WithScoringBudget("License", Budget{MaxCandidates: 2}),
+		WithParallelism(2),
+		WithTopK(3),
+		WithEditWeights(EditWeights{Added: .5, Removed: 2, Substituted: 1}),
+		WithDiffRule("none", DiffRuleFunc(func(string, []diffmatchpatch.Diff) (Verdict, string) { return Abstain, "" })),
+	)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if got := len(c.docs["Test"].exemptions); got != 1 {
+		t.Errorf("corpus entry has %d exemptions, want 1", got)
+	}
+	if !c.weighted || c.format != FormatMarkdown || c.budgets["License"].MaxCandidates != 2 || c.parallelism != 2 || c.topK != 3 || len(c.rules) != 1 || c.edits.Added != .5 {
+		t.Errorf("New() didn't apply the options: weighted = %v, format = %v, budgets = %v, parallelism = %d, topK = %d, rules = %d, edits = %+v",
+			c.weighted, c.format, c.budgets, c.parallelism, c.topK, len(c.rules), c.edits)
+	}
+}
+
+func TestNewDefaultClassifier(t *testing.T) {
+	want, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	c, err := NewDefaultClassifier(WithThreshold(defaultThreshold))
+	if err != nil {
+		t.Fatalf("NewDefaultClassifier() failed: %v", err)
+	}
+	if got, want := c.CorpusVersion(), want.CorpusVersion(); got != want {
+		t.Errorf("CorpusVersion() = %s, want %s of the corpus directory", got, want)
+	}
+	in, err := ioutil.ReadFile(filepath.Join(baseLicenses, "MIT.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := c.Match(in); len(m) == 0 || m[0].Name != "MIT" || m[0].Confidence != 1.0 {
+		t.Errorf("Match() = %v, want an exact MIT match", m)
+	}
+	if err := c.Reload(); err != nil {
+		t.Errorf("Reload() failed: %v", err)
+	}
+}
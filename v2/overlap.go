@@ -0,0 +1,203 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"fmt"
+	"sort"
+)
+
+// OverlapStrategy decides which of the candidate matches overlapping in the
+// content are reported. Overlaps are common, since licenses embed the
+// disclaimers and clauses of others, and several licenses can match the
+// same text.
+type OverlapStrategy int
+
+const (
+	// OverlapTokenDensity keeps the most confident of overlapping matches,
+	// unless a match containing it covers more tokens, weighted by
+	// confidence, as a less confident match of a license embedding another
+	// does. It is the default.
+	OverlapTokenDensity OverlapStrategy = iota
+	// OverlapHighestConfidence keeps the most confident of overlapping
+	// matches.
+	OverlapHighestConfidence
+	// OverlapLongest keeps the longest of overlapping matches, and the most
+	// confident of those of equal length.
+	OverlapLongest
+	// OverlapReportAll reports every candidate, recording in the Overlaps of
+	// each match the other licenses it overlaps.
+	OverlapReportAll
+)
+
+// overlapStrategyNames are the names of the strategies, as parsed by
+// ParseOverlapStrategy.
+var overlapStrategyNames = map[OverlapStrategy]string{
+	OverlapTokenDensity:      "token-density",
+	OverlapHighestConfidence: "highest-confidence",
+	OverlapLongest:           "longest",
+	OverlapReportAll:         "report-all",
+}
+
+func (s OverlapStrategy) String() string {
+	if n, ok := overlapStrategyNames[s]; ok {
+		return n
+	}
+	return fmt.Sprintf("OverlapStrategy(%d)", int(s))
+}
+
+// ParseOverlapStrategy returns the strategy with the supplied name:
+// token-density, highest-confidence, longest or report-all.
+func ParseOverlapStrategy(name string) (OverlapStrategy, error) {
+	for s, n := range overlapStrategyNames {
+		if n == name {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown overlap strategy %q", name)
+}
+
+// SetOverlapStrategy sets the strategy deciding which of overlapping matches
+// are reported. Matches of the same license in the same region are always
+// reported as one, with the scores of its corpus entries in Variants.
+func (c *Classifier) SetOverlapStrategy(s OverlapStrategy) {
+	defer c.update()()
+	c.overlap = s
+}
+
+// retainCandidates returns which of the candidates, ordered by confidence,
+// the strategy retains.
+func retainCandidates(candidates Matches, strategy OverlapStrategy) []bool {
+	switch strategy {
+	case OverlapHighestConfidence:
+		return retainGreedy(candidates, func(i, j int) bool { return i < j })
+	case OverlapLongest:
+		return retainGreedy(candidates, func(i, j int) bool {
+			li := candidates[i].EndTokenIndex - candidates[i].StartTokenIndex
+			lj := candidates[j].EndTokenIndex - candidates[j].StartTokenIndex
+			if li != lj {
+				return li > lj
+			}
+			return i < j
+		})
+	case OverlapReportAll:
+		retain := make([]bool, len(candidates))
+		for i := range retain {
+			retain[i] = true
+		}
+		return retain
+	}
+	return retainByDensity(candidates)
+}
+
+// retainGreedy retains the candidates in the order less sorts their indices
+// in, skipping those overlapping a candidate already retained.
+func retainGreedy(candidates Matches, less func(i, j int) bool) []bool {
+	order := make([]int, len(candidates))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return less(order[a], order[b]) })
+	retain := make([]bool, len(candidates))
+	var kept []*Match
+	for _, i := range order {
+		c := candidates[i]
+		overlapping := false
+		for _, k := range kept {
+			if sameRegion(c, k) {
+				overlapping = true
+				break
+			}
+		}
+		if !overlapping {
+			retain[i] = true
+			kept = append(kept, c)
+		}
+	}
+	return retain
+}
+
+// retainByDensity retains the candidates as OverlapTokenDensity does.
+func retainByDensity(candidates Matches) []bool {
+	retain := make([]bool, len(candidates))
+	for i, c := range candidates {
+		// Filter out overlapping licenses based primarily on confidence. Since
+		// the candidates slice is ordered by confidence, we look for overlaps and
+		// decide if we retain the record c.
+
+		// For each candidate, only add it to the report unless we have a
+		// higher-quality hit that contains these lines. In the case of two
+		// licenses having overlap, we consider 'token density' to break ties. If a
+		// less confident match of a larger license has more matching tokens than a
+		// perfect match of a smaller license, we want to keep that. This handles
+		// licenses that include another license as a subtext. NPL contains MPL
+		// as a concrete example.
+
+		keep := true
+		proposals := make(map[int]bool)
+		for j, o := range candidates {
+			if j == i {
+				break
+			}
+			// Make sure to only check containment on licenses that are still in consideration at this point.
+			if contains(c, o) && retain[j] {
+				// The license here can override a previous detection, but that isn't sufficient to be kept
+				// on its own. Consider the licenses Xnet, MPL-1.1 and NPL-1.1 in a file that just has MPL-1.1.
+				// The confidence rating on NPL-1.1 will cause Xnet to not be retained, which is correct, but it
+				// shouldn't be retained if the token confidence for MPL is higher than NPL since the NPL-specific
+				// bits are missing.
+
+				ctoks := float64(c.EndTokenIndex - c.StartTokenIndex)
+				otoks := float64(o.EndTokenIndex - o.StartTokenIndex)
+				cconf := ctoks * c.Confidence
+				oconf := otoks * o.Confidence
+
+				// If the two licenses are exactly the same confidence, that means we
+				// have an ambiguous detect and should retain both, so the caller can
+				// see and resolve the situation.
+				if cconf > oconf {
+					proposals[j] = false
+				} else if oconf > cconf {
+					keep = false
+				}
+			} else if overlaps(c, o) && retain[j] {
+				keep = false
+			}
+
+		}
+		if keep {
+			retain[i] = true
+			for p, v := range proposals {
+				retain[p] = v
+			}
+		}
+	}
+	return retain
+}
+
+// flagOverlaps records in each match the names of the other licenses whose
+// matches overlap it.
+func flagOverlaps(matches Matches) {
+	for _, m := range matches {
+		seen := make(map[string]bool)
+		for _, o := range matches {
+			if o.Name != m.Name && sameRegion(m, o) && !seen[o.Name] {
+				seen[o.Name] = true
+				m.Overlaps = append(m.Overlaps, o.Name)
+			}
+		}
+		sort.Strings(m.Overlaps)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestOverlapStrategies(t *testing.T) {
+	// A long license embedding a short one matches less confidently than
+	// the short license, whose text is also matched by another license.
+	candidates := func() Matches {
+		return Matches{
+			{Name: "Short", MatchType: "License", Variant: "Short", Confidence: 1, StartLine: 10, EndLine: 20, StartTokenIndex: 100, EndTokenIndex: 200},
+			{Name: "Other", MatchType: "License", Variant: "Other", Confidence: 0.95, StartLine: 10, EndLine: 21, StartTokenIndex: 100, EndTokenIndex: 210},
+			{Name: "Long", MatchType: "License", Variant: "Long", Confidence: 0.9, StartLine: 1, EndLine: 40, StartTokenIndex: 0, EndTokenIndex: 400},
+			{Name: "Elsewhere", MatchType: "License", Variant: "Elsewhere", Confidence: 0.85, StartLine: 50, EndLine: 60, StartTokenIndex: 500, EndTokenIndex: 600},
+		}
+	}
+	tests := []struct {
+		strategy OverlapStrategy
+		want     []string
+		overlaps map[string][]string
+	}{
+		{strategy: OverlapTokenDensity, want: []string{"Long", "Elsewhere"}},
+		{strategy: OverlapHighestConfidence, want: []string{"Short", "Elsewhere"}},
+		{strategy: OverlapLongest, want: []string{"Long", "Elsewhere"}},
+		{
+			strategy: OverlapReportAll,
+			want:     []string{"Short", "Other", "Long", "Elsewhere"},
+			overlaps: map[string][]string{
+				"Short": {"Long", "Other"},
+				"Other": {"Long", "Short"},
+				"Long":  {"Other", "Short"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.strategy.String(), func(t *testing.T) {
+			var got []string
+			overlaps := make(map[string][]string)
+			for _, m := range resolve(candidates(), nil, 0, test.strategy) {
+				got = append(got, m.Name)
+				if m.Overlaps != nil {
+					overlaps[m.Name] = m.Overlaps
+				}
+			}
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("resolve() = %v, want %v", got, test.want)
+			}
+			if test.overlaps == nil {
+				test.overlaps = map[string][]string{}
+			}
+			if !cmp.Equal(overlaps, test.overlaps) {
+				t.Errorf("resolve() overlaps = %v, want %v", overlaps, test.overlaps)
+			}
+		})
+	}
+}
+
+func TestParseOverlapStrategy(t *testing.T) {
+	for s := range overlapStrategyNames {
+		got, err := ParseOverlapStrategy(s.String())
+		if err != nil || got != s {
+			t.Errorf("ParseOverlapStrategy(%q) = %v, %v, want %v", s.String(), got, err, s)
+		}
+	}
+	if _, err := ParseOverlapStrategy("first"); err == nil {
+		t.Errorf("ParseOverlapStrategy(\"first\") succeeded, want an error")
+	}
+}
+
+func TestSetOverlapStrategy(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	in := []byte(gadgetLicense)
+	before := c.Match(in)
+	c.SetOverlapStrategy(OverlapReportAll)
+	all := c.Match(in)
+	if len(all) < len(before) {
+		t.Errorf("Match() with OverlapReportAll = %d matches, want at least the %d of the default strategy", len(all), len(before))
+	}
+	c.SetOverlapStrategy(OverlapTokenDensity)
+	if got := c.Match(in); !cmp.Equal(got, before) {
+		t.Errorf("Match() after restoring the default strategy = %v, want %v", got, before)
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"sort"
+)
+
+// A file may hold only part of a long license, as when a vendored copy was
+// truncated. Scored against the whole text, the missing part counts as
+// removed words and the confidence is near zero, so in partial matching mode
+// the licenses that weren't matched are also scored against the portion of
+// their text found in the content.
+
+// partialType is the match type of matches of a portion of a license text.
+const partialType = "Partial"
+
+const (
+	// minPartialTokens is the length of the smallest portion of a license
+	// reported, so that the sentences many licenses share aren't.
+	minPartialTokens = 100
+	// minPartialShare is the smallest share of a license text reported.
+	minPartialShare = 0.2
+	// minPartialSimilarity is the token similarity of the target to the
+	// licenses it's searched for portions of. A portion of a license has
+	// fewer of its distinct words than its share of the text.
+	minPartialSimilarity = 0.1
+)
+
+// Portion is the part of a license text found by a partial match.
+type Portion struct {
+	// Start and End are the indexes of the first token of the portion in
+	// the normalized license text, and of the token following it.
+	Start, End int
+	// Length is the number of tokens of the license text.
+	Length int
+	// FirstClause and LastClause are the labels of the clauses the portion
+	// starts and ends in, such as "Section 1" and "Section 3.2", as reported
+	// by Explanation.ClauseChanges.
+	FirstClause, LastClause string
+}
+
+// Share returns the share of the license text in the portion.
+func (p Portion) Share() float64 {
+	if p.Length == 0 {
+		return 0
+	}
+	return float64(p.End-p.Start) / float64(p.Length)
+}
+
+// SetPartialMatching enables or disables the reporting of the portions of
+// license texts found in content that doesn't have the whole of them, as
+// matches with a MatchType of Partial and their Portion set. The portions
+// must be at least a fifth of the license text, and match it at the
+// threshold. It doesn't apply to documents matched in windows, and makes
+// matching slower.
+func (c *Classifier) SetPartialMatching(enabled bool) {
+	defer c.update()()
+	c.partial = enabled
+}
+
+// partialMatches returns the partial matches in the target of the licenses
+// whose text isn't matched by ms, which they don't overlap.
+func (c *Classifier) partialMatches(id *indexedDocument, ms Matches) Matches {
+	matched := make(map[string]bool)
+	for _, m := range ms {
+		if m.MatchType != referenceType && m.MatchType != grantType {
+			matched[m.Name] = true
+		}
+	}
+	if id.s == nil {
+		id.generateTargetSearchSet(c.q)
+	}
+	var candidates Matches
+	for _, name := range sortedNames(c.docs) {
+		d := c.docs[name]
+		if detectionType(name) != "License" || matched[LicenseName(name)] || d.size() < minPartialTokens {
+			continue
+		}
+		if id.tokenSimilarity(d) < minPartialSimilarity {
+			continue
+		}
+		if m := c.partialMatch(name, id, d); m != nil {
+			candidates = append(candidates, m)
+		}
+	}
+	// The portions covering the most of the content win the regions they
+	// overlap.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ci, cj := candidates[i], candidates[j]
+		wi := float64(ci.EndTokenIndex-ci.StartTokenIndex) * ci.Confidence
+		wj := float64(cj.EndTokenIndex-cj.StartTokenIndex) * cj.Confidence
+		return wi > wj
+	})
+	var out Matches
+	overlapped := func(p *Match, ms Matches) bool {
+		for _, m := range ms {
+			if m.MatchType != referenceType && m.MatchType != grantType && sameRegion(m, p) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, p := range candidates {
+		if !overlapped(p, ms) && !overlapped(p, out) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// partialMatch returns the match of the portion of the named corpus entry
+// found in the target, or nil if there's none.
+func (c *Classifier) partialMatch(name string, id, d *indexedDocument) *Match {
+	ranges := targetMatchedRanges(d.s, id.s)
+	if len(ranges) == 0 {
+		return nil
+	}
+	// The range claiming the most tokens aligns the target with the entry,
+	// and the ranges offset from it by no more than the edits allowed in the
+	// entry extend the portion.
+	best := ranges[0]
+	margin := d.maxDistance
+	offset := best.TargetStart - best.SrcStart
+	ts, te, ss, se := best.TargetStart, best.TargetEnd, best.SrcStart, best.SrcEnd
+	for _, r := range ranges[1:] {
+		if o := r.TargetStart - r.SrcStart; o-offset > margin || offset-o > margin {
+			continue
+		}
+		if r.TargetStart < ts && r.SrcStart < ss {
+			ts, ss = r.TargetStart, r.SrcStart
+		}
+		if r.TargetEnd > te && r.SrcEnd > se {
+			te, se = r.TargetEnd, r.SrcEnd
+		}
+	}
+	length := se - ss
+	if length < minPartialTokens || float64(length) < minPartialShare*float64(d.size()) {
+		return nil
+	}
+	bound := maxDistance(length, c.threshold, c.minEditCost())
+	distance := scoreDiffs(name, docDiff(name, id, ts, te, d, ss, se), bound)
+	if distance < 0 {
+		return nil
+	}
+	conf := confidencePercentage(length, distance)
+	if conf < c.threshold {
+		return nil
+	}
+	return &Match{
+		Name:            LicenseName(name),
+		MatchType:       partialType,
+		Category:        LicenseCategory(LicenseName(name)),
+		Variant:         name,
+		Language:        VariantLanguage(name),
+		Confidence:      conf,
+		StartLine:       int(id.Tokens[ts].Line),
+		EndLine:         int(id.Tokens[te-1].Line),
+		StartTokenIndex: int(id.Tokens[ts].Index),
+		EndTokenIndex:   int(id.Tokens[te-1].Index),
+		Portion: &Portion{
+			Start:       ss,
+			End:         se,
+			Length:      d.size(),
+			FirstClause: clauseLabel(d.clauses, ss),
+			LastClause:  clauseLabel(d.clauses, se-1),
+		},
+	}
+}
+
+// clauseLabel returns the label of the clause the token at index k of a
+// corpus entry is part of, or an empty string if the entry has no clauses.
+func clauseLabel(clauses []clause, k int) string {
+	i := sort.Search(len(clauses), func(i int) bool { return clauses[i].start > k }) - 1
+	if i < 0 {
+		return ""
+	}
+	return clauses[i].label
+}
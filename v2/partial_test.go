@@ -0,0 +1,103 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// truncated returns the first share of the lines of the named license.
+func truncated(t *testing.T, name string, share float64) []byte {
+	t.Helper()
+	b, err := ioutil.ReadFile(filepath.Join(baseLicenses, name+".txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(b), "\n")
+	return []byte(strings.Join(lines[:int(share*float64(len(lines)))], "\n"))
+}
+
+func TestPartialMatches(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	c.SetPartialMatching(true)
+	tests := []struct {
+		name  string
+		share float64
+	}{
+		{"Apache-2.0", 0.5},
+		{"GPL-3.0", 0.4},
+		{"MPL-2.0", 0.3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *Match
+			for _, m := range c.Match(truncated(t, tt.name, tt.share)) {
+				if m.MatchType == partialType {
+					if got != nil {
+						t.Fatalf("partial matches %s and %s, want one", got.Name, m.Name)
+					}
+					got = m
+				}
+			}
+			if got == nil || got.Name != tt.name {
+				t.Fatalf("partial match = %v, want one of %s", got, tt.name)
+			}
+			if got.Confidence < defaultThreshold {
+				t.Errorf("confidence = %v, want at least %v", got.Confidence, defaultThreshold)
+			}
+			p := got.Portion
+			if p == nil {
+				t.Fatal("no portion")
+			}
+			if p.Start > 10 || p.Share() < tt.share-0.15 || p.Share() > tt.share+0.15 {
+				t.Errorf("portion [%d, %d) of %d tokens, want about the first %v of the license", p.Start, p.End, p.Length, tt.share)
+			}
+			if p.FirstClause == "" || p.LastClause == "" || p.FirstClause == p.LastClause {
+				t.Errorf("portion from clause %q to %q, want different clauses", p.FirstClause, p.LastClause)
+			}
+		})
+	}
+}
+
+func TestPartialMatchingDisabled(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	for _, m := range c.Match(truncated(t, "Apache-2.0", 0.5)) {
+		if m.MatchType == partialType {
+			t.Errorf("partial match %s without partial matching", m.Name)
+		}
+	}
+}
+
+func TestPartialMatchingWholeLicense(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	c.SetPartialMatching(true)
+	for _, m := range c.Match(truncated(t, "Apache-2.0", 1)) {
+		if m.Name != "Apache-2.0" || m.MatchType == partialType {
+			t.Errorf("match %s of type %s, want full matches of Apache-2.0", m.Name, m.MatchType)
+		}
+	}
+}
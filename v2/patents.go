@@ -0,0 +1,139 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Whether a license grants patent rights, and revokes them from licensees
+// who sue over patents, matters to reviewers more than most of its terms. A
+// match can tolerate the removal of such a clause from a long license, so the
+// clauses are looked for in the corpus entries and in the matched text, and
+// their status is reported with each match.
+
+// ClauseStatus tells whether a clause of a license is part of matched text.
+type ClauseStatus int
+
+const (
+	// ClauseUnknown is the status of clauses of matches that aren't backed
+	// by a license text, such as references.
+	ClauseUnknown ClauseStatus = iota
+	// ClauseAbsent is the status of clauses the license doesn't have.
+	ClauseAbsent
+	// ClausePresent is the status of clauses of the license found in the
+	// matched text.
+	ClausePresent
+	// ClauseMissing is the status of clauses of the license missing from the
+	// matched text, which is a significant modification of the license.
+	ClauseMissing
+)
+
+var clauseStatusNames = map[ClauseStatus]string{
+	ClauseUnknown: "unknown",
+	ClauseAbsent:  "absent",
+	ClausePresent: "present",
+	ClauseMissing: "missing",
+}
+
+func (s ClauseStatus) String() string {
+	if n, ok := clauseStatusNames[s]; ok {
+		return n
+	}
+	return fmt.Sprintf("ClauseStatus(%d)", int(s))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s ClauseStatus) MarshalText() ([]byte, error) {
+	if n, ok := clauseStatusNames[s]; ok {
+		return []byte(n), nil
+	}
+	return nil, fmt.Errorf("unknown clause status %d", int(s))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *ClauseStatus) UnmarshalText(text []byte) error {
+	for st, n := range clauseStatusNames {
+		if n == string(text) {
+			*s = st
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown clause status %q", text)
+}
+
+// patentGrant and patentRetaliation recognize the clauses in normalized text.
+var (
+	patentGrant = []*regexp.Regexp{
+		// Apache-2.0, EPL, GPL-3.0: "grants to You a ... patent license".
+		regexp.MustCompile(`\bgrants?\b(?: \S+){0,20}? patent license\b`),
+		// MPL-2.0: "under Patent Claims of such Contributor to make, use".
+		regexp.MustCompile(`\bunder patent claims\b(?: \S+){0,8}? to make\b`),
+		regexp.MustCompile(`\bpatent license to make\b`),
+	}
+	patentRetaliation = []*regexp.Regexp{
+		// Apache-2.0, EPL: "If You institute patent litigation".
+		regexp.MustCompile(`\b(?:institutes?|initiates?|commences?|brings?)(?: \S+){0,3}? patent litigation\b`),
+		// MPL-2.0, GPL-3.0: "initiate litigation ... asserting a patent
+		// infringement claim".
+		regexp.MustCompile(`\b(?:institutes?|initiates?|commences?) litigation\b(?: \S+){0,20}? patent\b`),
+	}
+)
+
+// patentClauses reports which patent clauses the normalized text has.
+func patentClauses(norm string) (grant, retaliation bool) {
+	return matchesAny(patentGrant, norm), matchesAny(patentRetaliation, norm)
+}
+
+func matchesAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// flagPatents sets the status of the patent clauses of the matches of corpus
+// entries found in the document.
+func (c *Classifier) flagPatents(ms Matches, doc *document) {
+	for _, m := range ms {
+		known, ok := c.docs[m.Variant]
+		if !ok || m.Variant == "" {
+			continue
+		}
+		var words []string
+		for i := m.StartTokenIndex; i <= m.EndTokenIndex && i < len(doc.Tokens); i++ {
+			words = append(words, doc.Tokens[i].Text)
+		}
+		grant, retaliation := patentClauses(strings.Join(words, " "))
+		m.PatentGrant = clauseStatus(known.patentGrant, grant)
+		m.PatentRetaliation = clauseStatus(known.patentRetaliation, retaliation)
+	}
+}
+
+// clauseStatus returns the status of a clause the license has or not in
+// matched text that has it or not.
+func clauseStatus(known, matched bool) ClauseStatus {
+	switch {
+	case !known:
+		return ClauseAbsent
+	case matched:
+		return ClausePresent
+	}
+	return ClauseMissing
+}
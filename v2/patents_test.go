@@ -0,0 +1,113 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPatentClausesOfCorpus(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	tests := []struct {
+		entry              string
+		grant, retaliation bool
+	}{
+		{"Apache-2.0", true, true},
+		{"MPL-2.0", true, true},
+		{"EPL-2.0", true, true},
+		{"GPL-3.0", true, true},
+		{"MIT", false, false},
+		{"BSD-3-Clause", false, false},
+		{"GPL-2.0", false, false},
+	}
+	for _, tt := range tests {
+		d, ok := c.docs[tt.entry]
+		if !ok {
+			t.Fatalf("no corpus entry %s", tt.entry)
+		}
+		if d.patentGrant != tt.grant || d.patentRetaliation != tt.retaliation {
+			t.Errorf("%s: grant %v, retaliation %v; want %v, %v", tt.entry, d.patentGrant, d.patentRetaliation, tt.grant, tt.retaliation)
+		}
+	}
+}
+
+func TestPatentFlags(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	apache, err := ioutil.ReadFile(filepath.Join(baseLicenses, "Apache-2.0.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mit, err := ioutil.ReadFile(filepath.Join(baseLicenses, "MIT.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Strip the retaliation sentence from the patent grant of Section 3.
+	lines := strings.Split(string(apache), "\n")
+	for i, l := range lines {
+		if j := strings.Index(l, " If You institute patent litigation"); j != -1 {
+			lines[i] = l[:j]
+		}
+	}
+	noRetaliation := strings.Join(lines, "\n")
+
+	tests := []struct {
+		description        string
+		in                 string
+		matchType          string
+		grant, retaliation ClauseStatus
+	}{
+		{"Apache-2.0", string(apache), "License", ClausePresent, ClausePresent},
+		{"Apache-2.0 without retaliation", noRetaliation, "License", ClausePresent, ClauseMissing},
+		{"MIT", string(mit), "License", ClauseAbsent, ClauseAbsent},
+		{"reference", "SPDX-License-Identifier: Apache-2.0", "Reference", ClauseUnknown, ClauseUnknown},
+	}
+	for _, tt := range tests {
+		var m *Match
+		for _, o := range c.Match([]byte(tt.in)) {
+			if o.MatchType == tt.matchType {
+				m = o
+			}
+		}
+		if m == nil {
+			t.Errorf("%s: Match() found no %s match", tt.description, tt.matchType)
+			continue
+		}
+		if m.PatentGrant != tt.grant || m.PatentRetaliation != tt.retaliation {
+			t.Errorf("%s: patent grant %v, retaliation %v; want %v, %v", tt.description, m.PatentGrant, m.PatentRetaliation, tt.grant, tt.retaliation)
+		}
+	}
+}
+
+func TestClauseStatusText(t *testing.T) {
+	for _, s := range []ClauseStatus{ClauseUnknown, ClauseAbsent, ClausePresent, ClauseMissing} {
+		text, err := s.MarshalText()
+		if err != nil {
+			t.Fatalf("MarshalText(%v) failed: %v", s, err)
+		}
+		var got ClauseStatus
+		if err := got.UnmarshalText(text); err != nil || got != s {
+			t.Errorf("UnmarshalText(%s) = %v, %v, want %v", text, got, err, s)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Many projects don't repeat their license in the header of each source
+// file, but point the reader to a license file, as in "See the LICENSE file
+// in the project root" or "Use of this source code is governed by a
+// BSD-style license that can be found in the LICENSE file". Such a file has
+// no license text of its own to match, and is associated with the
+// classification of the file it points to instead.
+
+// FilePointer is a sentence of a file that refers the reader to another file
+// for its license.
+type FilePointer struct {
+	// Path is the path of the referenced file as written, such as "LICENSE"
+	// or "docs/COPYING.txt".
+	Path string
+	// Root is true if the pointer places the file at the root of the
+	// project, as in "the LICENSE file in the top-level directory".
+	Root bool
+	// StartLine and EndLine are the lines of the pointer.
+	StartLine, EndLine int
+}
+
+// pointerPattern recognizes a pointer to a license file. The submatches are
+// the word "file" before the name, the name and the word "file" after it.
+var pointerPattern = regexp.MustCompile(`(?i)\b(?:see|refer\s+to|consult|read|(?:found|contained|included|available|located|described|specified|provided|stated|set\s+(?:forth|out))\s+in|terms\s+(?:of|in))\s+(?:the\s+)?(?:(?:accompanying|included|bundled|attached|enclosed|separate|top[- ]level|root|project|repository|distributed)\s+)*(file\s+)?` +
+	"[`\"'(\\[]?" +
+	`((?:[\w.-]+/)*(?:licen[cs]e|copying|copyright|notice|unlicense)(?:[-.][\w.-]*\w)?)` +
+	"[`\"')\\]]?" +
+	`(\s+file)?`)
+
+// pointerRoot recognizes the mention of the root of the project in or just
+// after a pointer.
+var pointerRoot = regexp.MustCompile(`(?i)^[^.]{0,60}?\b(?:root|top[- ]level)\b`)
+
+// commentMarker matches the comment markers starting a line, which are
+// blanked so that pointers can span the lines of a comment.
+var commentMarker = regexp.MustCompile(`(?m)^[ \t]*(?://+|#+|/\*+|\*+|--|;+|%+|!|')`)
+
+// FindFilePointers returns the pointers to license files in the content.
+// A name such as "License", which is as likely to refer to the license text
+// itself, is only taken as a file if it's followed or preceded by the word
+// "file", or has an extension or a directory.
+func FindFilePointers(in []byte) []FilePointer {
+	text := commentMarker.ReplaceAllStringFunc(string(in), func(s string) string {
+		return strings.Repeat(" ", len(s))
+	})
+	var out []FilePointer
+	seen := make(map[string]bool)
+	for _, m := range pointerPattern.FindAllStringSubmatchIndex(text, -1) {
+		name := text[m[4]:m[5]]
+		isFile := m[2] != -1 || m[6] != -1 || strings.ContainsAny(name, "./-")
+		if !isFile && name != strings.ToUpper(name) {
+			continue
+		}
+		rest := text[m[5]:]
+		if len(rest) > 80 {
+			rest = rest[:80]
+		}
+		p := FilePointer{
+			Path:      name,
+			Root:      pointerRoot.MatchString(text[m[0]:m[4]] + rest),
+			StartLine: 1 + strings.Count(text[:m[0]], "\n"),
+			EndLine:   1 + strings.Count(text[:m[1]], "\n"),
+		}
+		if k := fmt.Sprintf("%s %v", p.Path, p.Root); !seen[k] {
+			seen[k] = true
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// ResolveFilePointer returns the path in fsys of the file a pointer of the
+// file at the supplied path refers to. The referenced file is looked for in
+// the directory of the file and then in its parents, or at the root of fsys
+// first if the pointer places it there, so fsys should be rooted at the
+// project. Names are compared regardless of case, and a name without an
+// extension also matches documents such as LICENSE.md.
+func ResolveFilePointer(fsys fs.FS, file string, p FilePointer) (string, bool) {
+	var dirs []string
+	if p.Root {
+		dirs = append(dirs, ".")
+	}
+	for d := path.Dir(file); ; d = path.Dir(d) {
+		dirs = append(dirs, d)
+		if d == "." || d == "/" {
+			break
+		}
+	}
+	for _, d := range dirs {
+		if f, ok := findPointedFile(fsys, path.Join(d, path.Dir(p.Path)), path.Base(p.Path)); ok {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// findPointedFile returns the file of the directory with the supplied name,
+// or failing that a document with it as stem.
+func findPointedFile(fsys fs.FS, dir, name string) (string, bool) {
+	if !fs.ValidPath(dir) {
+		return "", false
+	}
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return "", false
+	}
+	found := ""
+	for _, e := range entries {
+		n := e.Name()
+		if e.IsDir() {
+			continue
+		}
+		if strings.EqualFold(n, name) {
+			return path.Join(dir, n), true
+		}
+		ext := path.Ext(n)
+		if found == "" && path.Ext(name) == "" && documentExtensions[strings.ToLower(ext)] && strings.EqualFold(strings.TrimSuffix(n, ext), name) {
+			found = path.Join(dir, n)
+		}
+	}
+	return found, found != ""
+}
+
+// Association is the classification of a license file a file points to.
+type Association struct {
+	Pointer FilePointer
+	// LicenseFile is the path in the file system of the file pointed to, or
+	// empty if it couldn't be found.
+	LicenseFile string
+	// Matches are the matches of the license file.
+	Matches Matches
+}
+
+// Associate finds the pointers to license files in the content of the file
+// at the supplied path of fsys, and matches the files they point to. Files
+// that only point to a license file, and so have no matches of their own,
+// can be reported with the licenses of the files instead. The association
+// of a pointer to a missing file has no license file, and each license file
+// is matched once however many pointers refer to it. A license file
+// mentioning its own name isn't associated with itself.
+func (c *Classifier) Associate(fsys fs.FS, file string, in []byte) ([]*Association, error) {
+	var out []*Association
+	matched := make(map[string]Matches)
+	for _, p := range FindFilePointers(in) {
+		a := &Association{Pointer: p}
+		f, ok := ResolveFilePointer(fsys, file, p)
+		if ok && f == path.Clean(file) {
+			// A license file mentioning its own name isn't a pointer.
+			continue
+		}
+		if ok {
+			ms, done := matched[f]
+			if !done {
+				b, err := fs.ReadFile(fsys, f)
+				if err != nil {
+					return nil, fmt.Errorf("classifier couldn't read %s, pointed to by %s: %w", f, file, err)
+				}
+				ms = c.Match(b)
+				matched[f] = ms
+			}
+			a.LicenseFile, a.Matches = f, ms
+		}
+		out = append(out, a)
+	}
+	return out, nil
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFindFilePointers(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []FilePointer
+	}{
+		{
+			name: "go header",
+			in: `// Copyright 2021 The Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.`,
+			want: []FilePointer{{Path: "LICENSE", StartLine: 3, EndLine: 3}},
+		},
+		{
+			name: "project root",
+			in:   "# See LICENSE in the project root for license information.",
+			want: []FilePointer{{Path: "LICENSE", Root: true, StartLine: 1, EndLine: 1}},
+		},
+		{
+			name: "wrapped across comment lines",
+			in: `/*
+ * Licensed under the terms found in the
+ * COPYING.txt file of the top-level directory.
+ */`,
+			want: []FilePointer{{Path: "COPYING.txt", Root: true, StartLine: 2, EndLine: 3}},
+		},
+		{
+			name: "directory",
+			in:   "Please refer to docs/License.md for details.",
+			want: []FilePointer{{Path: "docs/License.md", StartLine: 1, EndLine: 1}},
+		},
+		{
+			name: "license text",
+			in: `Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS.
+See the License for the specific language governing permissions and
+limitations under the License.`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := FindFilePointers([]byte(test.in)); !cmp.Equal(got, test.want) {
+				t.Errorf("FindFilePointers() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestResolveFilePointer(t *testing.T) {
+	fsys := fstest.MapFS{
+		"LICENSE":                {Data: []byte("root")},
+		"lib/LICENSE.md":         {Data: []byte("lib")},
+		"lib/src/main.go":        {Data: []byte("code")},
+		"lib/docs/COPYING":       {Data: []byte("docs")},
+		"tools/cmd/cmd.go":       {Data: []byte("code")},
+		"tools/cmd/license/x.go": {Data: []byte("code")},
+	}
+	tests := []struct {
+		file string
+		p    FilePointer
+		want string
+	}{
+		{file: "lib/src/main.go", p: FilePointer{Path: "LICENSE"}, want: "lib/LICENSE.md"},
+		{file: "lib/src/main.go", p: FilePointer{Path: "LICENSE", Root: true}, want: "LICENSE"},
+		{file: "lib/src/main.go", p: FilePointer{Path: "docs/copying"}, want: "lib/docs/COPYING"},
+		{file: "tools/cmd/cmd.go", p: FilePointer{Path: "license"}, want: "LICENSE"},
+		{file: "tools/cmd/cmd.go", p: FilePointer{Path: "NOTICE"}},
+		{file: "tools/cmd/cmd.go", p: FilePointer{Path: "../../../LICENSE"}},
+	}
+	for _, test := range tests {
+		got, ok := ResolveFilePointer(fsys, test.file, test.p)
+		if got != test.want || ok != (test.want != "") {
+			t.Errorf("ResolveFilePointer(%s, %+v) = %q, %v, want %q", test.file, test.p, got, ok, test.want)
+		}
+	}
+}
+
+func TestAssociate(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	mit, err := ioutil.ReadFile("licenses/MIT.txt")
+	if err != nil {
+		t.Fatalf("couldn't read the MIT license: %v", err)
+	}
+	header := []byte("// See the LICENSE file at the root of the repository.\n// For the authors, see the NOTICE file.\n")
+	fsys := fstest.MapFS{
+		"LICENSE":        {Data: mit},
+		"pkg/a/a.go":     {Data: header},
+		"pkg/b/b.go":     {Data: header},
+		"pkg/a/LICENSES": {Data: []byte("not a license")},
+	}
+
+	got, err := c.Associate(fsys, "pkg/a/a.go", header)
+	if err != nil {
+		t.Fatalf("Associate() failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Associate() = %d associations, want 2", len(got))
+	}
+	if a := got[0]; a.LicenseFile != "LICENSE" || len(a.Matches) != 1 || a.Matches[0].Name != "MIT" || !a.Pointer.Root {
+		t.Errorf("Associate() = %+v, want the MIT license of LICENSE", a)
+	}
+	if a := got[1]; a.LicenseFile != "" || a.Matches != nil || a.Pointer.Path != "NOTICE" {
+		t.Errorf("Associate() = %+v, want the pointer to a missing NOTICE", a)
+	}
+
+	got, err = c.Associate(fsys, "LICENSE", []byte("See the LICENSE file."))
+	if err != nil || len(got) != 0 {
+		t.Errorf("Associate() of a license file pointing to itself = %+v, %v, want none", got, err)
+	}
+}
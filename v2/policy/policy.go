@@ -0,0 +1,339 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package policy decides whether the licenses found by the classifier are
+// acceptable, so that license compliance can be enforced in continuous
+// integration.
+//
+// A policy lists the licenses that are allowed, restricted, which need review
+// but don't fail a check, and forbidden. Licenses are listed by identifier,
+// such as "Apache-2.0", or any name classifier.Resolve knows, by family,
+// such as "GPL" for every version of the GPL, by glob, such as "CC-BY-*", or
+// by category, such as "category:reciprocal". Exceptions allow further
+// licenses in the files matching a path pattern, such as vendored code that
+// has been reviewed.
+//
+// Policies are usually written as JSON:
+//
+//	{
+//	  "allowed": ["category:notice", "category:permissive", "MPL-2.0"],
+//	  "restricted": ["category:reciprocal"],
+//	  "forbidden": ["category:restricted", "category:forbidden"],
+//	  "unlisted": "restricted",
+//	  "exceptions": [
+//	    {"path": "third_party/gcc/**", "licenses": ["GPL-3.0"], "reason": "build tooling only"}
+//	  ]
+//	}
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+// Action is the decision of a policy for a license.
+type Action int
+
+// Actions, in increasing order of severity.
+const (
+	// Allow accepts the license.
+	Allow Action = iota
+	// Restrict accepts the license, but flags it for review.
+	Restrict
+	// Forbid rejects the license.
+	Forbid
+)
+
+var actionNames = map[Action]string{
+	Allow:    "allowed",
+	Restrict: "restricted",
+	Forbid:   "forbidden",
+}
+
+func (a Action) String() string {
+	if n, ok := actionNames[a]; ok {
+		return n
+	}
+	return fmt.Sprintf("Action(%d)", int(a))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (a Action) MarshalText() ([]byte, error) {
+	if n, ok := actionNames[a]; ok {
+		return []byte(n), nil
+	}
+	return nil, fmt.Errorf("unknown action %d", int(a))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (a *Action) UnmarshalText(text []byte) error {
+	for act, n := range actionNames {
+		if n == string(text) {
+			*a = act
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown action %q", text)
+}
+
+// Exception allows licenses in the files matching a path pattern.
+type Exception struct {
+	// Path is a slash-separated path pattern in the syntax of path.Match.
+	// A pattern ending in "/**" matches every file below the directory.
+	Path string `json:"path"`
+	// Licenses are the licenses allowed, listed as in a Policy. An empty
+	// list allows every license.
+	Licenses []string `json:"licenses,omitempty"`
+	// Reason explains why the exception was granted.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Policy lists the licenses that are allowed, restricted and forbidden.
+type Policy struct {
+	Allowed    []string `json:"allowed,omitempty"`
+	Restricted []string `json:"restricted,omitempty"`
+	Forbidden  []string `json:"forbidden,omitempty"`
+	// Unlisted is the action for licenses the policy doesn't list, which is
+	// Allow for the zero Policy.
+	Unlisted   Action       `json:"unlisted"`
+	Exceptions []*Exception `json:"exceptions,omitempty"`
+}
+
+// Parse reads a policy written as JSON. Licenses the policy doesn't list are
+// restricted unless it says otherwise.
+func Parse(data []byte) (*Policy, error) {
+	p := &Policy{Unlisted: Restrict}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("malformed policy: %v", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate checks the license and path patterns of the policy.
+func (p *Policy) Validate() error {
+	check := func(list string, patterns []string) error {
+		for _, l := range patterns {
+			if l == "" || strings.TrimPrefix(l, "category:") == "" {
+				return fmt.Errorf("policy: empty license in %s", list)
+			}
+			if _, err := path.Match(l, ""); err != nil {
+				return fmt.Errorf("policy: malformed license pattern %q in %s", l, list)
+			}
+		}
+		return nil
+	}
+	if err := check("allowed", p.Allowed); err != nil {
+		return err
+	}
+	if err := check("restricted", p.Restricted); err != nil {
+		return err
+	}
+	if err := check("forbidden", p.Forbidden); err != nil {
+		return err
+	}
+	for _, e := range p.Exceptions {
+		if _, err := path.Match(strings.TrimSuffix(e.Path, "/**"), ""); err != nil || e.Path == "" {
+			return fmt.Errorf("policy: malformed exception path %q", e.Path)
+		}
+		if err := check("exception "+e.Path, e.Licenses); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Decision is the action of a policy for a license found in a file.
+type Decision struct {
+	Path    string
+	License string
+	Action  Action
+	// Reason explains the action.
+	Reason string
+}
+
+func (d *Decision) String() string {
+	return fmt.Sprintf("%s: %s is %s: %s", d.Path, d.License, d.Action, d.Reason)
+}
+
+// Specificity of the ways of listing a license. When a license is listed
+// several times, the most specific listing decides, and the most severe of
+// equally specific listings.
+const (
+	byCategory = iota + 1
+	byPattern
+	byIdentifier
+)
+
+// listed returns how specifically the license is listed in patterns, or 0
+// if it isn't, and the pattern that lists it.
+func listed(license string, patterns []string) (int, string) {
+	best, which := 0, ""
+	for _, p := range patterns {
+		s := 0
+		switch {
+		case p == license || sameLicense(p, license):
+			s = byIdentifier
+		case strings.HasPrefix(p, "category:"):
+			if classifier.LicenseCategory(license) == strings.TrimPrefix(p, "category:") {
+				s = byCategory
+			}
+		case inFamily(license, p):
+			s = byPattern
+		default:
+			if ok, _ := path.Match(p, license); ok {
+				s = byPattern
+			}
+		}
+		if s > best {
+			best, which = s, p
+		}
+	}
+	return best, which
+}
+
+// inFamily reports whether the license is a version of family, such as
+// GPL-2.0 and GPL-3.0 of GPL. A version starts with a digit, so CC-BY-NC-4.0
+// isn't in the CC-BY family.
+func inFamily(license, family string) bool {
+	v := strings.TrimPrefix(license, family+"-")
+	return len(v) < len(license) && v != "" && v[0] >= '0' && v[0] <= '9'
+}
+
+// sameLicense reports whether a license listed by a policy by another of its
+// names, such as "GPLv2+" or "Apache 2", is the license found by the
+// classifier. The classifier doesn't distinguish the -only and -or-later
+// variants of the GNU licenses, so neither does the comparison.
+func sameLicense(listed, license string) bool {
+	a, ok := classifier.Resolve(listed)
+	if !ok {
+		return false
+	}
+	b, ok := classifier.Resolve(license)
+	return ok && baseID(a) == baseID(b)
+}
+
+// baseID strips the suffixes of SPDX identifiers the classifier doesn't
+// distinguish.
+func baseID(id string) string {
+	for _, suffix := range []string{"-only", "-or-later", "+"} {
+		id = strings.TrimSuffix(id, suffix)
+	}
+	return id
+}
+
+// matchPath returns true if the slash-separated file path matches an
+// exception path pattern.
+func matchPath(pattern, file string) bool {
+	file = path.Clean(strings.TrimPrefix(file, "./"))
+	if dir := strings.TrimSuffix(pattern, "/**"); dir != pattern {
+		dir = path.Clean(strings.TrimPrefix(dir, "./"))
+		return strings.HasPrefix(file, dir+"/")
+	}
+	ok, _ := path.Match(path.Clean(strings.TrimPrefix(pattern, "./")), file)
+	return ok
+}
+
+// Decide returns the decision of the policy for a license found in the file
+// with the supplied slash-separated path.
+func (p *Policy) Decide(file, license string) *Decision {
+	d := &Decision{Path: file, License: license}
+	for _, e := range p.Exceptions {
+		if !matchPath(e.Path, file) {
+			continue
+		}
+		if s, _ := listed(license, e.Licenses); s > 0 || len(e.Licenses) == 0 {
+			d.Action = Allow
+			d.Reason = fmt.Sprintf("allowed by the exception for %s", e.Path)
+			if e.Reason != "" {
+				d.Reason += ": " + e.Reason
+			}
+			return d
+		}
+	}
+
+	best := 0
+	for _, l := range []struct {
+		action   Action
+		patterns []string
+	}{
+		{Allow, p.Allowed},
+		{Restrict, p.Restricted},
+		{Forbid, p.Forbidden},
+	} {
+		s, pattern := listed(license, l.patterns)
+		if s == 0 || s < best {
+			continue
+		}
+		// Later lists are more severe, so they win ties.
+		best = s
+		d.Action = l.action
+		if s == byIdentifier {
+			d.Reason = fmt.Sprintf("%s is %s", license, l.action)
+		} else {
+			d.Reason = fmt.Sprintf("%s licenses are %s", pattern, l.action)
+		}
+	}
+	if best == 0 {
+		d.Action = p.Unlisted
+		d.Reason = fmt.Sprintf("%s isn't listed by the policy", license)
+	}
+	return d
+}
+
+// Report holds the decisions of a policy for a set of classification
+// results.
+type Report struct {
+	Decisions []*Decision
+}
+
+// Evaluate adds the decisions of the policy for the matches found in a file
+// to the report. References to a license by name are decided like license
+// texts, since they usually declare the license of the file. A license the
+// file offers as a choice with others is decided like the most acceptable of
+// them, since the recipient can pick that one.
+func (p *Policy) Evaluate(r *Report, file string, matches classifier.Matches) {
+	for _, m := range matches {
+		d := p.Decide(file, m.Name)
+		for _, alt := range m.Choice {
+			if a := p.Decide(file, alt); a.Action < d.Action {
+				d.Action = a.Action
+				d.Reason = fmt.Sprintf("offered as a choice with %s: %s", alt, a.Reason)
+			}
+		}
+		r.Decisions = append(r.Decisions, d)
+	}
+}
+
+// Pass returns true if no license was forbidden.
+func (r *Report) Pass() bool {
+	return len(r.Filter(Forbid)) == 0
+}
+
+// Filter returns the decisions with the supplied action.
+func (r *Report) Filter(a Action) []*Decision {
+	var out []*Decision
+	for _, d := range r.Decisions {
+		if d.Action == a {
+			out = append(out, d)
+		}
+	}
+	return out
+}
@@ -0,0 +1,190 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"testing"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+const testPolicy = `{
+  "allowed": ["category:notice", "MIT", "GPL-2.0-with-classpath-exception"],
+  "restricted": ["category:reciprocal", "CC-BY-*"],
+  "forbidden": ["GPL", "AGPL", "Apache-1.0"],
+  "exceptions": [
+    {"path": "third_party/gcc/**", "licenses": ["GPL-3.0"], "reason": "build tooling only"},
+    {"path": "docs/*.md"}
+  ]
+}`
+
+func TestDecide(t *testing.T) {
+	p, err := Parse([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	tests := []struct {
+		file, license string
+		want          Action
+		reason        string
+	}{
+		{file: "LICENSE", license: "MIT", want: Allow, reason: "MIT is allowed"},
+		{file: "LICENSE", license: "BSD-3-Clause", want: Allow, reason: "category:notice licenses are allowed"},
+		{file: "LICENSE", license: "MPL-2.0", want: Restrict, reason: "category:reciprocal licenses are restricted"},
+		{file: "LICENSE", license: "CC-BY-4.0", want: Restrict, reason: "CC-BY-* licenses are restricted"},
+		{file: "LICENSE", license: "GPL-3.0", want: Forbid, reason: "GPL licenses are forbidden"},
+		{file: "LICENSE", license: "LGPL-2.1", want: Restrict, reason: "LGPL-2.1 isn't listed by the policy"},
+		// An identifier is more specific than a family or category.
+		{file: "LICENSE", license: "GPL-2.0-with-classpath-exception", want: Allow, reason: "GPL-2.0-with-classpath-exception is allowed"},
+		{file: "LICENSE", license: "Apache-1.0", want: Forbid, reason: "Apache-1.0 is forbidden"},
+		{file: "third_party/gcc/COPYING", license: "GPL-3.0", want: Allow, reason: "allowed by the exception for third_party/gcc/**: build tooling only"},
+		{file: "./third_party/gcc/lib/COPYING", license: "GPL-3.0", want: Allow, reason: "allowed by the exception for third_party/gcc/**: build tooling only"},
+		{file: "third_party/gcc/COPYING", license: "AGPL-3.0", want: Forbid, reason: "AGPL licenses are forbidden"},
+		{file: "third_party/gccgo/COPYING", license: "GPL-3.0", want: Forbid, reason: "GPL licenses are forbidden"},
+		{file: "docs/index.md", license: "AGPL-3.0", want: Allow, reason: "allowed by the exception for docs/*.md"},
+		{file: "docs/api/index.md", license: "AGPL-3.0", want: Forbid, reason: "AGPL licenses are forbidden"},
+	}
+	for _, tt := range tests {
+		d := p.Decide(tt.file, tt.license)
+		if d.Action != tt.want || d.Reason != tt.reason {
+			t.Errorf("Decide(%q, %q) = %v, %q, want %v, %q", tt.file, tt.license, d.Action, d.Reason, tt.want, tt.reason)
+		}
+	}
+}
+
+func TestDecideFamilies(t *testing.T) {
+	p := &Policy{
+		Allowed:   []string{"CC-BY", "BSD"},
+		Forbidden: []string{"CC-BY-NC-*"},
+		Unlisted:  Restrict,
+	}
+	for _, tt := range []struct {
+		license string
+		want    Action
+	}{
+		{"CC-BY-4.0", Allow},
+		{"CC-BY-3.0-US", Allow},
+		{"BSD-3-Clause", Allow},
+		{"CC-BY-NC-4.0", Forbid},
+		{"CC-BY-SA-4.0", Restrict},
+		{"CC-BY-ND-4.0", Restrict},
+		{"BSD-Protection", Restrict},
+		{"CC-BY", Allow},
+	} {
+		if d := p.Decide("LICENSE", tt.license); d.Action != tt.want {
+			t.Errorf("Decide(%q) = %v (%s), want %v", tt.license, d.Action, d.Reason, tt.want)
+		}
+	}
+}
+
+func TestDecideResolvesNames(t *testing.T) {
+	p := &Policy{
+		Allowed:   []string{"Apache 2", "Simplified BSD"},
+		Forbidden: []string{"GPLv2+", "lgpl-2.1-only"},
+		Unlisted:  Restrict,
+	}
+	for _, tt := range []struct {
+		license string
+		want    Action
+	}{
+		{license: "Apache-2.0", want: Allow},
+		{license: "BSD-2-Clause", want: Allow},
+		{license: "BSD-3-Clause", want: Restrict},
+		{license: "GPL-2.0", want: Forbid},
+		{license: "LGPL-2.1", want: Forbid},
+		{license: "GPL-3.0", want: Restrict},
+	} {
+		d := p.Decide("LICENSE", tt.license)
+		if d.Action != tt.want {
+			t.Errorf("Decide(%q) = %v (%s), want %v", tt.license, d.Action, d.Reason, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	p, err := Parse([]byte(`{"allowed": ["MIT"], "unlisted": "forbidden"}`))
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	if d := p.Decide("LICENSE", "ISC"); d.Action != Forbid {
+		t.Errorf("Decide() of an unlisted license = %v, want forbidden", d.Action)
+	}
+
+	tests := []string{
+		`{"allowed": "MIT"}`,
+		`{"unlisted": "maybe"}`,
+		`{"forbidden": [""]}`,
+		`{"forbidden": ["category:"]}`,
+		`{"restricted": ["GPL-["]}`,
+		`{"exceptions": [{"licenses": ["MIT"]}]}`,
+		`{"exceptions": [{"path": "a/[", "licenses": ["MIT"]}]}`,
+		`{"exceptions": [{"path": "vendor/**", "licenses": ["["]}]}`,
+	}
+	for _, tt := range tests {
+		if _, err := Parse([]byte(tt)); err == nil {
+			t.Errorf("Parse(%s) succeeded, want error", tt)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	p, err := Parse([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	var r Report
+	p.Evaluate(&r, "LICENSE", classifier.Matches{{Name: "MIT"}, {Name: "MPL-2.0"}})
+	if !r.Pass() || len(r.Filter(Restrict)) != 1 {
+		t.Errorf("Evaluate() = %v, want a pass with one restricted license", r.Decisions)
+	}
+	p.Evaluate(&r, "vendor/LICENSE", classifier.Matches{{Name: "GPL-2.0"}})
+	if r.Pass() || len(r.Decisions) != 3 {
+		t.Errorf("Evaluate() = %v, want a failure", r.Decisions)
+	}
+	if f := r.Filter(Forbid); len(f) != 1 || f[0].Path != "vendor/LICENSE" {
+		t.Errorf("Filter(Forbid) = %v, want the GPL-2.0 license in vendor/LICENSE", f)
+	}
+}
+
+func TestEvaluateChoice(t *testing.T) {
+	p, err := Parse([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	var r Report
+	p.Evaluate(&r, "README", classifier.Matches{
+		{Name: "GPL-2.0", Choice: []string{"MIT"}},
+		{Name: "MIT", Choice: []string{"GPL-2.0"}},
+	})
+	if !r.Pass() || len(r.Filter(Allow)) != 2 {
+		t.Errorf("Evaluate() = %v, want both licenses of the choice allowed", r.Decisions)
+	}
+}
+
+func TestActionText(t *testing.T) {
+	for _, a := range []Action{Allow, Restrict, Forbid} {
+		text, err := a.MarshalText()
+		if err != nil {
+			t.Fatalf("MarshalText(%v) failed: %v", a, err)
+		}
+		var got Action
+		if err := got.UnmarshalText(text); err != nil || got != a {
+			t.Errorf("UnmarshalText(%s) = %v, %v, want %v", text, got, err, a)
+		}
+	}
+	if _, err := Action(7).MarshalText(); err == nil {
+		t.Error("MarshalText() of an unknown action succeeded, want error")
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import "sync"
+
+// Matching a document allocates buffers for every candidate it is diffed
+// against and every window it is split into, which are discarded as soon as
+// the candidate is scored. The pools below let the goroutines of a scan reuse
+// them instead, which keeps the garbage collector out of large scans. Only
+// the buffers of targets are pooled: the corpus keeps its own for as long as
+// the classifier is used.
+var (
+	// runePool holds the rune encodings of the tokens given to the diff.
+	runePool = sync.Pool{New: func() interface{} { return new([]rune) }}
+	// textPool holds the text assembled while locating a diff.
+	textPool = sync.Pool{New: func() interface{} { return new([]byte) }}
+	// tokenPool holds the tokens of the indexed targets.
+	tokenPool = sync.Pool{New: func() interface{} { return new([]indexedToken) }}
+	// checksumPool holds the checksums of the searchsets of targets.
+	checksumPool = sync.Pool{New: func() interface{} { return new([]uint32) }}
+)
+
+// maxPooled is the capacity of the largest buffer returned to a pool, so
+// that a single huge document doesn't pin its buffers for the rest of a scan.
+const maxPooled = 1 << 16
+
+// getRunes returns an empty rune slice with room for n runes.
+func getRunes(n int) *[]rune {
+	p := runePool.Get().(*[]rune)
+	if cap(*p) < n {
+		*p = make([]rune, 0, n)
+	}
+	*p = (*p)[:0]
+	return p
+}
+
+func putRunes(p *[]rune) {
+	if cap(*p) <= maxPooled {
+		runePool.Put(p)
+	}
+}
+
+// getTokens returns an empty token slice with room for n tokens.
+func getTokens(n int) []indexedToken {
+	p := tokenPool.Get().(*[]indexedToken)
+	if cap(*p) < n {
+		return make([]indexedToken, 0, n)
+	}
+	return (*p)[:0]
+}
+
+func putTokens(toks []indexedToken) {
+	if cap(toks) <= maxPooled {
+		tokenPool.Put(&toks)
+	}
+}
+
+// release returns the buffers of the searchset of a target to their pools.
+// The searchset must not be used afterwards.
+func (s *searchSet) release() {
+	if cs := s.Checksums; cap(cs) <= maxPooled {
+		checksumPool.Put(&cs)
+	}
+	s.Checksums = nil
+}
+
+// release returns the buffers of a target indexed by matchDocument to their
+// pools. The document must not be used afterwards.
+func (d *indexedDocument) release() {
+	if d.s != nil {
+		d.s.release()
+		d.s = nil
+	}
+	putTokens(d.Tokens)
+	d.Tokens = nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTargetSearchSetReuse(t *testing.T) {
+	c := NewClassifier(defaultThreshold)
+	c.AddContent("Widget-1.0", []byte(versionedLicense))
+	for _, text := range []string{gadgetLicense, versionedLicense, "a short text", gadgetLicense} {
+		id := c.createTargetIndexedDocument([]byte(text))
+		want := newSearchSet(id, c.q)
+		id.generateTargetSearchSet(c.q)
+		if !reflect.DeepEqual(id.s.Tokens, want.Tokens) || id.s.q != want.q || len(id.s.Checksums) != len(want.Checksums) {
+			t.Errorf("target searchset of %q differs from newSearchSet()", text)
+		}
+		for i, cs := range id.s.Checksums {
+			if cs != want.Checksums[i] {
+				t.Errorf("checksum %d of target searchset of %q = %d, want %d", i, text, cs, want.Checksums[i])
+			}
+		}
+		id.release()
+	}
+}
+
+func TestDiffRangeReuse(t *testing.T) {
+	c := NewClassifier(defaultThreshold)
+	c.AddContent("Widget-1.0", []byte(versionedLicense))
+	known := c.docs["Widget-1.0"]
+	for _, text := range []string{"prefix " + versionedLicense, versionedLicense + " suffix"} {
+		id := c.createTargetIndexedDocument([]byte(text))
+		diffs := docDiff("Widget-1.0", id, 0, id.size(), known, 0, known.size())
+		start, end := diffRange(known.norm, diffs)
+		if got := targetLength(diffs[start:end]); got != known.size() {
+			t.Errorf("diffRange() of %q covers %d tokens, want %d", text, got, known.size())
+		}
+	}
+}
+
+func benchmarkDiffInputs(b *testing.B) (*indexedDocument, *indexedDocument) {
+	c := NewClassifier(defaultThreshold)
+	in, err := ioutil.ReadFile(filepath.Join(baseLicenses, "Apache-2.0.txt"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	c.AddContent("Apache-2.0", in)
+	target := c.createTargetIndexedDocument(append([]byte("Copyright 2020 Example Inc.\n\n"), in...))
+	return c.docs["Apache-2.0"], target
+}
+
+func BenchmarkDocDiff(b *testing.B) {
+	known, target := benchmarkDiffInputs(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		diffs := docDiff("Apache-2.0", target, 0, target.size(), known, 0, known.size())
+		diffRange(known.norm, diffs)
+	}
+}
+
+func BenchmarkTargetSearchSet(b *testing.B) {
+	_, target := benchmarkDiffInputs(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		target.generateTargetSearchSet(computeQ(defaultThreshold))
+		target.s.release()
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+// This file contains a prefilter discarding the corpus entries that can't
+// match a target before their token frequencies are compared. The comparison
+// walks the frequency tables of every entry of the corpus for every target,
+// and most targets, such as source files without license text, share too few
+// words with most entries to match them.
+//
+// An entry matches a target at the threshold only if enough of its distinct
+// tokens occur in the target, so the entry is discarded when too few of them
+// are found in a bitset of the tokens of the target. The bitset is the Bloom
+// filter of token IDs with a single, exact, hash function: since the IDs are
+// dense it has no false positives, and since it has no false negatives the
+// prefilter never discards an entry the frequency comparison would keep.
+
+// tokenSet is a bitset of token IDs.
+type tokenSet []uint64
+
+// newTokenSet returns the set of the tokens of the document.
+func newTokenSet(d *indexedDocument) tokenSet {
+	s := make(tokenSet, len(d.dict.words)/64+1)
+	for _, t := range d.Tokens {
+		s[t.ID/64] |= 1 << (uint(t.ID) % 64)
+	}
+	return s
+}
+
+func (s tokenSet) has(id tokenID) bool {
+	return int(id/64) < len(s) && s[id/64]&(1<<(uint(id)%64)) != 0
+}
+
+// distinctTokens returns the distinct tokens of the document.
+func (d *indexedDocument) distinctTokens() []tokenID {
+	ids := make([]tokenID, 0, len(d.f.counts))
+	for id := range d.f.counts {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// plausible returns false if too few of the distinct tokens of the known
+// document occur in the set of tokens of a target for it to match at the
+// search threshold of the classifier.
+func (c *Classifier) plausible(present tokenSet, known *indexedDocument) bool {
+	if c.noPrefilter || len(known.distinct) == 0 {
+		return true
+	}
+	// tokenSimilarity counts the tokens of the known document occurring often
+	// enough in the target, out of its distinct tokens.
+	need := c.searchThreshold() * float64(len(known.distinct))
+	found, left := 0, len(known.distinct)
+	for _, id := range known.distinct {
+		left--
+		if present.has(id) {
+			found++
+			if float64(found) >= need {
+				return true
+			}
+		} else if float64(found+left) < need {
+			return false
+		}
+	}
+	return float64(found) >= need
+}
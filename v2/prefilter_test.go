@@ -0,0 +1,96 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPrefilterKeepsMatches(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	files, err := filepath.Glob(filepath.Join("scenarios", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	licenses, err := filepath.Glob(filepath.Join("licenses", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files = append(files, licenses[:20]...)
+	files = append(files, "classifier.go")
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := func() Matches {
+			c.noPrefilter = true
+			defer func() { c.noPrefilter = false }()
+			return c.matchContent(b, nil, nil)
+		}()
+		if got := c.matchContent(b, nil, nil); !cmp.Equal(got, want) {
+			t.Errorf("%s: prefiltered matches differ: %s", f, cmp.Diff(want, got))
+		}
+	}
+}
+
+func TestPlausible(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	mit, err := ioutil.ReadFile(filepath.Join("licenses", "MIT.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := c.createTargetIndexedDocument(bytes.Repeat(mit, 2))
+	present := newTokenSet(id)
+	if !c.plausible(present, c.docs["MIT"]) {
+		t.Error("plausible(MIT, MIT) = false, want true")
+	}
+	if c.plausible(present, c.docs["GPL-3.0"]) {
+		t.Error("plausible(MIT, GPL-3.0) = true, want false")
+	}
+}
+
+func TestTokenSet(t *testing.T) {
+	c := NewClassifier(.8)
+	c.AddContent("a", []byte("alpha beta gamma"))
+	id := c.createTargetIndexedDocument([]byte("alpha gamma delta"))
+	s := newTokenSet(id)
+	for _, tc := range []struct {
+		word string
+		want bool
+	}{
+		{"alpha", true},
+		{"beta", false},
+		{"gamma", true},
+	} {
+		if got := s.has(c.dict.getIndex(tc.word)); got != tc.want {
+			t.Errorf("has(%q) = %v, want %v", tc.word, got, tc.want)
+		}
+	}
+	if s.has(tokenID(1 << 20)) {
+		t.Error("has(out of range) = true, want false")
+	}
+}
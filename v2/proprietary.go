@@ -0,0 +1,118 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"regexp"
+	"strings"
+)
+
+// This file contains routines to recognize the terms of commercial end-user
+// license agreements and other proprietary notices. No corpus entry can match
+// them, since every vendor words its terms differently, so they are
+// recognized by the restrictions they typically state.
+
+const proprietaryType = "Proprietary"
+
+// Proprietary is the name of the matches of proprietary terms. Their
+// MatchType is "Proprietary" and their category CategoryProprietary.
+const Proprietary = "LicenseRef-proprietary"
+
+// proprietaryRule recognizes a phrase typical of proprietary terms in a line
+// of text. A strong phrase is enough to recognize the terms, while other
+// phrases are also found in notices of open source code, so two different
+// ones are needed.
+type proprietaryRule struct {
+	re     *regexp.Regexp
+	strong bool
+}
+
+var proprietaryRules = []proprietaryRule{
+	{regexp.MustCompile(`(?i)\bend[- ]user licen[cs]e agreement\b`), true},
+	{regexp.MustCompile(`(?i)\b(?:proprietary and confidential|confidential and proprietary)\b`), true},
+	{regexp.MustCompile(`(?i)\bunauthori[sz]ed (?:copying|use|reproduction|distribution)\b.*\b(?:strictly )?prohibited\b`), true},
+	{regexp.MustCompile(`(?i)\ball rights reserved\b`), false},
+	{regexp.MustCompile(`(?i)\b(?:may|shall|must) not (?:\w+ ){0,3}?(?:copy|redistribute|distribute|modify|sublicense|sell|rent|lease|decompile|disassemble|reverse[- ]engineer)\b`), false},
+	{regexp.MustCompile(`(?i)\bproprietary\b`), false},
+	{regexp.MustCompile(`(?i)\bconfidential(?:ity)?\b`), false},
+	{regexp.MustCompile(`(?i)\btrade secrets?\b`), false},
+	{regexp.MustCompile(`(?i)\bnon-?transferable\b`), false},
+	{regexp.MustCompile(`(?i)\bcommercial licen[cs]e\b`), false},
+}
+
+// within returns true if the phrase is part of one of the supplied phrases,
+// as "proprietary" is of "proprietary and confidential".
+func within(phrase string, phrases []string) bool {
+	for _, p := range phrases {
+		if strings.Contains(p, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// findProprietary returns a match of the proprietary terms of the supplied
+// content, tokenized as doc, if it has any. The match spans the lines of the
+// phrases that recognized the terms, which it records. It is only looked for
+// in content in which no license was found, since open source licenses
+// mention some of the same restrictions.
+func findProprietary(in []byte, doc *document) Matches {
+	var phrases []string
+	seen := make(map[string]bool)
+	strong := false
+	first, last := 0, 0
+	for i, line := range strings.Split(string(in), "\n") {
+		var found []string
+		for _, r := range proprietaryRules {
+			p := strings.ToLower(r.re.FindString(line))
+			if p == "" || within(p, found) {
+				continue
+			}
+			found = append(found, p)
+			strong = strong || r.strong
+			if first == 0 {
+				first = i + 1
+			}
+			last = i + 1
+			if !seen[p] {
+				seen[p] = true
+				phrases = append(phrases, p)
+			}
+		}
+	}
+	if !strong && len(phrases) < 2 {
+		return nil
+	}
+	m := &Match{
+		Name:       Proprietary,
+		Confidence: 1.0,
+		MatchType:  proprietaryType,
+		Category:   CategoryProprietary,
+		StartLine:  first,
+		EndLine:    last,
+		Phrases:    phrases,
+	}
+	m.StartTokenIndex, m.EndTokenIndex = len(doc.Tokens), len(doc.Tokens)
+	for _, t := range doc.Tokens {
+		if t.Line < first || t.Line > last {
+			continue
+		}
+		if m.StartTokenIndex == len(doc.Tokens) {
+			m.StartTokenIndex = t.Index
+		}
+		m.EndTokenIndex = t.Index
+	}
+	return Matches{m}
+}
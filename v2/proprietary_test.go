@@ -0,0 +1,89 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestProprietary(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	mit, err := ioutil.ReadFile(filepath.Join(baseLicenses, "MIT.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		description string
+		in          string
+		want        []string
+		lines       [2]int
+	}{
+		{
+			description: "end-user license agreement",
+			in: `ACME Widgets
+
+END USER LICENSE AGREEMENT
+
+You may not copy, modify or distribute the Software.
+`,
+			want:  []string{"end user license agreement", "may not copy"},
+			lines: [2]int{3, 5},
+		},
+		{
+			description: "source file notice",
+			in: `// Copyright 2020 ACME Corp. All rights reserved.
+// Proprietary and confidential.
+// Unauthorized copying of this file, via any medium, is strictly prohibited.
+`,
+			want:  []string{"all rights reserved", "proprietary and confidential", "unauthorized copying of this file, via any medium, is strictly prohibited"},
+			lines: [2]int{1, 3},
+		},
+		{
+			description: "copyright notice alone",
+			in:          "// Copyright 2020 ACME Corp. All rights reserved.\n",
+		},
+		{
+			description: "open source license",
+			in:          string(mit) + "\nAll rights reserved. The names of the authors may not be used to endorse this.\nProprietary extensions are under the MIT license too.\n",
+		},
+	}
+	for _, tt := range tests {
+		var got []string
+		var lines [2]int
+		for _, m := range c.Match([]byte(tt.in)) {
+			if m.Name != Proprietary {
+				continue
+			}
+			if m.Category != CategoryProprietary || m.Kind() != ProprietaryText {
+				t.Errorf("%s: match of category %q and kind %q", tt.description, m.Category, m.Kind())
+			}
+			got = m.Phrases
+			lines = [2]int{m.StartLine, m.EndLine}
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: phrases (-want +got):\n%s", tt.description, diff)
+		}
+		if lines != tt.lines {
+			t.Errorf("%s: lines = %v, want %v", tt.description, lines, tt.lines)
+		}
+	}
+}
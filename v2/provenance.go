@@ -0,0 +1,89 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+// VariantKind is the kind of text a match was found in: the full text of a
+// license, the header recommended for source files, or a mention of it.
+type VariantKind string
+
+// Kinds of matched text.
+const (
+	// FullText is the complete text of a license or exception.
+	FullText VariantKind = "full-text"
+	// HeaderText is the short notice a license recommends adding to source
+	// files, which refers to the full text without including it.
+	HeaderText VariantKind = "header"
+	// ReferenceText is a mention of a license by name, identifier or URL.
+	ReferenceText VariantKind = "reference"
+	// GrantText is an informal statement granting permission to use the
+	// content.
+	GrantText VariantKind = "grant"
+	// ProprietaryText is the terms of a commercial license.
+	ProprietaryText VariantKind = "proprietary"
+)
+
+// Kind returns the kind of text the match was found in. Policies that
+// require the full text of a license to be distributed, rather than a header
+// or reference to it, can check that a match of FullText was found.
+func (m *Match) Kind() VariantKind {
+	switch m.MatchType {
+	case "Header":
+		return HeaderText
+	case referenceType:
+		return ReferenceText
+	case grantType:
+		return GrantText
+	case proprietaryType:
+		return ProprietaryText
+	}
+	return FullText
+}
+
+// VariantInfo describes a corpus entry.
+type VariantInfo struct {
+	// Name is the name of the entry, as reported by Match.Variant.
+	Name    string
+	License string
+	Kind    VariantKind
+	// Language is the ISO 639-1 code of the language of a translation, and
+	// empty otherwise.
+	Language string
+	// Origin is the file the entry was loaded from: its path for entries
+	// loaded with LoadLicenses, or its path within the file system for
+	// LoadLicensesFS. It is empty for entries added with AddContent.
+	Origin string
+	// Tokens is the number of tokens of the normalized text of the entry.
+	Tokens int
+}
+
+// Variant describes the corpus entry with the supplied name, as reported by
+// Match.Variant, so the text that matched can be traced to its source.
+func (c *Classifier) Variant(name string) (*VariantInfo, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	d, ok := c.docs[name]
+	if !ok {
+		return nil, false
+	}
+	m := &Match{MatchType: detectionType(name)}
+	return &VariantInfo{
+		Name:     name,
+		License:  LicenseName(name),
+		Kind:     m.Kind(),
+		Language: VariantLanguage(name),
+		Origin:   c.origins[name],
+		Tokens:   d.size(),
+	}, true
+}
@@ -0,0 +1,89 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMatchKind(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	read := func(name string) []byte {
+		b, err := ioutil.ReadFile(filepath.Join(baseLicenses, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	tests := []struct {
+		description string
+		in          []byte
+		want        VariantKind
+	}{
+		{"full text", read("MIT.txt"), FullText},
+		{"header", read("Apache-2.0.header.txt"), HeaderText},
+		{"reference", []byte("SPDX-License-Identifier: MIT"), ReferenceText},
+	}
+	for _, tt := range tests {
+		ms := c.Match(tt.in)
+		if len(ms) == 0 {
+			t.Errorf("%s: Match() found no matches", tt.description)
+			continue
+		}
+		if got := ms[0].Kind(); got != tt.want {
+			t.Errorf("%s: Kind() = %q, want %q", tt.description, got, tt.want)
+		}
+	}
+}
+
+func TestVariant(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	c.AddContent("Widget-1.0", []byte(versionedLicense))
+
+	got, ok := c.Variant("Apache-2.0.header")
+	if !ok {
+		t.Fatal("Variant(Apache-2.0.header) found no entry")
+	}
+	want := &VariantInfo{
+		Name:    "Apache-2.0.header",
+		License: "Apache-2.0",
+		Kind:    HeaderText,
+		Origin:  filepath.Join(baseLicenses, "Apache-2.0.header.txt"),
+		Tokens:  got.Tokens,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Variant(Apache-2.0.header): diff (-want +got):\n%s", diff)
+	}
+	if got.Tokens == 0 {
+		t.Error("Variant(Apache-2.0.header) has no tokens")
+	}
+
+	if got, ok := c.Variant("Widget-1.0"); !ok || got.Origin != "" || got.Kind != FullText {
+		t.Errorf("Variant(Widget-1.0) = %+v, want full text without origin", got)
+	}
+	if _, ok := c.Variant("No-Such-License"); ok {
+		t.Error("Variant(No-Such-License) found an entry")
+	}
+}
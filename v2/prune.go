@@ -0,0 +1,106 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// corpusFilter restricts the corpus of a classifier to the entries of some
+// licenses, or to those outside some categories. Entries the filter rejects
+// are never read or indexed, so neither the searchset nor the first pass
+// spends time on them.
+type corpusFilter struct {
+	// licenses are the names of the licenses retained, or nil to retain
+	// all licenses.
+	licenses map[string]bool
+	// excluded are the categories whose licenses are dropped.
+	excluded map[string]bool
+}
+
+// retains reports whether the named corpus entry belongs to the corpus.
+func (f *corpusFilter) retains(name string) bool {
+	if f == nil {
+		return true
+	}
+	l := LicenseName(name)
+	if f.licenses != nil && !f.licenses[l] {
+		return false
+	}
+	return !f.excluded[LicenseCategory(l)]
+}
+
+// categories are the license categories, for validating the categories
+// given to WithoutCategories.
+var categories = map[string]bool{
+	CategoryRestricted:           true,
+	CategoryReciprocal:           true,
+	CategoryNotice:               true,
+	CategoryPermissive:           true,
+	CategoryUnencumbered:         true,
+	CategoryPublicDomain:         true,
+	CategoryByExceptionOnly:      true,
+	CategoryForbidden:            true,
+	CategoryProprietary:          true,
+	CategoryContributorAgreement: true,
+}
+
+// newCorpusFilter returns the filter retaining the supplied licenses, or all
+// licenses if there are none, except those of the excluded categories. It
+// returns nil if the filter would retain everything.
+func newCorpusFilter(licenses, excluded []string) (*corpusFilter, error) {
+	if len(licenses) == 0 && len(excluded) == 0 {
+		return nil, nil
+	}
+	f := &corpusFilter{excluded: make(map[string]bool)}
+	if len(licenses) > 0 {
+		f.licenses = make(map[string]bool)
+		for _, l := range licenses {
+			f.licenses[l] = true
+		}
+	}
+	for _, cat := range excluded {
+		if !categories[cat] {
+			return nil, fmt.Errorf("unknown license category %q", cat)
+		}
+		f.excluded[cat] = true
+	}
+	return f, nil
+}
+
+// checkCorpusFilter reports the licenses given to WithLicenses that have no
+// entry in the corpus, which are most likely misspelled.
+func (c *Classifier) checkCorpusFilter() error {
+	if c.filter == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for name := range c.docs {
+		seen[LicenseName(name)] = true
+	}
+	var missing []string
+	for l := range c.filter.licenses {
+		if !seen[l] {
+			missing = append(missing, l)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("licenses not in the corpus: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithLicenses(t *testing.T) {
+	c, err := New(WithCorpusDir(baseLicenses), WithLicenses("MIT", "Apache-2.0"))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if _, ok := c.docs["Apache-2.0.header"]; !ok {
+		t.Error("corpus lacks the Apache-2.0 header")
+	}
+	for name := range c.docs {
+		if l := LicenseName(name); l != "MIT" && l != "Apache-2.0" {
+			t.Errorf("corpus has entry %s of an unnamed license", name)
+		}
+	}
+
+	for _, tt := range []struct {
+		file string
+		want string
+	}{
+		{file: "MIT.txt", want: "MIT"},
+		{file: "GPL-2.0.txt", want: ""},
+	} {
+		in, err := ioutil.ReadFile(filepath.Join(baseLicenses, tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		for _, m := range c.Match(in) {
+			if m.MatchType == "License" {
+				got = m.Name
+			}
+		}
+		if got != tt.want {
+			t.Errorf("license matched in %s = %q, want %q", tt.file, got, tt.want)
+		}
+	}
+
+	c.AddContent("BSD-3-Clause", []byte("Redistribution is permitted."))
+	if _, ok := c.docs["BSD-3-Clause"]; ok {
+		t.Error("AddContent() added an entry of an unnamed license")
+	}
+}
+
+func TestWithoutCategories(t *testing.T) {
+	all, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	c, err := New(WithCorpusDir(baseLicenses), WithoutCategories(CategoryForbidden, CategoryRestricted))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	dropped := 0
+	for name := range all.docs {
+		cat := LicenseCategory(LicenseName(name))
+		_, ok := c.docs[name]
+		if want := cat != CategoryForbidden && cat != CategoryRestricted; ok != want {
+			t.Errorf("corpus has entry %s of category %q: %v, want %v", name, cat, ok, want)
+		}
+		if !ok {
+			dropped++
+		}
+	}
+	if dropped == 0 {
+		t.Error("no entries dropped")
+	}
+}
+
+func TestCorpusFilterErrors(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithCorpusDir(baseLicenses), WithLicenses("MIT", "Apache-2")},
+		{WithCorpusDir(baseLicenses), WithoutCategories("dangerous")},
+	} {
+		if _, err := New(opts...); err == nil {
+			t.Errorf("New() succeeded with an invalid corpus filter, want error")
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import "sort"
+
+// The searchset finds candidate regions of the input through the q-grams, runs
+// of q tokens, it shares with each corpus entry. By default q is the largest
+// value for which any text matching an entry at the threshold is guaranteed
+// to share a q-gram with it; larger values are faster but miss matches with
+// scattered differences, and smaller values find more regions to score.
+
+// Parameters of the tuning of q to the corpus.
+const (
+	// qgramsPerEntry is the number of distinct q-grams the entries of the
+	// corpus should span, so that partial copies of them are still found.
+	qgramsPerEntry = 8
+	// shortEntryFraction is the fraction of the shortest entries ignored,
+	// since tuning q for a few very short entries slows down every match.
+	shortEntryFraction = 0.05
+	// minTunedQ is the smallest q chosen by tuning, under which q-grams are
+	// too common to narrow the search.
+	minTunedQ = 3
+)
+
+// QGramSize returns the length of the q-grams used by the classifier.
+func (c *Classifier) QGramSize() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.q
+}
+
+// SetQGramSize sets the length of the q-grams used to find candidate regions,
+// rebuilding the searchsets of the corpus. A value less than one restores the
+// default, derived from the threshold.
+func (c *Classifier) SetQGramSize(q int) {
+	defer c.update()()
+	if q < 1 {
+		q = computeQ(c.searchThreshold())
+	}
+	c.setQ(q)
+}
+
+// TuneQGramSize sets the length of the q-grams from the statistics of the
+// corpus and returns it. It never exceeds the default derived from the
+// threshold, so no matches are lost, and is lowered so that all but the
+// shortest entries span several q-grams, which matters at high thresholds
+// where the default q-grams are longer than license headers. It should be
+// called once the corpus is loaded.
+func (c *Classifier) TuneQGramSize() int {
+	defer c.update()()
+	lengths := make([]int, 0, len(c.docs))
+	for _, d := range c.docs {
+		lengths = append(lengths, d.size())
+	}
+	q := tunedQ(c.searchThreshold(), lengths)
+	c.setQ(q)
+	return q
+}
+
+// tunedQ returns the length of the q-grams suiting a corpus with entries of
+// the supplied lengths, in tokens, at the threshold.
+func tunedQ(threshold float64, lengths []int) int {
+	limit := computeQ(threshold)
+	if len(lengths) == 0 {
+		return limit
+	}
+	sort.Ints(lengths)
+	short := lengths[int(float64(len(lengths))*shortEntryFraction)]
+	q := short / qgramsPerEntry
+	if q < minTunedQ {
+		q = minTunedQ
+	}
+	if q > limit {
+		q = limit
+	}
+	return q
+}
+
+// setQ changes the length of the q-grams, rebuilding the searchsets of the
+// corpus and checking its entries again. The caller must hold the write lock.
+func (c *Classifier) setQ(q int) {
+	if q == c.q {
+		return
+	}
+	c.q = q
+	for name, d := range c.docs {
+		d.generateSearchSet(q)
+		d.s.origin = name
+		c.checkEntry(name, d)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestTunedQ(t *testing.T) {
+	long := make([]int, 100)
+	for i := range long {
+		long[i] = 1000
+	}
+	tests := []struct {
+		name      string
+		threshold float64
+		lengths   []int
+		want      int
+	}{
+		{name: "empty corpus", threshold: 0.9, want: 9},
+		{name: "long entries keep the default", threshold: 0.9, lengths: long, want: 9},
+		{name: "short entries lower q", threshold: 0.9, lengths: append([]int{40, 40, 40, 40, 40, 40}, long...), want: 5},
+		{name: "very short entries are ignored", threshold: 0.9, lengths: append([]int{2, 2}, long...), want: 9},
+		{name: "q isn't lowered too far", threshold: 0.95, lengths: []int{10, 10, 10}, want: minTunedQ},
+		{name: "low thresholds keep their q", threshold: 0.5, lengths: []int{10}, want: 1},
+	}
+	for _, tt := range tests {
+		if got := tunedQ(tt.threshold, tt.lengths); got != tt.want {
+			t.Errorf("%s: tunedQ() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSetQGramSize(t *testing.T) {
+	mit, err := ioutil.ReadFile(filepath.Join(baseLicenses, "MIT.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := New(WithCorpusContent("MIT", mit), WithCorpusContent("Short", []byte("a short entry of seven tokens here")))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if got, want := c.QGramSize(), computeQ(DefaultThreshold); got != want {
+		t.Errorf("QGramSize() = %d, want %d", got, want)
+	}
+
+	c.SetQGramSize(10)
+	if got := c.QGramSize(); got != 10 {
+		t.Errorf("QGramSize() = %d after SetQGramSize(10)", got)
+	}
+	if m := c.Match(mit); len(m) != 1 || m[0].Name != "MIT" || m[0].Confidence != 1 {
+		t.Errorf("Match() with q of 10 = %v, want MIT", m)
+	}
+	if issues := c.ValidateCorpus(); len(issues) != 1 || issues[0].Name != "Short" {
+		t.Errorf("ValidateCorpus() = %v, want an issue with the entry shorter than q", issues)
+	}
+
+	c.SetQGramSize(0)
+	if got, want := c.QGramSize(), computeQ(DefaultThreshold); got != want {
+		t.Errorf("QGramSize() = %d after restoring the default, want %d", got, want)
+	}
+	if issues := c.ValidateCorpus(); len(issues) != 0 {
+		t.Errorf("ValidateCorpus() = %v after restoring the default, want no issues", issues)
+	}
+}
+
+func TestWithAutoQGramSize(t *testing.T) {
+	c, err := New(WithThreshold(0.95), WithCorpusDir(baseLicenses), WithAutoQGramSize())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if got := c.QGramSize(); got >= computeQ(0.95) || got < minTunedQ {
+		t.Errorf("QGramSize() = %d, want a tuned value below the default of %d", got, computeQ(0.95))
+	}
+	in, err := ioutil.ReadFile(filepath.Join(baseLicenses, "Apache-2.0.header.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := c.Match(in); len(m) == 0 || m[0].Name != "Apache-2.0" {
+		t.Errorf("Match() = %v, want the Apache-2.0 header", m)
+	}
+}
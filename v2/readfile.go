@@ -0,0 +1,67 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// This file contains routines to read files that may change while they are
+// read, as happens when scanning live build directories.
+
+// ReadFileStable reads the named file, checking that it didn't change while it
+// was read: its size and modification time are compared before and after the
+// read, and the size with the amount read. A file that changed is read once
+// more. If it changed again, the content of the second read is returned and
+// stale is true, since offsets and line numbers found in the content may not
+// correspond to the file as it ends up.
+func ReadFileStable(name string) (content []byte, stale bool, err error) {
+	return readStable(name, os.Stat, ioutil.ReadFile)
+}
+
+// readStable implements ReadFileStable using the supplied functions to access
+// the file.
+func readStable(name string, stat func(string) (os.FileInfo, error), read func(string) ([]byte, error)) ([]byte, bool, error) {
+	var content []byte
+	for attempt := 0; attempt < 2; attempt++ {
+		before, err := stat(name)
+		if err != nil {
+			return nil, false, err
+		}
+		if content, err = read(name); err != nil {
+			return nil, false, err
+		}
+		after, err := stat(name)
+		if err != nil {
+			// The file was removed after it was read.
+			return content, true, nil
+		}
+		if unchanged(before, after, len(content)) {
+			return content, false, nil
+		}
+	}
+	return content, true, nil
+}
+
+// unchanged returns true if a file described by before when it started being
+// read and after once n bytes were read didn't change in between. Only the
+// size of regular files is known in advance.
+func unchanged(before, after os.FileInfo, n int) bool {
+	if before.Size() != after.Size() || !before.ModTime().Equal(after.ModTime()) {
+		return false
+	}
+	return !after.Mode().IsRegular() || after.Size() == int64(n)
+}
@@ -0,0 +1,117 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeInfo is the os.FileInfo of a regular file of the given size and
+// modification time.
+type fakeInfo struct {
+	size    int64
+	modTime time.Time
+}
+
+func (f fakeInfo) Name() string       { return "file" }
+func (f fakeInfo) Size() int64        { return f.size }
+func (f fakeInfo) Mode() os.FileMode  { return 0644 }
+func (f fakeInfo) ModTime() time.Time { return f.modTime }
+func (f fakeInfo) IsDir() bool        { return false }
+func (f fakeInfo) Sys() interface{}   { return nil }
+
+// changingFile simulates a file rewritten while it is read: the first
+// changes reads each complete before the write of the next version, so the
+// file differs before and after them.
+type changingFile struct {
+	versions []string
+	changes  int
+	version  int
+}
+
+func (f *changingFile) stat(string) (os.FileInfo, error) {
+	v := f.versions[f.version]
+	return fakeInfo{size: int64(len(v)), modTime: time.Unix(int64(f.version), 0)}, nil
+}
+
+func (f *changingFile) read(string) ([]byte, error) {
+	v := f.versions[f.version]
+	if f.changes > 0 {
+		f.changes--
+		f.version++
+	}
+	return []byte(v), nil
+}
+
+func TestReadFileStable(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "LICENSE")
+	if err := ioutil.WriteFile(name, []byte("MIT License"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	b, stale, err := ReadFileStable(name)
+	if err != nil || stale || string(b) != "MIT License" {
+		t.Errorf("ReadFileStable() = %q, %v, %v; want the content of the file", b, stale, err)
+	}
+	if _, _, err := ReadFileStable(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Errorf("ReadFileStable() error = %v, want not exist", err)
+	}
+}
+
+func TestReadStable(t *testing.T) {
+	versions := []string{"one", "two two", "three three three"}
+	tests := []struct {
+		desc      string
+		changes   int
+		want      string
+		wantStale bool
+	}{
+		{desc: "unchanged", changes: 0, want: "one"},
+		{desc: "changed once", changes: 1, want: "two two"},
+		{desc: "kept changing", changes: 2, want: "two two", wantStale: true},
+	}
+	for _, tt := range tests {
+		f := &changingFile{versions: versions, changes: tt.changes}
+		b, stale, err := readStable("f", f.stat, f.read)
+		if err != nil {
+			t.Fatalf("%s: readStable() failed: %v", tt.desc, err)
+		}
+		if string(b) != tt.want || stale != tt.wantStale {
+			t.Errorf("%s: readStable() = %q, stale %v; want %q, stale %v", tt.desc, b, stale, tt.want, tt.wantStale)
+		}
+	}
+}
+
+func TestReadStableShortRead(t *testing.T) {
+	// A file truncated while it is read is detected by the amount read even
+	// if its size is the same once the read completes.
+	info := fakeInfo{size: 10, modTime: time.Unix(1, 0)}
+	stat := func(string) (os.FileInfo, error) { return info, nil }
+	read := func(string) ([]byte, error) { return []byte("short"), nil }
+	if _, stale, err := readStable("f", stat, read); err != nil || !stale {
+		t.Errorf("readStable() = stale %v, %v; want stale", stale, err)
+	}
+
+	failed := errors.New("read failed")
+	read = func(string) ([]byte, error) { return nil, failed }
+	if _, _, err := readStable("f", stat, read); err != failed {
+		t.Errorf("readStable() error = %v, want %v", err, failed)
+	}
+}
@@ -0,0 +1,359 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"regexp"
+	"strings"
+)
+
+// This file contains routines to detect references to a license by name,
+// identifier or URL, as opposed to the text of the license itself. Many files
+// only say "Licensed under the MIT License" or carry an SPDX identifier, which
+// the text matcher can't detect since there's no license text to compare.
+
+const referenceType = "Reference"
+
+// referencePattern recognizes a reference to a license in a line of text. The
+// license name is computed from the submatches of the expression.
+type referencePattern struct {
+	re   *regexp.Regexp
+	name func(m []string) string
+}
+
+// literal returns a name function that always yields the supplied name.
+func literal(name string) func([]string) string {
+	return func([]string) string { return name }
+}
+
+// versioned returns a name function that appends the version in the first
+// submatch to the supplied prefix, normalizing it to a major.minor form.
+func versioned(prefix string) func([]string) string {
+	return func(m []string) string {
+		v := m[len(m)-1]
+		if !strings.Contains(v, ".") {
+			v += ".0"
+		}
+		return prefix + v
+	}
+}
+
+// spdxIdentifier returns a name function that canonicalizes the SPDX
+// identifier in the first submatch to the name used in the corpus.
+func spdxIdentifier(m []string) string {
+	id := m[1]
+	for _, ext := range []string{".html", ".json", ".php", ".txt"} {
+		id = strings.TrimSuffix(id, ext)
+	}
+	id = strings.TrimSuffix(id, "+")
+	id = strings.TrimSuffix(id, "-only")
+	return strings.TrimSuffix(id, "-or-later")
+}
+
+var referencePatterns = []referencePattern{
+	{regexp.MustCompile(`(?i)\bspdx\.org/licenses/([a-z0-9.+-]*[a-z0-9+])`), spdxIdentifier},
+	{regexp.MustCompile(`(?i)\bopensource\.org/licenses/([a-z0-9.+-]*[a-z0-9+])`), spdxIdentifier},
+	{regexp.MustCompile(`(?i)\bapache\.org/licenses/LICENSE-2\.0\b`), literal("Apache-2.0")},
+	{regexp.MustCompile(`(?i)\bgnu\.org/(?:licenses|copyleft)/(?:old-licenses/)?gpl-(\d(?:\.\d)?)`), versioned("GPL-")},
+	{regexp.MustCompile(`(?i)\bgnu\.org/(?:licenses|copyleft)/(?:old-licenses/)?lgpl-(\d(?:\.\d)?)`), versioned("LGPL-")},
+	{regexp.MustCompile(`(?i)\bgnu\.org/(?:licenses|copyleft)/agpl-(\d(?:\.\d)?)`), versioned("AGPL-")},
+	{regexp.MustCompile(`(?i)\bthe MIT license\b`), literal("MIT")},
+	{regexp.MustCompile(`(?i)\bthe ISC license\b`), literal("ISC")},
+	{regexp.MustCompile(`(?i)\bApache License,? (?:v|version )?(\d(?:\.\d)?)\b`), versioned("Apache-")},
+	{regexp.MustCompile(`(?i)\bGNU Affero General Public License,? (?:v|version )?(\d(?:\.\d)?)\b`), versioned("AGPL-")},
+	{regexp.MustCompile(`(?i)\bGNU (?:Lesser|Library) General Public License,? (?:v|version )?(\d(?:\.\d)?)\b`), versioned("LGPL-")},
+	{regexp.MustCompile(`(?i)\bGNU General Public License,? (?:v|version )?(\d(?:\.\d)?)\b`), versioned("GPL-")},
+	{regexp.MustCompile(`(?i)\bMozilla Public License,? (?:v|version )?(\d\.\d)\b`), versioned("MPL-")},
+	{regexp.MustCompile(`(?i)\bEclipse Public License,? (?:v|version )?(\d\.\d)\b`), versioned("EPL-")},
+	{regexp.MustCompile(`(?i)\bBoost Software License,? (?:v|version )?1\.0\b`), literal("BSL-1.0")},
+	{regexp.MustCompile(`(?i)\bBSD[ -](?:2|two)[ -]clause\b`), literal("BSD-2-Clause")},
+	{regexp.MustCompile(`(?i)\bBSD[ -](?:3|three)[ -]clause\b`), literal("BSD-3-Clause")},
+}
+
+// identifierPatterns recognize SPDX identifiers in the wording of a license
+// reference, as in "Licensed under MIT or Apache-2.0" or "Apache-2.0
+// license". The submatch is a list of identifiers, each of which is only a
+// reference if it names a license of the corpus.
+var identifierPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\blicen[cs]ed under (?:the )?([a-z0-9][a-z0-9.+-]*[a-z0-9+](?:(?:,? or|,? and|,|/) (?:the )?[a-z0-9][a-z0-9.+-]*[a-z0-9+])*)`),
+	regexp.MustCompile(`(?i)\b([a-z0-9][a-z0-9.+-]*[a-z0-9+])[ -]licen[cs]ed?\b`),
+}
+
+// identifierSeparator separates the identifiers listed by an identifier
+// pattern.
+var identifierSeparator = regexp.MustCompile(`(?i),? or (?:the )?|,? and (?:the )?|, (?:the )?|/ (?:the )?`)
+
+// negation recognizes the words negating the reference that follows them in
+// the same clause, as in "Not MIT licensed".
+var negation = regexp.MustCompile(`(?i)(?:\b(?:not|no|never|nor|non|without)\b|n't\b)[^,;:!?]*$`)
+
+// negated reports whether a reference preceded by the supplied text on its
+// line is negated. A clause ends at punctuation, or at a period followed by a
+// space, which leaves the periods of version numbers alone.
+func negated(before string) bool {
+	if i := strings.LastIndex(before, ". "); i != -1 {
+		before = before[i+2:]
+	}
+	return negation.MatchString(before)
+}
+
+// spdxLicenseIdentifier recognizes the SPDX-License-Identifier tag, whose
+// value is a license expression that may name several licenses.
+var spdxLicenseIdentifier = regexp.MustCompile(`SPDX-License-Identifier:\s*(.*)`)
+
+// expressionOperators are the keywords of an SPDX license expression.
+var expressionOperators = map[string]bool{
+	"AND":  true,
+	"OR":   true,
+	"WITH": true,
+}
+
+// expressionToken splits an SPDX license expression into parentheses and
+// the identifiers and operators between them.
+var expressionToken = regexp.MustCompile(`[()]|[^\s()*/]+`)
+
+// spdxChoices returns the licenses of an SPDX license expression offered as
+// alternatives to each other, keyed by license. They are the operands of an
+// OR that are single licenses, with or without an exception: the licenses of
+// "MIT OR Apache-2.0" are alternatives, but those of the conjunction in
+// "MIT OR (Apache-2.0 AND BSD-3-Clause)" are not.
+func spdxChoices(expr string) map[string][]string {
+	p := &expressionParser{tokens: expressionToken.FindAllString(expr, -1)}
+	p.or()
+	return p.choices
+}
+
+// expressionParser parses an SPDX license expression, where AND binds more
+// tightly than OR, recording the alternatives it offers.
+type expressionParser struct {
+	tokens  []string
+	choices map[string][]string
+}
+
+// next returns the next token, in upper case if it is an operator, or the
+// empty string at the end of the expression.
+func (p *expressionParser) next() string {
+	if len(p.tokens) == 0 {
+		return ""
+	}
+	if t := strings.ToUpper(p.tokens[0]); expressionOperators[t] {
+		return t
+	}
+	return p.tokens[0]
+}
+
+// or parses operands joined by OR, and returns the license of the operand if
+// there is only one and it is a single license.
+func (p *expressionParser) or() string {
+	operands := []string{p.and()}
+	for p.next() == "OR" {
+		p.tokens = p.tokens[1:]
+		operands = append(operands, p.and())
+	}
+	if len(operands) == 1 {
+		return operands[0]
+	}
+	var licenses []string
+	seen := make(map[string]bool)
+	for _, l := range operands {
+		if l != "" && !seen[l] {
+			seen[l] = true
+			licenses = append(licenses, l)
+		}
+	}
+	for _, l := range licenses {
+		for _, o := range licenses {
+			if o == l {
+				continue
+			}
+			if p.choices == nil {
+				p.choices = make(map[string][]string)
+			}
+			p.choices[l] = append(p.choices[l], o)
+		}
+	}
+	return ""
+}
+
+// and parses operands joined by AND, and returns the license of the operand
+// if there is only one and it is a single license.
+func (p *expressionParser) and() string {
+	single := p.operand()
+	for p.next() == "AND" {
+		p.tokens = p.tokens[1:]
+		p.operand()
+		single = ""
+	}
+	return single
+}
+
+// operand parses a license, with its exception if any, or a parenthesized
+// expression, and returns the license if it is a single license.
+func (p *expressionParser) operand() string {
+	switch t := p.next(); t {
+	case "", ")", "AND", "OR", "WITH":
+		return ""
+	case "(":
+		p.tokens = p.tokens[1:]
+		single := p.or()
+		if p.next() == ")" {
+			p.tokens = p.tokens[1:]
+		}
+		return single
+	default:
+		p.tokens = p.tokens[1:]
+		if p.next() == "WITH" && len(p.tokens) > 1 {
+			p.tokens = p.tokens[2:]
+		}
+		return spdxIdentifier([]string{t, t})
+	}
+}
+
+// findReferences returns matches for each license referenced by name,
+// identifier or URL in the supplied content. The reference matches are
+// located in id, the indexed form of the content. licenses are the names of
+// the licenses of the corpus keyed by their lower case form, which
+// identifiers in prose are checked against.
+func findReferences(in []byte, id *indexedDocument, licenses map[string]string) Matches {
+	var out Matches
+	for i, line := range strings.Split(string(in), "\n") {
+		// Every reference pattern contains one of these words, which makes
+		// for a cheap filter ahead of the regular expressions.
+		lower := strings.ToLower(line)
+		if !strings.Contains(lower, "licen") && !strings.Contains(lower, "copyleft") {
+			continue
+		}
+		var names []string
+		var choices map[string][]string
+		if m := spdxLicenseIdentifier.FindStringSubmatch(line); m != nil {
+			choices = spdxChoices(m[1])
+			for _, f := range strings.FieldsFunc(m[1], func(r rune) bool {
+				return r == ' ' || r == '\t' || r == '(' || r == ')' || r == '*' || r == '/'
+			}) {
+				if !expressionOperators[strings.ToUpper(f)] {
+					names = append(names, spdxIdentifier([]string{f, f}))
+				}
+			}
+		} else {
+			for _, p := range referencePatterns {
+				for _, loc := range p.re.FindAllStringSubmatchIndex(line, -1) {
+					if !negated(line[:loc[0]]) {
+						names = append(names, p.name(submatches(line, loc)))
+					}
+				}
+			}
+			for _, re := range identifierPatterns {
+				for _, loc := range re.FindAllStringSubmatchIndex(line, -1) {
+					if negated(line[:loc[0]]) {
+						continue
+					}
+					for _, f := range identifierSeparator.Split(submatches(line, loc)[1], -1) {
+						if n, ok := licenses[strings.ToLower(spdxIdentifier([]string{f, f}))]; ok {
+							names = append(names, n)
+						}
+					}
+				}
+			}
+		}
+
+		seen := make(map[string]bool)
+		for _, n := range names {
+			if seen[n] {
+				continue
+			}
+			seen[n] = true
+			start, end := lineTokens(id, i+1)
+			out = append(out, &Match{
+				Name:            n,
+				Confidence:      1.0,
+				MatchType:       referenceType,
+				Category:        LicenseCategory(n),
+				Choice:          choices[n],
+				StartLine:       i + 1,
+				EndLine:         i + 1,
+				StartTokenIndex: start,
+				EndTokenIndex:   end,
+			})
+		}
+	}
+	return out
+}
+
+// submatches returns the submatches of line at the supplied locations.
+func submatches(line string, loc []int) []string {
+	out := make([]string, len(loc)/2)
+	for i := range out {
+		if loc[2*i] >= 0 {
+			out[i] = line[loc[2*i]:loc[2*i+1]]
+		}
+	}
+	return out
+}
+
+// corpusLicenses returns the names of the licenses of the corpus other than
+// exceptions, keyed by their lower case form.
+func (c *Classifier) corpusLicenses() map[string]string {
+	out := make(map[string]string)
+	for n := range c.docs {
+		if detectionType(n) != exceptionType {
+			out[strings.ToLower(LicenseName(n))] = LicenseName(n)
+		}
+	}
+	return out
+}
+
+// lineTokens returns the indices of the first and last token on the supplied
+// line, or the index of the next token if the line has none.
+func lineTokens(id *indexedDocument, line int) (int, int) {
+	start, end := -1, -1
+	for _, t := range id.Tokens {
+		if int(t.Line) < line {
+			continue
+		}
+		if int(t.Line) > line {
+			if start == -1 {
+				return int(t.Index), int(t.Index)
+			}
+			break
+		}
+		if start == -1 {
+			start = int(t.Index)
+		}
+		end = int(t.Index)
+	}
+	if start == -1 {
+		return len(id.Tokens), len(id.Tokens)
+	}
+	return start, end
+}
+
+// addReferences appends the references that aren't part of the text of an
+// existing match. License headers routinely name the license they apply to,
+// and those references are already accounted for by the header match.
+func addReferences(matches, refs Matches) Matches {
+	for _, r := range refs {
+		covered := false
+		for _, m := range matches {
+			if m.MatchType != referenceType && between(r.StartLine, m.StartLine, m.EndLine) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			matches = append(matches, r)
+		}
+	}
+	return matches
+}
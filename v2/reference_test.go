@@ -0,0 +1,195 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFindReferences(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	s := c.snapshot()
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "no reference",
+			input:    "func main() {}",
+			expected: nil,
+		},
+		{
+			name:     "license name",
+			input:    "// Licensed under the MIT License.",
+			expected: []string{"MIT"},
+		},
+		{
+			name:     "versioned license name",
+			input:    "# Released under the GNU General Public License, version 2",
+			expected: []string{"GPL-2.0"},
+		},
+		{
+			name:     "lesser license name",
+			input:    "This library is covered by the GNU Lesser General Public License v2.1",
+			expected: []string{"LGPL-2.1"},
+		},
+		{
+			name:     "spdx identifier expression",
+			input:    "/* SPDX-License-Identifier: (GPL-2.0-only WITH Linux-syscall-note) OR MIT */",
+			expected: []string{"GPL-2.0", "Linux-syscall-note", "MIT"},
+		},
+		{
+			name:     "spdx url",
+			input:    "See https://spdx.org/licenses/BSD-3-Clause.html.",
+			expected: []string{"BSD-3-Clause"},
+		},
+		{
+			name:     "osi url",
+			input:    "//      https://opensource.org/licenses/BSD-2-Clause",
+			expected: []string{"BSD-2-Clause"},
+		},
+		{
+			name:     "license url",
+			input:    "http://www.apache.org/licenses/LICENSE-2.0",
+			expected: []string{"Apache-2.0"},
+		},
+		{
+			name:     "duplicate reference on a line",
+			input:    "the MIT License (see the MIT license file)",
+			expected: []string{"MIT"},
+		},
+		{
+			name:     "licensed under identifier",
+			input:    "Licensed under MIT",
+			expected: []string{"MIT"},
+		},
+		{
+			name:     "identifier license",
+			input:    "This crate is distributed under the terms of the Apache-2.0 license.",
+			expected: []string{"Apache-2.0"},
+		},
+		{
+			name:     "identifier licensed",
+			input:    "Zlib licensed.",
+			expected: []string{"Zlib"},
+		},
+		{
+			name:     "identifier with suffix",
+			input:    "Licensed under the GPL-2.0-or-later.",
+			expected: []string{"GPL-2.0"},
+		},
+		{
+			name:     "word that isn't an identifier",
+			input:    "Licensed under this agreement, which is an open license.",
+			expected: nil,
+		},
+		{
+			name:     "negated reference",
+			input:    "Not MIT licensed.",
+			expected: nil,
+		},
+		{
+			name:     "negated licensed under",
+			input:    "This file is not licensed under the MIT License.",
+			expected: nil,
+		},
+		{
+			name:     "negation in another clause",
+			input:    "Licensed under Apache-2.0, not the MIT license.",
+			expected: []string{"Apache-2.0"},
+		},
+		{
+			name:     "negation in another sentence",
+			input:    "Do not remove this notice. Licensed under MIT.",
+			expected: []string{"MIT"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			in := []byte(test.input)
+			var got []string
+			for _, m := range findReferences(in, s.createTargetIndexedDocument(in), s.licenses) {
+				if m.MatchType != "Reference" {
+					t.Errorf("MatchType = %q, want Reference", m.MatchType)
+				}
+				got = append(got, m.Name)
+			}
+			if !cmp.Equal(got, test.expected) {
+				t.Errorf("findReferences(%q) = %v, want %v", test.input, got, test.expected)
+			}
+		})
+	}
+}
+
+func TestReferenceMatches(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+
+	// The reference inside the Apache header is part of the header match and
+	// shouldn't be reported separately, unlike the standalone reference.
+	in := `// Copyright 2020 Example Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Portions of this file are available under the MIT License.
+`
+	var got []string
+	for _, m := range c.Match([]byte(in)) {
+		got = append(got, m.MatchType+":"+m.Name)
+	}
+	want := []string{"Header:Apache-2.0", "Reference:MIT"}
+	if !cmp.Equal(got, want) {
+		t.Errorf("Match() = %v, want %v", got, want)
+	}
+}
+
+func TestSPDXChoices(t *testing.T) {
+	tests := []struct {
+		expr string
+		want map[string][]string
+	}{
+		{"MIT", nil},
+		{"MIT AND Apache-2.0", nil},
+		{"MIT OR Apache-2.0", map[string][]string{"MIT": {"Apache-2.0"}, "Apache-2.0": {"MIT"}}},
+		{"MIT or Apache-2.0 OR MIT", map[string][]string{"MIT": {"Apache-2.0"}, "Apache-2.0": {"MIT"}}},
+		{"GPL-2.0-only WITH Classpath-exception-2.0 OR MIT", map[string][]string{"GPL-2.0": {"MIT"}, "MIT": {"GPL-2.0"}}},
+		{"MIT OR (Apache-2.0 AND BSD-3-Clause)", nil},
+		{"(MIT OR Apache-2.0) AND BSD-3-Clause", map[string][]string{"MIT": {"Apache-2.0"}, "Apache-2.0": {"MIT"}}},
+	}
+	for _, tt := range tests {
+		if got := spdxChoices(tt.expr); !cmp.Equal(got, tt.want) {
+			t.Errorf("spdxChoices(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
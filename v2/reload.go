@@ -0,0 +1,78 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import "fmt"
+
+// corpusSource loads part of the corpus of a classifier: the licenses of a
+// directory, or an entry added with AddContent.
+type corpusSource func(c *Classifier) error
+
+// addSource records a source of the corpus, for Reload.
+func (c *Classifier) addSource(s corpusSource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sources = append(c.sources, s)
+}
+
+// Reload rebuilds the corpus from its sources, the directories loaded with
+// LoadLicenses or WithCorpusDir, the sources loaded with LoadCorpus, which
+// fetch the corpus again, and the entries added with AddContent, and swaps
+// it in atomically. The classifier keeps matching with the previous
+// corpus while the new one is built, so long-running services can pick up
+// changes to the license files without downtime. If loading fails, the
+// previous corpus is kept and the error is returned. The configuration
+// should not be changed while the corpus is reloaded, since the new corpus
+// is indexed with the configuration it had when Reload was called.
+func (c *Classifier) Reload() error {
+	c.reloadMu.Lock()
+	defer c.reloadMu.Unlock()
+
+	c.mu.RLock()
+	n := NewClassifier(c.threshold)
+	n.q = c.q
+	n.edits = c.edits
+	n.weighted = c.weighted
+	n.filter = c.filter
+	n.parallelism = c.parallelism
+	for name, ex := range c.exemptions {
+		n.exemptions[name] = ex
+	}
+	n.equivalences = c.equivalences
+	for name, a := range c.anchors {
+		n.anchors[name] = a
+	}
+	sources := c.sources
+	c.mu.RUnlock()
+
+	for _, load := range sources {
+		if err := load(n); err != nil {
+			return fmt.Errorf("couldn't reload the corpus: %w", corpusLoadError(err))
+		}
+	}
+	if c.tuneQ {
+		n.TuneQGramSize()
+	}
+
+	defer c.update()()
+	c.dict = n.dict
+	c.docs = n.docs
+	c.docFreq = n.docFreq
+	c.issues = n.issues
+	c.origins = n.origins
+	c.sources = n.sources
+	c.q = n.q
+	return nil
+}
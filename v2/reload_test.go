@@ -0,0 +1,78 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "corpus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("Widget-1.0.txt", versionedLicense)
+
+	c, err := New(WithThreshold(.9), WithCorpusDir(dir), WithCorpusContent("Gadget-1.0", []byte(gadgetLicense)))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if diff := cmp.Diff([]string{"Gadget-1.0", "Widget-1.0"}, c.LicenseDB().IDs()); diff != "" {
+		t.Errorf("licenses before reloading: (-want +got)\n%s", diff)
+	}
+	version := c.CorpusVersion()
+
+	// Matching continues with the previous corpus until it is reloaded.
+	os.Remove(filepath.Join(dir, "Widget-1.0.txt"))
+	write("Gizmo-1.0.txt", versionedLicense)
+	if got := licenseMatches(c.Match([]byte(versionedLicense))); len(got) != 1 || got[0].Name != "Widget-1.0" {
+		t.Errorf("Match() before reloading = %v, want Widget-1.0", got)
+	}
+
+	if err := c.Reload(); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+	if diff := cmp.Diff([]string{"Gadget-1.0", "Gizmo-1.0"}, c.LicenseDB().IDs()); diff != "" {
+		t.Errorf("licenses after reloading: (-want +got)\n%s", diff)
+	}
+	if c.CorpusVersion() == version {
+		t.Error("CorpusVersion() unchanged by reloading a changed corpus")
+	}
+	if got := licenseMatches(c.Match([]byte(versionedLicense))); len(got) != 1 || got[0].Name != "Gizmo-1.0" {
+		t.Errorf("Match() after reloading = %v, want Gizmo-1.0", got)
+	}
+
+	// A corpus that fails to load leaves the previous one in place.
+	version = c.CorpusVersion()
+	c.sources = append(c.sources, func(*Classifier) error { return errors.New("unreadable") })
+	if err := c.Reload(); err == nil {
+		t.Error("Reload() succeeded with a failing source, want error")
+	}
+	if c.CorpusVersion() != version {
+		t.Error("CorpusVersion() changed by a failed reload")
+	}
+}
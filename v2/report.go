@@ -0,0 +1,135 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// DiffReport collects the explanations of the matches found in a set of
+// files to show reviewers how the matched text differs from the licenses.
+// Only the matches below full confidence have their diff shown; the others
+// are listed with a note.
+type DiffReport struct {
+	// Title is the title of the report.
+	Title    string
+	sections []reportSection
+}
+
+type reportSection struct {
+	filename string
+	e        *Explanation
+}
+
+// Add adds the explanation of a match found in the named file to the report.
+func (r *DiffReport) Add(filename string, e *Explanation) {
+	r.sections = append(r.sections, reportSection{filename, e})
+}
+
+// heading describes the match of the section, naming the license by its
+// alias, if it has one, followed by its canonical name.
+func (s reportSection) heading() string {
+	m := s.e.Match
+	name := m.Name
+	if d := m.DisplayName(); d != m.Name {
+		name = fmt.Sprintf("%s [%s]", d, m.Name)
+	}
+	h := fmt.Sprintf("%s: %s (%s) matched at %v, lines %d-%d", s.filename, name, m.MatchType, m.Confidence, m.StartLine, m.EndLine)
+	if s.e.Variant != "" && s.e.Variant != m.Name {
+		h += ", against " + s.e.Variant
+	}
+	return h
+}
+
+// note describes the match of a section without a diff to show: a match at
+// full confidence, or one that isn't backed by a corpus text, such as a
+// reference. It returns the empty string for the other sections.
+func (s reportSection) note() string {
+	switch {
+	case s.e.tokens == nil && len(s.e.Rules) > 0:
+		return s.e.Rules[0]
+	case s.e.tokens == nil || s.e.Match.Confidence >= 1:
+		return "exact match"
+	}
+	return ""
+}
+
+// summary describes the edits of the diff of the section and the clauses
+// of the license they change.
+func (s reportSection) summary() string {
+	sum := fmt.Sprintf("words not in the license: %d, license words missing: %d", s.e.Deletions, s.e.Insertions)
+	if c := s.e.clauseSummary(); c != "" {
+		sum += "; " + c
+	}
+	return sum
+}
+
+// reportStyle styles the HTML report.
+const reportStyle = `body { font-family: sans-serif; margin: 2em; }
+pre { white-space: pre-wrap; border: 1px solid #ccc; padding: 1em; }
+del { background: #fdd; color: #900; }
+ins { background: #dfd; color: #060; text-decoration: none; }
+.summary { color: #555; }`
+
+// WriteHTML writes the report as a self-contained HTML page, marking the
+// text of each match that isn't in the license as deleted and the license
+// text missing from it as inserted.
+func (r *DiffReport) WriteHTML(w io.Writer) error {
+	var out strings.Builder
+	title := html.EscapeString(r.Title)
+	fmt.Fprintf(&out, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>\n%s\n</style>\n</head>\n<body>\n", title, reportStyle)
+	fmt.Fprintf(&out, "<h1>%s</h1>\n", title)
+	out.WriteString("<p>Legend: <del>text not in the license</del> <ins>license text missing</ins></p>\n")
+	for _, s := range r.sections {
+		fmt.Fprintf(&out, "<h2>%s</h2>\n", html.EscapeString(s.heading()))
+		if n := s.note(); n != "" {
+			fmt.Fprintf(&out, "<p class=\"summary\">%s</p>\n", html.EscapeString(n))
+			continue
+		}
+		fmt.Fprintf(&out, "<p class=\"summary\">%s</p>\n<pre>%s</pre>\n", s.summary(), s.e.HTML())
+	}
+	out.WriteString("</body>\n</html>\n")
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// WriteText writes the report for a terminal. With color, the text of each
+// match is rendered as by Explanation.ANSI, and otherwise as by
+// Explanation.Text.
+func (r *DiffReport) WriteText(w io.Writer, color bool) error {
+	var out strings.Builder
+	if r.Title != "" {
+		fmt.Fprintf(&out, "%s\n\n", r.Title)
+	}
+	for _, s := range r.sections {
+		fmt.Fprintf(&out, "%s\n", s.heading())
+		if n := s.note(); n != "" {
+			fmt.Fprintf(&out, "  %s\n\n", n)
+			continue
+		}
+		fmt.Fprintf(&out, "  %s\n\n", s.summary())
+		if color {
+			out.WriteString(s.e.ANSI())
+		} else {
+			out.WriteString(s.e.Text())
+		}
+		out.WriteString("\n\n")
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
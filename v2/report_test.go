@@ -0,0 +1,93 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffReport(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(baseLicenses, "MIT.txt"))
+	if err != nil {
+		t.Fatalf("couldn't read license: %v", err)
+	}
+	altered := []byte(strings.Replace(string(b), "free of charge", "entirely free of charge", 1))
+	ref := []byte("This project is licensed under the Apache License 2.0.\n")
+
+	r := &DiffReport{Title: "Review <2021>"}
+	for _, f := range []struct {
+		name string
+		in   []byte
+	}{
+		{"exact/LICENSE", b},
+		{"altered/LICENSE", altered},
+		{"README", ref},
+	} {
+		ms := c.Match(f.in)
+		if len(ms) != 1 {
+			t.Fatalf("Match() of %s = %v, want a single match", f.name, ms)
+		}
+		e, err := c.Explain(f.in, ms[0])
+		if err != nil {
+			t.Fatalf("Explain() of %s failed: %v", f.name, err)
+		}
+		r.Add(f.name, e)
+	}
+
+	var h strings.Builder
+	if err := r.WriteHTML(&h); err != nil {
+		t.Fatalf("WriteHTML() failed: %v", err)
+	}
+	for _, want := range []string{
+		"<title>Review &lt;2021&gt;</title>",
+		"<h2>exact/LICENSE: MIT (License) matched at 1, lines 1-17</h2>\n<p class=\"summary\">exact match</p>",
+		"<h2>altered/LICENSE: MIT (License) matched at 0.99",
+		"words not in the license: 1, license words missing: 0; paragraph 1 altered",
+		"<del>entirely</del>",
+		"<h2>README: Apache-2.0 (Reference)",
+		"accepted: license referenced by name",
+	} {
+		if !strings.Contains(h.String(), want) {
+			t.Errorf("WriteHTML() = %s\nwant it to contain %q", h.String(), want)
+		}
+	}
+	if n := strings.Count(h.String(), "<pre>"); n != 1 {
+		t.Errorf("WriteHTML() shows %d diffs, want 1", n)
+	}
+
+	r.sections[0].e.Match.Alias = Alias{ID: "ACME-1"}
+	var plain, color strings.Builder
+	if err := r.WriteText(&plain, false); err != nil {
+		t.Fatalf("WriteText() failed: %v", err)
+	}
+	if err := r.WriteText(&color, true); err != nil {
+		t.Fatalf("WriteText() failed: %v", err)
+	}
+	for _, want := range []string{"Review <2021>\n\n", "exact/LICENSE: ACME-1 [MIT] (License) matched at 1, lines 1-17\n  exact match\n", "[-entirely-]"} {
+		if !strings.Contains(plain.String(), want) {
+			t.Errorf("WriteText() = %s\nwant it to contain %q", plain.String(), want)
+		}
+	}
+	if strings.Contains(plain.String(), ansiReset) || !strings.Contains(color.String(), ansiRed+"entirely"+ansiReset) {
+		t.Errorf("WriteText() colors = %q, %q; want colors only when requested", plain.String(), color.String())
+	}
+}
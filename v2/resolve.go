@@ -0,0 +1,170 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"regexp"
+	"strings"
+)
+
+// colloquialNames maps names licenses are commonly known by, normalized by
+// normalizeLicenseName, to their corpus names.
+var colloquialNames = map[string]string{
+	"apache":                  "Apache-2.0",
+	"apache license":          "Apache-2.0",
+	"apache software license": "Apache-2.0",
+	"asl":                     "Apache-2.0",
+	"bsd":                     "BSD-3-Clause",
+	"bsd license":             "BSD-3-Clause",
+	"new bsd":                 "BSD-3-Clause",
+	"new bsd license":         "BSD-3-Clause",
+	"modified bsd":            "BSD-3-Clause",
+	"modified bsd license":    "BSD-3-Clause",
+	"revised bsd":             "BSD-3-Clause",
+	"simplified bsd":          "BSD-2-Clause",
+	"simplified bsd license":  "BSD-2-Clause",
+	"freebsd":                 "BSD-2-Clause",
+	"freebsd license":         "BSD-2-Clause",
+	"original bsd":            "BSD-4-Clause",
+	"bsd 2 clause":            "BSD-2-Clause",
+	"bsd 3 clause":            "BSD-3-Clause",
+	"bsd 4 clause":            "BSD-4-Clause",
+	"mit":                     "MIT",
+	"mit license":             "MIT",
+	"expat":                   "MIT",
+	"expat license":           "MIT",
+	"isc license":             "ISC",
+	"zlib license":            "Zlib",
+	"boost":                   "BSL-1.0",
+	"boost software license":  "BSL-1.0",
+	"unlicense":               "Unlicense",
+	"the unlicense":           "Unlicense",
+	"cc0":                     "CC0-1.0",
+	"wtfpl":                   "WTFPL",
+}
+
+// versionedName recognizes the colloquial names of versioned licenses, such
+// as "GPLv2+", "Apache 2" and "LGPL version 2.1 or later".
+var versionedName = regexp.MustCompile(`^(gpl|lgpl|agpl|apache|asl|mpl|epl|gnu gpl|gnu lgpl|gnu agpl)\s*(?:license\s*)?(?:v|version\s*)?(\d)(?:\.(\d))?(\+|\s*or later|\s*only)?$`)
+
+// versionedFamilies maps the families of versionedName to their corpus
+// names.
+var versionedFamilies = map[string]string{
+	"gpl":      "GPL",
+	"gnu gpl":  "GPL",
+	"lgpl":     "LGPL",
+	"gnu lgpl": "LGPL",
+	"agpl":     "AGPL",
+	"gnu agpl": "AGPL",
+	"apache":   "Apache",
+	"asl":      "Apache",
+	"mpl":      "MPL",
+	"epl":      "EPL",
+}
+
+// knownLicenses maps the lower-cased corpus names of licenses to the names,
+// and fullNames their lower-cased full names.
+var knownLicenses, fullNames = func() (map[string]string, map[string]string) {
+	ids, names := make(map[string]string), make(map[string]string)
+	for id, name := range licenseNames {
+		ids[strings.ToLower(id)] = id
+		names[normalizeLicenseName(name)] = id
+	}
+	for id := range licenseCategories {
+		ids[strings.ToLower(id)] = id
+	}
+	return ids, names
+}()
+
+// normalizeLicenseName lower-cases a license name and reduces the
+// punctuation and spacing that vary between the ways it is written.
+func normalizeLicenseName(name string) string {
+	name = strings.ToLower(name)
+	name = strings.NewReplacer("-", " ", "_", " ", ",", " ", "\"", " ", "'", " ", "(", " ", ")", " ").Replace(name)
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// Resolve maps a license name to its SPDX identifier. It accepts SPDX
+// identifiers in any case, the names the classifier reports, which predate
+// the -only and -or-later identifiers of the GNU licenses, the full names of
+// the licenses, such as "Apache License 2.0", and names licenses are commonly
+// known by, such as "BSD license", "GPLv2+" and "Apache 2". Deprecated
+// identifiers resolve to the expression replacing them, such as
+// "GPL-2.0-only WITH Classpath-exception-2.0". It returns false for names it
+// doesn't know.
+func Resolve(name string) (spdxID string, ok bool) {
+	id, orLater, ok := resolveLicense(name)
+	if !ok {
+		return "", false
+	}
+	replacement, deprecated := DeprecatedLicense(id)
+	switch {
+	case orLater && replacement == id+"-only":
+		return id + "-or-later", true
+	case orLater:
+		return id + "+", true
+	case deprecated:
+		return replacement, true
+	}
+	return id, true
+}
+
+// resolveLicense returns the corpus name of the named license and whether
+// the name allows later versions of it.
+func resolveLicense(name string) (id string, orLater bool, ok bool) {
+	name = strings.TrimSpace(name)
+	for _, suffix := range []string{"-or-later", "+"} {
+		if base := strings.TrimSuffix(name, suffix); base != name {
+			id, _, ok := resolveLicense(base)
+			return id, true, ok
+		}
+	}
+	if id, ok := knownLicenses[strings.ToLower(strings.TrimSuffix(name, "-only"))]; ok {
+		return id, false, true
+	}
+	n := normalizeLicenseName(name)
+	if id, ok := fullNames[n]; ok {
+		return id, false, true
+	}
+	if id, ok := colloquialNames[n]; ok {
+		return id, false, true
+	}
+	m := versionedName.FindStringSubmatch(n)
+	if m == nil {
+		return "", false, false
+	}
+	minor := m[3]
+	if minor == "" {
+		minor = "0"
+	}
+	id = versionedFamilies[m[1]] + "-" + m[2] + "." + minor
+	if _, ok := knownLicenses[strings.ToLower(id)]; !ok {
+		return "", false, false
+	}
+	return id, strings.TrimSpace(m[4]) == "+" || strings.TrimSpace(m[4]) == "or later", true
+}
+
+// SPDXID returns the SPDX identifier of the license of the match: the one
+// declared by its metadata, or else as resolved by Resolve, or else its name,
+// such as for licenses added to the corpus locally.
+func (m *Match) SPDXID() string {
+	if m.Metadata != nil && m.Metadata.SPDXID != "" {
+		return m.Metadata.SPDXID
+	}
+	if id, ok := Resolve(m.Name); ok {
+		return id
+	}
+	return m.Name
+}
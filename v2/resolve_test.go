@@ -0,0 +1,72 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import "testing"
+
+func TestResolve(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		want string
+	}{
+		{name: "MIT", want: "MIT"},
+		{name: "mit", want: "MIT"},
+		{name: "apache-2.0", want: "Apache-2.0"},
+		{name: "Apache 2", want: "Apache-2.0"},
+		{name: "Apache License, Version 2.0", want: "Apache-2.0"},
+		{name: "Apache License 2.0", want: "Apache-2.0"},
+		{name: "ASL 2.0", want: "Apache-2.0"},
+		{name: "BSD license", want: "BSD-3-Clause"},
+		{name: "Simplified BSD", want: "BSD-2-Clause"},
+		{name: "BSD-2-Clause", want: "BSD-2-Clause"},
+		{name: "Expat", want: "MIT"},
+		{name: "GPLv2", want: "GPL-2.0-only"},
+		{name: "GPLv2+", want: "GPL-2.0-or-later"},
+		{name: "GPL-2.0", want: "GPL-2.0-only"},
+		{name: "GPL-2.0+", want: "GPL-2.0-or-later"},
+		{name: "GPL-3.0-only", want: "GPL-3.0-only"},
+		{name: "GPL-3.0-or-later", want: "GPL-3.0-or-later"},
+		{name: "LGPL version 2.1 or later", want: "LGPL-2.1-or-later"},
+		{name: "GNU GPL v3", want: "GPL-3.0-only"},
+		{name: "MPL 2.0", want: "MPL-2.0"},
+		{name: "Apache-2.0+", want: "Apache-2.0+"},
+		{name: "GPL-2.0-with-classpath-exception", want: "GPL-2.0-only WITH Classpath-exception-2.0"},
+		{name: "Mozilla Public License 2.0", want: "MPL-2.0"},
+		{name: "GPLv9", want: ""},
+		{name: "Proprietary", want: ""},
+		{name: "", want: ""},
+	} {
+		got, ok := Resolve(tt.name)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("Resolve(%q) = %q, %v, want %q", tt.name, got, ok, tt.want)
+		}
+	}
+}
+
+func TestMatchSPDXID(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		want string
+	}{
+		{name: "GPL-2.0", want: "GPL-2.0-only"},
+		{name: "MIT", want: "MIT"},
+		{name: "Acme-Internal", want: "Acme-Internal"},
+	} {
+		m := &Match{Name: tt.name}
+		if got := m.SPDXID(); got != tt.want {
+			t.Errorf("SPDXID() of %s = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"fmt"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// Verdict is the decision of a DiffRule about a diff.
+type Verdict int
+
+const (
+	// Abstain leaves the decision about a diff to the other rules.
+	Abstain Verdict = iota
+	// Accept accepts a diff, even if the built-in rules reject it. The diff
+	// must still be within the distance allowed by the threshold.
+	Accept
+	// Reject rejects a diff, so that the content doesn't match the corpus
+	// entry.
+	Reject
+)
+
+func (v Verdict) String() string {
+	switch v {
+	case Abstain:
+		return "abstained"
+	case Accept:
+		return "accepted"
+	case Reject:
+		return "rejected"
+	}
+	return "unknown"
+}
+
+// DiffRule inspects the diff between content and a corpus entry for changes
+// the built-in scoring rules don't judge as the caller needs, such as the
+// edits that make up an in-house variant of a license.
+type DiffRule interface {
+	// Inspect is given the name of the corpus entry and the word diff from
+	// the normalized content to its text, as in Explanation.Diffs, and
+	// returns a verdict along with the reason for it. It is called
+	// concurrently by concurrent matches.
+	Inspect(entry string, diffs []diffmatchpatch.Diff) (Verdict, string)
+}
+
+// DiffRuleFunc adapts a function to a DiffRule.
+type DiffRuleFunc func(entry string, diffs []diffmatchpatch.Diff) (Verdict, string)
+
+// Inspect implements DiffRule.
+func (f DiffRuleFunc) Inspect(entry string, diffs []diffmatchpatch.Diff) (Verdict, string) {
+	return f(entry, diffs)
+}
+
+type namedRule struct {
+	name string
+	rule DiffRule
+}
+
+// AddDiffRule registers a rule consulted about the diff of every candidate
+// match after the built-in rules. Rules are consulted in the order they were
+// added, and the first that doesn't abstain decides: a rejection by the
+// built-in rules stands unless a rule accepts the diff, but no rule can
+// accept a diff with more edits than the threshold allows. The name
+// identifies the rule in explanations and in cache keys, so a rule must
+// always decide alike under the same name. Adding a rule with the name of a
+// registered rule replaces it.
+func (c *Classifier) AddDiffRule(name string, r DiffRule) {
+	defer c.update()()
+	for i, nr := range c.rules {
+		if nr.name == name {
+			c.rules[i].rule = r
+			return
+		}
+	}
+	c.rules = append(c.rules, namedRule{name, r})
+}
+
+// applyDiffRules consults the registered rules about a diff the built-in
+// rules scored as distance, returning the distance resulting from the
+// verdict and a description of the verdict, if a rule didn't abstain.
+func (c *Classifier) applyDiffRules(id string, diffs []diffmatchpatch.Diff, distance, bound int) (int, string) {
+	if distance == distanceExceeded {
+		return distance, ""
+	}
+	for _, nr := range c.rules {
+		v, reason := nr.rule.Inspect(id, diffs)
+		switch v {
+		case Accept:
+			if distance < 0 {
+				if distance = diffLevenshteinWord(diffs); distance > bound {
+					distance = distanceExceeded
+				}
+			}
+		case Reject:
+			distance = ruleRejected
+		default:
+			continue
+		}
+		return distance, fmt.Sprintf("%s by rule %s: %s", v, nr.name, reason)
+	}
+	return distance, ""
+}
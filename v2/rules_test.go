@@ -0,0 +1,169 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+const versionedLicense = `The Widget Public License
+
+Permission is granted under version 1.0 of these terms to any person
+obtaining a copy of this software to
+use, copy and modify it, provided that this notice is retained in all
+copies and that modified versions are clearly marked as such. The software
+is provided without warranty of any kind, express or implied. In no event
+shall the authors or copyright holders be liable for any claim, damages or
+other liability arising from its use.
+`
+
+// licenseMatches returns the matches of license texts among ms, leaving out
+// the informal grant the text of versionedLicense also makes.
+func licenseMatches(ms Matches) Matches {
+	var out Matches
+	for _, m := range ms {
+		if m.MatchType == "License" {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// inserts returns whether the diff removes the supplied word of the license.
+func inserts(diffs []diffmatchpatch.Diff, word string) bool {
+	for _, d := range diffs {
+		if d.Type == diffmatchpatch.DiffInsert && strings.Contains(" "+d.Text+" ", " "+word+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDiffRules(t *testing.T) {
+	// The in-house edition of the license is numbered 2.0, which the
+	// built-in rules take for a different version of the license.
+	inHouse := []byte(strings.Replace(versionedLicense, "version 1.0", "version 2.0", 1))
+	// Removing the warranty disclaimer is a small edit the built-in rules
+	// accept.
+	noWarranty := []byte(strings.Replace(versionedLicense, "without warranty of any kind, ", "", 1))
+	allowVersion := DiffRuleFunc(func(entry string, diffs []diffmatchpatch.Diff) (Verdict, string) {
+		if inserts(diffs, "1.0") {
+			return Accept, "the in-house edition is numbered 2.0"
+		}
+		return Abstain, ""
+	})
+	requireWarranty := DiffRuleFunc(func(entry string, diffs []diffmatchpatch.Diff) (Verdict, string) {
+		if inserts(diffs, "warranty") {
+			return Reject, "the warranty disclaimer was removed"
+		}
+		return Abstain, ""
+	})
+	// The edition also adds too many words to the license to match it.
+	tooLong := []byte(strings.Replace(string(inHouse), "copy and modify it", "copy and modify it"+strings.Repeat(" and more", 5), 1))
+
+	tests := []struct {
+		name    string
+		rules   map[string]DiffRule
+		in      []byte
+		matched bool
+		rule    string
+	}{
+		{name: "built-in rejection", in: inHouse, rule: "rejected: the license version was changed"},
+		{name: "built-in acceptance", in: noWarranty, matched: true},
+		{
+			name:    "rule overrides rejection",
+			rules:   map[string]DiffRule{"in-house": allowVersion},
+			in:      inHouse,
+			matched: true,
+			rule:    "accepted by rule in-house: the in-house edition is numbered 2.0",
+		},
+		{
+			name:  "rule rejects",
+			rules: map[string]DiffRule{"in-house": allowVersion, "warranty": requireWarranty},
+			in:    noWarranty,
+			rule:  "rejected by rule warranty: the warranty disclaimer was removed",
+		},
+		{
+			name:  "rules abstain",
+			rules: map[string]DiffRule{"warranty": requireWarranty},
+			in:    inHouse,
+		},
+		{
+			name:  "distance still bounded",
+			rules: map[string]DiffRule{"in-house": allowVersion},
+			in:    tooLong,
+			rule:  "rejected: 11 word edits against 80 known words, at most 8 allowed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClassifier(.9)
+			c.AddContent("Widget-1.0", []byte(versionedLicense))
+			for _, name := range []string{"in-house", "warranty"} {
+				if r, ok := tt.rules[name]; ok {
+					c.AddDiffRule(name, r)
+				}
+			}
+			ms := licenseMatches(c.Match(tt.in))
+			if got := len(ms) == 1; got != tt.matched {
+				t.Fatalf("Match() = %v, want a match: %v", ms, tt.matched)
+			}
+			if tt.rule == "" {
+				return
+			}
+			m := &Match{Name: "Widget-1.0", MatchType: "License", StartTokenIndex: 0}
+			if len(ms) == 1 {
+				m = ms[0]
+			} else {
+				doc := tokenize(tt.in)
+				m.StartLine, m.EndLine = 1, doc.Tokens[len(doc.Tokens)-1].Line
+				m.EndTokenIndex = doc.Tokens[len(doc.Tokens)-1].Index
+			}
+			e, err := c.Explain(tt.in, m)
+			if err != nil {
+				t.Fatalf("Explain() failed: %v", err)
+			}
+			found := false
+			for _, r := range e.Rules {
+				found = found || r == tt.rule
+			}
+			if !found {
+				t.Errorf("Explain() rules = %q, want %q", e.Rules, tt.rule)
+			}
+		})
+	}
+}
+
+func TestAddDiffRule(t *testing.T) {
+	c := NewClassifier(.9)
+	c.AddContent("Widget-1.0", []byte(versionedLicense))
+	scope := string(c.cacheScope())
+	reject := DiffRuleFunc(func(string, []diffmatchpatch.Diff) (Verdict, string) { return Reject, "rejected" })
+	c.AddDiffRule("strict", reject)
+	if string(c.cacheScope()) == scope {
+		t.Errorf("AddDiffRule() didn't change the cache scope")
+	}
+	if ms := licenseMatches(c.Match([]byte(versionedLicense))); len(ms) != 0 {
+		t.Errorf("Match() = %v, want the rule to reject the license", ms)
+	}
+	// Adding a rule under the same name replaces it.
+	c.AddDiffRule("strict", DiffRuleFunc(func(string, []diffmatchpatch.Diff) (Verdict, string) { return Abstain, "" }))
+	if ms := licenseMatches(c.Match([]byte(versionedLicense))); len(ms) != 1 {
+		t.Errorf("Match() = %v, want the replaced rule to abstain", ms)
+	}
+}
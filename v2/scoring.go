@@ -15,6 +15,7 @@
 package classifier
 
 import (
+	"math"
 	"strings"
 	"unicode"
 
@@ -27,26 +28,83 @@ const (
 	versionChange          = -1
 	introducedPhraseChange = -2
 	lesserGPLChange        = -3
+	creativeCommonsChange  = -4
+	distanceExceeded       = -5
+	ruleRejected           = -6
 )
 
+// noBound is the maximum distance of scoreDiffs that doesn't bound the
+// distance.
+const noBound = int(^uint(0) >> 1)
+
+// creativeCommonsTerms are the license elements that distinguish the Creative
+// Commons variants from one another. A CC license that gains or loses one of
+// these terms is a different license, regardless of how small the textual
+// change is.
+var creativeCommonsTerms = []string{
+	"noncommercial",
+	"noderivatives",
+	"noderivs",
+	"sharealike",
+}
+
+// isCreativeCommonsChange returns true if the diff text adds or removes one of
+// the elements that differentiate the Creative Commons license variants.
+func isCreativeCommonsChange(id, text string) bool {
+	if !strings.HasPrefix(id, "CC-BY") {
+		return false
+	}
+	for _, w := range strings.Split(text, " ") {
+		for _, t := range creativeCommonsTerms {
+			if w == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // score computes a metric of similarity between the known and unknown
 // document, including the offsets into the unknown that yield the content
-// generating the computed similarity.
-func (c *Classifier) score(id string, unknown, known *indexedDocument, unknownStart, unknownEnd int) (float64, int, int) {
-	if c.tc.traceScoring(known.s.origin) {
-		c.tc.trace("Scoring %s: [%d-%d]", known.s.origin, unknownStart, unknownEnd)
-	}
+// generating the computed similarity. Scoring is abandoned, with a confidence
+// of zero, as soon as the distance is certain to exceed bound.
+func (c *Classifier) score(id string, unknown, known *indexedDocument, unknownStart, unknownEnd int, bound int) (float64, int, int) {
+	c.log(PhaseScore, LevelDebug, "scoring candidate", "license", known.s.origin, "start", unknownStart, "end", unknownEnd)
+	dm := unknown.metrics
+	clock := dm.now()
+	defer func() { dm.record(PhaseScore, clock) }()
 
 	knownLength := known.size()
+	// The known tokens missing from a shorter unknown are edits, so the diff
+	// is skipped when there are too many of them.
+	required := knownLength
+	if known.template != nil {
+		required -= known.template.omittable
+	}
+	if required-(unknownEnd-unknownStart) > bound {
+		c.log(PhaseScore, LevelDebug, "candidate too short for the threshold", "license", known.s.origin, "maxDistance", bound)
+		return 0.0, 0, 0
+	}
 	diffs := docDiff(id, unknown, unknownStart, unknownEnd, known, 0, knownLength)
+	clock = dm.record(PhaseDiff, clock)
+	c.log(PhaseDiff, LevelDebug, "diffed candidate", "license", known.s.origin, "diffs", len(diffs))
 
 	start, end := diffRange(known.norm, diffs)
-	distance := scoreDiffs(id, diffs[start:end])
+	scored := diffs[start:end]
+	if known.template != nil {
+		scored, _ = known.template.filter(scored)
+	}
+	distance := scoreDiffs(id, scored, bound)
+	if len(c.rules) > 0 {
+		var verdict string
+		distance, verdict = c.applyDiffRules(id, scored, distance, bound)
+		if verdict != "" {
+			c.log(PhaseDiff, LevelInfo, "diff rule decided", "license", known.s.origin, "verdict", verdict)
+		}
+	}
 	if distance < 0 {
 		// If the distance is negative, this indicates an unacceptable diff so we return a zero-confidence match.
-		if c.tc.traceScoring(known.s.origin) {
-			c.tc.trace("Distance result %v, rejected match", distance)
-		}
+		c.log(PhaseDiff, LevelDebug, "diff rejected", "license", known.s.origin, "reason", rejectionReasons[distance])
 		return 0.0, 0, 0
 	}
 
@@ -62,11 +120,17 @@ func (c *Classifier) score(id string, unknown, known *indexedDocument, unknownSt
 	// corresponding to those regions.  This results in a more accurate
 	// confidence score and better position detection of the source in the
 	// target.
-	conf, so, eo := confidencePercentage(knownLength, distance), textLength(diffs[:start]), textLength(diffs[end:])
-
-	if c.tc.traceScoring(known.s.origin) {
-		c.tc.trace("Score result: %v [%d-%d]", conf, so, eo)
+	so, eo := textLength(diffs[:start]), textLength(diffs[end:])
+	exempt := exemptionDistance(unknown, known, unknownStart+so, unknownEnd-eo)
+	conf := confidencePercentage(knownLength, distance+exempt)
+	switch {
+	case c.weighted:
+		conf = 1.0 - (c.weightedDistance(scored)+float64(exempt))/float64(knownLength)
+	case c.edits != DefaultEditWeights:
+		conf = 1.0 - (c.editCost(scored, unitWeight)+float64(exempt))/float64(knownLength)
 	}
+
+	c.log(PhaseScore, LevelDebug, "scored candidate", "license", known.s.origin, "confidence", conf, "startOffset", so, "endOffset", eo)
 	return conf, so, eo
 }
 
@@ -82,28 +146,122 @@ func confidencePercentage(klen, distance int) float64 {
 	return 1.0 - float64(distance)/float64(klen)
 }
 
+// maxDistance returns the largest distance of a match of a known document of
+// klen tokens with a confidence of at least floor, when an edit costs at
+// least minCost. With token weighting, for example, an edit costs at least
+// half a token, so twice as many edits are allowed.
+func maxDistance(klen int, floor float64, minCost float64) int {
+	if floor <= 0 {
+		return noBound
+	}
+	// Computing the confidence of the candidate distances, rather than
+	// inverting confidencePercentage, rounds exactly as it does.
+	d := int((1-floor)*float64(klen)) + 1
+	for d > 0 && confidencePercentage(klen, d) < floor {
+		d--
+	}
+	if minCost != 1 {
+		d = int(float64(d)/minCost) + 1
+	}
+	return d
+}
+
+// levenshteinWord accumulates the word-based Levenshtein distance of a diff.
+// Since the distance never decreases as diffs are added, it can be compared
+// with a bound before the whole diff is seen.
+type levenshteinWord struct {
+	distance   int
+	insertions int
+	deletions  int
+}
+
+func (l *levenshteinWord) add(d diffmatchpatch.Diff) {
+	switch d.Type {
+	case diffmatchpatch.DiffInsert:
+		l.insertions += wordLen(d.Text)
+	case diffmatchpatch.DiffDelete:
+		l.deletions += wordLen(d.Text)
+	case diffmatchpatch.DiffEqual:
+		// A deletion and an insertion is one substitution.
+		l.distance += max(l.insertions, l.deletions)
+		l.insertions = 0
+		l.deletions = 0
+	}
+}
+
+// total returns the distance of the diffs added so far.
+func (l *levenshteinWord) total() int {
+	return l.distance + max(l.insertions, l.deletions)
+}
+
 // diffLevenshteinWord computes word-based Levenshtein count.
 func diffLevenshteinWord(diffs []diffmatchpatch.Diff) int {
-	levenshtein := 0
-	insertions := 0
-	deletions := 0
+	var l levenshteinWord
+	for _, aDiff := range diffs {
+		l.add(aDiff)
+	}
+	return l.total()
+}
+
+// minTokenWeight is the least weight of an edit to a word.
+const minTokenWeight = 0.5
+
+// tokenWeight returns the weight of an edit to the supplied word, based on
+// the inverse document frequency of the word in the corpus. Weights range
+// from 0.5 for words that appear in every corpus document to 1.5 for words
+// unique to a single document. Words that aren't in the corpus have a weight
+// of 1.
+func (c *Classifier) tokenWeight(word string) float64 {
+	df := c.docFreq[c.dict.getIndex(word)]
+	n := len(c.docs)
+	if df == 0 || n < 2 {
+		return 1.0
+	}
+	return minTokenWeight + math.Log(float64(n)/float64(df))/math.Log(float64(n))
+}
+
+// unitWeight weighs edits to all words alike.
+func unitWeight(string) float64 {
+	return 1
+}
+
+// weightedDistance computes a word-based Levenshtein distance like
+// diffLevenshteinWord, where each edited word counts for its tokenWeight
+// instead of 1.
+func (c *Classifier) weightedDistance(diffs []diffmatchpatch.Diff) float64 {
+	return c.editCost(diffs, c.tokenWeight)
+}
+
+// editCost computes the cost of the edits of a diff, where each edited word
+// counts for its weight and the edits cost as set by the edit weights of the
+// classifier. With the default edit weights and unitWeight, it is the
+// distance of diffLevenshteinWord.
+func (c *Classifier) editCost(diffs []diffmatchpatch.Diff, weight func(word string) float64) float64 {
+	sum := func(text string) float64 {
+		w := 0.0
+		for _, word := range strings.Split(text, " ") {
+			w += weight(word)
+		}
+		return w
+	}
 
+	distance := 0.0
+	insertions := 0.0
+	deletions := 0.0
 	for _, aDiff := range diffs {
 		switch aDiff.Type {
 		case diffmatchpatch.DiffInsert:
-			insertions += wordLen(aDiff.Text)
+			insertions += sum(aDiff.Text)
 		case diffmatchpatch.DiffDelete:
-			deletions += wordLen(aDiff.Text)
+			deletions += sum(aDiff.Text)
 		case diffmatchpatch.DiffEqual:
 			// A deletion and an insertion is one substitution.
-			levenshtein += max(insertions, deletions)
+			distance += c.edits.cost(deletions, insertions)
 			insertions = 0
 			deletions = 0
 		}
 	}
-
-	levenshtein += max(insertions, deletions)
-	return levenshtein
+	return distance + c.edits.cost(deletions, insertions)
 }
 
 func isVersionNumber(in string) bool {
@@ -115,18 +273,51 @@ func isVersionNumber(in string) bool {
 	return true
 }
 
+// inducedPhrases are phrases that can't be introduced to make a license hit,
+// keyed by the prefix of the license names they apply to. Most of these are
+// words or phrases that appear in a single/small number of licenses. TODO:
+// would like to generate this programmatically. Can we leverage frequency
+// analysis to identify these interesting words/phrases and auto-extract them?
+//
+// This table is shared by all concurrent scoring operations and must not be
+// modified after initialization.
+var inducedPhrases = map[string][]string{
+	"AGPL":                             {"affero"},
+	"Atmel":                            {"atmel"},
+	"Apache":                           {"apache"},
+	"BSD":                              {"bsd"},
+	"BSD-3-Clause-Attribution":         {"acknowledgment"},
+	"bzip2":                            {"seward"},
+	"GPL-2.0-with-GCC-exception":       {"gcc linking exception"},
+	"GPL-2.0-with-autoconf-exception":  {"autoconf exception"},
+	"GPL-2.0-with-bison-exception":     {"bison exception"},
+	"GPL-2.0-with-classpath-exception": {"class path exception"},
+	"GPL-2.0-with-font-exception":      {"font exception"},
+	"LGPL-2.0":                         {"library"},
+	"ImageMagick":                      {"imagemagick"},
+	"PHP":                              {"php"},
+	"SISSL":                            {"sun standards"},
+	"SGI-B":                            {"silicon graphics"},
+	"X11":                              {"x consortium"},
+}
+
 // scoreDiffs returns a score rating the acceptability of these diffs.  A
 // negative value means that the changes represented by the diff are not an
-// acceptable transformation since it would change the underlying license.  A
-// positive value indicates the Levenshtein word distance.
-func scoreDiffs(id string, diffs []diffmatchpatch.Diff) int {
+// acceptable transformation since it would change the underlying license, or
+// that the distance exceeds maxDistance, in which case the rest of the diffs
+// aren't examined. A positive value indicates the Levenshtein word distance.
+func scoreDiffs(id string, diffs []diffmatchpatch.Diff, maxDistance int) int {
 	// We make a pass looking for unacceptable substitutions
 	// Delete diffs are always ordered before insert diffs. This is leveraged to
 	// analyze a change by checking an insert against the delete text that was
 	// previously cached.
 	prevText := ""
 	prevDelete := ""
+	var distance levenshteinWord
 	for _, diff := range diffs {
+		if distance.add(diff); distance.total() > maxDistance {
+			return distanceExceeded
+		}
 		text := diff.Text
 		switch diff.Type {
 		case diffmatchpatch.DiffInsert:
@@ -139,32 +330,6 @@ func scoreDiffs(id string, diffs []diffmatchpatch.Diff) int {
 					return versionChange
 				}
 			}
-			// There are certain phrases that can't be introduced to make a license
-			// hit.  TODO: would like to generate this programmatically. Most of
-			// these are words or phrases that appear in a single/small number of
-			// licenses. Can we leverage frequency analysis to identify these
-			// interesting words/phrases and auto-extract them?
-
-			inducedPhrases := map[string][]string{
-				"AGPL":                             {"affero"},
-				"Atmel":                            {"atmel"},
-				"Apache":                           {"apache"},
-				"BSD":                              {"bsd"},
-				"BSD-3-Clause-Attribution":         {"acknowledgment"},
-				"bzip2":                            {"seward"},
-				"GPL-2.0-with-GCC-exception":       {"gcc linking exception"},
-				"GPL-2.0-with-autoconf-exception":  {"autoconf exception"},
-				"GPL-2.0-with-bison-exception":     {"bison exception"},
-				"GPL-2.0-with-classpath-exception": {"class path exception"},
-				"GPL-2.0-with-font-exception":      {"font exception"},
-				"LGPL-2.0":                         {"library"},
-				"ImageMagick":                      {"imagemagick"},
-				"PHP":                              {"php"},
-				"SISSL":                            {"sun standards"},
-				"SGI-B":                            {"silicon graphics"},
-				"X11":                              {"x consortium"},
-			}
-
 			for k, ps := range inducedPhrases {
 				if strings.HasPrefix(id, k) {
 					for _, p := range ps {
@@ -175,6 +340,10 @@ func scoreDiffs(id string, diffs []diffmatchpatch.Diff) int {
 				}
 			}
 
+			if isCreativeCommonsChange(id, text) {
+				return creativeCommonsChange
+			}
+
 			// Ignore changes between "library" and "lesser" in a GNU context as they
 			// changed the terms, but look for introductions of Lesser that would
 			// otherwise disqualify a match.
@@ -200,8 +369,11 @@ func scoreDiffs(id string, diffs []diffmatchpatch.Diff) int {
 					return lesserGPLChange
 				}
 			}
+			if isCreativeCommonsChange(id, text) {
+				return creativeCommonsChange
+			}
 			prevDelete = text
 		}
 	}
-	return diffLevenshteinWord(diffs)
+	return distance.total()
 }
@@ -179,11 +179,56 @@ func TestScoreDiffs(t *testing.T) {
 			},
 			expected: introducedPhraseChange,
 		},
+		{
+			name:    "creative commons noncommercial term removed",
+			license: "CC-BY-NC-4.0",
+			diffs: []diffmatchpatch.Diff{
+				{
+					Type: diffmatchpatch.DiffEqual,
+					Text: "attribution",
+				},
+				{
+					Type: diffmatchpatch.DiffInsert,
+					Text: "noncommercial",
+				},
+			},
+			expected: creativeCommonsChange,
+		},
+		{
+			name:    "creative commons sharealike term introduced",
+			license: "CC-BY-4.0",
+			diffs: []diffmatchpatch.Diff{
+				{
+					Type: diffmatchpatch.DiffEqual,
+					Text: "attribution",
+				},
+				{
+					Type: diffmatchpatch.DiffDelete,
+					Text: "sharealike",
+				},
+			},
+			expected: creativeCommonsChange,
+		},
+		{
+			name:    "creative commons terms ignored for other licenses",
+			license: "MIT",
+			diffs: []diffmatchpatch.Diff{
+				{
+					Type: diffmatchpatch.DiffEqual,
+					Text: "attribution",
+				},
+				{
+					Type: diffmatchpatch.DiffDelete,
+					Text: "sharealike",
+				},
+			},
+			expected: 1,
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			if got := scoreDiffs(test.license, test.diffs); got != test.expected {
+			if got := scoreDiffs(test.license, test.diffs, noBound); got != test.expected {
 				t.Errorf("got %d, want %d", got, test.expected)
 			}
 		})
@@ -274,7 +319,7 @@ func TestScore(t *testing.T) {
 			c.AddContent("known", []byte(test.known))
 			kd := c.docs["known"]
 			ud := c.createTargetIndexedDocument([]byte(test.unknown))
-			conf, so, eo := c.score(test.name, ud, kd, 0, ud.size())
+			conf, so, eo := c.score(test.name, ud, kd, 0, ud.size(), noBound)
 
 			success := true
 			if conf != test.expectedConf {
@@ -296,3 +341,118 @@ func TestScore(t *testing.T) {
 		})
 	}
 }
+
+func TestWeightedDistance(t *testing.T) {
+	c := NewClassifier(.8)
+	c.AddContent("one", []byte("the software is licensed under the apache terms"))
+	c.AddContent("two", []byte("the software is licensed under the mozilla terms"))
+	c.AddContent("three", []byte("the software is licensed under the eclipse terms"))
+
+	common := []diffmatchpatch.Diff{
+		{Type: diffmatchpatch.DiffEqual, Text: "software is licensed under"},
+		{Type: diffmatchpatch.DiffDelete, Text: "the"},
+	}
+	distinctive := []diffmatchpatch.Diff{
+		{Type: diffmatchpatch.DiffEqual, Text: "software is licensed under the"},
+		{Type: diffmatchpatch.DiffDelete, Text: "apache"},
+	}
+	unknown := []diffmatchpatch.Diff{
+		{Type: diffmatchpatch.DiffEqual, Text: "software is licensed under the"},
+		{Type: diffmatchpatch.DiffInsert, Text: "UNKNOWN"},
+	}
+
+	if got, want := c.weightedDistance(common), 0.5; got != want {
+		t.Errorf("weightedDistance(common word) = %v, want %v", got, want)
+	}
+	if got, want := c.weightedDistance(distinctive), 1.5; got != want {
+		t.Errorf("weightedDistance(distinctive word) = %v, want %v", got, want)
+	}
+	if got, want := c.weightedDistance(unknown), 1.0; got != want {
+		t.Errorf("weightedDistance(unknown word) = %v, want %v", got, want)
+	}
+
+	c.SetTokenWeighting(true)
+	kd := c.docs["one"]
+	ud := c.createTargetIndexedDocument([]byte("the software is licensed under the mozilla terms"))
+	weighted, _, _ := c.score("one", ud, kd, 0, ud.size(), noBound)
+	c.SetTokenWeighting(false)
+	unweighted, _, _ := c.score("one", ud, kd, 0, ud.size(), noBound)
+	if weighted >= unweighted {
+		t.Errorf("weighted confidence %v should be lower than unweighted %v for a distinctive edit", weighted, unweighted)
+	}
+}
+
+func TestDocFreqReplacedEntry(t *testing.T) {
+	c := NewClassifier(.8)
+	c.AddContent("one", []byte("the software is licensed under the apache terms"))
+	c.AddContent("two", []byte("the software is licensed under the mozilla terms"))
+	c.AddContent("one", []byte("the software is licensed under the eclipse terms"))
+
+	for word, want := range map[string]int{"software": 2, "mozilla": 1, "eclipse": 1, "apache": 0} {
+		if got := c.docFreq[c.dict.getIndex(word)]; got != want {
+			t.Errorf("docFreq[%q] = %d, want %d", word, got, want)
+		}
+	}
+}
+
+func TestMaxDistance(t *testing.T) {
+	tests := []struct {
+		klen    int
+		floor   float64
+		minCost float64
+		want    int
+	}{
+		{klen: 10, floor: .8, minCost: 1, want: 2},
+		{klen: 10, floor: .8, minCost: minTokenWeight, want: 5},
+		{klen: 10, floor: .8, minCost: .25, want: 9},
+		{klen: 10, floor: .8, minCost: 2, want: 2},
+		{klen: 100, floor: .8, minCost: 1, want: 20},
+		{klen: 7, floor: .9, minCost: 1, want: 0},
+		{klen: 10, floor: 1, minCost: 1, want: 0},
+		{klen: 10, floor: 0, minCost: 1, want: noBound},
+	}
+	for _, test := range tests {
+		got := maxDistance(test.klen, test.floor, test.minCost)
+		if got != test.want {
+			t.Errorf("maxDistance(%d, %v, %v) = %d, want %d", test.klen, test.floor, test.minCost, got, test.want)
+		}
+		if got != noBound && test.minCost == 1 && confidencePercentage(test.klen, got) < test.floor {
+			t.Errorf("maxDistance(%d, %v, 1) = %d has confidence below the floor", test.klen, test.floor, got)
+		}
+	}
+}
+
+func TestScoreDiffsBound(t *testing.T) {
+	diffs := []diffmatchpatch.Diff{
+		{Type: diffmatchpatch.DiffDelete, Text: "two deleted"},
+		{Type: diffmatchpatch.DiffEqual, Text: "identical words"},
+		{Type: diffmatchpatch.DiffInsert, Text: "inserted"},
+	}
+	if got, want := scoreDiffs("MIT", diffs, 3), 3; got != want {
+		t.Errorf("scoreDiffs() at the bound = %d, want %d", got, want)
+	}
+	if got, want := scoreDiffs("MIT", diffs, 2), distanceExceeded; got != want {
+		t.Errorf("scoreDiffs() past the bound = %d, want %d", got, want)
+	}
+}
+
+func TestScoreBound(t *testing.T) {
+	c := NewClassifier(.8)
+	c.AddContent("known", []byte("one two three four five six seven eight nine ten"))
+	kd := c.docs["known"]
+	ud := c.createTargetIndexedDocument([]byte("one two three four five six seven eight"))
+	if got, want := kd.maxDistance, 2; got != want {
+		t.Errorf("maxDistance = %d, want %d", got, want)
+	}
+	if conf, _, _ := c.score("known", ud, kd, 0, ud.size(), kd.maxDistance); conf != .8 {
+		t.Errorf("score() within the bound = %v, want .8", conf)
+	}
+	if conf, _, _ := c.score("known", ud, kd, 0, ud.size(), 1); conf != 0 {
+		t.Errorf("score() past the bound = %v, want 0", conf)
+	}
+
+	c.SetTokenWeighting(true)
+	if got, want := kd.maxDistance, 5; got != want {
+		t.Errorf("weighted maxDistance = %d, want %d", got, want)
+	}
+}
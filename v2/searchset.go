@@ -19,8 +19,6 @@ import (
 	"hash/crc32"
 	"math"
 	"sort"
-
-	"github.com/davecgh/go-spew/spew"
 )
 
 // searchSet is a set of q-grams that have hashes associated with them,
@@ -28,27 +26,18 @@ import (
 type searchSet struct {
 	// Tokens is a tokenized list of the original input string.
 	Tokens []indexedToken
-	// Hashes is a map of checksums to a range of tokens.
+	// Hashes maps the checksums of the q-grams to their offsets, unless the
+	// searchset was loaded from an index, which keeps them in packed.
 	Hashes hash
-	// Checksums is a list of checksums ordered from longest range to
-	// shortest.
+	packed *packedHash
+	// Checksums are the checksums of the q-grams in order, so that the q-gram
+	// of the checksum at index i covers the tokens i through i+q-1. The
+	// ranges of tokens are implied rather than stored, since a searchset is
+	// kept for every corpus entry.
 	Checksums []uint32
-	// ChecksumRanges are the token ranges for the above checksums.
-	ChecksumRanges tokenRanges
-	origin         string // A debugging identifier to label what this searchset is associated with
-
-	nodes []*node
-	q     int // The length of q-grams in this searchset.
-}
+	origin    string // A debugging identifier to label what this searchset is associated with
 
-// node consists of a range of tokens along with the checksum for those tokens.
-type node struct {
-	checksum uint32
-	tokens   *tokenRange
-}
-
-func (n *node) String() string {
-	return fmt.Sprintf("[%d:%d]", n.tokens.Start, n.tokens.End)
+	q int // The length of q-grams in this searchset.
 }
 
 // newSearchSet creates a new searchSet object. A searchset generates all
@@ -62,16 +51,27 @@ func newSearchSet(s *indexedDocument, q int) *searchSet {
 		// We can't have a smaller q than the number of tokens.
 		q = len(s.Tokens)
 	}
-	checksums, tokenRanges := generateHashes(h, q, s.Tokens, s.dict)
-	sset := &searchSet{
-		Tokens:         s.Tokens,
-		Hashes:         h,
-		Checksums:      checksums,
-		ChecksumRanges: tokenRanges,
-		q:              q,
+	return &searchSet{
+		Tokens:    s.Tokens,
+		Hashes:    h,
+		Checksums: generateHashes(h, q, s.Tokens, s.dict, nil),
+		q:         q,
+	}
+}
+
+// newTargetSearchSet creates the searchSet of a target, whose checksums are
+// taken from a pool and returned by release. Targets are only searched for
+// the q-grams of the corpus, so they have no hashes.
+func newTargetSearchSet(s *indexedDocument, q int) *searchSet {
+	if len(s.Tokens) < q {
+		q = len(s.Tokens)
+	}
+	css := checksumPool.Get().(*[]uint32)
+	return &searchSet{
+		Tokens:    s.Tokens,
+		Checksums: generateHashes(nil, q, s.Tokens, s.dict, (*css)[:0]),
+		q:         q,
 	}
-	sset.generateNodeList()
-	return sset
 }
 
 // tokenRange indicates the range of tokens that map to a particular checksum.
@@ -84,44 +84,27 @@ func (t *tokenRange) String() string {
 	return fmt.Sprintf("[%v, %v)", t.Start, t.End)
 }
 
-// tokenRanges is a sortable type of a slice of TokenRange.
-type tokenRanges []*tokenRange
-
-// generateHashes computes a hash using CRC-32 for each q-gram encountered in the provided tokens.
-func generateHashes(h hash, q int, toks []indexedToken, dict *dictionary) ([]uint32, tokenRanges) {
+// generateHashes computes a hash using CRC-32 for each q-gram encountered in
+// the provided tokens, appending the checksums to css and adding them to h
+// unless it is nil.
+func generateHashes(h hash, q int, toks []indexedToken, dict *dictionary, css []uint32) []uint32 {
 	if q == 0 {
-		return nil, nil
+		return css
 	}
-	var css []uint32
-	var tr tokenRanges
-	crc := crc32.NewIEEE()
+	var gram []byte
 	for offset := 0; offset+q <= len(toks); offset++ {
-		crc.Reset()
+		gram = gram[:0]
 		for i := 0; i < q; i++ {
-			crc.Write([]byte(dict.getWord(toks[offset+i].ID)))
-			crc.Write([]byte{' '})
+			gram = append(append(gram, dict.getWord(toks[offset+i].ID)...), ' ')
 		}
-		cs := crc.Sum32()
+		cs := crc32.ChecksumIEEE(gram)
 		css = append(css, cs)
-		tr = append(tr, &tokenRange{offset, offset + q})
-		h.add(cs, offset, offset+q)
-	}
-
-	return css, tr
-}
-
-// generateNodeList creates a node list out of the search set.
-func (s *searchSet) generateNodeList() {
-	if len(s.Tokens) == 0 {
-		return
+		if h != nil {
+			h.add(cs, offset)
+		}
 	}
 
-	for i := 0; i < len(s.Checksums); i++ {
-		s.nodes = append(s.nodes, &node{
-			checksum: s.Checksums[i],
-			tokens:   s.ChecksumRanges[i],
-		})
-	}
+	return css
 }
 
 // matchRange is the range within the source text that is a match to the range
@@ -171,9 +154,7 @@ func (m matchRanges) Less(i, j int) bool {
 // are best potential matches to the source (known) text.
 func (c *Classifier) findPotentialMatches(src, target *searchSet, confidence float64) matchRanges {
 	matchedRanges := c.getMatchedRanges(src, target, confidence, src.q)
-	if c.tc.traceSearchset(src.origin) {
-		c.tc.trace("matchedRanges = %s", spew.Sdump(matchedRanges))
-	}
+	c.log(PhaseSearchset, LevelDebug, "fused ranges", "license", src.origin, "ranges", matchedRanges)
 	if len(matchedRanges) == 0 {
 		return nil
 	}
@@ -181,7 +162,7 @@ func (c *Classifier) findPotentialMatches(src, target *searchSet, confidence flo
 	// After computing all potential matches, we only output ranges that contain
 	// enough tokens to clear the confidence threshold. As noted, this number can
 	// be too high, yielding false positives, but cannot yield false negatives.
-	threshold := int(confidence * float64(len(src.Tokens)))
+	threshold := int(confidence * float64(c.requiredTokens(src)))
 
 	for i, m := range matchedRanges {
 		if m.TokensClaimed < threshold {
@@ -193,6 +174,22 @@ func (c *Classifier) findPotentialMatches(src, target *searchSet, confidence flo
 	return matchedRanges
 }
 
+// requiredTokens returns the number of tokens of the source that a match
+// must have. The optional text of a template needn't be present, which
+// matters most for short templates, where it can be a large part of the text.
+func (c *Classifier) requiredTokens(src *searchSet) int {
+	return len(src.Tokens) - c.omittable(src.origin)
+}
+
+// omittable returns the number of tokens of the named corpus entry that may
+// be missing from a match.
+func (c *Classifier) omittable(name string) int {
+	if d, ok := c.docs[name]; ok && d.template != nil {
+		return d.template.omittable
+	}
+	return 0
+}
+
 // fuseRanges analyzes the source matches, attempting to combine hits without
 // errors into larger hits with tolerable amounts of error to produce matches
 // that contain enough tokens to be considered for exact matching against a a
@@ -202,6 +199,8 @@ func (c *Classifier) findPotentialMatches(src, target *searchSet, confidence flo
 func (c *Classifier) fuseRanges(origin string, matched matchRanges, confidence float64, size int, runs []matchRange, targetSize int) matchRanges {
 	var claimed matchRanges
 	errorMargin := int(math.Round(float64(size) * (1.0 - confidence)))
+	q := computeQ(confidence)
+	omittable := c.omittable(origin)
 
 	filter := make([]bool, targetSize)
 	for _, m := range runs {
@@ -226,8 +225,10 @@ func (c *Classifier) fuseRanges(origin string, matched matchRanges, confidence f
 		// practice, this would only be an issue if there are major substrings of a
 		// source in a target that aren't part of a real hit. We see many small
 		// references (the name of a license) but not large chunks of the license.
+		// Optional text of a template missing before the hit shifts it by up to
+		// the omittable tokens.
 		if off < 0 {
-			if -off <= errorMargin {
+			if -off <= errorMargin+omittable {
 				off = 0
 			} else {
 				continue
@@ -248,6 +249,12 @@ func (c *Classifier) fuseRanges(origin string, matched matchRanges, confidence f
 		for _, c := range claimed {
 			moff := m.TargetStart - m.SrcStart
 			coff := c.TargetStart - c.SrcStart
+			// Deletions and insertions shift the offset along a claim, so a hit
+			// past its end is compared with the offset at the end. Only hits of a
+			// few q-grams are, since a short one is as likely to be a stray phrase.
+			if m.TargetStart >= c.TargetEnd && m.TokensClaimed >= 2*q {
+				coff = c.TargetEnd - c.SrcEnd
+			}
 			sampleError := int(math.Round(math.Abs(float64(moff - coff))))
 			withinError := sampleError < errorMargin
 
@@ -297,16 +304,12 @@ func (c *Classifier) fuseRanges(origin string, matched matchRanges, confidence f
 		if unclaimed && m.TokensClaimed*10 > matched[0].TokensClaimed {
 			claimed = append(claimed, m)
 		}
-		if c.tc.traceSearchset(origin) {
-			c.tc.trace("after %d ranges, claimed is %s", i, spew.Sdump(claimed))
+		if c.logging(PhaseSearchset, LevelDebug) {
+			c.log(PhaseSearchset, LevelDebug, "claimed ranges", "license", origin, "hits", i+1, "claimed", claimed)
 		}
 	}
 	sort.Sort(claimed)
-	if c.tc.traceSearchset(origin) {
-		c.tc.trace("filterPasses = %+v", filterPasses)
-		c.tc.trace("filterDrops = %+v", filterDrops)
-		c.tc.trace("claimed = %s", spew.Sdump(claimed))
-	}
+	c.log(PhaseSearchset, LevelDebug, "fused hits", "license", origin, "filterPasses", filterPasses, "filterDrops", filterDrops, "claimed", claimed)
 	return claimed
 }
 
@@ -314,17 +317,10 @@ func (c *Classifier) fuseRanges(origin string, matched matchRanges, confidence f
 // text. The ranges returned are ordered from the entries with the most matched
 // tokens to the least.
 func (c *Classifier) getMatchedRanges(src, target *searchSet, confidence float64, q int) matchRanges {
-	shouldTrace := c.tc.traceSearchset(src.origin)
-
-	if shouldTrace {
-		c.tc.trace("src.origin = %+v", src.origin)
-	}
 	// Assemble a list of all the matched q-grams without any consideration to
 	// error tolerances.
 	matched := targetMatchedRanges(src, target)
-	if shouldTrace {
-		c.tc.trace("matched = %s", spew.Sdump(matched))
-	}
+	c.log(PhaseSearchset, LevelDebug, "matched q-grams", "license", src.origin, "matched", matched)
 	if len(matched) == 0 {
 		return nil
 	}
@@ -342,11 +338,10 @@ func (c *Classifier) getMatchedRanges(src, target *searchSet, confidence float64
 	// significantly since processing token matches is an N^2 (or worse)
 	// operation, so reducing N is a big win.
 
-	runs := c.detectRuns(src.origin, matched, len(target.Tokens), len(src.Tokens), confidence, q)
+	size := c.requiredTokens(src)
+	runs := c.detectRuns(src.origin, matched, len(target.Tokens), size, confidence, q)
 
-	if shouldTrace {
-		c.tc.trace("runs = %d: %s", len(runs), spew.Sdump(runs))
-	}
+	c.log(PhaseSearchset, LevelDebug, "detected runs", "license", src.origin, "runs", runs)
 
 	// If there are no target runs of source tokens, we're done.
 	if len(runs) == 0 {
@@ -357,15 +352,11 @@ func (c *Classifier) getMatchedRanges(src, target *searchSet, confidence float64
 	// match ranges into larger matches (with possible errors) to see if we can
 	// produce large enough runs that pass the confidence threshold.
 
-	fr := c.fuseRanges(src.origin, matched, confidence, len(src.Tokens), runs, len(target.Tokens))
-	if shouldTrace {
-		c.tc.trace("fr = %s", spew.Sdump(fr))
-	}
+	fr := c.fuseRanges(src.origin, matched, confidence, size, runs, len(target.Tokens))
 	return fr
 }
 
 func (c *Classifier) detectRuns(origin string, matched matchRanges, targetLength, subsetLength int, threshold float64, q int) []matchRange {
-	shouldTrace := c.tc.traceSearchset(origin)
 	hits := make([]bool, targetLength)
 	for _, m := range matched {
 		for idx := m.TargetStart; idx < m.TargetEnd; idx++ {
@@ -380,18 +371,12 @@ func (c *Classifier) detectRuns(origin string, matched matchRanges, targetLength
 
 	total := 0
 	target := int(float64(subsetLength) * threshold)
-	if shouldTrace {
-		c.tc.trace("target = %+v", target)
-		c.tc.trace("targetLength = %+v", targetLength)
-		c.tc.trace("subsetLength = %+v", subsetLength)
-	}
+	c.log(PhaseSearchset, LevelDebug, "detecting runs", "license", origin, "target", target, "targetLength", targetLength, "subsetLength", subsetLength)
 
 	// If we don't have at least 1 subset (i.e. the target is shorter than the
 	// source) just analyze what we have.
 	if len(hits) < subsetLength {
-		if shouldTrace {
-			c.tc.trace("trimmed search length from %d to %d", subsetLength, len(hits))
-		}
+		c.log(PhaseSearchset, LevelDebug, "trimmed search length", "license", origin, "from", subsetLength, "to", len(hits))
 		subsetLength = len(hits)
 	}
 	// Initialize our sliding window value.
@@ -423,20 +408,28 @@ func (c *Classifier) detectRuns(origin string, matched matchRanges, targetLength
 		return nil
 	}
 
+	// A window starting near the end of a target shorter than the source can
+	// extend past the target, so the ends of the runs are clamped to it.
+	end := func(start int) int {
+		if start+q > targetLength {
+			return targetLength
+		}
+		return start + q
+	}
 	final := []matchRange{
 		{
 			SrcStart: out[0],
-			SrcEnd:   out[0] + q,
+			SrcEnd:   end(out[0]),
 		},
 	}
 	for i := 1; i < len(out); i++ {
 		if out[i] != 1+out[i-1] {
 			final = append(final, matchRange{
 				SrcStart: out[i],
-				SrcEnd:   out[i] + q,
+				SrcEnd:   end(out[i]),
 			})
 		} else {
-			final[len(final)-1].SrcEnd = out[i] + q
+			final[len(final)-1].SrcEnd = end(out[i])
 		}
 	}
 
@@ -447,14 +440,15 @@ func targetMatchedRanges(src, target *searchSet) matchRanges {
 	offsetMappings := make(map[int][]*matchRange)
 
 	var matched matchRanges
-	for _, tgtNode := range target.nodes {
-		sr, ok := src.Hashes[tgtNode.checksum]
-		if !ok {
+	for i, checksum := range target.Checksums {
+		sr := src.offsets(checksum)
+		if len(sr) == 0 {
 			continue
 		}
 
-		tv := tgtNode.tokens
-		for _, sv := range sr {
+		tv := tokenRange{Start: i, End: i + target.q}
+		for _, start := range sr {
+			sv := tokenRange{Start: int(start), End: int(start) + src.q}
 			offset := tv.Start - sv.Start
 			if om, ok := offsetMappings[offset]; ok {
 				// See if this extends the most recent existing mapping
@@ -486,8 +480,54 @@ func targetMatchedRanges(src, target *searchSet) matchRanges {
 	return matched
 }
 
-type hash map[uint32]tokenRanges
+// hash maps the checksums of q-grams to the offsets of the first tokens of
+// the q-grams with them.
+type hash map[uint32][]uint32
+
+func (h hash) add(checksum uint32, start int) {
+	h[checksum] = append(h[checksum], uint32(start))
+}
+
+// packedHash is the form of a hash stored in an index: the checksums in
+// increasing order, the end of the offsets of each of them and the offsets,
+// which can be read in place from a mapped file.
+type packedHash struct {
+	keys, ends, offsets []uint32
+}
+
+// pack returns the packed form of h.
+func (h hash) pack() *packedHash {
+	p := &packedHash{keys: make([]uint32, 0, len(h))}
+	for k := range h {
+		p.keys = append(p.keys, k)
+	}
+	sort.Slice(p.keys, func(i, j int) bool { return p.keys[i] < p.keys[j] })
+	p.ends = make([]uint32, len(p.keys))
+	for i, k := range p.keys {
+		p.offsets = append(p.offsets, h[k]...)
+		p.ends[i] = uint32(len(p.offsets))
+	}
+	return p
+}
+
+// get returns the offsets of the q-grams with the checksum.
+func (p *packedHash) get(checksum uint32) []uint32 {
+	i := sort.Search(len(p.keys), func(i int) bool { return p.keys[i] >= checksum })
+	if i == len(p.keys) || p.keys[i] != checksum {
+		return nil
+	}
+	start := uint32(0)
+	if i > 0 {
+		start = p.ends[i-1]
+	}
+	return p.offsets[start:p.ends[i]]
+}
 
-func (h hash) add(checksum uint32, start, end int) {
-	h[checksum] = append(h[checksum], &tokenRange{Start: start, End: end})
+// offsets returns the offsets of the q-grams of the searchset with the
+// checksum.
+func (s *searchSet) offsets(checksum uint32) []uint32 {
+	if s.packed != nil {
+		return s.packed.get(checksum)
+	}
+	return s.Hashes[checksum]
 }
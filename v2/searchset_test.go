@@ -63,10 +63,9 @@ func TestSearchSet_New(t *testing.T) {
 			text:        "",
 			q:           4,
 			want: &searchSet{
-				Tokens:         []indexedToken{},
-				Hashes:         make(hash),
-				Checksums:      nil,
-				ChecksumRanges: nil,
+				Tokens:    []indexedToken{},
+				Hashes:    make(hash),
+				Checksums: nil,
 			},
 		},
 		{
@@ -78,11 +77,9 @@ func TestSearchSet_New(t *testing.T) {
 					{Index: 0, Line: 1, ID: 1},
 					{Index: 1, Line: 1, ID: 2},
 				},
-				Hashes:         hash{1957950203: tokenRanges{&tokenRange{Start: 0, End: 2}}},
-				Checksums:      []uint32{1957950203},
-				ChecksumRanges: tokenRanges{&tokenRange{Start: 0, End: 2}},
-				nodes:          []*node{{1957950203, &tokenRange{Start: 0, End: 2}}},
-				q:              2,
+				Hashes:    hash{1957950203: []uint32{0}},
+				Checksums: []uint32{1957950203},
+				q:         2,
 			},
 		},
 	}
@@ -266,6 +263,46 @@ func TestFuseRanges(t *testing.T) {
 				},
 			},
 		},
+		{
+			// Deletions in the target shift the offset of each hit, so the last
+			// hit is too far from the start of the claim to extend it, but not
+			// from its end.
+			name: "drifting offset",
+			conf: .8,
+			size: 100,
+			in: matchRanges{
+				&matchRange{
+					SrcStart:      0,
+					SrcEnd:        50,
+					TargetStart:   0,
+					TargetEnd:     50,
+					TokensClaimed: 50,
+				},
+				&matchRange{
+					SrcStart:      56,
+					SrcEnd:        78,
+					TargetStart:   50,
+					TargetEnd:     72,
+					TokensClaimed: 22,
+				},
+				&matchRange{
+					SrcStart:      88,
+					SrcEnd:        100,
+					TargetStart:   72,
+					TargetEnd:     84,
+					TokensClaimed: 12,
+				},
+			},
+			out: matchRanges{
+				&matchRange{
+					SrcStart:      0,
+					SrcEnd:        100,
+					TargetStart:   0,
+					TargetEnd:     84,
+					TokensClaimed: 84,
+				},
+			},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
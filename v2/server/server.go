@@ -0,0 +1,432 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package server exposes the license classifier as an HTTP service, so that
+// continuous integration systems can run it as a long-lived sidecar rather
+// than loading the corpus for every scan. Requests and responses are JSON,
+// except for the content to classify, which is sent as the request body.
+//
+//	POST /v1/classify          classify the text of the request body
+//	POST /v1/classify-archive  classify the license files of a zip or tar archive
+//	POST /v1/annotate          classify the text of the request body, annotating each word of the matches
+//	GET  /v1/corpus            describe the licenses of the corpus
+//	GET  /healthz              report that the server is ready
+//
+// For example:
+//
+//	c, err := classifier.New(classifier.WithCorpusDir(dir))
+//	...
+//	log.Fatal(http.ListenAndServe(":8080", server.New(c)))
+//
+// The grpcserver module serves the same requests over gRPC.
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+// DefaultMaxBodySize is the default limit of the size of request bodies.
+const DefaultMaxBodySize = 32 << 20
+
+// Match is a match found in classified content.
+type Match struct {
+	// ID identifies the match among those of the content. See
+	// classifier.Match.ID.
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// SPDXID is the SPDX identifier of the license, as resolved by
+	// classifier.Resolve.
+	SPDXID     string `json:"spdxId"`
+	Expression string `json:"expression"`
+	MatchType  string `json:"matchType"`
+	// Kind is the kind of text matched: "full-text", "header", "reference",
+	// "grant" or "proprietary".
+	Kind       classifier.VariantKind `json:"kind"`
+	Category   string                 `json:"category,omitempty"`
+	Variant    string                 `json:"variant,omitempty"`
+	Language   string                 `json:"language,omitempty"`
+	Confidence float64                `json:"confidence"`
+	StartLine  int                    `json:"startLine"`
+	EndLine    int                    `json:"endLine"`
+	// PatentGrant and PatentRetaliation tell whether the patent clauses of
+	// the license are "present" in the matched text or "missing" from it,
+	// or whether the license has them at all.
+	PatentGrant       classifier.ClauseStatus `json:"patentGrant"`
+	PatentRetaliation classifier.ClauseStatus `json:"patentRetaliation"`
+	// TrademarkRestriction and BinaryAttribution are set if the matched
+	// text restricts the use of the names and trademarks of the licensors,
+	// or requires attribution in distributions in binary form.
+	TrademarkRestriction bool `json:"trademarkRestriction,omitempty"`
+	BinaryAttribution    bool `json:"binaryAttribution,omitempty"`
+	// RecommendedThreshold is the lowest confidence at which matches of the
+	// corpus entry are recommended to be trusted, if its metadata declares
+	// one.
+	RecommendedThreshold float64 `json:"recommendedThreshold,omitempty"`
+	// Phrases are the phrases that recognized proprietary terms.
+	Phrases []string `json:"phrases,omitempty"`
+	// Choice are the other licenses the content offers as alternatives to
+	// this one, if any.
+	Choice []string `json:"choice,omitempty"`
+	// Portion is the part of the license text found by a partial match.
+	Portion *Portion `json:"portion,omitempty"`
+	// VersionConflict is set if the matched text has language of another
+	// version of the license.
+	VersionConflict *VersionConflict `json:"versionConflict,omitempty"`
+	// Approximate is true if the confidence is an estimate, because the
+	// time budget of the document ran out.
+	Approximate bool `json:"approximate,omitempty"`
+	// Overlaps are the other licenses whose matches overlap this one, if
+	// the classifier reports overlapping matches.
+	Overlaps []string `json:"overlaps,omitempty"`
+	// Alias is the organization-specific alias of the license, if any.
+	Alias *classifier.Alias `json:"alias,omitempty"`
+	// Alternatives are the other licenses that matched the region, best
+	// first, if the classifier reports them.
+	Alternatives []Alternative `json:"alternatives,omitempty"`
+}
+
+// Alternative is a license that matched the region of a match with a lower
+// confidence.
+type Alternative struct {
+	Name       string  `json:"name"`
+	MatchType  string  `json:"matchType"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Portion is the part of a license text found by a partial match. See
+// classifier.Portion.
+type Portion struct {
+	Start       int     `json:"start"`
+	End         int     `json:"end"`
+	Length      int     `json:"length"`
+	Share       float64 `json:"share"`
+	FirstClause string  `json:"firstClause,omitempty"`
+	LastClause  string  `json:"lastClause,omitempty"`
+}
+
+// VersionConflict describes a match whose text has language of another
+// version of the license. See classifier.VersionConflict.
+type VersionConflict struct {
+	Reason string `json:"reason"`
+	// Versions are the matched license, then the other version.
+	Versions []Alternative `json:"versions"`
+	Phrases  []string      `json:"phrases"`
+}
+
+// ClassifyResponse is the response to a classify request.
+type ClassifyResponse struct {
+	Matches []Match `json:"matches"`
+	// Coverage is the fraction of the tokens of the content attributed to
+	// license matches.
+	Coverage float64 `json:"coverage"`
+}
+
+// AnnotateResponse is the response to an annotate request.
+type AnnotateResponse struct {
+	Matches []AnnotatedMatch `json:"matches"`
+}
+
+// AnnotatedMatch is a match with the annotation of the words of its text.
+type AnnotatedMatch struct {
+	Match
+	// Tokens are the words of the matched text and the license words
+	// missing from it, in order. Matches that aren't diffed against a
+	// license text, such as references, have none.
+	Tokens []Token `json:"tokens"`
+}
+
+// Token is a word of an annotated match. Start and End are byte offsets in
+// the request body. See classifier.TokenAnnotation.
+type Token struct {
+	Kind      classifier.SpanKind `json:"kind"`
+	Start     int                 `json:"start"`
+	End       int                 `json:"end"`
+	Text      string              `json:"text"`
+	Agreement float64             `json:"agreement"`
+}
+
+// ArchiveEntry holds the matches found in an entry of an archive.
+type ArchiveEntry struct {
+	Name    string  `json:"name"`
+	Matches []Match `json:"matches"`
+}
+
+// ArchiveResponse is the response to a classify-archive request. Entries
+// without matches are omitted.
+type ArchiveResponse struct {
+	Entries []ArchiveEntry `json:"entries"`
+}
+
+// License describes a license of the corpus.
+type License struct {
+	ID          string                  `json:"id"`
+	Name        string                  `json:"name"`
+	Category    string                  `json:"category"`
+	OSIApproved bool                    `json:"osiApproved"`
+	Obligations []classifier.Obligation `json:"obligations"`
+	Alias       *classifier.Alias       `json:"alias,omitempty"`
+	// Variants is the number of corpus entries of the license, MinTokens
+	// and MaxTokens their shortest and longest lengths, and IndexBytes an
+	// estimate of the memory of their index. See classifier.LicenseStats.
+	Variants   int   `json:"variants"`
+	MinTokens  int   `json:"minTokens"`
+	MaxTokens  int   `json:"maxTokens"`
+	IndexBytes int64 `json:"indexBytes"`
+}
+
+// CorpusResponse is the response to a corpus request.
+type CorpusResponse struct {
+	Version string `json:"version"`
+	// IndexBytes is an estimate of the memory of the index of the corpus.
+	IndexBytes int64     `json:"indexBytes"`
+	Licenses   []License `json:"licenses"`
+}
+
+// ErrorResponse is the body of unsuccessful responses.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server is an http.Handler serving classification requests with a shared
+// classifier.
+type Server struct {
+	// MaxBodySize limits the size of request bodies, or DefaultMaxBodySize
+	// if it is zero.
+	MaxBodySize int64
+
+	c   *classifier.Classifier
+	mux *http.ServeMux
+}
+
+// New returns a server classifying content with the supplied classifier,
+// which must not be modified while the server is in use.
+func New(c *classifier.Classifier) *Server {
+	s := &Server{c: c, mux: http.NewServeMux()}
+	s.mux.HandleFunc("/v1/classify", s.post(s.classify))
+	s.mux.HandleFunc("/v1/classify-archive", s.post(s.classifyArchive))
+	s.mux.HandleFunc("/v1/annotate", s.post(s.annotate))
+	s.mux.HandleFunc("/v1/corpus", s.corpus)
+	s.mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// post wraps a handler of the content of POST requests.
+func (s *Server) post(h func(w http.ResponseWriter, body []byte)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+		limit := s.MaxBodySize
+		if limit == 0 {
+			limit = DefaultMaxBodySize
+		}
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body, limit+1))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("couldn't read request: %v", err))
+			return
+		}
+		if int64(len(body)) > limit {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body exceeds %d bytes", limit))
+			return
+		}
+		h(w, body)
+	}
+}
+
+func (s *Server) classify(w http.ResponseWriter, body []byte) {
+	ms := s.c.Match(body)
+	writeJSON(w, http.StatusOK, &ClassifyResponse{Matches: matches(ms), Coverage: s.c.Coverage(body, ms)})
+}
+
+func (s *Server) annotate(w http.ResponseWriter, body []byte) {
+	ms := s.c.Match(body)
+	resp := &AnnotateResponse{Matches: []AnnotatedMatch{}}
+	for i, m := range matches(ms) {
+		e, err := s.c.Explain(body, ms[i])
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		tokens := []Token{}
+		for _, t := range e.AnnotateTokens() {
+			tokens = append(tokens, Token{Kind: t.Kind, Start: t.Start, End: t.End, Text: t.Text, Agreement: t.Agreement})
+		}
+		resp.Matches = append(resp.Matches, AnnotatedMatch{Match: m, Tokens: tokens})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// zipMagic starts zip archives.
+var zipMagic = []byte("PK\x03\x04")
+
+func (s *Server) classifyArchive(w http.ResponseWriter, body []byte) {
+	var results []*classifier.ArchiveResult
+	var err error
+	if bytes.HasPrefix(body, zipMagic) {
+		results, err = s.c.MatchArchive(bytes.NewReader(body), int64(len(body)))
+	} else {
+		results, err = s.c.MatchTar(bytes.NewReader(body))
+	}
+	switch {
+	case errors.Is(err, classifier.ErrDocumentTooLarge):
+		writeError(w, http.StatusRequestEntityTooLarge, err)
+		return
+	case err != nil:
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	resp := &ArchiveResponse{Entries: []ArchiveEntry{}}
+	for _, r := range results {
+		resp.Entries = append(resp.Entries, ArchiveEntry{Name: r.Name, Matches: matches(r.Matches)})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) corpus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	db := s.c.LicenseDB()
+	stats := make(map[string]classifier.LicenseStats)
+	for _, st := range s.c.Licenses() {
+		stats[st.Name] = st
+	}
+	resp := &CorpusResponse{Version: s.c.CorpusVersion(), IndexBytes: s.c.IndexBytes(), Licenses: []License{}}
+	for _, id := range db.IDs() {
+		l, _ := db.Lookup(id)
+		st := stats[id]
+		resp.Licenses = append(resp.Licenses, License{
+			ID:          l.ID,
+			Name:        l.Name,
+			Category:    l.Category,
+			OSIApproved: l.OSIApproved,
+			Obligations: l.Obligations,
+			Alias:       alias(l.Alias),
+			Variants:    st.Variants,
+			MinTokens:   st.MinTokens,
+			MaxTokens:   st.MaxTokens,
+			IndexBytes:  st.IndexBytes,
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// matches converts classifier matches to their response form.
+func matches(ms classifier.Matches) []Match {
+	out := []Match{}
+	for _, m := range ms {
+		var alts []Alternative
+		for _, a := range m.Alternatives {
+			alts = append(alts, Alternative{Name: a.Name, MatchType: a.MatchType, Confidence: a.Confidence})
+		}
+		out = append(out, Match{
+			ID:                m.ID,
+			Name:              m.Name,
+			SPDXID:            m.SPDXID(),
+			Expression:        m.Expression(),
+			MatchType:         m.MatchType,
+			Kind:              m.Kind(),
+			Category:          m.Category,
+			Variant:           m.Variant,
+			Language:          m.Language,
+			Confidence:        m.Confidence,
+			StartLine:         m.StartLine,
+			EndLine:           m.EndLine,
+			PatentGrant:       m.PatentGrant,
+			PatentRetaliation: m.PatentRetaliation,
+			Phrases:           m.Phrases,
+			Choice:            m.Choice,
+			Portion:           portion(m.Portion),
+			VersionConflict:   versionConflict(m.VersionConflict),
+			Approximate:       m.Approximate,
+			Overlaps:          m.Overlaps,
+			Alias:             alias(m.Alias),
+			Alternatives:      alts,
+
+			TrademarkRestriction: m.TrademarkRestriction,
+			BinaryAttribution:    m.BinaryAttribution,
+			RecommendedThreshold: recommendedThreshold(m),
+		})
+	}
+	return out
+}
+
+// recommendedThreshold returns the threshold recommended by the metadata of
+// the matched corpus entry, or 0 if it has none.
+func recommendedThreshold(m *classifier.Match) float64 {
+	if m.Metadata == nil {
+		return 0
+	}
+	return m.Metadata.Threshold
+}
+
+// alias returns the supplied alias, or nil if it is empty so that it is
+// omitted from responses.
+func alias(a classifier.Alias) *classifier.Alias {
+	if a == (classifier.Alias{}) {
+		return nil
+	}
+	return &a
+}
+
+// portion returns the response form of the supplied portion, or nil if there
+// is none.
+func portion(p *classifier.Portion) *Portion {
+	if p == nil {
+		return nil
+	}
+	return &Portion{Start: p.Start, End: p.End, Length: p.Length, Share: p.Share(), FirstClause: p.FirstClause, LastClause: p.LastClause}
+}
+
+// versionConflict returns the response form of the supplied conflict, or nil
+// if there is none.
+func versionConflict(v *classifier.VersionConflict) *VersionConflict {
+	if v == nil {
+		return nil
+	}
+	out := &VersionConflict{Reason: v.Reason(), Phrases: v.Phrases}
+	for _, a := range v.Versions {
+		out.Versions = append(out.Versions, Alternative{Name: a.Name, MatchType: a.MatchType, Confidence: a.Confidence})
+	}
+	return out
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, &ErrorResponse{Error: err.Error()})
+}
@@ -0,0 +1,206 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	c, err := classifier.New(
+		classifier.WithCorpusDir(filepath.Join("..", "licenses")),
+		classifier.WithAliases(map[string]classifier.Alias{"MIT": {ID: "ACME-1", Name: "ACME MIT"}}),
+		classifier.WithTopK(2),
+	)
+	if err != nil {
+		t.Fatalf("classifier.New() failed: %v", err)
+	}
+	s := New(c)
+	s.MaxBodySize = 1 << 20
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func readLicense(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := ioutil.ReadFile(filepath.Join("..", "licenses", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// do sends a request and decodes the JSON response into v, returning the
+// status code.
+func do(t *testing.T, method, url string, body []byte, v interface{}) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("%s %s: couldn't decode response: %v", method, url, err)
+	}
+	return resp.StatusCode
+}
+
+func TestClassify(t *testing.T) {
+	ts := newServer(t)
+
+	var got ClassifyResponse
+	if code := do(t, "POST", ts.URL+"/v1/classify", readLicense(t, "MIT.txt"), &got); code != http.StatusOK {
+		t.Fatalf("classify status = %d, want %d", code, http.StatusOK)
+	}
+	if len(got.Matches) == 0 || got.Matches[0].Name != "MIT" || got.Matches[0].MatchType != "License" {
+		t.Fatalf("classify matches = %+v, want MIT", got.Matches)
+	}
+	if a := got.Matches[0].Alias; a == nil || a.ID != "ACME-1" {
+		t.Errorf("classify MIT alias = %+v, want ACME-1", a)
+	}
+	if alts := got.Matches[0].Alternatives; len(alts) != 1 || alts[0].Confidence >= got.Matches[0].Confidence {
+		t.Errorf("classify MIT alternatives = %+v, want a single less confident license", alts)
+	}
+	if got.Coverage != 1 {
+		t.Errorf("classify MIT coverage = %v, want 1", got.Coverage)
+	}
+
+	got = ClassifyResponse{}
+	if code := do(t, "POST", ts.URL+"/v1/classify", []byte("nothing to see here"), &got); code != http.StatusOK || got.Matches == nil || len(got.Matches) != 0 || got.Coverage != 0 {
+		t.Errorf("classify = %d, %+v; want no matches and no coverage", code, got)
+	}
+
+	var e ErrorResponse
+	if code := do(t, "GET", ts.URL+"/v1/classify", nil, &e); code != http.StatusMethodNotAllowed || e.Error == "" {
+		t.Errorf("GET classify = %d, %+v; want %d", code, e, http.StatusMethodNotAllowed)
+	}
+	big := bytes.Repeat([]byte("x"), 1<<20+1)
+	if code := do(t, "POST", ts.URL+"/v1/classify", big, &e); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("classify of a large body = %d, want %d", code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	ts := newServer(t)
+
+	in := bytes.Replace(readLicense(t, "MIT.txt"), []byte("free of charge"), []byte("entirely free of charge"), 1)
+	var got AnnotateResponse
+	if code := do(t, "POST", ts.URL+"/v1/annotate", in, &got); code != http.StatusOK {
+		t.Fatalf("annotate status = %d, want %d", code, http.StatusOK)
+	}
+	if len(got.Matches) != 1 || got.Matches[0].Name != "MIT" || len(got.Matches[0].Tokens) == 0 {
+		t.Fatalf("annotate matches = %+v, want MIT with its tokens", got.Matches)
+	}
+	var deleted []string
+	for _, tok := range got.Matches[0].Tokens {
+		if tok.Kind == classifier.SpanDeleted {
+			deleted = append(deleted, string(in[tok.Start:tok.End]))
+		}
+	}
+	if strings.Join(deleted, " ") != "entirely" {
+		t.Errorf("annotate deleted tokens = %q, want entirely", deleted)
+	}
+
+	got = AnnotateResponse{}
+	if code := do(t, "POST", ts.URL+"/v1/annotate", []byte("SPDX-License-Identifier: MIT"), &got); code != http.StatusOK || len(got.Matches) != 1 || got.Matches[0].Tokens == nil || len(got.Matches[0].Tokens) != 0 {
+		t.Errorf("annotate of a reference = %d, %+v; want a match without tokens", code, got)
+	}
+}
+
+func TestClassifyArchive(t *testing.T) {
+	ts := newServer(t)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string][]byte{
+		"mod/LICENSE": readLicense(t, "Apache-2.0.txt"),
+		"mod/main.go": []byte("package main"),
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(content)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got ArchiveResponse
+	if code := do(t, "POST", ts.URL+"/v1/classify-archive", buf.Bytes(), &got); code != http.StatusOK {
+		t.Fatalf("classify-archive status = %d, want %d", code, http.StatusOK)
+	}
+	if len(got.Entries) != 1 || got.Entries[0].Name != "mod/LICENSE" || got.Entries[0].Matches[0].Name != "Apache-2.0" {
+		t.Errorf("classify-archive entries = %+v, want Apache-2.0 in mod/LICENSE", got.Entries)
+	}
+
+	var e ErrorResponse
+	if code := do(t, "POST", ts.URL+"/v1/classify-archive", []byte("not an archive"), &e); code != http.StatusBadRequest || e.Error == "" {
+		t.Errorf("classify-archive of text = %d, %+v; want %d", code, e, http.StatusBadRequest)
+	}
+}
+
+func TestCorpus(t *testing.T) {
+	ts := newServer(t)
+
+	var got CorpusResponse
+	if code := do(t, "GET", ts.URL+"/v1/corpus", nil, &got); code != http.StatusOK {
+		t.Fatalf("corpus status = %d, want %d", code, http.StatusOK)
+	}
+	if got.Version == "" {
+		t.Error("corpus version is empty")
+	}
+	if got.IndexBytes <= 0 {
+		t.Errorf("corpus index bytes = %d, want them positive", got.IndexBytes)
+	}
+	var mit *License
+	for i, l := range got.Licenses {
+		if l.ID == "MIT" {
+			mit = &got.Licenses[i]
+		}
+	}
+	if mit == nil || mit.Name != "MIT License" || !mit.OSIApproved || len(mit.Obligations) != 1 || mit.Alias == nil {
+		t.Errorf("corpus MIT = %+v, want the aliased MIT License", mit)
+	}
+	if mit != nil && (mit.Variants == 0 || mit.MaxTokens == 0 || mit.IndexBytes == 0) {
+		t.Errorf("corpus MIT = %+v, want the statistics of its entries", mit)
+	}
+
+	resp, err := http.Get(ts.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || strings.TrimSpace(string(b)) != "ok" {
+		t.Errorf("healthz = %d %q, want 200 ok", resp.StatusCode, b)
+	}
+}
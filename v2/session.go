@@ -0,0 +1,289 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// SessionState is the state of a ScanSession.
+type SessionState int
+
+// States of a ScanSession.
+const (
+	// SessionIdle sessions haven't been started.
+	SessionIdle SessionState = iota
+	// SessionRunning sessions are classifying files.
+	SessionRunning
+	// SessionPaused sessions finish the files in progress and then wait to
+	// be resumed.
+	SessionPaused
+	// SessionStopped sessions were stopped before classifying all files.
+	SessionStopped
+	// SessionCompleted sessions classified all files.
+	SessionCompleted
+)
+
+var sessionStateNames = map[SessionState]string{
+	SessionIdle:      "idle",
+	SessionRunning:   "running",
+	SessionPaused:    "paused",
+	SessionStopped:   "stopped",
+	SessionCompleted: "completed",
+}
+
+func (s SessionState) String() string {
+	return sessionStateNames[s]
+}
+
+// ErrSessionStarted is returned when starting a session that was already
+// started.
+var ErrSessionStarted = errors.New("classifier: scan session already started")
+
+// ScanResult holds the matches found in a file scanned by a ScanSession, or
+// the error encountered reading it.
+type ScanResult struct {
+	Filename string
+	Matches  Matches
+	Err      error
+	// Stale is true if the file kept changing while it was read, so the
+	// lines of the matches may not correspond to its final content. See
+	// ReadFileStable.
+	Stale bool
+	// Cached is true if the matches were recorded by a previous scan of the
+	// unchanged file rather than classified again. See Rescan.
+	Cached bool
+}
+
+// ScanStats are the statistics of a ScanSession at a point in time.
+type ScanStats struct {
+	// Total is the number of files in the session, and Scanned the number
+	// classified so far, including Failed files that couldn't be read and
+	// Stale files that changed while they were read.
+	Total, Scanned, Failed, Stale int
+	// Matches is the number of matches found so far.
+	Matches int
+	// Bytes is the amount of content classified so far.
+	Bytes int64
+	// Elapsed is the time since the session was started, up to its end.
+	Elapsed time.Duration
+}
+
+// ScanSession classifies a set of files in the background. Unlike Match, which
+// blocks until a document is classified, a session can be paused, resumed
+// and stopped while it runs, and its progress inspected at any time, which
+// suits interactive tools and long-running agents.
+//
+//	s := c.NewScanSession(files, 0)
+//	s.Start()
+//	...
+//	fmt.Println(s.Stats().Scanned)
+//	...
+//	results := s.Wait()
+type ScanSession struct {
+	c       *Classifier
+	files   []string
+	workers int
+
+	mu       sync.Mutex
+	resumed  *sync.Cond // Signaled when a paused session resumes or stops.
+	state    SessionState
+	paused   bool // Pause was called before Start.
+	next     int  // Index of the next file to scan.
+	results  []*ScanResult
+	stats    ScanStats
+	started  time.Time
+	finished time.Time
+	done     chan struct{}
+}
+
+// NewScanSession creates a session to classify the named files using the
+// supplied number of concurrent workers, or the parallelism of the classifier
+// if it is zero or less. The session doesn't run until it is started.
+func (c *Classifier) NewScanSession(files []string, workers int) *ScanSession {
+	if workers <= 0 {
+		workers = c.workers()
+	}
+	s := &ScanSession{
+		c:       c,
+		files:   files,
+		workers: workers,
+		results: make([]*ScanResult, len(files)),
+		stats:   ScanStats{Total: len(files)},
+		done:    make(chan struct{}),
+	}
+	s.resumed = sync.NewCond(&s.mu)
+	return s
+}
+
+// Start begins classifying the files of the session in the background. A
+// session paused before it is started begins in the paused state.
+func (s *ScanSession) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state != SessionIdle {
+		return ErrSessionStarted
+	}
+	s.state = SessionRunning
+	if s.paused {
+		s.state = SessionPaused
+	}
+	s.started = time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work()
+		}()
+	}
+	go func() {
+		wg.Wait()
+		s.mu.Lock()
+		if s.state != SessionStopped {
+			s.state = SessionCompleted
+		}
+		s.finished = time.Now()
+		s.mu.Unlock()
+		close(s.done)
+	}()
+	return nil
+}
+
+// work classifies files until none remain or the session is stopped.
+func (s *ScanSession) work() {
+	for {
+		s.mu.Lock()
+		for s.state == SessionPaused {
+			s.resumed.Wait()
+		}
+		if s.state == SessionStopped || s.next == len(s.files) {
+			s.mu.Unlock()
+			return
+		}
+		i := s.next
+		s.next++
+		s.mu.Unlock()
+
+		r := &ScanResult{Filename: s.files[i]}
+		b, stale, err := ReadFileStable(s.files[i])
+		r.Stale = stale
+		if err != nil {
+			r.Err = err
+		} else {
+			r.Matches = s.c.Match(b)
+		}
+
+		s.mu.Lock()
+		s.results[i] = r
+		s.stats.Scanned++
+		if err != nil {
+			s.stats.Failed++
+		}
+		if stale {
+			s.stats.Stale++
+		}
+		s.stats.Matches += len(r.Matches)
+		s.stats.Bytes += int64(len(b))
+		s.mu.Unlock()
+	}
+}
+
+// Pause stops the session from starting to classify more files until it is
+// resumed. Files already being classified are finished.
+func (s *ScanSession) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch s.state {
+	case SessionIdle:
+		s.paused = true
+	case SessionRunning:
+		s.state = SessionPaused
+	}
+}
+
+// Resume continues a paused session.
+func (s *ScanSession) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch s.state {
+	case SessionIdle:
+		s.paused = false
+	case SessionPaused:
+		s.state = SessionRunning
+		s.resumed.Broadcast()
+	}
+}
+
+// Stop ends the session without classifying the remaining files. Files
+// already being classified are finished, and Wait returns once they are.
+func (s *ScanSession) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch s.state {
+	case SessionIdle:
+		s.state = SessionStopped
+		close(s.done)
+	case SessionRunning, SessionPaused:
+		s.state = SessionStopped
+		s.resumed.Broadcast()
+	}
+}
+
+// State returns the current state of the session.
+func (s *ScanSession) State() SessionState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// Stats returns the statistics of the session so far.
+func (s *ScanSession) Stats() ScanStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.stats
+	switch {
+	case s.started.IsZero():
+	case s.finished.IsZero():
+		st.Elapsed = time.Since(s.started)
+	default:
+		st.Elapsed = s.finished.Sub(s.started)
+	}
+	return st
+}
+
+// Done returns a channel that is closed when the session completes or, after
+// being stopped, finishes the files it was classifying.
+func (s *ScanSession) Done() <-chan struct{} {
+	return s.done
+}
+
+// Wait blocks until the session is done and returns the results of the files
+// it classified, in the order the files were supplied.
+func (s *ScanSession) Wait() []*ScanResult {
+	<-s.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []*ScanResult
+	for _, r := range s.results {
+		if r != nil {
+			out = append(out, r)
+		}
+	}
+	return out
+}
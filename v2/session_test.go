@@ -0,0 +1,130 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func sessionFiles() []string {
+	return []string{
+		filepath.Join(baseLicenses, "MIT.txt"),
+		filepath.Join(baseLicenses, "BSD-3-Clause.txt"),
+		filepath.Join(baseLicenses, "does-not-exist.txt"),
+		filepath.Join(baseLicenses, "ISC.txt"),
+	}
+}
+
+func TestScanSession(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+
+	s := c.NewScanSession(sessionFiles(), 2)
+	if got := s.State(); got != SessionIdle {
+		t.Errorf("State() = %v, want %v", got, SessionIdle)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	if err := s.Start(); err != ErrSessionStarted {
+		t.Errorf("second Start() = %v, want %v", err, ErrSessionStarted)
+	}
+
+	results := s.Wait()
+	if got := s.State(); got != SessionCompleted {
+		t.Errorf("State() = %v, want %v", got, SessionCompleted)
+	}
+	want := []string{"MIT", "BSD-3-Clause", "", "ISC"}
+	if len(results) != len(want) {
+		t.Fatalf("Wait() returned %d results, want %d", len(results), len(want))
+	}
+	for i, r := range results {
+		if want[i] == "" {
+			if r.Err == nil {
+				t.Errorf("%s: got no error, want a read error", r.Filename)
+			}
+			continue
+		}
+		if r.Err != nil || len(r.Matches) != 1 || r.Matches[0].Name != want[i] {
+			t.Errorf("%s: got %v, %v, want %s", r.Filename, r.Matches, r.Err, want[i])
+		}
+	}
+
+	st := s.Stats()
+	if st.Total != 4 || st.Scanned != 4 || st.Failed != 1 || st.Matches != 3 || st.Bytes == 0 || st.Elapsed <= 0 {
+		t.Errorf("Stats() = %+v, want 4 scanned, 1 failed and 3 matches", st)
+	}
+}
+
+func TestScanSessionPauseResume(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+
+	s := c.NewScanSession(sessionFiles(), 1)
+	s.Pause()
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	if got := s.State(); got != SessionPaused {
+		t.Errorf("State() = %v, want %v", got, SessionPaused)
+	}
+	select {
+	case <-s.Done():
+		t.Fatal("paused session finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if got := s.Stats().Scanned; got != 0 {
+		t.Errorf("paused session scanned %d files, want 0", got)
+	}
+
+	s.Resume()
+	if got := len(s.Wait()); got != 4 {
+		t.Errorf("Wait() returned %d results, want 4", got)
+	}
+}
+
+func TestScanSessionStop(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+
+	s := c.NewScanSession(sessionFiles(), 1)
+	s.Pause()
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	s.Stop()
+	if got := len(s.Wait()); got != 0 {
+		t.Errorf("Wait() returned %d results, want 0", got)
+	}
+	if got := s.State(); got != SessionStopped {
+		t.Errorf("State() = %v, want %v", got, SessionStopped)
+	}
+
+	// A session stopped before it starts is done immediately.
+	s = c.NewScanSession(sessionFiles(), 1)
+	s.Stop()
+	<-s.Done()
+	if err := s.Start(); err != ErrSessionStarted {
+		t.Errorf("Start() after Stop() = %v, want %v", err, ErrSessionStarted)
+	}
+}
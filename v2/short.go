@@ -0,0 +1,166 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+// Licenses of a few dozen tokens are matched as the longer ones are, but
+// their scores are brittle: a handful of differing words is a large share of
+// the text, and a short sentence that merely mentions a license, such as
+// "this code is not in the public domain", can come close to matching it.
+// The short entries prone to this are given anchor phrases, the wording that
+// identifies the license: a match of one must contain its anchors and clear
+// a tighter threshold. Short entries without anchors, such as the license
+// headers, are matched as any other entry is.
+
+// shortLicenseTokens is the number of required tokens below which a corpus
+// entry is treated as short.
+const shortLicenseTokens = 50
+
+// shortLicenseThreshold is the confidence a match of a short entry with
+// anchors must reach.
+const shortLicenseThreshold = 0.9
+
+// defaultAnchors are the anchor phrases of the short licenses of the
+// standard corpus, keyed by license or corpus entry name. The public domain
+// statements are anchored to the phrases that dedicate the work, so that
+// text merely saying something is in the public domain doesn't match them.
+var defaultAnchors = map[string][]string{
+	"Beerware":        {"beer-ware license", "buy me a beer"},
+	"WTFPL":           {"do what the fuck you want to public license"},
+	"blessing":        {"may you do good and not evil", "may you share freely"},
+	"Public-Domain":   {"hereby releases", "into the public domain"},
+	"Public-Domain_a": {"hereby dedicate", "to the public domain"},
+	"Public-Domain_b": {"hereby dedicates", "to the public domain"},
+	"Public-Domain_c": {"hereby places", "in the public domain"},
+	"Public-Domain_d": {"released into the public domain"},
+	"Public-Domain_e": {"placed into the public domain"},
+	"Public-Domain_f": {"placed in the public domain"},
+	"Public-Domain_g": {"dedicated to the public domain"},
+}
+
+// SetAnchors declares the phrases a match of a short license must contain.
+// A short license has fewer than 50 tokens, not counting the optional text
+// of a template. Matches of a short license with anchors must also have a
+// confidence of at least 0.9, and are rejected when closely preceded by
+// "not", "never" or "no"; those without anchors are matched as longer
+// licenses are. The name is either a license name, applying to all of its
+// corpus entries, or the name of a single corpus entry. Like normalization
+// exemptions, anchors apply to content added to the corpus after they are
+// set, and replace those previously declared for the name.
+func (c *Classifier) SetAnchors(name string, phrases []string) {
+	defer c.update()()
+	c.anchors[name] = append([]string(nil), phrases...)
+}
+
+// anchorsFor returns the anchor phrases of the named corpus entry.
+func (c *Classifier) anchorsFor(name string) []string {
+	if a, ok := c.anchors[name]; ok {
+		return a
+	}
+	return c.anchors[LicenseName(name)]
+}
+
+// setAnchors records the anchor phrases of the named corpus entry as token
+// sequences. It must be called once the words of the entry are in the
+// dictionary, since the words of an anchor missing from the corpus can't be
+// matched.
+func (c *Classifier) setAnchors(name string, d *indexedDocument) {
+	d.anchors = nil
+	for _, p := range c.anchorsFor(name) {
+		var ids []tokenID
+		for _, t := range c.tokenize([]byte(p)).Tokens {
+			ids = append(ids, c.dict.getIndex(t.Text))
+		}
+		if len(ids) > 0 {
+			d.anchors = append(d.anchors, ids)
+		}
+	}
+}
+
+// short reports whether the corpus entry is short.
+func (d *indexedDocument) short() bool {
+	n := d.size()
+	if d.template != nil {
+		n -= d.template.omittable
+	}
+	return n < shortLicenseTokens
+}
+
+// acceptShort reports whether a match of the named corpus entry with the
+// supplied confidence, spanning the tokens start through end of the target,
+// is accepted. Matches of entries that aren't short, or have no anchors,
+// always are.
+func (c *Classifier) acceptShort(name string, id, known *indexedDocument, start, end int, conf float64) bool {
+	if !known.short() || len(known.anchors) == 0 {
+		return true
+	}
+	if conf < shortLicenseThreshold {
+		c.log(PhaseScore, LevelInfo, "rejected match of a short license below its threshold", "license", name, "confidence", conf)
+		return false
+	}
+	for _, a := range known.anchors {
+		if !containsRun(id.Tokens[start:end], a) {
+			c.log(PhaseScore, LevelInfo, "rejected match of a short license without its anchor", "license", name, "confidence", conf)
+			return false
+		}
+	}
+	if c.negated(id, start) {
+		c.log(PhaseScore, LevelInfo, "rejected negated match of a short license", "license", name, "confidence", conf)
+		return false
+	}
+	return true
+}
+
+// negators are the words negating an anchored short license they closely
+// precede, as in "This code is not released into the public domain".
+var negators = []string{"not", "never", "no"}
+
+// negationWindow is the number of tokens before a match searched for
+// negators, which spans "has not been".
+const negationWindow = 3
+
+// negated reports whether a negator precedes the match starting at the
+// supplied token of the target.
+func (c *Classifier) negated(id *indexedDocument, start int) bool {
+	from := start - negationWindow
+	if from < 0 {
+		from = 0
+	}
+	for _, w := range negators {
+		n := c.dict.getIndex(w)
+		if n == unknownIndex {
+			continue
+		}
+		for _, t := range id.Tokens[from:start] {
+			if t.ID == n {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containsRun reports whether the tokens contain the run of token IDs.
+func containsRun(tokens []indexedToken, run []tokenID) bool {
+	for i := 0; i+len(run) <= len(tokens); i++ {
+		j := 0
+		for j < len(run) && run[j] != unknownIndex && tokens[i+j].ID == run[j] {
+			j++
+		}
+		if j == len(run) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestShortLicenses(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	beerware := `"THE BEER-WARE LICENSE" (Revision 42): <jdoe@example.com> wrote this
+file. As long as you retain this notice you can do whatever you want with
+this stuff. If we meet some day, and you think this stuff is worth it, you
+can buy me a beer in return. Jane Doe`
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "beerware with another author",
+			in:   beerware,
+			want: "Beerware",
+		},
+		{
+			name: "beerware without its anchor",
+			in:   strings.Replace(beerware, "buy me a beer", "buy me a coffee", 1),
+		},
+		{
+			name: "blessing without its preamble",
+			in: `May you do good and not evil.
+May you find forgiveness for yourself and forgive others.
+May you share freely, never taking more than you give.`,
+			want: "blessing",
+		},
+		{
+			name: "public domain dedication",
+			in:   "The author hereby places this work in the public domain.",
+			want: "Public-Domain",
+		},
+		{
+			name: "public domain mentioned",
+			in:   "This code is not in the public domain.",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got string
+			for _, m := range c.Match([]byte(test.in)) {
+				if m.MatchType == "License" {
+					got = m.Name
+				}
+			}
+			if got != test.want {
+				t.Errorf("Match() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSetAnchors(t *testing.T) {
+	text := "Do as you please with this file, so long as it stays pleasant."
+	for _, test := range []struct {
+		anchors []string
+		want    bool
+	}{
+		{want: true},
+		{anchors: []string{"so long as it stays pleasant"}, want: true},
+		{anchors: []string{"so long as it stays kind"}, want: false},
+	} {
+		c := NewClassifier(.8)
+		if test.anchors != nil {
+			c.SetAnchors("Pleasant", test.anchors)
+		}
+		c.AddContent("Pleasant", []byte(text))
+		got := len(c.Match([]byte(text))) > 0
+		if got != test.want {
+			t.Errorf("Match() with anchors %q found a match = %v, want %v", test.anchors, got, test.want)
+		}
+	}
+}
+
+func TestShortHeaders(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	tests := []struct {
+		name      string
+		in        string
+		matchType string
+		want      string
+	}{
+		{
+			name:      "AFL header",
+			in:        "Licensed under the Academic Free License version 3.0",
+			matchType: "Header",
+			want:      "AFL-3.0",
+		},
+		{
+			name: "MPL header with another URL",
+			in: `This Source Code Form is subject to the terms of the Mozilla Public License, v.
+2.0. If a copy of the MPL was not distributed with this file, you can get one
+at https://www.mozilla.org/en-US/MPL/2.0/.`,
+			matchType: "Header",
+			want:      "MPL-2.0",
+		},
+		{
+			name: "OSL header without its copyright line",
+			in: `This software is licensed under the Open Software License version 3.0. The
+full text of this license can be found in the file LICENSE which is
+distributed along with the software.`,
+			matchType: "Header",
+			want:      "OSL-3.0",
+		},
+		{
+			name:      "BabelstoneIDS",
+			in:        "This file is not copyrighted, and may be used freely for any purpose.",
+			matchType: "License",
+			want:      "BabelstoneIDS",
+		},
+		{
+			name: "WTFPL header",
+			in: `This work is free. It comes without any warranty, to the extent permitted by
+applicable law. You can redistribute it and/or modify it under the terms of the
+Do What The Fuck You Want To Public License, Version 2, as published by Sam
+Hocevar. See http://www.wtfpl.net/ for more details.`,
+			matchType: "Header",
+			want:      "WTFPL",
+		},
+		{
+			name:      "WTFPL one-liner",
+			in:        "Licensed under the WTFPL.",
+			matchType: "Reference",
+			want:      "WTFPL",
+		},
+		{
+			name:      "Zlib one-liner",
+			in:        "This library is released under the zlib license.",
+			matchType: "Reference",
+			want:      "Zlib",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, m := range c.Match([]byte(test.in)) {
+				if m.MatchType == test.matchType && m.Name == test.want {
+					return
+				}
+				got = append(got, m.MatchType+":"+m.Name)
+			}
+			t.Errorf("Match() = %v, want %s:%s", got, test.matchType, test.want)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import "github.com/sergi/go-diff/diffmatchpatch"
+
+// Similarity compares the text a with the candidate text b the way the
+// classifier compares content with the texts of its corpus, without adding b
+// to a corpus. This answers questions such as which of two versions of a
+// custom license a text is. It returns the confidence with which a matches b,
+// between 0 and 1, and the word diff of the normalized texts, in which
+// deletions are words of a that aren't in b and insertions are words of b
+// missing from a.
+//
+// As for matches, text of a before or after the region most similar to b,
+// such as a copyright notice or a preamble, doesn't lower the confidence; it
+// is reported as deletions at the ends of the diff. Unlike matches, the
+// confidence isn't subject to the rules that reject changes of the version
+// or the variant of a license, since b is an arbitrary text.
+func Similarity(a, b []byte) (float64, []diffmatchpatch.Diff) {
+	c := &Classifier{core: core{dict: newDictionary()}}
+	known := c.generateIndexedDocument(tokenize(b), true)
+	unknown := c.generateIndexedDocument(tokenize(a), true)
+	diffs := docDiff("", unknown, 0, unknown.size(), known, 0, known.size())
+	if known.size() == 0 {
+		if unknown.size() == 0 {
+			return 1, diffs
+		}
+		return 0, diffs
+	}
+	start, end := diffRange(known.norm, diffs)
+	conf := confidencePercentage(known.size(), diffLevenshteinWord(diffs[start:end]))
+	if conf < 0 {
+		conf = 0
+	}
+	return conf, diffs
+}
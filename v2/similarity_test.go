@@ -0,0 +1,71 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+func TestSimilarity(t *testing.T) {
+	v3 := "Acme End User License Agreement, version 3. You may install the Software on up to three devices you own. You may not rent, lease or lend the Software."
+	v4 := "Acme End User License Agreement, version 4. You may install the Software on any device you own. You may not rent, lease, lend or resell the Software."
+
+	for _, tt := range []struct {
+		desc string
+		a, b string
+		want func(float64) bool
+	}{
+		{desc: "identical", a: v3, b: v3, want: func(c float64) bool { return c == 1 }},
+		{desc: "reformatted", a: strings.ToUpper(strings.ReplaceAll(v3, " the ", "\n  the ")), b: v3, want: func(c float64) bool { return c == 1 }},
+		{desc: "with a preamble", a: "Copyright 2021 Acme Corp.\nREAD CAREFULLY.\n\n" + v3, b: v3, want: func(c float64) bool { return c == 1 }},
+		{desc: "another version", a: v4, b: v3, want: func(c float64) bool { return c > 0.7 && c < 1 }},
+		{desc: "unrelated", a: "The quick brown fox jumps over the lazy dog.", b: v3, want: func(c float64) bool { return c < 0.2 }},
+		{desc: "empty candidate", a: v3, b: "", want: func(c float64) bool { return c == 0 }},
+		{desc: "both empty", a: "", b: "", want: func(c float64) bool { return c == 1 }},
+	} {
+		if got, _ := Similarity([]byte(tt.a), []byte(tt.b)); !tt.want(got) {
+			t.Errorf("Similarity() of the %s text = %v", tt.desc, got)
+		}
+	}
+
+	// The diff shows how a differs from b.
+	_, diffs := Similarity([]byte(v4), []byte(v3))
+	var deleted, inserted []string
+	for _, d := range diffs {
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			deleted = append(deleted, d.Text)
+		case diffmatchpatch.DiffInsert:
+			inserted = append(inserted, d.Text)
+		}
+	}
+	if got := strings.Join(deleted, "|"); !strings.Contains(got, "resell") || !strings.Contains(got, "4") {
+		t.Errorf("Similarity() deletions = %q, want the words of a missing from b", got)
+	}
+	if got := strings.Join(inserted, "|"); !strings.Contains(got, "three") {
+		t.Errorf("Similarity() insertions = %q, want the words of b missing from a", got)
+	}
+
+	// Closer versions are more similar.
+	v3b := strings.Replace(v3, "three", "four", 1)
+	s3, _ := Similarity([]byte(v3b), []byte(v3))
+	s4, _ := Similarity([]byte(v3b), []byte(v4))
+	if s3 <= s4 {
+		t.Errorf("Similarity() to version 3 = %v, to version 4 = %v, want version 3 to be closer", s3, s4)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+// Matches don't lock the classifier. They run on a snapshot of its core,
+// which is never modified: the setters and the loading of content change the
+// core under the write lock and discard the snapshot, and the first match
+// after a change takes a new one. Matches in progress keep the snapshot they
+// started with.
+
+// snapshot returns the snapshot of the core of the classifier, taking it if
+// the core changed since the last one was taken. The snapshot is a
+// Classifier whose maps, corpus entries and dictionary are copies, so that
+// later changes don't affect it, and which holds the state derived from the
+// corpus that matches share: the cache scope, the index of the versions of
+// licenses and the names of the licenses.
+func (c *Classifier) snapshot() *Classifier {
+	if s, _ := c.snap.Load().(*Classifier); s != nil {
+		return s
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, _ := c.snap.Load().(*Classifier); s != nil {
+		return s
+	}
+	s := &Classifier{core: c.core}
+	s.dict = c.dict.clone()
+	s.docs = make(map[string]*indexedDocument, len(c.docs))
+	for name, d := range c.docs {
+		cp := *d
+		cp.dict = s.dict
+		s.docs[name] = &cp
+	}
+	s.docFreq = make(map[tokenID]int, len(c.docFreq))
+	for t, n := range c.docFreq {
+		s.docFreq[t] = n
+	}
+	s.exemptions = make(map[string][]*exemption, len(c.exemptions))
+	for name, e := range c.exemptions {
+		s.exemptions[name] = e
+	}
+	s.anchors = make(map[string][]string, len(c.anchors))
+	for name, a := range c.anchors {
+		s.anchors[name] = a
+	}
+	s.metadata = make(map[string]*EntryMetadata, len(c.metadata))
+	for name, m := range c.metadata {
+		s.metadata[name] = m
+	}
+	s.issues = make(map[string]*CorpusIssue, len(c.issues))
+	for name, i := range c.issues {
+		s.issues[name] = i
+	}
+	s.origins = make(map[string]string, len(c.origins))
+	for name, o := range c.origins {
+		s.origins[name] = o
+	}
+	s.budgets = make(map[string]Budget, len(c.budgets))
+	for t, b := range c.budgets {
+		s.budgets[t] = b
+	}
+	s.aliases = make(map[string]Alias, len(c.aliases))
+	for name, a := range c.aliases {
+		s.aliases[name] = a
+	}
+	s.rules = c.rules[:len(c.rules):len(c.rules)]
+	s.sources = nil
+	s.versions = s.indexVersions()
+	s.licenses = s.corpusLicenses()
+	if s.cache != nil {
+		s.scope = s.cacheScope()
+	}
+	// A snapshot is its own snapshot, for the methods of the classifier
+	// that matching calls.
+	s.snap.Store(s)
+	c.snap.Store(s)
+	return s
+}
@@ -0,0 +1,133 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spdx
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+// CorpusName returns the name of the corpus entry of a license or exception
+// of the SPDX license list, or the empty string if the corpus has none. The
+// corpus predates the -only and -or-later suffixes of the GNU licenses: the
+// -only licenses are named without their suffix and the -or-later ones,
+// which have the same text, aren't in the corpus. Deprecated identifiers
+// aren't either, since the licenses replacing them have the same text.
+func CorpusName(e *Entry) string {
+	if e.Deprecated || strings.HasSuffix(e.ID, "-or-later") {
+		return ""
+	}
+	return strings.TrimSuffix(e.ID, "-only")
+}
+
+// baseID returns the identifier without the -only and -or-later suffixes.
+func baseID(id string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(id, "-only"), "-or-later")
+}
+
+// Diff describes how a corpus differs from a release of the SPDX license
+// list. Each list is sorted.
+type Diff struct {
+	// Added are the names of the corpus entries of the release that are
+	// missing from the corpus.
+	Added []string
+	// Changed are the names of the corpus entries whose text differs from
+	// that of the release, other than in whitespace.
+	Changed []string
+	// Unlisted are the names of the corpus entries of licenses the release
+	// doesn't have, such as licenses SPDX has withdrawn and licenses added to
+	// the corpus locally. Headers and variants of licenses aren't reported.
+	Unlisted []string
+}
+
+// Diff compares the corpus in the supplied directory with the release.
+func (r *Release) Diff(dir string) (*Diff, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	corpus := make(map[string]string)
+	for _, f := range files {
+		corpus[strings.TrimSuffix(filepath.Base(f), ".txt")] = f
+	}
+
+	d := &Diff{}
+	listed := make(map[string]bool)
+	for _, e := range r.Entries {
+		listed[baseID(e.ID)] = true
+		name := CorpusName(e)
+		if name == "" {
+			continue
+		}
+		f, ok := corpus[name]
+		if !ok {
+			d.Added = append(d.Added, name)
+			continue
+		}
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		if !sameText(string(b), e.Text) {
+			d.Changed = append(d.Changed, name)
+		}
+	}
+	for name := range corpus {
+		if l := classifier.LicenseName(name); l == name && !listed[l] {
+			d.Unlisted = append(d.Unlisted, name)
+		}
+	}
+	sort.Strings(d.Added)
+	sort.Strings(d.Changed)
+	sort.Strings(d.Unlisted)
+	return d, nil
+}
+
+// sameText reports whether two texts differ only in whitespace.
+func sameText(a, b string) bool {
+	return strings.Join(strings.Fields(a), " ") == strings.Join(strings.Fields(b), " ")
+}
+
+// WriteCorpus writes the texts of the entries the diff reports as added or
+// changed to the corpus in the supplied directory, creating it if needed.
+// Unlisted entries are left in place, since they may have been added
+// locally on purpose.
+func (r *Release) WriteCorpus(dir string, d *Diff) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	entries := make(map[string]*Entry)
+	for _, e := range r.Entries {
+		if name := CorpusName(e); name != "" {
+			entries[name] = e
+		}
+	}
+	for _, name := range append(append([]string(nil), d.Added...), d.Changed...) {
+		e, ok := entries[name]
+		if !ok {
+			return fmt.Errorf("release %s has no entry %s", r.Version, name)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name+".txt"), []byte(e.Text), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spdx
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCorpusName(t *testing.T) {
+	for _, e := range wantRelease().Entries {
+		want := map[string]string{
+			"Classpath-exception-2.0": "Classpath-exception-2.0",
+			"GPL-2.0-only":            "GPL-2.0",
+			"MIT":                     "MIT",
+		}[e.ID]
+		if got := CorpusName(e); got != want {
+			t.Errorf("CorpusName(%s) = %q, want %q", e.ID, got, want)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "corpus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, content := range map[string]string{
+		"MIT.txt":         "MIT\n  text\n",
+		"GPL-2.0.txt":     "old GPL text",
+		"GPL-2.0.header":  "not a corpus file",
+		"Apache-2.0.txt":  "Apache text",
+		"Local-1.0.txt":   "local text",
+		"MIT.header.txt":  "MIT header",
+		"MIT_variant.txt": "MIT variant",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := wantRelease()
+	d, err := r.Diff(dir)
+	if err != nil {
+		t.Fatalf("Diff() failed: %v", err)
+	}
+	want := &Diff{
+		Added:    []string{"Classpath-exception-2.0"},
+		Changed:  []string{"GPL-2.0"},
+		Unlisted: []string{"Apache-2.0", "Local-1.0"},
+	}
+	if diff := cmp.Diff(want, d); diff != "" {
+		t.Errorf("Diff() mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := r.WriteCorpus(dir, d); err != nil {
+		t.Fatalf("WriteCorpus() failed: %v", err)
+	}
+	d, err = r.Diff(dir)
+	if err != nil {
+		t.Fatalf("Diff() failed: %v", err)
+	}
+	if len(d.Added) > 0 || len(d.Changed) > 0 {
+		t.Errorf("Diff() after WriteCorpus() = %+v, want only unlisted entries", d)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Local-1.0.txt")); err != nil {
+		t.Errorf("WriteCorpus() removed an unlisted entry: %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package spdx keeps the license corpus in sync with the SPDX license list.
+// It reads releases of the SPDX license-list-data repository, converts their
+// license and exception texts into the layout of the corpus, and reports how
+// they differ from an existing corpus.
+//
+//	r, err := spdx.Fetch(spdx.ReleaseURL("v3.21"))
+//	...
+//	d, err := r.Diff(licenseDir)
+//	...
+//	err = r.WriteCorpus(licenseDir, d)
+package spdx
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ReleaseURL returns the URL of the archive of the supplied release of the
+// SPDX license-list-data repository, such as "v3.21".
+func ReleaseURL(version string) string {
+	return fmt.Sprintf("https://github.com/spdx/license-list-data/archive/refs/tags/%s.tar.gz", version)
+}
+
+// maxArchiveSize bounds the size of the files kept from a release archive.
+const maxArchiveSize = 256 << 20
+
+// Entry is a license or exception of the SPDX license list.
+type Entry struct {
+	// ID is the SPDX identifier of the entry.
+	ID   string
+	Name string
+	// Exception is set for license exceptions.
+	Exception bool
+	// Deprecated is set for identifiers SPDX has replaced, such as GPL-2.0.
+	Deprecated  bool
+	OSIApproved bool
+	// Text is the plain text of the entry, and Template its SPDX license
+	// template, which marks the replaceable and optional parts of the text.
+	// Template is empty if the release has none.
+	Text     string
+	Template string
+}
+
+// Release is a release of the SPDX license list.
+type Release struct {
+	// Version is the version of the license list, such as "3.21".
+	Version string
+	// Entries are the licenses and exceptions of the release, ordered by
+	// identifier.
+	Entries []*Entry
+}
+
+// listFile is the subset of the JSON format of the license and exception
+// lists used here. Licenses and exceptions are published in separate files
+// with different identifier keys.
+type listFile struct {
+	Version    string      `json:"licenseListVersion"`
+	Licenses   []listEntry `json:"licenses"`
+	Exceptions []listEntry `json:"exceptions"`
+}
+
+type listEntry struct {
+	LicenseID   string `json:"licenseId"`
+	ExceptionID string `json:"licenseExceptionId"`
+	Name        string `json:"name"`
+	Deprecated  bool   `json:"isDeprecatedLicenseId"`
+	OSIApproved bool   `json:"isOsiApproved"`
+}
+
+// Load reads a release from a directory holding a checkout of the SPDX
+// license-list-data repository, or an unpacked release archive.
+func Load(dir string) (*Release, error) {
+	return readRelease(func(name string) ([]byte, error) {
+		return ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	})
+}
+
+// Fetch downloads the release archive at the supplied URL, such as that
+// returned by ReleaseURL, and reads the release from it.
+func Fetch(url string) (*Release, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't download %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("couldn't download %s: %s", url, resp.Status)
+	}
+	files, err := readArchive(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read %s: %v", url, err)
+	}
+	return readRelease(func(name string) ([]byte, error) {
+		b, ok := files[name]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return b, nil
+	})
+}
+
+// releaseFile reports whether a file of a release is read by readRelease.
+func releaseFile(name string) bool {
+	switch path.Dir(name) {
+	case "json":
+		return name == "json/licenses.json" || name == "json/exceptions.json"
+	case "text", "template":
+		return strings.HasSuffix(name, ".txt")
+	}
+	return false
+}
+
+// readArchive returns the files of a gzipped tar release archive used by
+// readRelease, keyed by their path below the top-level directory of the
+// archive.
+func readArchive(r io.Reader) (map[string][]byte, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(zr)
+	files := make(map[string][]byte)
+	var size int64
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		// Archives of GitHub releases hold the repository in a directory
+		// named after it and the release.
+		i := strings.Index(h.Name, "/")
+		if i == -1 || !releaseFile(h.Name[i+1:]) {
+			continue
+		}
+		if size += h.Size; size > maxArchiveSize {
+			return nil, fmt.Errorf("license files exceed %d bytes", maxArchiveSize)
+		}
+		b, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files[h.Name[i+1:]] = b
+	}
+}
+
+// readRelease reads a release with the supplied function reading its files
+// by their path in the license-list-data repository. A release must have a
+// license list, and may lack an exception list.
+func readRelease(read func(name string) ([]byte, error)) (*Release, error) {
+	licenses, err := readList(read, "json/licenses.json")
+	if err != nil {
+		return nil, err
+	}
+	r := &Release{Version: licenses.Version}
+	for _, l := range licenses.Licenses {
+		r.Entries = append(r.Entries, &Entry{ID: l.LicenseID, Name: l.Name, Deprecated: l.Deprecated, OSIApproved: l.OSIApproved})
+	}
+	exceptions, err := readList(read, "json/exceptions.json")
+	switch {
+	case err == nil:
+		for _, e := range exceptions.Exceptions {
+			r.Entries = append(r.Entries, &Entry{ID: e.ExceptionID, Name: e.Name, Exception: true, Deprecated: e.Deprecated})
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, err
+	}
+	sort.Slice(r.Entries, func(i, j int) bool { return r.Entries[i].ID < r.Entries[j].ID })
+
+	for _, e := range r.Entries {
+		b, err := read("text/" + e.ID + ".txt")
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read the text of %s: %w", e.ID, err)
+		}
+		e.Text = string(b)
+		if b, err := read("template/" + e.ID + ".template.txt"); err == nil {
+			e.Template = string(b)
+		}
+	}
+	return r, nil
+}
+
+func readList(read func(name string) ([]byte, error), name string) (*listFile, error) {
+	b, err := read(name)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read %s: %w", name, err)
+	}
+	var l listFile
+	if err := json.Unmarshal(b, &l); err != nil {
+		return nil, fmt.Errorf("couldn't parse %s: %v", name, err)
+	}
+	return &l, nil
+}
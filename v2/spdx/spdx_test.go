@@ -0,0 +1,126 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spdx
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// releaseFiles are the files of a small release of the license list.
+var releaseFiles = map[string]string{
+	"json/licenses.json": `{"licenseListVersion": "3.99", "licenses": [
+		{"licenseId": "MIT", "name": "MIT License", "isOsiApproved": true},
+		{"licenseId": "GPL-2.0-only", "name": "GNU General Public License v2.0 only", "isOsiApproved": true},
+		{"licenseId": "GPL-2.0-or-later", "name": "GNU General Public License v2.0 or later", "isOsiApproved": true},
+		{"licenseId": "GPL-2.0", "name": "GNU General Public License v2.0 only", "isDeprecatedLicenseId": true}
+	]}`,
+	"json/exceptions.json": `{"licenseListVersion": "3.99", "exceptions": [
+		{"licenseExceptionId": "Classpath-exception-2.0", "name": "Classpath exception 2.0"}
+	]}`,
+	"text/MIT.txt":                     "MIT text",
+	"text/GPL-2.0-only.txt":            "GPL text",
+	"text/GPL-2.0-or-later.txt":        "GPL text",
+	"text/GPL-2.0.txt":                 "GPL text",
+	"text/Classpath-exception-2.0.txt": "Classpath text",
+	"template/MIT.template.txt":        "<<var;name=\"copyright\";original=\"Copyright (c) <year> <copyright holders>\";match=\".+\">> MIT text",
+	"html/MIT.html":                    "<p>MIT text</p>",
+}
+
+func wantRelease() *Release {
+	return &Release{
+		Version: "3.99",
+		Entries: []*Entry{
+			{ID: "Classpath-exception-2.0", Name: "Classpath exception 2.0", Exception: true, Text: "Classpath text"},
+			{ID: "GPL-2.0", Name: "GNU General Public License v2.0 only", Deprecated: true, Text: "GPL text"},
+			{ID: "GPL-2.0-only", Name: "GNU General Public License v2.0 only", OSIApproved: true, Text: "GPL text"},
+			{ID: "GPL-2.0-or-later", Name: "GNU General Public License v2.0 or later", OSIApproved: true, Text: "GPL text"},
+			{ID: "MIT", Name: "MIT License", OSIApproved: true, Text: "MIT text", Template: releaseFiles["template/MIT.template.txt"]},
+		},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spdx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, content := range releaseFiles {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if diff := cmp.Diff(wantRelease(), r); diff != "" {
+		t.Errorf("Load() mismatch (-want +got):\n%s", diff)
+	}
+
+	os.Remove(filepath.Join(dir, "text", "MIT.txt"))
+	if _, err := Load(dir); err == nil {
+		t.Error("Load() succeeded without the text of a license, want error")
+	}
+}
+
+func TestFetch(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	tw.WriteHeader(&tar.Header{Name: "license-list-data-3.99/", Typeflag: tar.TypeDir, Mode: 0755})
+	for name, content := range releaseFiles {
+		tw.WriteHeader(&tar.Header{Name: "license-list-data-3.99/" + name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content))})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	zw.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3.99.tar.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(buf.Bytes())
+	}))
+	defer srv.Close()
+
+	r, err := Fetch(srv.URL + "/v3.99.tar.gz")
+	if err != nil {
+		t.Fatalf("Fetch() failed: %v", err)
+	}
+	if diff := cmp.Diff(wantRelease(), r); diff != "" {
+		t.Errorf("Fetch() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := Fetch(srv.URL + "/v0.0.tar.gz"); err == nil {
+		t.Error("Fetch() succeeded for a missing release, want error")
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ScanState records the content hashes and matches of the files classified by
+// a previous run, so that a CI job can persist it between runs and only
+// classify the files that changed since. See Rescan.
+type ScanState struct {
+	// Scope identifies the corpus and configuration of the classifier that
+	// produced the recorded matches. Matches recorded under another scope
+	// are never reused.
+	Scope string `json:"scope"`
+	// Files maps the names of the files to their recorded state.
+	Files map[string]*FileState `json:"files"`
+}
+
+// FileState is the recorded state of a file in a ScanState.
+type FileState struct {
+	// Hash is the hex-encoded SHA-256 hash of the content of the file.
+	Hash    string  `json:"hash"`
+	Matches Matches `json:"matches"`
+}
+
+// RescanStats counts what Rescan did with the files it was given.
+type RescanStats struct {
+	// Classified files had no recorded state matching their content, and
+	// Reused files did, so their recorded matches were reported.
+	Classified, Reused int
+	// Failed files couldn't be read. Removed is the number of files
+	// recorded in the state that weren't part of the rescan.
+	Failed, Removed int
+}
+
+// LoadScanState reads a state saved by ScanState.Save. A missing file yields
+// an empty state, as for the first run of a job.
+func LoadScanState(name string) (*ScanState, error) {
+	b, err := ioutil.ReadFile(name)
+	if os.IsNotExist(err) {
+		return &ScanState{Files: make(map[string]*FileState)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("classifier couldn't read scan state: %w", err)
+	}
+	var s ScanState
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("classifier couldn't parse scan state %s: %w", name, err)
+	}
+	if s.Files == nil {
+		s.Files = make(map[string]*FileState)
+	}
+	return &s, nil
+}
+
+// Save writes the state to the named file as JSON. The file is replaced
+// atomically, so an interrupted job never leaves a truncated state behind.
+func (s *ScanState) Save(name string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("classifier couldn't encode scan state: %w", err)
+	}
+	f, err := ioutil.TempFile(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return fmt.Errorf("classifier couldn't save scan state: %w", err)
+	}
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), name)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("classifier couldn't save scan state: %w", err)
+	}
+	return nil
+}
+
+// Rescan classifies the named files, reusing the matches recorded in the
+// state for the files whose content is unchanged, and returns the results of
+// all of them in the order the files were supplied, as a complete report of
+// the current files. Reused results have Cached set. The files are read and
+// classified concurrently, using the parallelism of the classifier.
+//
+// The state is updated to hold exactly the supplied files, ready to be saved
+// for the next run. Files that couldn't be read, or that changed while they
+// were read, aren't recorded, so they are classified again next time. A
+// state recorded by a classifier with a different corpus or configuration is
+// discarded.
+func (c *Classifier) Rescan(state *ScanState, files []string) ([]*ScanResult, RescanStats) {
+	s := c.snapshot()
+	scope := hex.EncodeToString(s.cacheScope())
+	if state.Scope != scope {
+		state.Scope = scope
+		state.Files = nil
+	}
+
+	out := make([]*ScanResult, len(files))
+	hashes := make([]string, len(files))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < c.workers() && w < len(files); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				r := &ScanResult{Filename: files[i]}
+				out[i] = r
+				b, stale, err := ReadFileStable(files[i])
+				if err != nil {
+					r.Err = err
+					continue
+				}
+				r.Stale = stale
+				sum := sha256.Sum256(b)
+				hashes[i] = hex.EncodeToString(sum[:])
+				if fs, ok := state.Files[files[i]]; ok && !stale && fs.Hash == hashes[i] {
+					r.Matches = copyMatches(fs.Matches)
+					r.Cached = true
+					s.applyAliases(r.Matches)
+					s.applyMetadata(r.Matches)
+					continue
+				}
+				r.Matches = s.Match(b)
+			}
+		}()
+	}
+	for i := range files {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	var st RescanStats
+	current := make(map[string]bool, len(files))
+	for _, f := range files {
+		current[f] = true
+	}
+	for name := range state.Files {
+		if !current[name] {
+			st.Removed++
+		}
+	}
+	recorded := make(map[string]*FileState, len(files))
+	for i, r := range out {
+		switch {
+		case r.Err != nil:
+			st.Failed++
+			continue
+		case r.Cached:
+			st.Reused++
+		default:
+			st.Classified++
+		}
+		if !r.Stale {
+			recorded[r.Filename] = &FileState{Hash: hashes[i], Matches: copyMatches(r.Matches)}
+		}
+	}
+	state.Files = recorded
+	return out, st
+}
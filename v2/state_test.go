@@ -0,0 +1,112 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRescan(t *testing.T) {
+	c := NewClassifier(defaultThreshold)
+	c.AddContent("MIT", []byte("Permission is hereby granted, free of charge, to any person obtaining a copy"))
+	c.AddContent("ISC", []byte("Permission to use, copy, modify, and/or distribute this software for any purpose"))
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	a := write("a", "Permission is hereby granted, free of charge, to any person obtaining a copy")
+	b := write("b", "Permission to use, copy, modify, and/or distribute this software for any purpose")
+	missing := filepath.Join(dir, "missing")
+	statePath := filepath.Join(dir, "state.json")
+
+	names := func(rs []*ScanResult) map[string][]string {
+		out := make(map[string][]string)
+		for _, r := range rs {
+			var n []string
+			for _, m := range r.Matches {
+				n = append(n, m.Name)
+			}
+			out[filepath.Base(r.Filename)] = n
+		}
+		return out
+	}
+
+	state, err := LoadScanState(statePath)
+	if err != nil {
+		t.Fatalf("LoadScanState() of a missing file failed: %v", err)
+	}
+	results, stats := c.Rescan(state, []string{a, b, missing})
+	if want := (RescanStats{Classified: 2, Failed: 1}); stats != want {
+		t.Errorf("first Rescan() stats = %+v, want %+v", stats, want)
+	}
+	if results[2].Err == nil {
+		t.Error("Rescan() of a missing file didn't report an error")
+	}
+	if err := state.Save(statePath); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	// The next run only classifies the changed file, and drops the removed
+	// one, reporting the matches of all current files.
+	write("b", "Permission is hereby granted, free of charge, to any person obtaining a copy")
+	if state, err = LoadScanState(statePath); err != nil {
+		t.Fatalf("LoadScanState() failed: %v", err)
+	}
+	c2 := NewClassifier(defaultThreshold)
+	c2.AddContent("MIT", []byte("Permission is hereby granted, free of charge, to any person obtaining a copy"))
+	c2.AddContent("ISC", []byte("Permission to use, copy, modify, and/or distribute this software for any purpose"))
+	results, stats = c2.Rescan(state, []string{a, b})
+	if want := (RescanStats{Classified: 1, Reused: 1}); stats != want {
+		t.Errorf("second Rescan() stats = %+v, want %+v", stats, want)
+	}
+	if !results[0].Cached || results[1].Cached {
+		t.Errorf("second Rescan() cached = %v, %v, want true, false", results[0].Cached, results[1].Cached)
+	}
+	if got, want := names(results), map[string][]string{"a": {"MIT"}, "b": {"MIT"}}; !cmp.Equal(got, want) {
+		t.Errorf("second Rescan() = %v, want %v", got, want)
+	}
+	if _, ok := state.Files[missing]; ok || len(state.Files) != 2 {
+		t.Errorf("state holds %d files after the second Rescan(), want 2", len(state.Files))
+	}
+
+	// Results recorded with another corpus are discarded.
+	c2.AddContent("Other", []byte("Some other license text entirely"))
+	if _, stats = c2.Rescan(state, []string{a, b}); stats != (RescanStats{Classified: 2}) {
+		t.Errorf("Rescan() after changing the corpus stats = %+v, want everything classified", stats)
+	}
+}
+
+func TestLoadScanStateInvalid(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "state.json")
+	if err := ioutil.WriteFile(p, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadScanState(p); err == nil {
+		t.Error("LoadScanState() of invalid content succeeded")
+	}
+	if _, err := LoadScanState(filepath.Dir(p)); err == nil || os.IsNotExist(err) {
+		t.Errorf("LoadScanState() of a directory = %v, want a read error", err)
+	}
+}
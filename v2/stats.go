@@ -0,0 +1,115 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import "sort"
+
+// LicenseStats describes the corpus entries of a license, so that services
+// can tell what a classifier detects and how much memory its corpus takes.
+type LicenseStats struct {
+	Name     string
+	Category string
+	// Variants is the number of corpus entries of the license, which
+	// include alternative texts, headers and translations.
+	Variants int
+	// MinTokens and MaxTokens are the lengths, in tokens, of the shortest
+	// and longest entries of the license.
+	MinTokens, MaxTokens int
+	// IndexBytes is an estimate of the memory used by the index of the
+	// entries, on 64-bit platforms.
+	IndexBytes int64
+}
+
+// Estimates of the memory of the index structures on 64-bit platforms.
+const (
+	// wordBytes is the size of ints and pointers.
+	wordBytes = 8
+	// mapEntryBytes is the memory of an entry of a map with keys and values
+	// of a few words, including its share of the buckets.
+	mapEntryBytes = 48
+	// qgramBytes is the memory of each q-gram of a searchset: its checksum
+	// and its offset among those of the checksum.
+	qgramBytes = 4 + 4
+)
+
+// indexBytes returns an estimate of the memory used by the index of a
+// corpus entry.
+func (d *indexedDocument) indexBytes() int64 {
+	n := int64(len(d.Tokens)) * 3 * 4
+	n += int64(len(d.norm) + len(d.content))
+	n += int64(len(d.distinct)) * wordBytes
+	if d.f != nil {
+		n += int64(len(d.f.counts)) * mapEntryBytes
+	}
+	if s := d.s; s != nil {
+		n += int64(len(s.Checksums)) * qgramBytes
+		n += int64(len(s.Hashes)) * (mapEntryBytes + 3*wordBytes)
+		if p := s.packed; p != nil {
+			n += int64(len(p.keys)+len(p.ends)+len(p.offsets)) * 4
+		}
+	}
+	if t := d.template; t != nil {
+		n += int64(len(t.variable) + len(t.optional))
+		n += int64(len(t.slots)) * mapEntryBytes
+	}
+	return n
+}
+
+// Licenses describes the licenses of the corpus, ordered by name.
+func (c *Classifier) Licenses() []LicenseStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	stats := make(map[string]*LicenseStats)
+	for _, name := range sortedNames(c.docs) {
+		d := c.docs[name]
+		license := LicenseName(name)
+		s, ok := stats[license]
+		if !ok {
+			s = &LicenseStats{Name: license, Category: LicenseCategory(license), MinTokens: d.size()}
+			stats[license] = s
+		}
+		s.Variants++
+		if d.size() < s.MinTokens {
+			s.MinTokens = d.size()
+		}
+		s.MaxTokens = max(s.MaxTokens, d.size())
+		s.IndexBytes += d.indexBytes()
+	}
+	out := make([]LicenseStats, 0, len(stats))
+	for _, s := range stats {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// IndexBytes returns an estimate of the memory used by the index of the
+// corpus: that of the entries, as reported by Licenses, and that of the
+// dictionary of their words shared by the entries.
+func (c *Classifier) IndexBytes() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var n int64
+	for _, d := range c.docs {
+		n += d.indexBytes()
+	}
+	// Each word is in the slice and the map of the dictionary, which share
+	// its bytes.
+	for _, w := range c.dict.words {
+		n += mapEntryBytes + 4*wordBytes + int64(len(w))
+	}
+	n += int64(len(c.docFreq)) * mapEntryBytes
+	return n
+}
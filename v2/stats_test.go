@@ -0,0 +1,72 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestLicenses(t *testing.T) {
+	c := NewClassifier(defaultThreshold)
+	c.AddContent("Widget-1.0", []byte(versionedLicense))
+	c.AddContent("Gadget-1.0", []byte(gadgetLicense))
+	c.AddContent("Gadget-1.0.header", []byte("Licensed under the Gadget Community Terms, which you may find in the LICENSE file."))
+
+	want := []LicenseStats{
+		{Name: "Gadget-1.0", Variants: 2, MinTokens: c.docs["Gadget-1.0.header"].size(), MaxTokens: c.docs["Gadget-1.0"].size()},
+		{Name: "Widget-1.0", Variants: 1, MinTokens: c.docs["Widget-1.0"].size(), MaxTokens: c.docs["Widget-1.0"].size()},
+	}
+	got := c.Licenses()
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(LicenseStats{}, "IndexBytes")); diff != "" {
+		t.Errorf("Licenses() mismatch (-want +got):\n%s", diff)
+	}
+	var sum int64
+	for _, s := range got {
+		if s.IndexBytes <= 0 {
+			t.Errorf("IndexBytes of %s = %d, want it positive", s.Name, s.IndexBytes)
+		}
+		sum += s.IndexBytes
+	}
+	if total := c.IndexBytes(); total <= sum {
+		t.Errorf("IndexBytes() = %d, want more than the %d of the entries", total, sum)
+	}
+}
+
+func TestLicensesOfCorpus(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	stats := c.Licenses()
+	if !sort.SliceIsSorted(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name }) {
+		t.Error("Licenses() isn't ordered by name")
+	}
+	entries := 0
+	for _, s := range stats {
+		entries += s.Variants
+		if s.Name == "GPL-2.0" {
+			if s.Category != "restricted" || s.Variants < 2 || s.MinTokens >= s.MaxTokens {
+				t.Errorf("Licenses() of GPL-2.0 = %+v, want a restricted license with headers", s)
+			}
+		}
+	}
+	if entries != len(c.docs) {
+		t.Errorf("Licenses() counts %d entries, want %d", entries, len(c.docs))
+	}
+}
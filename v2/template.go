@@ -0,0 +1,203 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// SPDX license templates mark the parts of a license text that may vary
+// between copies of the license:
+//
+//	<<var;name="copyright";original="Copyright (c) <year> <owner>";match=".+">>
+//	<<beginOptional>> text that may be left out <<endOptional>>
+//
+// A corpus entry holding such markup is indexed with the original text of
+// its variables and its optional text, and edits to them don't count against
+// a match.
+const (
+	templateVar           = "<<var;"
+	templateBeginOptional = "<<beginOptional"
+	templateEndOptional   = "<<endOptional>>"
+)
+
+// Marker words delimit the variable and optional text of a template while it
+// is tokenized, so that its tokens can be told apart from the others.
+const (
+	markBeginVar      = "spdxtemplatebeginvar"
+	markEndVar        = "spdxtemplateendvar"
+	markBeginOptional = "spdxtemplatebeginoptional"
+	markEndOptional   = "spdxtemplateendoptional"
+)
+
+// templateOriginal extracts the original text of a variable.
+var templateOriginal = regexp.MustCompile(`original="((?:[^"\\]|\\.)*)"`)
+
+// template records which tokens of a corpus entry are variable or optional
+// in its SPDX license template.
+type template struct {
+	// variable and optional flag the tokens of the variable and optional
+	// text.
+	variable, optional []bool
+	// slots are the positions of variables without original text, where any
+	// text may be inserted.
+	slots map[int]bool
+	// omittable is the number of tokens that may be missing from a match.
+	omittable int
+}
+
+// isTemplate reports whether the content of a corpus entry is an SPDX
+// license template.
+func isTemplate(content []byte) bool {
+	return bytes.Contains(content, []byte(templateVar)) || bytes.Contains(content, []byte(templateBeginOptional))
+}
+
+// renderTemplate returns the text of a template, with the original text of
+// its variables, and the same text with marker words around the variable and
+// optional text. Both have the lines of the template.
+func renderTemplate(content []byte) (plain, marked []byte) {
+	var p, m bytes.Buffer
+	s := string(content)
+	for {
+		i := strings.Index(s, "<<")
+		if i == -1 {
+			break
+		}
+		j := strings.Index(s[i:], ">>")
+		if j == -1 {
+			break
+		}
+		p.WriteString(s[:i])
+		m.WriteString(s[:i])
+		tag := s[i : i+j+2]
+		s = s[i+j+2:]
+		switch {
+		case strings.HasPrefix(tag, templateVar):
+			var original string
+			if o := templateOriginal.FindStringSubmatch(tag); o != nil {
+				original = strings.Replace(o[1], `\"`, `"`, -1)
+			}
+			p.WriteString(original)
+			m.WriteString(" " + markBeginVar + " " + original + " " + markEndVar + " ")
+		case strings.HasPrefix(tag, templateBeginOptional):
+			m.WriteString(" " + markBeginOptional + " ")
+		case tag == templateEndOptional:
+			m.WriteString(" " + markEndOptional + " ")
+		default:
+			// Text that merely looks like markup is kept.
+			p.WriteString(tag)
+			m.WriteString(tag)
+		}
+	}
+	p.WriteString(s)
+	m.WriteString(s)
+	return p.Bytes(), m.Bytes()
+}
+
+// parseTemplate returns the text of a template, its tokens and the template
+// marking them, with the supplied equivalences applied.
+func parseTemplate(content []byte, eq *equivalences) ([]byte, *document, *template) {
+	plain, marked := renderTemplate(content)
+	raw := eq.apply(tokenize(marked))
+	doc := &document{}
+	t := &template{slots: make(map[int]bool)}
+	vars, optionals := 0, 0
+	varTokens := 0
+	for _, tok := range raw.Tokens {
+		switch tok.Text {
+		case markBeginVar:
+			vars++
+			varTokens = 0
+			continue
+		case markEndVar:
+			if vars > 0 {
+				vars--
+				if varTokens == 0 {
+					t.slots[len(doc.Tokens)] = true
+				}
+			}
+			continue
+		case markBeginOptional:
+			optionals++
+			continue
+		case markEndOptional:
+			if optionals > 0 {
+				optionals--
+			}
+			continue
+		}
+		varTokens++
+		tok.Index = len(doc.Tokens)
+		doc.Tokens = append(doc.Tokens, tok)
+		t.variable = append(t.variable, vars > 0)
+		t.optional = append(t.optional, optionals > 0)
+		if vars > 0 || optionals > 0 {
+			t.omittable++
+		}
+	}
+	return plain, doc, t
+}
+
+// free reports whether the known token at position k may be missing from a
+// match.
+func (t *template) free(k int) bool {
+	return k < len(t.variable) && (t.variable[k] || t.optional[k])
+}
+
+// replaceable reports whether text may be inserted before the known token at
+// position k: within or next to a variable.
+func (t *template) replaceable(k int) bool {
+	return t.slots[k] || (k < len(t.variable) && t.variable[k]) || (k > 0 && k-1 < len(t.variable) && t.variable[k-1])
+}
+
+// filter removes the edits the template allows from diffs starting at the
+// first known token: the known words of its variable and optional text that
+// are missing, and the words inserted in its variables. It returns the
+// remaining diffs and the number of words removed.
+func (t *template) filter(diffs []diffmatchpatch.Diff) ([]diffmatchpatch.Diff, int) {
+	var out []diffmatchpatch.Diff
+	k, removed := 0, 0
+	for _, d := range diffs {
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			k += wordLen(d.Text)
+			out = append(out, d)
+		case diffmatchpatch.DiffDelete:
+			if t.replaceable(k) {
+				removed += wordLen(d.Text)
+				continue
+			}
+			out = append(out, d)
+		case diffmatchpatch.DiffInsert:
+			var kept []string
+			for _, w := range strings.Fields(d.Text) {
+				if t.free(k) {
+					removed++
+				} else {
+					kept = append(kept, w)
+				}
+				k++
+			}
+			if len(kept) > 0 {
+				out = append(out, diffmatchpatch.Diff{Type: d.Type, Text: strings.Join(kept, " ")})
+			}
+		}
+	}
+	return out, removed
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"strings"
+	"testing"
+)
+
+// bsdTemplate is the SPDX license template of BSD-3-Clause, abridged.
+const bsdTemplate = `<<beginOptional>>BSD 3-Clause License
+
+<<endOptional>>Redistribution and use in source and binary forms, with or without modification,
+are permitted provided that the following conditions are met:
+
+1. Redistributions of source code must retain the above copyright notice, this
+   list of conditions and the following disclaimer.
+
+2. Redistributions in binary form must reproduce the above copyright notice,
+   this list of conditions and the following disclaimer in the documentation
+   and/or other materials provided with the distribution.
+
+3. Neither the name of <<var;name="copyrightHolder0";original="the copyright holder";match=".+">> nor the names of its contributors
+   may be used to endorse or promote products derived from this software without
+   specific prior written permission.
+
+THIS SOFTWARE IS PROVIDED BY <<var;name="copyrightHolder1";original="THE COPYRIGHT HOLDERS AND CONTRIBUTORS";match=".+">> "AS IS" AND
+ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE IMPLIED
+WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+DISCLAIMED. IN NO EVENT SHALL <<var;name="copyrightHolder2";original="THE COPYRIGHT HOLDER OR CONTRIBUTORS";match=".+">> BE LIABLE FOR
+ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL DAMAGES
+(INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES;
+LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON
+ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT
+(INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+`
+
+// bsdCopy is a copy of the license naming its copyright holder.
+var bsdCopy = strings.NewReplacer(
+	"<<beginOptional>>BSD 3-Clause License\n\n<<endOptional>>", "",
+	`<<var;name="copyrightHolder0";original="the copyright holder";match=".+">>`, "Acme Widget Works Limited",
+	`<<var;name="copyrightHolder1";original="THE COPYRIGHT HOLDERS AND CONTRIBUTORS";match=".+">>`, "ACME WIDGET WORKS",
+	`<<var;name="copyrightHolder2";original="THE COPYRIGHT HOLDER OR CONTRIBUTORS";match=".+">>`, "ACME WIDGET WORKS LIMITED",
+).Replace(bsdTemplate)
+
+func TestRenderTemplate(t *testing.T) {
+	plain, marked := renderTemplate([]byte(`<<beginOptional;name="title">>The Title<<endOptional>>
+Copyright <<var;name="holder";original="the \"holder\"";match=".+">>, <<var;name="year";original="";match=".*">> 1 << 2`))
+	if want := "The Title\nCopyright the \"holder\",  1 << 2"; string(plain) != want {
+		t.Errorf("renderTemplate() plain = %q, want %q", plain, want)
+	}
+	if got, want := strings.Count(string(marked), "\n"), 1; got != want {
+		t.Errorf("renderTemplate() marked has %d line breaks, want %d", got, want)
+	}
+
+	_, doc, tmpl := parseTemplate([]byte(`<<beginOptional>>The Title<<endOptional>>
+Copyright <<var;name="holder";original="the holder";match=".+">> <<var;name="year";original="";match=".*">> and its contributors`), nil)
+	var words []string
+	for _, tok := range doc.Tokens {
+		words = append(words, tok.Text)
+	}
+	if got, want := strings.Join(words, " "), "the title copyright the holder and its contributors"; got != want {
+		t.Errorf("parseTemplate() tokens = %q, want %q", got, want)
+	}
+	for k, want := range []bool{true, true, false, true, true, false, false, false} {
+		if got := tmpl.free(k); got != want {
+			t.Errorf("free(%d) = %v, want %v", k, got, want)
+		}
+	}
+	if !tmpl.slots[5] || !tmpl.replaceable(5) || tmpl.replaceable(7) || tmpl.omittable != 4 {
+		t.Errorf("parseTemplate() template = %+v, want a slot at 5 and 4 omittable tokens", tmpl)
+	}
+}
+
+func TestTemplateMatch(t *testing.T) {
+	// Naming the copyright holder drags the plain text of the license below
+	// the threshold.
+	for _, tt := range []struct {
+		desc    string
+		content string
+		want    bool
+	}{
+		{
+			desc:    "template",
+			content: bsdTemplate,
+			want:    true,
+		},
+		{
+			desc:    "plain text",
+			content: string(renderedTemplate(bsdTemplate)),
+			want:    false,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			c := NewClassifier(.8)
+			c.AddContent("BSD-3-Clause", []byte(tt.content))
+			m := licenseMatches(c.Match([]byte(bsdCopy)))
+			if got := len(m) == 1 && m[0].Name == "BSD-3-Clause" && m[0].Confidence == 1; got != tt.want {
+				t.Errorf("Match() = %v, want an exact match: %v", m, tt.want)
+			}
+		})
+	}
+
+	// Edits outside the variable and optional text still count.
+	c := NewClassifier(.8)
+	c.AddContent("BSD-3-Clause", []byte(bsdTemplate))
+	edited := strings.Replace(bsdCopy, "specific prior written permission", "permission", 1)
+	m := licenseMatches(c.Match([]byte(edited)))
+	if len(m) != 1 || m[0].Confidence >= 1 {
+		t.Errorf("Match() of an edited copy = %v, want a match below full confidence", m)
+	}
+	e, err := c.Explain([]byte(edited), m[0])
+	if err != nil {
+		t.Fatalf("Explain() failed: %v", err)
+	}
+	if e.Distance != 3 || !strings.HasPrefix(e.Rules[0], "ignored: ") {
+		t.Errorf("Explain() distance = %d, rules = %q, want 3 edits and the template edits ignored", e.Distance, e.Rules)
+	}
+}
+
+func renderedTemplate(s string) []byte {
+	plain, _ := renderTemplate([]byte(s))
+	return plain
+}
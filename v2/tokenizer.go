@@ -15,6 +15,7 @@
 package classifier
 
 import (
+	"bytes"
 	"html"
 	"regexp"
 	"strings"
@@ -31,8 +32,20 @@ func isSignificant(r rune) bool {
 
 var eol = "\n"
 
+// isIdeographic reports whether r belongs to a script written without spaces
+// between words: Chinese characters and the Japanese kana, including the
+// prolonged sound marks. Runs of these are tokenized a character at a time,
+// so that the q-grams of the searchset and the word diff work on them as they
+// do on the words of other scripts.
+func isIdeographic(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) || r == '\u30fc' || r == '\uff70'
+}
+
 func cleanupToken(in string) string {
 	r, _ := utf8.DecodeRuneInString(in)
+	if isIdeographic(r) {
+		return in
+	}
 	var out strings.Builder
 	if !unicode.IsLetter(r) {
 		if unicode.IsDigit(r) {
@@ -66,16 +79,29 @@ func cleanupToken(in string) string {
 	return out.String()
 }
 
-// tokenize produces a document from the input content.
+// tokenize produces a document from the input content. Large inputs are
+// tokenized in chunks in parallel.
 func tokenize(in []byte) *document {
-	// Apply the global transforms described in SPDX
+	if len(in) >= parallelTokenizeSize {
+		return tokenizeChunks(in, tokenizeChunkSize)
+	}
+	return &document{Tokens: cleanupTokens(scanTokens(removeIgnorableTexts(normalizeText(in))))}
+}
 
-	norm := strings.ToLower(string(in))
+// normalizeText applies the global transforms described in SPDX to the input
+// content. The transforms don't add or remove line breaks, so they can be
+// applied to each line independently.
+func normalizeText(in []byte) string {
+	norm := strings.ToLower(normalizeUnicode(string(in)))
 	norm = html.UnescapeString(norm)
+	norm = normalizeURLs(norm)
 	norm = normalizePunctuation(norm)
-	norm = normalizeEquivalentWords(norm)
-	norm = removeIgnorableTexts(norm)
+	return normalizeEquivalentWords(norm)
+}
 
+// scanTokens splits normalized text into raw tokens, including tokens for
+// line breaks, which are sanitized by cleanupTokens.
+func scanTokens(norm string) []*token {
 	var doc document
 	// Iterate on a line-by-line basis.
 
@@ -114,10 +140,11 @@ func tokenize(in []byte) *document {
 				continue
 			}
 
-			// We're at a word/number character.
-			for pos < len(line) {
+			// We're at a word/number character. An ideographic character is a
+			// token of its own, and ends the word before it.
+			for !isIdeographic(r) && pos < len(line) {
 				next()
-				if unicode.IsSpace(r) {
+				if unicode.IsSpace(r) || isIdeographic(r) {
 					pos -= wid // Will skip this in outer loop
 					break
 				}
@@ -152,8 +179,7 @@ func tokenize(in []byte) *document {
 		}
 		doc.Tokens = append(doc.Tokens, &tok)
 	}
-	doc.Tokens = cleanupTokens(doc.Tokens)
-	return &doc
+	return doc.Tokens
 }
 
 func cleanupTokens(in []*token) []*token {
@@ -198,6 +224,72 @@ func cleanupTokens(in []*token) []*token {
 	return out
 }
 
+// urlPattern matches URLs with a scheme, or starting with www. The match stops
+// at whitespace and at characters that commonly delimit a URL in text.
+var urlPattern = regexp.MustCompile(`\b(?:(?:https?|ftp)://|www\.)[^\s<>"'()\[\]]+`)
+
+// urlSuffixes are file extensions that are commonly present or absent in
+// otherwise equivalent references to a license URL.
+var urlSuffixes = []string{".html", ".htm", ".php", ".txt"}
+
+// normalizeURLs rewrites URLs into a canonical form so that equivalent
+// references to the same page don't produce differences. The scheme, a
+// leading www, file extensions of web pages and trailing slashes are removed,
+// so http://www.apache.org/licenses/LICENSE-2.0 and
+// https://apache.org/licenses/LICENSE-2.0.html are treated the same.
+func normalizeURLs(s string) string {
+	return urlPattern.ReplaceAllStringFunc(s, func(u string) string {
+		if i := strings.Index(u, "://"); i != -1 {
+			u = u[i+3:]
+		}
+		u = strings.TrimPrefix(u, "www.")
+		// Trailing punctuation usually belongs to the surrounding sentence.
+		u = strings.TrimRight(u, ".,;:")
+		for _, suffix := range urlSuffixes {
+			u = strings.TrimSuffix(u, suffix)
+		}
+		return strings.TrimRight(u, "/")
+	})
+}
+
+// unicodeCompatibility maps compatibility characters, which commonly appear in
+// text extracted from PDFs and word processors, to their plain equivalents, in
+// the spirit of Unicode NFKC normalization.
+var unicodeCompatibility = strings.NewReplacer(
+	// Ligatures.
+	"\ufb00", "ff", "\ufb01", "fi", "\ufb02", "fl", "\ufb03", "ffi",
+	"\ufb04", "ffl", "\ufb05", "st", "\ufb06", "st",
+	// No-break, fixed-width and ideographic spaces.
+	"\u00a0", " ", "\u2000", " ", "\u2001", " ", "\u2002", " ", "\u2003", " ",
+	"\u2004", " ", "\u2005", " ", "\u2006", " ", "\u2007", " ", "\u2008", " ",
+	"\u2009", " ", "\u200a", " ", "\u202f", " ", "\u205f", " ", "\u3000", " ",
+	// Line and paragraph separators, which editors don't break lines at, so
+	// that line numbers are those of the input.
+	"\u2028", " ", "\u2029", " ",
+	// Invisible characters: soft hyphen, zero-width spaces and joiners, and
+	// the byte order mark.
+	"\u00ad", "", "\u200b", "", "\u200c", "", "\u200d", "", "\u2060", "", "\ufeff", "",
+	// Hyphens and dashes not covered by interchangeablePunctuation.
+	"\u2011", "-", "\u2015", "-", "\u2212", "-", "\ufe58", "-", "\ufe63", "-", "\uff0d", "-",
+	// Quotation marks not covered by interchangeablePunctuation.
+	"\u201a", "'", "\u201b", "'", "\u201e", "'", "\u201f", "'", "\u2032", "'", "\u2033", "'",
+	"\u00ab", "'", "\u00bb", "'", "\u2039", "'", "\u203a", "'",
+	// Ellipsis.
+	"\u2026", "...",
+)
+
+// normalizeUnicode replaces compatibility characters with their plain
+// equivalents and folds fullwidth ASCII forms to ASCII.
+func normalizeUnicode(s string) string {
+	s = unicodeCompatibility.Replace(s)
+	return strings.Map(func(r rune) rune {
+		if r >= '\uff01' && r <= '\uff5e' {
+			return r - 0xfee0
+		}
+		return r
+	}, s)
+}
+
 // interchangeablePunctutation is punctuation that can be normalized.
 var interchangeablePunctuation = []struct {
 	interchangeable string
@@ -358,3 +450,112 @@ func removeIgnorableTexts(s string) string {
 	}
 	return strings.Join(out, "\n") + "\n"
 }
+
+// Token is a word of a tokenized document, normalized as the classifier
+// matches it.
+type Token struct {
+	Text  string // normalized text of the token
+	Index int    // position of the token, as in Match.StartTokenIndex
+	Line  int    // line of the token in the source, starting at 1
+	// Start and End are the byte offsets of the token in the source, or of
+	// its first part for a word hyphenated across lines. They are both zero for tokens that can't be located, such as those of text
+	// extracted from markup or binary content.
+	Start, End int
+}
+
+// TokenizedDocument is content tokenized for matching. Pipelines that
+// analyze the tokens of content in other ways, such as indexing it for
+// search, can tokenize it once with Tokenize and classify the document with
+// MatchTokenized, with any number of classifiers.
+type TokenizedDocument struct {
+	content []byte
+	// source is the content as supplied, before any markup was stripped.
+	source []byte
+	doc    *document
+	// eq are the equivalences applied to the tokens, if the document was
+	// tokenized by a classifier that has them.
+	eq *equivalences
+}
+
+// Tokenize tokenizes the supplied content. The content must not be modified
+// while the document is in use.
+func Tokenize(in []byte) *TokenizedDocument {
+	return &TokenizedDocument{content: in, source: in, doc: tokenize(in)}
+}
+
+// Tokenize tokenizes the supplied content as Match does, after stripping its
+// markup or extracting the strings of binary content as configured. Matching
+// the document with MatchTokenized is equivalent to matching the content.
+func (c *Classifier) Tokenize(in []byte) *TokenizedDocument {
+	c.mu.RLock()
+	content := c.stripMarkup(in)
+	eq := c.equivalences
+	c.mu.RUnlock()
+	return &TokenizedDocument{content: content, source: in, doc: eq.apply(tokenize(content)), eq: eq}
+}
+
+// Tokens returns the tokens of the document in order.
+func (d *TokenizedDocument) Tokens() []Token {
+	var offsets [][2]int
+	if bytes.Equal(d.content, d.source) {
+		offsets = tokenOffsets(d.source, d.doc.Tokens)
+	}
+	out := make([]Token, len(d.doc.Tokens))
+	for i, t := range d.doc.Tokens {
+		out[i] = Token{Text: t.Text, Index: t.Index, Line: t.Line}
+		if offsets != nil {
+			out[i].Start, out[i].End = offsets[i][0], offsets[i][1]
+		}
+	}
+	return out
+}
+
+// Normalize returns the normalized form of the supplied content, which is the
+// view of the text the classifier uses for matching. Each line of the output
+// holds the tokens found on the corresponding line of the input, separated by
+// a single space, so line numbers reported in matches can be correlated with
+// the output.
+func Normalize(in []byte) string {
+	return normalizedText(tokenize(in))
+}
+
+// normalizedText returns the tokens of the document, a line of them for
+// each line of its source.
+func normalizedText(doc *document) string {
+	if len(doc.Tokens) == 0 {
+		return ""
+	}
+	lines := make([][]string, doc.Tokens[len(doc.Tokens)-1].Line)
+	for _, t := range doc.Tokens {
+		lines[t.Line-1] = append(lines[t.Line-1], t.Text)
+	}
+	var out strings.Builder
+	for _, l := range lines {
+		out.WriteString(strings.Join(l, " "))
+		out.WriteString(eol)
+	}
+	return out.String()
+}
+
+// Normalize returns the normalized form of the supplied content as Normalize
+// does, after stripping its markup or extracting the strings of binary
+// content as configured, which is the text Match compares with the corpus.
+func (c *Classifier) Normalize(in []byte) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return normalizedText(c.tokenize(c.stripMarkup(in)))
+}
+
+// CorpusText returns the normalized text of the corpus entry with the
+// supplied name, as reported by Match.Variant, with its tokens separated by a
+// single space. Comparing it with the output of Normalize shows why content
+// didn't match the entry.
+func (c *Classifier) CorpusText(name string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	d, ok := c.docs[name]
+	if !ok {
+		return "", false
+	}
+	return d.norm, true
+}
@@ -15,6 +15,8 @@
 package classifier
 
 import (
+	"io/ioutil"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -229,11 +231,36 @@ The FreeType Project`,
 				       cation follow.`,
 			output: "distribution and modification follow",
 		},
+		{
+			name:   "equivalent URLs",
+			input:  "http://www.apache.org/licenses/LICENSE-2.0 https://apache.org/licenses/LICENSE-2.0.html",
+			output: "apacheorglicenseslicense apacheorglicenseslicense",
+		},
+		{
+			name:   "URL with trailing slash and punctuation",
+			input:  "see <http://www.gnu.org/licenses/>. or www.gnu.org/licenses.",
+			output: "see gnuorglicenses or gnuorglicenses",
+		},
 		{
 			name:   "preserve internal references, even on line break",
 			input:  "(ii) should be preserved as (ii) is preserved",
 			output: "ii should be preserved as ii is preserved",
 		},
+		{
+			name:   "Chinese text is tokenized by character",
+			input:  "本软件按“原样”提供。",
+			output: "本 软 件 按 原 样 提 供",
+		},
+		{
+			name:   "Japanese text is tokenized by character",
+			input:  "ソフトウェアは、ユーザーに",
+			output: "ソ フ ト ウ ェ ア は ユ ー ザ ー に",
+		},
+		{
+			name:   "words end at ideographic characters",
+			input:  "使用GPL许可证，版本2.0",
+			output: "使 用 gpl 许 可 证 版 本 2.0",
+		},
 	}
 
 	for _, test := range tests {
@@ -251,3 +278,172 @@ The FreeType Project`,
 		})
 	}
 }
+
+func TestNormalizeURLs(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{
+			input:  "http://www.apache.org/licenses/LICENSE-2.0",
+			output: "apache.org/licenses/LICENSE-2.0",
+		},
+		{
+			input:  "https://opensource.org/licenses/MIT.html",
+			output: "opensource.org/licenses/MIT",
+		},
+		{
+			input:  "(see ftp://example.com/pub/license.txt)",
+			output: "(see example.com/pub/license)",
+		},
+		{
+			input:  "no urls here.",
+			output: "no urls here.",
+		},
+	}
+	for _, test := range tests {
+		if got := normalizeURLs(test.input); got != test.output {
+			t.Errorf("normalizeURLs(%q) = %q, want %q", test.input, got, test.output)
+		}
+	}
+}
+
+func TestNormalizeUnicode(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{
+			input:  "\ufb01le \ufb02ag o\ufb03ce",
+			output: "file flag office",
+		},
+		{
+			input:  "non\u00a0exclusive,\u2009royalty\u202ffree",
+			output: "non exclusive, royalty free",
+		},
+		{
+			input:  "sub\u00adli\u200bcense\ufeff",
+			output: "sublicense",
+		},
+		{
+			input:  "\u201eAS IS\u201f \u00abwithout\u00bb non\u2011infringement \u2026",
+			output: "'AS IS' 'without' non-infringement ...",
+		},
+		{
+			input:  "\uff2c\uff29\uff23\uff25\uff2e\uff33\uff25\uff0d\uff12\uff0e\uff10",
+			output: "LICENSE-2.0",
+		},
+		{
+			input:  "first\u2028second\u2029third",
+			output: "first second third",
+		},
+	}
+	for _, test := range tests {
+		if got := normalizeUnicode(test.input); got != test.output {
+			t.Errorf("normalizeUnicode(%q) = %q, want %q", test.input, got, test.output)
+		}
+	}
+
+	// Editors don't break lines at the line and paragraph separators, so
+	// neither does the tokenizer.
+	doc := tokenize([]byte("first\u2028second\nthird"))
+	var lines []int
+	for _, tok := range doc.Tokens {
+		lines = append(lines, tok.Line)
+	}
+	if diff := cmp.Diff([]int{1, 1, 2}, lines); diff != "" {
+		t.Errorf("token lines mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		output string
+	}{
+		{
+			name:   "empty input",
+			input:  "",
+			output: "",
+		},
+		{
+			name:   "single line",
+			input:  "Here are some words. ",
+			output: "here are some words\n",
+		},
+		{
+			name:   "preserves line positions",
+			input:  "The AWESOME Project\n\nModifi-\ncations prohibited",
+			output: "the awesome project\n\nmodifications\nprohibited\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Normalize([]byte(test.input)); got != test.output {
+				t.Errorf("Normalize(%q): got %q want %q", test.input, got, test.output)
+			}
+		})
+	}
+}
+
+func TestTokenizedDocument(t *testing.T) {
+	d := Tokenize([]byte("The AWESOME Project\n\nModifi-\ncations prohibited"))
+	want := []Token{
+		{Text: "the", Index: 0, Line: 1, Start: 0, End: 3},
+		{Text: "awesome", Index: 1, Line: 1, Start: 4, End: 11},
+		{Text: "project", Index: 2, Line: 1, Start: 12, End: 19},
+		{Text: "modifications", Index: 3, Line: 3, Start: 21, End: 28},
+		{Text: "prohibited", Index: 4, Line: 4, Start: 37, End: 47},
+	}
+	if diff := cmp.Diff(want, d.Tokens()); diff != "" {
+		t.Errorf("Tokens(): diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestClassifierTokenize(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	c.SetInputFormat(FormatHTML)
+	in := []byte("<p>The <b>AWESOME</b> Project</p>\n<p>Use at your own risk</p>")
+	var got []string
+	for _, tok := range c.Tokenize(in).Tokens() {
+		got = append(got, tok.Text)
+	}
+	want := []string{"the", "awesome", "project", "use", "at", "your", "own", "risk"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Tokenize(): diff (-want +got):\n%s", diff)
+	}
+	if got, want := c.Normalize(in), "the awesome project\nuse at your own risk\n"; got != want {
+		t.Errorf("Normalize() = %q, want %q", got, want)
+	}
+}
+
+func TestCorpusText(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	in, err := ioutil.ReadFile(filepath.Join(baseLicenses, "MIT.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms := c.Match(in)
+	if len(ms) == 0 {
+		t.Fatal("Match() found no matches")
+	}
+	text, ok := c.CorpusText(ms[0].Variant)
+	if !ok {
+		t.Fatalf("CorpusText(%q) found no entry", ms[0].Variant)
+	}
+	// The license text normalizes to its corpus entry.
+	if want := strings.Join(strings.Fields(c.Normalize(in)), " "); text != want {
+		t.Errorf("CorpusText(%q) = %q, want %q", ms[0].Variant, text, want)
+	}
+	if _, ok := c.CorpusText("no/such/entry"); ok {
+		t.Error("CorpusText() found an unknown entry")
+	}
+}
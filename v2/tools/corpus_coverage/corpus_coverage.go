@@ -0,0 +1,213 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The corpus_coverage program compares the license corpus against a release of
+// the SPDX license list and reports which SPDX licenses and exceptions are
+// present in the corpus, which are only present as non-canonical variants
+// (header-only entries, deprecated identifiers or differently cased names),
+// and which are missing. Corpus entries that don't correspond to any SPDX
+// identifier are also reported.
+//
+// The SPDX data is read from the licenses.json and exceptions.json files of a
+// https://github.com/spdx/license-list-data release.
+//
+//	$ corpus_coverage -spdx-licenses json/licenses.json -spdx-exceptions json/exceptions.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	classifier "github.com/google/licenseclassifier/v2"
+)
+
+var (
+	licenseDir     = flag.String("license-dir", "", "directory containing the license corpus (defaults to the corpus in the source tree)")
+	spdxLicenses   = flag.String("spdx-licenses", "", "path to the SPDX licenses.json file")
+	spdxExceptions = flag.String("spdx-exceptions", "", "path to the SPDX exceptions.json file (optional)")
+)
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s -spdx-licenses <licenses.json> [-spdx-exceptions <exceptions.json>]
+
+Report the coverage of the license corpus against an SPDX license list release.
+
+Options:
+`, filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+}
+
+// spdxList is the subset of the SPDX license-list-data JSON format used here.
+// Licenses and exceptions are published in separate files with different
+// identifier keys.
+type spdxList struct {
+	Version    string `json:"licenseListVersion"`
+	Licenses   []spdxEntry
+	Exceptions []spdxEntry
+}
+
+type spdxEntry struct {
+	LicenseID   string `json:"licenseId"`
+	ExceptionID string `json:"licenseExceptionId"`
+	Deprecated  bool   `json:"isDeprecatedLicenseId"`
+}
+
+func (e spdxEntry) id() string {
+	if e.ExceptionID != "" {
+		return e.ExceptionID
+	}
+	return e.LicenseID
+}
+
+// coverage is the status of a single SPDX identifier in the corpus.
+type coverage int
+
+const (
+	missing coverage = iota
+	variant
+	present
+)
+
+func (c coverage) String() string {
+	switch c {
+	case present:
+		return "present"
+	case variant:
+		return "variant"
+	}
+	return "missing"
+}
+
+func main() {
+	flag.Parse()
+	if *spdxLicenses == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dir := *licenseDir
+	if dir == "" {
+		_, filename, _, _ := runtime.Caller(0)
+		dir = filepath.Join(filepath.Dir(filename), "..", "..", "licenses")
+	}
+	corpus, err := corpusEntries(dir)
+	if err != nil {
+		log.Fatalf("cannot read license corpus: %v", err)
+	}
+
+	list, err := readList(*spdxLicenses)
+	if err != nil {
+		log.Fatalf("cannot read SPDX licenses: %v", err)
+	}
+	entries := list.Licenses
+	if *spdxExceptions != "" {
+		exc, err := readList(*spdxExceptions)
+		if err != nil {
+			log.Fatalf("cannot read SPDX exceptions: %v", err)
+		}
+		entries = append(entries, exc.Exceptions...)
+	}
+
+	fmt.Printf("SPDX license list version %s\n", list.Version)
+	counts := make(map[coverage]int)
+	known := make(map[string]bool)
+	for _, e := range entries {
+		status, names := check(e.id(), corpus)
+		for _, n := range names {
+			known[n] = true
+		}
+		counts[status]++
+		var note string
+		if e.Deprecated {
+			note = " (deprecated)"
+		}
+		if status == variant {
+			fmt.Printf("%s\t%s%s: %s\n", status, e.id(), note, strings.Join(names, ", "))
+		} else {
+			fmt.Printf("%s\t%s%s\n", status, e.id(), note)
+		}
+	}
+
+	var extra []string
+	for n := range corpus {
+		if !known[n] {
+			extra = append(extra, n)
+		}
+	}
+	sort.Strings(extra)
+	for _, n := range extra {
+		fmt.Printf("extra\t%s\n", n)
+	}
+	fmt.Printf("\n%d present, %d variant, %d missing, %d corpus entries not in SPDX\n",
+		counts[present], counts[variant], counts[missing], len(extra))
+}
+
+// corpusEntries returns the names of the corpus documents in dir, keyed by
+// document name and mapped to the license name the classifier reports.
+func corpusEntries(dir string) (map[string]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]string)
+	for _, f := range files {
+		name := strings.TrimSuffix(filepath.Base(f), ".txt")
+		out[name] = classifier.LicenseName(name)
+	}
+	return out, nil
+}
+
+func readList(path string) (*spdxList, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var l spdxList
+	if err := json.Unmarshal(b, &l); err != nil {
+		return nil, fmt.Errorf("couldn't parse %s: %v", path, err)
+	}
+	return &l, nil
+}
+
+// check determines the coverage of the SPDX identifier id, returning the corpus
+// documents that correspond to it.
+func check(id string, corpus map[string]string) (coverage, []string) {
+	// The corpus predates the -only/-or-later suffixes for GNU licenses and
+	// uses the deprecated identifiers.
+	base := strings.TrimSuffix(strings.TrimSuffix(id, "-only"), "-or-later")
+	var names []string
+	for n, lic := range corpus {
+		if strings.EqualFold(lic, id) || strings.EqualFold(lic, base) {
+			names = append(names, n)
+		}
+	}
+	if len(names) == 0 {
+		return missing, nil
+	}
+	sort.Strings(names)
+	if _, ok := corpus[id]; ok {
+		return present, names
+	}
+	return variant, names
+}
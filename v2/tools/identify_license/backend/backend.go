@@ -0,0 +1,535 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package backend contains the necessary functions to classify a license.
+package backend
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	classifier "github.com/google/licenseclassifier/v2"
+	"github.com/google/licenseclassifier/v2/comments"
+	"github.com/google/licenseclassifier/v2/extract"
+	"github.com/google/licenseclassifier/v2/tools/identify_license/results"
+)
+
+// ClassifierInterface is the interface each backend must implement.
+type ClassifierInterface interface {
+	Close()
+	ClassifyLicenses(filenames []string) []error
+	ClassifyLicensesWithContext(ctx context.Context, filenames []string) []error
+	GetResults() results.LicenseTypes
+}
+
+// ClassifierBackend is an object that handles classifying a license.
+type ClassifierBackend struct {
+	results    results.LicenseTypes
+	files      []*results.FileResult
+	mu         sync.Mutex
+	classifier *classifier.Classifier
+	explainDir string
+	minStrings int
+	comments   string
+	quiet      bool
+	unknowns   bool
+	maxSize    int64
+	// licenseFiles are the matches of the license files pointed to by the
+	// files classified, keyed by path.
+	licenseFiles map[string]classifier.Matches
+}
+
+// DefaultLicenseDirectory returns the location of the license corpus in the
+// classifier source tree.
+func DefaultLicenseDirectory() (string, error) {
+	_, filename, _, ok := runtime.Caller(0)
+	if !ok {
+		return "", fmt.Errorf("unable to compute path of licenseclassifier source")
+	}
+	// this file must stay in tools/identify_license/backend, or the relative path will be wrong.
+	return filepath.Join(filepath.Dir(filename), "..", "..", "..", "licenses"), nil
+}
+
+// New creates a new backend working on the local filesystem. The corpus is
+// loaded from the supplied source, or is the corpus embedded in the
+// classifier if it is nil. The options configure the classifier further.
+func New(threshold float64, corpus classifier.CorpusSource, opts ...classifier.Option) (*ClassifierBackend, error) {
+	var c *classifier.Classifier
+	var err error
+	opts = append([]classifier.Option{classifier.WithThreshold(threshold)}, opts...)
+	if corpus == nil {
+		c, err = classifier.NewDefaultClassifier(opts...)
+	} else {
+		c, err = classifier.New(append(opts, classifier.WithCorpusSource(corpus))...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ClassifierBackend{classifier: c}, nil
+}
+
+// Close does nothing here since there's nothing to close.
+func (b *ClassifierBackend) Close() {
+}
+
+// ClassifyLicenses runs the license classifier over the given file.
+func (b *ClassifierBackend) ClassifyLicenses(filenames []string) (errors []error) {
+	// Create a pool from which tasks can later be started. We use a pool because the OS limits
+	// the number of files that can be open at any one time.
+	const numTasks = 1000
+	task := make(chan bool, numTasks)
+	for i := 0; i < numTasks; i++ {
+		task <- true
+	}
+
+	errs := make(chan error, len(filenames))
+
+	var wg sync.WaitGroup
+	analyze := func(filename string) {
+		defer func() {
+			task <- true
+			wg.Done()
+		}()
+		if err := b.classifyLicense(filename); err != nil {
+			errs <- err
+		}
+	}
+
+	for _, filename := range filenames {
+		wg.Add(1)
+		<-task
+		go analyze(filename)
+	}
+	go func() {
+		wg.Wait()
+		close(task)
+		close(errs)
+	}()
+
+	for err := range errs {
+		errors = append(errors, err)
+	}
+	return errors
+}
+
+// ClassifyLicensesWithContext runs the license classifier over the given file; ensure that it will respect the timeout in the provided context.
+func (b *ClassifierBackend) ClassifyLicensesWithContext(ctx context.Context, filenames []string) (errors []error) {
+	done := make(chan bool)
+	go func() {
+		errors = b.ClassifyLicenses(filenames)
+		done <- true
+	}()
+	select {
+	case <-ctx.Done():
+		err := ctx.Err()
+		errors = append(errors, err)
+		return errors
+	case <-done:
+		return errors
+	}
+}
+
+// classifyLicense is called by a Go-function to perform the actual
+// classification of a license.
+func (b *ClassifierBackend) classifyLicense(filename string) error {
+	var (
+		contents []byte
+		size     int64
+		stale    bool
+		err      error
+	)
+	if b.maxSize > 0 {
+		contents, size, stale, err = readHead(filename, b.maxSize)
+	} else {
+		contents, stale, err = classifier.ReadFileStable(filename)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read %q: %v", filename, err)
+	}
+
+	if !b.quiet {
+		log.Printf("Classifying license(s): %s", filename)
+	}
+	start := time.Now()
+	fr := &results.FileResult{Filename: filename, Stale: stale}
+	if stale {
+		fr.Warnings = append(fr.Warnings, &results.Warning{
+			Kind:    results.WarningStale,
+			Message: "file changed while it was read; the reported lines may not match its current content",
+		})
+	}
+	if b.maxSize > 0 && int64(len(contents)) > b.maxSize {
+		contents = truncate(contents, b.maxSize)
+		msg := fmt.Sprintf("only the first %d of %d bytes were classified", len(contents), size)
+		if size <= b.maxSize {
+			msg = fmt.Sprintf("only the first %d of more than %d bytes were classified", len(contents), b.maxSize)
+		}
+		fr.Warnings = append(fr.Warnings, &results.Warning{
+			Kind:    results.WarningTruncated,
+			Message: msg,
+		})
+	}
+	if b.minStrings > 0 {
+		for _, m := range b.classifier.MatchBlob(contents, b.minStrings) {
+			lt := licenseType(filename, m.Match)
+			lt.StartOffset = m.StartOffset
+			lt.EndOffset = m.EndOffset
+			fr.Licenses = append(fr.Licenses, lt)
+		}
+	} else {
+		if f := extract.Detect(contents); f != extract.Text {
+			if text, err := extract.Bytes(contents); err != nil {
+				fr.Warnings = append(fr.Warnings, &results.Warning{
+					Kind:    results.WarningExtractorFallback,
+					Message: fmt.Sprintf("unable to extract text from %s document, classifying raw content: %v", f, err),
+				})
+			} else {
+				contents = text
+			}
+		}
+		if lang := comments.LanguageOf(filename); lang != comments.Unknown {
+			switch b.comments {
+			case "all":
+				contents = comments.Extract(contents, lang)
+			case "header":
+				contents = comments.Header(contents, lang)
+			}
+		}
+		if classifier.LooksBinary(contents) {
+			fr.Warnings = append(fr.Warnings, &results.Warning{
+				Kind:    results.WarningDegraded,
+				Message: "file appears to be binary; consider classifying it with -strings",
+			})
+		}
+		matches := b.classifier.Match(contents)
+		if len(matches) == 0 {
+			lts, warnings := b.pointedLicenses(filename, contents)
+			fr.Licenses = append(fr.Licenses, lts...)
+			fr.Warnings = append(fr.Warnings, warnings...)
+		}
+		for i, m := range matches {
+			if b.explainDir != "" {
+				if err := b.writeExplanation(filename, contents, i, m); err != nil {
+					return err
+				}
+			}
+			fr.Licenses = append(fr.Licenses, licenseType(filename, m))
+		}
+		fr.Coverage = b.classifier.Coverage(contents, matches)
+		if b.unknowns {
+			for _, u := range b.classifier.Unknowns(contents, matches) {
+				fr.Unknowns = append(fr.Unknowns, &results.Unknown{
+					StartLine:   u.StartLine,
+					EndLine:     u.EndLine,
+					Fingerprint: uint64(u.Fingerprint),
+				})
+			}
+		}
+	}
+	for _, l := range fr.Licenses {
+		if r, ok := classifier.DeprecatedLicense(l.Name); ok {
+			fr.Warnings = append(fr.Warnings, &results.Warning{
+				Kind:    results.WarningDeprecatedLicense,
+				Message: fmt.Sprintf("%s is a deprecated SPDX identifier; use %s", l.Name, r),
+			})
+		}
+	}
+
+	b.mu.Lock()
+	b.results = append(b.results, fr.Licenses...)
+	b.files = append(b.files, fr)
+	b.mu.Unlock()
+	if !b.quiet {
+		log.Printf("Finished Classifying License %q: %v", filename, time.Since(start))
+	}
+	return nil
+}
+
+// pointedLicenses returns the licenses of the license files the content of
+// filename points to, as in "See the LICENSE file in the project root". The
+// license files are looked for from the directory of the file up to the
+// root of its version control repository, if it's in one.
+func (b *ClassifierBackend) pointedLicenses(filename string, contents []byte) (results.LicenseTypes, []*results.Warning) {
+	pointers := classifier.FindFilePointers(contents)
+	if len(pointers) == 0 {
+		return nil, nil
+	}
+	root, rel, err := projectRoot(filename)
+	if err != nil {
+		return nil, nil
+	}
+	fsys := os.DirFS(root)
+	var lts results.LicenseTypes
+	var warnings []*results.Warning
+	for _, p := range pointers {
+		f, ok := classifier.ResolveFilePointer(fsys, rel, p)
+		if !ok {
+			warnings = append(warnings, &results.Warning{
+				Kind:    results.WarningDanglingPointer,
+				Message: fmt.Sprintf("line %d points to %s, which wasn't found", p.StartLine, p.Path),
+			})
+			continue
+		}
+		if f == rel {
+			continue
+		}
+		path := filepath.Join(root, filepath.FromSlash(f))
+		matches, err := b.licenseFileMatches(path)
+		if err != nil {
+			warnings = append(warnings, &results.Warning{
+				Kind:    results.WarningDanglingPointer,
+				Message: fmt.Sprintf("line %d points to %s: %v", p.StartLine, path, err),
+			})
+			continue
+		}
+		for _, m := range matches {
+			lt := licenseType(filename, m)
+			lt.StartLine, lt.EndLine = p.StartLine, p.EndLine
+			lt.LicenseFile = path
+			lts = append(lts, lt)
+		}
+	}
+	return lts, warnings
+}
+
+// licenseFileMatches returns the matches of the license file at path,
+// classifying it the first time.
+func (b *ClassifierBackend) licenseFileMatches(path string) (classifier.Matches, error) {
+	b.mu.Lock()
+	matches, ok := b.licenseFiles[path]
+	b.mu.Unlock()
+	if ok {
+		return matches, nil
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	matches = b.classifier.Match(contents)
+	b.mu.Lock()
+	if b.licenseFiles == nil {
+		b.licenseFiles = make(map[string]classifier.Matches)
+	}
+	b.licenseFiles[path] = matches
+	b.mu.Unlock()
+	return matches, nil
+}
+
+// projectRoot returns the root of the version control repository holding
+// filename, or else the root of its volume, and the slash-separated path of
+// the file relative to it.
+func projectRoot(filename string) (string, string, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return "", "", err
+	}
+	root := filepath.VolumeName(abs) + string(filepath.Separator)
+	for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+		if isRepository(dir) {
+			root = dir
+			break
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return "", "", err
+	}
+	return root, filepath.ToSlash(rel), nil
+}
+
+// isRepository reports whether the directory is the root of a version
+// control repository.
+func isRepository(dir string) bool {
+	for _, vcs := range []string{".git", ".hg", ".svn"} {
+		if _, err := os.Stat(filepath.Join(dir, vcs)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// licenseType converts a match in filename to its result.
+func licenseType(filename string, m *classifier.Match) *results.LicenseType {
+	var alts []results.Alternative
+	for _, a := range m.Alternatives {
+		alts = append(alts, results.Alternative{Name: a.Name, Confidence: a.Confidence})
+	}
+	var threshold float64
+	if m.Metadata != nil {
+		threshold = m.Metadata.Threshold
+	}
+	return &results.LicenseType{
+		Filename:     filename,
+		Name:         m.Name,
+		ID:           m.ID,
+		SPDXID:       m.SPDXID(),
+		Language:     m.Language,
+		MatchType:    m.MatchType,
+		Confidence:   m.Confidence,
+		StartLine:    m.StartLine,
+		EndLine:      m.EndLine,
+		AliasID:      m.Alias.ID,
+		AliasName:    m.Alias.Name,
+		Alternatives: alts,
+		Approximate:  m.Approximate,
+		Overlaps:     m.Overlaps,
+
+		TrademarkRestriction: m.TrademarkRestriction,
+		BinaryAttribution:    m.BinaryAttribution,
+		RecommendedThreshold: threshold,
+	}
+}
+
+// SetInputFormat configures the markup stripped from files before they are
+// classified.
+func (b *ClassifierBackend) SetInputFormat(f classifier.Format) {
+	b.classifier.SetInputFormat(f)
+}
+
+// SetAliases installs organization-specific aliases of licenses, which are
+// reported along with their canonical names.
+func (b *ClassifierBackend) SetAliases(aliases map[string]classifier.Alias) {
+	b.classifier.SetAliases(aliases)
+}
+
+// SetCommentMode configures the backend to classify only the comments of
+// source files in a supported language: "all" keeps every comment, and
+// "header" the comments preceding the first line of code. Any other mode
+// classifies the whole file.
+func (b *ClassifierBackend) SetCommentMode(mode string) {
+	b.comments = mode
+}
+
+// SetBlobMode configures the backend to treat files as binary blobs,
+// classifying the runs of at least minLen printable characters they contain.
+// A minLen of zero disables blob mode.
+func (b *ClassifierBackend) SetBlobMode(minLen int) {
+	b.minStrings = minLen
+}
+
+// SetTopK makes the backend report the other licenses among the k best
+// scoring in the region of each match.
+func (b *ClassifierBackend) SetTopK(k int) {
+	b.classifier.SetTopK(k)
+}
+
+// SetOverlapStrategy sets the strategy deciding which of overlapping matches
+// are reported. See classifier.SetOverlapStrategy.
+func (b *ClassifierBackend) SetOverlapStrategy(s classifier.OverlapStrategy) {
+	b.classifier.SetOverlapStrategy(s)
+}
+
+// SetTimeBudget limits the time spent matching each file, after which its
+// remaining matches are approximated. See classifier.SetTimeBudget.
+func (b *ClassifierBackend) SetTimeBudget(d time.Duration) {
+	b.classifier.SetTimeBudget(d)
+}
+
+// SetUnknowns makes the backend report the regions of the files classified
+// that read like licenses but don't match any.
+func (b *ClassifierBackend) SetUnknowns(enabled bool) {
+	b.unknowns = enabled
+}
+
+// SetMaxSize limits the content classified of each file to its first n
+// bytes, ending at the last line break among them so that no line is cut,
+// and reports larger files as truncated. Zero classifies files whole.
+func (b *ClassifierBackend) SetMaxSize(n int64) {
+	b.maxSize = n
+}
+
+// readHead reads no more than the first n+1 bytes of the named file, enough
+// to tell whether it is larger than n bytes, and returns them along with the
+// size of the file, which is only known for regular files. Like
+// classifier.ReadFileStable, it reports whether the file changed while it
+// was read.
+func readHead(name string, n int64) (head []byte, size int64, stale bool, err error) {
+	before, err := os.Stat(name)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	defer f.Close()
+	if head, err = ioutil.ReadAll(io.LimitReader(f, n+1)); err != nil {
+		return nil, 0, false, err
+	}
+	after, err := os.Stat(name)
+	if err != nil {
+		// The file was removed after it was read.
+		return head, 0, true, nil
+	}
+	stale = before.Size() != after.Size() || !before.ModTime().Equal(after.ModTime())
+	if after.Mode().IsRegular() {
+		size = after.Size()
+		// A regular file read whole must be as large as it claims.
+		stale = stale || int64(len(head)) <= n && size != int64(len(head))
+	}
+	return head, size, stale, nil
+}
+
+// truncate returns the first n bytes of contents, up to the last line break
+// among them if there is one.
+func truncate(contents []byte, n int64) []byte {
+	head := contents[:n]
+	if i := bytes.LastIndexByte(head, '\n'); i >= 0 {
+		return head[:i+1]
+	}
+	return head
+}
+
+// SetQuiet suppresses the progress messages logged for each file classified.
+func (b *ClassifierBackend) SetQuiet(quiet bool) {
+	b.quiet = quiet
+}
+
+// LicenseDB returns a description of the licenses the backend recognizes.
+func (b *ClassifierBackend) LicenseDB() *classifier.LicenseDB {
+	return b.classifier.LicenseDB()
+}
+
+// CorpusVersion returns the identifier of the license corpus of the backend.
+func (b *ClassifierBackend) CorpusVersion() string {
+	return b.classifier.CorpusVersion()
+}
+
+// GetResults returns the results of the classifications. Classifications
+// still running after a timeout may add results later.
+func (b *ClassifierBackend) GetResults() results.LicenseTypes {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append(results.LicenseTypes(nil), b.results...)
+}
+
+// GetFileResults returns the results of the classifications grouped by file,
+// along with the warnings raised for each file.
+func (b *ClassifierBackend) GetFileResults() []*results.FileResult {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]*results.FileResult(nil), b.files...)
+}
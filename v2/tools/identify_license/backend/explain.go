@@ -0,0 +1,116 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	classifier "github.com/google/licenseclassifier/v2"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// SetExplainDir configures the backend to write an explanation bundle for
+// every match to a subdirectory of dir.
+func (b *ClassifierBackend) SetExplainDir(dir string) {
+	b.explainDir = dir
+}
+
+// ExplainFile classifies filename and returns the explanation of each match
+// found in it.
+func (b *ClassifierBackend) ExplainFile(filename string) ([]*classifier.Explanation, error) {
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %q: %v", filename, err)
+	}
+	var out []*classifier.Explanation
+	for _, m := range b.classifier.Match(contents) {
+		e, err := b.classifier.Explain(contents, m)
+		if err != nil {
+			return nil, fmt.Errorf("unable to explain %s in %q: %v", m.Name, filename, err)
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// writeExplanation writes the evidence for match m, the index'th match found
+// in filename, to its own directory under the explain directory. The bundle
+// holds the matched text, the canonical text it was compared to, the diff
+// between the two and the score breakdown with the rule decisions.
+func (b *ClassifierBackend) writeExplanation(filename string, contents []byte, index int, m *classifier.Match) error {
+	e, err := b.classifier.Explain(contents, m)
+	if err != nil {
+		return fmt.Errorf("unable to explain %s in %q: %v", m.Name, filename, err)
+	}
+
+	name := strings.Trim(strings.ReplaceAll(filepath.ToSlash(filename), "/", "_"), "._")
+	dir := filepath.Join(b.explainDir, fmt.Sprintf("%s.%d.%s", name, index, m.Name))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	var score strings.Builder
+	fmt.Fprintf(&score, "file: %s\n", filename)
+	fmt.Fprintf(&score, "license: %s\n", m.Name)
+	fmt.Fprintf(&score, "type: %s\n", m.MatchType)
+	fmt.Fprintf(&score, "variant: %s\n", e.Variant)
+	fmt.Fprintf(&score, "confidence: %v\n", m.Confidence)
+	fmt.Fprintf(&score, "lines: %d-%d\n", m.StartLine, m.EndLine)
+	fmt.Fprintf(&score, "known words: %d\n", e.KnownLength)
+	fmt.Fprintf(&score, "insertions: %d\n", e.Insertions)
+	fmt.Fprintf(&score, "deletions: %d\n", e.Deletions)
+	fmt.Fprintf(&score, "distance: %d\n", e.Distance)
+	for _, r := range e.Rules {
+		fmt.Fprintf(&score, "rule: %s\n", r)
+	}
+
+	files := map[string]string{
+		"matched.txt":   e.MatchedText,
+		"canonical.txt": e.KnownText,
+		"diff.txt":      formatDiffs(e.Diffs),
+		"diff.html":     "<pre>" + e.HTML() + "</pre>\n",
+		"score.txt":     score.String(),
+	}
+	for f, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, f), []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatDiffs renders diffs one per line, prefixed with + for text in the
+// canonical license that's missing from the match, - for text in the match
+// that isn't in the canonical license, and a space for common text.
+func formatDiffs(diffs []diffmatchpatch.Diff) string {
+	var out strings.Builder
+	for _, d := range diffs {
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			out.WriteString("+ ")
+		case diffmatchpatch.DiffDelete:
+			out.WriteString("- ")
+		default:
+			out.WriteString("  ")
+		}
+		out.WriteString(d.Text)
+		out.WriteString("\n")
+	}
+	return out.String()
+}
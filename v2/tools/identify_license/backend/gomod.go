@@ -0,0 +1,34 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backend
+
+import (
+	"github.com/google/licenseclassifier/v2/gomod"
+)
+
+// AuditModules classifies the licenses of the Go modules listed in a go.mod
+// or go.sum file, built into a Go binary, or extracted in a module cache
+// directory. Modules are located in the local module cache, or downloaded
+// from the supplied module proxy if it isn't empty.
+func (b *ClassifierBackend) AuditModules(name, proxy string) ([]*gomod.Report, error) {
+	mods, err := gomod.Load(name, gomod.CacheDir())
+	if err != nil {
+		return nil, err
+	}
+	if proxy != "" {
+		return gomod.AuditProxy(b.classifier, mods, proxy), nil
+	}
+	return gomod.Audit(b.classifier, mods), nil
+}
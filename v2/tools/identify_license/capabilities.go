@@ -0,0 +1,171 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	classifier "github.com/google/licenseclassifier/v2"
+	"github.com/google/licenseclassifier/v2/tools/identify_license/backend"
+)
+
+// subcommands are the subcommands of the program, in addition to the default
+// of classifying the named files.
+var subcommands = []string{"normalize", "diff", "compare", "deps", "capabilities", "completion"}
+
+// inputFormats are the values of -input-format.
+var inputFormats = []classifier.Format{
+	classifier.FormatPlain,
+	classifier.FormatAuto,
+	classifier.FormatMarkdown,
+	classifier.FormatHTML,
+}
+
+// commentModes are the values of -comments.
+var commentModes = []string{"all", "header"}
+
+// capabilities describes what the program supports, so that wrapper tools
+// don't have to make assumptions about the installed version.
+type capabilities struct {
+	Subcommands   []string        `json:"subcommands"`
+	InputFormats  []string        `json:"inputFormats"`
+	OutputFormats []string        `json:"outputFormats"`
+	CommentModes  []string        `json:"commentModes"`
+	CorpusVersion string          `json:"corpusVersion"`
+	Licenses      []licenseEntry  `json:"licenses"`
+	Options       []optionDefault `json:"options"`
+}
+
+// licenseEntry describes a license the program recognizes.
+type licenseEntry struct {
+	ID          string                  `json:"id"`
+	Name        string                  `json:"name"`
+	Category    string                  `json:"category"`
+	OSIApproved bool                    `json:"osiApproved"`
+	Deprecated  bool                    `json:"deprecated,omitempty"`
+	Exception   bool                    `json:"exception,omitempty"`
+	Obligations []classifier.Obligation `json:"obligations"`
+}
+
+// optionDefault describes a command line option and its default value.
+type optionDefault struct {
+	Name    string `json:"name"`
+	Default string `json:"default"`
+	Usage   string `json:"usage"`
+}
+
+// describe returns the capabilities of the program using the corpus of be.
+func describe(be *backend.ClassifierBackend) *capabilities {
+	c := &capabilities{
+		Subcommands:   subcommands,
+		OutputFormats: append(append([]string(nil), outputFormats...), "ansi", "html"),
+		CommentModes:  commentModes,
+		CorpusVersion: be.CorpusVersion(),
+	}
+	for _, f := range inputFormats {
+		c.InputFormats = append(c.InputFormats, f.String())
+	}
+	db := be.LicenseDB()
+	for _, id := range db.IDs() {
+		l, _ := db.Lookup(id)
+		c.Licenses = append(c.Licenses, licenseEntry{
+			ID:          l.ID,
+			Name:        l.Name,
+			Category:    l.Category,
+			OSIApproved: l.OSIApproved,
+			Deprecated:  l.Deprecated,
+			Exception:   l.Exception,
+			Obligations: l.Obligations,
+		})
+	}
+	flag.VisitAll(func(f *flag.Flag) {
+		c.Options = append(c.Options, optionDefault{Name: f.Name, Default: f.DefValue, Usage: f.Usage})
+	})
+	return c
+}
+
+// printCapabilities prints the capabilities of the program, as JSON if the
+// -json option of the subcommand is given.
+func printCapabilities(be *backend.ClassifierBackend, args []string) error {
+	fs := flag.NewFlagSet("capabilities", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the capabilities as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	c := describe(be)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(c)
+	}
+	fmt.Printf("subcommands: %s\n", strings.Join(c.Subcommands, ", "))
+	fmt.Printf("input formats: %s\n", strings.Join(c.InputFormats, ", "))
+	fmt.Printf("output formats: %s\n", strings.Join(c.OutputFormats, ", "))
+	fmt.Printf("comment modes: %s\n", strings.Join(c.CommentModes, ", "))
+	fmt.Printf("corpus version: %s (%d licenses)\n", c.CorpusVersion, len(c.Licenses))
+	fmt.Println("options:")
+	for _, o := range c.Options {
+		fmt.Printf("  -%s (default %q)\n", o.Name, o.Default)
+	}
+	return nil
+}
+
+// bashCompletion is the template of the bash completion script. It is
+// formatted with the program name, the subcommands, the options, the input
+// formats, the comment modes and the output formats.
+const bashCompletion = `# bash completion for %[1]s
+_%[1]s() {
+	local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}
+	case $prev in
+	-input-format|--input-format)
+		COMPREPLY=($(compgen -W "%[4]s" -- "$cur"))
+		return ;;
+	-comments|--comments)
+		COMPREPLY=($(compgen -W "%[5]s" -- "$cur"))
+		return ;;
+	-format|--format)
+		COMPREPLY=($(compgen -W "%[6]s" -- "$cur"))
+		return ;;
+	esac
+	case $cur in
+	-*) COMPREPLY=($(compgen -W "%[3]s" -- "$cur")) ;;
+	*) COMPREPLY=($(compgen -W "%[2]s" -- "$cur") $(compgen -f -- "$cur")) ;;
+	esac
+}
+complete -o filenames -F _%[1]s %[1]s
+`
+
+// completion prints the shell completion script for the named shell.
+func completion(args []string) error {
+	if len(args) != 1 || args[0] != "bash" {
+		return fmt.Errorf("completion: supported shells: bash")
+	}
+	var opts []string
+	flag.VisitAll(func(f *flag.Flag) { opts = append(opts, "-"+f.Name) })
+	sort.Strings(opts)
+	var formats []string
+	for _, f := range inputFormats {
+		formats = append(formats, f.String())
+	}
+	fmt.Printf(bashCompletion, filepath.Base(os.Args[0]), strings.Join(subcommands, " "), strings.Join(opts, " "),
+		strings.Join(formats, " "), strings.Join(commentModes, " "), strings.Join(outputFormats, " "))
+	return nil
+}
@@ -0,0 +1,647 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The identify_license program tries to identify the license type of an
+// unknown license using the v2 classifier. The file containing the license
+// text is specified on the command line. Multiple license files can be
+// analyzed with a single command. The type of the license is returned along
+// with the confidence level of the match and the lines it was found on. The
+// results are sorted by confidence level.
+//
+//	$ identify_license LICENSE1 LICENSE2
+//	LICENSE2: MIT (License, confidence: 0.987, lines: 1-21)
+//	LICENSE1: BSD-2-Clause (License, confidence: 0.833, lines: 3-24)
+//
+// Directories named on the command line are scanned recursively. With
+// -include and -exclude, the files classified are selected by glob patterns,
+// which follow the syntax of .gitignore files and can be repeated. The
+// directories named by -skip-dirs, which default to version control and
+// vendored code directories, are skipped, as are the files excluded by the
+// .gitignore and .licenseignore files found in the scanned directories.
+//
+//	$ identify_license -include 'LICENSE*' -include '*.go' -exclude testdata/ src
+//
+// Symbolic links, and junctions and other reparse points on Windows, are
+// skipped unless -follow-links is set. Either way, a file or directory
+// reached by several names, such as through a link cycle, or by a path
+// differing in case on a case-insensitive file system, is classified once.
+// On Windows, directories are scanned by the extended-length form of their
+// path, \\?\C:\..., so that files nested deeper than 260 characters can be
+// read.
+//
+// The text of PDF and RTF documents is extracted before they are classified.
+//
+// A file without a license of its own that points to a license file, as in
+// "See the LICENSE file in the project root", is reported with the licenses
+// of the file it points to, found from its directory up to the root of its
+// repository, and the lines of the pointer.
+//
+//	$ identify_license src/main.go
+//	src/main.go: MIT (License, confidence: 1, lines: 2-2, via /home/me/project/LICENSE)
+//
+// With -corpus, the license corpus is loaded from an archive rather than the
+// one embedded in the program, either a local file or one downloaded from an
+// http or https URL or a gs:// or s3:// bucket URL. With -corpus-sha256, the
+// archive must have the given checksum, which downloaded archives require,
+// and with -corpus-cache, downloaded archives are kept in a directory so they
+// are only fetched once.
+//
+//	$ identify_license -corpus gs://corpora/licenses.zip -corpus-sha256 9f86d0... LICENSE
+//
+// With -equivalences, a JSON file declares words and phrases to treat as
+// the same, such as regional spellings, and stopwords to ignore (see
+// classifier.ParseEquivalences).
+//
+// With -input-format, Markdown or HTML markup is stripped from each file before
+// it is classified; "auto" detects the markup of each file.
+//
+// With -comments, only the comments of source files are classified, so that
+// code can't disturb the detection of license headers. The language of a file
+// is determined by its extension, and line numbers still refer to the file.
+//
+// With -max-size, only the beginning of larger files is classified, where
+// license headers are found, and the files are reported with a warning that
+// they were truncated.
+//
+// With -strings, files are treated as binary blobs such as firmware images.
+// The runs of printable characters they contain are classified, and matches
+// are reported by their byte offsets in the file.
+//
+// With -policy, the licenses found are checked against a license policy
+// written as JSON (see the policy package). Restricted and forbidden licenses
+// are reported, and the program exits with status 1 if any license is
+// forbidden, which makes it suitable for gating continuous integration.
+//
+// With -fail-on, the program exits with status 1 if any of the listed
+// licenses is found. Licenses are listed by category, such as restricted or
+// forbidden, or as in a policy, by name, SPDX identifier, family or glob. The
+// listed licenses are added to the forbidden licenses of the -policy, if
+// there is one. With -min-confidence, matches below the given confidence are
+// still reported but aren't checked, so that weak matches don't fail a build.
+//
+//	$ identify_license -fail-on restricted,forbidden,AGPL-3.0 -min-confidence 0.9 .
+//
+// With -summary, only the aggregated results are printed: the number of files
+// each license was found in, the policy verdict and the number of files
+// scanned, skipped when the timeout expired and errored. Classification errors
+// no longer stop the scan, and the program exits with status 1 if any file
+// couldn't be classified or the policy check failed. With -output, the full
+// results, warnings and policy decisions are written to a file, so that
+// continuous integration logs stay short while the details are kept.
+//
+//	$ identify_license -summary -policy policy.json -output results.txt src/*
+//
+// With -format, the results are written as structured records holding the
+// file, license, SPDX identifier, match type, confidence and lines (or byte
+// offsets with -strings) of each match, for processing by other tools: json
+// writes a JSON array, ndjson a JSON record per line, and csv comma-separated
+// values with a header row. Messages are logged to standard error.
+//
+//	$ identify_license -format ndjson src | jq -r 'select(.confidence > 0.9) | .spdxId'
+//
+// With -top-k, the other licenses among the k best scoring in the region of
+// each match are printed below it, so that close calls can be reviewed.
+//
+//	$ identify_license -top-k 3 LICENSE
+//	LICENSE: MIT (License, confidence: 1, lines: 1-17)
+//	  also JSON (confidence: 0.9473684210526316)
+//	  also Xnet (confidence: 0.8617021276595744)
+//
+// With -overlap, the strategy deciding which of overlapping matches are
+// reported is chosen: by default, the most confident match is kept unless a
+// match containing it covers more of the text, as for a license embedding
+// another; highest-confidence and longest keep the most confident or the
+// longest match, and report-all reports every match with the licenses it
+// overlaps.
+//
+//	$ identify_license -overlap report-all LICENSE
+//
+// With -file-budget, no single file can hold up a scan for longer than about
+// the given time: once it runs out, the remaining matches of the file are
+// estimated from the words they share with the licenses rather than diffed,
+// and reported with an approximate confidence.
+//
+//	$ identify_license -file-budget 5s vendor
+//
+// With -explain-dir, the evidence behind each match is written to its own
+// directory: the matched text, the canonical license text, the diff between
+// them and the score breakdown including the decisions of the scoring rules.
+//
+// The normalize subcommand prints the text of a file as the classifier sees it
+// after normalization, which is useful when reporting or debugging a
+// mismatch. With -tokens, each token is printed on its own line prefixed by
+// the line of the input it came from.
+//
+//	$ identify_license normalize LICENSE
+//
+// The diff subcommand prints a report of the matches in the named files,
+// showing the text of each match below full confidence with the words that
+// differ from the license highlighted: text that isn't part of the license in
+// red, and license text missing from the file in green. When the output isn't
+// a terminal, they are bracketed with [- -] and {+ +} instead. With -html, the
+// report is written as an HTML page.
+//
+//	$ identify_license diff LICENSE
+//
+// The compare subcommand compares two scan reports, written with -format json
+// or ndjson or saved as a scan state, and prints the licenses added to and
+// removed from each file by the later scan, and those whose match type or
+// confidence changed. With -format json or ndjson, the changes are printed as
+// JSON.
+//
+//	$ identify_license compare before.json after.json
+//	added GPL-3.0 in vendor/foo/COPYING (License, confidence: 1)
+//
+// The deps subcommand reports the licenses of the dependencies of a Go
+// module, listed in a go.mod or go.sum file, built into a Go binary, or
+// extracted in a module cache directory. The license files of each module are
+// read from the local module cache or, with -proxy, downloaded from a module
+// proxy.
+//
+//	$ identify_license deps go.sum
+//	$ identify_license -proxy https://proxy.golang.org deps ./server
+//
+// The capabilities subcommand lists the subcommands, input and output
+// formats, corpus version, recognized licenses and option defaults of the
+// program, so that wrapper tools can inspect the installed version. With
+// -json, the listing is printed as JSON.
+//
+//	$ identify_license capabilities -json
+//
+// The completion subcommand prints a bash completion script.
+//
+//	$ source <(identify_license completion bash)
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	classifier "github.com/google/licenseclassifier/v2"
+	"github.com/google/licenseclassifier/v2/gomod"
+	"github.com/google/licenseclassifier/v2/policy"
+	"github.com/google/licenseclassifier/v2/tools/identify_license/backend"
+)
+
+var (
+	licenseDir    = flag.String("license-dir", "", "directory containing the license corpus (defaults to the embedded corpus)")
+	corpus        = flag.String("corpus", "", "license corpus to load rather than the embedded one: a zip, tar or gzipped tar archive, or the http, https, gs or s3 URL of one")
+	corpusSum     = flag.String("corpus-sha256", "", "hex-encoded SHA-256 checksum the -corpus archive must have, required for URLs")
+	corpusCache   = flag.String("corpus-cache", "", "directory to cache the downloaded -corpus archive in, when its checksum is given")
+	threshold     = flag.Float64("threshold", 0.8, "confidence threshold")
+	timeout       = flag.Duration("timeout", 24*time.Hour, "timeout before giving up on classifying a file.")
+	maxSize       = flag.Int64("max-size", 0, "classify only the first bytes of files larger than this, up to a line break, and report them as truncated; 0 classifies files whole")
+	fileBudget    = flag.Duration("file-budget", 0, "time to spend matching a single file before estimating the confidence of its remaining matches, which are reported as approximate (0 disables)")
+	tokens        = flag.Bool("tokens", false, "normalize: print one token per line, prefixed with its source line")
+	minStrings    = flag.Int("strings", 0, "treat files as binary blobs and classify runs of at least this many printable characters, reporting byte offsets (0 disables)")
+	format        = flag.String("input-format", "plain", "markup to strip from files before classifying them: plain, auto, markdown or html")
+	commentMode   = flag.String("comments", "", "classify only the comments of source files: all, or header for the comments before the first line of code")
+	htmlDiff      = flag.Bool("html", false, "diff: write the report as an HTML page rather than text")
+	explainDir    = flag.String("explain-dir", "", "directory to write an explanation bundle (matched text, canonical text, diff and score) for each match")
+	policyFile    = flag.String("policy", "", "JSON license policy to check the licenses found against; exits with status 1 if a license is forbidden")
+	failOn        = flag.String("fail-on", "", "comma-separated license categories, such as restricted or forbidden, and license names, SPDX identifiers, families or globs to forbid; exits with status 1 if any is found")
+	minConfidence = flag.Float64("min-confidence", 0, "minimum confidence of the matches checked by -policy and -fail-on; weaker matches are reported but don't fail the check")
+	aliasFile     = flag.String("aliases", "", "JSON file mapping license names to organization-specific aliases to report them with")
+	equivFile     = flag.String("equivalences", "", "JSON file declaring equivalence classes of interchangeable words and phrases, and stopwords, to normalize text with")
+	summary       = flag.Bool("summary", false, "print only the number of files each license was found in, the policy verdict and the number of files scanned, skipped and errored; exits with status 1 if the policy check fails or a file couldn't be classified")
+	topK          = flag.Int("top-k", 0, "also print the other licenses among the k best scoring in the region of each match")
+	overlap       = flag.String("overlap", "token-density", "strategy deciding which of overlapping matches are reported: token-density, highest-confidence, longest or report-all, which prints the licenses each match overlaps")
+	outputFile    = flag.String("output", "", "file to write the results to rather than standard output")
+	outFormat     = flag.String("format", "text", "format of the results: text, json for a JSON array of records, ndjson for a JSON record per line, or csv")
+	coverage      = flag.Bool("coverage", false, "also print the fraction of the text of each file attributed to the licenses found in it")
+	unknowns      = flag.Bool("unknowns", false, "also print the regions of the files that read like licenses but match none, grouping the copies of each unknown license")
+	proxy         = flag.String("proxy", "", "deps: module proxy to download modules from, such as "+gomod.DefaultProxy+", rather than the local module cache")
+)
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %[1]s <licensefile|directory> ...
+       %[1]s normalize <file>
+       %[1]s diff <file>
+       %[1]s compare <before> <after>
+       %[1]s deps <go.mod|go.sum|binary|module cache>
+       %[1]s capabilities [-json]
+       %[1]s completion bash
+
+Identify an unknown license, print the normalized text of a file, show how
+the licenses in a file differ from the known license texts, compare two scan
+reports, report the licenses of the dependencies of a Go module, describe the
+capabilities of the program or print a shell completion script.
+
+Options:
+`, filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	if flag.NArg() > 0 && flag.Arg(0) == "normalize" {
+		if err := normalize(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if flag.NArg() > 0 && flag.Arg(0) == "compare" {
+		if err := compare(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if flag.NArg() > 0 && flag.Arg(0) == "completion" {
+		if err := completion(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	f, ok := classifier.ParseFormat(*format)
+	if !ok {
+		log.Fatalf("unknown input format %q", *format)
+	}
+	if !validOutputFormat(*outFormat) {
+		log.Fatalf("unknown output format %q", *outFormat)
+	}
+	if *outFormat != "text" && (*coverage || *unknowns) {
+		log.Fatalf("-coverage and -unknowns are only supported with -format text")
+	}
+
+	var opts []classifier.Option
+	if *equivFile != "" {
+		b, err := ioutil.ReadFile(*equivFile)
+		if err != nil {
+			log.Fatalf("cannot read equivalences: %v", err)
+		}
+		eq, err := classifier.ParseEquivalences(b)
+		if err != nil {
+			log.Fatalf("%s: %v", *equivFile, err)
+		}
+		opts = append(opts, classifier.WithEquivalences(eq))
+	}
+	var src classifier.CorpusSource
+	switch {
+	case *licenseDir != "" && *corpus != "":
+		log.Fatalf("-license-dir and -corpus are exclusive")
+	case *licenseDir != "":
+		src = classifier.DirCorpus(*licenseDir)
+	case *corpus != "":
+		src = classifier.ParseCorpusSource(*corpus, *corpusSum, *corpusCache)
+	}
+	be, err := backend.New(*threshold, src, opts...)
+	if err != nil {
+		log.Fatalf("cannot create license classifier: %v", err)
+	}
+	be.SetInputFormat(f)
+	be.SetCommentMode(*commentMode)
+	if *aliasFile != "" {
+		b, err := ioutil.ReadFile(*aliasFile)
+		if err != nil {
+			log.Fatalf("cannot read aliases: %v", err)
+		}
+		aliases, err := classifier.ParseAliases(b)
+		if err != nil {
+			log.Fatalf("%s: %v", *aliasFile, err)
+		}
+		be.SetAliases(aliases)
+	}
+
+	if flag.NArg() > 0 && flag.Arg(0) == "diff" {
+		if err := diff(be, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if flag.NArg() > 0 && flag.Arg(0) == "capabilities" {
+		if err := printCapabilities(be, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if flag.NArg() > 0 && flag.Arg(0) == "deps" {
+		if err := deps(be, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	be.SetExplainDir(*explainDir)
+	be.SetBlobMode(*minStrings)
+	be.SetTopK(*topK)
+	strategy, err := classifier.ParseOverlapStrategy(*overlap)
+	if err != nil {
+		log.Fatal(err)
+	}
+	be.SetOverlapStrategy(strategy)
+	be.SetTimeBudget(*fileBudget)
+	be.SetUnknowns(*unknowns)
+	be.SetMaxSize(*maxSize)
+
+	var pol *policy.Policy
+	if *policyFile != "" {
+		b, err := ioutil.ReadFile(*policyFile)
+		if err != nil {
+			log.Fatalf("cannot read policy: %v", err)
+		}
+		if pol, err = policy.Parse(b); err != nil {
+			log.Fatalf("%s: %v", *policyFile, err)
+		}
+	}
+	if pol, err = addFailOn(pol, *failOn); err != nil {
+		log.Fatalf("-fail-on: %v", err)
+	}
+
+	// The results are written to the -output file if there is one, or else
+	// to standard output unless only the summary is printed.
+	var out io.Writer = os.Stdout
+	if *summary {
+		out = ioutil.Discard
+	}
+	var outFile *os.File
+	if *outputFile != "" {
+		if outFile, err = os.Create(*outputFile); err != nil {
+			log.Fatalf("cannot create output: %v", err)
+		}
+		out = outFile
+	}
+	files, err := expandArgs(flag.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = classify(be, pol, files, out)
+	be.Close()
+	if outFile != nil {
+		if cerr := outFile.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("cannot write output: %v", cerr)
+		}
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// classify classifies the named files and writes the results to out, and the
+// summary to standard output with -summary. It returns an error if the scan
+// failed or the policy check didn't pass.
+func classify(be *backend.ClassifierBackend, pol *policy.Policy, filenames []string, out io.Writer) error {
+	// In summary mode, per-file messages would drown the summary in the
+	// logs, so they are written along with the results instead.
+	// Structured results can't be interleaved with messages, so the messages
+	// are always logged with them.
+	logf := log.Printf
+	if *summary {
+		be.SetQuiet(true)
+		if *outFormat == "text" {
+			logf = func(format string, args ...interface{}) {
+				fmt.Fprintf(out, format+"\n", args...)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	errs := be.ClassifyLicensesWithContext(ctx, filenames)
+	errored := 0
+	for _, err := range errs {
+		log.Printf("classify license failed: %v", err)
+		if err != ctx.Err() {
+			errored++
+		}
+	}
+	if errs != nil && !*summary {
+		return fmt.Errorf("cannot classify licenses")
+	}
+
+	files := be.GetFileResults()
+	sort.Slice(files, func(i, j int) bool { return files[i].Filename < files[j].Filename })
+	for _, f := range files {
+		for _, w := range f.Warnings {
+			logf("warning: %s: %s (%s)", f.Filename, w.Message, w.Kind)
+		}
+	}
+
+	results := be.GetResults()
+	if len(results) == 0 && !*summary {
+		return fmt.Errorf("couldn't classify license(s)")
+	}
+
+	sort.Sort(results)
+	if err := writeResults(out, *outFormat, results, *minStrings > 0); err != nil {
+		return fmt.Errorf("cannot write results: %v", err)
+	}
+	if *coverage && *minStrings == 0 {
+		for _, f := range files {
+			fmt.Fprintf(out, "%s: coverage %.1f%%\n", f.Filename, 100*f.Coverage)
+		}
+	}
+	if *unknowns {
+		printUnknowns(out, files)
+	}
+
+	var report *policy.Report
+	if pol != nil {
+		report = &policy.Report{}
+		for _, r := range results {
+			if r.Confidence < *minConfidence {
+				continue
+			}
+			report.Decisions = append(report.Decisions, pol.Decide(filepath.ToSlash(r.Filename), r.Name))
+		}
+		for _, d := range report.Decisions {
+			if d.Action != policy.Allow {
+				logf("policy: %v", d)
+			}
+		}
+	}
+	if *summary {
+		printSummary(os.Stdout, files, len(filenames), errored, report)
+	}
+	switch {
+	case report != nil && !report.Pass():
+		return fmt.Errorf("policy check failed")
+	case errs != nil:
+		return fmt.Errorf("cannot classify licenses")
+	}
+	return nil
+}
+
+// licenseCategories are the license categories -fail-on accepts by name.
+var licenseCategories = map[string]bool{
+	classifier.CategoryRestricted:           true,
+	classifier.CategoryReciprocal:           true,
+	classifier.CategoryNotice:               true,
+	classifier.CategoryPermissive:           true,
+	classifier.CategoryUnencumbered:         true,
+	classifier.CategoryPublicDomain:         true,
+	classifier.CategoryByExceptionOnly:      true,
+	classifier.CategoryForbidden:            true,
+	classifier.CategoryProprietary:          true,
+	classifier.CategoryContributorAgreement: true,
+}
+
+// addFailOn adds the licenses listed by -fail-on to the forbidden licenses of
+// the policy. Without a policy, it returns one that allows every other
+// license, or nil if no license is listed.
+func addFailOn(pol *policy.Policy, list string) (*policy.Policy, error) {
+	names := splitList(list)
+	if len(names) == 0 {
+		return pol, nil
+	}
+	if pol == nil {
+		pol = &policy.Policy{Unlisted: policy.Allow}
+	}
+	for _, n := range names {
+		if licenseCategories[n] {
+			n = "category:" + n
+		}
+		pol.Forbidden = append(pol.Forbidden, n)
+	}
+	return pol, pol.Validate()
+}
+
+// label returns the text naming a license in the output: its display name,
+// followed by its canonical name if the license has an alias.
+func label(name, display string) string {
+	if display == name {
+		return name
+	}
+	return fmt.Sprintf("%s [%s]", display, name)
+}
+
+// normalize prints the normalized form of each named file.
+func normalize(filenames []string) error {
+	if len(filenames) == 0 {
+		return fmt.Errorf("normalize: no files specified")
+	}
+	for _, f := range filenames {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return fmt.Errorf("unable to read %q: %v", f, err)
+		}
+		norm := classifier.Normalize(b)
+		if !*tokens {
+			fmt.Print(norm)
+			continue
+		}
+		for i, l := range strings.Split(norm, "\n") {
+			for _, t := range strings.Fields(l) {
+				fmt.Printf("%d\t%s\n", i+1, t)
+			}
+		}
+	}
+	return nil
+}
+
+// diff prints a report of the diffs of the matches in the named files.
+func diff(be *backend.ClassifierBackend, filenames []string) error {
+	if len(filenames) == 0 {
+		return fmt.Errorf("diff: no files specified")
+	}
+	r := &classifier.DiffReport{Title: "License diffs of " + strings.Join(filenames, ", ")}
+	for _, f := range filenames {
+		explanations, err := be.ExplainFile(f)
+		if err != nil {
+			return err
+		}
+		for _, e := range explanations {
+			r.Add(f, e)
+		}
+	}
+	if *htmlDiff {
+		return r.WriteHTML(os.Stdout)
+	}
+	return r.WriteText(os.Stdout, isTerminal(os.Stdout))
+}
+
+// compare prints the changes of the findings between two scan reports.
+func compare(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("compare: expected the reports of two scans, got %d files", len(args))
+	}
+	var reports [2][]classifier.Finding
+	for i, name := range args {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		reports[i], err = classifier.ReadFindings(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("compare: %s: %v", name, err)
+		}
+	}
+	changes := classifier.DiffFindings(reports[0], reports[1])
+	switch *outFormat {
+	case "text":
+		for _, c := range changes {
+			fmt.Println(c)
+		}
+		return nil
+	case "json":
+		if changes == nil {
+			changes = []classifier.FindingChange{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(changes)
+	case "ndjson":
+		enc := json.NewEncoder(os.Stdout)
+		for _, c := range changes {
+			if err := enc.Encode(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("compare: unsupported output format %q", *outFormat)
+}
+
+// isTerminal reports whether f is a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// deps prints the licenses of the dependencies of each named Go module.
+func deps(be *backend.ClassifierBackend, names []string) error {
+	if len(names) == 0 {
+		return fmt.Errorf("deps: no files specified")
+	}
+	for _, n := range names {
+		reports, err := be.AuditModules(n, *proxy)
+		if err != nil {
+			return err
+		}
+		for _, r := range reports {
+			switch {
+			case r.Err != nil:
+				log.Printf("warning: %s: %v", r.Module, r.Err)
+			case r.License == "":
+				fmt.Printf("%s: no license found\n", r.Module)
+			default:
+				fmt.Printf("%s: %s (confidence: %v)\n", r.Module, r.License, r.Confidence)
+			}
+		}
+	}
+	return nil
+}
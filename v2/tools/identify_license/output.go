@@ -0,0 +1,194 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/google/licenseclassifier/v2/tools/identify_license/results"
+)
+
+// outputFormats are the values of -format.
+var outputFormats = []string{"text", "json", "ndjson", "csv"}
+
+func validOutputFormat(f string) bool {
+	for _, o := range outputFormats {
+		if o == f {
+			return true
+		}
+	}
+	return false
+}
+
+// record is a result in the structured output formats. The lines of a match
+// are omitted for files classified as binary blobs, and its byte offsets for
+// the other files.
+type record struct {
+	File        string  `json:"file"`
+	ID          string  `json:"id"`
+	License     string  `json:"license"`
+	SPDXID      string  `json:"spdxId"`
+	Language    string  `json:"language,omitempty"`
+	Alias       string  `json:"alias,omitempty"`
+	MatchType   string  `json:"matchType"`
+	Confidence  float64 `json:"confidence"`
+	StartLine   int     `json:"startLine,omitempty"`
+	EndLine     int     `json:"endLine,omitempty"`
+	StartOffset *int    `json:"startOffset,omitempty"`
+	EndOffset   *int    `json:"endOffset,omitempty"`
+	// Alternatives are reported with -top-k. They are omitted from the csv
+	// format, as are Approximate, Overlaps, LicenseFile and the conditions.
+	Alternatives []results.Alternative `json:"alternatives,omitempty"`
+	Approximate  bool                  `json:"approximate,omitempty"`
+	Overlaps     []string              `json:"overlaps,omitempty"`
+	LicenseFile  string                `json:"licenseFile,omitempty"`
+
+	TrademarkRestriction bool    `json:"trademarkRestriction,omitempty"`
+	BinaryAttribution    bool    `json:"binaryAttribution,omitempty"`
+	RecommendedThreshold float64 `json:"recommendedThreshold,omitempty"`
+}
+
+func newRecord(r *results.LicenseType, blob bool) *record {
+	rec := &record{
+		File:         r.Filename,
+		ID:           r.ID,
+		License:      r.Name,
+		SPDXID:       r.SPDXID,
+		Language:     r.Language,
+		MatchType:    r.MatchType,
+		Confidence:   r.Confidence,
+		Alternatives: r.Alternatives,
+		Approximate:  r.Approximate,
+		Overlaps:     r.Overlaps,
+		LicenseFile:  r.LicenseFile,
+
+		TrademarkRestriction: r.TrademarkRestriction,
+		BinaryAttribution:    r.BinaryAttribution,
+		RecommendedThreshold: r.RecommendedThreshold,
+	}
+	if d := r.DisplayName(); d != r.Name {
+		rec.Alias = d
+	}
+	if blob {
+		start, end := r.StartOffset, r.EndOffset
+		rec.StartOffset, rec.EndOffset = &start, &end
+	} else {
+		rec.StartLine, rec.EndLine = r.StartLine, r.EndLine
+	}
+	return rec
+}
+
+// csvHeader names the columns of the csv format.
+var csvHeader = []string{"file", "id", "license", "spdx_id", "alias", "match_type", "confidence", "start_line", "end_line", "start_offset", "end_offset"}
+
+func (rec *record) csv() []string {
+	num := func(n int) string {
+		if n == 0 {
+			return ""
+		}
+		return strconv.Itoa(n)
+	}
+	offset := func(n *int) string {
+		if n == nil {
+			return ""
+		}
+		return strconv.Itoa(*n)
+	}
+	return []string{rec.File, rec.ID, rec.License, rec.SPDXID, rec.Alias, rec.MatchType,
+		strconv.FormatFloat(rec.Confidence, 'g', -1, 64), num(rec.StartLine), num(rec.EndLine),
+		offset(rec.StartOffset), offset(rec.EndOffset)}
+}
+
+// writeResults writes the results in the named format: text for people to
+// read, a JSON array of records, one JSON record per line with ndjson, or
+// comma-separated values with a header row.
+func writeResults(out io.Writer, format string, rs results.LicenseTypes, blob bool) error {
+	switch format {
+	case "text":
+		for _, r := range rs {
+			conf := "confidence"
+			if r.Approximate {
+				conf = "approximate confidence"
+			}
+			if blob {
+				fmt.Fprintf(out, "%s: %s (%s, %s: %v, offsets: %d-%d)\n",
+					r.Filename, label(r.Name, r.DisplayName()), r.MatchType, conf, r.Confidence, r.StartOffset, r.EndOffset)
+				continue
+			}
+			via := ""
+			if r.LicenseFile != "" {
+				via = ", via " + r.LicenseFile
+			}
+			fmt.Fprintf(out, "%s: %s (%s, %s: %v, lines: %d-%d%s)\n",
+				r.Filename, label(r.Name, r.DisplayName()), r.MatchType, conf, r.Confidence, r.StartLine, r.EndLine, via)
+			for _, a := range r.Alternatives {
+				fmt.Fprintf(out, "  also %s (confidence: %v)\n", a.Name, a.Confidence)
+			}
+			if len(r.Overlaps) > 0 {
+				fmt.Fprintf(out, "  overlaps %s\n", strings.Join(r.Overlaps, ", "))
+			}
+			if c := conditions(r); len(c) > 0 {
+				fmt.Fprintf(out, "  %s\n", strings.Join(c, ", "))
+			}
+		}
+		return nil
+	case "json":
+		recs := []*record{}
+		for _, r := range rs {
+			recs = append(recs, newRecord(r, blob))
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(recs)
+	case "ndjson":
+		enc := json.NewEncoder(out)
+		for _, r := range rs {
+			if err := enc.Encode(newRecord(r, blob)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		w := csv.NewWriter(out)
+		w.Write(csvHeader)
+		for _, r := range rs {
+			w.Write(newRecord(r, blob).csv())
+		}
+		w.Flush()
+		return w.Error()
+	}
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+// conditions describes the conditions flagged on a result, and a confidence
+// below the one recommended for its corpus entry, for the text format.
+func conditions(r *results.LicenseType) []string {
+	var c []string
+	if r.BinaryAttribution {
+		c = append(c, "requires attribution in binary distributions")
+	}
+	if r.TrademarkRestriction {
+		c = append(c, "restricts the use of names and trademarks")
+	}
+	if r.Confidence < r.RecommendedThreshold {
+		c = append(c, fmt.Sprintf("below the recommended confidence of %v", r.RecommendedThreshold))
+	}
+	return c
+}
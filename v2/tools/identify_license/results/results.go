@@ -0,0 +1,175 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package results contains the result type returned by the classifier backend.
+// Placing the type into a separate module allows us to swap out backends and
+// still use the same datatype.
+package results
+
+// LicenseType is the assumed type of the unknown license.
+type LicenseType struct {
+	Filename string
+	Name     string
+	// ID identifies the match among those of the file, and is the same in
+	// every scan of the file. See classifier.Match.ID.
+	ID string
+	// SPDXID is the SPDX identifier of the license, as resolved by
+	// classifier.Match.SPDXID.
+	SPDXID string
+	// Language is the ISO 639-1 code of the language of the matched text if
+	// it matched a translation of the license.
+	Language   string
+	MatchType  string
+	Confidence float64
+	StartLine  int
+	EndLine    int
+	// StartOffset and EndOffset are the byte offsets of the match when the
+	// file was classified as a binary blob.
+	StartOffset int
+	EndOffset   int
+	// AliasID and AliasName are the organization-specific alias of the
+	// license, if any. Name remains the canonical name.
+	AliasID   string
+	AliasName string
+	// Alternatives are the other licenses that matched the same region with
+	// a lower confidence, best first.
+	Alternatives []Alternative
+	// Approximate is true if the confidence is an estimate, because the time
+	// budget of the file ran out. See classifier.Match.Approximate.
+	Approximate bool
+	// Overlaps are the other licenses whose matches overlap this one, when
+	// the backend reports overlapping matches.
+	Overlaps []string
+	// TrademarkRestriction and BinaryAttribution are the conditions the
+	// matched text imposes. See classifier.Match.TrademarkRestriction.
+	TrademarkRestriction, BinaryAttribution bool
+	// RecommendedThreshold is the lowest confidence at which matches of the
+	// corpus entry are recommended to be trusted, if its metadata declares
+	// one.
+	RecommendedThreshold float64
+	// LicenseFile is the license file the match was found in, if the file
+	// has no license of its own but points to the license file, as in "See
+	// the LICENSE file". The lines are then those of the pointer.
+	LicenseFile string
+}
+
+// Alternative is a license that matched the region of a result with a lower
+// confidence.
+type Alternative struct {
+	Name       string  `json:"name"`
+	Confidence float64 `json:"confidence"`
+}
+
+// DisplayName returns the name the license should be presented with: the
+// name of its alias, or else its alias ID, or else its canonical name.
+func (lt *LicenseType) DisplayName() string {
+	switch {
+	case lt.AliasName != "":
+		return lt.AliasName
+	case lt.AliasID != "":
+		return lt.AliasID
+	}
+	return lt.Name
+}
+
+// LicenseTypes is a list of LicenseType objects.
+type LicenseTypes []*LicenseType
+
+func (lt LicenseTypes) Len() int      { return len(lt) }
+func (lt LicenseTypes) Swap(i, j int) { lt[i], lt[j] = lt[j], lt[i] }
+func (lt LicenseTypes) Less(i, j int) bool {
+	if lt[i].Confidence > lt[j].Confidence {
+		return true
+	}
+	if lt[i].Confidence < lt[j].Confidence {
+		return false
+	}
+	if lt[i].Filename != lt[j].Filename {
+		return lt[i].Filename < lt[j].Filename
+	}
+	// Results of the same confidence in a file are ordered by position, then
+	// by name, so the order is the same in every scan.
+	if lt[i].StartLine != lt[j].StartLine {
+		return lt[i].StartLine < lt[j].StartLine
+	}
+	if lt[i].StartOffset != lt[j].StartOffset {
+		return lt[i].StartOffset < lt[j].StartOffset
+	}
+	if lt[i].EndLine != lt[j].EndLine {
+		return lt[i].EndLine > lt[j].EndLine
+	}
+	if lt[i].Name != lt[j].Name {
+		return lt[i].Name < lt[j].Name
+	}
+	return lt[i].ID < lt[j].ID
+}
+
+// WarningKind identifies the kind of issue a Warning reports.
+type WarningKind string
+
+// Kinds of warnings.
+const (
+	// WarningDegraded is reported when a file was classified in a mode that
+	// is likely to give poor results, such as binary content classified as
+	// text.
+	WarningDegraded WarningKind = "degraded"
+	// WarningExtractorFallback is reported when the text of a file couldn't
+	// be extracted in its native format and a fallback was used.
+	WarningExtractorFallback WarningKind = "extractor-fallback"
+	// WarningTruncated is reported when only part of a file was classified.
+	WarningTruncated WarningKind = "truncated"
+	// WarningStale is reported when a file kept changing while it was read,
+	// so the lines of its results may not correspond to its final content.
+	WarningStale WarningKind = "stale"
+	// WarningDeprecatedLicense is reported for matches of licenses whose
+	// SPDX identifier is deprecated.
+	WarningDeprecatedLicense WarningKind = "deprecated-license"
+	// WarningDanglingPointer is reported when a file points to a license
+	// file that couldn't be found.
+	WarningDanglingPointer WarningKind = "dangling-pointer"
+)
+
+// Warning is an issue found while classifying a file that doesn't prevent
+// its results from being reported. Unlike errors, warnings don't fail a scan.
+type Warning struct {
+	Kind    WarningKind
+	Message string
+}
+
+// FileResult holds the licenses found in a single file along with any
+// warnings raised while classifying it.
+type FileResult struct {
+	Filename string
+	Licenses LicenseTypes
+	Warnings []*Warning
+	// Stale is true if the file kept changing while it was read.
+	Stale bool
+	// Coverage is the fraction of the tokens of the file attributed to
+	// license matches. It isn't computed for files classified as binary
+	// blobs.
+	Coverage float64
+	// Unknowns are the regions of the file that read like licenses but
+	// didn't match any, if the backend looks for them.
+	Unknowns []*Unknown
+}
+
+// Unknown is a region of a file that reads like a license missing from the
+// corpus.
+type Unknown struct {
+	StartLine int
+	EndLine   int
+	// Fingerprint is the simhash of the text of the region, which is close
+	// to those of the other copies of the license.
+	Fingerprint uint64
+}
@@ -0,0 +1,96 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	classifier "github.com/google/licenseclassifier/v2"
+	"github.com/google/licenseclassifier/v2/policy"
+	"github.com/google/licenseclassifier/v2/tools/identify_license/results"
+)
+
+// printSummary prints the aggregated results of a scan of n files for
+// -summary: the number of files each license was found in, the verdict of
+// the policy if one was checked and the number of files scanned, skipped
+// because the scan timed out before reaching them, and errored.
+func printSummary(w io.Writer, files []*results.FileResult, n, errored int, report *policy.Report) {
+	counts := make(map[string]int)
+	for _, f := range files {
+		seen := make(map[string]bool)
+		for _, l := range f.Licenses {
+			name := label(l.Name, l.DisplayName())
+			if !seen[name] {
+				seen[name] = true
+				counts[name]++
+			}
+		}
+	}
+	var names []string
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	fmt.Fprintln(w, "licenses (files):")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s: %d\n", name, counts[name])
+	}
+
+	if report != nil {
+		verdict := "pass"
+		if !report.Pass() {
+			verdict = "fail"
+		}
+		fmt.Fprintf(w, "policy: %s (%d forbidden, %d restricted)\n", verdict,
+			len(report.Filter(policy.Forbid)), len(report.Filter(policy.Restrict)))
+	}
+
+	skipped := n - len(files) - errored
+	if skipped < 0 {
+		skipped = 0
+	}
+	fmt.Fprintf(w, "files: %d scanned, %d skipped, %d errored\n", len(files), skipped, errored)
+}
+
+// printUnknowns writes the regions of the files that read like licenses but
+// match none, grouping the copies of each unknown license so that it can be
+// reviewed once.
+func printUnknowns(w io.Writer, files []*results.FileResult) {
+	type region struct {
+		filename string
+		u        *results.Unknown
+	}
+	var regions []region
+	var fps []classifier.Fingerprint
+	for _, f := range files {
+		for _, u := range f.Unknowns {
+			regions = append(regions, region{f.Filename, u})
+			fps = append(fps, classifier.Fingerprint(u.Fingerprint))
+		}
+	}
+	for i, group := range classifier.Cluster(fps, classifier.DefaultClusterDistance) {
+		fmt.Fprintf(w, "unknown license %d (%d regions):\n", i+1, len(group))
+		for _, j := range group {
+			fmt.Fprintf(w, "  %s: lines %d-%d\n", regions[j].filename, regions[j].u.StartLine, regions[j].u.EndLine)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+var (
+	includes    patternList
+	excludes    patternList
+	skipDirs    = flag.String("skip-dirs", ".git,.hg,.svn,node_modules,vendor,third_party,bower_components,__pycache__", "comma-separated names of directories not scanned when classifying directories, such as those holding vendored code")
+	ignoreFiles = flag.String("ignore-files", ".gitignore,.licenseignore", "comma-separated names of gitignore-style files whose patterns exclude files and directories from directory scans")
+	followLinks = flag.Bool("follow-links", false, "follow symbolic links, and junctions and other reparse points on Windows, when scanning directories; a directory reached twice, as through a link cycle, is scanned once")
+)
+
+func init() {
+	flag.Var(&includes, "include", "glob pattern of the files to classify when scanning directories, matched against the base name or, if it contains a slash, the path from the directory; can be repeated (default all files)")
+	flag.Var(&excludes, "exclude", "glob pattern, as for -include, of the files and directories to skip when scanning directories; can be repeated")
+}
+
+// pattern is a glob pattern with the syntax of gitignore files: * and ?
+// don't match slashes, ** matches any number of directories, a pattern
+// without a slash other than a trailing one matches the base name at any
+// depth, and a trailing slash only matches directories.
+type pattern struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// compilePattern compiles a glob pattern. A leading ! negates the pattern,
+// which only has a meaning in ignore files.
+func compilePattern(p string) (*pattern, error) {
+	pat := &pattern{}
+	if strings.HasPrefix(p, "!") {
+		pat.negate, p = true, p[1:]
+	}
+	if strings.HasSuffix(p, "/") {
+		pat.dirOnly, p = true, strings.TrimRight(p, "/")
+	}
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+	if p == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+
+	var re strings.Builder
+	re.WriteString("^")
+	if !anchored {
+		re.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; {
+		case strings.HasPrefix(p[i:], "**/"):
+			re.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			re.WriteString(".*")
+			i++
+		case c == '*':
+			re.WriteString("[^/]*")
+		case c == '?':
+			re.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(p[i+1:], ']')
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated character class in %q", p)
+			}
+			class := p[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			re.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(p):
+			i++
+			re.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		default:
+			re.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		}
+	}
+	re.WriteString("$")
+	var err error
+	if pat.re, err = regexp.Compile(re.String()); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %v", p, err)
+	}
+	return pat, nil
+}
+
+// match reports whether the pattern matches the slash-separated path, which
+// is relative to the directory the pattern applies to.
+func (p *pattern) match(rel string, isDir bool) bool {
+	return (isDir || !p.dirOnly) && p.re.MatchString(rel)
+}
+
+// patternList is a command line option holding glob patterns, which can be
+// repeated.
+type patternList []*pattern
+
+func (l *patternList) String() string {
+	var s []string
+	for _, p := range *l {
+		s = append(s, p.re.String())
+	}
+	return strings.Join(s, ",")
+}
+
+func (l *patternList) Set(v string) error {
+	p, err := compilePattern(v)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, p)
+	return nil
+}
+
+// matchAny reports whether any of the patterns match the path.
+func (l patternList) matchAny(rel string, isDir bool) bool {
+	for _, p := range l {
+		if p.match(rel, isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoreRules are the patterns of an ignore file, which apply to the paths
+// below its directory.
+type ignoreRules struct {
+	dir      string // slash-separated, relative to the scanned directory
+	patterns []*pattern
+}
+
+// readIgnoreFile reads the patterns of a gitignore-style file. It returns
+// nil if the file doesn't exist.
+func readIgnoreFile(name string) ([]*pattern, error) {
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var patterns []*pattern
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimRight(s.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p, err := compilePattern(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", name, n, err)
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, s.Err()
+}
+
+// ignored reports whether the path is excluded by the ignore files of the
+// directories above it. As in git, the last matching pattern decides, and the
+// patterns of deeper directories take precedence.
+func ignored(rules []ignoreRules, rel string, isDir bool) bool {
+	ignore := false
+	for _, r := range rules {
+		sub := rel
+		if r.dir != "." {
+			if !strings.HasPrefix(rel, r.dir+"/") {
+				continue
+			}
+			sub = rel[len(r.dir)+1:]
+		}
+		for _, p := range r.patterns {
+			if p.match(sub, isDir) {
+				ignore = !p.negate
+			}
+		}
+	}
+	return ignore
+}
+
+// splitList splits a comma-separated option into its non-empty values.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// expandArgs returns the files named on the command line, replacing each
+// directory by the files found by scanning it recursively. The files of a
+// directory are selected by -include and -exclude, and the directories named
+// by -skip-dirs and the files and directories excluded by the ignore files
+// named by -ignore-files are skipped. Files named directly are always
+// classified. A file or directory reached twice by another name, such as one
+// differing in case on a case-insensitive file system, or through a link, is
+// only classified once.
+func expandArgs(args []string) ([]string, error) {
+	skip := make(map[string]bool)
+	for _, d := range splitList(*skipDirs) {
+		skip[d] = true
+	}
+	w := &walker{skip: skip, ignoreNames: splitList(*ignoreFiles), follow: *followLinks, seen: make(map[fileID]bool)}
+	for _, arg := range args {
+		fi, err := os.Stat(arg)
+		if err != nil || !fi.IsDir() {
+			// Errors are reported when the file is classified.
+			if err == nil && !w.first(arg) {
+				continue
+			}
+			w.files = append(w.files, arg)
+			continue
+		}
+		if err := w.scan(arg); err != nil {
+			return nil, err
+		}
+	}
+	return w.files, nil
+}
+
+// walker scans directories for the files to classify.
+type walker struct {
+	skip        map[string]bool
+	ignoreNames []string
+	// follow is set if symbolic links, and junctions and other reparse
+	// points on Windows, are followed.
+	follow bool
+	// seen holds the directories scanned and the files found, so that they
+	// are only classified once.
+	seen  map[fileID]bool
+	files []string
+}
+
+// first reports whether the file or directory at name hasn't been seen
+// before, and records it. Files that can't be identified are always new.
+func (w *walker) first(name string) bool {
+	id, ok := fileKey(name)
+	if !ok {
+		return true
+	}
+	if w.seen[id] {
+		return false
+	}
+	w.seen[id] = true
+	return true
+}
+
+// scan adds the files to classify in the directory root, in lexical order.
+// On Windows, the directory is walked by the extended-length form of its
+// path, so that the files below it can be read however deep they are.
+func (w *walker) scan(root string) error {
+	walked := root
+	if runtime.GOOS == "windows" {
+		if abs, err := filepath.Abs(root); err == nil {
+			walked = longPath(abs)
+		}
+	}
+	if !w.first(walked) {
+		return nil
+	}
+	if err := w.walk(root, walked, ".", nil); err != nil {
+		return fmt.Errorf("cannot scan %s: %v", root, err)
+	}
+	return nil
+}
+
+// maxPath is the length of the longest path Windows opens without the
+// extended-length form.
+const maxPath = 259
+
+// walk adds the files to classify in the directory at rel below the scanned
+// directory, named dir as the directory was named and walked as it is
+// walked.
+func (w *walker) walk(dir, walked, rel string, rules []ignoreRules) error {
+	for _, n := range w.ignoreNames {
+		patterns, err := readIgnoreFile(filepath.Join(walked, n))
+		if err != nil {
+			return err
+		}
+		if len(patterns) > 0 {
+			// The rules of a directory only apply below it.
+			rules = append(rules[:len(rules):len(rules)], ignoreRules{dir: rel, patterns: patterns})
+		}
+	}
+	entries, err := os.ReadDir(walked)
+	if err != nil {
+		return err
+	}
+	for _, d := range entries {
+		name, path := filepath.Join(dir, d.Name()), filepath.Join(walked, d.Name())
+		sub := d.Name()
+		if rel != "." {
+			sub = rel + "/" + d.Name()
+		}
+		mode, err := w.mode(path, d)
+		if err != nil {
+			return err
+		}
+		switch {
+		case mode.IsDir():
+			if w.skip[d.Name()] || excludes.matchAny(sub, true) || ignored(rules, sub, true) || !w.first(path) {
+				continue
+			}
+			if err := w.walk(name, path, sub, rules); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			if excludes.matchAny(sub, false) || ignored(rules, sub, false) || len(includes) > 0 && !includes.matchAny(sub, false) || !w.first(path) {
+				continue
+			}
+			if len(name) > maxPath && runtime.GOOS == "windows" {
+				name = path
+			}
+			w.files = append(w.files, name)
+		}
+	}
+	return nil
+}
+
+// mode returns the type of the directory entry at path, following it if it
+// is a link that is to be followed. Links that aren't, and links whose
+// target doesn't exist, have the type of the link, which is neither a
+// directory nor a regular file.
+func (w *walker) mode(path string, d fs.DirEntry) (fs.FileMode, error) {
+	link := d.Type()&fs.ModeSymlink != 0
+	if !link && d.IsDir() {
+		// Junctions are reported as directories.
+		fi, err := d.Info()
+		if err != nil {
+			return 0, err
+		}
+		link = isReparsePoint(fi)
+	}
+	if !link {
+		return d.Type(), nil
+	}
+	if !w.follow {
+		return fs.ModeSymlink, nil
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fs.ModeSymlink, nil
+	}
+	return fi.Mode().Type(), nil
+}
+
+// longPath returns the extended-length form of an absolute Windows path,
+// which isn't limited to 260 characters: \\?\C:\src for C:\src, and
+// \\?\UNC\server\share for \\server\share. Other paths, including those
+// already in the extended-length form, are returned unchanged.
+func longPath(p string) string {
+	switch {
+	case strings.HasPrefix(p, `\\?\`) || strings.HasPrefix(p, `\\.\`):
+		return p
+	case strings.HasPrefix(p, `\\`):
+		return `\\?\UNC\` + p[2:]
+	case len(p) >= 3 && p[1] == ':' && (p[2] == '\\' || p[2] == '/'):
+		return `\\?\` + strings.ReplaceAll(p, "/", `\`)
+	}
+	return p
+}
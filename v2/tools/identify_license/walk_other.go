@@ -0,0 +1,36 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package main
+
+import "os"
+
+// fileID identifies a file, on platforms where files can't be identified
+// other than by name.
+type fileID struct{}
+
+// fileKey reports that the file at name can't be identified, so that files
+// reached by several names are classified under each of them.
+func fileKey(name string) (fileID, bool) {
+	return fileID{}, false
+}
+
+// isReparsePoint reports whether the file is a Windows reparse point, which
+// it never is elsewhere.
+func isReparsePoint(fi os.FileInfo) bool {
+	return false
+}
@@ -0,0 +1,143 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLongPath(t *testing.T) {
+	for _, tt := range []struct {
+		in, want string
+	}{
+		{`C:\src\project`, `\\?\C:\src\project`},
+		{`C:/src/project`, `\\?\C:\src\project`},
+		{`\\server\share\src`, `\\?\UNC\server\share\src`},
+		{`\\?\C:\src`, `\\?\C:\src`},
+		{`\\.\pipe\name`, `\\.\pipe\name`},
+		{`src\project`, `src\project`},
+		{`/home/src`, `/home/src`},
+	} {
+		if got := longPath(tt.in); got != tt.want {
+			t.Errorf("longPath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// linkedTree creates a directory holding LICENSE, sub/COPYING, a link to
+// sub and a link to the directory itself, and returns its path.
+func linkedTree(t *testing.T) string {
+	t.Helper()
+	root := filepath.Join(t.TempDir(), "root")
+	if err := os.MkdirAll(filepath.Join(root, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"LICENSE", filepath.Join("sub", "COPYING")} {
+		if err := ioutil.WriteFile(filepath.Join(root, name), []byte("MIT"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for link, target := range map[string]string{"alias": "sub", "loop": "."} {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Skipf("can't create links: %v", err)
+		}
+	}
+	return root
+}
+
+func TestExpandArgsLinks(t *testing.T) {
+	root := linkedTree(t)
+	defer func(follow bool) { *followLinks = follow }(*followLinks)
+
+	for _, tt := range []struct {
+		follow bool
+		want   []string
+	}{
+		{false, []string{"LICENSE", "sub/COPYING"}},
+		// The link to sub comes first, and sub isn't scanned again, nor is
+		// the directory through the link cycle.
+		{true, []string{"LICENSE", "alias/COPYING"}},
+	} {
+		*followLinks = tt.follow
+		files, err := expandArgs([]string{root})
+		if err != nil {
+			t.Fatalf("expandArgs() failed: %v", err)
+		}
+		var got []string
+		for _, f := range files {
+			rel, _ := filepath.Rel(root, f)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("expandArgs() with -follow-links=%t = %q, want %q", tt.follow, got, tt.want)
+		}
+	}
+}
+
+func TestExpandArgsDuplicates(t *testing.T) {
+	root := linkedTree(t)
+	// Another name for the directory, as a path differing in case is on a
+	// case-insensitive file system.
+	other := filepath.Join(filepath.Dir(root), "ROOT")
+	if err := os.Symlink(root, other); err != nil {
+		t.Skipf("can't create links: %v", err)
+	}
+
+	for _, tt := range []struct {
+		args, want []string
+	}{
+		{
+			args: []string{root, other},
+			want: []string{filepath.Join(root, "LICENSE"), filepath.Join(root, "sub", "COPYING")},
+		},
+		{
+			args: []string{filepath.Join(root, "LICENSE"), filepath.Join(other, "LICENSE")},
+			want: []string{filepath.Join(root, "LICENSE")},
+		},
+		{
+			args: []string{filepath.Join(root, "LICENSE"), root},
+			want: []string{filepath.Join(root, "LICENSE"), filepath.Join(root, "sub", "COPYING")},
+		},
+		{
+			args: []string{root, filepath.Join(other, "sub", "COPYING")},
+			want: []string{filepath.Join(root, "LICENSE"), filepath.Join(root, "sub", "COPYING")},
+		},
+	} {
+		files, err := expandArgs(tt.args)
+		if err != nil {
+			t.Fatalf("expandArgs() failed: %v", err)
+		}
+		if !reflect.DeepEqual(files, tt.want) {
+			t.Errorf("expandArgs(%q) = %q, want %q", tt.args, files, tt.want)
+		}
+	}
+
+	// Files that only differ in case are distinct on case-sensitive file
+	// systems, and both classified.
+	if err := ioutil.WriteFile(filepath.Join(root, "license"), []byte("MIT"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	files, err := expandArgs([]string{filepath.Join(root, "LICENSE"), filepath.Join(root, "license")})
+	if err != nil {
+		t.Fatalf("expandArgs() failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("expandArgs() of two files = %q, want both", files)
+	}
+}
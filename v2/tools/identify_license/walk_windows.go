@@ -0,0 +1,54 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID identifies a file or directory, whatever its name: by the serial
+// number of its volume and its index on the volume.
+type fileID struct {
+	volume uint32
+	index  uint64
+}
+
+// fileKey returns the identity of the file at name, following reparse
+// points such as junctions and symbolic links.
+func fileKey(name string) (fileID, bool) {
+	p, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return fileID{}, false
+	}
+	// Directories can only be opened with backup semantics.
+	h, err := syscall.CreateFile(p, 0, syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE, nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return fileID{}, false
+	}
+	defer syscall.CloseHandle(h)
+	var d syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(h, &d); err != nil {
+		return fileID{}, false
+	}
+	return fileID{volume: d.VolumeSerialNumber, index: uint64(d.FileIndexHigh)<<32 | uint64(d.FileIndexLow)}, true
+}
+
+// isReparsePoint reports whether the file is a reparse point, such as a
+// junction, which recent Go releases report as a plain directory.
+func isReparsePoint(fi os.FileInfo) bool {
+	d, ok := fi.Sys().(*syscall.Win32FileAttributeData)
+	return ok && d.FileAttributes&syscall.FILE_ATTRIBUTE_REPARSE_POINT != 0
+}
@@ -0,0 +1,114 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The license_eval program measures the accuracy of the classifier against a
+// labeled dataset and reports the precision, recall and F1 score of each
+// license and over all licenses, so that the effect of changes to the
+// threshold or the corpus can be evaluated quantitatively. The dataset is
+// given as a directory laid out in one of the formats of the eval package, or
+// as a mapping file listing the expected licenses of files.
+//
+//	$ license_eval -dataset spdx license-test-files
+//	$ license_eval -threshold 0.9 -dataset mapping expected.txt
+//
+// With -failures, the cases that weren't classified correctly are listed
+// with the licenses missed and those matched unexpectedly.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	classifier "github.com/google/licenseclassifier/v2"
+	"github.com/google/licenseclassifier/v2/eval"
+)
+
+var (
+	licenseDir = flag.String("license-dir", "", "directory containing the license corpus (defaults to the embedded corpus)")
+	threshold  = flag.Float64("threshold", 0.8, "confidence threshold")
+	dataset    = flag.String("dataset", "scenarios", "layout of the dataset: scenarios, spdx, scancode, or mapping for a file mapping files to their licenses")
+	failures   = flag.Bool("failures", false, "also list the cases that weren't classified correctly")
+)
+
+// loaders load the cases of each dataset layout.
+var loaders = map[string]func(string) ([]*eval.Case, error){
+	"scenarios": eval.LoadScenarios,
+	"spdx":      eval.LoadSPDX,
+	"scancode":  eval.LoadScanCode,
+	"mapping":   eval.LoadMapping,
+}
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s [-dataset scenarios|spdx|scancode|mapping] <dataset> ...
+
+Measure the accuracy of the license classifier against labeled datasets.
+
+Options:
+`, filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+	load, ok := loaders[*dataset]
+	if !ok || flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var cases []*eval.Case
+	for _, d := range flag.Args() {
+		cs, err := load(d)
+		if err != nil {
+			log.Fatalf("cannot load dataset: %v", err)
+		}
+		cases = append(cases, cs...)
+	}
+
+	var c *classifier.Classifier
+	var err error
+	if *licenseDir == "" {
+		c, err = classifier.NewDefaultClassifier(classifier.WithThreshold(*threshold))
+	} else {
+		c, err = classifier.New(classifier.WithThreshold(*threshold), classifier.WithCorpusDir(*licenseDir))
+	}
+	if err != nil {
+		log.Fatalf("cannot create license classifier: %v", err)
+	}
+
+	r := eval.Evaluate(c, cases)
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "license\ttp\tfp\tfn\tprecision\trecall\tf1")
+	row := func(name string, n eval.Counts) {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.3f\t%.3f\t%.3f\n", name, n.TruePositives, n.FalsePositives, n.FalseNegatives, n.Precision(), n.Recall(), n.F1())
+	}
+	for _, s := range r.ByLicense() {
+		row(s.License, s.Counts)
+	}
+	row("all", r.Counts)
+	w.Flush()
+	fmt.Printf("\n%d cases, %d classified correctly\n", len(r.Results), len(r.Results)-len(r.Failures()))
+
+	if *failures {
+		for _, f := range r.Failures() {
+			fmt.Printf("%s: missing %v, unexpected %v\n", f.Case.Name, f.Missing, f.Unexpected)
+		}
+	}
+}
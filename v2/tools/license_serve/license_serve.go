@@ -0,0 +1,128 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The license_serve program serves license classification over HTTP, loading
+// the corpus once at startup. See the server package for the endpoints. With
+// -corpus, the corpus is loaded from a local or downloaded archive, verified
+// against the checksum given with -corpus-sha256.
+//
+//	$ license_serve -addr :8080 &
+//	$ curl --data-binary @LICENSE localhost:8080/v1/classify
+//	{"matches":[{"name":"MIT","expression":"MIT","matchType":"License",...}]}
+//
+// Requests must be read within -read-timeout and answered within
+// -write-timeout. With -file-budget, no single document is matched for much
+// longer than the budget: its remaining matches are estimated, and reported as
+// approximate, so that a pathological document can't exhaust the write
+// timeout.
+package main
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	classifier "github.com/google/licenseclassifier/v2"
+	"github.com/google/licenseclassifier/v2/server"
+)
+
+var (
+	addr         = flag.String("addr", ":8080", "address to listen on")
+	licenseDir   = flag.String("license-dir", "", "directory containing the license corpus (defaults to the embedded corpus)")
+	corpus       = flag.String("corpus", "", "license corpus to load rather than the embedded one: a zip, tar or gzipped tar archive, or the http, https, gs or s3 URL of one")
+	corpusSum    = flag.String("corpus-sha256", "", "hex-encoded SHA-256 checksum the -corpus archive must have, required for URLs")
+	corpusCache  = flag.String("corpus-cache", "", "directory to cache the downloaded -corpus archive in, when its checksum is given")
+	threshold    = flag.Float64("threshold", classifier.DefaultThreshold, "confidence threshold")
+	maxBodySize  = flag.Int64("max-body-size", server.DefaultMaxBodySize, "maximum size in bytes of the content of a request")
+	aliasFile    = flag.String("aliases", "", "JSON file mapping license names to organization-specific aliases reported with them")
+	topK         = flag.Int("top-k", 0, "report the other licenses among the k best scoring in the region of each match")
+	overlap      = flag.String("overlap", "token-density", "strategy deciding which of overlapping matches are reported: token-density, highest-confidence, longest or report-all")
+	fileBudget   = flag.Duration("file-budget", 0, "time to spend matching a single document before estimating the confidence of its remaining matches, which are reported as approximate (0 disables)")
+	readTimeout  = flag.Duration("read-timeout", time.Minute, "time to read a request, including its body")
+	writeTimeout = flag.Duration("write-timeout", 5*time.Minute, "time to classify a request and write its response")
+)
+
+func main() {
+	flag.Parse()
+
+	strategy, err := classifier.ParseOverlapStrategy(*overlap)
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := []classifier.Option{classifier.WithThreshold(*threshold), classifier.WithTopK(*topK), classifier.WithOverlapStrategy(strategy), classifier.WithTimeBudget(*fileBudget)}
+	if *aliasFile != "" {
+		b, err := ioutil.ReadFile(*aliasFile)
+		if err != nil {
+			log.Fatalf("cannot read aliases: %v", err)
+		}
+		aliases, err := classifier.ParseAliases(b)
+		if err != nil {
+			log.Fatalf("%s: %v", *aliasFile, err)
+		}
+		opts = append(opts, classifier.WithAliases(aliases))
+	}
+	newClassifier := classifier.NewDefaultClassifier
+	switch {
+	case *licenseDir != "" && *corpus != "":
+		log.Fatalf("-license-dir and -corpus are exclusive")
+	case *licenseDir != "":
+		opts = append(opts, classifier.WithCorpusDir(*licenseDir))
+		newClassifier = classifier.New
+	case *corpus != "":
+		opts = append(opts, classifier.WithCorpusSource(classifier.ParseCorpusSource(*corpus, *corpusSum, *corpusCache)))
+		newClassifier = classifier.New
+	}
+	c, err := newClassifier(opts...)
+	if err != nil {
+		log.Fatalf("cannot create license classifier: %v", err)
+	}
+	s := server.New(c)
+	s.MaxBodySize = *maxBodySize
+
+	// Bound the time a client can hold a connection, so that slow clients
+	// can't tie up the server.
+	hs := &http.Server{
+		Addr:              *addr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       *readTimeout,
+		WriteTimeout:      *writeTimeout,
+		IdleTimeout:       2 * time.Minute,
+	}
+	done := make(chan struct{})
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		// Let the requests in progress finish before exiting.
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := hs.Shutdown(ctx); err != nil {
+			log.Printf("shutdown: %v", err)
+		}
+		close(done)
+	}()
+
+	log.Printf("serving %d licenses (corpus %s) on %s", len(c.LicenseDB().IDs()), c.CorpusVersion(), *addr)
+	if err := hs.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+	<-done
+}
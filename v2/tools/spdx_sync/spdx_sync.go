@@ -0,0 +1,107 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The spdx_sync program compares the license corpus with a release of the
+// SPDX license list, and with -write updates the corpus with the licenses
+// and exceptions the release adds or changes. The release is downloaded, or
+// read from a local checkout of the license-list-data repository.
+//
+//	$ spdx_sync -version v3.21
+//	$ spdx_sync -spdx-dir ~/license-list-data -write
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/google/licenseclassifier/v2/spdx"
+)
+
+var (
+	licenseDir = flag.String("license-dir", "", "directory containing the license corpus (defaults to the corpus in the source tree)")
+	version    = flag.String("version", "", "release of the SPDX license list to download, such as v3.21")
+	url        = flag.String("url", "", "URL of the release archive to download, instead of that of -version")
+	spdxDir    = flag.String("spdx-dir", "", "directory holding a checkout of the SPDX license-list-data repository, instead of downloading a release")
+	write      = flag.Bool("write", false, "write the added and changed licenses to the corpus")
+)
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `Usage: %s (-version <release> | -url <archive> | -spdx-dir <dir>) [-write]
+
+Compare the license corpus with a release of the SPDX license list, and
+optionally update it.
+
+Options:
+`, filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	var r *spdx.Release
+	var err error
+	switch {
+	case *spdxDir != "":
+		r, err = spdx.Load(*spdxDir)
+	case *url != "":
+		r, err = spdx.Fetch(*url)
+	case *version != "":
+		r, err = spdx.Fetch(spdx.ReleaseURL(*version))
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("cannot read SPDX release: %v", err)
+	}
+
+	dir := *licenseDir
+	if dir == "" {
+		_, filename, _, _ := runtime.Caller(0)
+		dir = filepath.Join(filepath.Dir(filename), "..", "..", "licenses")
+	}
+	d, err := r.Diff(dir)
+	if err != nil {
+		log.Fatalf("cannot compare license corpus: %v", err)
+	}
+
+	fmt.Printf("SPDX license list version %s\n", r.Version)
+	for _, l := range []struct {
+		status string
+		names  []string
+	}{
+		{"added", d.Added},
+		{"changed", d.Changed},
+		{"unlisted", d.Unlisted},
+	} {
+		for _, n := range l.names {
+			fmt.Printf("%s\t%s\n", l.status, n)
+		}
+	}
+	fmt.Printf("\n%d added, %d changed, %d corpus entries not in SPDX\n", len(d.Added), len(d.Changed), len(d.Unlisted))
+
+	if *write {
+		if err := r.WriteCorpus(dir, d); err != nil {
+			log.Fatalf("cannot update license corpus: %v", err)
+		}
+		fmt.Printf("wrote %d licenses to %s\n", len(d.Added)+len(d.Changed), dir)
+	}
+}
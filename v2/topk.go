@@ -0,0 +1,75 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import "sort"
+
+// Alternative is a license that matched the region of a match with a lower
+// confidence than the reported license.
+type Alternative struct {
+	Name       string
+	MatchType  string
+	Confidence float64
+}
+
+// SetTopK makes the classifier report, along with each match, the other
+// licenses that scored best in its region, so that the reported match is the
+// first of the top k licenses of the region. Close calls, such as a region
+// matching BSD-3-Clause at 0.96 and BSD-2-Clause at 0.93, can then be
+// reviewed. Alternatives must still reach the threshold. A k of zero or one,
+// the default, reports no alternatives.
+func (c *Classifier) SetTopK(k int) {
+	defer c.update()()
+	c.topK = k
+}
+
+// addAlternatives records in each match the best candidates of up to k-1
+// other licenses overlapping it, best first.
+func addAlternatives(matches, candidates Matches, k int) {
+	if k < 2 {
+		return
+	}
+	for _, m := range matches {
+		if m.MatchType == referenceType || m.MatchType == grantType {
+			continue
+		}
+		best := make(map[string]Alternative)
+		for _, o := range candidates {
+			if o.Name == m.Name || !sameRegion(m, o) {
+				continue
+			}
+			if a, ok := best[o.Name]; !ok || o.Confidence > a.Confidence {
+				best[o.Name] = Alternative{Name: o.Name, MatchType: o.MatchType, Confidence: o.Confidence}
+			}
+		}
+		if len(best) == 0 {
+			continue
+		}
+		alts := make([]Alternative, 0, len(best))
+		for _, a := range best {
+			alts = append(alts, a)
+		}
+		sort.Slice(alts, func(i, j int) bool {
+			if alts[i].Confidence != alts[j].Confidence {
+				return alts[i].Confidence > alts[j].Confidence
+			}
+			return alts[i].Name < alts[j].Name
+		})
+		if len(alts) > k-1 {
+			alts = alts[:k-1]
+		}
+		m.Alternatives = alts
+	}
+}
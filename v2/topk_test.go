@@ -0,0 +1,79 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTopK(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("couldn't instantiate standard test classifier: %v", err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join("licenses", "MIT.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := c.Match(b); len(m) != 1 || m[0].Alternatives != nil {
+		t.Fatalf("Match() without top-k = %v, want a single match without alternatives", m)
+	}
+
+	for _, k := range []int{2, 3} {
+		c.SetTopK(k)
+		m := c.Match(b)
+		if len(m) != 1 || m[0].Name != "MIT" {
+			t.Fatalf("Match() with top-%d = %v, want a single MIT match", k, m)
+		}
+		alts := m[0].Alternatives
+		if len(alts) != k-1 {
+			t.Fatalf("top-%d alternatives = %v, want %d", k, alts, k-1)
+		}
+		if alts[0].Name != "JSON" {
+			t.Errorf("top-%d best alternative = %s, want JSON", k, alts[0].Name)
+		}
+		for i, a := range alts {
+			if a.Confidence < c.threshold || a.Confidence > m[0].Confidence || (i > 0 && a.Confidence > alts[i-1].Confidence) {
+				t.Errorf("top-%d alternatives aren't ranked between the match and the threshold: %v", k, alts)
+			}
+		}
+	}
+}
+
+func TestAddAlternatives(t *testing.T) {
+	candidates := Matches{
+		{Name: "BSD-3-Clause", MatchType: "License", Confidence: .96, StartLine: 1, EndLine: 20},
+		{Name: "BSD-2-Clause", MatchType: "License", Confidence: .93, StartLine: 1, EndLine: 18},
+		{Name: "BSD-2-Clause", MatchType: "License", Confidence: .91, StartLine: 2, EndLine: 18},
+		{Name: "BSD-3-Clause", MatchType: "Header", Confidence: .9, StartLine: 1, EndLine: 5},
+		{Name: "MIT", MatchType: "License", Confidence: .99, StartLine: 30, EndLine: 40},
+	}
+	matches := Matches{
+		{Name: "BSD-3-Clause", MatchType: "License", Confidence: .96, StartLine: 1, EndLine: 20},
+		{Name: "MIT", MatchType: "License", Confidence: .99, StartLine: 30, EndLine: 40},
+	}
+	addAlternatives(matches, candidates, 5)
+	want := []Alternative{{Name: "BSD-2-Clause", MatchType: "License", Confidence: .93}}
+	if diff := cmp.Diff(want, matches[0].Alternatives); diff != "" {
+		t.Errorf("alternatives mismatch (-want +got):\n%s", diff)
+	}
+	if matches[1].Alternatives != nil {
+		t.Errorf("alternatives of an isolated match = %v, want none", matches[1].Alternatives)
+	}
+}
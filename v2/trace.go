@@ -19,10 +19,11 @@ import (
 	"strings"
 )
 
-// This file contains routines for a simple trace execution mechanism.
-
 // TraceConfiguration specifies the configuration for tracing execution of the
-// license classifier.
+// license classifier. It is a Logger of the diagnostics of the traced phases
+// and licenses at all levels.
+//
+// Deprecated: use SetLogger, with NewLogger or a Logger of your own.
 type TraceConfiguration struct {
 	// Comma-separated list of phases to be traced. Can use * for all phases.
 	TracePhases string
@@ -58,9 +59,6 @@ func (t *TraceConfiguration) init() {
 	}
 }
 
-var traceLicenses map[string]bool
-var tracePhases map[string]bool
-
 func (t *TraceConfiguration) shouldTrace(phase string) bool {
 	if t == nil {
 		return false
@@ -90,30 +88,29 @@ func (t *TraceConfiguration) isTraceLicense(lic string) bool {
 	return false
 }
 
-func (t *TraceConfiguration) trace(f string, args ...interface{}) {
-	if t == nil || t.Tracer == nil {
-		fmt.Printf(f, args...)
-		fmt.Println()
-		return
-	}
-
-	t.Tracer(f, args...)
-}
-
-func (t *TraceConfiguration) traceSearchset(lic string) bool {
-	return t.isTraceLicense(lic) && t.shouldTrace("searchset")
-}
-
-func (t *TraceConfiguration) traceTokenize(lic string) bool {
-	return t.isTraceLicense(lic) && t.shouldTrace("tokenize")
-}
-
-func (t *TraceConfiguration) traceScoring(lic string) bool {
-	return t.isTraceLicense(lic) && t.shouldTrace("score")
+// Enabled implements Logger.
+func (t *TraceConfiguration) Enabled(phase Phase, level LogLevel) bool {
+	return t.shouldTrace(string(phase))
 }
 
-func (t *TraceConfiguration) traceFrequency(lic string) bool {
-	return t.isTraceLicense(lic) && t.shouldTrace("frequency")
+// Log implements Logger, tracing the diagnostics about the traced licenses.
+func (t *TraceConfiguration) Log(phase Phase, level LogLevel, msg string, args ...interface{}) {
+	if !t.shouldTrace(string(phase)) {
+		return
+	}
+	var b strings.Builder
+	b.WriteString(msg)
+	for i := 0; i+1 < len(args); i += 2 {
+		if args[i] == "license" && !t.isTraceLicense(fmt.Sprint(args[i+1])) {
+			return
+		}
+		fmt.Fprintf(&b, " %v=%v", args[i], args[i+1])
+	}
+	if t.Tracer == nil {
+		fmt.Println(b.String())
+		return
+	}
+	t.Tracer("%s", b.String())
 }
 
 // TraceFunc works like fmt.Printf to emit tracing data for the
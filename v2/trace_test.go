@@ -57,10 +57,10 @@ func TestInitTrace(t *testing.T) {
 			}
 			tc.init()
 			if !cmp.Equal(tc.traceLicenses, test.expectedLics) {
-				t.Errorf("got %v want %v", traceLicenses, test.expectedLics)
+				t.Errorf("got %v want %v", tc.traceLicenses, test.expectedLics)
 			}
 			if !cmp.Equal(tc.tracePhases, test.expectedPhases) {
-				t.Errorf("got %v want %v", traceLicenses, test.expectedPhases)
+				t.Errorf("got %v want %v", tc.tracePhases, test.expectedPhases)
 			}
 		})
 	}
@@ -0,0 +1,140 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// CorpusIssue is a problem with a corpus entry that affects how reliably it
+// can be matched.
+type CorpusIssue struct {
+	// Name is the name of the corpus entry.
+	Name    string
+	Message string
+}
+
+func (i *CorpusIssue) String() string {
+	return fmt.Sprintf("%s: %s", i.Name, i.Message)
+}
+
+// ValidateCorpus reports the problems found with the entries of the corpus as
+// they were added, ordered by entry name. SelfTest reports the problems found
+// by classifying them.
+func (c *Classifier) ValidateCorpus() []*CorpusIssue {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var out []*CorpusIssue
+	for _, i := range c.issues {
+		out = append(out, i)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// minReliableLength returns the number of tokens below which an entry can't
+// be matched reliably at the threshold of the classifier. An entry shorter
+// than the q-gram length shares no q-grams with longer input, and an entry
+// too short for a single edit to keep its confidence above the threshold only
+// matches text that is identical after normalization.
+func (c *Classifier) minReliableLength() int {
+	if c.threshold >= 1.0 {
+		return c.q
+	}
+	return max(c.q, int(math.Ceil(1/(1-c.threshold)-1e-9)))
+}
+
+// checkEntry records any problems with a newly added corpus entry.
+func (c *Classifier) checkEntry(name string, id *indexedDocument) {
+	delete(c.issues, name)
+	n := id.size()
+	switch {
+	case n < c.q:
+		c.issues[name] = &CorpusIssue{
+			Name:    name,
+			Message: fmt.Sprintf("%d tokens is shorter than the q-gram length of %d at threshold %v, so the entry is only found in input consisting of nothing else", n, c.q, c.threshold),
+		}
+	case n < c.minReliableLength():
+		c.issues[name] = &CorpusIssue{
+			Name:    name,
+			Message: fmt.Sprintf("%d tokens is below the reliable minimum of %d at threshold %v, so the entry only matches identical text", n, c.minReliableLength(), c.threshold),
+		}
+	}
+}
+
+// SelfTest classifies the text of every corpus entry against the corpus, and
+// reports the entries that don't match their own license with a confidence of
+// 1, and those matching another license at the threshold, ordered by entry
+// name. References to other licenses, as in the compatibility clauses of many
+// licenses, aren't problems. It catches the regressions a custom license
+// causes, such as a variant of a license that also matches the original, at
+// the cost of classifying the whole corpus, which is done concurrently.
+func (c *Classifier) SelfTest() []*CorpusIssue {
+	s := c.snapshot()
+	names := sortedNames(s.docs)
+	issues := make([][]*CorpusIssue, len(names))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < s.workers() && w < len(names); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				issues[i] = s.selfTest(names[i])
+			}
+		}()
+	}
+	for i := range names {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	var out []*CorpusIssue
+	for _, is := range issues {
+		out = append(out, is...)
+	}
+	return out
+}
+
+// selfTest returns the problems found by classifying the named corpus entry.
+func (c *Classifier) selfTest(name string) []*CorpusIssue {
+	license := LicenseName(name)
+	best := 0.0
+	var out []*CorpusIssue
+	for _, m := range c.matchContent(c.docs[name].content, nil, nil) {
+		switch {
+		case m.MatchType == referenceType || m.MatchType == grantType:
+		case m.Name == license:
+			best = math.Max(best, m.Confidence)
+		case m.Confidence >= c.threshold:
+			out = append(out, &CorpusIssue{
+				Name:    name,
+				Message: fmt.Sprintf("matches %s (%s) with confidence %.3f on lines %d-%d", m.Name, m.Variant, m.Confidence, m.StartLine, m.EndLine),
+			})
+		}
+	}
+	if best < 1 {
+		msg := "doesn't match itself"
+		if best > 0 {
+			msg = fmt.Sprintf("matches itself with confidence %.3f", best)
+		}
+		out = append([]*CorpusIssue{{Name: name, Message: msg}}, out...)
+	}
+	return out
+}
@@ -0,0 +1,115 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+func TestMinReliableLength(t *testing.T) {
+	tests := []struct {
+		threshold float64
+		want      int
+	}{
+		{threshold: 0.5, want: 2},
+		{threshold: 0.8, want: 5},
+		{threshold: 0.9, want: 10},
+		{threshold: 0.95, want: 20},
+		{threshold: 1.0, want: 10},
+	}
+	for _, tt := range tests {
+		if got := NewClassifier(tt.threshold).minReliableLength(); got != tt.want {
+			t.Errorf("minReliableLength() at %v = %d, want %d", tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func TestValidateCorpus(t *testing.T) {
+	c := NewClassifier(0.9)
+	c.AddContent("License/Long/license.txt", []byte("Permission is hereby granted to use, copy, modify and distribute this software for any purpose."))
+	c.AddContent("License/Brittle/license.txt", []byte("You may use this software for any purpose whatsoever."))
+	c.AddContent("License/Tiny/license.txt", []byte("Do anything you want."))
+
+	issues := c.ValidateCorpus()
+	if len(issues) != 2 {
+		t.Fatalf("ValidateCorpus() = %v, want 2 issues", issues)
+	}
+	if issues[0].Name != "License/Brittle/license.txt" || !strings.Contains(issues[0].Message, "only matches identical text") {
+		t.Errorf("ValidateCorpus()[0] = %v, want a brittle entry", issues[0])
+	}
+	if issues[1].Name != "License/Tiny/license.txt" || !strings.Contains(issues[1].Message, "nothing else") {
+		t.Errorf("ValidateCorpus()[1] = %v, want an unmatchable entry", issues[1])
+	}
+
+	// The short entry only matches input consisting of its own text, which is
+	// the behavior the issue explains.
+	if got := c.Match([]byte("Do anything you want.")); len(got) != 1 {
+		t.Errorf("Match() = %v, want 1 match", got)
+	}
+	if got := c.Match([]byte("Copyright 2020 Some Author.\nDo anything you want.\nNo warranty is given.")); len(got) != 0 {
+		t.Errorf("Match() = %v, want no matches", got)
+	}
+
+	// Replacing an entry with a longer text clears its issue.
+	c.AddContent("License/Tiny/license.txt", []byte("Permission is granted to do anything you want with this software, without any conditions."))
+	if issues := c.ValidateCorpus(); len(issues) != 1 {
+		t.Errorf("ValidateCorpus() = %v, want 1 issue", issues)
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	if issues := c.SelfTest(); len(issues) != 0 {
+		t.Errorf("SelfTest() of the base corpus = %v, want no issues", issues)
+	}
+
+	c = NewClassifier(defaultThreshold)
+	c.AddContent("Widget-1.0", []byte(versionedLicense))
+	c.AddContent("Gadget-1.0", []byte(gadgetLicense))
+	if issues := c.SelfTest(); len(issues) != 0 {
+		t.Errorf("SelfTest() = %v, want no issues", issues)
+	}
+
+	// A custom copy of a license matches the original, and the original
+	// matches it.
+	c.AddContent("Widget-Custom", []byte(versionedLicense))
+	issues := c.SelfTest()
+	if len(issues) != 2 {
+		t.Fatalf("SelfTest() = %v, want 2 issues", issues)
+	}
+	if issues[0].Name != "Widget-1.0" || !strings.Contains(issues[0].Message, "matches Widget-Custom") {
+		t.Errorf("SelfTest()[0] = %v, want Widget-1.0 to match Widget-Custom", issues[0])
+	}
+	if issues[1].Name != "Widget-Custom" || !strings.Contains(issues[1].Message, "matches Widget-1.0") {
+		t.Errorf("SelfTest()[1] = %v, want Widget-Custom to match Widget-1.0", issues[1])
+	}
+
+	// A rule rejecting an entry keeps it from matching itself.
+	c = NewClassifier(defaultThreshold)
+	c.AddContent("Widget-1.0", []byte(versionedLicense))
+	c.AddDiffRule("no widgets", DiffRuleFunc(func(entry string, _ []diffmatchpatch.Diff) (Verdict, string) {
+		return Reject, "no widgets"
+	}))
+	issues = c.SelfTest()
+	if len(issues) != 1 || issues[0].Message != "doesn't match itself" {
+		t.Errorf("SelfTest() = %v, want Widget-1.0 not to match itself", issues)
+	}
+}
@@ -0,0 +1,345 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vendored audits the licenses of the JavaScript and Python packages
+// installed in a project: those of its node_modules directories and of the
+// site-packages directories of its virtualenvs. The license each package
+// declares in its metadata is compared with the licenses found in its license
+// files, as the manifest package does for the manifest of a single package.
+package vendored
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	classifier "github.com/google/licenseclassifier/v2"
+	"github.com/google/licenseclassifier/v2/manifest"
+)
+
+// maxLicenseFileSize bounds the size of the license files that are
+// classified, as some packages ship large notice files listing their own
+// dependencies.
+const maxLicenseFileSize = 1 << 20
+
+// Ecosystem identifies the package manager that installed a package.
+type Ecosystem string
+
+// Ecosystems of installed packages.
+const (
+	// NPM packages are installed in node_modules directories.
+	NPM Ecosystem = "npm"
+	// PyPI packages are installed in site-packages directories.
+	PyPI Ecosystem = "pypi"
+)
+
+// Package is an installed package.
+type Package struct {
+	Ecosystem Ecosystem
+	Name      string
+	Version   string
+	// Dir is the directory holding the metadata of the package: the
+	// directory of an npm package, or the .dist-info or .egg-info directory
+	// of a Python package.
+	Dir string
+	// Metadata is the name of the metadata file of the package in Dir:
+	// package.json, METADATA or PKG-INFO.
+	Metadata string
+}
+
+func (p *Package) String() string {
+	if p.Version == "" {
+		return p.Name
+	}
+	return p.Name + "@" + p.Version
+}
+
+// Report is the result of reconciling the license declared by a package with
+// the licenses found in its license files.
+type Report struct {
+	Package *Package
+	// Declaration is the license declared by the metadata of the package.
+	Declaration *manifest.Declaration
+	// Files are the license files of the package, named relative to its Dir.
+	Files         []*manifest.LicenseFile
+	Discrepancies []*manifest.Discrepancy
+	// Err is the error that prevented the package from being checked, such
+	// as malformed metadata.
+	Err error
+}
+
+// Reconciled returns true if the package was checked and its declared
+// license agrees with its license files.
+func (r *Report) Reconciled() bool {
+	return r.Err == nil && len(r.Discrepancies) == 0
+}
+
+// Scan checks the packages found by Find.
+func Scan(c *classifier.Classifier, root string) ([]*Report, error) {
+	pkgs, err := Find(root)
+	if err != nil {
+		return nil, err
+	}
+	return Check(c, pkgs), nil
+}
+
+// Find returns the packages installed in the node_modules, site-packages and
+// dist-packages directories under root, including those nested in other
+// packages, in the order of their directories. Symbolic links in node_modules
+// directories, as npm creates for the packages of a workspace, aren't
+// followed: the packages they point to are part of the project or are found
+// where they are installed.
+func Find(root string) ([]*Package, error) {
+	var pkgs []*Package
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		var found []*Package
+		switch d.Name() {
+		case ".git":
+			return filepath.SkipDir
+		case "node_modules":
+			found, err = nodePackages(p)
+		case "site-packages", "dist-packages":
+			found, err = pythonPackages(p)
+		}
+		pkgs = append(pkgs, found...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pkgs, nil
+}
+
+// nodePackages returns the packages of a node_modules directory, including
+// those of scopes such as @types. Hidden entries, such as .bin, are skipped.
+func nodePackages(dir string) ([]*Package, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var pkgs []*Package
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		if !strings.HasPrefix(e.Name(), "@") {
+			p, err := nodePackage(filepath.Join(dir, e.Name()))
+			if err != nil {
+				return nil, err
+			}
+			if p != nil {
+				pkgs = append(pkgs, p)
+			}
+			continue
+		}
+		scoped, err := os.ReadDir(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range scoped {
+			if !s.IsDir() {
+				continue
+			}
+			p, err := nodePackage(filepath.Join(dir, e.Name(), s.Name()))
+			if err != nil {
+				return nil, err
+			}
+			if p != nil {
+				pkgs = append(pkgs, p)
+			}
+		}
+	}
+	return pkgs, nil
+}
+
+// nodePackage returns the npm package in dir, or nil if dir has no
+// package.json.
+func nodePackage(dir string) (*Package, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, "package.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p := &Package{Ecosystem: NPM, Dir: dir, Metadata: "package.json"}
+	var pkg struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if json.Unmarshal(b, &pkg) == nil {
+		p.Name, p.Version = pkg.Name, pkg.Version
+	}
+	if p.Name == "" {
+		// The malformed package.json is reported when the package is
+		// checked.
+		p.Name = filepath.Base(dir)
+	}
+	return p, nil
+}
+
+// pythonPackages returns the packages of a site-packages directory, found by
+// their .dist-info and .egg-info directories. Their names and versions are
+// those of the directories, such as "attrs-23.2.0.dist-info".
+func pythonPackages(dir string) ([]*Package, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var pkgs []*Package
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		var base, metadata string
+		switch ext := filepath.Ext(e.Name()); ext {
+		case ".dist-info":
+			base, metadata = strings.TrimSuffix(e.Name(), ext), "METADATA"
+		case ".egg-info":
+			base, metadata = strings.TrimSuffix(e.Name(), ext), "PKG-INFO"
+		default:
+			continue
+		}
+		p := &Package{Ecosystem: PyPI, Dir: filepath.Join(dir, e.Name()), Metadata: metadata}
+		parts := strings.SplitN(base, "-", 3)
+		p.Name = parts[0]
+		if len(parts) > 1 {
+			p.Version = parts[1]
+		}
+		pkgs = append(pkgs, p)
+	}
+	return pkgs, nil
+}
+
+// Check reconciles the declared license of each package with its license
+// files, and returns a report for each in the same order.
+func Check(c *classifier.Classifier, pkgs []*Package) []*Report {
+	var reports []*Report
+	for _, p := range pkgs {
+		reports = append(reports, check(c, p))
+	}
+	return reports
+}
+
+func check(c *classifier.Classifier, p *Package) *Report {
+	r := &Report{Package: p}
+	d, err := manifest.ParseFile(filepath.Join(p.Dir, p.Metadata))
+	if err != nil {
+		r.Err = fmt.Errorf("package %s: %v", p, err)
+		return r
+	}
+	r.Declaration = d
+	names, err := licenseFiles(p)
+	if err != nil {
+		r.Err = fmt.Errorf("package %s: %v", p, err)
+		return r
+	}
+	for _, f := range d.Files {
+		name, ok := referencedFile(p, f)
+		if !ok {
+			r.Discrepancies = append(r.Discrepancies, &manifest.Discrepancy{
+				Kind:    manifest.MissingFile,
+				Message: fmt.Sprintf("license file %s named by %s doesn't exist", f, p.Metadata),
+			})
+			continue
+		}
+		names = append(names, name)
+	}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		file := filepath.Join(p.Dir, filepath.FromSlash(name))
+		if fi, err := os.Stat(file); err == nil && fi.Size() > maxLicenseFileSize {
+			continue
+		}
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			r.Err = fmt.Errorf("package %s: %v", p, err)
+			return r
+		}
+		r.Files = append(r.Files, &manifest.LicenseFile{Name: name, Matches: c.Match(b)})
+	}
+	r.Discrepancies = append(r.Discrepancies, manifest.Compare(d, r.Files)...)
+	return r
+}
+
+// licenseFiles returns the likely license files of a package, relative to
+// its Dir. Those of a Python package also include every file of the licenses
+// directory of its .dist-info directory, where wheels store the files named
+// by License-File.
+func licenseFiles(p *Package) ([]string, error) {
+	entries, err := os.ReadDir(p.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.Type().IsRegular() && classifier.LikelyLicenseFile(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	if p.Ecosystem != PyPI {
+		return names, nil
+	}
+	licenses := filepath.Join(p.Dir, "licenses")
+	err = filepath.WalkDir(licenses, func(f string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && f == licenses {
+				return nil
+			}
+			return err
+		}
+		if d.Type().IsRegular() {
+			rel, err := filepath.Rel(p.Dir, f)
+			if err != nil {
+				return err
+			}
+			names = append(names, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return names, err
+}
+
+// referencedFile returns the name, relative to the Dir of the package, of a
+// license file named by its metadata, and whether it exists. The files named
+// by the License-File fields of a Python package are looked up in its
+// licenses directory first.
+func referencedFile(p *Package, f string) (string, bool) {
+	f = path.Clean(filepath.ToSlash(f))
+	candidates := []string{f}
+	if p.Ecosystem == PyPI {
+		candidates = []string{path.Join("licenses", f), f}
+	}
+	for _, name := range candidates {
+		if fi, err := os.Stat(filepath.Join(p.Dir, filepath.FromSlash(name))); err == nil && fi.Mode().IsRegular() {
+			return name, true
+		}
+	}
+	return "", false
+}
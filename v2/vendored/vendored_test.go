@@ -0,0 +1,144 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vendored
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	classifier "github.com/google/licenseclassifier/v2"
+	"github.com/google/licenseclassifier/v2/manifest"
+)
+
+func readLicense(t *testing.T, name string) string {
+	t.Helper()
+	b, err := ioutil.ReadFile(filepath.Join("..", "licenses", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	c := classifier.NewClassifier(0.8)
+	if err := c.LoadLicenses(filepath.Join("..", "licenses")); err != nil {
+		t.Fatalf("LoadLicenses() failed: %v", err)
+	}
+	dir, err := ioutil.TempDir("", "vendored")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	mit := readLicense(t, "MIT.txt")
+	site := "venv/lib/python3.11/site-packages/"
+	writeFiles(t, dir, map[string]string{
+		"node_modules/left-pad/package.json":                     `{"name": "left-pad", "version": "1.3.0", "license": "MIT"}`,
+		"node_modules/left-pad/LICENSE":                          mit,
+		"node_modules/left-pad/node_modules/nested/package.json": `{"name": "nested", "version": "0.1.0", "license": "ISC"}`,
+		"node_modules/@acme/widget/package.json":                 `{"name": "@acme/widget", "version": "2.0.0", "license": "Apache-2.0"}`,
+		"node_modules/@acme/widget/LICENSE.md":                   mit,
+		"node_modules/.bin/left-pad":                             "#!/usr/bin/env node\n",
+		"packages/app/package.json":                              `{"name": "app", "license": "MIT"}`,
+		site + "attrs-23.2.0.dist-info/METADATA":                 "Metadata-Version: 2.4\nName: attrs\nLicense-Expression: MIT\nLicense-File: LICENSE\n",
+		site + "attrs-23.2.0.dist-info/licenses/LICENSE":         mit,
+		site + "six-1.16.0.dist-info/METADATA":                   "Metadata-Version: 2.1\nName: six\nLicense: MIT\nLicense-File: COPYING\n",
+		site + "six-1.16.0.dist-info/LICENSE":                    mit,
+		site + "legacy-0.9-py3.11.egg-info/PKG-INFO":             "Metadata-Version: 1.1\nName: legacy\nLicense: UNKNOWN\n",
+		site + "attrs/__init__.py":                               "",
+	})
+	// Workspace packages are linked into node_modules, and aren't
+	// dependencies.
+	if err := os.Symlink(filepath.Join(dir, "packages", "app"), filepath.Join(dir, "node_modules", "app")); err != nil {
+		t.Fatal(err)
+	}
+
+	reports, err := Scan(c, dir)
+	if err != nil {
+		t.Fatalf("Scan() failed: %v", err)
+	}
+	type result struct {
+		Package       string
+		Ecosystem     Ecosystem
+		Files         []string
+		Discrepancies []manifest.DiscrepancyKind
+	}
+	var got []result
+	for _, r := range reports {
+		if r.Err != nil {
+			t.Errorf("%s: %v", r.Package, r.Err)
+			continue
+		}
+		res := result{Package: r.Package.String(), Ecosystem: r.Package.Ecosystem}
+		for _, f := range r.Files {
+			res.Files = append(res.Files, f.Name)
+		}
+		for _, d := range r.Discrepancies {
+			res.Discrepancies = append(res.Discrepancies, d.Kind)
+		}
+		if r.Reconciled() != (len(r.Discrepancies) == 0) {
+			t.Errorf("%s: Reconciled() = %v with discrepancies %v", r.Package, r.Reconciled(), r.Discrepancies)
+		}
+		got = append(got, res)
+	}
+	want := []result{
+		{Package: "@acme/widget@2.0.0", Ecosystem: NPM, Files: []string{"LICENSE.md"}, Discrepancies: []manifest.DiscrepancyKind{manifest.NotFound, manifest.Undeclared}},
+		{Package: "left-pad@1.3.0", Ecosystem: NPM, Files: []string{"LICENSE"}},
+		{Package: "nested@0.1.0", Ecosystem: NPM, Discrepancies: []manifest.DiscrepancyKind{manifest.NoLicenseFile}},
+		{Package: "attrs@23.2.0", Ecosystem: PyPI, Files: []string{"licenses/LICENSE"}},
+		{Package: "legacy@0.9", Ecosystem: PyPI, Discrepancies: []manifest.DiscrepancyKind{manifest.NoDeclaration, manifest.NoLicenseFile}},
+		{Package: "six@1.16.0", Ecosystem: PyPI, Files: []string{"LICENSE"}, Discrepancies: []manifest.DiscrepancyKind{manifest.MissingFile}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Scan() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCheckMalformedMetadata(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vendored")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{
+		"node_modules/broken/package.json": `{"license": `,
+	})
+	pkgs, err := Find(dir)
+	if err != nil {
+		t.Fatalf("Find() failed: %v", err)
+	}
+	if len(pkgs) != 1 || pkgs[0].Name != "broken" {
+		t.Fatalf("Find() = %v, want the broken package", pkgs)
+	}
+	r := Check(classifier.NewClassifier(0.8), pkgs)[0]
+	if r.Err == nil || r.Reconciled() {
+		t.Errorf("Check() of malformed metadata = %+v, want an error", r)
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A text matching an old version of a license closely may still have been
+// edited toward a newer one, as when the headings of the MPL-2.0 are pasted
+// into an MPL-1.1. The version change rule only rejects edits of the version
+// number itself, so the matches of versioned licenses are checked for the
+// language of their other versions, and reported as ambiguous rather than as
+// a plain match of the best scoring version.
+
+// VersionConflict describes a match of a version of a license whose text has
+// language of another version of the license.
+type VersionConflict struct {
+	// Versions are the conflicting versions: the matched license first, then
+	// the version whose language the matched text has, each with the
+	// confidence of the matched text against it.
+	Versions []Alternative
+	// Phrases are the runs of the matched text that are in the other version
+	// but in no text of the matched license, in the order they appear.
+	Phrases []string
+}
+
+// Reason explains the conflict.
+func (v *VersionConflict) Reason() string {
+	return fmt.Sprintf("version conflict: the text matches %s but has language of %s", v.Versions[0].Name, v.Versions[1].Name)
+}
+
+// licenseVersion splits the name of a license into the name of its family
+// and its version, such as "MPL" and "1.1".
+var licenseVersion = regexp.MustCompile(`^(.+)-(\d+(?:\.\d+)+)$`)
+
+const (
+	// versionShingle is the number of words of the phrases compared
+	// between versions.
+	versionShingle = 5
+	// minVersionPhrases is the number of distinct phrases of another version
+	// the matched text must have, so that a sentence or heading is needed
+	// rather than a few words the versions happen to phrase differently.
+	minVersionPhrases = 5
+	// maxVersionPhrases bounds the runs reported as evidence.
+	maxVersionPhrases = 5
+)
+
+// shingles returns the phrases of versionShingle words of the normalized
+// words.
+func shingles(words []string) map[string]bool {
+	out := make(map[string]bool)
+	for i := 0; i+versionShingle <= len(words); i++ {
+		out[strings.Join(words[i:i+versionShingle], " ")] = true
+	}
+	return out
+}
+
+// otherVersion is another version of a license.
+type otherVersion struct {
+	// entries are the corpus entries of the version.
+	entries []string
+	// phrases are the phrases of the entries that are in no entry of the
+	// license, computed once when first needed.
+	once    sync.Once
+	phrases map[string]bool
+}
+
+// indexVersions returns the other versions of each versioned license of the
+// corpus, keyed by license and other license name.
+func (c *Classifier) indexVersions() map[string]map[string]*otherVersion {
+	families := make(map[string]map[string][]string)
+	for _, n := range sortedNames(c.docs) {
+		name := LicenseName(n)
+		v := licenseVersion.FindStringSubmatch(name)
+		if v == nil || detectionType(n) == exceptionType {
+			continue
+		}
+		if families[v[1]] == nil {
+			families[v[1]] = make(map[string][]string)
+		}
+		families[v[1]][name] = append(families[v[1]][name], n)
+	}
+	out := make(map[string]map[string]*otherVersion)
+	for _, versions := range families {
+		for license := range versions {
+			for other, entries := range versions {
+				if other == license {
+					continue
+				}
+				if out[license] == nil {
+					out[license] = make(map[string]*otherVersion)
+				}
+				out[license][other] = &otherVersion{entries: entries}
+			}
+		}
+	}
+	return out
+}
+
+// versionPhrases returns the phrases of the corpus entries of the other
+// version that are in no entry of license.
+func (c *Classifier) versionPhrases(license string, other *otherVersion) map[string]bool {
+	other.once.Do(func() {
+		own := make(map[string]bool)
+		for n, d := range c.docs {
+			if LicenseName(n) == license {
+				for s := range shingles(strings.Fields(d.norm)) {
+					own[s] = true
+				}
+			}
+		}
+		other.phrases = make(map[string]bool)
+		for _, n := range other.entries {
+			for s := range shingles(strings.Fields(c.docs[n].norm)) {
+				if !own[s] {
+					other.phrases[s] = true
+				}
+			}
+		}
+	})
+	return other.phrases
+}
+
+// flagVersions records the conflicts of the matches of versioned licenses
+// found in the document with the other versions of the licenses.
+func (c *Classifier) flagVersions(ms Matches, doc *document) {
+	var id *indexedDocument
+	for _, m := range ms {
+		if m.Variant == "" || m.MatchType == exceptionType {
+			continue
+		}
+		others := c.versions[m.Name]
+		if len(others) == 0 {
+			continue
+		}
+		var words []string
+		for i := m.StartTokenIndex; i <= m.EndTokenIndex && i < len(doc.Tokens); i++ {
+			words = append(words, doc.Tokens[i].Text)
+		}
+		// The version with the most phrases in the text conflicts, the first
+		// by name in case of a tie.
+		var other string
+		var found []bool
+		best := 0
+		names := make([]string, 0, len(others))
+		for o := range others {
+			names = append(names, o)
+		}
+		sort.Strings(names)
+		for _, o := range names {
+			p := c.versionPhrases(m.Name, others[o])
+			in := make([]bool, len(words))
+			seen := make(map[string]bool)
+			for i := 0; i+versionShingle <= len(words); i++ {
+				if s := strings.Join(words[i:i+versionShingle], " "); p[s] {
+					seen[s] = true
+					for j := i; j < i+versionShingle; j++ {
+						in[j] = true
+					}
+				}
+			}
+			if len(seen) >= minVersionPhrases && len(seen) > best {
+				other, found, best = o, in, len(seen)
+			}
+		}
+		if other == "" {
+			continue
+		}
+		if id == nil {
+			id = c.generateIndexedDocument(doc, false)
+		}
+		m.VersionConflict = &VersionConflict{
+			Versions: []Alternative{
+				{Name: m.Name, MatchType: m.MatchType, Confidence: m.Confidence},
+				c.versionAlternative(id, m, other, others[other].entries),
+			},
+			Phrases: runs(words, found, maxVersionPhrases),
+		}
+	}
+}
+
+// versionAlternative returns the best confidence of the text of the match
+// against the corpus entries of another version of its license, or zero if
+// the text is more different from all of them than their length. The diff
+// isn't checked by the rules, which would reject it for the version change.
+func (c *Classifier) versionAlternative(id *indexedDocument, m *Match, other string, entries []string) Alternative {
+	start, end := -1, -1
+	for i, t := range id.Tokens {
+		if int(t.Index) == m.StartTokenIndex {
+			start = i
+		}
+		if int(t.Index) == m.EndTokenIndex {
+			end = i + 1
+		}
+	}
+	alt := Alternative{Name: other, MatchType: detectionType(entries[0])}
+	if start == -1 || end == -1 || start >= end {
+		return alt
+	}
+	for _, n := range entries {
+		known := c.docs[n]
+		diffs := docDiff(n, id, start, end, known, 0, known.size())
+		conf := c.calibrate(n, known, confidencePercentage(known.size(), diffLevenshteinWord(diffs)))
+		if conf > alt.Confidence {
+			alt.MatchType, alt.Confidence = detectionType(n), conf
+		}
+	}
+	return alt
+}
+
+// runs returns up to n of the runs of words marked in the supplied slice.
+func runs(words []string, marked []bool, n int) []string {
+	var out []string
+	for i := 0; i < len(words) && len(out) < n; i++ {
+		if !marked[i] {
+			continue
+		}
+		j := i
+		for j < len(words) && marked[j] {
+			j++
+		}
+		out = append(out, strings.Join(words[i:j], " "))
+		i = j
+	}
+	return out
+}
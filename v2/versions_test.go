@@ -0,0 +1,132 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// mpl2Definition is a definition of the MPL-2.0 that the MPL-1.1 lacks.
+const mpl2Definition = `1.4. "Covered Software" means Source Code Form to which the initial
+Contributor has attached the notice in Exhibit A, the Executable Form of such
+Source Code Form, and Modifications of such Source Code Form, in each case
+including portions thereof.
+
+`
+
+func TestVersionConflicts(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	mpl11, err := ioutil.ReadFile(filepath.Join(baseLicenses, "MPL-1.1.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mpl20, err := ioutil.ReadFile(filepath.Join(baseLicenses, "MPL-2.0.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mixed := strings.Replace(string(mpl11), `1.5. "Executable"`, mpl2Definition+`1.5. "Executable"`, 1)
+
+	tests := []struct {
+		description string
+		in          string
+		name        string
+		conflict    []string
+	}{
+		{
+			description: "MPL-1.1",
+			in:          string(mpl11),
+			name:        "MPL-1.1",
+		},
+		{
+			description: "MPL-2.0",
+			in:          string(mpl20),
+			name:        "MPL-2.0",
+		},
+		{
+			description: "MPL-1.1 with a definition of the MPL-2.0",
+			in:          mixed,
+			name:        "MPL-1.1",
+			conflict:    []string{"MPL-1.1", "MPL-2.0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			var m *Match
+			for _, o := range c.Match([]byte(tt.in)) {
+				if o.Name == tt.name {
+					m = o
+				}
+			}
+			if m == nil {
+				t.Fatalf("no match of %s", tt.name)
+			}
+			var got []string
+			if v := m.VersionConflict; v != nil {
+				for _, a := range v.Versions {
+					got = append(got, a.Name)
+				}
+			}
+			if diff := cmp.Diff(tt.conflict, got); diff != "" {
+				t.Errorf("conflicting versions mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestVersionConflictEvidence(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	mpl11, err := ioutil.ReadFile(filepath.Join(baseLicenses, "MPL-1.1.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mixed := strings.Replace(string(mpl11), `1.5. "Executable"`, mpl2Definition+`1.5. "Executable"`, 1)
+	var v *VersionConflict
+	for _, m := range c.Match([]byte(mixed)) {
+		if m.Name == "MPL-1.1" {
+			v = m.VersionConflict
+		}
+	}
+	if v == nil {
+		t.Fatal("no version conflict of MPL-1.1")
+	}
+	if want := "version conflict: the text matches MPL-1.1 but has language of MPL-2.0"; v.Reason() != want {
+		t.Errorf("Reason() = %q, want %q", v.Reason(), want)
+	}
+	if len(v.Phrases) == 0 || !strings.Contains(v.Phrases[0], "source code form to which the initial contributor") {
+		t.Errorf("Phrases = %q, want the definition of the MPL-2.0", v.Phrases)
+	}
+	if mpl, other := v.Versions[0].Confidence, v.Versions[1].Confidence; other >= mpl {
+		t.Errorf("confidence of MPL-2.0 = %v, want it below that of MPL-1.1 (%v)", other, mpl)
+	}
+}
+
+func TestRuns(t *testing.T) {
+	words := strings.Fields("a b c d e f g")
+	marked := []bool{false, true, true, false, true, false, true}
+	if diff := cmp.Diff([]string{"b c", "e"}, runs(words, marked, 2)); diff != "" {
+		t.Errorf("runs() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"strings"
+	"time"
+)
+
+// bytesPerToken estimates the memory used for each token of a window while it
+// is matched: its frequency table, normalized forms and searchset, and the
+// diffs of the regions found in it.
+const bytesPerToken = 1024
+
+// SetMaxTokens bounds the memory used to match large documents. Documents
+// with more than n tokens are matched in overlapping windows of n tokens
+// rather than as a whole, and the matches found in the windows are combined.
+// The windows overlap by the length of the longest corpus entry, up to half
+// their size, so that a license crossing the end of a window is found whole
+// in the next; n should therefore be well above the length of the longest
+// license, a few thousand tokens for the default corpus, or long licenses are
+// only found in parts. Zero, the default, matches documents whole.
+func (c *Classifier) SetMaxTokens(n int) {
+	defer c.update()()
+	c.maxTokens = n
+}
+
+// maxTokensForMemory returns the number of tokens of the windows matched
+// within the supplied amount of memory.
+func maxTokensForMemory(bytes int64) int {
+	n := bytes / bytesPerToken
+	if n < 1 {
+		return 1
+	}
+	return int(n)
+}
+
+// matchWindows matches a document with more tokens than the limit of the
+// classifier in overlapping windows. The document is only tokenized once, and
+// its tokens keep their lines and indices, so the matches found in each
+// window are positioned within the whole document.
+func (c *Classifier) matchWindows(in []byte, doc *document, dm *DocumentMetrics, deadline time.Time) Matches {
+	// Only the compact indexed form of the tokens is kept while the windows
+	// are matched.
+	tokens := make([]indexedToken, len(doc.Tokens))
+	for i, t := range doc.Tokens {
+		tokens[i] = indexedToken{ID: c.dict.getIndex(t.Text), Index: uint32(t.Index), Line: uint32(t.Line)}
+	}
+	refs := withGrants(findReferences(in, &indexedDocument{Tokens: tokens}, c.licenses), doc)
+	doc = nil
+
+	overlap := 0
+	for _, d := range c.docs {
+		overlap = max(overlap, d.size())
+	}
+	if overlap > c.maxTokens/2 {
+		overlap = c.maxTokens / 2
+	}
+	// The lines compared for exempt phrases are shared by the windows rather
+	// than computed for each.
+	exemptLines := strings.Split(exemptionText(in), "\n")
+
+	var candidates Matches
+	found := false
+	for start := 0; ; start += c.maxTokens - overlap {
+		end := start + c.maxTokens
+		if end > len(tokens) {
+			end = len(tokens)
+		}
+		w := &indexedDocument{Tokens: tokens[start:end], dict: c.dict, content: in, exemptLines: exemptLines, metrics: dm, deadline: deadline}
+		w.generateDerived()
+		if firstPass := c.firstPass(w); len(firstPass) > 0 {
+			found = true
+			candidates = append(candidates, c.candidates(w, firstPass)...)
+			w.s.release()
+		}
+		if end == len(tokens) {
+			break
+		}
+	}
+	if !found {
+		return refs
+	}
+	return resolve(dedupCandidates(candidates), refs, c.topK, c.overlap)
+}
+
+// dedupCandidates removes the candidates found again in the overlap of
+// consecutive windows, keeping the first of each.
+func dedupCandidates(candidates Matches) Matches {
+	type key struct {
+		variant    string
+		start, end int
+	}
+	seen := make(map[key]bool)
+	var out Matches
+	for _, m := range candidates {
+		k := key{m.Variant, m.StartTokenIndex, m.EndTokenIndex}
+		if !seen[k] {
+			seen[k] = true
+			out = append(out, m)
+		}
+	}
+	return out
+}
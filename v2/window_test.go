@@ -0,0 +1,77 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package classifier
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestMatchWindows(t *testing.T) {
+	c, err := classifier()
+	if err != nil {
+		t.Fatalf("classifier() failed: %v", err)
+	}
+	var in bytes.Buffer
+	files := []string{"MIT.txt", "Apache-2.0.txt", "BSD-3-Clause.txt", "ISC.txt", "GPL-2.0.txt", "Zlib.txt", "MPL-2.0.txt"}
+	for _, f := range append(files, files...) {
+		b, err := ioutil.ReadFile(filepath.Join(baseLicenses, f))
+		if err != nil {
+			t.Fatal(err)
+		}
+		in.Write(b)
+		in.WriteString("\nSome unrelated text in between.\n")
+	}
+	want := c.Match(in.Bytes())
+	if len(want) < 2*len(files) {
+		t.Fatalf("Match() = %d matches, want at least one per license", len(want))
+	}
+
+	c.SetMaxTokens(8000)
+	if n := len(tokenize(in.Bytes()).Tokens); n <= 8000 {
+		t.Fatalf("test input has %d tokens, want more than the window", n)
+	}
+	got := c.Match(in.Bytes())
+	if diff := cmp.Diff(want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("Match() in windows mismatch (-whole +windows):\n%s", diff)
+	}
+}
+
+func TestWithMaxMemory(t *testing.T) {
+	c, err := New(WithMaxMemory(8 << 20))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if want := (8 << 20) / bytesPerToken; c.maxTokens != want {
+		t.Errorf("maxTokens = %d, want %d", c.maxTokens, want)
+	}
+	if c, _ := New(WithMaxMemory(1)); c.maxTokens != 1 {
+		t.Errorf("maxTokens = %d for a tiny budget, want 1", c.maxTokens)
+	}
+}
+
+func TestDedupCandidates(t *testing.T) {
+	a := &Match{Variant: "MIT", StartTokenIndex: 10, EndTokenIndex: 20, Confidence: 1}
+	b := &Match{Variant: "MIT", StartTokenIndex: 10, EndTokenIndex: 20, Confidence: 1}
+	c := &Match{Variant: "MIT", StartTokenIndex: 10, EndTokenIndex: 15, Confidence: 0.9}
+	if got, want := dedupCandidates(Matches{a, c, b}), (Matches{a, c}); !cmp.Equal(got, want) {
+		t.Errorf("dedupCandidates() = %v, want %v", got, want)
+	}
+}